@@ -1,15 +1,19 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
 
-	"github.com/gonum/matrix/mat64"
 	"github.com/milosgajdos83/go-neural/neural"
 	"github.com/milosgajdos83/go-neural/pkg/config"
 	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"gonum.org/v1/gonum/mat"
 )
 
 var (
@@ -21,6 +25,15 @@ var (
 	scale bool
 	// manifest contains neural net config
 	manifest string
+	// blasImpl is the name of BLAS implementation used by matrix operations
+	blasImpl string
+	// device is the name of device which multiplies network matrices
+	device string
+	// cpuProfile and memProfile are paths of CPU and allocations profiles of the training
+	cpuProfile string
+	memProfile string
+	// overrides contains manifest parameters overridden on the command line
+	overrides = make(config.Overrides)
 )
 
 func init() {
@@ -28,6 +41,11 @@ func init() {
 	flag.BoolVar(&labeled, "labeled", false, "Is the data set labeled")
 	flag.BoolVar(&scale, "scale", false, "Require data scaling")
 	flag.StringVar(&manifest, "manifest", "", "Path to a neural net manifest file")
+	flag.StringVar(&cpuProfile, "cpuprofile", "", "Path to CPU profile of the training")
+	flag.StringVar(&memProfile, "memprofile", "", "Path to allocations profile of the training")
+	flag.StringVar(&device, "device", "cpu", fmt.Sprintf("Device: %s", strings.Join(matrix.Devices(), ", ")))
+	flag.StringVar(&blasImpl, "blas", matrix.BLAS(), fmt.Sprintf("BLAS implementation: %s", strings.Join(matrix.BLASBackends(), ", ")))
+	overrides.Flags(flag.CommandLine)
 }
 
 func parseCliFlags() error {
@@ -41,7 +59,7 @@ func parseCliFlags() error {
 	if manifest == "" {
 		return errors.New("You must specify path to manifest file")
 	}
-	return nil
+	return matrix.UseBLAS(blasImpl)
 }
 
 func main() {
@@ -51,11 +69,25 @@ func main() {
 		os.Exit(1)
 	}
 	// Read in configuration file
-	config, err := config.New(manifest)
+	m, err := config.ReadManifest(manifest)
 	if err != nil {
 		fmt.Printf("Error reading manifest file: %s\n", err)
 		os.Exit(1)
 	}
+	// environment variables override the manifest and cli flags override both
+	if err := config.EnvOverrides().Apply(m); err != nil {
+		fmt.Printf("Error overriding manifest: %s\n", err)
+		os.Exit(1)
+	}
+	if err := overrides.Apply(m); err != nil {
+		fmt.Printf("Error overriding manifest: %s\n", err)
+		os.Exit(1)
+	}
+	config, err := config.ParseManifest(m)
+	if err != nil {
+		fmt.Printf("Error parsing manifest file: %s\n", err)
+		os.Exit(1)
+	}
 	// load new data set from provided file
 	ds, err := dataset.NewDataSet(data, labeled)
 	if err != nil {
@@ -75,31 +107,48 @@ func main() {
 		os.Exit(1)
 	}
 	// Create new FEEDFWD network
-	net, err := neural.NewNetwork(config.Network)
+	net, err := neural.NewNetworkFromConfig(config)
 	if err != nil {
 		fmt.Printf("Error creating neural network: %s\n", err)
 		os.Exit(1)
 	}
-	// Run neural network training
-	err = net.Train(config.Training, features.(*mat64.Dense), labels.(*mat64.Vector))
+	d, err := matrix.LookupDevice(device)
 	if err != nil {
+		fmt.Printf("Error selecting device: %s\n", err)
+		os.Exit(1)
+	}
+	net.SetDevice(d)
+	// interrupt stops the training and keeps the best weights found so far
+	ctx, cancel := context.WithCancel(context.Background())
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+	go func() {
+		<-sigChan
+		cancel()
+	}()
+	// Run neural network training
+	err = neural.Profile(cpuProfile, memProfile, func() error {
+		return net.TrainContext(ctx, config.Training, features.(*mat.Dense), labels.(*mat.VecDense))
+	})
+	signal.Stop(sigChan)
+	if err != nil && err != context.Canceled {
 		fmt.Printf("Error training network: %s\n", err)
 		os.Exit(1)
 	}
 	// check the success rate i.e. successful number of classifications
-	success, err := net.Validate(features.(*mat64.Dense), labels.(*mat64.Vector))
+	success, err := net.Validate(features.(*mat.Dense), labels.(*mat.VecDense))
 	if err != nil {
 		fmt.Printf("Could not calculate success rate: %s\n", err)
 		os.Exit(1)
 	}
 	fmt.Printf("\nNeural net accuracy: %f\n", success)
 	// Example of sample classification: in this case it's 1st data sample
-	sample := (features.(*mat64.Dense)).RowView(0).T()
+	sample := (features.(*mat.Dense)).RowView(0).T()
 	classMx, err := net.Classify(sample)
 	if err != nil {
 		fmt.Printf("Could not classify sample: %s\n", err)
 		os.Exit(1)
 	}
-	fa := mat64.Formatted(classMx.T(), mat64.Prefix(""))
+	fa := mat.Formatted(classMx.T(), mat.Prefix(""))
 	fmt.Printf("\nClassification result:\n% v\n\n", fa)
 }
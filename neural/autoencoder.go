@@ -0,0 +1,296 @@
+package neural
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Autoencoder is a neural network which learns to reconstruct its input through a smaller code
+// layer. Autoencoder consists of an encoder HIDDEN layer which maps the input to the code and
+// a decoder OUTPUT layer which maps the code back to the input. Both layers use sigmoid activation
+// function, so the input data are expected to be scaled to [0, 1] interval.
+type Autoencoder struct {
+	// encoder maps input to code
+	encoder *Layer
+	// decoder maps code to reconstructed input
+	decoder *Layer
+	// tied is true if decoder weights are the transposed encoder weights
+	tied bool
+}
+
+// NewAutoencoder creates new autoencoder with inputDim inputs and codeDim code neurons and returns it.
+// If tied is true, decoder weights are the transposed encoder weights and only decoder bias weights
+// are trained separately. It fails with error if any of the dimensions is not a positive integer.
+func NewAutoencoder(inputDim, codeDim int, tied bool) (*Autoencoder, error) {
+	encoder, err := NewLayer(&config.LayerConfig{
+		Kind:   "hidden",
+		Size:   codeDim,
+		NeurFn: &config.NeuronConfig{Activation: Sigmoid},
+	}, inputDim)
+	if err != nil {
+		return nil, err
+	}
+	decoder, err := NewLayer(&config.LayerConfig{
+		Kind:   "output",
+		Size:   inputDim,
+		NeurFn: &config.NeuronConfig{Activation: Sigmoid},
+	}, codeDim)
+	if err != nil {
+		return nil, err
+	}
+	a := &Autoencoder{
+		encoder: encoder,
+		decoder: decoder,
+		tied:    tied,
+	}
+	a.tie()
+	return a, nil
+}
+
+// Encoder returns autoencoder encoder layer
+func (a Autoencoder) Encoder() *Layer {
+	return a.encoder
+}
+
+// Decoder returns autoencoder decoder layer
+func (a Autoencoder) Decoder() *Layer {
+	return a.decoder
+}
+
+// Tied returns true if decoder weights are tied to encoder weights
+func (a Autoencoder) Tied() bool {
+	return a.tied
+}
+
+// Encode returns the code i.e. the bottleneck representation of the supplied input.
+// It fails with error if the input dimensions don't match the autoencoder input.
+func (a *Autoencoder) Encode(inMx mat.Matrix) (mat.Matrix, error) {
+	return a.encoder.FwdOut(inMx)
+}
+
+// Decode returns the input reconstructed from the supplied code.
+// It fails with error if the code dimensions don't match the autoencoder code.
+func (a *Autoencoder) Decode(codeMx mat.Matrix) (mat.Matrix, error) {
+	return a.decoder.FwdOut(codeMx)
+}
+
+// Reconstruct encodes the supplied input and returns its reconstruction.
+// It fails with error if the input dimensions don't match the autoencoder input.
+func (a *Autoencoder) Reconstruct(inMx mat.Matrix) (mat.Matrix, error) {
+	codeMx, err := a.Encode(inMx)
+	if err != nil {
+		return nil, err
+	}
+	return a.Decode(codeMx)
+}
+
+// Train trains autoencoder to reconstruct the supplied input per configuration passed in as parameter.
+// Autoencoder minimizes cross entropy between its input and its reconstruction, so the cost function
+// in training configuration is ignored. It returns error if either the training configuration is invalid
+// or the training fails.
+func (a *Autoencoder) Train(c *config.TrainConfig, inMx *mat.Dense) error {
+	// validate the supplied configuration
+	if err := ValidateTrainConfig(c); err != nil {
+		return err
+	}
+	// input matrix can't be nil
+	if inMx == nil {
+		return fmt.Errorf("Incorrect input supplied: %v\n", inMx)
+	}
+	return a.train(c, inMx, inMx)
+}
+
+// Corruption defines how denoising autoencoder corrupts its input
+type Corruption uint
+
+const (
+	// MaskingNoise sets randomly chosen inputs to zero. Corruption level is the probability
+	// of an input to be set to zero.
+	MaskingNoise Corruption = iota
+	// GaussianNoise adds Gaussian noise to all inputs. Corruption level is the standard
+	// deviation of the noise.
+	GaussianNoise
+)
+
+// String implements Stringer interface for pretty printing
+func (c Corruption) String() string {
+	switch c {
+	case MaskingNoise:
+		return "masking"
+	case GaussianNoise:
+		return "gaussian"
+	}
+	return "unknown"
+}
+
+// DenoiseConfig allows to specify denoising autoencoder training
+type DenoiseConfig struct {
+	// Corruption is input corruption method
+	Corruption Corruption
+	// Levels contains corruption level for each training epoch. Autoencoder is trained for
+	// as many epochs as there are levels.
+	Levels []float64
+}
+
+// TrainDenoising trains autoencoder to reconstruct the clean input from its corrupted copy. Training
+// runs in epochs: at the beginning of each epoch a new corrupted copy of the input is created using
+// the corruption level for the epoch and the autoencoder weights are then optimized per configuration
+// passed in as parameter. It returns error if either of the configurations is invalid or if the
+// training fails.
+func (a *Autoencoder) TrainDenoising(c *config.TrainConfig, inMx *mat.Dense, d *DenoiseConfig) error {
+	// validate the supplied configuration
+	if err := ValidateTrainConfig(c); err != nil {
+		return err
+	}
+	if d == nil || len(d.Levels) == 0 {
+		return fmt.Errorf("Incorrect denoising configuration supplied: %v\n", d)
+	}
+	var corrupt func(float64) func(int, int, float64) float64
+	switch d.Corruption {
+	case MaskingNoise:
+		corrupt = matrix.MaskMx
+	case GaussianNoise:
+		corrupt = matrix.NoiseMx
+	default:
+		return fmt.Errorf("Unsupported corruption: %s\n", d.Corruption)
+	}
+	for _, level := range d.Levels {
+		if level < 0 || (d.Corruption == MaskingNoise && level > 1) {
+			return fmt.Errorf("Incorrect %s corruption level: %f\n", d.Corruption, level)
+		}
+	}
+	// input matrix can't be nil
+	if inMx == nil {
+		return fmt.Errorf("Incorrect input supplied: %v\n", inMx)
+	}
+	for _, level := range d.Levels {
+		corruptMx := new(mat.Dense)
+		corruptMx.Apply(corrupt(level), inMx)
+		if err := a.train(c, corruptMx, inMx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// train optimizes autoencoder weights to reconstruct the target matrix from the input matrix
+func (a *Autoencoder) train(c *config.TrainConfig, inMx, targetMx *mat.Dense) error {
+	if _, cols := inMx.Dims(); cols != a.encoder.InSize() {
+		return fmt.Errorf("Dimension mismatch. Autoencoder: %d, Input: %d\n", a.encoder.InSize(), cols)
+	}
+	costFunc := func(x []float64) (float64, error) {
+		if err := a.setParams(x); err != nil {
+			return -1.0, err
+		}
+		return a.cost(inMx, targetMx, trainPenalty(c))
+	}
+	gradFunc := func(x []float64) ([]float64, error) {
+		if err := a.setParams(x); err != nil {
+			return nil, err
+		}
+		return a.gradient(inMx, targetMx, trainPenalty(c))
+	}
+	return optimizeParams(context.Background(), c, a.params(), a.setParams, costFunc, gradFunc)
+}
+
+// tie sets decoder weights to the transposed encoder weights if the weights are tied
+func (a *Autoencoder) tie() {
+	if !a.tied {
+		return
+	}
+	r, c := a.encoder.Weights().Dims()
+	weights := a.decoder.Weights().Slice(0, c-1, 1, r+1).(*mat.Dense)
+	weights.Copy(a.encoder.maskedWeights().Slice(0, r, 1, c).T())
+}
+
+// params returns autoencoder weights unrolled into a single slice: encoder weights followed
+// by decoder weights or only by decoder bias weights if the weights are tied
+func (a *Autoencoder) params() []float64 {
+	params := netWeights([]*Layer{a.encoder})
+	if a.tied {
+		rows, _ := a.decoder.Weights().Dims()
+		return append(params, mat.Col(make([]float64, rows), 0, a.decoder.Weights())...)
+	}
+	return append(params, netWeights([]*Layer{a.decoder})...)
+}
+
+// setParams sets autoencoder weights to the values supplied via params slice
+// ordered the same way as the slice returned by params
+func (a *Autoencoder) setParams(params []float64) error {
+	r, c := a.encoder.Weights().Dims()
+	if len(params) < r*c {
+		return fmt.Errorf("Insufficient number of weights supplied %d\n", len(params))
+	}
+	if err := setNetWeights([]*Layer{a.encoder}, params[:r*c]); err != nil {
+		return err
+	}
+	if !a.tied {
+		return setNetWeights([]*Layer{a.decoder}, params[r*c:])
+	}
+	rows, _ := a.decoder.Weights().Dims()
+	if len(params)-r*c != rows {
+		return fmt.Errorf("Incorrect number of weights supplied %d\n", len(params))
+	}
+	a.decoder.Weights().SetCol(0, params[r*c:])
+	a.tie()
+	return nil
+}
+
+// regLayers returns autoencoder layers whose weights are regularized
+func (a *Autoencoder) regLayers() []*Layer {
+	if a.tied {
+		return []*Layer{a.encoder}
+	}
+	return []*Layer{a.encoder, a.decoder}
+}
+
+// cost calculates cross entropy between the reconstruction of the input and the target
+// matrix plus regularization of the autoencoder weights
+func (a *Autoencoder) cost(inMx, targetMx *mat.Dense, p penalty) (float64, error) {
+	outMx, err := a.Reconstruct(inMx)
+	if err != nil {
+		return -1.0, err
+	}
+	// cross entropy cost modifies its input matrices
+	tMx := new(mat.Dense)
+	tMx.CloneFrom(targetMx)
+	samples, _ := inMx.Dims()
+	cost := CrossEntropy{}.CostFunc(inMx, outMx, tMx)
+	return cost + regCost(a.regLayers(), p, samples), nil
+}
+
+// gradient calculates the gradient of the reconstruction cost with respect to autoencoder
+// weights. It returns the gradient ordered the same way as the slice returned by params.
+func (a *Autoencoder) gradient(inMx, targetMx *mat.Dense, p penalty) ([]float64, error) {
+	codeMx, codeActIn, err := a.encoder.fwdOut(inMx)
+	if err != nil {
+		return nil, err
+	}
+	outMx, _, err := a.decoder.fwdOut(codeMx)
+	if err != nil {
+		return nil, err
+	}
+	// sigmoid output with cross entropy cost
+	outErr := CrossEntropy{}.Delta(outMx, targetMx)
+	a.decoder.deltas = a.decoder.deltasUpdate(outErr, codeMx)
+	codeErr := a.encoder.actInErr(a.decoder.inErr(outErr), codeActIn)
+	a.encoder.deltas = a.encoder.deltasUpdate(codeErr, inMx)
+	samples, _ := inMx.Dims()
+	if !a.tied {
+		return layersGradient(a.regLayers(), p, samples), nil
+	}
+	// tied decoder weights contribute to the encoder gradient
+	r, c := a.encoder.deltas.Dims()
+	encDeltas := a.encoder.deltas.Slice(0, r, 1, c).(*mat.Dense)
+	encDeltas.Add(encDeltas, a.decoder.deltas.Slice(0, c-1, 1, r+1).T())
+	gradient := layersGradient(a.regLayers(), p, samples)
+	biasGrad := mat.Col(make([]float64, c-1), 0, a.decoder.deltas)
+	for i := range biasGrad {
+		biasGrad[i] /= float64(samples)
+	}
+	return append(gradient, biasGrad...), nil
+}
@@ -0,0 +1,153 @@
+package neural
+
+import (
+	"math"
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// aeInMx is autoencoder test input scaled to [0, 1] interval
+var aeInMx = mat.NewDense(4, 5, []float64{
+	0.1, 0.9, 0.2, 0.8, 0.3,
+	0.7, 0.2, 0.6, 0.1, 0.9,
+	0.5, 0.5, 0.4, 0.6, 0.2,
+	0.9, 0.1, 0.8, 0.3, 0.7,
+})
+
+func TestNewAutoencoder(t *testing.T) {
+	assert := assert.New(t)
+
+	// incorrect dimensions
+	a, err := NewAutoencoder(0, 2, false)
+	assert.Nil(a)
+	assert.Error(err)
+	a, err = NewAutoencoder(5, 0, false)
+	assert.Nil(a)
+	assert.Error(err)
+	a, err = NewAutoencoder(5, 2, true)
+	assert.NotNil(a)
+	assert.NoError(err)
+	assert.True(a.Tied())
+	assert.Equal(a.Encoder().Kind(), HIDDEN)
+	assert.Equal(a.Decoder().Kind(), OUTPUT)
+	// tied decoder weights are transposed encoder weights
+	ew, dw := a.Encoder().Weights(), a.Decoder().Weights()
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 5; j++ {
+			assert.Equal(ew.At(i, j+1), dw.At(j, i+1))
+		}
+	}
+	// encode and reconstruct
+	codeMx, err := a.Encode(aeInMx)
+	assert.NoError(err)
+	rows, cols := codeMx.Dims()
+	assert.Equal(rows, 4)
+	assert.Equal(cols, 2)
+	outMx, err := a.Reconstruct(aeInMx)
+	assert.NoError(err)
+	rows, cols = outMx.Dims()
+	assert.Equal(rows, 4)
+	assert.Equal(cols, 5)
+	outMx, err = a.Reconstruct(inMx)
+	assert.Nil(outMx)
+	assert.Error(err)
+}
+
+func TestAutoencoderGradient(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, tied := range []bool{false, true} {
+		a, err := NewAutoencoder(5, 3, tied)
+		assert.NoError(err)
+		params := a.params()
+		// 3 x 6 encoder weights and 5 decoder bias weights or 5 x 4 decoder weights
+		if tied {
+			assert.Len(params, 23)
+		} else {
+			assert.Len(params, 38)
+		}
+		assert.NoError(a.setParams(params))
+		grad, err := a.gradient(aeInMx, aeInMx, penalty{l1: 0.1, l2: 0.5})
+		assert.NoError(err)
+		assert.Len(grad, len(params))
+		// gradient matches its numerical approximation
+		eps := 1e-5
+		for i := range params {
+			p := params[i]
+			params[i] = p + eps
+			assert.NoError(a.setParams(params))
+			costPlus, err := a.cost(aeInMx, aeInMx, penalty{l1: 0.1, l2: 0.5})
+			assert.NoError(err)
+			params[i] = p - eps
+			assert.NoError(a.setParams(params))
+			costMinus, err := a.cost(aeInMx, aeInMx, penalty{l1: 0.1, l2: 0.5})
+			assert.NoError(err)
+			params[i] = p
+			assert.True(math.Abs(grad[i]-(costPlus-costMinus)/(2*eps)) < 1e-6)
+		}
+	}
+}
+
+func TestAutoencoderTrain(t *testing.T) {
+	assert := assert.New(t)
+
+	a, err := NewAutoencoder(5, 3, true)
+	assert.NoError(err)
+	c := &config.TrainConfig{
+		Kind:   "backprop",
+		Cost:   "xentropy",
+		Lambda: 0.0,
+		Optimize: &config.OptimConfig{
+			Method:     "bfgs",
+			Iterations: 20,
+		},
+	}
+	assert.Error(a.Train(nil, aeInMx))
+	assert.Error(a.Train(c, nil))
+	assert.Error(a.Train(c, inMx))
+	before, err := a.cost(aeInMx, aeInMx, penalty{})
+	assert.NoError(err)
+	assert.NoError(a.Train(c, aeInMx))
+	after, err := a.cost(aeInMx, aeInMx, penalty{})
+	assert.NoError(err)
+	assert.True(after < before)
+}
+
+func TestAutoencoderTrainDenoising(t *testing.T) {
+	assert := assert.New(t)
+
+	a, err := NewAutoencoder(5, 3, false)
+	assert.NoError(err)
+	c := &config.TrainConfig{
+		Kind:   "backprop",
+		Cost:   "xentropy",
+		Lambda: 0.0,
+		Optimize: &config.OptimConfig{
+			Method:     "bfgs",
+			Iterations: 5,
+		},
+	}
+	d := &DenoiseConfig{
+		Corruption: MaskingNoise,
+		Levels:     []float64{0.3, 0.2, 0.1},
+	}
+	// incorrect configuration
+	assert.Error(a.TrainDenoising(nil, aeInMx, d))
+	assert.Error(a.TrainDenoising(c, aeInMx, nil))
+	assert.Error(a.TrainDenoising(c, aeInMx, &DenoiseConfig{Corruption: Corruption(10), Levels: d.Levels}))
+	assert.Error(a.TrainDenoising(c, aeInMx, &DenoiseConfig{Corruption: MaskingNoise, Levels: []float64{1.5}}))
+	assert.Error(a.TrainDenoising(c, nil, d))
+	assert.Error(a.TrainDenoising(c, inMx, d))
+	assert.Equal(GaussianNoise.String(), "gaussian")
+	before, err := a.cost(aeInMx, aeInMx, penalty{})
+	assert.NoError(err)
+	assert.NoError(a.TrainDenoising(c, aeInMx, d))
+	d.Corruption = GaussianNoise
+	assert.NoError(a.TrainDenoising(c, aeInMx, d))
+	after, err := a.cost(aeInMx, aeInMx, penalty{})
+	assert.NoError(err)
+	assert.True(after < before)
+}
@@ -0,0 +1,156 @@
+package neural
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"gonum.org/v1/gonum/mat"
+)
+
+// benchSizes contains layer sizes used in benchmarks
+var benchSizes = []int{16, 128, 512}
+
+// benchBatches contains batch sizes used in benchmarks
+var benchBatches = []int{1, 32, 256}
+
+// benchMx returns random rows x cols matrix used in benchmarks
+func benchMx(b *testing.B, rows, cols int) *mat.Dense {
+	mx, err := matrix.MakeRandMx(rows, cols, -1.0, 1.0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return mx
+}
+
+func BenchmarkLayerOut(b *testing.B) {
+	for _, size := range benchSizes {
+		layer, err := NewLayer(&config.LayerConfig{
+			Kind:   "hidden",
+			Size:   size,
+			NeurFn: &config.NeuronConfig{Activation: Sigmoid},
+		}, size)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, batch := range benchBatches {
+			inMx := benchMx(b, batch, size)
+			b.Run(fmt.Sprintf("size=%d/batch=%d", size, batch), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := layer.FwdOut(inMx); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkBackprop(b *testing.B) {
+	for _, size := range benchSizes {
+		n, err := NewFeedForward(size, []int{size}, 10)
+		if err != nil {
+			b.Fatal(err)
+		}
+		last := len(n.Layers()) - 1
+		for _, batch := range benchBatches {
+			inMx, errMx := benchMx(b, batch, size), benchMx(b, batch, 10)
+			b.Run(fmt.Sprintf("size=%d/batch=%d", size, batch), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if err := n.BackProp(inMx, errMx, last); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkTrainEpoch(b *testing.B) {
+	const samples = 512
+	for _, size := range benchSizes {
+		inMx := benchMx(b, samples, size)
+		labelsVec := mat.NewVecDense(samples, nil)
+		for i := 0; i < samples; i++ {
+			labelsVec.SetVec(i, float64(i%10+1))
+		}
+		for _, batch := range benchBatches[1:] {
+			c := newTrainerConfig()
+			c.Epochs, c.BatchSize = 1, batch
+			tr, err := NewTrainer(c)
+			if err != nil {
+				b.Fatal(err)
+			}
+			n, err := NewFeedForward(size, []int{size}, 10)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.Run(fmt.Sprintf("size=%d/batch=%d", size, batch), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if err := tr.Train(n, inMx, labelsVec); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkWeightsLayout compares the layer activation input computed from the transposed view
+// of row-major weights, which is how layers store them, with pre-transposed weights
+func BenchmarkWeightsLayout(b *testing.B) {
+	for _, size := range benchSizes {
+		weights := benchMx(b, size, size+1)
+		weightsT := mat.DenseCopyOf(weights.T())
+		for _, batch := range benchBatches {
+			biasInMx := matrix.AddBias(benchMx(b, batch, size))
+			layouts := []struct {
+				name    string
+				weights mat.Matrix
+			}{
+				{"view", weights.T()},
+				{"transposed", weightsT},
+			}
+			for _, layout := range layouts {
+				b.Run(fmt.Sprintf("%s/size=%d/batch=%d", layout.name, size, batch), func(b *testing.B) {
+					b.ReportAllocs()
+					actInMx := mat.NewDense(batch, size, nil)
+					for i := 0; i < b.N; i++ {
+						actInMx.Mul(biasInMx, layout.weights)
+					}
+				})
+			}
+		}
+	}
+}
+
+// BenchmarkPrecision compares network forward propagation in float64 and float32 precision
+func BenchmarkPrecision(b *testing.B) {
+	for _, size := range benchSizes {
+		n, err := NewFeedForward(size, []int{size}, 10)
+		if err != nil {
+			b.Fatal(err)
+		}
+		last := len(n.Layers()) - 1
+		for _, p := range []Precision{Float64, Float32} {
+			if err := n.SetPrecision(p); err != nil {
+				b.Fatal(err)
+			}
+			for _, batch := range benchBatches {
+				inMx := benchMx(b, batch, size)
+				b.Run(fmt.Sprintf("%s/size=%d/batch=%d", p, size, batch), func(b *testing.B) {
+					b.ReportAllocs()
+					for i := 0; i < b.N; i++ {
+						if _, err := n.ForwardProp(inMx, last); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			}
+		}
+	}
+}
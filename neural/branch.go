@@ -0,0 +1,193 @@
+package neural
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Branch is an input branch of a multi-input neural network.
+// Branch consists of an INPUT layer optionally followed by HIDDEN layers. Network with input
+// branches expects its input matrix to contain the inputs of all branches stacked next to each
+// other in the order in which the branches were added. Outputs of all branches are concatenated
+// in the same order and passed to the network INPUT layer.
+type Branch struct {
+	// name is branch name
+	name string
+	// layers are branch layers: the first layer is an INPUT layer
+	layers []*Layer
+}
+
+// branchProp holds branch layers inputs and activation inputs needed by backpropagation
+type branchProp struct {
+	ins    []mat.Matrix
+	actIns []*mat.Dense
+}
+
+// NewBranch creates new network input branch and returns it. It fails with error if the name
+// is empty or if the layers are not an INPUT layer followed by HIDDEN layers.
+func NewBranch(name string, layers ...*Layer) (*Branch, error) {
+	if name == "" {
+		return nil, fmt.Errorf("Branch name can not be empty\n")
+	}
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("Branch must contain at least %s layer\n", INPUT)
+	}
+	for i, layer := range layers {
+		expKind := HIDDEN
+		if i == 0 {
+			expKind = INPUT
+		}
+		if layer == nil || layer.Kind() != expKind {
+			return nil, fmt.Errorf("Branch layer %d must be %s layer\n", i, expKind)
+		}
+	}
+	// make sure the branch layers are compatible
+	resized, err := resizeLayers(layers)
+	if err != nil {
+		return nil, err
+	}
+	commitLayers(layers, resized)
+	return &Branch{
+		name:   name,
+		layers: layers,
+	}, nil
+}
+
+// Name returns branch name
+func (b Branch) Name() string {
+	return b.name
+}
+
+// Layers returns branch layers sorted from the INPUT layer to the last HIDDEN layer
+func (b Branch) Layers() []*Layer {
+	return b.layers
+}
+
+// InSize returns the number of branch inputs
+func (b Branch) InSize() int {
+	return b.layers[0].InSize()
+}
+
+// OutSize returns the number of branch outputs
+func (b Branch) OutSize() int {
+	return b.layers[len(b.layers)-1].OutSize()
+}
+
+// Clone returns a deep copy of the branch
+func (b *Branch) Clone() *Branch {
+	branch := &Branch{
+		name:   b.name,
+		layers: make([]*Layer, len(b.layers)),
+	}
+	for i, layer := range b.layers {
+		branch.layers[i] = layer.Clone()
+	}
+	return branch
+}
+
+// AddBranch adds a new input branch to the network. The network INPUT layer is resized to accept
+// the concatenated output of all the branches and the weights of the following layer are reinitialized
+// if its number of inputs changes. AddBranch fails with error if the network has no INPUT layer,
+// if a branch with the same name already exists or if the network layers can't accept the branches output.
+func (n *Network) AddBranch(b *Branch) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if b == nil {
+		return fmt.Errorf("Incorrect branch supplied: %v\n", b)
+	}
+	if len(n.layers) == 0 || n.layers[0].Kind() != INPUT {
+		return fmt.Errorf("Network must have %s layer to add branches\n", INPUT)
+	}
+	size := b.OutSize()
+	for _, branch := range n.branches {
+		if branch.Name() == b.Name() {
+			return fmt.Errorf("Duplicate branch: %s\n", b.Name())
+		}
+		size += branch.OutSize()
+	}
+	// INPUT layer accepts the concatenated branches output
+	layers := append([]*Layer{}, n.layers...)
+	inLayer := layers[0]
+	layers[0] = inLayer.Clone()
+	layers[0].in, layers[0].out = size, size
+	if err := n.setLayers(layers); err != nil {
+		return err
+	}
+	*inLayer = *layers[0]
+	n.layers[0] = inLayer
+	n.branches = append(n.branches, b)
+	n.applyPrecision()
+	return nil
+}
+
+// Branches returns network input branches in the order in which they were added
+func (n *Network) Branches() []*Branch {
+	return n.branches
+}
+
+// inputFwd propagates the input through the network INPUT layer. If the network has input branches
+// the input is first split between the branches and the concatenated output of all the branches
+// is passed to the INPUT layer. It returns the INPUT layer output along with the branches
+// layer inputs and activation inputs which are needed by backpropagation.
+func (n *Network) inputFwd(inMx mat.Matrix) (mat.Matrix, []*branchProp, error) {
+	if len(n.branches) == 0 {
+		out, err := n.layers[0].FwdOut(inMx)
+		return out, nil, err
+	}
+	rows, cols := inMx.Dims()
+	inSize, outSize := 0, 0
+	for _, branch := range n.branches {
+		inSize += branch.InSize()
+		outSize += branch.OutSize()
+	}
+	if cols != inSize {
+		return nil, nil, fmt.Errorf("Dimension mismatch. Branches: %d, Input: %d\n", inSize, cols)
+	}
+	denseInMx := asDense(inMx)
+	outMx := mat.NewDense(rows, outSize, nil)
+	props := make([]*branchProp, len(n.branches))
+	inCol, outCol := 0, 0
+	for i, branch := range n.branches {
+		branchIn := denseInMx.Slice(0, rows, inCol, inCol+branch.InSize())
+		ins, actIns, branchOut, err := fwdProp(branch.layers, branchIn)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Branch %s: %s", branch.name, err)
+		}
+		outMx.Slice(0, rows, outCol, outCol+branch.OutSize()).(*mat.Dense).Copy(branchOut)
+		props[i] = &branchProp{ins: ins, actIns: actIns}
+		inCol += branch.InSize()
+		outCol += branch.OutSize()
+	}
+	out, err := n.layers[0].FwdOut(outMx)
+	return out, props, err
+}
+
+// branchesBackProp splits the supplied error of the INPUT layer output between the network
+// branches and backpropagates it through all the branch layers.
+func (n *Network) branchesBackProp(props []*branchProp, errMx *mat.Dense) {
+	rows, _ := errMx.Dims()
+	col := 0
+	for i, branch := range n.branches {
+		last := len(branch.layers) - 1
+		branchErr := errMx.Slice(0, rows, col, col+branch.OutSize())
+		col += branch.OutSize()
+		// branch with INPUT layer only has no weights
+		if last == 0 {
+			continue
+		}
+		prop := props[i]
+		actInErr := branch.layers[last].actInErr(branchErr, prop.actIns[last])
+		backProp(branch.layers[1:], prop.ins[1:], prop.actIns[1:], actInErr, false)
+	}
+}
+
+// asDense returns the supplied matrix as *mat.Dense
+func asDense(m mat.Matrix) *mat.Dense {
+	if d, ok := m.(*mat.Dense); ok {
+		return d
+	}
+	d := new(mat.Dense)
+	d.CloneFrom(m)
+	return d
+}
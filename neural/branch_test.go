@@ -0,0 +1,109 @@
+package neural
+
+import (
+	"math"
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"github.com/stretchr/testify/assert"
+)
+
+// newInputLayer creates a new INPUT layer of given size
+func newInputLayer(size int) *Layer {
+	layer, err := NewLayer(&config.LayerConfig{Kind: "input", Size: size}, size)
+	if err != nil {
+		panic(err)
+	}
+	return layer
+}
+
+func TestNewBranch(t *testing.T) {
+	assert := assert.New(t)
+
+	inLayer := newInputLayer(2)
+	hiddenLayer := newTestLayer("hidden", 3, Tanh)
+	// empty name
+	b, err := NewBranch("", inLayer)
+	assert.Nil(b)
+	assert.Error(err)
+	// no layers
+	b, err = NewBranch("foo")
+	assert.Nil(b)
+	assert.Error(err)
+	// first layer must be INPUT layer
+	b, err = NewBranch("foo", hiddenLayer)
+	assert.Nil(b)
+	assert.Error(err)
+	b, err = NewBranch("foo", inLayer, hiddenLayer)
+	assert.NotNil(b)
+	assert.NoError(err)
+	assert.Equal(b.Name(), "foo")
+	assert.Equal(b.Layers(), []*Layer{inLayer, hiddenLayer})
+	assert.Equal(b.InSize(), 2)
+	assert.Equal(b.OutSize(), 3)
+	// hidden layer is resized to accept the input
+	assert.Equal(hiddenLayer.InSize(), 2)
+}
+
+func TestBranches(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewBuilder().Input(4).Hidden(5, Sigmoid).Output(5, Softmax).Build()
+	assert.NotNil(n)
+	assert.NoError(err)
+	assert.Error(n.AddBranch(nil))
+	bA, err := NewBranch("a", newInputLayer(2), newTestLayer("hidden", 3, Tanh))
+	assert.NoError(err)
+	bB, err := NewBranch("b", newInputLayer(2))
+	assert.NoError(err)
+	assert.NoError(n.AddBranch(bA))
+	assert.NoError(n.AddBranch(bB))
+	assert.Len(n.Branches(), 2)
+	// duplicate branches are not allowed
+	assert.Error(n.AddBranch(bA))
+	// INPUT layer accepts concatenated branches output
+	assert.Equal(n.Layers()[0].InSize(), 5)
+	assert.Equal(n.Layers()[1].InSize(), 5)
+	// input must match the branches inputs
+	out, err := n.ForwardProp(inMx.Slice(0, 5, 0, 3), 2)
+	assert.Nil(out)
+	assert.Error(err)
+	out, err = n.ForwardProp(inMx, 2)
+	assert.NoError(err)
+	rows, cols := out.Dims()
+	assert.Equal(rows, 5)
+	assert.Equal(cols, 5)
+	// gradient matches its numerical approximation
+	c := &config.TrainConfig{
+		Kind:   "backprop",
+		Cost:   "loglike",
+		Lambda: 0.5,
+	}
+	var weights []float64
+	for _, layer := range n.trainLayers() {
+		weights = append(weights, matrix.Mx2Vec(layer.Weights(), false)...)
+	}
+	grad, err := n.getGradient(c, weights, inMx, labelsVec)
+	assert.NoError(err)
+	assert.Len(grad, len(weights))
+	eps := 1e-4
+	for i := range weights {
+		w := weights[i]
+		weights[i] = w + eps
+		costPlus, err := n.getCost(c, weights, inMx, labelsVec)
+		assert.NoError(err)
+		weights[i] = w - eps
+		costMinus, err := n.getCost(c, weights, inMx, labelsVec)
+		assert.NoError(err)
+		weights[i] = w
+		assert.True(math.Abs(grad[i]-(costPlus-costMinus)/(2*eps)) < 1e-6)
+	}
+	// branches are cloned with network
+	clone := n.Clone()
+	assert.Len(clone.Branches(), 2)
+	assert.False(clone.Branches()[0].Layers()[1] == bA.Layers()[1])
+	// branches are included in network summary and DOT
+	assert.Contains(n.Summary(), "a.1")
+	assert.Contains(n.DOT(), "branch1_0 -> layer0;")
+}
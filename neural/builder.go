@@ -0,0 +1,164 @@
+package neural
+
+import (
+	"fmt"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+)
+
+// Builder allows to build feedforward neural networks layer by layer using a fluent API:
+//
+//	net, err := NewBuilder().Input(784).Hidden(128, ReLU).Hidden(64, ReLU).Output(10, Softmax).Build()
+//
+// Builder records the first error it encounters and returns it from Build.
+type Builder struct {
+	// input is INPUT layer configuration
+	input *config.LayerConfig
+	// hidden contains configurations of HIDDEN layers
+	hidden []*config.LayerConfig
+	// output is OUTPUT layer configuration
+	output *config.LayerConfig
+	// err is the first error encountered while building
+	err error
+}
+
+// NewBuilder creates new neural network builder and returns it
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Input configures network INPUT layer with size inputs
+func (b *Builder) Input(size int) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if b.input != nil {
+		b.err = fmt.Errorf("Duplicate %s layers not allowed\n", INPUT)
+		return b
+	}
+	b.input = &config.LayerConfig{
+		Kind: "input",
+		Size: size,
+	}
+	return b
+}
+
+// Hidden appends a fully connected HIDDEN layer with size neurons and activation function
+func (b *Builder) Hidden(size int, activation string) *Builder {
+	return b.addHidden(&config.LayerConfig{
+		Kind: "hidden",
+		Size: size,
+		NeurFn: &config.NeuronConfig{
+			Activation: activation,
+		},
+	})
+}
+
+// Maxout appends a maxout HIDDEN layer with size neurons each of which has pieces affine pieces
+func (b *Builder) Maxout(size, pieces int) *Builder {
+	return b.addHidden(&config.LayerConfig{
+		Kind: "hidden",
+		Size: size,
+		NeurFn: &config.NeuronConfig{
+			Activation: Maxout,
+			Pieces:     pieces,
+		},
+	})
+}
+
+// Conv1D appends a 1D convolution HIDDEN layer with filters output channels. Kernel of
+// the supplied width is moved by stride positions over the input which has channels channels.
+func (b *Builder) Conv1D(filters, width, stride, channels int, activation string) *Builder {
+	return b.addHidden(&config.LayerConfig{
+		Kind: "hidden",
+		Size: filters,
+		NeurFn: &config.NeuronConfig{
+			Activation: activation,
+		},
+		Conv: &config.ConvConfig{
+			Width:    width,
+			Stride:   stride,
+			Channels: channels,
+		},
+	})
+}
+
+// addHidden appends HIDDEN layer configuration to builder
+func (b *Builder) addHidden(c *config.LayerConfig) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if b.input == nil {
+		b.err = fmt.Errorf("%s layer must be added before %s layers\n", INPUT, HIDDEN)
+		return b
+	}
+	if b.output != nil {
+		b.err = fmt.Errorf("%s layer can't be added after %s layer\n", HIDDEN, OUTPUT)
+		return b
+	}
+	b.hidden = append(b.hidden, c)
+	return b
+}
+
+// Output configures network OUTPUT layer with size neurons and activation function
+func (b *Builder) Output(size int, activation string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if b.output != nil {
+		b.err = fmt.Errorf("Duplicate %s layers not allowed\n", OUTPUT)
+		return b
+	}
+	b.output = &config.LayerConfig{
+		Kind: "output",
+		Size: size,
+		NeurFn: &config.NeuronConfig{
+			Activation: activation,
+		},
+	}
+	return b
+}
+
+// Config returns network configuration assembled by the builder.
+// It fails with error if the builder encountered any error or if either INPUT or OUTPUT layer is missing.
+func (b *Builder) Config() (*config.NetConfig, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.input == nil {
+		return nil, fmt.Errorf("Missing %s layer\n", INPUT)
+	}
+	if b.output == nil {
+		return nil, fmt.Errorf("Missing %s layer\n", OUTPUT)
+	}
+	return &config.NetConfig{
+		Kind: "feedfwd",
+		Arch: &config.NetArch{
+			Input:  b.input,
+			Hidden: b.hidden,
+			Output: b.output,
+		},
+	}, nil
+}
+
+// Build creates new feedforward neural network from the layers added to the builder.
+// It validates that every layer accepts the output of the preceding layer and fails with error
+// which identifies the offending layer if any of the layers can not be created.
+func (b *Builder) Build() (*Network, error) {
+	c, err := b.Config()
+	if err != nil {
+		return nil, err
+	}
+	layerConfigs := append([]*config.LayerConfig{c.Arch.Input}, c.Arch.Hidden...)
+	layerConfigs = append(layerConfigs, c.Arch.Output)
+	// validate the layers one by one so we can report which layer is invalid
+	layerInSize := c.Arch.Input.Size
+	for i, layerConfig := range layerConfigs {
+		layer, err := NewLayer(layerConfig, layerInSize)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid layer %d: %s", i, err)
+		}
+		layerInSize = layer.OutSize()
+	}
+	return NewNetwork(c)
+}
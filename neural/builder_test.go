@@ -0,0 +1,56 @@
+package neural
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	assert := assert.New(t)
+
+	// build a simple network
+	net, err := NewBuilder().Input(4).Hidden(6, ReLU).Hidden(5, Sigmoid).Output(3, Softmax).Build()
+	assert.NotNil(net)
+	assert.NoError(err)
+	layers := net.Layers()
+	assert.Len(layers, 4)
+	assert.Equal(layers[0].Kind(), INPUT)
+	assert.Equal(layers[1].Kind(), HIDDEN)
+	assert.Equal(layers[3].Kind(), OUTPUT)
+	r, c := layers[2].Weights().Dims()
+	assert.Equal(r, 5)
+	assert.Equal(c, 7)
+	// convolution and maxout layers
+	net, err = NewBuilder().Input(8).Conv1D(2, 2, 2, 1, ReLU).Maxout(3, 2).Output(2, Softmax).Build()
+	assert.NotNil(net)
+	assert.NoError(err)
+	assert.Equal(net.Layers()[1].OutSize(), 8)
+	// missing INPUT layer
+	net, err = NewBuilder().Hidden(5, ReLU).Output(3, Softmax).Build()
+	assert.Nil(net)
+	assert.Error(err)
+	// missing OUTPUT layer
+	net, err = NewBuilder().Input(4).Hidden(5, ReLU).Build()
+	assert.Nil(net)
+	assert.Error(err)
+	// duplicate layers
+	net, err = NewBuilder().Input(4).Input(4).Output(3, Softmax).Build()
+	assert.Nil(net)
+	assert.Error(err)
+	net, err = NewBuilder().Input(4).Output(3, Softmax).Output(3, Softmax).Build()
+	assert.Nil(net)
+	assert.Error(err)
+	// HIDDEN layer after OUTPUT layer
+	net, err = NewBuilder().Input(4).Output(3, Softmax).Hidden(5, ReLU).Build()
+	assert.Nil(net)
+	assert.Error(err)
+	// incompatible convolution input
+	net, err = NewBuilder().Input(5).Conv1D(2, 2, 1, 2, ReLU).Output(3, Softmax).Build()
+	assert.Nil(net)
+	assert.Error(err)
+	// unsupported activation
+	net, err = NewBuilder().Input(4).Hidden(5, "foobar").Output(3, Softmax).Build()
+	assert.Nil(net)
+	assert.Error(err)
+}
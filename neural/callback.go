@@ -0,0 +1,71 @@
+package neural
+
+import "errors"
+
+// ErrStopTraining can be returned by Callback to stop the training early.
+// Trainer stops the training without error when it receives ErrStopTraining.
+var ErrStopTraining = errors.New("Training stopped")
+
+// Metrics maps metric names to their values. Trainer reports the following metrics:
+// "cost" is training cost of the epoch or of the mini-batch. Validation metrics of the epoch are
+// prefixed with "val_" and they are only reported with validation split: "val_cost" and "val_accuracy"
+// by default, or "val_cost", "val_accuracy", "val_f1", "val_auc" and "val_logloss" as configured
+// by training Metrics. Custom metrics added by Trainer.AddMetric are reported under their names.
+type Metrics map[string]float64
+
+// Callback receives training events from Trainer. Callbacks allow to implement logging,
+// checkpointing, learning rate scheduling or custom early stopping without modifying Trainer.
+// Epochs and mini-batches within each epoch are counted from 1. If any of the methods returns
+// ErrStopTraining the training stops without error. Any other error aborts the training.
+// Callbacks are called while the network is being trained, so they must not call network methods.
+type Callback interface {
+	// OnTrainBegin is called before the first epoch
+	OnTrainBegin() error
+	// OnEpochEnd is called after every epoch with epoch metrics
+	OnEpochEnd(epoch int, m Metrics) error
+	// OnBatchEnd is called after every mini-batch update with mini-batch metrics
+	OnBatchEnd(epoch, batch int, m Metrics) error
+	// OnTrainEnd is called when the training finishes with the metrics of the last epoch
+	OnTrainEnd(m Metrics) error
+}
+
+// CallbackFuncs allows to use ordinary functions as Callback.
+// Events with nil functions are ignored.
+type CallbackFuncs struct {
+	TrainBegin func() error
+	EpochEnd   func(epoch int, m Metrics) error
+	BatchEnd   func(epoch, batch int, m Metrics) error
+	TrainEnd   func(m Metrics) error
+}
+
+// OnTrainBegin calls TrainBegin function
+func (c CallbackFuncs) OnTrainBegin() error {
+	if c.TrainBegin == nil {
+		return nil
+	}
+	return c.TrainBegin()
+}
+
+// OnEpochEnd calls EpochEnd function
+func (c CallbackFuncs) OnEpochEnd(epoch int, m Metrics) error {
+	if c.EpochEnd == nil {
+		return nil
+	}
+	return c.EpochEnd(epoch, m)
+}
+
+// OnBatchEnd calls BatchEnd function
+func (c CallbackFuncs) OnBatchEnd(epoch, batch int, m Metrics) error {
+	if c.BatchEnd == nil {
+		return nil
+	}
+	return c.BatchEnd(epoch, batch, m)
+}
+
+// OnTrainEnd calls TrainEnd function
+func (c CallbackFuncs) OnTrainEnd(m Metrics) error {
+	if c.TrainEnd == nil {
+		return nil
+	}
+	return c.TrainEnd(m)
+}
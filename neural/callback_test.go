@@ -0,0 +1,111 @@
+package neural
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// hasMetrics returns true if all the supplied metrics are reported
+func hasMetrics(m Metrics, names ...string) bool {
+	for _, name := range names {
+		if _, ok := m[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCallbackFuncs(t *testing.T) {
+	assert := assert.New(t)
+
+	// nil functions are ignored
+	var cb Callback = CallbackFuncs{}
+	assert.NoError(cb.OnTrainBegin())
+	assert.NoError(cb.OnEpochEnd(1, Metrics{}))
+	assert.NoError(cb.OnBatchEnd(1, 1, Metrics{}))
+	assert.NoError(cb.OnTrainEnd(Metrics{}))
+	// functions are called
+	var events []string
+	cb = CallbackFuncs{
+		TrainBegin: func() error { events = append(events, "begin"); return nil },
+		EpochEnd:   func(epoch int, m Metrics) error { events = append(events, "epoch"); return nil },
+		BatchEnd:   func(epoch, batch int, m Metrics) error { events = append(events, "batch"); return nil },
+		TrainEnd:   func(m Metrics) error { events = append(events, "end"); return ErrStopTraining },
+	}
+	assert.NoError(cb.OnTrainBegin())
+	assert.NoError(cb.OnEpochEnd(1, Metrics{}))
+	assert.NoError(cb.OnBatchEnd(1, 1, Metrics{}))
+	assert.Equal(ErrStopTraining, cb.OnTrainEnd(Metrics{}))
+	assert.Equal([]string{"begin", "epoch", "batch", "end"}, events)
+}
+
+func TestTrainerCallbacks(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	c.Epochs = 3
+	c.Shuffle = false
+	c.ValidSplit = 0.4
+	tr, err := NewTrainer(c)
+	assert.NoError(err)
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	// nil callback
+	assert.Error(tr.AddCallback(nil))
+	// record all the events
+	var events []string
+	var last Metrics
+	assert.NoError(tr.AddCallback(CallbackFuncs{
+		TrainBegin: func() error {
+			events = append(events, "begin")
+			return nil
+		},
+		EpochEnd: func(epoch int, m Metrics) error {
+			events = append(events, fmt.Sprintf("epoch %d", epoch))
+			assert.True(hasMetrics(m, "cost", "val_cost", "val_accuracy"))
+			last = m
+			return nil
+		},
+		BatchEnd: func(epoch, batch int, m Metrics) error {
+			events = append(events, fmt.Sprintf("batch %d.%d", epoch, batch))
+			assert.True(hasMetrics(m, "cost"))
+			return nil
+		},
+		TrainEnd: func(m Metrics) error {
+			events = append(events, "end")
+			assert.Equal(last, m)
+			return nil
+		},
+	}))
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+	assert.Equal([]string{
+		"begin",
+		"batch 1.1", "batch 1.2", "epoch 1",
+		"batch 2.1", "batch 2.2", "epoch 2",
+		"batch 3.1", "batch 3.2", "epoch 3",
+		"end",
+	}, events)
+	// callback stops the training
+	tr, err = NewTrainer(c)
+	assert.NoError(err)
+	epochs := 0
+	assert.NoError(tr.AddCallback(CallbackFuncs{
+		EpochEnd: func(epoch int, m Metrics) error {
+			epochs++
+			return ErrStopTraining
+		},
+	}))
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+	assert.Equal(1, epochs)
+	// callback aborts the training
+	tr, err = NewTrainer(c)
+	assert.NoError(err)
+	assert.NoError(tr.AddCallback(CallbackFuncs{
+		BatchEnd: func(epoch, batch int, m Metrics) error {
+			return fmt.Errorf("abort")
+		},
+	}))
+	assert.Error(tr.Train(n, inMx, labelsVec))
+}
@@ -0,0 +1,92 @@
+package neural
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Checkpoint is a snapshot of mini-batch training which allows to resume the training exactly
+// where it was left off. Training noise is only restored when the training configuration has
+// non-zero seed and augmentation is only restored if the augmenter implements dataset.RandomState.
+type Checkpoint struct {
+	// Epoch is the number of finished epochs
+	Epoch int
+	// Batch is the number of trained mini-batches of the next epoch if the training was cancelled in its middle
+	Batch int
+	// Step is the number of finished mini-batch updates
+	Step int
+	// Seed is the seed of mini-batch shuffling
+	Seed int64
+	// Weights contains weights of all trainable network layers
+	Weights []float64
+	// Optim contains optimizer state; it's nil if the optimizer is not StatefulOptimizer
+	Optim *OptimState
+	// Metrics contains metrics of the last finished epoch
+	Metrics Metrics
+	// Best is the best validation metric found by early stopping
+	Best float64
+	// BestWeights contains network weights with the best validation metric
+	BestWeights []float64
+	// Wait is the number of epochs in which the validation metric has not improved
+	Wait int
+	// SWAWeights contains network weights averaged by stochastic weight averaging
+	SWAWeights []float64
+	// SWACount is the number of averaged epochs
+	SWACount int
+	// NoiseDraws is the number of random numbers drawn by training noise since the training started
+	NoiseDraws int64
+	// AugmentState is random state of the augmenter; it's nil if the augmenter is not dataset.RandomState
+	AugmentState []int64
+}
+
+// SaveCheckpoint saves the supplied checkpoint to the file with the supplied path.
+// The checkpoint is written to a temporary file first, which then replaces the checkpoint
+// file, so the existing checkpoint is never left half written after a crash.
+// It returns error if the checkpoint is nil or if it fails to write the checkpoint file.
+func SaveCheckpoint(path string, cp *Checkpoint) error {
+	if cp == nil {
+		return fmt.Errorf("Incorrect checkpoint supplied: %v\n", cp)
+	}
+	return writeFile(path, func(w io.Writer) error {
+		return gob.NewEncoder(w).Encode(cp)
+	})
+}
+
+// writeFile writes the file with the supplied path using the supplied write function. The data are
+// written to a temporary file first, which then replaces the file, so the existing file is never left
+// half written after a crash. The temporary file is removed if the write fails.
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// LoadCheckpoint loads checkpoint from the file with the supplied path and returns it.
+// It returns error if the file can't be read or if it does not contain a checkpoint.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	cp := new(Checkpoint)
+	if err := gob.NewDecoder(f).Decode(cp); err != nil {
+		return nil, fmt.Errorf("Incorrect checkpoint file %s: %v\n", path, err)
+	}
+	return cp, nil
+}
@@ -0,0 +1,229 @@
+package neural
+
+import (
+	"context"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestSaveCheckpoint(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "checkpoint")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "train.ckpt")
+	// nil checkpoint
+	assert.Error(SaveCheckpoint(path, nil))
+	// save and load checkpoint
+	cp := &Checkpoint{
+		Epoch:   2,
+		Step:    6,
+		Seed:    42,
+		Weights: []float64{1.0, 2.0, 3.0},
+		Optim: &OptimState{
+			Buffers: map[string]map[int]*mat.Dense{
+				"m": {1: mat.NewDense(1, 2, []float64{0.5, 1.5})},
+			},
+			Steps: map[int]int{1: 6},
+		},
+		Metrics:     Metrics{"cost": 0.5},
+		Best:        0.25,
+		BestWeights: []float64{3.0, 2.0, 1.0},
+		Wait:        1,
+	}
+	assert.NoError(SaveCheckpoint(path, cp))
+	loaded, err := LoadCheckpoint(path)
+	assert.NoError(err)
+	assert.Equal(cp, loaded)
+	// checkpoint is replaced
+	cp.Epoch = 3
+	assert.NoError(SaveCheckpoint(path, cp))
+	loaded, err = LoadCheckpoint(path)
+	assert.NoError(err)
+	assert.Equal(3, loaded.Epoch)
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(err)
+	assert.Len(files, 1)
+	// nonexistent file
+	loaded, err = LoadCheckpoint(filepath.Join(dir, "foo"))
+	assert.Nil(loaded)
+	assert.Error(err)
+	// incorrect file
+	assert.NoError(ioutil.WriteFile(path, []byte("foobar"), 0666))
+	loaded, err = LoadCheckpoint(path)
+	assert.Nil(loaded)
+	assert.Error(err)
+}
+
+func TestTrainerResume(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "checkpoint")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "train.ckpt")
+	newConfig := func(epochs int) *config.TrainConfig {
+		c := newTrainerConfig()
+		c.Optimize.Method = "adam"
+		c.Optimize.LearnRate = 0.05
+		c.Epochs = epochs
+		c.Checkpoint = &config.CheckpointConfig{Path: path, Every: 2}
+		return c
+	}
+	newTrainer := func(epochs int) *Trainer {
+		tr, err := NewTrainer(newConfig(epochs))
+		assert.NoError(err)
+		tr.rng = rand.New(rand.NewSource(1))
+		return tr
+	}
+	net, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	// uninterrupted training
+	full := net.Clone()
+	assert.NoError(newTrainer(4).Train(full, inMx, labelsVec))
+	// training interrupted after 2 epochs
+	assert.NoError(newTrainer(2).Train(net.Clone(), inMx, labelsVec))
+	cp, err := LoadCheckpoint(path)
+	assert.NoError(err)
+	assert.Equal(2, cp.Epoch)
+	assert.Equal(6, cp.Step)
+	assert.NotNil(cp.Optim)
+	// resumed training ends with the same weights as the uninterrupted training
+	resumed := net.Clone()
+	tr := newTrainer(4)
+	assert.Error(tr.Resume(nil))
+	assert.NoError(tr.Resume(cp))
+	assert.NoError(tr.Train(resumed, inMx, labelsVec))
+	assert.Equal(full.Params(), resumed.Params())
+	// checkpoint does not match the network
+	other, err := NewFeedForward(4, []int{3}, 5)
+	assert.NoError(err)
+	assert.NoError(tr.Resume(cp))
+	assert.Error(tr.Train(other, inMx, labelsVec))
+	// checkpoint does not match the training
+	tr = newTrainer(1)
+	assert.NoError(tr.Resume(cp))
+	assert.Error(tr.Train(net.Clone(), inMx, labelsVec))
+	// optimizer without state
+	tr = newTrainer(4)
+	assert.NoError(tr.SetOptimizer(constOptim{}))
+	assert.NoError(tr.Resume(cp))
+	assert.Error(tr.Train(net.Clone(), inMx, labelsVec))
+	// incorrect checkpointing
+	c := newConfig(4)
+	c.Checkpoint.Every = 0
+	tr, err = NewTrainer(c)
+	assert.Nil(tr)
+	assert.Error(err)
+}
+
+func TestTrainerResumeRandom(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "resume")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "train.ckpt")
+	newTrainer := func(epochs int) *Trainer {
+		c := newTrainerConfig()
+		c.Epochs, c.Seed = epochs, 3
+		c.Checkpoint = &config.CheckpointConfig{Path: path, Every: 2}
+		tr, err := NewTrainer(c)
+		assert.NoError(err)
+		g, err := dataset.NewGaussianNoise(0.1, 4)
+		assert.NoError(err)
+		tr.SetAugmenter(dataset.Pipeline{g})
+		return tr
+	}
+	net, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	assert.NoError(net.Layers()[1].SetNoise(0.1))
+	// uninterrupted training
+	full := net.Clone()
+	assert.NoError(newTrainer(4).Train(full, inMx, labelsVec))
+	// training interrupted after 2 epochs
+	assert.NoError(newTrainer(2).Train(net.Clone(), inMx, labelsVec))
+	cp, err := LoadCheckpoint(path)
+	assert.NoError(err)
+	assert.True(cp.NoiseDraws > 0)
+	assert.Len(cp.AugmentState, 1)
+	// resumed training continues with the same noise and augmentation
+	resumed := net.Clone()
+	tr := newTrainer(4)
+	assert.NoError(tr.Resume(cp))
+	assert.NoError(tr.Train(resumed, inMx, labelsVec))
+	assert.Equal(full.Params(), resumed.Params())
+	// augmentation state does not match the augmenter
+	cp.AugmentState = []int64{1, 2}
+	tr = newTrainer(4)
+	assert.NoError(tr.Resume(cp))
+	assert.Error(tr.Train(net.Clone(), inMx, labelsVec))
+}
+
+func TestTrainerCancelCheckpoint(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "cancel")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "train.ckpt")
+	newTrainer := func(cancel func(epoch, batch int)) *Trainer {
+		c := newTrainerConfig()
+		c.Optimize.Method = "adam"
+		c.Optimize.LearnRate = 0.05
+		c.Epochs, c.Seed = 3, 3
+		c.Checkpoint = &config.CheckpointConfig{Path: path, Every: 10}
+		tr, err := NewTrainer(c)
+		assert.NoError(err)
+		g, err := dataset.NewGaussianNoise(0.1, 4)
+		assert.NoError(err)
+		tr.SetAugmenter(dataset.Pipeline{g})
+		// mini-batch costs are calculated in all the trainings alike
+		assert.NoError(tr.AddCallback(CallbackFuncs{BatchEnd: func(epoch, batch int, m Metrics) error {
+			cancel(epoch, batch)
+			return nil
+		}}))
+		return tr
+	}
+	net, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	assert.NoError(net.Layers()[1].SetNoise(0.1))
+	// uninterrupted training
+	full := net.Clone()
+	assert.NoError(newTrainer(func(int, int) {}).Train(full, inMx, labelsVec))
+	_, err = os.Stat(path)
+	assert.True(os.IsNotExist(err))
+	// training cancelled after the second mini-batch of the second epoch is checkpointed
+	ctx, cancel := context.WithCancel(context.Background())
+	tr := newTrainer(func(epoch, batch int) {
+		if epoch == 2 && batch == 2 {
+			cancel()
+		}
+	})
+	assert.Equal(context.Canceled, tr.TrainContext(ctx, net.Clone(), inMx, labelsVec))
+	cp, err := LoadCheckpoint(path)
+	assert.NoError(err)
+	assert.Equal(1, cp.Epoch)
+	assert.Equal(2, cp.Batch)
+	assert.Equal(5, cp.Step)
+	// resumed training ends with the same weights as the uninterrupted training
+	resumed := net.Clone()
+	tr = newTrainer(func(int, int) {})
+	assert.NoError(tr.Resume(cp))
+	assert.NoError(tr.Train(resumed, inMx, labelsVec))
+	assert.Equal(full.Params(), resumed.Params())
+	// checkpoint mini-batch outside of the epoch
+	cp.Batch = 10
+	tr = newTrainer(func(int, int) {})
+	assert.NoError(tr.Resume(cp))
+	assert.Error(tr.Train(net.Clone(), inMx, labelsVec))
+}
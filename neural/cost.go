@@ -1,18 +1,30 @@
 package neural
 
 import (
-	"github.com/gonum/matrix/mat64"
+	"fmt"
+	"math"
+
 	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"gonum.org/v1/gonum/mat"
 )
 
+// Loss is neural network training loss
+type Loss interface {
+	// Cost returns the loss of the predictions with respect to the targets averaged over samples
+	Cost(pred, target mat.Matrix) float64
+	// Grad returns the gradient of the loss of every sample with respect to its predictions.
+	// Unlike Cost, the gradient is not averaged over samples.
+	Grad(pred, target mat.Matrix) mat.Matrix
+}
+
 // Cost is neural network training cost
 type Cost interface {
 	// CostFunc defines neural network cost function for given input, output and labels.
 	// It returns a single number: cost for given input and output
-	CostFunc(mat64.Matrix, mat64.Matrix, mat64.Matrix) float64
+	CostFunc(mat.Matrix, mat.Matrix, mat.Matrix) float64
 	// Delta implements function that calculates error in the last network layer
 	// It returns the output error matrix
-	Delta(mat64.Matrix, mat64.Matrix) mat64.Matrix
+	Delta(mat.Matrix, mat.Matrix) mat.Matrix
 }
 
 // CrossEntropy implements Cost interface
@@ -20,16 +32,16 @@ type CrossEntropy struct{}
 
 // CostFunc implements cross entropy cost function.
 // C = -(sum(sum((out_k .* log(out) + (1 - out_k) .* log(1 - out)), 2)))/samples
-func (c CrossEntropy) CostFunc(inMx, outMx, labelsMx mat64.Matrix) float64 {
-	// safe switch type as matrix.MakeLabelsMx returns *mat64.Dense
-	lMx := labelsMx.(*mat64.Dense)
-	oMx := outMx.(*mat64.Dense)
+func (c CrossEntropy) CostFunc(inMx, outMx, labelsMx mat.Matrix) float64 {
+	// safe switch type as matrix.MakeLabelsMx returns *mat.Dense
+	lMx := labelsMx.(*mat.Dense)
+	oMx := outMx.(*mat.Dense)
 	// out_k .* log(out)
-	costMxA := new(mat64.Dense)
+	costMxA := new(mat.Dense)
 	costMxA.Apply(matrix.LogMx, oMx)
 	costMxA.MulElem(lMx, costMxA)
 	// (1 - out_k) .* log(1 - out)
-	costMxB := new(mat64.Dense)
+	costMxB := new(mat.Dense)
 	lMx.Apply(matrix.SubtrMx(1.0), lMx)
 	oMx.Apply(matrix.SubtrMx(1.0), oMx)
 	oMx.Apply(matrix.LogMx, oMx)
@@ -38,41 +50,373 @@ func (c CrossEntropy) CostFunc(inMx, outMx, labelsMx mat64.Matrix) float64 {
 	costMxB.Add(costMxA, costMxB)
 	// calculate the cost
 	samples, _ := inMx.Dims()
-	cost := -(mat64.Sum(costMxB) / float64(samples))
+	cost := -(mat.Sum(costMxB) / float64(samples))
 	return cost
 }
 
 // Delta calculates the error of the last layer and returns it
 // D = (out_k - out)
-func (c CrossEntropy) Delta(outMx, expMx mat64.Matrix) mat64.Matrix {
-	deltaMx := new(mat64.Dense)
+func (c CrossEntropy) Delta(outMx, expMx mat.Matrix) mat.Matrix {
+	deltaMx := new(mat.Dense)
 	deltaMx.Sub(outMx, expMx)
 	return deltaMx
 }
 
+// Cost implements cross entropy loss without modifying the supplied matrices
+func (c CrossEntropy) Cost(pred, target mat.Matrix) float64 {
+	return elemLoss(pred, target, func(p, t float64) float64 {
+		return -(t*math.Log(p) + (1-t)*math.Log(1-p))
+	})
+}
+
+// Grad calculates cross entropy loss gradient
+// G = (out - out_k)/(out .* (1 - out))
+func (c CrossEntropy) Grad(pred, target mat.Matrix) mat.Matrix {
+	return elemGrad(pred, target, func(p, t float64) float64 {
+		return (p - t) / (p * (1 - p))
+	})
+}
+
 // LogLikelihood implements Cost interface
 type LogLikelihood struct{}
 
 // CostFunc implements log-likelihood cost function.
 // C = -sum(sum(out_k.*log(out)))
-func (c LogLikelihood) CostFunc(inMx, outMx, labelsMx mat64.Matrix) float64 {
-	// safe switch type as matrix.MakeLabelsMx returns *mat64.Dense
-	lMx := labelsMx.(*mat64.Dense)
-	oMx := outMx.(*mat64.Dense)
+func (c LogLikelihood) CostFunc(inMx, outMx, labelsMx mat.Matrix) float64 {
+	// safe switch type as matrix.MakeLabelsMx returns *mat.Dense
+	lMx := labelsMx.(*mat.Dense)
+	oMx := outMx.(*mat.Dense)
 	// out_k .* log(out)
-	costMx := new(mat64.Dense)
+	costMx := new(mat.Dense)
 	costMx.Apply(matrix.LogMx, oMx)
 	costMx.MulElem(lMx, costMx)
 	// calculate the cost
 	samples, _ := inMx.Dims()
-	cost := (-mat64.Sum(costMx) / float64(samples))
+	cost := (-mat.Sum(costMx) / float64(samples))
 	return cost
 }
 
 // Delta calculates the error of the last layer and returns it
 // D = (out_k - out)
-func (c LogLikelihood) Delta(outMx, expMx mat64.Matrix) mat64.Matrix {
-	deltaMx := new(mat64.Dense)
+func (c LogLikelihood) Delta(outMx, expMx mat.Matrix) mat.Matrix {
+	deltaMx := new(mat.Dense)
 	deltaMx.Sub(outMx, expMx)
 	return deltaMx
 }
+
+// Cost implements log-likelihood loss without modifying the supplied matrices
+func (c LogLikelihood) Cost(pred, target mat.Matrix) float64 {
+	return elemLoss(pred, target, func(p, t float64) float64 {
+		return -t * math.Log(p)
+	})
+}
+
+// Grad calculates log-likelihood loss gradient
+// G = -out_k ./ out
+func (c LogLikelihood) Grad(pred, target mat.Matrix) mat.Matrix {
+	return elemGrad(pred, target, func(p, t float64) float64 {
+		if t == 0 {
+			return 0.0
+		}
+		return -t / p
+	})
+}
+
+// MSE implements Loss interface
+type MSE struct{}
+
+// Cost implements mean squared error loss
+// C = sum(sum((out - out_k).^2))/(2*samples)
+func (m MSE) Cost(pred, target mat.Matrix) float64 {
+	return elemLoss(pred, target, func(p, t float64) float64 {
+		return (p - t) * (p - t) / 2
+	})
+}
+
+// Grad calculates mean squared error loss gradient
+// G = out - out_k
+func (m MSE) Grad(pred, target mat.Matrix) mat.Matrix {
+	return elemGrad(pred, target, func(p, t float64) float64 {
+		return p - t
+	})
+}
+
+// Huber implements Loss interface.
+// Huber loss is quadratic for small errors and linear for errors larger than delta.
+type Huber struct {
+	// delta is the error at which the loss becomes linear
+	delta float64
+}
+
+// NewHuber creates new Huber loss with the supplied delta and returns it.
+// Non-positive delta is replaced with its default 1.0.
+func NewHuber(delta float64) Huber {
+	if delta <= 0 {
+		delta = 1.0
+	}
+	return Huber{delta: delta}
+}
+
+// Delta returns the error at which Huber loss becomes linear
+func (h Huber) Delta() float64 {
+	return h.delta
+}
+
+// Cost implements Huber loss
+// C = sum(sum(L))/samples, L = e.^2/2 if |e| <= delta, delta*(|e| - delta/2) otherwise
+func (h Huber) Cost(pred, target mat.Matrix) float64 {
+	return elemLoss(pred, target, func(p, t float64) float64 {
+		e := math.Abs(p - t)
+		if e <= h.delta {
+			return e * e / 2
+		}
+		return h.delta * (e - h.delta/2)
+	})
+}
+
+// Grad calculates Huber loss gradient: the error clipped to [-delta, delta]
+func (h Huber) Grad(pred, target mat.Matrix) mat.Matrix {
+	return elemGrad(pred, target, func(p, t float64) float64 {
+		return math.Max(-h.delta, math.Min(h.delta, p-t))
+	})
+}
+
+// Hinge implements Loss interface.
+// Hinge loss treats every output as a one-vs-all classifier with targets mapped to -1 and 1.
+type Hinge struct{}
+
+// Cost implements hinge loss
+// C = sum(sum(max(0, 1 - y.*out)))/samples, y = 2*out_k - 1
+func (h Hinge) Cost(pred, target mat.Matrix) float64 {
+	return elemLoss(pred, target, func(p, t float64) float64 {
+		return math.Max(0, 1-(2*t-1)*p)
+	})
+}
+
+// Grad calculates hinge loss gradient
+// G = -y if y.*out < 1, 0 otherwise
+func (h Hinge) Grad(pred, target mat.Matrix) mat.Matrix {
+	return elemGrad(pred, target, func(p, t float64) float64 {
+		if y := 2*t - 1; y*p < 1 {
+			return -y
+		}
+		return 0.0
+	})
+}
+
+// ClassWeighted implements Loss interface.
+// ClassWeighted scales the loss of every sample by the weight of the sample class.
+// The class of a sample is the index of its largest target value.
+type ClassWeighted struct {
+	// loss is weighted loss
+	loss Loss
+	// weights are class weights
+	weights []float64
+}
+
+// NewClassWeighted creates new ClassWeighted loss which weights the supplied loss by the supplied
+// class weights and returns it. It fails with error if the loss is nil, if no weights are supplied
+// or if any of the weights is negative.
+func NewClassWeighted(loss Loss, weights []float64) (*ClassWeighted, error) {
+	if loss == nil {
+		return nil, fmt.Errorf("Incorrect loss supplied: %v\n", loss)
+	}
+	if len(weights) == 0 {
+		return nil, fmt.Errorf("Class weights can not be empty\n")
+	}
+	for _, w := range weights {
+		if w < 0 {
+			return nil, fmt.Errorf("Incorrect class weight: %f\n", w)
+		}
+	}
+	return &ClassWeighted{
+		loss:    loss,
+		weights: weights,
+	}, nil
+}
+
+// BalancedWeights returns class weights which are inversely proportional to class frequencies
+// in the supplied labels: samples/(classes*count). Labels are expected to start at 1.
+// Classes which are not present in the labels have weight 1.
+func BalancedWeights(labelsVec *mat.VecDense, classes int) []float64 {
+	counts := make([]float64, classes)
+	for i := 0; i < labelsVec.Len(); i++ {
+		if c := int(labelsVec.At(i, 0)) - 1; c >= 0 && c < classes {
+			counts[c]++
+		}
+	}
+	weights := make([]float64, classes)
+	for c, count := range counts {
+		weights[c] = 1.0
+		if count > 0 {
+			weights[c] = float64(labelsVec.Len()) / (float64(classes) * count)
+		}
+	}
+	return weights
+}
+
+// Loss returns weighted loss
+func (c ClassWeighted) Loss() Loss {
+	return c.loss
+}
+
+// Weights returns class weights
+func (c ClassWeighted) Weights() []float64 {
+	return c.weights
+}
+
+// Cost returns the average of the losses of all samples scaled by their class weights
+func (c ClassWeighted) Cost(pred, target mat.Matrix) float64 {
+	rows, cols := pred.Dims()
+	cost := 0.0
+	for i := 0; i < rows; i++ {
+		w := c.weight(target, i)
+		if w == 0 {
+			continue
+		}
+		predRow := mat.NewDense(1, cols, mat.Row(nil, i, pred))
+		targetRow := mat.NewDense(1, cols, mat.Row(nil, i, target))
+		cost += w * c.loss.Cost(predRow, targetRow)
+	}
+	return cost / float64(rows)
+}
+
+// Grad returns the gradient of the weighted loss: the loss gradient of every sample
+// scaled by its class weight
+func (c ClassWeighted) Grad(pred, target mat.Matrix) mat.Matrix {
+	return c.scale(c.loss.Grad(pred, target), target)
+}
+
+// scale scales the rows of the supplied matrix by the class weights of the samples
+func (c ClassWeighted) scale(m, target mat.Matrix) *mat.Dense {
+	scaled := new(mat.Dense)
+	scaled.Apply(func(i, j int, x float64) float64 {
+		return c.weight(target, i) * x
+	}, m)
+	return scaled
+}
+
+// weight returns the class weight of i-th sample
+func (c ClassWeighted) weight(target mat.Matrix, i int) float64 {
+	_, cols := target.Dims()
+	class := 0
+	for j := 1; j < cols; j++ {
+		if target.At(i, j) > target.At(i, class) {
+			class = j
+		}
+	}
+	if class >= len(c.weights) {
+		return 1.0
+	}
+	return c.weights[class]
+}
+
+// LabelSmoothing implements Loss interface.
+// LabelSmoothing replaces one-of-N targets with smoothed targets before calculating the loss:
+// the target class gets 1-eps+eps/N and every other class gets eps/N.
+type LabelSmoothing struct {
+	// loss is loss of smoothed targets
+	loss Loss
+	// eps is smoothing parameter
+	eps float64
+}
+
+// NewLabelSmoothing creates new LabelSmoothing loss which smooths the targets of the supplied loss
+// and returns it. It fails with error if the loss is nil or if eps is not in [0, 1) interval.
+func NewLabelSmoothing(loss Loss, eps float64) (*LabelSmoothing, error) {
+	if loss == nil {
+		return nil, fmt.Errorf("Incorrect loss supplied: %v\n", loss)
+	}
+	if eps < 0 || eps >= 1 {
+		return nil, fmt.Errorf("Incorrect label smoothing: %f\n", eps)
+	}
+	return &LabelSmoothing{
+		loss: loss,
+		eps:  eps,
+	}, nil
+}
+
+// Loss returns the loss of smoothed targets
+func (l LabelSmoothing) Loss() Loss {
+	return l.loss
+}
+
+// Epsilon returns smoothing parameter
+func (l LabelSmoothing) Epsilon() float64 {
+	return l.eps
+}
+
+// Cost returns the loss of smoothed targets
+func (l LabelSmoothing) Cost(pred, target mat.Matrix) float64 {
+	return l.loss.Cost(pred, l.smooth(target))
+}
+
+// Grad returns the loss gradient of smoothed targets
+func (l LabelSmoothing) Grad(pred, target mat.Matrix) mat.Matrix {
+	return l.loss.Grad(pred, l.smooth(target))
+}
+
+// smooth returns smoothed targets
+func (l LabelSmoothing) smooth(target mat.Matrix) *mat.Dense {
+	_, cols := target.Dims()
+	smoothMx := new(mat.Dense)
+	smoothMx.Apply(func(i, j int, t float64) float64 {
+		return (1-l.eps)*t + l.eps/float64(cols)
+	}, target)
+	return smoothMx
+}
+
+// elemLoss sums the supplied element loss over all predictions and averages it over samples
+func elemLoss(pred, target mat.Matrix, loss func(p, t float64) float64) float64 {
+	rows, cols := pred.Dims()
+	sum := 0.0
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			sum += loss(pred.At(i, j), target.At(i, j))
+		}
+	}
+	return sum / float64(rows)
+}
+
+// elemGrad applies the supplied element gradient to all predictions
+func elemGrad(pred, target mat.Matrix, grad func(p, t float64) float64) mat.Matrix {
+	gradMx := new(mat.Dense)
+	gradMx.Apply(func(i, j int, p float64) float64 {
+		return grad(p, target.At(i, j))
+	}, pred)
+	return gradMx
+}
+
+// outputErr calculates the error of the activation inputs of the output layer for the supplied
+// loss. Losses which implement Cost interface calculate the error directly, whereas the gradient
+// of other losses is backpropagated through the output layer activation function.
+func outputErr(loss Loss, layer *Layer, outMx mat.Matrix, actInMx *mat.Dense, labelsMx mat.Matrix) mat.Matrix {
+	// class weights scale the output error of the weighted loss
+	if cw, ok := loss.(*ClassWeighted); ok {
+		return cw.scale(outputErr(cw.loss, layer, outMx, actInMx, labelsMx), labelsMx)
+	}
+	// smoothed targets replace the targets of the smoothed loss
+	if ls, ok := loss.(*LabelSmoothing); ok {
+		return outputErr(ls.loss, layer, outMx, actInMx, ls.smooth(labelsMx))
+	}
+	if cost, ok := loss.(Cost); ok {
+		return cost.Delta(outMx, labelsMx)
+	}
+	gradMx := loss.Grad(outMx, labelsMx)
+	if layer.meta != Softmax {
+		return layer.actInErr(gradMx, actInMx)
+	}
+	// softmax Jacobian: E_i = out_i * (G_i - sum_j(G_j * out_j))
+	rows, cols := outMx.Dims()
+	errMx := mat.NewDense(rows, cols, nil)
+	for i := 0; i < rows; i++ {
+		dot := 0.0
+		for j := 0; j < cols; j++ {
+			dot += gradMx.At(i, j) * outMx.At(i, j)
+		}
+		for j := 0; j < cols; j++ {
+			errMx.Set(i, j, outMx.At(i, j)*(gradMx.At(i, j)-dot))
+		}
+	}
+	return errMx
+}
@@ -0,0 +1,170 @@
+package neural
+
+import (
+	"math"
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestLossCost(t *testing.T) {
+	assert := assert.New(t)
+
+	predMx := mat.NewDense(2, 2, []float64{0.5, -1.0, 3.0, 0.0})
+	targetMx := mat.NewDense(2, 2, []float64{1.0, 0.0, 0.0, 1.0})
+	testCases := []struct {
+		loss Loss
+		cost float64
+		grad []float64
+	}{
+		// errors: -0.5, -1.0, 3.0, -1.0
+		{MSE{}, (0.25 + 1.0 + 9.0 + 1.0) / 4, []float64{-0.5, -1.0, 3.0, -1.0}},
+		{NewHuber(1.0), (0.125 + 0.5 + 2.5 + 0.5) / 2, []float64{-0.5, -1.0, 1.0, -1.0}},
+		// margins: 0.5, 1.0, -3.0, 0.0
+		{Hinge{}, (0.5 + 0.0 + 4.0 + 1.0) / 2, []float64{-1.0, 0.0, 1.0, -1.0}},
+	}
+	for _, tc := range testCases {
+		assert.InDelta(tc.cost, tc.loss.Cost(predMx, targetMx), 1e-9)
+		assert.Equal(tc.grad, tc.loss.Grad(predMx, targetMx).(*mat.Dense).RawMatrix().Data)
+	}
+	assert.Equal(NewHuber(-1.0).Delta(), 1.0)
+	assert.Equal(NewHuber(0.5).Delta(), 0.5)
+}
+
+func TestCostLoss(t *testing.T) {
+	assert := assert.New(t)
+
+	outMx := mat.NewDense(2, 3, []float64{0.2, 0.3, 0.5, 0.6, 0.3, 0.1})
+	labelsMx := mat.NewDense(2, 3, []float64{0.0, 0.0, 1.0, 1.0, 0.0, 0.0})
+	for _, cost := range []Cost{CrossEntropy{}, LogLikelihood{}} {
+		loss := cost.(Loss)
+		// Loss cost matches Cost function and does not modify its input
+		oMx, lMx := new(mat.Dense), new(mat.Dense)
+		oMx.CloneFrom(outMx)
+		lMx.CloneFrom(labelsMx)
+		assert.InDelta(cost.CostFunc(outMx, oMx, lMx), loss.Cost(outMx, labelsMx), 1e-9)
+		assert.Equal(outMx.At(0, 0), 0.2)
+		assert.Equal(labelsMx.At(0, 2), 1.0)
+		// loss gradient matches its numerical approximation
+		grad := loss.Grad(outMx, labelsMx)
+		eps := 1e-6
+		for i := 0; i < 2; i++ {
+			for j := 0; j < 3; j++ {
+				pMx, mMx := new(mat.Dense), new(mat.Dense)
+				pMx.CloneFrom(outMx)
+				mMx.CloneFrom(outMx)
+				pMx.Set(i, j, outMx.At(i, j)+eps)
+				mMx.Set(i, j, outMx.At(i, j)-eps)
+				numGrad := 2 * (loss.Cost(pMx, labelsMx) - loss.Cost(mMx, labelsMx)) / (2 * eps)
+				assert.InDelta(numGrad, grad.At(i, j), 1e-4)
+			}
+		}
+	}
+}
+
+func TestLossGradient(t *testing.T) {
+	assert := assert.New(t)
+
+	testCases := []*config.TrainConfig{
+		{Cost: "mse", Lambda: 0.1},
+		{Cost: "huber", Lambda: 0.1},
+		{Cost: "hinge", Lambda: 0.1},
+		{Cost: "loglike", Lambda: 0.1, ClassWeights: []float64{1.0, 2.0, 3.0, 0.5, 0.0}},
+		{Cost: "mse", Balanced: true},
+		{Cost: "loglike", LabelSmoothing: 0.1},
+		{Cost: "loglike", LabelSmoothing: 0.2, ClassWeights: []float64{1.0, 2.0, 3.0, 0.5, 0.0}},
+	}
+	for _, c := range testCases {
+		n, err := NewFeedForward(4, []int{5}, 5)
+		assert.NoError(err)
+		weights := netWeights(n.trainLayers())
+		grad, err := n.getGradient(c, weights, inMx, labelsVec)
+		assert.NoError(err)
+		// gradient matches its numerical approximation
+		eps := 1e-5
+		for i := range weights {
+			w := weights[i]
+			weights[i] = w + eps
+			costPlus, err := n.getCost(c, weights, inMx, labelsVec)
+			assert.NoError(err)
+			weights[i] = w - eps
+			costMinus, err := n.getCost(c, weights, inMx, labelsVec)
+			assert.NoError(err)
+			weights[i] = w
+			assert.True(math.Abs(grad[i]-(costPlus-costMinus)/(2*eps)) < 1e-6, c.Cost)
+		}
+	}
+}
+
+func TestTrainerLoss(t *testing.T) {
+	assert := assert.New(t)
+
+	tr, err := NewTrainer(newTrainerConfig())
+	assert.NoError(err)
+	assert.Equal(tr.Loss(), LogLikelihood{})
+	assert.Error(tr.SetLoss(nil))
+	assert.NoError(tr.SetLoss(MSE{}))
+	assert.Equal(tr.Loss(), MSE{})
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	before, err := n.lossCost(MSE{}, penalty{}, inMx, labelsVec)
+	assert.NoError(err)
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+	after, err := n.lossCost(MSE{}, penalty{}, inMx, labelsVec)
+	assert.NoError(err)
+	assert.True(after < before)
+}
+
+func TestClassWeighted(t *testing.T) {
+	assert := assert.New(t)
+
+	cw, err := NewClassWeighted(nil, []float64{1.0})
+	assert.Nil(cw)
+	assert.Error(err)
+	cw, err = NewClassWeighted(MSE{}, nil)
+	assert.Nil(cw)
+	assert.Error(err)
+	cw, err = NewClassWeighted(MSE{}, []float64{1.0, -1.0})
+	assert.Nil(cw)
+	assert.Error(err)
+	cw, err = NewClassWeighted(MSE{}, []float64{2.0, 0.5})
+	assert.NoError(err)
+	assert.Equal(cw.Loss(), MSE{})
+	assert.Equal(cw.Weights(), []float64{2.0, 0.5})
+	predMx := mat.NewDense(2, 2, []float64{0.0, 0.0, 0.0, 0.0})
+	targetMx := mat.NewDense(2, 2, []float64{1.0, 0.0, 0.0, 1.0})
+	// sample losses are 0.5 and 0.5
+	assert.InDelta((2.0*0.5+0.5*0.5)/2, cw.Cost(predMx, targetMx), 1e-9)
+	assert.Equal([]float64{-2.0, 0.0, 0.0, -0.5}, cw.Grad(predMx, targetMx).(*mat.Dense).RawMatrix().Data)
+}
+
+func TestBalancedWeights(t *testing.T) {
+	assert := assert.New(t)
+
+	labels := mat.NewVecDense(6, []float64{1, 1, 1, 1, 2, 2})
+	// the 3rd class is not present in the labels
+	assert.Equal([]float64{0.5, 1.0, 1.0}, BalancedWeights(labels, 3))
+}
+
+func TestLabelSmoothing(t *testing.T) {
+	assert := assert.New(t)
+
+	ls, err := NewLabelSmoothing(nil, 0.1)
+	assert.Nil(ls)
+	assert.Error(err)
+	ls, err = NewLabelSmoothing(MSE{}, 1.0)
+	assert.Nil(ls)
+	assert.Error(err)
+	ls, err = NewLabelSmoothing(MSE{}, 0.2)
+	assert.NoError(err)
+	assert.Equal(ls.Loss(), MSE{})
+	assert.Equal(ls.Epsilon(), 0.2)
+	// smoothed targets: 0.9 and 0.1
+	predMx := mat.NewDense(1, 2, []float64{0.9, 0.1})
+	targetMx := mat.NewDense(1, 2, []float64{1.0, 0.0})
+	assert.InDelta(0.0, ls.Cost(predMx, targetMx), 1e-9)
+	assert.True(mat.EqualApprox(ls.Grad(predMx, targetMx), mat.NewDense(1, 2, nil), 1e-9))
+	assert.Equal(targetMx.At(0, 0), 1.0)
+}
@@ -0,0 +1,154 @@
+package neural
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Curriculum selects the training samples of every epoch of mini-batch training, which allows
+// to present easy samples first and the hard ones later. Trainer consults its Curriculum before
+// every epoch. Curriculum which implements Callback receives training events after all the Trainer
+// callbacks, so it can adapt the selection to the training progress.
+type Curriculum interface {
+	// Samples returns indices of the training samples used in the given epoch counted from 1.
+	// Indices can repeat to oversample some of the samples. nil selects all the training samples.
+	// The samples are passed in the returned order unless the training shuffles the data.
+	Samples(epoch int) []int
+}
+
+// CurriculumFunc allows to use ordinary functions as custom curricula
+type CurriculumFunc func(epoch int) []int
+
+// Samples returns samples selected by f
+func (f CurriculumFunc) Samples(epoch int) []int {
+	return f(epoch)
+}
+
+// Stages is Curriculum which passes through a sequence of training data subsets.
+// Every stage lasts the same number of epochs and the last stage lasts until the training ends.
+type Stages struct {
+	// epochs is a number of epochs of every stage
+	epochs int
+	// stages contains sample indices of all stages
+	stages [][]int
+}
+
+// NewStages creates new Stages curriculum with the supplied sample indices of every stage and returns it.
+// It fails with error if epochs is not positive or if there are no stages or any of the stages is empty.
+func NewStages(epochs int, stages ...[]int) (*Stages, error) {
+	if epochs <= 0 {
+		return nil, fmt.Errorf("Incorrect number of epochs per stage: %d\n", epochs)
+	}
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("No curriculum stages supplied\n")
+	}
+	for i, stage := range stages {
+		if len(stage) == 0 {
+			return nil, fmt.Errorf("Empty curriculum stage: %d\n", i)
+		}
+	}
+	return &Stages{
+		epochs: epochs,
+		stages: stages,
+	}, nil
+}
+
+// Samples returns the samples of the stage of the given epoch
+func (s Stages) Samples(epoch int) []int {
+	stage := (epoch - 1) / s.epochs
+	if stage >= len(s.stages) {
+		stage = len(s.stages) - 1
+	}
+	return s.stages[stage]
+}
+
+// Pacing is Curriculum which orders the training samples from easy to hard by their difficulty
+// and trains on a growing fraction of the easiest samples. The fraction grows by a constant step
+// whenever the training cost has not improved for the given number of epochs.
+type Pacing struct {
+	// order contains sample indices ordered from the easiest to the hardest sample
+	order []int
+	// start is the initial fraction of samples
+	start float64
+	// step is fraction increment
+	step float64
+	// patience is a number of epochs without improvement after which the fraction grows
+	patience int
+	// fraction is the current fraction of samples
+	fraction float64
+	// best is the best training cost of the current fraction
+	best float64
+	// wait is a number of epochs without improvement
+	wait int
+}
+
+// NewPacing creates new Pacing curriculum which trains on start fraction of the easiest samples first.
+// difficulty contains difficulty of every training sample: the higher the value, the harder the sample.
+// It fails with error if there are no samples, if start or step are not in (0, 1] or if patience is not positive.
+func NewPacing(difficulty []float64, start, step float64, patience int) (*Pacing, error) {
+	if len(difficulty) == 0 {
+		return nil, fmt.Errorf("No sample difficulties supplied\n")
+	}
+	if start <= 0 || start > 1 || step <= 0 || step > 1 {
+		return nil, fmt.Errorf("Incorrect pacing. Start: %f, Step: %f\n", start, step)
+	}
+	if patience <= 0 {
+		return nil, fmt.Errorf("Incorrect pacing patience: %d\n", patience)
+	}
+	order := make([]int, len(difficulty))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return difficulty[order[i]] < difficulty[order[j]]
+	})
+	p := &Pacing{
+		order:    order,
+		start:    start,
+		step:     step,
+		patience: patience,
+	}
+	p.OnTrainBegin()
+	return p, nil
+}
+
+// Fraction returns the current fraction of the training samples
+func (p Pacing) Fraction() float64 {
+	return p.fraction
+}
+
+// Samples returns the current fraction of the easiest samples ordered from easy to hard
+func (p Pacing) Samples(epoch int) []int {
+	count := int(math.Ceil(p.fraction * float64(len(p.order))))
+	return p.order[:count]
+}
+
+// OnTrainBegin resets the fraction to the start fraction
+func (p *Pacing) OnTrainBegin() error {
+	p.fraction, p.best, p.wait = p.start, math.Inf(1), 0
+	return nil
+}
+
+// OnEpochEnd grows the fraction if the training cost has not improved for patience epochs
+func (p *Pacing) OnEpochEnd(epoch int, m Metrics) error {
+	if cost := m["cost"]; cost < p.best {
+		p.best, p.wait = cost, 0
+		return nil
+	}
+	if p.wait++; p.wait >= p.patience && p.fraction < 1 {
+		p.fraction = math.Min(p.fraction+p.step, 1)
+		p.best, p.wait = math.Inf(1), 0
+	}
+	return nil
+}
+
+// OnBatchEnd does nothing
+func (p *Pacing) OnBatchEnd(epoch, batch int, m Metrics) error {
+	return nil
+}
+
+// OnTrainEnd does nothing
+func (p *Pacing) OnTrainEnd(m Metrics) error {
+	return nil
+}
@@ -0,0 +1,116 @@
+package neural
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStages(t *testing.T) {
+	assert := assert.New(t)
+
+	// incorrect stages
+	s, err := NewStages(0, []int{0})
+	assert.Nil(s)
+	assert.Error(err)
+	s, err = NewStages(2)
+	assert.Nil(s)
+	assert.Error(err)
+	s, err = NewStages(2, []int{0}, []int{})
+	assert.Nil(s)
+	assert.Error(err)
+	// every stage lasts 2 epochs and the last stage lasts forever
+	s, err = NewStages(2, []int{0}, []int{0, 1})
+	assert.NotNil(s)
+	assert.NoError(err)
+	assert.Equal([]int{0}, s.Samples(1))
+	assert.Equal([]int{0}, s.Samples(2))
+	assert.Equal([]int{0, 1}, s.Samples(3))
+	assert.Equal([]int{0, 1}, s.Samples(10))
+}
+
+func TestPacing(t *testing.T) {
+	assert := assert.New(t)
+
+	// incorrect pacing
+	p, err := NewPacing(nil, 0.5, 0.5, 1)
+	assert.Nil(p)
+	assert.Error(err)
+	p, err = NewPacing([]float64{1.0}, 0.0, 0.5, 1)
+	assert.Nil(p)
+	assert.Error(err)
+	p, err = NewPacing([]float64{1.0}, 0.5, 1.5, 1)
+	assert.Nil(p)
+	assert.Error(err)
+	p, err = NewPacing([]float64{1.0}, 0.5, 0.5, 0)
+	assert.Nil(p)
+	assert.Error(err)
+	// samples are ordered from easy to hard
+	p, err = NewPacing([]float64{0.3, 0.1, 0.4, 0.2}, 0.5, 0.25, 2)
+	assert.NotNil(p)
+	assert.NoError(err)
+	assert.Equal([]int{1, 3}, p.Samples(1))
+	// fraction grows when cost stops improving
+	assert.NoError(p.OnEpochEnd(1, Metrics{"cost": 1.0}))
+	assert.NoError(p.OnEpochEnd(2, Metrics{"cost": 0.5}))
+	assert.NoError(p.OnEpochEnd(3, Metrics{"cost": 0.6}))
+	assert.Equal(0.5, p.Fraction())
+	assert.NoError(p.OnEpochEnd(4, Metrics{"cost": 0.5}))
+	assert.Equal(0.75, p.Fraction())
+	assert.Equal([]int{1, 3, 0}, p.Samples(5))
+	for epoch := 5; epoch < 15; epoch++ {
+		assert.NoError(p.OnEpochEnd(epoch, Metrics{"cost": 1.0}))
+	}
+	assert.Equal(1.0, p.Fraction())
+	assert.Equal([]int{1, 3, 0, 2}, p.Samples(15))
+	// training starts with the start fraction
+	assert.NoError(p.OnTrainBegin())
+	assert.Equal(0.5, p.Fraction())
+}
+
+// callbackCurriculum is Curriculum which receives training events
+type callbackCurriculum struct {
+	CurriculumFunc
+	CallbackFuncs
+}
+
+func TestTrainerCurriculum(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	c.Epochs = 4
+	tr, err := NewTrainer(c)
+	assert.NoError(err)
+	assert.Nil(tr.Curriculum())
+	r := &recordOptim{}
+	assert.NoError(tr.SetOptimizer(r))
+	// the curriculum is consulted before every epoch and receives training events
+	var epochs, ends []int
+	cur := callbackCurriculum{
+		CurriculumFunc: func(epoch int) []int {
+			epochs = append(epochs, epoch)
+			if epoch <= 2 {
+				return []int{0, 1}
+			}
+			return nil
+		},
+		CallbackFuncs: CallbackFuncs{
+			EpochEnd: func(epoch int, m Metrics) error {
+				ends = append(ends, epoch)
+				return nil
+			},
+		},
+	}
+	tr.SetCurriculum(cur)
+	assert.NotNil(tr.Curriculum())
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+	assert.Equal([]int{1, 2, 3, 4}, epochs)
+	assert.Equal([]int{1, 2, 3, 4}, ends)
+	// 1 mini-batch in the first 2 epochs and 3 mini-batches in the others
+	assert.Len(r.grads, 8*len(n.trainLayers()))
+	// incorrect samples
+	tr.SetCurriculum(CurriculumFunc(func(epoch int) []int { return []int{5} }))
+	assert.Error(tr.Train(n, inMx, labelsVec))
+}
@@ -0,0 +1,56 @@
+package neural
+
+import (
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Device returns the device which multiplies network matrices
+func (n *Network) Device() matrix.Device {
+	if n.device == nil {
+		return matrix.CPU
+	}
+	return n.device
+}
+
+// SetDevice sets the device which multiplies the matrices of forward propagation and backpropagation
+// of all network layers, including the layers of input branches and heads and the layers added to the
+// network later on. nil device resets the network to the default CPU device.
+func (n *Network) SetDevice(d matrix.Device) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.device = d
+	n.applyPrecision()
+}
+
+// mul stores the product of matrices a and b in dst using the layer device
+func (l *Layer) mul(dst *mat.Dense, a, b mat.Matrix) {
+	if l.device == nil {
+		dst.Mul(a, b)
+		return
+	}
+	l.device.Mul(dst, a, b)
+}
+
+// deviceActivate applies layer activation function to actInMx on the layer device and stores the result
+// in dst if the device implements matrix.Activator and supports the function. Fast activations are
+// always approximated on CPU. It returns false if the activation was not applied.
+func (l *Layer) deviceActivate(dst, actInMx *mat.Dense) bool {
+	a, ok := l.device.(matrix.Activator)
+	if !ok {
+		return false
+	}
+	if _, fast := fastActivations[l.meta]; fast && l.fast && !l.training {
+		return false
+	}
+	return a.Activate(dst, l.activationName(), actInMx)
+}
+
+// activationName returns the name of layer activation function passed to matrix.Activator:
+// tanh of OUTPUT layers is rescaled, so it's named tanhout
+func (l *Layer) activationName() string {
+	if l.meta == Tanh && l.kind == OUTPUT {
+		return "tanhout"
+	}
+	return l.meta
+}
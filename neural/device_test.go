@@ -0,0 +1,114 @@
+package neural
+
+import (
+	"math"
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// countDevice is CPU device which counts matrix multiplications
+type countDevice struct {
+	muls int
+}
+
+func (d *countDevice) Name() string {
+	return "count"
+}
+
+func (d *countDevice) Mul(dst *mat.Dense, a, b mat.Matrix) {
+	d.muls++
+	dst.Mul(a, b)
+}
+
+func TestDevice(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NotNil(n)
+	assert.NoError(err)
+	assert.Equal(matrix.CPU, n.Device())
+	expOut, err := n.ForwardProp(inMx, 2)
+	assert.NoError(err)
+	d := &countDevice{}
+	n.SetDevice(d)
+	assert.Equal(d, n.Device())
+	// forward propagation multiplies on the device
+	out, err := n.ForwardProp(inMx, 2)
+	assert.NoError(err)
+	assert.True(mat.Equal(expOut, out))
+	assert.Equal(2, d.muls)
+	// backpropagation multiplies on the device
+	errMx := mat.NewDense(5, 5, nil)
+	assert.NoError(n.BackProp(inMx, errMx, 2))
+	assert.True(d.muls > 2)
+	// layers added later and clones use the device
+	assert.NoError(n.AddLayer(newTestLayer("hidden", 3, Tanh)))
+	assert.Equal(d, n.Layers()[2].device)
+	assert.Equal(d, n.Clone().Device())
+	n.SetDevice(nil)
+	assert.Equal(matrix.CPU, n.Device())
+}
+
+// activDevice is CPU device which applies sigmoid and tanh activations the way
+// matrix.Activator requires and counts the activations
+type activDevice struct {
+	countDevice
+	acts int
+}
+
+func (d *activDevice) Activate(dst *mat.Dense, activation string, a mat.Matrix) bool {
+	act, ok := map[string]func(int, int, float64) float64{
+		"sigmoid": matrix.SigmoidMx,
+		"tanh":    matrix.TanhMx,
+		"tanhout": func(i, j int, x float64) float64 { return 0.5 * (math.Tanh(x) + 1.0) },
+	}[activation]
+	if !ok {
+		return false
+	}
+	d.acts++
+	dst.Apply(act, a)
+	return true
+}
+
+func TestDeviceActivate(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NotNil(n)
+	assert.NoError(err)
+	expOut, err := n.ForwardProp(inMx, 2)
+	assert.NoError(err)
+	d := &activDevice{}
+	n.SetDevice(d)
+	// hidden sigmoid layer is activated on the device, softmax output layer on CPU
+	out, err := n.ForwardProp(inMx, 2)
+	assert.NoError(err)
+	assert.True(mat.EqualApprox(expOut, out, 1e-12))
+	assert.Equal(1, d.acts)
+	// fast sigmoid is approximated on CPU
+	n.SetFastActivation(true)
+	_, err = n.ForwardProp(inMx, 2)
+	assert.NoError(err)
+	assert.Equal(1, d.acts)
+}
+
+func TestDeviceActivateTanhOut(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewBuilder().Input(4).Hidden(5, Tanh).Output(5, Tanh).Build()
+	assert.NotNil(n)
+	assert.NoError(err)
+	expOut, err := n.ForwardProp(inMx, 2)
+	assert.NoError(err)
+	d := &activDevice{}
+	n.SetDevice(d)
+	// both hidden and rescaled output tanh layers are activated on the device
+	out, err := n.ForwardProp(inMx, 2)
+	assert.NoError(err)
+	assert.Equal(2, d.acts)
+	assert.True(mat.EqualApprox(expOut, out, 1e-12))
+	assert.True(mat.Min(out) >= 0.0)
+}
@@ -0,0 +1,137 @@
+package neural
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// encryptedMagic starts every encrypted network
+var encryptedMagic = []byte("GONE")
+
+// encryptedExt is extension of encrypted network files which is ignored when selecting network format
+const encryptedExt = ".enc"
+
+// newGCM creates AES-GCM cipher with the supplied key. It fails with error if the key is not
+// 16, 24 or 32 bytes long, which selects AES-128, AES-192 or AES-256.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("Incorrect encryption key: %v\n", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptedHeader returns the header of encrypted network which is authenticated along with the network
+func encryptedHeader() []byte {
+	header := new(bytes.Buffer)
+	header.Write(encryptedMagic)
+	binary.Write(header, binary.BigEndian, formatVersion)
+	return header.Bytes()
+}
+
+// WriteEncryptedNetwork writes the supplied network to the supplied writer in the supplied format
+// encrypted by AES-GCM with the supplied key, so proprietary weights can be shipped to devices
+// which are not trusted. The key must be 16, 24 or 32 bytes long to select AES-128, AES-192 or
+// AES-256. Encrypted network starts with magic bytes and format version followed by random nonce
+// and the encrypted network written by WriteNetwork. It returns error if the key is incorrect or
+// if the network can't be written.
+func WriteEncryptedNetwork(w io.Writer, n *Network, f Format, key []byte) error {
+	return writeEncrypted(w, key, func(w io.Writer) error {
+		return WriteNetwork(w, n, f)
+	})
+}
+
+// writeEncrypted writes the data written by the supplied write function encrypted with the supplied key
+func writeEncrypted(w io.Writer, key []byte, write func(w io.Writer) error) error {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	var plain bytes.Buffer
+	if err := write(&plain); err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	header := encryptedHeader()
+	data := append(header, nonce...)
+	data = gcm.Seal(data, nonce, plain.Bytes(), header)
+	_, err = w.Write(data)
+	return err
+}
+
+// ReadEncryptedNetwork reads the network encrypted by WriteEncryptedNetwork from the supplied reader,
+// decrypts it with the supplied key and returns it. It returns error if the data are not encrypted
+// network, if the format version is not supported, if the key is incorrect or if the data can't be
+// decrypted, which means either the key is wrong or the data are corrupted.
+func ReadEncryptedNetwork(r io.Reader, key []byte) (*Network, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	header := encryptedHeader()
+	if len(data) < len(header)+gcm.NonceSize() || !bytes.Equal(data[:len(encryptedMagic)], encryptedMagic) {
+		return nil, fmt.Errorf("Network is not encrypted\n")
+	}
+	if err := checkVersion(binary.BigEndian.Uint16(data[len(encryptedMagic):])); err != nil {
+		return nil, err
+	}
+	nonce := data[len(header) : len(header)+gcm.NonceSize()]
+	plain, err := gcm.Open(nil, nonce, data[len(header)+gcm.NonceSize():], header)
+	if err != nil {
+		return nil, fmt.Errorf("Network can't be decrypted: wrong key or corrupted data\n")
+	}
+	return ReadNetwork(bytes.NewReader(plain))
+}
+
+// SaveEncryptedNetwork saves the supplied network to the file with the supplied path encrypted by
+// AES-GCM with the supplied key. The network format and compression are selected by the file extension
+// the same way as by SaveNetwork, with .enc extension being ignored: net.bin.enc is encrypted network
+// in Binary format. It returns error if the network is nil, if the key is incorrect or if it fails
+// to write the file.
+func SaveEncryptedNetwork(path string, n *Network, key []byte) error {
+	if n == nil {
+		return fmt.Errorf("Incorrect network supplied: %v\n", n)
+	}
+	plainPath := path
+	if strings.ToLower(filepath.Ext(path)) == encryptedExt {
+		plainPath = strings.TrimSuffix(path, filepath.Ext(path))
+	}
+	f, compress := fileFormat(plainPath)
+	return writeFile(path, func(w io.Writer) error {
+		return writeEncrypted(w, key, gzipWrite(compress, func(w io.Writer) error {
+			return WriteNetwork(w, n, f)
+		}))
+	})
+}
+
+// LoadEncryptedNetwork loads the network encrypted by SaveEncryptedNetwork from the file with
+// the supplied path, decrypts it with the supplied key and returns it. It returns error if the file
+// can't be read or decrypted or if it does not contain a valid network.
+func LoadEncryptedNetwork(path string, key []byte) (*Network, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	n, err := ReadEncryptedNetwork(f, key)
+	if err != nil {
+		return nil, fmt.Errorf("Incorrect network file %s: %v\n", path, err)
+	}
+	return n, nil
+}
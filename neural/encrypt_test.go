@@ -0,0 +1,76 @@
+package neural
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteEncryptedNetwork(t *testing.T) {
+	assert := assert.New(t)
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	n, err := newModelNetwork()
+	assert.NoError(err)
+	for _, f := range []Format{JSON, Binary} {
+		var buf bytes.Buffer
+		assert.NoError(WriteEncryptedNetwork(&buf, n, f, key))
+		data := buf.Bytes()
+		assert.Equal(encryptedMagic, data[:len(encryptedMagic)])
+		loaded, err := ReadEncryptedNetwork(bytes.NewReader(data), key)
+		assert.NoError(err)
+		assert.Equal(n.Params(), loaded.Params())
+		// wrong key
+		_, err = ReadEncryptedNetwork(bytes.NewReader(data), []byte("0123456789abcdef0123456789abcdeF"))
+		assert.Error(err)
+		// corrupted data
+		corrupted := append([]byte{}, data...)
+		corrupted[len(corrupted)-1] ^= 1
+		_, err = ReadEncryptedNetwork(bytes.NewReader(corrupted), key)
+		assert.Error(err)
+		// encrypted network can't be read without the key
+		_, err = ReadNetwork(bytes.NewReader(data))
+		assert.Error(err)
+	}
+	// nonce is random
+	var a, b bytes.Buffer
+	assert.NoError(WriteEncryptedNetwork(&a, n, Binary, key))
+	assert.NoError(WriteEncryptedNetwork(&b, n, Binary, key))
+	assert.NotEqual(a.Bytes(), b.Bytes())
+	// incorrect key size, plain network and nil network
+	var buf bytes.Buffer
+	assert.Error(WriteEncryptedNetwork(&buf, n, Binary, []byte("foo")))
+	assert.NoError(WriteNetwork(&buf, n, Binary))
+	_, err = ReadEncryptedNetwork(&buf, key)
+	assert.Error(err)
+	_, err = ReadEncryptedNetwork(bytes.NewReader(nil), []byte("foo"))
+	assert.Error(err)
+	assert.Error(WriteEncryptedNetwork(&buf, nil, Binary, key))
+}
+
+func TestSaveEncryptedNetwork(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "encrypted")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	key := []byte("0123456789abcdef")
+	n, err := newModelNetwork()
+	assert.NoError(err)
+	assert.Error(SaveEncryptedNetwork(filepath.Join(dir, "net.enc"), nil, key))
+	for _, name := range []string{"net.enc", "net.bin.enc", "net.json.gz.enc"} {
+		path := filepath.Join(dir, name)
+		assert.NoError(SaveEncryptedNetwork(path, n, key))
+		loaded, err := LoadEncryptedNetwork(path, key)
+		assert.NoError(err)
+		assert.Equal(n.Params(), loaded.Params())
+		_, err = LoadNetwork(path)
+		assert.Error(err)
+	}
+	_, err = LoadEncryptedNetwork(filepath.Join(dir, "foo.enc"), key)
+	assert.Error(err)
+}
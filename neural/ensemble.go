@@ -0,0 +1,195 @@
+package neural
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Combine defines how ensemble combines classifications of its networks
+type Combine uint
+
+const (
+	// Average averages class probabilities of all ensemble networks
+	Average Combine = iota
+	// Vote picks the class predicted by the majority of ensemble networks
+	Vote
+)
+
+// String implements Stringer interface for pretty printing
+func (c Combine) String() string {
+	switch c {
+	case Average:
+		return "average"
+	case Vote:
+		return "vote"
+	}
+	return "unknown"
+}
+
+// Ensemble is a collection of trained neural networks which classify data together
+type Ensemble struct {
+	// combine is the method used to combine networks classifications
+	combine Combine
+	// nets are ensemble networks
+	nets []*Network
+}
+
+// NewEnsemble creates new ensemble of the supplied networks which combines their classifications
+// using the requested combine method. It fails with error if no networks are supplied, if any of
+// the networks is nil, if the networks have different number of outputs or if the combine method
+// is not supported.
+func NewEnsemble(combine Combine, nets ...*Network) (*Ensemble, error) {
+	if combine != Average && combine != Vote {
+		return nil, fmt.Errorf("Unsupported combine method: %s\n", combine)
+	}
+	if len(nets) == 0 {
+		return nil, fmt.Errorf("Ensemble must contain at least one network\n")
+	}
+	outs := -1
+	for i, net := range nets {
+		if net == nil || len(net.Layers()) == 0 {
+			return nil, fmt.Errorf("Incorrect network supplied: %d\n", i)
+		}
+		layers := net.Layers()
+		out := layers[len(layers)-1].OutSize()
+		if outs != -1 && out != outs {
+			return nil, fmt.Errorf("Network outputs mismatch. Expected: %d, Network %d: %d\n", outs, i, out)
+		}
+		outs = out
+	}
+	return &Ensemble{
+		combine: combine,
+		nets:    nets,
+	}, nil
+}
+
+// Networks returns ensemble networks
+func (e Ensemble) Networks() []*Network {
+	return e.nets
+}
+
+// Combine returns the method used to combine networks classifications
+func (e Ensemble) Combine() Combine {
+	return e.combine
+}
+
+// Classify classifies the provided data using all ensemble networks. It returns a matrix which
+// contains probabilities of the input belonging to a particular class expressed in percents.
+// Average combine method averages the probabilities returned by all the networks, Vote combine method
+// returns the percentage of the networks which voted for the particular class.
+// It returns error if any of the networks fails to classify the data.
+func (e *Ensemble) Classify(inMx mat.Matrix) (mat.Matrix, error) {
+	if inMx == nil {
+		return nil, fmt.Errorf("Can't classify %v\n", inMx)
+	}
+	var classMx *mat.Dense
+	for _, net := range e.nets {
+		out, err := net.Classify(inMx)
+		if err != nil {
+			return nil, err
+		}
+		rows, cols := out.Dims()
+		if classMx == nil {
+			classMx = mat.NewDense(rows, cols, nil)
+		}
+		if e.combine == Average {
+			classMx.Add(classMx, out)
+			continue
+		}
+		// every network votes for its most probable class
+		for i := 0; i < rows; i++ {
+			j := maxCol(out, i)
+			classMx.Set(i, j, classMx.At(i, j)+100.0)
+		}
+	}
+	classMx.Scale(1/float64(len(e.nets)), classMx)
+	return classMx, nil
+}
+
+// Predict classifies the provided data using all ensemble networks and returns the predicted class
+// labels. Class labels start at 1 the same way as the labels used for the network training.
+// It returns error if the classification fails.
+func (e *Ensemble) Predict(inMx mat.Matrix) (*mat.VecDense, error) {
+	classMx, err := e.Classify(inMx)
+	if err != nil {
+		return nil, err
+	}
+	rows, _ := classMx.Dims()
+	labels := mat.NewVecDense(rows, nil)
+	for i := 0; i < rows; i++ {
+		labels.SetVec(i, float64(maxCol(classMx, i)+1))
+	}
+	return labels, nil
+}
+
+// Bagging creates count networks per the supplied network configuration, trains each of them
+// on a bootstrap sample of the supplied data and returns an ensemble of the trained networks.
+// Bootstrap sample contains the same number of samples as the supplied data which are drawn
+// with replacement. Non-zero seeds of the supplied configurations make the bagging deterministic:
+// the networks are then initialized with consecutive seeds starting at network configuration Seed.
+// It fails with error if count is not a positive integer or if any of the
+// networks fails to be created or trained.
+func Bagging(combine Combine, count int, netConf *config.NetConfig, trainConf *config.TrainConfig,
+	inMx *mat.Dense, labelsVec *mat.VecDense) (*Ensemble, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("Incorrect number of networks: %d\n", count)
+	}
+	if inMx == nil || labelsVec == nil {
+		return nil, fmt.Errorf("Incorrect data supplied. In: %v, Labels: %v\n", inMx, labelsVec)
+	}
+	samples, cols := inMx.Dims()
+	if labelsVec.Len() != samples {
+		return nil, fmt.Errorf("Labels mismatch. Samples: %d, Labels: %d\n", samples, labelsVec.Len())
+	}
+	var seed int64
+	if trainConf != nil {
+		seed = trainConf.Seed
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+	nets := make([]*Network, count)
+	for n := range nets {
+		// networks must not be initialized with the same weights
+		conf := netConf
+		if netConf != nil && netConf.Seed != 0 {
+			c := *netConf
+			c.Seed += int64(n)
+			conf = &c
+		}
+		net, err := NewNetwork(conf)
+		if err != nil {
+			return nil, err
+		}
+		// draw bootstrap sample
+		bagInMx := mat.NewDense(samples, cols, nil)
+		bagLabels := mat.NewVecDense(samples, nil)
+		for i := 0; i < samples; i++ {
+			j := rng.Intn(samples)
+			bagInMx.SetRow(i, inMx.RawRowView(j))
+			bagLabels.SetVec(i, labelsVec.At(j, 0))
+		}
+		if err := net.Train(trainConf, bagInMx, bagLabels); err != nil {
+			return nil, err
+		}
+		nets[n] = net
+	}
+	return NewEnsemble(combine, nets...)
+}
+
+// maxCol returns the index of the column which holds the largest value in the row i
+func maxCol(m mat.Matrix, i int) int {
+	_, cols := m.Dims()
+	max := 0
+	for j := 1; j < cols; j++ {
+		if m.At(i, j) > m.At(i, max) {
+			max = j
+		}
+	}
+	return max
+}
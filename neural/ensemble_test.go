@@ -0,0 +1,122 @@
+package neural
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestNewEnsemble(t *testing.T) {
+	assert := assert.New(t)
+
+	netA, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	netB, err := NewFeedForward(4, []int{3}, 5)
+	assert.NoError(err)
+	netC, err := NewFeedForward(4, nil, 3)
+	assert.NoError(err)
+	// incorrect combine method
+	e, err := NewEnsemble(Combine(10), netA)
+	assert.Nil(e)
+	assert.Error(err)
+	// no networks
+	e, err = NewEnsemble(Average)
+	assert.Nil(e)
+	assert.Error(err)
+	// nil network
+	e, err = NewEnsemble(Average, netA, nil)
+	assert.Nil(e)
+	assert.Error(err)
+	// outputs mismatch
+	e, err = NewEnsemble(Average, netA, netC)
+	assert.Nil(e)
+	assert.Error(err)
+	e, err = NewEnsemble(Vote, netA, netB)
+	assert.NotNil(e)
+	assert.NoError(err)
+	assert.Equal(e.Combine(), Vote)
+	assert.Equal(e.Networks(), []*Network{netA, netB})
+}
+
+func TestEnsembleClassify(t *testing.T) {
+	assert := assert.New(t)
+
+	netA, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	netB, err := NewFeedForward(4, []int{3}, 5)
+	assert.NoError(err)
+	classA, err := netA.Classify(inMx)
+	assert.NoError(err)
+	classB, err := netB.Classify(inMx)
+	assert.NoError(err)
+	// average of probabilities
+	e, err := NewEnsemble(Average, netA, netB)
+	assert.NoError(err)
+	classMx, err := e.Classify(nil)
+	assert.Nil(classMx)
+	assert.Error(err)
+	classMx, err = e.Classify(inMx)
+	assert.NoError(err)
+	expMx := new(mat.Dense)
+	expMx.Add(classA, classB)
+	expMx.Scale(0.5, expMx)
+	assert.True(mat.EqualApprox(classMx, expMx, 1e-9))
+	labels, err := e.Predict(inMx)
+	assert.NoError(err)
+	assert.Equal(labels.Len(), 5)
+	for i := 0; i < labels.Len(); i++ {
+		assert.Equal(labels.At(i, 0), float64(maxCol(expMx, i)+1))
+	}
+	// majority vote
+	e, err = NewEnsemble(Vote, netA, netA, netB)
+	assert.NoError(err)
+	classMx, err = e.Classify(inMx)
+	assert.NoError(err)
+	for i := 0; i < 5; i++ {
+		// every network casts exactly one vote
+		row := classMx.(*mat.Dense).RawRowView(i)
+		assert.InDelta(row[0]+row[1]+row[2]+row[3]+row[4], 100.0, 1e-9)
+		assert.True(row[maxCol(classA, i)] >= 200.0/3-1e-9)
+	}
+	labels, err = e.Predict(inMx)
+	assert.NoError(err)
+	for i := 0; i < labels.Len(); i++ {
+		assert.Equal(labels.At(i, 0), float64(maxCol(classA, i)+1))
+	}
+}
+
+func TestBagging(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NoError(err)
+	trainConf := &config.TrainConfig{
+		Kind:   "backprop",
+		Cost:   "xentropy",
+		Lambda: 1.0,
+		Optimize: &config.OptimConfig{
+			Method:     "bfgs",
+			Iterations: 2,
+		},
+	}
+	// incorrect number of networks
+	e, err := Bagging(Average, 0, conf.Network, trainConf, inMx, labelsVec)
+	assert.Nil(e)
+	assert.Error(err)
+	// nil data
+	e, err = Bagging(Average, 2, conf.Network, trainConf, nil, labelsVec)
+	assert.Nil(e)
+	assert.Error(err)
+	e, err = Bagging(Vote, 3, conf.Network, trainConf, inMx, labelsVec)
+	assert.NotNil(e)
+	assert.NoError(err)
+	assert.Len(e.Networks(), 3)
+	labels, err := e.Predict(inMx)
+	assert.NoError(err)
+	assert.Equal(labels.Len(), 5)
+}
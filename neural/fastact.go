@@ -0,0 +1,37 @@
+package neural
+
+import "github.com/milosgajdos83/go-neural/pkg/matrix"
+
+// fastActivations maps activation function names to their fast approximations
+var fastActivations = map[string]ActivFunc{
+	"sigmoid": matrix.FastSigmoidMx,
+	"softmax": matrix.FastExpMx,
+}
+
+// FastActivation returns true if the network uses fast approximate activation functions
+func (n *Network) FastActivation() bool {
+	return n.fast
+}
+
+// SetFastActivation switches all network layers, including the layers of input branches and heads
+// and the layers added to the network later on, to fast approximate activation functions. Sigmoid
+// and softmax layers then compute exponential by polynomial approximation with relative error below
+// 1e-5, which speeds up inference on large batches. Like Float32 precision, fast activation is used
+// for inference only: layers always use exact activation functions while they are being trained.
+func (n *Network) SetFastActivation(fast bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.fast = fast
+	n.applyPrecision()
+}
+
+// activation returns layer activation function: its fast approximation if the layer uses
+// fast activation and is not being trained
+func (l *Layer) activation() ActivFunc {
+	if l.fast && !l.training {
+		if act, ok := fastActivations[l.meta]; ok {
+			return act
+		}
+	}
+	return l.act
+}
@@ -0,0 +1,45 @@
+package neural
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestFastActivation(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NotNil(n)
+	assert.NoError(err)
+	assert.False(n.FastActivation())
+	expOut, err := n.ForwardProp(inMx, 2)
+	assert.NoError(err)
+	n.SetFastActivation(true)
+	assert.True(n.FastActivation())
+	// fast output is close to exact output
+	out, err := n.ForwardProp(inMx, 2)
+	assert.NoError(err)
+	assert.True(mat.EqualApprox(out, expOut, 1e-5))
+	// layers use exact activation functions while they are being trained
+	layer := n.Layers()[1]
+	assert.Equal(matrix.FastSigmoid(1.0), layer.activation()(0, 0, 1.0))
+	n.setTraining(true)
+	assert.Equal(matrix.Sigmoid(1.0), layer.activation()(0, 0, 1.0))
+	n.setTraining(false)
+	// layers added later use fast activation
+	assert.NoError(n.AddLayer(newTestLayer("hidden", 3, Tanh)))
+	assert.True(n.Layers()[2].fast)
+	// fast activation is cloned and saved along with the network
+	assert.True(n.Clone().FastActivation())
+	data, err := json.Marshal(n)
+	assert.NoError(err)
+	loaded := new(Network)
+	assert.NoError(json.Unmarshal(data, loaded))
+	assert.True(loaded.FastActivation())
+	n.SetFastActivation(false)
+	assert.False(n.Layers()[1].fast)
+}
@@ -0,0 +1,205 @@
+package neural
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// goActivations maps activation functions to their implementations in generated Go source
+var goActivations = map[string]string{
+	Sigmoid: `
+// sigmoid applies sigmoid activation function to x in place
+func sigmoid(x []float64) []float64 {
+	for i := range x {
+		x[i] = 1.0 / (1.0 + math.Exp(-x[i]))
+	}
+	return x
+}
+`,
+	Tanh: `
+// tanh applies tanh activation function to x in place
+func tanh(x []float64) []float64 {
+	for i := range x {
+		x[i] = math.Tanh(x[i])
+	}
+	return x
+}
+`,
+	"tanhout": `
+// tanhout applies tanh activation function rescaled to (0,1) to x in place
+func tanhout(x []float64) []float64 {
+	for i := range x {
+		x[i] = 0.5 * (math.Tanh(x[i]) + 1.0)
+	}
+	return x
+}
+`,
+	ReLU: `
+// relu applies leaky ReLU activation function to x in place
+func relu(x []float64) []float64 {
+	for i := range x {
+		if x[i] <= 0 {
+			x[i] = 0.1 * x[i]
+		}
+	}
+	return x
+}
+`,
+	StdReLU: `
+// stdrelu applies ReLU activation function to x in place
+func stdrelu(x []float64) []float64 {
+	for i := range x {
+		if x[i] <= 0 {
+			x[i] = 0.0
+		}
+	}
+	return x
+}
+`,
+	Softmax: `
+// softmax applies softmax activation function to x in place
+func softmax(x []float64) []float64 {
+	sum := 0.0
+	for i := range x {
+		x[i] = math.Exp(x[i])
+		sum += x[i]
+	}
+	for i := range x {
+		x[i] /= sum
+	}
+	return x
+}
+`,
+	Maxout: `
+// maxout returns the max of every group of pieces consecutive elements of x
+func maxout(x []float64, pieces int) []float64 {
+	out := make([]float64, len(x)/pieces)
+	for i := range out {
+		out[i] = x[i*pieces]
+		for _, v := range x[i*pieces+1 : (i+1)*pieces] {
+			if v > out[i] {
+				out[i] = v
+			}
+		}
+	}
+	return out
+}
+`,
+}
+
+// goAffine is the implementation of fully connected layer in generated Go source
+const goAffine = `
+// affine returns the bias plus the weighted inputs of each of the rows neurons whose
+// weights are stored in w row by row with the bias in the first column
+func affine(w, in []float64, rows int) []float64 {
+	cols := len(in) + 1
+	out := make([]float64, rows)
+	for i := range out {
+		row := w[i*cols : (i+1)*cols]
+		sum := row[0]
+		for j, x := range in {
+			sum += row[j+1] * x
+		}
+		out[i] = sum
+	}
+	return out
+}
+`
+
+// GenerateGo writes Go source file of package pkg to w which contains the network weights and Predict
+// function which computes the network output, so small networks can be embedded into binaries with no
+// dependency on this package or on any model files. Generated code has the following API:
+//
+//	const Inputs = 4  // number of network inputs
+//	const Outputs = 3 // number of network outputs
+//	func Predict(in []float64) ([]float64, error)
+//
+// Predict fails with error if the number of inputs is not Inputs. GenerateGo fails with error if pkg
+// is not a valid package name, if the network has no layers or if it has convolution layers, branches
+// or heads, which are not supported.
+func (n *Network) GenerateGo(w io.Writer, pkg string) error {
+	if !token.IsIdentifier(pkg) {
+		return fmt.Errorf("Incorrect package name: %s\n", pkg)
+	}
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if len(n.layers) < 2 || n.layers[0].Kind() != INPUT {
+		return fmt.Errorf("Network must have %s layer and at least one more layer to be generated\n", INPUT)
+	}
+	if len(n.branches) > 0 || len(n.heads) > 0 {
+		return fmt.Errorf("Networks with branches or heads can't be generated\n")
+	}
+	var vars, body bytes.Buffer
+	used := make(map[string]bool)
+	for i, layer := range n.layers[1:] {
+		if layer.conv != nil {
+			return fmt.Errorf("Unsupported layer %s: %s\n", layer.ID(), layer.Type())
+		}
+		act := layer.meta
+		if act == Tanh && layer.kind == OUTPUT {
+			act = "tanhout"
+		}
+		if _, ok := goActivations[act]; !ok {
+			return fmt.Errorf("Unsupported activation of layer %s: %s\n", layer.ID(), act)
+		}
+		used[act] = true
+		weights := layer.maskedWeights()
+		rows, cols := weights.Dims()
+		fmt.Fprintf(&vars, "\n// layer%d contains weights of %s layer %d with the bias in the first column: %d x %d\n",
+			i+1, layer.Kind(), i+1, rows, cols)
+		fmt.Fprintf(&vars, "var layer%d = [...]float64{", i+1)
+		for r := 0; r < rows; r++ {
+			vars.WriteString("\n")
+			for c := 0; c < cols; c++ {
+				vars.WriteString(strconv.FormatFloat(weights.At(r, c), 'g', -1, 64) + ", ")
+			}
+		}
+		vars.WriteString("\n}\n")
+		fmt.Fprintf(&body, "\tout = affine(layer%d[:], out, %d)\n", i+1, rows)
+		if act == Maxout {
+			fmt.Fprintf(&body, "\tout = maxout(out, %d)\n", layer.pieces)
+			continue
+		}
+		fmt.Fprintf(&body, "\tout = %s(out)\n", act)
+	}
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by go-neural from network %s. DO NOT EDIT.\n\n", n.id)
+	fmt.Fprintf(&src, "package %s\n\n", pkg)
+	if used[Sigmoid] || used[Tanh] || used["tanhout"] || used[Softmax] {
+		src.WriteString("import (\n\t\"fmt\"\n\t\"math\"\n)\n")
+	} else {
+		src.WriteString("import \"fmt\"\n")
+	}
+	fmt.Fprintf(&src, "\n// Inputs is the number of network inputs\nconst Inputs = %d\n", n.layers[0].OutSize())
+	fmt.Fprintf(&src, "\n// Outputs is the number of network outputs\nconst Outputs = %d\n", n.layers[len(n.layers)-1].OutSize())
+	src.Write(vars.Bytes())
+	src.WriteString("\n// Predict returns network outputs for the supplied inputs.\n")
+	src.WriteString("// It fails with error if the number of inputs is not Inputs.\n")
+	src.WriteString("func Predict(in []float64) ([]float64, error) {\n")
+	src.WriteString("\tif len(in) != Inputs {\n")
+	src.WriteString("\t\treturn nil, fmt.Errorf(\"incorrect number of inputs: %d\", len(in))\n\t}\n")
+	src.WriteString("\tout := in\n")
+	src.Write(body.Bytes())
+	src.WriteString("\treturn out, nil\n}\n")
+	src.WriteString(goAffine)
+	// activation functions are sorted so the generated source is deterministic
+	var acts []string
+	for act := range used {
+		acts = append(acts, act)
+	}
+	sort.Strings(acts)
+	for _, act := range acts {
+		src.WriteString(goActivations[act])
+	}
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(formatted)
+	return err
+}
@@ -0,0 +1,49 @@
+package neural
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateGo(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewBuilder().Input(4).Maxout(3, 2).Hidden(4, StdReLU).Output(2, Softmax).Build()
+	assert.NoError(err)
+	var buf bytes.Buffer
+	assert.NoError(n.GenerateGo(&buf, "model"))
+	f, err := parser.ParseFile(token.NewFileSet(), "model.go", buf.Bytes(), parser.ParseComments)
+	assert.NoError(err)
+	assert.Equal("model", f.Name.Name)
+	for _, name := range []string{"Inputs", "Outputs", "Predict", "layer1", "layer2", "layer3",
+		"affine", "maxout", "stdrelu", "softmax"} {
+		assert.NotNil(f.Scope.Lookup(name), name)
+	}
+	assert.Nil(f.Scope.Lookup("sigmoid"))
+	assert.Contains(buf.String(), "const Inputs = 4")
+	assert.Contains(buf.String(), "const Outputs = 2")
+	// generated source is deterministic
+	var again bytes.Buffer
+	assert.NoError(n.GenerateGo(&again, "model"))
+	assert.Equal(buf.String(), again.String())
+	// math is only imported when needed
+	n, err = NewBuilder().Input(4).Hidden(3, ReLU).Output(2, StdReLU).Build()
+	assert.NoError(err)
+	buf.Reset()
+	assert.NoError(n.GenerateGo(&buf, "model"))
+	assert.NotContains(buf.String(), "math")
+	// invalid package names and unsupported networks
+	assert.Error(n.GenerateGo(&buf, "func"))
+	assert.Error(n.GenerateGo(&buf, "foo-bar"))
+	n, err = newModelNetwork()
+	assert.NoError(err)
+	assert.Error(n.GenerateGo(&buf, "model"))
+	n, err = NewBuilder().Input(4).Conv1D(2, 2, 1, 2, ReLU).Output(2, Softmax).Build()
+	assert.NoError(err)
+	assert.Error(n.GenerateGo(&buf, "model"))
+	assert.Error(new(Network).GenerateGo(&buf, "model"))
+}
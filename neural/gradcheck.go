@@ -0,0 +1,85 @@
+package neural
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// CheckGradients compares backpropagation gradients of log-likelihood cost of the supplied network
+// with their central difference approximations (J(w+eps) - J(w-eps)) / 2*eps computed on the supplied
+// data and returns the relative error of every trainable layer keyed by layer ID. Relative error
+// is ||analytic - numeric|| / (||analytic|| + ||numeric||), so the errors around 1e-7 or smaller
+// mean the gradients are correct. It is useful for validating new layer kinds and activations.
+// It returns error if the data are invalid or if eps is not positive.
+func CheckGradients(n *Network, inMx *mat.Dense, labelsVec *mat.VecDense, eps float64) (map[string]float64, error) {
+	return CheckLossGradients(n, LogLikelihood{}, inMx, labelsVec, eps)
+}
+
+// CheckLossGradients compares backpropagation gradients of the supplied loss with their central
+// difference approximations and returns the relative error of every trainable layer keyed by layer ID.
+// The gradients are compared without regularization. See CheckGradients for more details.
+// It returns error if the data are invalid or if eps is not positive.
+func CheckLossGradients(n *Network, loss Loss, inMx *mat.Dense, labelsVec *mat.VecDense,
+	eps float64) (map[string]float64, error) {
+	if n == nil || loss == nil {
+		return nil, fmt.Errorf("Incorrect network or loss supplied: %v, %v\n", n, loss)
+	}
+	if inMx == nil || labelsVec == nil {
+		return nil, fmt.Errorf("Incorrect data supplied. In: %v, Labels: %v\n", inMx, labelsVec)
+	}
+	samples, _ := inMx.Dims()
+	if labelsVec.Len() != samples {
+		return nil, fmt.Errorf("Labels mismatch. Samples: %d, Labels: %d\n", samples, labelsVec.Len())
+	}
+	if eps <= 0 {
+		return nil, fmt.Errorf("Incorrect epsilon: %f\n", eps)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := n.lossDeltas(loss, inMx, labelsVec); err != nil {
+		return nil, err
+	}
+	layers := n.trainLayers()
+	grads := layersGradMx(layers, penalty{}, samples)
+	relErrs := make(map[string]float64)
+	for i, layer := range layers {
+		weights := layer.Weights()
+		rows, cols := weights.Dims()
+		numGrad := mat.NewDense(rows, cols, nil)
+		for r := 0; r < rows; r++ {
+			for c := 0; c < cols; c++ {
+				w := weights.At(r, c)
+				weights.Set(r, c, w+eps)
+				layer.syncWeights32()
+				costPlus, errPlus := n.lossCost(loss, penalty{}, inMx, labelsVec)
+				weights.Set(r, c, w-eps)
+				layer.syncWeights32()
+				costMinus, errMinus := n.lossCost(loss, penalty{}, inMx, labelsVec)
+				weights.Set(r, c, w)
+				layer.syncWeights32()
+				if errPlus != nil {
+					return nil, errPlus
+				}
+				if errMinus != nil {
+					return nil, errMinus
+				}
+				numGrad.Set(r, c, (costPlus-costMinus)/(2*eps))
+			}
+		}
+		relErrs[layer.ID()] = relError(grads[i], numGrad)
+	}
+	return relErrs, nil
+}
+
+// relError returns relative error of two matrices: ||a - b|| / (||a|| + ||b||).
+// Relative error of two zero matrices is zero.
+func relError(a, b *mat.Dense) float64 {
+	norm := mat.Norm(a, 2) + mat.Norm(b, 2)
+	if norm == 0 {
+		return 0.0
+	}
+	diff := new(mat.Dense)
+	diff.Sub(a, b)
+	return mat.Norm(diff, 2) / norm
+}
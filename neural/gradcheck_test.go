@@ -0,0 +1,60 @@
+package neural
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// scaledLoss is MSE loss with incorrectly scaled gradient
+type scaledLoss struct {
+	MSE
+}
+
+func (s scaledLoss) Grad(pred, target mat.Matrix) mat.Matrix {
+	grad := new(mat.Dense)
+	grad.Scale(2.0, s.MSE.Grad(pred, target))
+	return grad
+}
+
+func TestCheckGradients(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	// correct gradients
+	relErrs, err := CheckGradients(n, inMx, labelsVec, 1e-5)
+	assert.NoError(err)
+	assert.Len(relErrs, len(n.trainLayers()))
+	for _, layer := range n.trainLayers() {
+		assert.True(relErrs[layer.ID()] < 1e-7, layer.ID())
+	}
+	relErrs, err = CheckLossGradients(n, MSE{}, inMx, labelsVec, 1e-5)
+	assert.NoError(err)
+	for _, relErr := range relErrs {
+		assert.True(relErr < 1e-7)
+	}
+	// incorrect gradients
+	relErrs, err = CheckLossGradients(n, scaledLoss{}, inMx, labelsVec, 1e-5)
+	assert.NoError(err)
+	for _, relErr := range relErrs {
+		assert.True(relErr > 0.1)
+	}
+	// weights are left intact
+	params := n.Params()
+	_, err = CheckGradients(n, inMx, labelsVec, 1e-5)
+	assert.NoError(err)
+	assert.Equal(params, n.Params())
+	// incorrect parameters
+	_, err = CheckGradients(nil, inMx, labelsVec, 1e-5)
+	assert.Error(err)
+	_, err = CheckLossGradients(n, nil, inMx, labelsVec, 1e-5)
+	assert.Error(err)
+	_, err = CheckGradients(n, nil, labelsVec, 1e-5)
+	assert.Error(err)
+	_, err = CheckGradients(n, inMx, labelsVec.SliceVec(0, 3).(*mat.VecDense), 1e-5)
+	assert.Error(err)
+	_, err = CheckGradients(n, inMx, labelsVec, 0.0)
+	assert.Error(err)
+}
@@ -0,0 +1,94 @@
+package neural
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// NonFiniteError is returned by Trainer with enabled guard when training produces NaN or Inf values.
+// Layer is ID of the layer whose activations or gradients are not finite; it is empty if the training
+// cost is not finite, but no layer can be blamed for it. Batch is 0 if the epoch cost is not finite.
+type NonFiniteError struct {
+	// Layer is ID of the offending layer
+	Layer string
+	// Value is the name of non-finite value: activations, gradients, cost
+	Value string
+	// Epoch is the training epoch
+	Epoch int
+	// Batch is the mini-batch of the epoch
+	Batch int
+}
+
+// Error returns error message
+func (e *NonFiniteError) Error() string {
+	if e.Layer == "" {
+		return fmt.Sprintf("Non-finite %s in epoch %d, batch %d\n", e.Value, e.Epoch, e.Batch)
+	}
+	return fmt.Sprintf("Non-finite %s of layer %s in epoch %d, batch %d\n", e.Value, e.Layer, e.Epoch, e.Batch)
+}
+
+// checkGrads returns NonFiniteError if any of the supplied gradients of the supplied layers is not finite.
+// The error blames the first layer whose activations of the supplied input are not finite. Gradients are
+// blamed only if all the activations are finite.
+func checkGrads(n *Network, layers []*Layer, grads []*mat.Dense, inMx mat.Matrix, epoch, batch int) error {
+	for i, grad := range grads {
+		if isFinite(grad) {
+			continue
+		}
+		if layer := nonFiniteLayer(n, inMx); layer != nil {
+			return &NonFiniteError{Layer: layer.ID(), Value: "activations", Epoch: epoch, Batch: batch}
+		}
+		return &NonFiniteError{Layer: layers[i].ID(), Value: "gradients", Epoch: epoch, Batch: batch}
+	}
+	return nil
+}
+
+// checkCost returns NonFiniteError if the supplied cost is not finite. The error blames
+// the first layer whose activations of the supplied input are not finite, if there is any.
+func checkCost(n *Network, cost float64, inMx mat.Matrix, epoch, batch int) error {
+	if !math.IsNaN(cost) && !math.IsInf(cost, 0) {
+		return nil
+	}
+	e := &NonFiniteError{Value: "cost", Epoch: epoch, Batch: batch}
+	if layer := nonFiniteLayer(n, inMx); layer != nil {
+		e.Layer, e.Value = layer.ID(), "activations"
+	}
+	return e
+}
+
+// nonFiniteLayer propagates the supplied input through the network and returns the first layer
+// whose output is not finite. It returns nil if all the outputs are finite.
+func nonFiniteLayer(n *Network, inMx mat.Matrix) *Layer {
+	out, _, err := n.inputFwd(inMx)
+	if err != nil {
+		return nil
+	}
+	layers := n.Layers()
+	if !isFinite(out) {
+		return layers[0]
+	}
+	for _, layer := range layers[1:] {
+		if out, err = layer.FwdOut(out); err != nil {
+			return nil
+		}
+		if !isFinite(out) {
+			return layer
+		}
+	}
+	return nil
+}
+
+// isFinite returns true if the supplied matrix contains neither NaN nor Inf values
+func isFinite(m mat.Matrix) bool {
+	rows, cols := m.Dims()
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			if v := m.At(r, c); math.IsNaN(v) || math.IsInf(v, 0) {
+				return false
+			}
+		}
+	}
+	return true
+}
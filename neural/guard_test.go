@@ -0,0 +1,100 @@
+package neural
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// nanOptim is Optimizer which poisons layer weights with NaN after the given number of steps
+type nanOptim struct {
+	after int
+	steps int
+}
+
+func (o *nanOptim) Step(layer *Layer, grad *mat.Dense) error {
+	if o.steps++; o.steps > o.after {
+		layer.Weights().Set(0, 0, math.NaN())
+	}
+	return nil
+}
+
+func TestTrainerGuard(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	c.Guard = true
+	tr, err := NewTrainer(c)
+	assert.NoError(err)
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	// non-finite weights of the hidden layer poison its activations
+	hidden := n.Layers()[1]
+	hidden.Weights().Set(0, 0, math.NaN())
+	err = tr.Train(n, inMx, labelsVec)
+	assert.Error(err)
+	nfErr, ok := err.(*NonFiniteError)
+	assert.True(ok)
+	assert.Equal(&NonFiniteError{Layer: hidden.ID(), Value: "activations", Epoch: 1, Batch: 1}, nfErr)
+	// non-finite gradients of healthy network
+	n, err = NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	layers := n.trainLayers()
+	grads := []*mat.Dense{mat.NewDense(1, 1, nil), mat.NewDense(1, 1, []float64{math.Inf(1)})}
+	err = checkGrads(n, layers, grads, inMx, 2, 3)
+	assert.Equal(&NonFiniteError{Layer: layers[1].ID(), Value: "gradients", Epoch: 2, Batch: 3}, err)
+	assert.NoError(checkGrads(n, layers, grads[:1], inMx, 2, 3))
+	// non-finite cost
+	err = checkCost(n, math.NaN(), inMx, 2, 0)
+	assert.Equal(&NonFiniteError{Value: "cost", Epoch: 2}, err)
+	assert.NoError(checkCost(n, 1.0, inMx, 2, 0))
+	// rollback requires guard
+	c.Guard = false
+	c.Rollback = true
+	tr, err = NewTrainer(c)
+	assert.Nil(tr)
+	assert.Error(err)
+}
+
+func TestTrainerRollback(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	c.Epochs = 3
+	c.Guard = true
+	c.Rollback = true
+	// rollback to the initial weights
+	tr, err := NewTrainer(c)
+	assert.NoError(err)
+	assert.NoError(tr.SetOptimizer(&nanOptim{after: 2}))
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	params := n.Params()
+	_, ok := tr.Train(n, inMx, labelsVec).(*NonFiniteError)
+	assert.True(ok)
+	assert.Equal(params, n.Params())
+	// rollback to the last checkpoint
+	dir, err := ioutil.TempDir("", "rollback")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "train.ckpt")
+	c.Checkpoint = &config.CheckpointConfig{Path: path, Every: 1}
+	tr, err = NewTrainer(c)
+	assert.NoError(err)
+	// 3 mini-batches of 2 layers take 6 steps per epoch
+	assert.NoError(tr.SetOptimizer(&nanOptim{after: 8}))
+	err = tr.Train(n, inMx, labelsVec)
+	nfErr, ok := err.(*NonFiniteError)
+	assert.True(ok)
+	assert.Equal(2, nfErr.Epoch)
+	cp, err := LoadCheckpoint(path)
+	assert.NoError(err)
+	assert.Equal(1, cp.Epoch)
+	assert.Equal(cp.Weights, n.Params())
+}
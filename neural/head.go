@@ -0,0 +1,309 @@
+package neural
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Head is an output branch of a multi-output neural network.
+// Head consists of optional HIDDEN layers followed by an OUTPUT layer and is attached to
+// the network trunk i.e. to the last network layer before the network OUTPUT layer.
+// Every head has its own loss and weight which scales the head loss
+// in the aggregated cost of all network heads.
+type Head struct {
+	// name is head name
+	name string
+	// layers are head layers: the last layer is an OUTPUT layer
+	layers []*Layer
+	// loss is head loss
+	loss Loss
+	// weight scales the head loss
+	weight float64
+}
+
+// NewHead creates new network head and returns it. It fails with error if the name is empty,
+// loss is nil, weight is not positive or if the layers are not a sequence of HIDDEN layers
+// followed by exactly one OUTPUT layer.
+func NewHead(name string, loss Loss, weight float64, layers ...*Layer) (*Head, error) {
+	if name == "" {
+		return nil, fmt.Errorf("Head name can not be empty\n")
+	}
+	if loss == nil {
+		return nil, fmt.Errorf("Incorrect head loss supplied: %v\n", loss)
+	}
+	if weight <= 0 {
+		return nil, fmt.Errorf("Head weight must be positive: %f\n", weight)
+	}
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("Head must contain at least %s layer\n", OUTPUT)
+	}
+	for i, layer := range layers {
+		expKind := HIDDEN
+		if i == len(layers)-1 {
+			expKind = OUTPUT
+		}
+		if layer == nil || layer.Kind() != expKind {
+			return nil, fmt.Errorf("Head layer %d must be %s layer\n", i, expKind)
+		}
+	}
+	return &Head{
+		name:   name,
+		layers: layers,
+		loss:   loss,
+		weight: weight,
+	}, nil
+}
+
+// Name returns head name
+func (h Head) Name() string {
+	return h.name
+}
+
+// Layers returns head layers sorted from the first HIDDEN to the OUTPUT layer
+func (h Head) Layers() []*Layer {
+	return h.layers
+}
+
+// Loss returns head loss
+func (h Head) Loss() Loss {
+	return h.loss
+}
+
+// Weight returns head loss weight
+func (h Head) Weight() float64 {
+	return h.weight
+}
+
+// Clone returns a deep copy of the head
+func (h *Head) Clone() *Head {
+	head := &Head{
+		name:   h.name,
+		layers: make([]*Layer, len(h.layers)),
+		loss:   h.loss,
+		weight: h.weight,
+	}
+	for i, layer := range h.layers {
+		head.layers[i] = layer.Clone()
+	}
+	return head
+}
+
+// trunkSize returns the number of trunk layers i.e. the number of layers preceding OUTPUT layer
+func trunkSize(layers []*Layer) int {
+	if len(layers) > 0 && layers[len(layers)-1].Kind() == OUTPUT {
+		return len(layers) - 1
+	}
+	return len(layers)
+}
+
+// AddHead attaches a new head to the network trunk i.e. to the last network layer before
+// the network OUTPUT layer. The weights of the first head layer are reinitialized if its number of
+// inputs does not match the trunk output. AddHead fails with error if the network has no INPUT layer,
+// if a head with the same name already exists or if the head layers can't accept the trunk output.
+func (n *Network) AddHead(h *Head) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if h == nil {
+		return fmt.Errorf("Incorrect head supplied: %v\n", h)
+	}
+	trunk := n.layers[:trunkSize(n.layers)]
+	if len(trunk) == 0 || trunk[0].Kind() != INPUT {
+		return fmt.Errorf("Network must have %s layer to attach heads\n", INPUT)
+	}
+	for _, head := range n.heads {
+		if head.Name() == h.Name() {
+			return fmt.Errorf("Duplicate head: %s\n", h.Name())
+		}
+	}
+	layers := append([]*Layer{trunk[len(trunk)-1]}, h.layers...)
+	resized, err := resizeLayers(layers)
+	if err != nil {
+		return err
+	}
+	commitLayers(layers, resized)
+	n.heads = append(n.heads, h)
+	n.applyPrecision()
+	return nil
+}
+
+// Heads returns network heads in the order in which they were added
+func (n *Network) Heads() []*Head {
+	return n.heads
+}
+
+// headsLayers returns all layers trained by multi-output training: trunk layers
+// excluding the INPUT layer, HIDDEN layers of input branches and the layers of all heads
+func (n *Network) headsLayers() []*Layer {
+	layers := append([]*Layer{}, n.layers[1:trunkSize(n.layers)]...)
+	for _, branch := range n.branches {
+		layers = append(layers, branch.layers[1:]...)
+	}
+	for _, head := range n.heads {
+		layers = append(layers, head.layers...)
+	}
+	return layers
+}
+
+// HeadsOut propagates the input through the network trunk and all network heads.
+// It returns the outputs of all heads in the order in which the heads were added.
+// It fails with error if the network has no heads or if the forward propagation fails.
+func (n *Network) HeadsOut(inMx mat.Matrix) ([]mat.Matrix, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if inMx == nil {
+		return nil, fmt.Errorf("Can't forward propagate input: %v\n", inMx)
+	}
+	if len(n.heads) == 0 {
+		return nil, fmt.Errorf("Network has no heads\n")
+	}
+	inputOut, _, err := n.inputFwd(inMx)
+	if err != nil {
+		return nil, err
+	}
+	_, _, trunkOut, err := fwdProp(n.layers[1:trunkSize(n.layers)], inputOut)
+	if err != nil {
+		return nil, err
+	}
+	outs := make([]mat.Matrix, len(n.heads))
+	for i, head := range n.heads {
+		_, _, outs[i], err = fwdProp(head.layers, trunkOut)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return outs, nil
+}
+
+// HeadsCost calculates the aggregated cost of all network heads for the given input and labels.
+// labels must contain one labels vector per each network head. The aggregated cost is a weighted
+// sum of the costs of all heads plus L2 regularization of the trunk and heads weights.
+func (n *Network) HeadsCost(inMx *mat.Dense, labels []*mat.VecDense, lambda float64) (float64, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.headsProp(inMx, labels, penalty{l2: lambda}, false)
+}
+
+// HeadsGradient calculates the gradient of the aggregated cost of all network heads for the given
+// input and labels. Errors of all heads are summed at the trunk output and backpropagated through
+// the shared trunk. It returns the gradient of trunk layers followed by the gradient of all heads
+// unrolled into a single slice.
+func (n *Network) HeadsGradient(inMx *mat.Dense, labels []*mat.VecDense, lambda float64) ([]float64, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.headsGradient(inMx, labels, penalty{l2: lambda})
+}
+
+// headsGradient calculates the gradient of the aggregated cost of all network heads
+func (n *Network) headsGradient(inMx *mat.Dense, labels []*mat.VecDense, p penalty) ([]float64, error) {
+	layers := n.headsLayers()
+	resetDeltas(layers)
+	if _, err := n.headsProp(inMx, labels, p, true); err != nil {
+		return nil, err
+	}
+	samples, _ := inMx.Dims()
+	return layersGradient(layers, p, samples), nil
+}
+
+// headsProp propagates the input through the trunk and all network heads and returns
+// the aggregated cost. If backprop is true the errors of all heads are backpropagated
+// and the deltas of all the layers are updated.
+func (n *Network) headsProp(inMx *mat.Dense, labels []*mat.VecDense, p penalty, backprop bool) (float64, error) {
+	if inMx == nil {
+		return -1.0, fmt.Errorf("Incorrect input supplied: %v\n", inMx)
+	}
+	if len(n.heads) == 0 || len(labels) != len(n.heads) {
+		return -1.0, fmt.Errorf("Expected %d labels vectors, got %d\n", len(n.heads), len(labels))
+	}
+	trunk := n.layers[:trunkSize(n.layers)]
+	inputOut, props, err := n.inputFwd(inMx)
+	if err != nil {
+		return -1.0, err
+	}
+	ins, actIns, trunkOut, err := fwdProp(trunk[1:], inputOut)
+	if err != nil {
+		return -1.0, err
+	}
+	samples, _ := inMx.Dims()
+	trunkErr := mat.NewDense(samples, trunk[len(trunk)-1].OutSize(), nil)
+	cost := 0.0
+	for i, head := range n.heads {
+		if labels[i] == nil {
+			return -1.0, fmt.Errorf("Incorrect labels supplied for head: %s\n", head.name)
+		}
+		headIns, headActIns, outMx, err := fwdProp(head.layers, trunkOut)
+		if err != nil {
+			return -1.0, err
+		}
+		_, labelCount := outMx.Dims()
+		labelsMx, err := matrix.MakeLabelsMx(labels[i], labelCount)
+		if err != nil {
+			return -1.0, err
+		}
+		if backprop {
+			deltaMx := new(mat.Dense)
+			last := len(head.layers) - 1
+			errMx := outputErr(head.loss, head.layers[last], outMx, headActIns[last], labelsMx)
+			deltaMx.Scale(head.weight, errMx)
+			headErr := backProp(head.layers, headIns, headActIns, deltaMx, true)
+			trunkErr.Add(trunkErr, headErr)
+		}
+		cost += head.weight * head.loss.Cost(outMx, labelsMx)
+	}
+	// backpropagate the aggregated heads error through the trunk and input branches
+	if backprop {
+		propBranches := len(n.branches) > 0
+		if last := len(trunk) - 2; last >= 0 {
+			errMx := trunk[last+1].actInErr(trunkErr, actIns[last])
+			trunkErr = backProp(trunk[1:], ins, actIns, errMx, propBranches)
+		}
+		if propBranches {
+			n.branchesBackProp(props, trunkErr)
+		}
+	}
+	return cost + regCost(n.headsLayers(), p, samples), nil
+}
+
+// TrainHeads trains the network trunk and all network heads per configuration passed in as
+// parameter. Heads use their own losses, so the cost function in training configuration
+// is ignored. The network OUTPUT layer, if any, is not trained. It returns error if either
+// the training configuration is invalid or the training fails.
+func (n *Network) TrainHeads(c *config.TrainConfig, inMx *mat.Dense, labels []*mat.VecDense) error {
+	return n.TrainHeadsContext(context.Background(), c, inMx, labels)
+}
+
+// TrainHeadsContext trains the network trunk and all network heads the same way as TrainHeads does.
+// Training stops when the supplied context is cancelled or its deadline expires. The network is then
+// left with the best weights found so far and the context error is returned.
+func (n *Network) TrainHeadsContext(ctx context.Context, c *config.TrainConfig, inMx *mat.Dense,
+	labels []*mat.VecDense) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	// validate the supplied configuration
+	if err := ValidateTrainConfig(c); err != nil {
+		return err
+	}
+	if len(n.heads) == 0 {
+		return fmt.Errorf("Network has no heads\n")
+	}
+	layers := n.headsLayers()
+	// switch layers into training mode
+	n.setTraining(true)
+	defer n.setTraining(false)
+	costFunc := func(x []float64) (float64, error) {
+		if err := setNetWeights(layers, x); err != nil {
+			return -1.0, err
+		}
+		return n.headsProp(inMx, labels, trainPenalty(c), false)
+	}
+	gradFunc := func(x []float64) ([]float64, error) {
+		if err := setNetWeights(layers, x); err != nil {
+			return nil, err
+		}
+		return n.headsGradient(inMx, labels, trainPenalty(c))
+	}
+	return optimizeWeights(ctx, c, layers, costFunc, gradFunc)
+}
@@ -0,0 +1,181 @@
+package neural
+
+import (
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// newTestLayer creates a new layer of given kind, size and activation accepting 10 inputs
+func newTestLayer(kind string, size int, activation string) *Layer {
+	layer, err := NewLayer(&config.LayerConfig{
+		Kind: kind,
+		Size: size,
+		NeurFn: &config.NeuronConfig{
+			Activation: activation,
+		},
+	}, 10)
+	if err != nil {
+		panic(err)
+	}
+	return layer
+}
+
+func TestNewHead(t *testing.T) {
+	assert := assert.New(t)
+
+	outLayer := newTestLayer("output", 3, Softmax)
+	hiddenLayer := newTestLayer("hidden", 3, ReLU)
+	// empty name
+	h, err := NewHead("", CrossEntropy{}, 1.0, outLayer)
+	assert.Nil(h)
+	assert.Error(err)
+	// nil cost
+	h, err = NewHead("foo", nil, 1.0, outLayer)
+	assert.Nil(h)
+	assert.Error(err)
+	// incorrect weight
+	h, err = NewHead("foo", CrossEntropy{}, 0.0, outLayer)
+	assert.Nil(h)
+	assert.Error(err)
+	// no layers
+	h, err = NewHead("foo", CrossEntropy{}, 1.0)
+	assert.Nil(h)
+	assert.Error(err)
+	// last layer must be OUTPUT layer
+	h, err = NewHead("foo", CrossEntropy{}, 1.0, outLayer, hiddenLayer)
+	assert.Nil(h)
+	assert.Error(err)
+	h, err = NewHead("foo", CrossEntropy{}, 0.5, hiddenLayer, outLayer)
+	assert.NotNil(h)
+	assert.NoError(err)
+	assert.Equal(h.Name(), "foo")
+	assert.Equal(h.Weight(), 0.5)
+	assert.Equal(h.Loss(), CrossEntropy{})
+	assert.Equal(h.Layers(), []*Layer{hiddenLayer, outLayer})
+}
+
+func TestAddHead(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewBuilder().Input(4).Hidden(5, Sigmoid).Output(3, Softmax).Build()
+	assert.NotNil(n)
+	assert.NoError(err)
+	assert.Error(n.AddHead(nil))
+	h, err := NewHead("foo", LogLikelihood{}, 1.0, newTestLayer("output", 2, Softmax))
+	assert.NoError(err)
+	assert.NoError(n.AddHead(h))
+	assert.Len(n.Heads(), 1)
+	// head is resized to accept the trunk output
+	assert.Equal(h.Layers()[0].InSize(), 5)
+	// duplicate heads are not allowed
+	assert.Error(n.AddHead(h))
+	// heads are resized when the trunk changes
+	assert.NoError(n.InsertLayer(2, newTestLayer("hidden", 7, ReLU)))
+	assert.Equal(h.Layers()[0].InSize(), 7)
+	// heads are cloned with network
+	clone := n.Clone()
+	assert.Len(clone.Heads(), 1)
+	assert.False(clone.Heads()[0].Layers()[0] == h.Layers()[0])
+	// heads are included in network summary and DOT
+	assert.Contains(n.Summary(), "foo.0")
+	assert.Contains(n.DOT(), "layer2 -> head0_0;")
+}
+
+func TestHeads(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewBuilder().Input(4).Hidden(5, Sigmoid).Output(3, Softmax).Build()
+	assert.NotNil(n)
+	assert.NoError(err)
+	// network without heads
+	outs, err := n.HeadsOut(inMx)
+	assert.Nil(outs)
+	assert.Error(err)
+	hA, err := NewHead("a", CrossEntropy{}, 1.0, newTestLayer("hidden", 4, ReLU),
+		newTestLayer("output", 5, Softmax))
+	assert.NoError(err)
+	assert.NoError(n.AddHead(hA))
+	hB, err := NewHead("b", LogLikelihood{}, 0.5, newTestLayer("output", 2, Softmax))
+	assert.NoError(err)
+	assert.NoError(n.AddHead(hB))
+	// nil input
+	outs, err = n.HeadsOut(nil)
+	assert.Nil(outs)
+	assert.Error(err)
+	outs, err = n.HeadsOut(inMx)
+	assert.NoError(err)
+	assert.Len(outs, 2)
+	rows, cols := outs[0].Dims()
+	assert.Equal(rows, 5)
+	assert.Equal(cols, 5)
+	_, cols = outs[1].Dims()
+	assert.Equal(cols, 2)
+	// every head needs labels
+	labels := []*mat.VecDense{labelsVec, mat.NewVecDense(5, []float64{1, 2, 1, 2, 2})}
+	cost, err := n.HeadsCost(inMx, labels[:1], 0.0)
+	assert.Error(err)
+	cost, err = n.HeadsCost(inMx, labels, 0.0)
+	assert.NoError(err)
+	assert.True(cost > 0.0)
+	grad, err := n.HeadsGradient(inMx, labels, 1.0)
+	assert.NoError(err)
+	params := 0
+	for _, layer := range n.headsLayers() {
+		params += layer.ParamCount()
+	}
+	assert.Len(grad, params)
+	// train all heads
+	trainConf := &config.TrainConfig{
+		Kind:   "backprop",
+		Cost:   "xentropy",
+		Lambda: 1.0,
+		Optimize: &config.OptimConfig{
+			Method:     "bfgs",
+			Iterations: 2,
+		},
+	}
+	assert.NoError(n.TrainHeads(trainConf, inMx, labels))
+	assert.Error(n.TrainHeads(nil, inMx, labels))
+}
+
+func TestHeadsLoss(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewBuilder().Input(4).Hidden(5, Sigmoid).Output(3, Softmax).Build()
+	assert.NotNil(n)
+	assert.NoError(err)
+	smooth, err := NewLabelSmoothing(NewHuber(0.5), 0.1)
+	assert.NoError(err)
+	weighted, err := NewClassWeighted(smooth, []float64{1.0, 2.0})
+	assert.NoError(err)
+	hA, err := NewHead("a", MSE{}, 1.0, newTestLayer("output", 5, Softmax))
+	assert.NoError(err)
+	assert.NoError(n.AddHead(hA))
+	hB, err := NewHead("b", weighted, 0.5, newTestLayer("output", 2, Sigmoid))
+	assert.NoError(err)
+	assert.NoError(n.AddHead(hB))
+	assert.Equal(weighted, hB.Loss())
+	labels := []*mat.VecDense{labelsVec, mat.NewVecDense(5, []float64{1, 2, 1, 2, 2})}
+	// gradient of the head losses matches numerical gradient
+	grad, err := n.HeadsGradient(inMx, labels, 0.0)
+	assert.NoError(err)
+	layers := n.headsLayers()
+	weights := netWeights(layers)
+	eps := 1e-6
+	for i := range weights {
+		w := weights[i]
+		weights[i] = w + eps
+		assert.NoError(setNetWeights(layers, weights))
+		costPlus, err := n.HeadsCost(inMx, labels, 0.0)
+		assert.NoError(err)
+		weights[i] = w - eps
+		assert.NoError(setNetWeights(layers, weights))
+		costMinus, err := n.HeadsCost(inMx, labels, 0.0)
+		assert.NoError(err)
+		weights[i] = w
+		assert.InDelta((costPlus-costMinus)/(2*eps), grad[i], 1e-6)
+	}
+}
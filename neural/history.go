@@ -0,0 +1,129 @@
+package neural
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// History records metrics of every training epoch. Trainer records the history of every training
+// and History can be added to Trainer as Callback, too. Recorded metrics can be exported
+// as CSV or JSON, which allows to plot the learning curves.
+type History struct {
+	// epochs contains recorded epochs
+	epochs []int
+	// metrics contains metrics of recorded epochs
+	metrics []Metrics
+}
+
+// NewHistory creates new empty History and returns it
+func NewHistory() *History {
+	return &History{}
+}
+
+// Len returns the number of recorded epochs
+func (h History) Len() int {
+	return len(h.epochs)
+}
+
+// Epochs returns recorded epochs
+func (h History) Epochs() []int {
+	return append([]int{}, h.epochs...)
+}
+
+// Names returns sorted names of all recorded metrics
+func (h History) Names() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, m := range h.metrics {
+		for name := range m {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Metric returns the values of the requested metric in all recorded epochs.
+// It returns nil if the metric has not been recorded.
+func (h History) Metric(name string) []float64 {
+	var values []float64
+	for _, m := range h.metrics {
+		if value, ok := m[name]; ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// OnTrainBegin clears the history
+func (h *History) OnTrainBegin() error {
+	h.epochs, h.metrics = nil, nil
+	return nil
+}
+
+// OnEpochEnd records a copy of the supplied epoch metrics
+func (h *History) OnEpochEnd(epoch int, m Metrics) error {
+	metrics := make(Metrics)
+	for name, value := range m {
+		metrics[name] = value
+	}
+	h.epochs = append(h.epochs, epoch)
+	h.metrics = append(h.metrics, metrics)
+	return nil
+}
+
+// OnBatchEnd does nothing: History records epoch metrics only
+func (h *History) OnBatchEnd(epoch, batch int, m Metrics) error {
+	return nil
+}
+
+// OnTrainEnd does nothing
+func (h *History) OnTrainEnd(m Metrics) error {
+	return nil
+}
+
+// WriteCSV writes the history to the supplied writer in CSV format. The first row contains
+// the column names: epoch followed by the metric names. Every other row contains one epoch.
+// Metrics which were not recorded in an epoch are left empty.
+func (h History) WriteCSV(w io.Writer) error {
+	names := h.Names()
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"epoch"}, names...)); err != nil {
+		return err
+	}
+	for i, epoch := range h.epochs {
+		record := []string{strconv.Itoa(epoch)}
+		for _, name := range names {
+			value, ok := h.metrics[i][name]
+			if !ok {
+				record = append(record, "")
+				continue
+			}
+			record = append(record, strconv.FormatFloat(value, 'g', -1, 64))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// MarshalJSON encodes the history as JSON array of epochs. Every epoch is JSON object
+// which contains epoch number and the metrics of the epoch.
+func (h History) MarshalJSON() ([]byte, error) {
+	epochs := make([]map[string]float64, len(h.epochs))
+	for i, epoch := range h.epochs {
+		epochs[i] = map[string]float64{"epoch": float64(epoch)}
+		for name, value := range h.metrics[i] {
+			epochs[i][name] = value
+		}
+	}
+	return json.Marshal(epochs)
+}
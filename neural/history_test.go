@@ -0,0 +1,61 @@
+package neural
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	assert := assert.New(t)
+
+	h := NewHistory()
+	assert.Equal(0, h.Len())
+	assert.Nil(h.Metric("cost"))
+	m := Metrics{"cost": 0.5, "val_cost": 0.75}
+	assert.NoError(h.OnEpochEnd(1, m))
+	// recorded metrics are copied
+	m["cost"] = 1.5
+	assert.NoError(h.OnBatchEnd(2, 1, Metrics{"cost": 2.0}))
+	assert.NoError(h.OnEpochEnd(2, Metrics{"cost": 0.25}))
+	assert.NoError(h.OnTrainEnd(nil))
+	assert.Equal(2, h.Len())
+	assert.Equal([]int{1, 2}, h.Epochs())
+	assert.Equal([]string{"cost", "val_cost"}, h.Names())
+	assert.Equal([]float64{0.5, 0.25}, h.Metric("cost"))
+	assert.Equal([]float64{0.75}, h.Metric("val_cost"))
+	// export to CSV
+	var buf bytes.Buffer
+	assert.NoError(h.WriteCSV(&buf))
+	assert.Equal("epoch,cost,val_cost\n1,0.5,0.75\n2,0.25,\n", buf.String())
+	// export to JSON
+	data, err := json.Marshal(h)
+	assert.NoError(err)
+	assert.Equal(`[{"cost":0.5,"epoch":1,"val_cost":0.75},{"cost":0.25,"epoch":2}]`, string(data))
+	// new training clears the history
+	assert.NoError(h.OnTrainBegin())
+	assert.Equal(0, h.Len())
+}
+
+func TestTrainerHistory(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	c.Epochs = 3
+	c.ValidSplit = 0.4
+	tr, err := NewTrainer(c)
+	assert.NoError(err)
+	assert.Nil(tr.History())
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	// history can be used as callback, too
+	h := NewHistory()
+	assert.NoError(tr.AddCallback(h))
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+	assert.Equal([]int{1, 2, 3}, tr.History().Epochs())
+	assert.Equal([]string{"cost", "val_accuracy", "val_cost"}, tr.History().Names())
+	assert.Len(tr.History().Metric("val_cost"), 3)
+	assert.Equal(tr.History(), h)
+}
@@ -0,0 +1,124 @@
+package neural
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// hdf5Magic is the signature of HDF5 files
+var hdf5Magic = []byte("\x89HDF\r\n\x1a\n")
+
+// kerasLayer is Keras layer weights in Keras weights JSON
+type kerasLayer struct {
+	// Name is Keras layer name
+	Name string `json:"name"`
+	// ClassName is Keras layer class such as Dense
+	ClassName string `json:"class_name"`
+	// Weights contains layer kernel with one row per layer input followed by optional bias
+	Weights []json.RawMessage `json:"weights"`
+}
+
+// ImportKeras reads Keras Dense layer weights in Keras weights JSON format from r and sets them as
+// the weights of the matching network layers. The JSON is an array of Keras model layers, which
+// can be written from Python by:
+//
+//	json.dump([{"name": l.name, "class_name": type(l).__name__,
+//		"weights": [w.tolist() for w in l.get_weights()]} for l in model.layers], f)
+//
+// Layers with no weights, such as Dropout, are skipped and Dense layers are mapped in order onto
+// the network layers following the INPUT layer. Dense kernel has one row per layer input and bias is
+// optional. Network weights are only modified if all the layers match. ImportKeras fails with error
+// if the data are in HDF5 format, which is not supported, if they can't be decoded, if any Keras layer
+// with weights is not a Dense layer or if the Keras layers don't match the network layers.
+func (n *Network) ImportKeras(r io.Reader) error {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(len(hdf5Magic)); bytes.Equal(magic, hdf5Magic) {
+		return fmt.Errorf("Keras HDF5 files are not supported: save the weights as JSON\n")
+	}
+	var data []kerasLayer
+	if err := json.NewDecoder(br).Decode(&data); err != nil {
+		return fmt.Errorf("Incorrect Keras weights: %v\n", err)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var layers []*Layer
+	for _, layer := range n.layers {
+		if layer.Kind() != INPUT {
+			layers = append(layers, layer)
+		}
+	}
+	var weights []*mat.Dense
+	for _, k := range data {
+		if len(k.Weights) == 0 {
+			continue
+		}
+		if k.ClassName != "Dense" {
+			return fmt.Errorf("Unsupported Keras layer %s: %s\n", k.Name, k.ClassName)
+		}
+		i := len(weights)
+		if i >= len(layers) {
+			return fmt.Errorf("Keras layer %s has no matching network layer\n", k.Name)
+		}
+		w, err := kerasWeights(k, layers[i])
+		if err != nil {
+			return err
+		}
+		weights = append(weights, w)
+	}
+	if len(weights) != len(layers) {
+		return fmt.Errorf("Layers mismatch. Network: %d, Keras: %d\n", len(layers), len(weights))
+	}
+	for i, layer := range layers {
+		if err := layer.SetWeights(weights[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kerasWeights converts weights of the supplied Keras Dense layer to the weights of the supplied
+// network layer. It fails with error if the network layer is not a fully connected layer or if
+// the Keras weights dimensions don't match the network layer.
+func kerasWeights(k kerasLayer, layer *Layer) (*mat.Dense, error) {
+	if layer.conv != nil || layer.pieces > 0 {
+		return nil, fmt.Errorf("Keras layer %s can't be imported to %s layer %s\n", k.Name, layer.Type(), layer.ID())
+	}
+	var kernel [][]float64
+	if err := json.Unmarshal(k.Weights[0], &kernel); err != nil {
+		return nil, fmt.Errorf("Incorrect kernel of Keras layer %s: %v\n", k.Name, err)
+	}
+	var bias []float64
+	if len(k.Weights) > 1 {
+		if err := json.Unmarshal(k.Weights[1], &bias); err != nil {
+			return nil, fmt.Errorf("Incorrect bias of Keras layer %s: %v\n", k.Name, err)
+		}
+	}
+	rows, cols := layer.Weights().Dims()
+	if len(kernel) != cols-1 {
+		return nil, fmt.Errorf("Dimension mismatch of Keras layer %s. Inputs: %d, Kernel rows: %d\n",
+			k.Name, cols-1, len(kernel))
+	}
+	if bias != nil && len(bias) != rows {
+		return nil, fmt.Errorf("Dimension mismatch of Keras layer %s. Outputs: %d, Bias: %d\n",
+			k.Name, rows, len(bias))
+	}
+	weights := mat.NewDense(rows, cols, nil)
+	for j, row := range kernel {
+		if len(row) != rows {
+			return nil, fmt.Errorf("Dimension mismatch of Keras layer %s. Outputs: %d, Kernel columns: %d\n",
+				k.Name, rows, len(row))
+		}
+		for i, x := range row {
+			weights.Set(i, j+1, x)
+		}
+	}
+	for i, x := range bias {
+		weights.Set(i, 0, x)
+	}
+	return weights, nil
+}
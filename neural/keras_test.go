@@ -0,0 +1,50 @@
+package neural
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestImportKeras(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewBuilder().Input(2).Hidden(3, ReLU).Output(2, Softmax).Build()
+	assert.NoError(err)
+	data := `[
+		{"name": "input_1", "class_name": "InputLayer", "weights": []},
+		{"name": "dense", "class_name": "Dense", "weights": [[[1, 2, 3], [4, 5, 6]], [0.1, 0.2, 0.3]]},
+		{"name": "dropout", "class_name": "Dropout", "weights": []},
+		{"name": "dense_1", "class_name": "Dense", "weights": [[[1, 2], [3, 4], [5, 6]]]}
+	]`
+	assert.NoError(n.ImportKeras(strings.NewReader(data)))
+	hidden := mat.NewDense(3, 3, []float64{0.1, 1, 4, 0.2, 2, 5, 0.3, 3, 6})
+	assert.True(mat.Equal(hidden, n.Layers()[1].Weights()))
+	output := mat.NewDense(2, 4, []float64{0, 1, 3, 5, 0, 2, 4, 6})
+	assert.True(mat.Equal(output, n.Layers()[2].Weights()))
+	// weights are not modified if any layer does not match
+	params := n.Params()
+	testCases := []string{
+		`[{"name": "dense", "class_name": "Dense", "weights": [[[1, 2, 3], [4, 5, 6]]]}]`,
+		`[{"name": "dense", "class_name": "Dense", "weights": [[[1, 2, 3], [4, 5, 6]]]},
+		  {"name": "dense_1", "class_name": "Dense", "weights": [[[1, 2], [3, 4]]]}]`,
+		`[{"name": "dense", "class_name": "Dense", "weights": [[[1, 2, 3], [4, 5, 6]]]},
+		  {"name": "dense_1", "class_name": "Dense", "weights": [[[1, 2], [3, 4], [5, 6]], [1, 2, 3]]}]`,
+		`[{"name": "dense", "class_name": "Dense", "weights": [[[1, 2, 3], [4, 5]]]},
+		  {"name": "dense_1", "class_name": "Dense", "weights": [[[1, 2], [3, 4], [5, 6]]]}]`,
+		`[{"name": "conv", "class_name": "Conv1D", "weights": [[[[1]]]]}]`,
+		`[{"name": "dense", "class_name": "Dense", "weights": ["foo"]}]`,
+		`{"foo": "bar"}`,
+		"\x89HDF\r\n\x1a\n",
+	}
+	for _, tc := range testCases {
+		assert.Error(n.ImportKeras(strings.NewReader(tc)), tc)
+	}
+	assert.Equal(params, n.Params())
+	// maxout layers can't be imported
+	n, err = NewBuilder().Input(2).Maxout(3, 2).Output(2, Softmax).Build()
+	assert.NoError(err)
+	assert.Error(n.ImportKeras(strings.NewReader(data)))
+}
@@ -2,11 +2,13 @@ package neural
 
 import (
 	"fmt"
+	"sync"
 
-	"github.com/gonum/matrix/mat64"
 	"github.com/milosgajdos83/go-neural/pkg/config"
 	"github.com/milosgajdos83/go-neural/pkg/helpers"
 	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"gonum.org/v1/gonum/blas/blas32"
+	"gonum.org/v1/gonum/mat"
 )
 
 const (
@@ -18,6 +20,21 @@ const (
 	OUTPUT
 )
 
+const (
+	// Sigmoid is sigmoid activation function
+	Sigmoid = "sigmoid"
+	// Softmax is softmax activation function
+	Softmax = "softmax"
+	// Tanh is hyperbolic tangent activation function
+	Tanh = "tanh"
+	// ReLU is leaky rectified linear unit activation function
+	ReLU = "relu"
+	// StdReLU is standard rectified linear unit activation function which zeroes negative inputs
+	StdReLU = "stdrelu"
+	// Maxout is maxout activation function
+	Maxout = "maxout"
+)
+
 // ActivFunc defines a neuron activation function
 type ActivFunc func(int, int, float64) float64
 
@@ -39,6 +56,10 @@ var activations = map[string]map[string]ActivFunc{
 		"act":  matrix.ReluMx,
 		"grad": matrix.ReluGradMx,
 	},
+	"stdrelu": {
+		"act":  matrix.StdReluMx,
+		"grad": matrix.StdReluGradMx,
+	},
 }
 
 // layerKind maps string representations to LayerKind
@@ -73,21 +94,66 @@ type Layer struct {
 	// kind is layer kind: input, hidden or output
 	kind LayerKind
 	// weights matrix holds layer neuron weights per row
-	weights *mat64.Dense
+	weights *mat.Dense
 	// deltas matrix holds output deltas used for backprop
-	deltas *mat64.Dense
+	deltas *mat.Dense
+	// mask is a binary matrix applied to weights: zero elements disable connections
+	mask *mat.Dense
 	// act is neuron activation function
 	act ActivFunc
 	// actGrad is derivation of neuron activation function
 	actGrad ActivFunc
 	// meta contains layer metadata: currently only info about OUT ActFn
 	meta string
+	// noise is standard deviation of Gaussian noise added to layer input in training
+	noise float64
+	// training is set to true when the layer is being trained
+	training bool
+	// pieces is a number of affine pieces per neuron in maxout layer
+	pieces int
+	// conv holds 1D convolution parameters; it's nil for fully connected layers
+	conv *conv1D
+	// in is a number of layer inputs
+	in int
+	// out is a number of layer outputs
+	out int
+	// precision is forward propagation precision used outside of training
+	precision Precision
+	// weights32 is float32 copy of the masked weights kept in Float32 precision
+	weights32 blas32.General
+	// fast requests fast approximate activation function outside of training
+	fast bool
+	// device multiplies layer matrices: nil means CPU
+	device matrix.Device
+	// biasBuf is a buffer of the input matrix with bias reused while the layer is being trained
+	biasBuf []float64
+	// pool is a pool of deltas update matrices of the layer weights dimensions
+	pool *matrix.Pool
+}
+
+// conv1D holds 1D convolution layer parameters
+type conv1D struct {
+	// width is kernel width
+	width int
+	// stride is a step between kernel positions
+	stride int
+	// channels is a number of input channels
+	channels int
+	// positions is a number of kernel positions in input sequence
+	positions int
 }
 
 // NewLayer creates a new neural network layer and returns it.
 // Layer weights are initialized to uniformly distributed random values (-1,1)
 // NewLayer fails with error if the neural network supplied as a parameter does not exist.
 func NewLayer(c *config.LayerConfig, layerIn int) (*Layer, error) {
+	return newLayer(c, layerIn, true)
+}
+
+// newLayer creates a new neural network layer and returns it. Layer weights are initialized
+// to random values if randWeights is true, otherwise they are zero and no random values are drawn,
+// which is used by layers whose weights are set right after they are created.
+func newLayer(c *config.LayerConfig, layerIn int, randWeights bool) (*Layer, error) {
 	// layer in must be positive integer
 	if layerIn <= 0 {
 		return nil, fmt.Errorf("Layer input must be positive integer: %d\n", layerIn)
@@ -100,41 +166,150 @@ func NewLayer(c *config.LayerConfig, layerIn int) (*Layer, error) {
 	if _, ok := layerKind[c.Kind]; !ok {
 		return nil, fmt.Errorf("Invalid layer kind requested: %s", c.Kind)
 	}
+	// noise can't be negative
+	if c.Noise < 0 {
+		return nil, fmt.Errorf("Layer noise must be non-negative: %f\n", c.Noise)
+	}
 	layer := &Layer{}
 	layer.id = helpers.PseudoRandString(10)
 	layer.kind = layerKind[c.Kind]
+	layer.noise = c.Noise
+	layer.in = layerIn
+	layer.out = c.Size
 	// INPUT layer has neither weights matrix nor activation funcs
 	if layer.kind != INPUT {
-		// Set activation function
-		activFunc, ok := activations[c.NeurFn.Activation]
-		if !ok {
-			return nil, fmt.Errorf("Unsupported activation function: %s\n",
-				c.NeurFn.Activation)
-		}
-		// set activation functions
-		layer.act = activFunc["act"]
-		// if tanh - needs to be rescaled if used in OUTPUT layer
-		if c.NeurFn.Activation == "tanh" {
+		layerOut, weightsIn := c.Size, layerIn
+		if c.NeurFn.Activation == "maxout" {
+			// maxout can't be used in OUTPUT layer
 			if layer.kind == OUTPUT {
-				layer.act = matrix.TanhOutMx
+				return nil, fmt.Errorf("Unsupported %s layer activation: %s\n",
+					layer.kind, c.NeurFn.Activation)
+			}
+			// maxout requires at least two pieces
+			if c.NeurFn.Pieces < 2 {
+				return nil, fmt.Errorf("Maxout requires at least 2 pieces: %d\n",
+					c.NeurFn.Pieces)
+			}
+			layer.pieces = c.NeurFn.Pieces
+			// each neuron has weights for every piece
+			layerOut = c.Size * c.NeurFn.Pieces
+		} else {
+			// Set activation function
+			activFunc, ok := activations[c.NeurFn.Activation]
+			if !ok {
+				return nil, fmt.Errorf("Unsupported activation function: %s\n",
+					c.NeurFn.Activation)
 			}
+			// set activation functions
+			layer.act = activFunc["act"]
+			// if tanh - needs to be rescaled if used in OUTPUT layer
+			if c.NeurFn.Activation == "tanh" {
+				if layer.kind == OUTPUT {
+					layer.act = matrix.TanhOutMx
+				}
+			}
+			layer.actGrad = activFunc["grad"]
 		}
-
-		layer.actGrad = activFunc["grad"]
 		layer.meta = c.NeurFn.Activation
-		layerOut := c.Size
-		// initialize weights to random values
-		var err error
-		layer.weights, err = matrix.MakeRandMx(layerOut, layerIn+1, 0.0, 1.0)
-		if err != nil {
-			return nil, err
+		// convolution layer shares weights across all input positions
+		if c.Conv != nil {
+			conv, err := newConv1D(c, layer.kind, layerIn)
+			if err != nil {
+				return nil, err
+			}
+			layer.conv = conv
+			layer.out = conv.positions * c.Size
+			weightsIn = conv.width * conv.channels
+		}
+		// initialize weights to random values unless they are set later
+		if randWeights {
+			var err error
+			layer.weights, err = matrix.MakeRandMx(layerOut, weightsIn+1, 0.0, 1.0)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			layer.weights = mat.NewDense(layerOut, weightsIn+1, nil)
 		}
 		// initializes deltas to zero values
-		layer.deltas = mat64.NewDense(layerOut, layerIn+1, nil)
+		layer.deltas = mat.NewDense(layerOut, weightsIn+1, nil)
 	}
 	return layer, nil
 }
 
+// newConv1D validates 1D convolution layer configuration and returns convolution parameters
+func newConv1D(c *config.LayerConfig, kind LayerKind, layerIn int) (*conv1D, error) {
+	// convolution can only be used in HIDDEN layers
+	if kind != HIDDEN {
+		return nil, fmt.Errorf("Convolution is not supported in %s layer\n", kind)
+	}
+	// only element-wise activations are supported
+	if c.NeurFn.Activation == "maxout" || c.NeurFn.Activation == "softmax" {
+		return nil, fmt.Errorf("Unsupported convolution activation: %s\n", c.NeurFn.Activation)
+	}
+	return makeConv1D(c.Conv.Width, c.Conv.Stride, c.Conv.Channels, layerIn)
+}
+
+// makeConv1D validates 1D convolution parameters for the supplied input size and returns them
+func makeConv1D(width, stride, channels, layerIn int) (*conv1D, error) {
+	if width <= 0 || stride <= 0 || channels <= 0 {
+		return nil, fmt.Errorf("Incorrect convolution: width %d, stride %d, channels %d\n",
+			width, stride, channels)
+	}
+	// layer input must contain the same number of values for every channel
+	if layerIn%channels != 0 {
+		return nil, fmt.Errorf("Input size %d is not divisible by channels: %d\n",
+			layerIn, channels)
+	}
+	length := layerIn / channels
+	if length < width {
+		return nil, fmt.Errorf("Input length %d is shorter than kernel width: %d\n",
+			length, width)
+	}
+	return &conv1D{
+		width:     width,
+		stride:    stride,
+		channels:  channels,
+		positions: (length-width)/stride + 1,
+	}, nil
+}
+
+// resize changes the number of layer inputs. Layer weights are reinitialized to random values
+// and the weights mask is removed if the number of weights inputs changes; convolution layers
+// keep their trained kernel and mask as the kernel does not depend on the input length.
+// It fails with error if the layer can not accept the requested number of inputs.
+func (l *Layer) resize(layerIn int) error {
+	if layerIn <= 0 {
+		return fmt.Errorf("Layer input must be positive integer: %d\n", layerIn)
+	}
+	if l.kind == INPUT || l.in == layerIn {
+		return nil
+	}
+	rows, cols := l.weights.Dims()
+	weightsIn, layerOut := layerIn, l.out
+	if l.conv != nil {
+		conv, err := makeConv1D(l.conv.width, l.conv.stride, l.conv.channels, layerIn)
+		if err != nil {
+			return err
+		}
+		// convolution kernel does not change with input size
+		weightsIn, layerOut = cols-1, conv.positions*rows
+		l.conv = conv
+	}
+	if weightsIn+1 != cols {
+		weights, err := matrix.MakeRandMx(rows, weightsIn+1, 0.0, 1.0)
+		if err != nil {
+			return err
+		}
+		l.weights = weights
+		l.mask = nil
+	}
+	l.deltas = mat.NewDense(rows, weightsIn+1, nil)
+	l.in, l.out = layerIn, layerOut
+	l.syncWeights32()
+	return nil
+}
+
 // ID returns layer id
 func (l Layer) ID() string {
 	return l.id
@@ -145,8 +320,20 @@ func (l Layer) Kind() LayerKind {
 	return l.kind
 }
 
+// InSize returns the number of layer inputs
+func (l Layer) InSize() int {
+	return l.in
+}
+
+// OutSize returns the number of layer outputs
+func (l Layer) OutSize() int {
+	return l.out
+}
+
 // Weights returns layer's eights matrix
-func (l *Layer) Weights() *mat64.Dense {
+// Layers in Float32 precision keep float32 copy of the weights, so the weights modified
+// in place outside of training must be set again via SetWeights to take effect.
+func (l *Layer) Weights() *mat.Dense {
 	return l.weights
 }
 
@@ -154,7 +341,7 @@ func (l *Layer) Weights() *mat64.Dense {
 // It fails with error if either the supplied weights have different dimensions
 // than the existing layer weights or if the passed in weights matrix is nil
 // or if the layer is an INPUT layer: INPUT layer has no weights matrix.
-func (l *Layer) SetWeights(w *mat64.Dense) error {
+func (l *Layer) SetWeights(w *mat.Dense) error {
 	// INPUT layer has no weights
 	if l.kind == INPUT {
 		return fmt.Errorf("Can't set weights matrix of %s layer\n", l.kind)
@@ -171,52 +358,406 @@ func (l *Layer) SetWeights(w *mat64.Dense) error {
 			lr, lc, wr, wc)
 	}
 	l.weights = w
+	// masked weights must stay zeroed
+	if l.mask != nil {
+		l.weights.MulElem(l.weights, l.mask)
+	}
 	// We must re-allocate deltas too
-	deltas := mat64.NewDense(wr, wc, nil)
+	deltas := mat.NewDense(wr, wc, nil)
 	l.deltas = deltas
+	l.syncWeights32()
 	return nil
 }
 
+// Mask returns layer's weights mask matrix.
+// It returns nil if the layer weights are not masked.
+func (l *Layer) Mask() *mat.Dense {
+	return l.mask
+}
+
+// SetMask sets a binary mask which is applied to the layer weights during forward propagation
+// and to the weight gradients during training. Zero elements of the mask disable particular
+// connections, which allows to experiment with pruning and sparse connectivity patterns.
+// Passing nil mask removes the existing mask. It fails with error if the layer is an INPUT layer,
+// if the mask dimensions differ from the weights dimensions or if the mask is not binary.
+func (l *Layer) SetMask(m *mat.Dense) error {
+	// INPUT layer has no weights
+	if l.kind == INPUT {
+		return fmt.Errorf("Can't set weights mask of %s layer\n", l.kind)
+	}
+	// nil mask removes the mask
+	if m == nil {
+		l.mask = nil
+		l.syncWeights32()
+		return nil
+	}
+	// mask dimensions must match weights dimensions
+	mr, mc := m.Dims()
+	lr, lc := l.weights.Dims()
+	if mr != lr || mc != lc {
+		return fmt.Errorf("Dimension mismatch. Weights: %d x %d Mask: %d x %d\n",
+			lr, lc, mr, mc)
+	}
+	// mask must only contain zeros and ones
+	for i := 0; i < mr; i++ {
+		for j := 0; j < mc; j++ {
+			if v := m.At(i, j); v != 0.0 && v != 1.0 {
+				return fmt.Errorf("Mask must be binary. Found: %f\n", v)
+			}
+		}
+	}
+	l.mask = new(mat.Dense)
+	l.mask.CloneFrom(m)
+	// zero the masked weights
+	l.weights.MulElem(l.weights, l.mask)
+	l.syncWeights32()
+	return nil
+}
+
+// maskedWeights returns layer weights with the weights mask applied
+func (l *Layer) maskedWeights() *mat.Dense {
+	if l.mask == nil {
+		return l.weights
+	}
+	weights := new(mat.Dense)
+	weights.MulElem(l.weights, l.mask)
+	return weights
+}
+
 // Deltas returns layer's output deltas matrix
 // Deltas matrix is initialized to zeros and is only non-zero if the back propagation
 // algorithm has been run.
-func (l *Layer) Deltas() *mat64.Dense {
+func (l *Layer) Deltas() *mat.Dense {
 	return l.deltas
 }
 
+// Noise returns standard deviation of Gaussian noise added to layer input during training
+func (l Layer) Noise() float64 {
+	return l.noise
+}
+
+// SetNoise sets standard deviation of Gaussian noise added to layer input during training.
+// Setting noise to 0 disables noise injection. It fails with error if noise is negative.
+func (l *Layer) SetNoise(noise float64) error {
+	if noise < 0 {
+		return fmt.Errorf("Layer noise must be non-negative: %f\n", noise)
+	}
+	l.noise = noise
+	return nil
+}
+
 // FwdOut calculates forward output of the network layer for given input.
 // If the layer is an INPUT layer, it returns the matrix supplied as an argument.
-func (l *Layer) FwdOut(inputMx mat64.Matrix) (mat64.Matrix, error) {
+// If the layer is being trained and has non-zero noise, Gaussian noise is added to the input.
+func (l *Layer) FwdOut(inputMx mat.Matrix) (mat.Matrix, error) {
+	out, _, err := l.fwdOut(inputMx)
+	return out, err
+}
+
+// fwdOut calculates forward output of the network layer for given input.
+// Apart from the layer output it also returns the matrix of activation function inputs
+// which is used in backpropagation. INPUT layer returns nil activation inputs matrix.
+func (l *Layer) fwdOut(inputMx mat.Matrix) (mat.Matrix, *mat.Dense, error) {
 	// if input is nil, return error
 	if inputMx == nil {
-		return nil, fmt.Errorf("Cant calculate output for: %v\n", inputMx)
+		return nil, nil, fmt.Errorf("Cant calculate output for: %v\n", inputMx)
+	}
+	// inject noise into input during training
+	if l.training && l.noise > 0 {
+		noiseMx := new(mat.Dense)
+		noiseMx.Apply(matrix.NoiseMx(l.noise), inputMx)
+		inputMx = noiseMx
 	}
 	// if it's INPUT layer, output is input
 	if l.kind == INPUT {
-		return inputMx, nil
+		return inputMx, nil, nil
 	}
-	// input column dimensions + bias must match the weights column dimensions
+	// convolution layer input must have the configured size
 	inRows, inCols := inputMx.Dims()
+	if l.conv != nil {
+		if inCols != l.in {
+			return nil, nil, fmt.Errorf("Dimension mismatch. Layer: %d, Input: %d\n", l.in, inCols)
+		}
+		// activation inputs of all samples at all positions
+		biasInMx, bufp := l.biasIn(l.patches(inputMx))
+		actInMx := l.actIn(biasInMx)
+		releaseBias(bufp)
+		// reshape to one row per sample
+		actInMx = mat.NewDense(inRows, l.out, actInMx.RawMatrix().Data)
+		return l.activate(actInMx), actInMx, nil
+	}
+	// input column dimensions + bias must match the weights column dimensions
 	_, wCols := l.weights.Dims()
 	if inCols+1 != wCols {
-		return nil, fmt.Errorf("Dimension mismatch. Weight: %d, Input: %d\n", wCols, inCols)
+		return nil, nil, fmt.Errorf("Dimension mismatch. Weight: %d, Input: %d\n", wCols, inCols)
 	}
 	// add bias to input
-	biasInMx := matrix.AddBias(inputMx)
+	biasInMx, bufp := l.biasIn(inputMx)
 	// calculate activation function inputs
-	out := new(mat64.Dense)
-	out.Mul(biasInMx, l.weights.T())
+	actInMx := l.actIn(biasInMx)
+	releaseBias(bufp)
 	// activate layer neurons
-	out.Apply(l.act, out)
+	return l.activate(actInMx), actInMx, nil
+}
+
+// biasBufs is a pool of buffers of input matrices with bias shared by layers outside of training
+var biasBufs = sync.Pool{
+	New: func() interface{} { return new([]float64) },
+}
+
+// biasIn returns the input matrix augmented with bias along with the pooled buffer backing it.
+// Layers being trained are used by a single goroutine, so they reuse their own bias buffer and
+// no pooled buffer is returned; other layers, such as the layers used by many goroutines during
+// inference, take the buffer from the pool. The buffer must be released by releaseBias once
+// the returned matrix is no longer used.
+func (l *Layer) biasIn(inputMx mat.Matrix) (*mat.Dense, *[]float64) {
+	if l.training {
+		biasInMx, buf := matrix.AddBiasBuf(l.biasBuf, inputMx)
+		l.biasBuf = buf
+		return biasInMx, nil
+	}
+	bufp := biasBufs.Get().(*[]float64)
+	biasInMx, buf := matrix.AddBiasBuf(*bufp, inputMx)
+	*bufp = buf
+	return biasInMx, bufp
+}
+
+// releaseBias returns the buffer of input matrix with bias returned by biasIn to the pool
+func releaseBias(bufp *[]float64) {
+	if bufp != nil {
+		biasBufs.Put(bufp)
+	}
+}
+
+// activate applies layer activation function to activation inputs matrix
+func (l *Layer) activate(actInMx *mat.Dense) *mat.Dense {
+	// maxout picks the max piece of each neuron
+	if l.pieces > 0 {
+		rows, cols := actInMx.Dims()
+		out := mat.NewDense(rows, cols/l.pieces, nil)
+		for i := 0; i < rows; i++ {
+			for j := 0; j < cols/l.pieces; j++ {
+				_, max := l.maxPiece(actInMx, i, j)
+				out.Set(i, j, max)
+			}
+		}
+		return out
+	}
+	out := new(mat.Dense)
+	if !l.deviceActivate(out, actInMx) {
+		matrix.ParallelApply(out, l.activation(), actInMx)
+	}
 	if l.meta == "softmax" {
+		rows, _ := out.Dims()
 		rowSums := matrix.RowSums(out)
-		for i := 0; i < inRows; i++ {
-			rowVec := out.RowView(i)
+		for i := 0; i < rows; i++ {
+			rowVec := out.RowView(i).(*mat.VecDense)
 			rowVec.ScaleVec(1/rowSums[i], rowVec)
 			out.SetRow(i, rowVec.RawVector().Data)
 		}
 	}
-	return out, nil
+	return out
+}
+
+// maxPiece returns the index of the column that holds the max piece of j-th neuron
+// in i-th row of maxout activation inputs matrix along with its value
+func (l *Layer) maxPiece(actInMx *mat.Dense, i, j int) (int, float64) {
+	maxCol := j * l.pieces
+	for k := maxCol + 1; k < (j+1)*l.pieces; k++ {
+		if actInMx.At(i, k) > actInMx.At(i, maxCol) {
+			maxCol = k
+		}
+	}
+	return maxCol, actInMx.At(i, maxCol)
+}
+
+// actInErr calculates the error of activation inputs from the supplied layer output error.
+// Maxout layer routes the error to the max pieces only; other layers scale the output error
+// by activation function gradient.
+func (l *Layer) actInErr(outErrMx mat.Matrix, actInMx *mat.Dense) *mat.Dense {
+	if l.pieces > 0 {
+		rows, cols := outErrMx.Dims()
+		errMx := mat.NewDense(rows, cols*l.pieces, nil)
+		for i := 0; i < rows; i++ {
+			for j := 0; j < cols; j++ {
+				maxCol, _ := l.maxPiece(actInMx, i, j)
+				errMx.Set(i, maxCol, outErrMx.At(i, j))
+			}
+		}
+		return errMx
+	}
+	errMx := new(mat.Dense)
+	matrix.ParallelApply(errMx, l.actGrad, actInMx)
+	errMx.MulElem(outErrMx, errMx)
+	return errMx
+}
+
+// patches returns a matrix of convolution input patches.
+// Every row of the returned matrix holds input values covered by the kernel at particular
+// position; patches of all kernel positions of a sample are stored in consecutive rows.
+func (l *Layer) patches(inputMx mat.Matrix) *mat.Dense {
+	rows, _ := inputMx.Dims()
+	patchSize := l.conv.width * l.conv.channels
+	patchesMx := mat.NewDense(rows*l.conv.positions, patchSize, nil)
+	for i := 0; i < rows; i++ {
+		for p := 0; p < l.conv.positions; p++ {
+			start := p * l.conv.stride * l.conv.channels
+			for k := 0; k < patchSize; k++ {
+				patchesMx.Set(i*l.conv.positions+p, k, inputMx.At(i, start+k))
+			}
+		}
+	}
+	return patchesMx
+}
+
+// deltasUpdate calculates layer deltas update for the supplied activation inputs error
+// and the layer input matrix.
+func (l *Layer) deltasUpdate(errMx, inputMx mat.Matrix) *mat.Dense {
+	return l.deltasUpdateTo(new(mat.Dense), errMx, inputMx)
+}
+
+// deltasUpdateTo calculates layer deltas update the same way as deltasUpdate does and stores it
+// in dMx, which must be either empty or have the same dimensions as layer weights.
+func (l *Layer) deltasUpdateTo(dMx *mat.Dense, errMx, inputMx mat.Matrix) *mat.Dense {
+	if l.conv != nil {
+		// kernel weights are shared across all positions
+		biasInMx, bufp := l.biasIn(l.patches(inputMx))
+		l.mul(dMx, l.positionsErr(errMx).T(), biasInMx)
+		releaseBias(bufp)
+		return dMx
+	}
+	biasInMx, bufp := l.biasIn(inputMx)
+	l.mul(dMx, errMx.T(), biasInMx)
+	releaseBias(bufp)
+	return dMx
+}
+
+// deltasPool returns the pool of deltas update matrices. The pool is created when it's needed
+// for the first time and it's recreated whenever the layer weights are resized.
+func (l *Layer) deltasPool() *matrix.Pool {
+	r, c := l.weights.Dims()
+	if l.pool != nil {
+		if pr, pc := l.pool.Dims(); pr == r && pc == c {
+			return l.pool
+		}
+	}
+	// weights dimensions are always positive
+	l.pool, _ = matrix.NewPool(r, c)
+	return l.pool
+}
+
+// inErr propagates the supplied activation inputs error to layer input error
+// not accounting for bias.
+func (l *Layer) inErr(errMx mat.Matrix) *mat.Dense {
+	weightsMx := l.maskedWeights()
+	r, c := weightsMx.Dims()
+	if l.conv != nil {
+		// patches error must be accumulated into input positions
+		patchesErr := new(mat.Dense)
+		l.mul(patchesErr, l.positionsErr(errMx), weightsMx.Slice(0, r, 1, c))
+		rows, _ := errMx.Dims()
+		inErrMx := mat.NewDense(rows, l.in, nil)
+		for i := 0; i < rows; i++ {
+			for p := 0; p < l.conv.positions; p++ {
+				start := p * l.conv.stride * l.conv.channels
+				for k := 0; k < c-1; k++ {
+					v := inErrMx.At(i, start+k) + patchesErr.At(i*l.conv.positions+p, k)
+					inErrMx.Set(i, start+k, v)
+				}
+			}
+		}
+		return inErrMx
+	}
+	inErrMx := new(mat.Dense)
+	l.mul(inErrMx, errMx, weightsMx.Slice(0, r, 1, c))
+	return inErrMx
+}
+
+// positionsErr reshapes convolution layer error to one row per sample position
+func (l *Layer) positionsErr(errMx mat.Matrix) *mat.Dense {
+	rows, _ := errMx.Dims()
+	filters, _ := l.weights.Dims()
+	posErrMx := mat.NewDense(rows*l.conv.positions, filters, nil)
+	for i := 0; i < rows; i++ {
+		for p := 0; p < l.conv.positions; p++ {
+			for f := 0; f < filters; f++ {
+				posErrMx.Set(i*l.conv.positions+p, f, errMx.At(i, p*filters+f))
+			}
+		}
+	}
+	return posErrMx
+}
+
+// Pieces returns a number of affine pieces per neuron if the layer uses maxout activation.
+// It returns 0 for all other activations.
+func (l Layer) Pieces() int {
+	return l.pieces
+}
+
+// Clone returns a deep copy of the layer.
+// The returned layer has the same id, kind and activation functions and its own copy
+// of weights and deltas matrices, so it does not share any state with the original layer.
+func (l *Layer) Clone() *Layer {
+	layer := &Layer{
+		id:        l.id,
+		kind:      l.kind,
+		act:       l.act,
+		actGrad:   l.actGrad,
+		meta:      l.meta,
+		noise:     l.noise,
+		pieces:    l.pieces,
+		conv:      l.conv,
+		in:        l.in,
+		out:       l.out,
+		precision: l.precision,
+		weights32: l.weights32,
+		fast:      l.fast,
+		device:    l.device,
+	}
+	if l.weights != nil {
+		layer.weights = new(mat.Dense)
+		layer.weights.CloneFrom(l.weights)
+	}
+	if l.deltas != nil {
+		layer.deltas = new(mat.Dense)
+		layer.deltas.CloneFrom(l.deltas)
+	}
+	if l.mask != nil {
+		layer.mask = new(mat.Dense)
+		layer.mask.CloneFrom(l.mask)
+	}
+	return layer
+}
+
+// Activation returns the name of layer activation function.
+// It returns empty string for INPUT layer.
+func (l Layer) Activation() string {
+	return l.meta
+}
+
+// Type returns the type of layer connectivity: Dense, Maxout or Conv1D
+func (l Layer) Type() string {
+	switch {
+	case l.conv != nil:
+		return "Conv1D"
+	case l.pieces > 0:
+		return "Maxout"
+	default:
+		return "Dense"
+	}
+}
+
+// ParamCount returns the number of trainable layer parameters i.e. the number
+// of layer weights, including bias weights, which are not disabled by weights mask.
+func (l *Layer) ParamCount() int {
+	if l.weights == nil {
+		return 0
+	}
+	if l.mask != nil {
+		return int(mat.Sum(l.mask))
+	}
+	r, c := l.weights.Dims()
+	return r * c
 }
 
 // ActFn returns layer activation function
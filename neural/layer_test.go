@@ -1,11 +1,13 @@
 package neural
 
 import (
+	"sync"
 	"testing"
 
-	"github.com/gonum/matrix/mat64"
 	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
 	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
 )
 
 func TestLayerKind(t *testing.T) {
@@ -116,7 +118,7 @@ func TestSetWeights(t *testing.T) {
 	tstLayer, err := NewLayer(c, 10)
 	assert.NotNil(tstLayer)
 	assert.NoError(err)
-	weights := mat64.NewDense(100, 200, nil)
+	weights := mat.NewDense(100, 200, nil)
 	err = tstLayer.SetWeights(weights)
 	assert.Error(err)
 	// INPUT layer has no weights or deltas
@@ -138,7 +140,7 @@ func TestSetWeights(t *testing.T) {
 	assert.NotNil(tstLayer)
 	assert.NoError(err)
 	wRows, wCols := 20, 1000
-	weights = mat64.NewDense(wRows, wCols, nil)
+	weights = mat.NewDense(wRows, wCols, nil)
 	err = tstLayer.SetWeights(weights)
 	assert.Error(err)
 
@@ -147,7 +149,7 @@ func TestSetWeights(t *testing.T) {
 	assert.NotNil(tstLayer)
 	assert.NoError(err)
 	wRows, wCols = 20, 11
-	weights = mat64.NewDense(wRows, wCols, nil)
+	weights = mat.NewDense(wRows, wCols, nil)
 	err = tstLayer.SetWeights(weights)
 	assert.NoError(err)
 	// check the deltas and weights dimensions
@@ -179,7 +181,7 @@ func TestFwdOut(t *testing.T) {
 
 	// Correct dimension matrix
 	data := []float64{1.0, 1.0, 2.0, 2.0, 3.0, 3.0}
-	corrInMx := mat64.NewDense(layerIn+1, layerOut, data)
+	corrInMx := mat.NewDense(layerIn+1, layerOut, data)
 	assert.NotNil(corrInMx)
 
 	// nil input yields nil output
@@ -190,7 +192,7 @@ func TestFwdOut(t *testing.T) {
 	out, err = inputLayer.FwdOut(corrInMx)
 	assert.NotNil(out)
 	assert.NoError(err)
-	assert.True(mat64.Equal(corrInMx, out))
+	assert.True(mat.Equal(corrInMx, out))
 
 	// HIDDEN layer test
 	c.Kind = "hidden"
@@ -199,21 +201,327 @@ func TestFwdOut(t *testing.T) {
 	assert.NoError(err)
 	// mismatched dimension
 	mismData := []float64{3.0, 4.0, 1.0}
-	mismInMx := mat64.NewDense(1, 3, mismData)
+	mismInMx := mat.NewDense(1, 3, mismData)
 	out, err = hiddenLayer.FwdOut(mismInMx)
 	assert.Nil(out)
 	assert.Error(err)
 	// correct data dimension must yield the following result
 	dataOut := []float64{1.0, 1.0, 1.0, 1.0, 1.0, 1.0}
-	expOut := mat64.NewDense(layerIn+1, layerOut, dataOut)
+	expOut := mat.NewDense(layerIn+1, layerOut, dataOut)
 	// testing weights
 	weightsData := []float64{2.0, 3.0, 4.0, 5.0, 6.0, 7.0}
-	weights := mat64.NewDense(layerOut, layerIn+1, weightsData)
+	weights := mat.NewDense(layerOut, layerIn+1, weightsData)
 	err = hiddenLayer.SetWeights(weights)
 	assert.NoError(err)
 	// compute output
 	out, err = hiddenLayer.FwdOut(corrInMx)
 	assert.NotNil(out)
 	assert.NoError(err)
-	assert.True(mat64.EqualApprox(out, expOut, 0.001))
+	assert.True(mat.EqualApprox(out, expOut, 0.001))
+}
+
+func TestLayerClone(t *testing.T) {
+	assert := assert.New(t)
+
+	// test configuration
+	c := &config.LayerConfig{
+		Kind: "hidden",
+		Size: 5,
+		NeurFn: &config.NeuronConfig{
+			Activation: "sigmoid",
+		},
+	}
+	layer, err := NewLayer(c, 10)
+	assert.NotNil(layer)
+	assert.NoError(err)
+	clone := layer.Clone()
+	assert.Equal(layer.ID(), clone.ID())
+	assert.Equal(layer.Kind(), clone.Kind())
+	assert.True(mat.Equal(layer.Weights(), clone.Weights()))
+	assert.True(mat.Equal(layer.Deltas(), clone.Deltas()))
+	// modifying the clone must not modify the original layer
+	clone.Weights().Set(0, 0, 100.0)
+	assert.False(mat.Equal(layer.Weights(), clone.Weights()))
+	// INPUT layer has no weights
+	c.Kind = "input"
+	layer, err = NewLayer(c, 10)
+	assert.NotNil(layer)
+	assert.NoError(err)
+	clone = layer.Clone()
+	assert.Nil(clone.Weights())
+	assert.Nil(clone.Deltas())
+}
+
+func TestLayerDeltasPool(t *testing.T) {
+	assert := assert.New(t)
+
+	layer := newTestLayer("hidden", 5, Sigmoid)
+	pool := layer.deltasPool()
+	r, c := pool.Dims()
+	assert.Equal(5, r)
+	assert.Equal(11, c)
+	assert.True(pool == layer.deltasPool())
+	// pooled matrix receives the same deltas update as a new matrix
+	inMx := mat.NewDense(2, 10, nil)
+	errMx := mat.NewDense(2, 5, []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+	expMx := layer.deltasUpdate(errMx, inMx)
+	assert.True(mat.Equal(expMx, layer.deltasUpdateTo(pool.Get(), errMx, inMx)))
+	// resized layer gets a new pool
+	assert.NoError(layer.resize(4))
+	r, c = layer.deltasPool().Dims()
+	assert.Equal(5, r)
+	assert.Equal(5, c)
+}
+
+func TestLayerBiasIn(t *testing.T) {
+	assert := assert.New(t)
+
+	layer := newTestLayer("hidden", 5, Sigmoid)
+	inputs := []*mat.Dense{mat.NewDense(1, 10, nil), mat.NewDense(3, 10, nil), mat.NewDense(2, 10, nil)}
+	for _, inMx := range inputs {
+		inMx.Apply(func(i, j int, x float64) float64 { return float64(i*10 + j) }, inMx)
+	}
+	// pooled inputs with bias give the same outputs as the inputs with bias allocated per call
+	exp := make([]mat.Matrix, len(inputs))
+	for i, inMx := range inputs {
+		actInMx := new(mat.Dense)
+		actInMx.Mul(matrix.AddBias(inMx), layer.Weights().T())
+		exp[i] = actInMx
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := 0; k < 50; k++ {
+				for i, inMx := range inputs {
+					_, actInMx, err := layer.fwdOut(inMx)
+					assert.NoError(err)
+					assert.True(mat.EqualApprox(exp[i], actInMx, 1e-12))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	// training layers reuse their own buffer
+	layer.training = true
+	biasInMx, bufp := layer.biasIn(inputs[1])
+	assert.Nil(bufp)
+	assert.True(mat.Equal(matrix.AddBias(inputs[1]), biasInMx))
+}
+
+func TestLayerNoise(t *testing.T) {
+	assert := assert.New(t)
+
+	// test configuration
+	c := &config.LayerConfig{
+		Kind:  "input",
+		Size:  2,
+		Noise: -1.0,
+	}
+	// negative noise is not allowed
+	layer, err := NewLayer(c, 2)
+	assert.Nil(layer)
+	assert.Error(err)
+	c.Noise = 0.5
+	layer, err = NewLayer(c, 2)
+	assert.NotNil(layer)
+	assert.NoError(err)
+	assert.Equal(layer.Noise(), 0.5)
+	inMx := mat.NewDense(2, 2, []float64{1.0, 2.0, 3.0, 4.0})
+	// noise is not applied outside of training
+	out, err := layer.FwdOut(inMx)
+	assert.NoError(err)
+	assert.True(mat.Equal(out, inMx))
+	// noise is applied in training
+	layer.training = true
+	out, err = layer.FwdOut(inMx)
+	assert.NoError(err)
+	assert.False(mat.Equal(out, inMx))
+	// noise can be disabled
+	assert.Error(layer.SetNoise(-1.0))
+	assert.NoError(layer.SetNoise(0.0))
+	out, err = layer.FwdOut(inMx)
+	assert.NoError(err)
+	assert.True(mat.Equal(out, inMx))
+}
+
+func TestSetMask(t *testing.T) {
+	assert := assert.New(t)
+
+	// test configuration
+	c := &config.LayerConfig{
+		Kind: "input",
+		Size: 2,
+		NeurFn: &config.NeuronConfig{
+			Activation: "sigmoid",
+		},
+	}
+	// INPUT layer has no weights to mask
+	layer, err := NewLayer(c, 2)
+	assert.NotNil(layer)
+	assert.NoError(err)
+	assert.Error(layer.SetMask(mat.NewDense(2, 3, nil)))
+	// HIDDEN layer
+	c.Kind = "hidden"
+	layer, err = NewLayer(c, 2)
+	assert.NotNil(layer)
+	assert.NoError(err)
+	assert.Nil(layer.Mask())
+	// incorrect dimensions
+	assert.Error(layer.SetMask(mat.NewDense(3, 3, nil)))
+	// mask must be binary
+	assert.Error(layer.SetMask(mat.NewDense(2, 3, []float64{1, 0, 2, 1, 1, 1})))
+	// masked weights are zeroed
+	mask := mat.NewDense(2, 3, []float64{1, 0, 1, 0, 1, 1})
+	assert.NoError(layer.SetMask(mask))
+	assert.True(mat.Equal(layer.Mask(), mask))
+	assert.Equal(layer.Weights().At(0, 1), 0.0)
+	assert.Equal(layer.Weights().At(1, 0), 0.0)
+	// weights set after masking are masked, too
+	weights := mat.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6})
+	assert.NoError(layer.SetWeights(weights))
+	assert.True(mat.Equal(layer.Weights(), mat.NewDense(2, 3, []float64{1, 0, 3, 0, 5, 6})))
+	// nil mask removes the mask
+	assert.NoError(layer.SetMask(nil))
+	assert.Nil(layer.Mask())
+}
+
+func TestMaxout(t *testing.T) {
+	assert := assert.New(t)
+
+	// test configuration
+	c := &config.LayerConfig{
+		Kind: "output",
+		Size: 2,
+		NeurFn: &config.NeuronConfig{
+			Activation: "maxout",
+			Pieces:     2,
+		},
+	}
+	// maxout is not allowed in OUTPUT layer
+	layer, err := NewLayer(c, 2)
+	assert.Nil(layer)
+	assert.Error(err)
+	// maxout requires at least 2 pieces
+	c.Kind = "hidden"
+	c.NeurFn.Pieces = 1
+	layer, err = NewLayer(c, 2)
+	assert.Nil(layer)
+	assert.Error(err)
+	c.NeurFn.Pieces = 2
+	layer, err = NewLayer(c, 2)
+	assert.NotNil(layer)
+	assert.NoError(err)
+	assert.Equal(layer.Pieces(), 2)
+	// every neuron has weights for each piece
+	wRows, wCols := layer.Weights().Dims()
+	assert.Equal(wRows, 4)
+	assert.Equal(wCols, 3)
+	weights := mat.NewDense(4, 3, []float64{
+		0.0, 1.0, 0.0,
+		0.0, 0.0, 1.0,
+		1.0, -1.0, 0.0,
+		-1.0, 0.0, 0.0})
+	assert.NoError(layer.SetWeights(weights))
+	inMx := mat.NewDense(2, 2, []float64{1.0, 2.0, 3.0, -1.0})
+	out, err := layer.FwdOut(inMx)
+	assert.NoError(err)
+	expOut := mat.NewDense(2, 2, []float64{2.0, 0.0, 3.0, -1.0})
+	assert.True(mat.Equal(out, expOut))
+	// error is routed to the max pieces only
+	_, actInMx, err := layer.fwdOut(inMx)
+	assert.NoError(err)
+	outErrMx := mat.NewDense(2, 2, []float64{1.0, 2.0, 3.0, 4.0})
+	errMx := layer.actInErr(outErrMx, actInMx)
+	expErr := mat.NewDense(2, 4, []float64{0.0, 1.0, 2.0, 0.0, 3.0, 0.0, 0.0, 4.0})
+	assert.True(mat.Equal(errMx, expErr))
+}
+
+func TestConv1D(t *testing.T) {
+	assert := assert.New(t)
+
+	// test configuration
+	c := &config.LayerConfig{
+		Kind: "output",
+		Size: 2,
+		NeurFn: &config.NeuronConfig{
+			Activation: "relu",
+		},
+		Conv: &config.ConvConfig{
+			Width:    2,
+			Stride:   2,
+			Channels: 1,
+		},
+	}
+	// convolution is only supported in HIDDEN layers
+	layer, err := NewLayer(c, 6)
+	assert.Nil(layer)
+	assert.Error(err)
+	c.Kind = "hidden"
+	// incorrect kernel width
+	c.Conv.Width = 0
+	layer, err = NewLayer(c, 6)
+	assert.Nil(layer)
+	assert.Error(err)
+	// kernel can't be wider than input
+	c.Conv.Width = 7
+	layer, err = NewLayer(c, 6)
+	assert.Nil(layer)
+	assert.Error(err)
+	c.Conv.Width = 2
+	// input size must be divisible by channels
+	c.Conv.Channels = 4
+	layer, err = NewLayer(c, 6)
+	assert.Nil(layer)
+	assert.Error(err)
+	c.Conv.Channels = 1
+	layer, err = NewLayer(c, 6)
+	assert.NotNil(layer)
+	assert.NoError(err)
+	// 3 kernel positions, 2 output channels
+	assert.Equal(layer.InSize(), 6)
+	assert.Equal(layer.OutSize(), 6)
+	wRows, wCols := layer.Weights().Dims()
+	assert.Equal(wRows, 2)
+	assert.Equal(wCols, 3)
+	// first channel sums the inputs, second subtracts them
+	weights := mat.NewDense(2, 3, []float64{0.0, 1.0, 1.0, 0.0, 1.0, -1.0})
+	assert.NoError(layer.SetWeights(weights))
+	inMx := mat.NewDense(1, 6, []float64{1.0, 2.0, 3.0, 5.0, 4.0, 1.0})
+	out, err := layer.FwdOut(inMx)
+	assert.NoError(err)
+	expOut := mat.NewDense(1, 6, []float64{3.0, -0.1, 8.0, -0.2, 5.0, 3.0})
+	assert.True(mat.EqualApprox(out, expOut, 0.0001))
+	// incorrect input size
+	out, err = layer.FwdOut(mat.NewDense(1, 5, nil))
+	assert.Nil(out)
+	assert.Error(err)
+	// resized layer keeps its kernel without drawing random values
+	draws := matrix.Draws()
+	assert.NoError(layer.resize(8))
+	assert.Equal(draws, matrix.Draws())
+	assert.True(mat.Equal(weights, layer.Weights()))
+	assert.Equal(8, layer.OutSize())
+	out, err = layer.FwdOut(mat.NewDense(1, 8, []float64{1.0, 2.0, 3.0, 5.0, 4.0, 1.0, 2.0, 2.0}))
+	assert.NoError(err)
+	expOut = mat.NewDense(1, 8, []float64{3.0, -0.1, 8.0, -0.2, 5.0, 3.0, 4.0, 0.0})
+	assert.True(mat.EqualApprox(out, expOut, 0.0001))
+}
+
+func TestLayerType(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewBuilder().Input(6).Conv1D(2, 2, 2, 1, ReLU).Maxout(3, 2).Output(2, Softmax).Build()
+	assert.NotNil(n)
+	assert.NoError(err)
+	layers := n.Layers()
+	expTypes := []string{"Dense", "Conv1D", "Maxout", "Dense"}
+	expActs := []string{"", ReLU, Maxout, Softmax}
+	expParams := []int{0, 2 * 3, 3 * 2 * 7, 2 * 4}
+	for i, layer := range layers {
+		assert.Equal(layer.Type(), expTypes[i])
+		assert.Equal(layer.Activation(), expActs[i])
+		assert.Equal(layer.ParamCount(), expParams[i])
+	}
 }
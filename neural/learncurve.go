@@ -0,0 +1,85 @@
+package neural
+
+import (
+	"fmt"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"gonum.org/v1/gonum/mat"
+)
+
+// LearningPoint contains the scores of a network trained on a part of the training data
+type LearningPoint struct {
+	// Samples is the number of training samples
+	Samples int
+	// TrainCost is the cost of the training samples
+	TrainCost float64
+	// TrainAccuracy is the percentage of correctly classified training samples
+	TrainAccuracy float64
+	// ValCost is the cost of the validation samples
+	ValCost float64
+	// ValAccuracy is the percentage of correctly classified validation samples
+	ValAccuracy float64
+}
+
+// LearningCurve trains a new network per the supplied configuration on every requested fraction
+// of the training data and returns the training and validation scores of the trained networks
+// ordered the same way as the fractions. Every network is trained on the leading samples of the
+// training data, so the data should be shuffled first. Training scores which stay high while
+// validation scores lag behind indicate overfitting, low scores of both indicate underfitting.
+// It fails with error if any fraction is not in (0, 1], if the data are invalid or if any
+// network fails to be created or trained.
+func LearningCurve(c *config.Config, fractions []float64, inMx *mat.Dense, labelsVec *mat.VecDense,
+	valInMx *mat.Dense, valLabels *mat.VecDense) ([]LearningPoint, error) {
+	if len(fractions) == 0 {
+		return nil, fmt.Errorf("No training data fractions supplied\n")
+	}
+	if inMx == nil || labelsVec == nil {
+		return nil, fmt.Errorf("Incorrect data supplied. In: %v, Labels: %v\n", inMx, labelsVec)
+	}
+	if valInMx == nil || valLabels == nil {
+		return nil, fmt.Errorf("Incorrect validation data supplied. In: %v, Labels: %v\n", valInMx, valLabels)
+	}
+	samples, cols := inMx.Dims()
+	if labelsVec.Len() != samples {
+		return nil, fmt.Errorf("Labels mismatch. Samples: %d, Labels: %d\n", samples, labelsVec.Len())
+	}
+	if valSamples, _ := valInMx.Dims(); valLabels.Len() != valSamples {
+		return nil, fmt.Errorf("Validation labels mismatch. Samples: %d, Labels: %d\n", valSamples, valLabels.Len())
+	}
+	for _, f := range fractions {
+		if f <= 0 || f > 1 {
+			return nil, fmt.Errorf("Incorrect training data fraction: %f\n", f)
+		}
+	}
+	points := make([]LearningPoint, len(fractions))
+	for i, f := range fractions {
+		size := int(f * float64(samples))
+		if size == 0 {
+			size = 1
+		}
+		n, err := NewNetworkFromConfig(c)
+		if err != nil {
+			return nil, err
+		}
+		trainInMx := inMx.Slice(0, size, 0, cols).(*mat.Dense)
+		trainLabels := labelsVec.SliceVec(0, size).(*mat.VecDense)
+		if err := n.Train(c.Training, trainInMx, trainLabels); err != nil {
+			return nil, err
+		}
+		p := LearningPoint{Samples: size}
+		if p.TrainCost, err = n.getCost(c.Training, nil, trainInMx, trainLabels); err != nil {
+			return nil, err
+		}
+		if p.TrainAccuracy, err = n.Validate(trainInMx, trainLabels); err != nil {
+			return nil, err
+		}
+		if p.ValCost, err = n.getCost(c.Training, nil, valInMx, valLabels); err != nil {
+			return nil, err
+		}
+		if p.ValAccuracy, err = n.Validate(valInMx, valLabels); err != nil {
+			return nil, err
+		}
+		points[i] = p
+	}
+	return points, nil
+}
@@ -0,0 +1,60 @@
+package neural
+
+import (
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestLearningCurve(t *testing.T) {
+	assert := assert.New(t)
+
+	c, err := config.Preset("mlp-small")
+	assert.NoError(err)
+	c.Network.Seed, c.Training.Seed = 1, 1
+	c.Training.Epochs = 5
+	// 3 classes separated by the first feature
+	samples := 30
+	data := make([]float64, samples*4)
+	labels := make([]float64, samples)
+	for i := 0; i < samples; i++ {
+		class := i % 3
+		data[i*4] = float64(class)
+		data[i*4+1] = float64(i%5) / 5
+		labels[i] = float64(class + 1)
+	}
+	trainInMx := mat.NewDense(20, 4, data[:80])
+	trainLabels := mat.NewVecDense(20, labels[:20])
+	valInMx := mat.NewDense(10, 4, data[80:])
+	valLabels := mat.NewVecDense(10, labels[20:])
+	points, err := LearningCurve(c, []float64{0.01, 0.5, 1}, trainInMx, trainLabels, valInMx, valLabels)
+	assert.NoError(err)
+	assert.Len(points, 3)
+	for i, samples := range []int{1, 10, 20} {
+		p := points[i]
+		assert.Equal(samples, p.Samples)
+		assert.True(p.TrainCost > 0 && p.ValCost > 0)
+		assert.True(p.TrainAccuracy >= 0 && p.TrainAccuracy <= 100)
+		assert.True(p.ValAccuracy >= 0 && p.ValAccuracy <= 100)
+	}
+	// the networks are trained the same way as a network trained on the same samples
+	n, err := NewNetworkFromConfig(c)
+	assert.NoError(err)
+	assert.NoError(n.Train(c.Training, trainInMx, trainLabels))
+	acc, err := n.Validate(valInMx, valLabels)
+	assert.NoError(err)
+	assert.Equal(acc, points[2].ValAccuracy)
+	// incorrect fractions and data
+	for _, fractions := range [][]float64{nil, {0.5, 0}, {1.5}} {
+		_, err = LearningCurve(c, fractions, trainInMx, trainLabels, valInMx, valLabels)
+		assert.Error(err)
+	}
+	_, err = LearningCurve(c, []float64{1}, trainInMx, valLabels, valInMx, valLabels)
+	assert.Error(err)
+	_, err = LearningCurve(c, []float64{1}, trainInMx, trainLabels, valInMx, nil)
+	assert.Error(err)
+	_, err = LearningCurve(c, []float64{1}, trainInMx, trainLabels, valInMx, trainLabels)
+	assert.Error(err)
+}
@@ -0,0 +1,40 @@
+package neural
+
+import "fmt"
+
+// Metadata returns a copy of the network metadata: arbitrary key/value pairs, such as training date,
+// data set hash, metrics or git commit, which are saved along with the network so deployed networks
+// are traceable. It returns empty map if the network has no metadata.
+func (n *Network) Metadata() map[string]string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return copyMetadata(n.metadata)
+}
+
+// SetMetadata sets network metadata key to the supplied value. Empty value removes the key.
+// It fails with error if the key is empty.
+func (n *Network) SetMetadata(key, value string) error {
+	if key == "" {
+		return fmt.Errorf("Metadata key must not be empty\n")
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if value == "" {
+		delete(n.metadata, key)
+		return nil
+	}
+	if n.metadata == nil {
+		n.metadata = make(map[string]string)
+	}
+	n.metadata[key] = value
+	return nil
+}
+
+// copyMetadata returns a copy of the supplied metadata
+func copyMetadata(metadata map[string]string) map[string]string {
+	c := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,38 @@
+package neural
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewFeedForward(4, []int{5}, 3)
+	assert.NoError(err)
+	assert.Empty(n.Metadata())
+	assert.Error(n.SetMetadata("", "foo"))
+	assert.NoError(n.SetMetadata("commit", "abc123"))
+	assert.NoError(n.SetMetadata("accuracy", "0.97"))
+	assert.Equal(map[string]string{"commit": "abc123", "accuracy": "0.97"}, n.Metadata())
+	// returned metadata is a copy
+	n.Metadata()["commit"] = "foo"
+	assert.Equal("abc123", n.Metadata()["commit"])
+	// empty value removes the key
+	assert.NoError(n.SetMetadata("accuracy", ""))
+	assert.Equal(map[string]string{"commit": "abc123"}, n.Metadata())
+	// cloned network has its own metadata
+	clone := n.Clone()
+	assert.NoError(clone.SetMetadata("commit", "def456"))
+	assert.Equal("abc123", n.Metadata()["commit"])
+	// metadata are saved along with the network
+	for _, f := range []Format{JSON, Binary} {
+		var buf bytes.Buffer
+		assert.NoError(WriteNetwork(&buf, n, f))
+		loaded, err := ReadNetwork(&buf)
+		assert.NoError(err)
+		assert.Equal(n.Metadata(), loaded.Metadata())
+	}
+}
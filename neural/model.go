@@ -0,0 +1,632 @@
+package neural
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"gonum.org/v1/gonum/mat"
+)
+
+// networkData is serializable representation of Network
+type networkData struct {
+	ID         string            `json:"id"`
+	Kind       string            `json:"kind"`
+	Precision  string            `json:"precision"`
+	Fast       bool              `json:"fast,omitempty"`
+	Layers     []*layerData      `json:"layers"`
+	Branches   []branchData      `json:"branches,omitempty"`
+	Heads      []headData        `json:"heads,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Thresholds []float64         `json:"thresholds,omitempty"`
+	Preprocess []preprocessData  `json:"preprocess,omitempty"`
+}
+
+// layerData is serializable representation of Layer
+type layerData struct {
+	ID         string      `json:"id"`
+	Kind       string      `json:"kind"`
+	In         int         `json:"in"`
+	Size       int         `json:"size"`
+	Activation string      `json:"activation,omitempty"`
+	Pieces     int         `json:"pieces,omitempty"`
+	Noise      float64     `json:"noise,omitempty"`
+	Conv       *convData   `json:"conv,omitempty"`
+	Weights    [][]float64 `json:"weights,omitempty"`
+	Mask       [][]float64 `json:"mask,omitempty"`
+}
+
+// convData is serializable representation of 1D convolution parameters
+type convData struct {
+	Width    int `json:"width"`
+	Stride   int `json:"stride"`
+	Channels int `json:"channels"`
+}
+
+// branchData is serializable representation of Branch
+type branchData struct {
+	Name   string       `json:"name"`
+	Layers []*layerData `json:"layers"`
+}
+
+// headData is serializable representation of Head
+type headData struct {
+	Name         string       `json:"name"`
+	Cost         string       `json:"cost"`
+	Delta        float64      `json:"delta,omitempty"`
+	Smoothing    float64      `json:"smoothing,omitempty"`
+	ClassWeights []float64    `json:"class_weights,omitempty"`
+	Weight       float64      `json:"weight"`
+	Layers       []*layerData `json:"layers"`
+}
+
+// headCosts maps names of head losses to their implementations
+var headCosts = map[string]Loss{
+	"xentropy": CrossEntropy{},
+	"loglike":  LogLikelihood{},
+	"mse":      MSE{},
+	"huber":    Huber{},
+	"hinge":    Hinge{},
+}
+
+// setLoss stores the supplied head loss in head data. Label smoothing and class weights are
+// stored alongside the name of the loss they wrap. It fails with error if the loss is custom.
+func (hd *headData) setLoss(loss Loss) error {
+	switch l := loss.(type) {
+	case *ClassWeighted:
+		if hd.ClassWeights != nil {
+			break
+		}
+		hd.ClassWeights = append([]float64{}, l.weights...)
+		return hd.setLoss(l.loss)
+	case *LabelSmoothing:
+		if hd.Smoothing != 0 {
+			break
+		}
+		hd.Smoothing = l.eps
+		return hd.setLoss(l.loss)
+	case Huber:
+		hd.Cost, hd.Delta = "huber", l.delta
+		return nil
+	default:
+		for name, c := range headCosts {
+			if c == loss {
+				hd.Cost = name
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("Unsupported loss of head %s: %T\n", hd.Name, loss)
+}
+
+// loss returns head loss decoded from head data
+func (hd *headData) loss() (Loss, error) {
+	loss, ok := headCosts[hd.Cost]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported loss of head %s: %s\n", hd.Name, hd.Cost)
+	}
+	if _, ok := loss.(Huber); ok {
+		loss = NewHuber(hd.Delta)
+	}
+	if hd.Smoothing != 0 {
+		smooth, err := NewLabelSmoothing(loss, hd.Smoothing)
+		if err != nil {
+			return nil, err
+		}
+		loss = smooth
+	}
+	if hd.ClassWeights != nil {
+		return NewClassWeighted(loss, hd.ClassWeights)
+	}
+	return loss, nil
+}
+
+// MarshalJSON encodes the network topology, layer kinds, activation functions, weights and weight
+// masks, input branches, heads, precision, metadata and preprocessing as JSON. Network weights are encoded per layer
+// with one array per weights matrix row. It fails with error if any network head uses custom loss.
+func (n *Network) MarshalJSON() ([]byte, error) {
+	data, err := n.data()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON decodes the network from JSON. The decoded network replaces the network it is called on.
+// It fails with error if the JSON does not describe a valid network.
+func (n *Network) UnmarshalJSON(b []byte) error {
+	data := new(networkData)
+	if err := json.Unmarshal(b, data); err != nil {
+		return err
+	}
+	return n.setData(data)
+}
+
+// Format is a network file format
+type Format uint
+
+const (
+	// JSON is human readable JSON format produced by Network.MarshalJSON
+	JSON Format = iota
+	// Binary is compact binary format, which is faster to write and read than JSON
+	Binary
+)
+
+// String implements Stringer interface for pretty printing
+func (f Format) String() string {
+	switch f {
+	case JSON:
+		return "json"
+	case Binary:
+		return "binary"
+	}
+	return "unknown"
+}
+
+// binaryMagic starts every network encoded in Binary format
+var binaryMagic = []byte("GONN")
+
+// formatVersion is the version of network formats written by WriteNetwork
+const formatVersion uint16 = 1
+
+// binaryExts contains extensions of network files saved in Binary format
+var binaryExts = map[string]bool{
+	".bin": true,
+	".gob": true,
+}
+
+// gzipExt is extension of gzip compressed files
+const gzipExt = ".gz"
+
+// gzipMagic starts every gzip compressed file
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// fileFormat returns the format of the file with the supplied path, which is selected by the file
+// extension, and whether the file is gzip compressed. Compressed files have .gz extension appended
+// to their format extension such as net.bin.gz.
+func fileFormat(path string) (Format, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	compress := ext == gzipExt
+	if compress {
+		ext = strings.ToLower(filepath.Ext(strings.TrimSuffix(path, filepath.Ext(path))))
+	}
+	if binaryExts[ext] {
+		return Binary, compress
+	}
+	return JSON, compress
+}
+
+// gzipWrite returns write function which writes the data written by the supplied write function
+// gzip compressed if compress is true
+func gzipWrite(compress bool, write func(w io.Writer) error) func(w io.Writer) error {
+	if !compress {
+		return write
+	}
+	return func(w io.Writer) error {
+		zw := gzip.NewWriter(w)
+		if err := write(zw); err != nil {
+			return err
+		}
+		return zw.Close()
+	}
+}
+
+// gzipReader returns buffered reader which reads the data read from the supplied reader
+// decompressed if they are gzip compressed
+func gzipReader(r io.Reader) (*bufio.Reader, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(len(gzipMagic)); !bytes.Equal(magic, gzipMagic) {
+		return br, nil
+	}
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, err
+	}
+	return bufio.NewReader(zr), nil
+}
+
+// jsonFile is network encoded in JSON format along with format version and network checksum
+type jsonFile struct {
+	Version uint16          `json:"version"`
+	SHA256  string          `json:"sha256"`
+	Network json.RawMessage `json:"network"`
+}
+
+// WriteNetwork writes the supplied network to the supplied writer in the supplied format. Both formats
+// contain format version and SHA-256 checksum of the encoded network. JSON format is an object with
+// "version", "sha256" and "network" fields. Binary format starts with magic bytes, format version,
+// checksum and the length of the gob encoded network which follows. It returns error if the network
+// is nil, if the format is not supported or if the network can't be encoded or written.
+func WriteNetwork(w io.Writer, n *Network, f Format) error {
+	if n == nil {
+		return fmt.Errorf("Incorrect network supplied: %v\n", n)
+	}
+	switch f {
+	case JSON:
+		payload, err := json.Marshal(n)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(payload)
+		return json.NewEncoder(w).Encode(jsonFile{
+			Version: formatVersion,
+			SHA256:  hex.EncodeToString(sum[:]),
+			Network: payload,
+		})
+	case Binary:
+		data, err := n.data()
+		if err != nil {
+			return err
+		}
+		var payload bytes.Buffer
+		if err := gob.NewEncoder(&payload).Encode(data); err != nil {
+			return err
+		}
+		sum := sha256.Sum256(payload.Bytes())
+		header := new(bytes.Buffer)
+		header.Write(binaryMagic)
+		binary.Write(header, binary.BigEndian, formatVersion)
+		header.Write(sum[:])
+		binary.Write(header, binary.BigEndian, uint64(payload.Len()))
+		if _, err := w.Write(header.Bytes()); err != nil {
+			return err
+		}
+		_, err = w.Write(payload.Bytes())
+		return err
+	}
+	return fmt.Errorf("Unsupported network format: %s\n", f)
+}
+
+// ReadNetwork reads the network from the supplied reader and returns it. The format of the network
+// is detected automatically and gzip compressed data are decompressed transparently. The reader is
+// buffered, so it may be read past the end of the network.
+// It returns error if the data can't be read, if the format version is not supported, if the network
+// checksum does not match, which means the data are corrupted, or if the data don't contain a valid network.
+func ReadNetwork(r io.Reader) (*Network, error) {
+	br, err := gzipReader(r)
+	if err != nil {
+		return nil, err
+	}
+	magic, err := br.Peek(len(binaryMagic))
+	if err == nil && bytes.Equal(magic, encryptedMagic) {
+		return nil, fmt.Errorf("Network is encrypted: use ReadEncryptedNetwork\n")
+	}
+	if err != nil || !bytes.Equal(magic, binaryMagic) {
+		return readJSONNetwork(br)
+	}
+	br.Discard(len(binaryMagic))
+	var version uint16
+	if err := binary.Read(br, binary.BigEndian, &version); err != nil {
+		return nil, err
+	}
+	if err := checkVersion(version); err != nil {
+		return nil, err
+	}
+	var sum [sha256.Size]byte
+	if _, err := io.ReadFull(br, sum[:]); err != nil {
+		return nil, err
+	}
+	var size uint64
+	if err := binary.Read(br, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	// corrupted size must not allocate more memory than there are data
+	payload, err := ioutil.ReadAll(io.LimitReader(br, int64(size)))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(payload)) != size || sha256.Sum256(payload) != sum {
+		return nil, fmt.Errorf("Network checksum mismatch: data are corrupted\n")
+	}
+	data := new(networkData)
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(data); err != nil {
+		return nil, err
+	}
+	n := new(Network)
+	if err := n.setData(data); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// readJSONNetwork reads the network encoded in JSON format from the supplied reader
+func readJSONNetwork(r io.Reader) (*Network, error) {
+	file := new(jsonFile)
+	if err := json.NewDecoder(r).Decode(file); err != nil {
+		return nil, err
+	}
+	if err := checkVersion(file.Version); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(file.Network)
+	if file.SHA256 != hex.EncodeToString(sum[:]) {
+		return nil, fmt.Errorf("Network checksum mismatch: data are corrupted\n")
+	}
+	n := new(Network)
+	if err := json.Unmarshal(file.Network, n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// checkVersion checks if the supplied network format version is supported
+func checkVersion(version uint16) error {
+	if version != formatVersion {
+		return fmt.Errorf("Unsupported network format version: %d, supported: %d\n", version, formatVersion)
+	}
+	return nil
+}
+
+// SaveNetwork saves the supplied network to the file with the supplied path. Files with .bin or .gob
+// extension are saved in Binary format, other files are saved as JSON. Files with .gz extension, such as
+// net.json.gz or net.bin.gz, are gzip compressed. The network is written to
+// a temporary file first, which then replaces the file, so the existing file is never left half written
+// after a crash. It returns error if the network is nil, if it can't be encoded or if it fails to write the file.
+func SaveNetwork(path string, n *Network) error {
+	if n == nil {
+		return fmt.Errorf("Incorrect network supplied: %v\n", n)
+	}
+	f, compress := fileFormat(path)
+	return writeFile(path, gzipWrite(compress, func(w io.Writer) error {
+		return WriteNetwork(w, n, f)
+	}))
+}
+
+// LoadNetwork loads the network from the file with the supplied path and returns it. The file format
+// and compression are detected automatically. It returns error if the file can't be read or if it does not contain a valid network.
+func LoadNetwork(path string) (*Network, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	n, err := ReadNetwork(f)
+	if err != nil {
+		return nil, fmt.Errorf("Incorrect network file %s: %v\n", path, err)
+	}
+	return n, nil
+}
+
+// data returns serializable representation of the network
+func (n *Network) data() (*networkData, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	data := &networkData{
+		ID:        n.id,
+		Kind:      strings.ToLower(n.kind.String()),
+		Precision: n.precision.String(),
+		Fast:      n.fast,
+		Layers:    layersData(n.layers),
+		Metadata:  copyMetadata(n.metadata),
+	}
+	if n.thresholds != nil {
+		data.Thresholds = append([]float64{}, n.thresholds...)
+	}
+	preprocess, err := preprocessingData(n.preprocess)
+	if err != nil {
+		return nil, err
+	}
+	data.Preprocess = preprocess
+	for _, b := range n.branches {
+		data.Branches = append(data.Branches, branchData{Name: b.name, Layers: layersData(b.layers)})
+	}
+	for _, h := range n.heads {
+		hd := headData{
+			Name:   h.name,
+			Weight: h.weight,
+			Layers: layersData(h.layers),
+		}
+		if err := hd.setLoss(h.loss); err != nil {
+			return nil, err
+		}
+		data.Heads = append(data.Heads, hd)
+	}
+	return data, nil
+}
+
+// setData replaces the network with the network decoded from the supplied representation
+func (n *Network) setData(data *networkData) error {
+	kind, ok := netKind[data.Kind]
+	if !ok {
+		return fmt.Errorf("Unsupported neural network type: %s\n", data.Kind)
+	}
+	p, ok := precision[data.Precision]
+	if !ok {
+		return fmt.Errorf("Unsupported precision: %s\n", data.Precision)
+	}
+	layers, err := dataLayers(data.Layers)
+	if err != nil {
+		return err
+	}
+	if len(layers) == 0 || layers[0].kind != INPUT {
+		return fmt.Errorf("Network must start with %s layer\n", INPUT)
+	}
+	for i, layer := range layers[1:] {
+		if layer.kind == INPUT || (layer.kind == OUTPUT && i != len(layers)-2) {
+			return fmt.Errorf("Unexpected %s layer at position %d\n", layer.kind, i+1)
+		}
+	}
+	var branches []*Branch
+	size := 0
+	for _, bd := range data.Branches {
+		branchLayers, err := dataLayers(bd.Layers)
+		if err != nil {
+			return err
+		}
+		b, err := NewBranch(bd.Name, branchLayers...)
+		if err != nil {
+			return err
+		}
+		branches = append(branches, b)
+		size += b.OutSize()
+	}
+	if branches != nil && size != layers[0].in {
+		return fmt.Errorf("Branches output mismatch. Input: %d, Branches: %d\n", layers[0].in, size)
+	}
+	var heads []*Head
+	trunk := layers[trunkSize(layers)-1]
+	for _, hd := range data.Heads {
+		loss, err := hd.loss()
+		if err != nil {
+			return err
+		}
+		headLayers, err := dataLayers(hd.Layers)
+		if err != nil {
+			return err
+		}
+		if err := checkChain(append([]*Layer{trunk}, headLayers...)); err != nil {
+			return fmt.Errorf("Head %s: %s", hd.Name, err)
+		}
+		h, err := NewHead(hd.Name, loss, hd.Weight, headLayers...)
+		if err != nil {
+			return err
+		}
+		heads = append(heads, h)
+	}
+	if data.Thresholds != nil {
+		if err := checkThresholds(data.Thresholds, layers[len(layers)-1].OutSize()); err != nil {
+			return err
+		}
+	}
+	preprocess, err := dataPreprocessing(data.Preprocess)
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.id, n.kind, n.precision, n.fast = data.ID, kind, p, data.Fast
+	n.layers, n.branches, n.heads = layers, branches, heads
+	n.metadata = data.Metadata
+	n.thresholds = data.Thresholds
+	n.preprocess = preprocess
+	n.online = nil
+	n.applyPrecision()
+	return nil
+}
+
+// layersData returns serializable representations of the supplied layers
+func layersData(layers []*Layer) []*layerData {
+	data := make([]*layerData, len(layers))
+	for i, l := range layers {
+		d := &layerData{
+			ID:         l.id,
+			Kind:       strings.ToLower(l.kind.String()),
+			In:         l.in,
+			Size:       l.out,
+			Activation: l.meta,
+			Pieces:     l.pieces,
+			Noise:      l.noise,
+			Weights:    matrixRows(l.weights),
+			Mask:       matrixRows(l.mask),
+		}
+		// convolution layer size is a number of output channels
+		if l.conv != nil {
+			d.Size = l.out / l.conv.positions
+			d.Conv = &convData{Width: l.conv.width, Stride: l.conv.stride, Channels: l.conv.channels}
+		}
+		data[i] = d
+	}
+	return data
+}
+
+// dataLayers creates layers from their serializable representations. It fails with error
+// if any layer is invalid or if any layer does not accept the output of its preceding layer.
+func dataLayers(data []*layerData) ([]*Layer, error) {
+	layers := make([]*Layer, len(data))
+	for i, d := range data {
+		if d == nil {
+			return nil, fmt.Errorf("Incorrect layer %d: %v\n", i, d)
+		}
+		c := &config.LayerConfig{
+			Kind:   d.Kind,
+			Size:   d.Size,
+			Noise:  d.Noise,
+			NeurFn: &config.NeuronConfig{Activation: d.Activation, Pieces: d.Pieces},
+		}
+		if d.Conv != nil {
+			c.Conv = &config.ConvConfig{Width: d.Conv.Width, Stride: d.Conv.Stride, Channels: d.Conv.Channels}
+		}
+		// loaded weights replace the initial weights, so no random values are drawn
+		layer, err := newLayer(c, d.In, false)
+		if err != nil {
+			return nil, err
+		}
+		if d.ID != "" {
+			layer.id = d.ID
+		}
+		if layer.kind != INPUT {
+			weights, err := rowsMatrix(d.Weights)
+			if err != nil {
+				return nil, fmt.Errorf("Incorrect weights of layer %d: %v", i, err)
+			}
+			if err := layer.SetWeights(weights); err != nil {
+				return nil, err
+			}
+			if d.Mask != nil {
+				mask, err := rowsMatrix(d.Mask)
+				if err != nil {
+					return nil, fmt.Errorf("Incorrect mask of layer %d: %v", i, err)
+				}
+				if err := layer.SetMask(mask); err != nil {
+					return nil, err
+				}
+			}
+		}
+		layers[i] = layer
+	}
+	if err := checkChain(layers); err != nil {
+		return nil, err
+	}
+	return layers, nil
+}
+
+// checkChain checks if every layer accepts the output of its preceding layer
+func checkChain(layers []*Layer) error {
+	for i := 1; i < len(layers); i++ {
+		if out := layers[i-1].OutSize(); layers[i].InSize() != out {
+			return fmt.Errorf("Layer %d can't accept %d inputs: %d\n", i, out, layers[i].InSize())
+		}
+	}
+	return nil
+}
+
+// matrixRows returns the rows of the supplied matrix. It returns nil if the matrix is nil.
+func matrixRows(m *mat.Dense) [][]float64 {
+	if m == nil {
+		return nil
+	}
+	rows, _ := m.Dims()
+	data := make([][]float64, rows)
+	for i := range data {
+		data[i] = mat.Row(nil, i, m)
+	}
+	return data
+}
+
+// rowsMatrix returns the matrix with the supplied rows. It fails with error
+// if there are no rows or if the rows differ in length.
+func rowsMatrix(data [][]float64) (*mat.Dense, error) {
+	if len(data) == 0 || len(data[0]) == 0 {
+		return nil, fmt.Errorf("Empty matrix\n")
+	}
+	m := mat.NewDense(len(data), len(data[0]), nil)
+	for i, row := range data {
+		if len(row) != len(data[0]) {
+			return nil, fmt.Errorf("Row %d length mismatch. Expected: %d, Found: %d\n", i, len(data[0]), len(row))
+		}
+		m.SetRow(i, row)
+	}
+	return m, nil
+}
@@ -0,0 +1,277 @@
+package neural
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// newModelNetwork creates a network which uses all serialized network features
+func newModelNetwork() (*Network, error) {
+	n, err := NewBuilder().Input(4).Conv1D(2, 2, 1, 2, ReLU).Maxout(3, 2).Hidden(4, Tanh).
+		Output(3, Softmax).Build()
+	if err != nil {
+		return nil, err
+	}
+	b, err := NewBranch("a", newInputLayer(3), newTestLayer("hidden", 4, Sigmoid))
+	if err != nil {
+		return nil, err
+	}
+	if err := n.AddBranch(b); err != nil {
+		return nil, err
+	}
+	h, err := NewHead("foo", CrossEntropy{}, 0.5, newTestLayer("output", 2, Sigmoid))
+	if err != nil {
+		return nil, err
+	}
+	if err := n.AddHead(h); err != nil {
+		return nil, err
+	}
+	layer := n.Layers()[3]
+	rows, cols := layer.Weights().Dims()
+	mask := mat.NewDense(rows, cols, nil)
+	mask.Apply(func(i, j int, x float64) float64 { return float64((i + j) % 2) }, mask)
+	if err := layer.SetMask(mask); err != nil {
+		return nil, err
+	}
+	if err := layer.SetNoise(0.1); err != nil {
+		return nil, err
+	}
+	return n, n.SetPrecision(Float32)
+}
+
+func TestNetworkJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := newModelNetwork()
+	assert.NoError(err)
+	data, err := json.Marshal(n)
+	assert.NoError(err)
+	loaded := new(Network)
+	assert.NoError(json.Unmarshal(data, loaded))
+	assert.Equal(n.ID(), loaded.ID())
+	assert.Equal(n.Kind(), loaded.Kind())
+	assert.Equal(Float32, loaded.Precision())
+	assert.Equal(n.Params(), loaded.Params())
+	assert.Equal(n.Summary(), loaded.Summary())
+	assert.Len(loaded.Branches(), 1)
+	assert.Len(loaded.Heads(), 1)
+	assert.Equal(CrossEntropy{}, loaded.Heads()[0].Loss())
+	assert.Equal(0.5, loaded.Heads()[0].Weight())
+	assert.Equal(0.1, loaded.Layers()[3].Noise())
+	assert.True(mat.Equal(n.Layers()[3].Mask(), loaded.Layers()[3].Mask()))
+	for i, layer := range n.Layers() {
+		assert.Equal(layer.ID(), loaded.Layers()[i].ID())
+		assert.Equal(layer.Type(), loaded.Layers()[i].Type())
+		assert.Equal(layer.Activation(), loaded.Layers()[i].Activation())
+	}
+	// loaded network gives the same results
+	inMx := mat.NewDense(2, 3, []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6})
+	out, err := n.ForwardProp(inMx, len(n.Layers())-1)
+	assert.NoError(err)
+	loadedOut, err := loaded.ForwardProp(inMx, len(loaded.Layers())-1)
+	assert.NoError(err)
+	assert.True(mat.Equal(out, loadedOut))
+	headsOut, err := n.HeadsOut(inMx)
+	assert.NoError(err)
+	loadedHeadsOut, err := loaded.HeadsOut(inMx)
+	assert.NoError(err)
+	assert.True(mat.Equal(headsOut[0], loadedHeadsOut[0]))
+	// heads with other losses are encoded with their parameters
+	smooth, err := NewLabelSmoothing(NewHuber(0.5), 0.1)
+	assert.NoError(err)
+	weighted, err := NewClassWeighted(smooth, []float64{1.0, 2.0})
+	assert.NoError(err)
+	for _, loss := range []Loss{MSE{}, Hinge{}, NewHuber(2.0), weighted} {
+		hn, err := NewBuilder().Input(3).Hidden(4, Tanh).Output(2, Softmax).Build()
+		assert.NoError(err)
+		h, err := NewHead("bar", loss, 1.0, newTestLayer("output", 2, Sigmoid))
+		assert.NoError(err)
+		assert.NoError(hn.AddHead(h))
+		data, err := json.Marshal(hn)
+		assert.NoError(err)
+		loaded := new(Network)
+		assert.NoError(json.Unmarshal(data, loaded))
+		assert.Equal(loss, loaded.Heads()[0].Loss())
+	}
+	// heads with custom losses can't be encoded
+	h, err := NewHead("bar", struct{ CrossEntropy }{}, 1.0, newTestLayer("output", 2, Sigmoid))
+	assert.NoError(err)
+	assert.NoError(n.AddHead(h))
+	_, err = json.Marshal(n)
+	assert.Error(err)
+	// incorrect networks
+	for _, data := range []string{
+		`{"kind":"foo","layers":[]}`,
+		`{"kind":"feedfwd","precision":"foo"}`,
+		`{"kind":"feedfwd","layers":[]}`,
+		`{"kind":"feedfwd","layers":[{"kind":"hidden","in":2,"size":2,"activation":"relu","weights":[[1,2,3],[1,2,3]]}]}`,
+		`{"kind":"feedfwd","layers":[{"kind":"input","in":2,"size":2},{"kind":"output","in":2,"size":1,"activation":"sigmoid","weights":[[1,2]]}]}`,
+		`{"kind":"feedfwd","layers":[{"kind":"input","in":2,"size":2},{"kind":"output","in":3,"size":1,"activation":"sigmoid","weights":[[1,2,3,4]]}]}`,
+		`{"kind":"feedfwd","layers":[{"kind":"input","in":2,"size":2},{"kind":"output","in":2,"size":1,"activation":"sigmoid","weights":[[1,2,3]]},{"kind":"output","in":1,"size":1,"activation":"sigmoid","weights":[[1,2]]}]}`,
+		`{"kind":"feedfwd","layers":[{"kind":"input","in":3,"size":3}],"branches":[{"name":"a","layers":[{"kind":"input","in":2,"size":2}]}]}`,
+		`{"kind":"feedfwd","layers":[{"kind":"input","in":2,"size":2}],"heads":[{"name":"a","cost":"foo","weight":1,"layers":[{"kind":"output","in":2,"size":1,"activation":"sigmoid","weights":[[1,2,3]]}]}]}`,
+		`{"kind":"feedfwd","layers":[{"kind":"input","in":2,"size":2}],"heads":[{"name":"a","cost":"mse","smoothing":2,"weight":1,"layers":[{"kind":"output","in":2,"size":1,"activation":"sigmoid","weights":[[1,2,3]]}]}]}`,
+		`{"kind":"feedfwd","layers":[{"kind":"input","in":2,"size":2}],"heads":[{"name":"a","cost":"loglike","weight":1,"layers":[{"kind":"output","in":3,"size":1,"activation":"sigmoid","weights":[[1,2,3,4]]}]}]}`,
+	} {
+		assert.Error(json.Unmarshal([]byte(data), new(Network)), data)
+	}
+	// minimal network
+	assert.NoError(json.Unmarshal([]byte(`{"kind":"feedfwd","layers":[{"kind":"input","in":2,"size":2}]}`), loaded))
+	assert.Len(loaded.Layers(), 1)
+	assert.Nil(loaded.Heads())
+}
+
+func TestSaveNetwork(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "network")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "net.json")
+	assert.Error(SaveNetwork(path, nil))
+	n, err := newModelNetwork()
+	assert.NoError(err)
+	assert.NoError(SaveNetwork(path, n))
+	loaded, err := LoadNetwork(path)
+	assert.NoError(err)
+	assert.Equal(n.Params(), loaded.Params())
+	// format is selected by extension
+	binPath := filepath.Join(dir, "net.bin")
+	assert.NoError(SaveNetwork(binPath, n))
+	data, err := ioutil.ReadFile(binPath)
+	assert.NoError(err)
+	assert.Equal(binaryMagic, data[:len(binaryMagic)])
+	loaded, err = LoadNetwork(binPath)
+	assert.NoError(err)
+	assert.Equal(n.Params(), loaded.Params())
+	// loading doesn't draw random values, so seeded draws stay reproducible
+	draws := matrix.Draws()
+	_, err = LoadNetwork(path)
+	assert.NoError(err)
+	assert.Equal(draws, matrix.Draws())
+	// files with .gz extension are compressed
+	for _, name := range []string{"net.json.gz", "net.bin.GZ"} {
+		gzPath := filepath.Join(dir, name)
+		assert.NoError(SaveNetwork(gzPath, n))
+		data, err := ioutil.ReadFile(gzPath)
+		assert.NoError(err)
+		assert.Equal(gzipMagic, data[:len(gzipMagic)])
+		loaded, err = LoadNetwork(gzPath)
+		assert.NoError(err)
+		assert.Equal(n.Params(), loaded.Params())
+	}
+	// trained network can be trained further
+	c := newTrainerConfig()
+	c.Epochs = 2
+	trained, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	assert.NoError(trained.Train(c, inMx, labelsVec))
+	assert.NoError(SaveNetwork(path, trained))
+	loaded, err = LoadNetwork(path)
+	assert.NoError(err)
+	assert.Equal(trained.Params(), loaded.Params())
+	assert.NoError(loaded.Train(c, inMx, labelsVec))
+	// nonexistent and incorrect files
+	loaded, err = LoadNetwork(filepath.Join(dir, "foo"))
+	assert.Nil(loaded)
+	assert.Error(err)
+	assert.NoError(ioutil.WriteFile(path, []byte("foobar"), 0666))
+	loaded, err = LoadNetwork(path)
+	assert.Nil(loaded)
+	assert.Error(err)
+}
+
+func TestWriteNetwork(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := newModelNetwork()
+	assert.NoError(err)
+	var buf bytes.Buffer
+	assert.Error(WriteNetwork(&buf, nil, JSON))
+	assert.Error(WriteNetwork(&buf, n, Format(10)))
+	for _, f := range []Format{JSON, Binary} {
+		buf.Reset()
+		assert.NoError(WriteNetwork(&buf, n, f))
+		loaded, err := ReadNetwork(&buf)
+		assert.NoError(err, f.String())
+		assert.Equal(n.Params(), loaded.Params())
+		assert.Equal(n.Summary(), loaded.Summary())
+	}
+	// binary format is more compact
+	assert.NoError(WriteNetwork(&buf, n, JSON))
+	jsonSize := buf.Len()
+	buf.Reset()
+	assert.NoError(WriteNetwork(&buf, n, Binary))
+	assert.True(buf.Len() < jsonSize)
+	// unsupported version and corrupted data
+	data := append([]byte{}, buf.Bytes()...)
+	data[len(binaryMagic)+1] = 2
+	_, err = ReadNetwork(bytes.NewReader(data))
+	assert.Error(err)
+	data[len(binaryMagic)+1] = 1
+	data[len(data)-1] ^= 0xff
+	_, err = ReadNetwork(bytes.NewReader(data))
+	assert.Error(err)
+	data[len(data)-1] ^= 0xff
+	_, err = ReadNetwork(bytes.NewReader(data))
+	assert.NoError(err)
+	_, err = ReadNetwork(bytes.NewReader(data[:len(data)/2]))
+	assert.Error(err)
+	_, err = ReadNetwork(bytes.NewReader(binaryMagic))
+	assert.Error(err)
+	_, err = ReadNetwork(bytes.NewReader(nil))
+	assert.Error(err)
+	buf.Reset()
+	assert.NoError(WriteNetwork(&buf, n, JSON))
+	file := new(jsonFile)
+	assert.NoError(json.Unmarshal(buf.Bytes(), file))
+	assert.Equal(formatVersion, file.Version)
+	file.Version = 2
+	data, err = json.Marshal(file)
+	assert.NoError(err)
+	_, err = ReadNetwork(bytes.NewReader(data))
+	assert.Error(err)
+	file.Version = formatVersion
+	file.Network = bytes.Replace(file.Network, []byte(`"weight":0.5`), []byte(`"weight":0.6`), 1)
+	data, err = json.Marshal(file)
+	assert.NoError(err)
+	_, err = ReadNetwork(bytes.NewReader(data))
+	assert.Error(err)
+	// network without format version
+	data, err = json.Marshal(n)
+	assert.NoError(err)
+	_, err = ReadNetwork(bytes.NewReader(data))
+	assert.Error(err)
+	assert.Equal("unknown", Format(10).String())
+}
+
+func TestFileFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	testCases := []struct {
+		path     string
+		format   Format
+		compress bool
+	}{
+		{"net.json", JSON, false},
+		{"net", JSON, false},
+		{"net.BIN", Binary, false},
+		{"dir.bin/net.gob", Binary, false},
+		{"net.json.gz", JSON, true},
+		{"net.gz", JSON, true},
+		{"net.bin.gz", Binary, true},
+	}
+	for _, tc := range testCases {
+		format, compress := fileFormat(tc.path)
+		assert.Equal(tc.format, format, tc.path)
+		assert.Equal(tc.compress, compress, tc.path)
+	}
+}
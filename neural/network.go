@@ -1,13 +1,20 @@
 package neural
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"text/tabwriter"
 
-	"github.com/gonum/matrix/mat64"
-	"github.com/gonum/optimize"
 	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
 	"github.com/milosgajdos83/go-neural/pkg/helpers"
 	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/optimize"
 )
 
 const (
@@ -16,8 +23,38 @@ const (
 )
 
 // optim maps optimization algorithm names to their actual implementations
-var optim = map[string]optimize.Method{
-	"bfgs": &optimize.BFGS{},
+var optim = map[string]func(*config.OptimConfig) optimize.Method{
+	"bfgs": func(c *config.OptimConfig) optimize.Method {
+		return &optimize.BFGS{Linesearcher: lineSearcher(c)}
+	},
+	"lbfgs": func(c *config.OptimConfig) optimize.Method {
+		return &optimize.LBFGS{Linesearcher: lineSearcher(c), Store: c.Memory}
+	},
+	"cg": func(c *config.OptimConfig) optimize.Method {
+		return &optimize.CG{Linesearcher: lineSearcher(c), Variant: cgVariant[c.Variant]()}
+	},
+}
+
+// cgVariant maps conjugate gradient formulas to their implementations
+var cgVariant = map[string]func() optimize.CGVariant{
+	"":   func() optimize.CGVariant { return &optimize.PolakRibierePolyak{} },
+	"fr": func() optimize.CGVariant { return &optimize.FletcherReeves{} },
+	"pr": func() optimize.CGVariant { return &optimize.PolakRibierePolyak{} },
+	"hs": func() optimize.CGVariant { return &optimize.HestenesStiefel{} },
+	"dy": func() optimize.CGVariant { return &optimize.DaiYuan{} },
+	"hz": func() optimize.CGVariant { return &optimize.HagerZhang{} },
+}
+
+// lineSearcher returns More-Thuente line search with the configured parameters
+// or nil if no line search is configured, which selects the default line search
+func lineSearcher(c *config.OptimConfig) optimize.Linesearcher {
+	if c.LineSearch == nil {
+		return nil
+	}
+	return &optimize.MoreThuente{
+		DecreaseFactor:  c.LineSearch.Decrease,
+		CurvatureFactor: c.LineSearch.Curvature,
+	}
 }
 
 // kindMap maps strings to NetworkKind
@@ -38,19 +75,51 @@ func (n NetworkKind) String() string {
 	}
 }
 
+// classifyBatch is a number of samples classified at once by ClassifyContext
+const classifyBatch = 256
+
+// defaultGradThreshold is gradient norm at which full-batch optimization stops unless tolerance is configured
+const defaultGradThreshold = 1e-6
+
 // network maps supported neural network types to their constructors
 var network = map[string]func(*config.NetArch) (*Network, error){
 	"feedfwd": createFeedFwdNetwork,
 }
 
-// Network represents Neural Network
+// Network represents Neural Network.
+// Network is safe for concurrent use: methods which only read the network, such as ForwardProp,
+// Classify or Validate, can be called from many goroutines at the same time. They never modify
+// the network and allocate all the intermediate results per call. Methods which modify the network,
+// such as Train or SetParams, wait for all running reads to finish and block new reads until they
+// are done. Layers returned by Layers must not be modified while the network is being used.
 type Network struct {
+	// mu guards network layers and weights
+	mu     sync.RWMutex
 	id     string
 	kind   NetworkKind
 	layers []*Layer
+	// heads are additional network outputs attached to the network trunk
+	heads []*Head
+	// branches are network input branches
+	branches []*Branch
+	// precision is network forward propagation precision
+	precision Precision
+	// fast requests fast approximate activation functions for inference
+	fast bool
+	// device multiplies network matrices: nil means CPU
+	device matrix.Device
+	// online is Trainer used by PartialFit
+	online *Trainer
+	// metadata contains arbitrary key/value pairs saved along with the network
+	metadata map[string]string
+	// preprocess contains transformers which preprocess the network input
+	preprocess []dataset.Transformer
+	// thresholds are per-class decision thresholds honored by Classify
+	thresholds []float64
 }
 
 // NewNetwork creates new Neural Network based on the passed in configuration parameters.
+// Networks created with the same non-zero configuration Seed are initialized with the same weights.
 // It fails with error if either the requested network type is not supported or
 // if any of the neural network layers failed to be created.
 func NewNetwork(c *config.NetConfig) (*Network, error) {
@@ -63,8 +132,56 @@ func NewNetwork(c *config.NetConfig) (*Network, error) {
 	if !ok {
 		return nil, fmt.Errorf("Unsupported neural network type: %s\n", c.Kind)
 	}
+	// check if the requested precision is supported
+	p, ok := precision[c.Precision]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported precision: %s\n", c.Precision)
+	}
+	// non-zero seed makes weights initialization deterministic
+	if c.Seed != 0 {
+		matrix.Seed(c.Seed)
+	}
 	// create new network and return it
-	return createNet(c.Arch)
+	net, err := createNet(c.Arch)
+	if err != nil {
+		return nil, err
+	}
+	if err := net.SetPrecision(p); err != nil {
+		return nil, err
+	}
+	return net, nil
+}
+
+// NewNetworkFromConfig creates new Neural Network from the network configuration of the supplied
+// config, which is usually decoded from a YAML or JSON manifest by config.New, so the experiments
+// can be defined declaratively. It checks the training configuration first, so the network is only
+// created if it can also be trained as configured. It fails with error if the config is nil, if its
+// training configuration is invalid or if the network can't be created.
+func NewNetworkFromConfig(c *config.Config) (*Network, error) {
+	if c == nil {
+		return nil, fmt.Errorf("Invalid configuration: %v\n", c)
+	}
+	// mini-batch optimization methods are validated by Trainer
+	if c.Training != nil && c.Training.Optimize != nil && trainerOptim[c.Training.Optimize.Method] != nil {
+		if err := ValidateTrainerConfig(c.Training); err != nil {
+			return nil, err
+		}
+	} else if err := ValidateTrainConfig(c.Training); err != nil {
+		return nil, err
+	}
+	return NewNetwork(c.Network)
+}
+
+// NewFeedForward creates new feedforward neural network with inputs INPUT layer neurons,
+// one HIDDEN layer per each element of hidden slice and outputs OUTPUT layer neurons.
+// HIDDEN layers use sigmoid activation function, OUTPUT layer uses softmax activation function.
+// It fails with error if any of the requested layer sizes is not a positive integer.
+func NewFeedForward(inputs int, hidden []int, outputs int) (*Network, error) {
+	b := NewBuilder().Input(inputs)
+	for _, size := range hidden {
+		b.Hidden(size, Sigmoid)
+	}
+	return b.Output(outputs, Softmax).Build()
 }
 
 // createFeedFwdNetwork creates feedforward neural network or fails with error
@@ -102,7 +219,7 @@ func createFeedFwdNetwork(arch *config.NetArch) (*Network, error) {
 			return nil, err
 		}
 		// layerInSize is set to output of the previous layer
-		layerInSize = layerConfig.Size
+		layerInSize = layer.OutSize()
 	}
 	// OUTPUT layer can't be nil
 	if arch.Output == nil {
@@ -126,7 +243,11 @@ func createFeedFwdNetwork(arch *config.NetArch) (*Network, error) {
 // 2. HIDDEN layer - new HIDDEN layer is appened after the last HIDDEN layer
 // 3. OUTPUT layer - there can only be one OUTPUT layer
 // AddLayer fails with error if either 1. or 3. are not satisfied
+// If the added layer changes the number of inputs of any layer, the weights of the affected
+// layers are reinitialized. AddLayer fails with error if any layer can't accept its input.
 func (n *Network) AddLayer(layer *Layer) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
 	layerCount := len(n.layers)
 	// if no layer exists yet, just append
 	if layerCount == 0 {
@@ -143,13 +264,13 @@ func (n *Network) AddLayer(layer *Layer) error {
 			return fmt.Errorf("Duplicate %s layers not allowed\n", k)
 		}
 		// prepend INPUT layer i.e. add it at the beginning
-		n.layers = append([]*Layer{layer}, n.layers...)
+		return n.setLayers(append([]*Layer{layer}, n.layers...))
 	case OUTPUT:
 		if k == lastLayer.Kind() {
 			return fmt.Errorf("Duplicate %s layers not allowed\n", k)
 		}
 		// append OUTPUT layer i.e. add it at the end
-		n.layers = append(n.layers, layer)
+		return n.setLayers(append(n.layers[:layerCount:layerCount], layer))
 	case HIDDEN:
 		// find last hidden layer and append afterwards
 		var lastHidden int
@@ -159,33 +280,390 @@ func (n *Network) AddLayer(layer *Layer) error {
 			}
 		}
 		// append new HIDDEN layer after the last HIDDEN layer
-		n.layers = append(n.layers, nil)
-		copy(n.layers[lastHidden+2:], n.layers[lastHidden+1:])
-		n.layers[lastHidden+1] = layer
+		return n.insertLayer(lastHidden+1, layer)
 	}
 	return nil
 }
 
+// InsertLayer inserts HIDDEN layer at the position i in network layers.
+// The weights of the inserted layer and of the layer following it are reinitialized
+// if their number of inputs changes. InsertLayer fails with error if the layer is not a HIDDEN
+// layer, if the position is not between INPUT and OUTPUT layers or if any layer can't accept its input.
+func (n *Network) InsertLayer(i int, layer *Layer) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.insertLayer(i, layer)
+}
+
+// insertLayer inserts HIDDEN layer at the position i in network layers
+func (n *Network) insertLayer(i int, layer *Layer) error {
+	if layer == nil || layer.Kind() != HIDDEN {
+		return fmt.Errorf("Only %s layers can be inserted\n", HIDDEN)
+	}
+	// we can't insert before INPUT or after OUTPUT layer
+	first, last := 0, len(n.layers)
+	if last > 0 && n.layers[0].Kind() == INPUT {
+		first = 1
+	}
+	if last > 0 && n.layers[last-1].Kind() == OUTPUT {
+		last--
+	}
+	if i < first || i > last {
+		return fmt.Errorf("Can't insert layer at position: %d\n", i)
+	}
+	layers := make([]*Layer, 0, len(n.layers)+1)
+	layers = append(layers, n.layers[:i]...)
+	layers = append(layers, layer)
+	layers = append(layers, n.layers[i:]...)
+	return n.setLayers(layers)
+}
+
+// RemoveLayer removes HIDDEN layer at the position i from network layers.
+// The weights of the layer following the removed layer are reinitialized if its number of inputs
+// changes. RemoveLayer fails with error if the layer at position i does not exist, is not a HIDDEN
+// layer or if the following layer can't accept the output of the preceding layer.
+func (n *Network) RemoveLayer(i int) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if i < 0 || i > len(n.layers)-1 {
+		return fmt.Errorf("Layer does not exist: %d\n", i)
+	}
+	if k := n.layers[i].Kind(); k != HIDDEN {
+		return fmt.Errorf("Can't remove %s layer\n", k)
+	}
+	layers := make([]*Layer, 0, len(n.layers)-1)
+	layers = append(layers, n.layers[:i]...)
+	layers = append(layers, n.layers[i+1:]...)
+	return n.setLayers(layers)
+}
+
+// setLayers sets network layers to the supplied layers after making sure that every layer
+// accepts the output of its preceding layer. Layers whose number of inputs changes are resized
+// and reinitialized; convolution layers keep their kernel. It fails with error without modifying the network if any layer can't be resized.
+func (n *Network) setLayers(layers []*Layer) error {
+	// resize copies of the layers first so we don't leave the network in a broken state
+	resized, err := resizeLayers(layers)
+	if err != nil {
+		return err
+	}
+	// heads must accept the output of the new trunk
+	var headsResized [][]*Layer
+	if trunk := trunkSize(layers); trunk > 0 {
+		trunkLast := layers[trunk-1]
+		if resized[trunk-1] != nil {
+			trunkLast = resized[trunk-1]
+		}
+		for _, head := range n.heads {
+			headResized, err := resizeLayers(append([]*Layer{trunkLast}, head.layers...))
+			if err != nil {
+				return fmt.Errorf("Head %s: %s", head.name, err)
+			}
+			headsResized = append(headsResized, headResized[1:])
+		}
+	}
+	commitLayers(layers, resized)
+	for i, headResized := range headsResized {
+		commitLayers(n.heads[i].layers, headResized)
+	}
+	n.layers = layers
+	n.applyPrecision()
+	return nil
+}
+
+// resizeLayers makes sure that every layer in the supplied slice, except for the first one,
+// accepts the output of its preceding layer. It returns a slice which contains resized copies
+// of the layers whose number of inputs had to change and nils for the layers that don't need to change.
+// It fails with error if any of the layers can't be resized.
+func resizeLayers(layers []*Layer) ([]*Layer, error) {
+	resized := make([]*Layer, len(layers))
+	for i := 1; i < len(layers); i++ {
+		prevOut := layers[i-1].OutSize()
+		if resized[i-1] != nil {
+			prevOut = resized[i-1].OutSize()
+		}
+		if layers[i].InSize() == prevOut {
+			continue
+		}
+		layer := layers[i].Clone()
+		if err := layer.resize(prevOut); err != nil {
+			return nil, fmt.Errorf("Layer %d can't accept %d inputs: %s", i, prevOut, err)
+		}
+		resized[i] = layer
+	}
+	return resized, nil
+}
+
+// commitLayers replaces layers with their resized copies returned by resizeLayers
+func commitLayers(layers, resized []*Layer) {
+	for i, layer := range resized {
+		if layer != nil {
+			*layers[i] = *layer
+		}
+	}
+}
+
 // ID returns neural network id
-func (n Network) ID() string {
+func (n *Network) ID() string {
 	return n.id
 }
 
 // Kind returns kind of neural network
-func (n Network) Kind() NetworkKind {
+func (n *Network) Kind() NetworkKind {
 	return n.kind
 }
 
 // Layers returns network layers in slice sorted from INPUT to OUTPUT layer
-func (n Network) Layers() []*Layer {
+func (n *Network) Layers() []*Layer {
 	return n.layers
 }
 
+// Clone returns a deep copy of the neural network.
+// Every network layer is cloned, so the returned network can be modified
+// or trained without affecting the original network.
+func (n *Network) Clone() *Network {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.clone()
+}
+
+// clone returns a deep copy of the network without locking it
+func (n *Network) clone() *Network {
+	net := &Network{
+		id:        n.id,
+		kind:      n.kind,
+		layers:    make([]*Layer, len(n.layers)),
+		precision: n.precision,
+		fast:      n.fast,
+		device:    n.device,
+	}
+	if n.metadata != nil {
+		net.metadata = copyMetadata(n.metadata)
+	}
+	if n.preprocess != nil {
+		net.preprocess = append([]dataset.Transformer{}, n.preprocess...)
+	}
+	if n.thresholds != nil {
+		net.thresholds = append([]float64{}, n.thresholds...)
+	}
+	for i, layer := range n.layers {
+		net.layers[i] = layer.Clone()
+	}
+	for _, head := range n.heads {
+		net.heads = append(net.heads, head.Clone())
+	}
+	for _, branch := range n.branches {
+		net.branches = append(net.branches, branch.Clone())
+	}
+	return net
+}
+
+// paramLayers returns all network layers which have weights: trainable network layers
+// followed by the layers of all network heads
+func (n *Network) paramLayers() []*Layer {
+	layers := n.trainLayers()
+	for _, head := range n.heads {
+		layers = append(layers, head.layers...)
+	}
+	return layers
+}
+
+// Params returns all network weights unrolled into a single slice. The weights are ordered
+// layer by layer: network layers following the INPUT layer, HIDDEN layers of input branches
+// and the layers of network heads. Each layer's weights matrix is unrolled column by column.
+func (n *Network) Params() []float64 {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return netWeights(n.paramLayers())
+}
+
+// SetParams sets all network weights to the values supplied in params slice. The params must
+// be ordered the same way as the slice returned by Params. It fails with error if the length
+// of the supplied slice does not match the number of network weights.
+func (n *Network) SetParams(params []float64) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	layers := n.paramLayers()
+	count := 0
+	for _, layer := range layers {
+		r, c := layer.Weights().Dims()
+		count += r * c
+	}
+	if len(params) != count {
+		return fmt.Errorf("Incorrect number of params. Expected: %d, Supplied: %d\n", count, len(params))
+	}
+	return setNetWeights(layers, params)
+}
+
+// CopyWeightsFrom copies weights from other network into the network. Network layers are matched
+// by their index in the network, input branches and heads are matched by name and their layers by
+// index. Weights are copied only between layers of the same kind whose weights dimensions agree.
+// CopyWeightsFrom returns the list of layers whose weights were not copied along with the reason.
+// It fails with error if the supplied network is nil.
+func (n *Network) CopyWeightsFrom(other *Network) ([]string, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if other == nil {
+		return nil, fmt.Errorf("Incorrect network supplied: %v\n", other)
+	}
+	if other != n {
+		other.mu.RLock()
+		defer other.mu.RUnlock()
+	}
+	var skipped []string
+	copyLayers := func(name string, dst, src []*Layer) {
+		for i, layer := range dst {
+			if layer.Kind() == INPUT {
+				continue
+			}
+			if i >= len(src) {
+				skipped = append(skipped, fmt.Sprintf("%s.%d: no matching layer", name, i))
+				continue
+			}
+			if layer.Kind() != src[i].Kind() {
+				skipped = append(skipped, fmt.Sprintf("%s.%d: kind mismatch %s != %s",
+					name, i, layer.Kind(), src[i].Kind()))
+				continue
+			}
+			weights := new(mat.Dense)
+			weights.CloneFrom(src[i].Weights())
+			if err := layer.SetWeights(weights); err != nil {
+				skipped = append(skipped, fmt.Sprintf("%s.%d: %s", name, i, strings.TrimSpace(err.Error())))
+			}
+		}
+	}
+	copyLayers("layer", n.layers, other.layers)
+	for _, branch := range n.branches {
+		src := []*Layer{}
+		for _, b := range other.branches {
+			if b.name == branch.name {
+				src = b.layers
+			}
+		}
+		copyLayers(branch.name, branch.layers, src)
+	}
+	for _, head := range n.heads {
+		src := []*Layer{}
+		for _, h := range other.heads {
+			if h.name == head.name {
+				src = h.layers
+			}
+		}
+		copyLayers(head.name, head.layers, src)
+	}
+	return skipped, nil
+}
+
+// Summary returns a human readable report of the network architecture.
+// The report lists every network layer along with its kind, id, type, number of inputs
+// and outputs, activation function and number of trainable parameters, followed by
+// the total number of trainable parameters of the whole network.
+func (n *Network) Summary() string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Network: %s (%s)\n", n.id, n.kind)
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Layer\tKind\tID\tType\tIn\tOut\tActivation\tParams")
+	total := 0
+	for i, layer := range n.layers {
+		activation := layer.Activation()
+		if activation == "" {
+			activation = "-"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%d\t%s\t%d\n", i, layer.Kind(), layer.ID(),
+			layer.Type(), layer.InSize(), layer.OutSize(), activation, layer.ParamCount())
+		total += layer.ParamCount()
+	}
+	for _, branch := range n.branches {
+		for i, layer := range branch.layers {
+			fmt.Fprintf(w, "%s.%d\t%s\t%s\t%s\t%d\t%d\t%s\t%d\n", branch.name, i, layer.Kind(),
+				layer.ID(), layer.Type(), layer.InSize(), layer.OutSize(), layer.Activation(),
+				layer.ParamCount())
+			total += layer.ParamCount()
+		}
+	}
+	for _, head := range n.heads {
+		for i, layer := range head.layers {
+			fmt.Fprintf(w, "%s.%d\t%s\t%s\t%s\t%d\t%d\t%s\t%d\n", head.name, i, layer.Kind(),
+				layer.ID(), layer.Type(), layer.InSize(), layer.OutSize(), layer.Activation(),
+				layer.ParamCount())
+			total += layer.ParamCount()
+		}
+	}
+	w.Flush()
+	fmt.Fprintf(&buf, "Total trainable params: %d\n", total)
+	return buf.String()
+}
+
+// DOT returns Graphviz DOT description of the network topology.
+// Every layer is rendered as a node labeled with layer kind, id, type, activation function
+// and dimensions; edges connect layers in the direction of forward propagation.
+func (n *Network) DOT() string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "digraph \"%s\" {\n", n.id)
+	fmt.Fprintln(&buf, "\trankdir=LR;")
+	fmt.Fprintln(&buf, "\tnode [shape=record];")
+	for i, layer := range n.layers {
+		fmt.Fprintf(&buf, "\t%s [label=\"%s\"];\n", dotNode(i), dotLabel(layer))
+	}
+	for i := 1; i < len(n.layers); i++ {
+		fmt.Fprintf(&buf, "\t%s -> %s;\n", dotNode(i-1), dotNode(i))
+	}
+	// branches are concatenated into INPUT layer
+	for b, branch := range n.branches {
+		for i, layer := range branch.layers {
+			node := fmt.Sprintf("branch%d_%d", b, i)
+			fmt.Fprintf(&buf, "\t%s [label=\"%s\"];\n", node, branch.name+"|"+dotLabel(layer))
+			next := fmt.Sprintf("branch%d_%d", b, i+1)
+			if i == len(branch.layers)-1 {
+				next = dotNode(0)
+			}
+			fmt.Fprintf(&buf, "\t%s -> %s;\n", node, next)
+		}
+	}
+	// heads are attached to the last trunk layer
+	trunkLast := dotNode(trunkSize(n.layers) - 1)
+	for h, head := range n.heads {
+		prev := trunkLast
+		for i, layer := range head.layers {
+			node := fmt.Sprintf("head%d_%d", h, i)
+			fmt.Fprintf(&buf, "\t%s [label=\"%s\"];\n", node, head.name+"|"+dotLabel(layer))
+			fmt.Fprintf(&buf, "\t%s -> %s;\n", prev, node)
+			prev = node
+		}
+	}
+	fmt.Fprintln(&buf, "}")
+	return buf.String()
+}
+
+// dotNode returns DOT node name of i-th network layer
+func dotNode(i int) string {
+	return fmt.Sprintf("layer%d", i)
+}
+
+// dotLabel returns DOT record label describing the supplied layer
+func dotLabel(layer *Layer) string {
+	label := fmt.Sprintf("{%s|%s|%s", layer.Kind(), layer.ID(), layer.Type())
+	if activation := layer.Activation(); activation != "" {
+		label += "|" + activation
+	}
+	return label + fmt.Sprintf("|%d → %d}", layer.InSize(), layer.OutSize())
+}
+
 // ForwardProp performs forward propagation for a given input up to a specified network layer.
 // It recursively activates all layers in the network and returns the output in a matrix
 // It fails with error if requested end layer index is beyond all available layers or if
 // the supplied input data is nil.
-func (n *Network) ForwardProp(inMx mat64.Matrix, toLayer int) (mat64.Matrix, error) {
+func (n *Network) ForwardProp(inMx mat.Matrix, toLayer int) (mat.Matrix, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.forwardProp(inMx, toLayer)
+}
+
+// forwardProp performs forward propagation up to the specified network layer
+func (n *Network) forwardProp(inMx mat.Matrix, toLayer int) (mat.Matrix, error) {
 	if inMx == nil {
 		return nil, fmt.Errorf("Can't forward propagate input: %v\n", inMx)
 	}
@@ -195,12 +673,17 @@ func (n *Network) ForwardProp(inMx mat64.Matrix, toLayer int) (mat64.Matrix, err
 	if toLayer < 0 || toLayer > len(layers)-1 {
 		return nil, fmt.Errorf("Cant propagate beyond network layers: %d\n", len(layers))
 	}
+	// propagate through INPUT layer and input branches
+	out, _, err := n.inputFwd(inMx)
+	if err != nil || toLayer == 0 {
+		return out, err
+	}
 	// calculate the propagation
-	return n.doForwardProp(inMx, 0, toLayer)
+	return n.doForwardProp(out, 1, toLayer)
 }
 
 // doForwProp perform the actual forward propagation
-func (n *Network) doForwardProp(inMx mat64.Matrix, from, to int) (mat64.Matrix, error) {
+func (n *Network) doForwardProp(inMx mat.Matrix, from, to int) (mat.Matrix, error) {
 	// get all the layers
 	layers := n.Layers()
 	// pick starting layer
@@ -220,7 +703,14 @@ func (n *Network) doForwardProp(inMx mat64.Matrix, from, to int) (mat64.Matrix,
 // from layer specified via parameter and calculates error deltas for each network layer.
 // It fails with error if either the supplied input and delta matrices are nil or if the specified
 // from boundary goes beyond the first network layer that can have output errors calculated
-func (n *Network) BackProp(inMx, errMx mat64.Matrix, fromLayer int) error {
+func (n *Network) BackProp(inMx, errMx mat.Matrix, fromLayer int) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.backPropagate(inMx, errMx, fromLayer)
+}
+
+// backPropagate performs back propagation from the specified network layer
+func (n *Network) backPropagate(inMx, errMx mat.Matrix, fromLayer int) error {
 	if inMx == nil {
 		return fmt.Errorf("Can't backpropagate input: %v\n", inMx)
 	}
@@ -239,55 +729,81 @@ func (n *Network) BackProp(inMx, errMx mat64.Matrix, fromLayer int) error {
 }
 
 // doBackProp performs the actual backpropagation
-func (n *Network) doBackProp(inMx, errMx mat64.Matrix, from, to int) error {
+func (n *Network) doBackProp(inMx, errMx mat.Matrix, from, to int) error {
 	// get all the layers
 	layers := n.Layers()
-	// pick deltas layer
-	layer := layers[from]
-	deltasMx := layer.Deltas()
-	weightsMx := layer.Weights()
-	//forward propagate to previous layer
-	outMx, err := n.ForwardProp(inMx, from-1)
+	// propagate through INPUT layer and input branches
+	inputOut, props, err := n.inputFwd(inMx)
 	if err != nil {
 		return err
 	}
-	outMxBias := matrix.AddBias(outMx)
-	// compute deltas update
-	dMx := new(mat64.Dense)
-	dMx.Mul(errMx.T(), outMxBias)
-	// update deltas
-	deltasMx.Add(deltasMx, dMx)
-	// If we reach the 1st hidden layer we return
-	if from == to {
-		return nil
-	}
-	// errTmpMx holds layer error not accounting for bias
-	errTmpMx := new(mat64.Dense)
-	errTmpMx.Mul(weightsMx.T(), errMx.T())
-	r, c := errTmpMx.Dims()
-	// avoid bias
-	layerErr := errTmpMx.View(1, 0, r-1, c).(*mat64.Dense)
-	// pre-activation unit
-	actInMx, err := n.ForwardProp(inMx, from-2)
+	// forward propagate up to the from layer and remember layer inputs and activation inputs
+	ins, actIns, outMx, err := fwdProp(layers[1:from], inputOut)
 	if err != nil {
 		return err
 	}
-	biasActInMx := matrix.AddBias(actInMx)
-	// pick errLayer
-	weightsErrLayer := layers[from-1]
-	weightsErrMx := weightsErrLayer.Weights()
-	// compute gradient matrix
-	gradMx := new(mat64.Dense)
-	gradMx.Mul(biasActInMx, weightsErrMx.T())
-	gradMx.Apply(weightsErrLayer.ActGrad(), gradMx)
-	gradMx.MulElem(layerErr.T(), gradMx)
-	return n.doBackProp(inMx, gradMx, from-1, to)
+	ins = append([]mat.Matrix{inMx}, append(ins, outMx)...)
+	actIns = append([]*mat.Dense{nil}, append(actIns, nil)...)
+	// backpropagate into input branches if we reach the 1st hidden layer
+	propBranches := to == 1 && len(n.branches) > 0
+	inErrMx := backProp(layers[to:from+1], ins[to:], actIns[to:], errMx, propBranches)
+	if propBranches {
+		n.branchesBackProp(props, inErrMx)
+	}
+	return nil
 }
 
-// costMap maps name of cost to their actual implementations
-var trainCost = map[string]Cost{
+// fwdProp propagates the input through the supplied layers. Apart from the output of the last
+// layer it returns the inputs and the activation inputs of all the layers, which are needed
+// by backProp. It fails with error if any of the layers fails to calculate its output.
+func fwdProp(layers []*Layer, inMx mat.Matrix) ([]mat.Matrix, []*mat.Dense, mat.Matrix, error) {
+	ins := make([]mat.Matrix, len(layers))
+	actIns := make([]*mat.Dense, len(layers))
+	outMx := inMx
+	for i, layer := range layers {
+		ins[i] = outMx
+		var err error
+		outMx, actIns[i], err = layer.fwdOut(outMx)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return ins, actIns, outMx, nil
+}
+
+// backProp propagates the activation inputs error of the last of the supplied layers back through
+// all the layers and accumulates the deltas of every layer. ins and actIns are the layer inputs and
+// activation inputs returned by fwdProp. If propIn is true, backProp returns the error of the first
+// layer input, otherwise it returns nil.
+func backProp(layers []*Layer, ins []mat.Matrix, actIns []*mat.Dense, errMx mat.Matrix, propIn bool) *mat.Dense {
+	for i := len(layers) - 1; i >= 0; i-- {
+		layer := layers[i]
+		// compute and update deltas reusing the deltas update matrices across iterations
+		pool := layer.deltasPool()
+		dMx := layer.deltasUpdateTo(pool.Get(), errMx, ins[i])
+		layer.deltas.Add(layer.deltas, dMx)
+		pool.Put(dMx)
+		if i == 0 && !propIn {
+			break
+		}
+		// layer input error not accounting for bias
+		inErrMx := layer.inErr(errMx)
+		if i == 0 {
+			return inErrMx
+		}
+		// compute previous layer activation inputs error
+		errMx = layers[i-1].actInErr(inErrMx, actIns[i-1])
+	}
+	return nil
+}
+
+// trainCost maps name of cost to their actual implementations
+var trainCost = map[string]Loss{
 	"xentropy": CrossEntropy{},
 	"loglike":  LogLikelihood{},
+	"mse":      MSE{},
+	"huber":    NewHuber(1.0),
+	"hinge":    Hinge{},
 }
 
 // ValidateTrainConfig validates training configuration.
@@ -305,6 +821,12 @@ func ValidateTrainConfig(c *config.TrainConfig) error {
 	if c.Lambda < 0 {
 		return fmt.Errorf("Incorrect regularizer supplied: %f\n", c.Lambda)
 	}
+	if c.L1 < 0 {
+		return fmt.Errorf("Incorrect L1 regularizer supplied: %f\n", c.L1)
+	}
+	if err := validateLossConfig(c); err != nil {
+		return err
+	}
 	// if the optimization method is not supported
 	if _, ok := optim[c.Optimize.Method]; !ok {
 		return fmt.Errorf("Unsupported optimization method: %s\n", c.Optimize.Method)
@@ -313,12 +835,52 @@ func ValidateTrainConfig(c *config.TrainConfig) error {
 	if c.Optimize.Iterations <= 0 {
 		return fmt.Errorf("Incorrect number of iterations: %d\n", c.Optimize.Iterations)
 	}
+	if c.Optimize.Memory < 0 {
+		return fmt.Errorf("Incorrect lbfgs memory: %d\n", c.Optimize.Memory)
+	}
+	if _, ok := cgVariant[c.Optimize.Variant]; !ok {
+		return fmt.Errorf("Unsupported conjugate gradient variant: %s\n", c.Optimize.Variant)
+	}
+	if c.Optimize.Tolerance < 0 {
+		return fmt.Errorf("Incorrect tolerance: %f\n", c.Optimize.Tolerance)
+	}
+	if ls := c.Optimize.LineSearch; ls != nil {
+		// zero curvature factor defaults to 0.9
+		curvature := ls.Curvature
+		if curvature == 0 {
+			curvature = 0.9
+		}
+		if ls.Decrease < 0 || curvature < 0 || curvature >= 1 || ls.Decrease >= curvature {
+			return fmt.Errorf("Incorrect line search. Decrease: %f, Curvature: %f\n",
+				ls.Decrease, ls.Curvature)
+		}
+	}
 	return nil
 }
 
 // Train trains feedforward neural network per configuration passed in as parameter.
 // It returns error if either the training configuration is invalid ot the training fails.
-func (n *Network) Train(c *config.TrainConfig, inMx *mat64.Dense, labelsVec *mat64.Vector) error {
+func (n *Network) Train(c *config.TrainConfig, inMx *mat.Dense, labelsVec *mat.VecDense) error {
+	return n.TrainContext(context.Background(), c, inMx, labelsVec)
+}
+
+// TrainContext trains feedforward neural network per configuration passed in as parameter.
+// Training stops when the supplied context is cancelled or its deadline expires. The network
+// is then left with the best weights found so far and the context error is returned.
+// Training which requests mini-batch optimization method, such as sgd, is delegated to Trainer.
+// It returns error if either the training configuration is invalid ot the training fails.
+func (n *Network) TrainContext(ctx context.Context, c *config.TrainConfig, inMx *mat.Dense,
+	labelsVec *mat.VecDense) error {
+	// mini-batch optimization methods are run by Trainer
+	if c != nil && c.Optimize != nil && trainerOptim[c.Optimize.Method] != nil {
+		t, err := NewTrainer(c)
+		if err != nil {
+			return err
+		}
+		return t.TrainContext(ctx, n, inMx, labelsVec)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
 	// validate the supplied configuration
 	if err := ValidateTrainConfig(c); err != nil {
 		return err
@@ -331,9 +893,66 @@ func (n *Network) Train(c *config.TrainConfig, inMx *mat64.Dense, labelsVec *mat
 	if labelsVec == nil {
 		return fmt.Errorf("Incorrect lables supplied: %v\n", labelsVec)
 	}
+	// non-zero seed makes the noise deterministic
+	if c.Seed != 0 {
+		matrix.Seed(c.Seed)
+	}
+	// switch layers into training mode
+	n.setTraining(true)
+	defer n.setTraining(false)
+	// costFunc for optimization
+	costFunc := func(x []float64) (float64, error) {
+		return n.getCost(c, x, inMx, labelsVec)
+	}
+	// gradfunc for optimization
+	gradFunc := func(x []float64) ([]float64, error) {
+		return n.getGradient(c, x, inMx, labelsVec)
+	}
+	return optimizeWeights(ctx, c, n.trainLayers(), costFunc, gradFunc)
+}
+
+// PartialFit updates network weights with a single gradient step on the supplied samples per
+// the supplied mini-batch training configuration. It can be called repeatedly with a single sample
+// or a micro-batch to update the network incrementally from a live stream of data. Optimizer state,
+// such as momentum, is kept between the calls as long as the same configuration is supplied.
+// It returns error if either the training configuration is invalid or the update fails.
+func (n *Network) PartialFit(c *config.TrainConfig, inMx *mat.Dense, labelsVec *mat.VecDense) error {
+	n.mu.Lock()
+	t := n.online
+	if t == nil || t.c != c {
+		var err error
+		if t, err = NewTrainer(c); err != nil {
+			n.mu.Unlock()
+			return err
+		}
+		n.online = t
+	}
+	n.mu.Unlock()
+	return t.PartialFit(n, inMx, labelsVec)
+}
+
+// optimizeWeights runs the optimization method requested in training configuration over
+// the weights of the supplied layers using the supplied cost and gradient functions.
+// Both functions accept the weights of all the layers unrolled into a single slice.
+// When the optimization finishes or the context is cancelled, the layers are set to the best
+// weights found by the optimization.
+func optimizeWeights(ctx context.Context, c *config.TrainConfig, layers []*Layer,
+	cost func([]float64) (float64, error), grad func([]float64) ([]float64, error)) error {
+	setWeights := func(weights []float64) error {
+		return setNetWeights(layers, weights)
+	}
+	return optimizeParams(ctx, c, netWeights(layers), setWeights, cost, grad)
+}
+
+// optimizeParams runs the optimization method requested in training configuration starting
+// from the supplied parameters using the supplied cost and gradient functions. When the
+// optimization finishes or the context is cancelled, the best parameters found by the
+// optimization are passed to setParams.
+func optimizeParams(ctx context.Context, c *config.TrainConfig, params []float64, setParams func([]float64) error,
+	cost func([]float64) (float64, error), grad func([]float64) ([]float64, error)) error {
 	// costFunc for optimization
 	costFunc := func(x []float64) float64 {
-		curCost, err := n.getCost(c, x, inMx, labelsVec)
+		curCost, err := cost(x)
 		if err != nil {
 			panic(err)
 		}
@@ -342,33 +961,44 @@ func (n *Network) Train(c *config.TrainConfig, inMx *mat64.Dense, labelsVec *mat
 		return curCost
 	}
 	// gradfunc for optimization
-	gradFunc := func(grad []float64, x []float64) {
-		curGrad, err := n.getGradient(c, x, inMx, labelsVec)
+	gradFunc := func(g []float64, x []float64) {
+		curGrad, err := grad(x)
 		if err != nil {
 			panic(err)
 		}
-		cdata := copy(grad, curGrad)
+		cdata := copy(g, curGrad)
 		if len(curGrad) != cdata {
 			panic("Could not calculate gradient!")
 		}
 	}
-	// initialize parameters
-	var initWeights []float64
-	layers := n.Layers()
-	for i := range layers[1:] {
-		initWeights = append(initWeights, matrix.Mx2Vec(layers[i+1].Weights(), false)...)
-	}
 	// optimization problem settings
 	p := optimize.Problem{
 		Func: costFunc,
 		Grad: gradFunc,
+		// stop the optimization if the context is done
+		Status: func() (optimize.Status, error) {
+			if err := ctx.Err(); err != nil {
+				return optimize.Failure, err
+			}
+			return optimize.NotTerminated, nil
+		},
+	}
+	settings := &optimize.Settings{
+		GradientThreshold: defaultGradThreshold,
+		Converger:         optimize.NeverTerminate{},
+		MajorIterations:   c.Optimize.Iterations,
+	}
+	if c.Optimize.Tolerance > 0 {
+		settings.GradientThreshold = c.Optimize.Tolerance
 	}
-	settings := optimize.DefaultSettings()
-	settings.Recorder = nil
-	settings.FunctionConverge = nil
-	settings.MajorIterations = c.Optimize.Iterations
 	// run the optimization
-	result, err := optimize.Local(p, initWeights, settings, optim[c.Optimize.Method])
+	result, err := optimize.Minimize(p, params, settings, optim[c.Optimize.Method](c.Optimize))
+	// checkpoint the best weights found so far
+	if result != nil {
+		if err := setParams(result.X); err != nil {
+			return err
+		}
+	}
 	if err != nil {
 		return err
 	}
@@ -378,17 +1008,83 @@ func (n *Network) Train(c *config.TrainConfig, inMx *mat64.Dense, labelsVec *mat
 
 // getCost calculates the cost of the neural network output for given input and expected output.
 func (n *Network) getCost(c *config.TrainConfig, weights []float64,
-	inMx *mat64.Dense, labelsVec *mat64.Vector) (float64, error) {
-	// get all network layers
-	layers := n.Layers()
+	inMx *mat.Dense, labelsVec *mat.VecDense) (float64, error) {
 	// if we supply network weights, set the neural network to provided weights
 	if weights != nil {
-		if err := setNetWeights(layers[1:], weights); err != nil {
+		if err := setNetWeights(n.trainLayers(), weights); err != nil {
 			return -1.0, err
 		}
 	}
+	loss, err := n.trainLoss(c, labelsVec)
+	if err != nil {
+		return -1.0, err
+	}
+	return n.lossCost(loss, trainPenalty(c), inMx, labelsVec)
+}
+
+// validateLossConfig checks if the class weights and label smoothing of the supplied
+// training configuration are valid
+func validateLossConfig(c *config.TrainConfig) error {
+	if c.LabelSmoothing < 0 || c.LabelSmoothing >= 1 {
+		return fmt.Errorf("Incorrect label smoothing: %f\n", c.LabelSmoothing)
+	}
+	if c.Balanced && len(c.ClassWeights) > 0 {
+		return fmt.Errorf("Class weights can not be combined with balanced weighting\n")
+	}
+	for _, w := range c.ClassWeights {
+		if w < 0 {
+			return fmt.Errorf("Incorrect class weight: %f\n", w)
+		}
+	}
+	return nil
+}
+
+// trainLoss returns the loss of the supplied training configuration with the configured
+// label smoothing and class weights of the supplied labels
+func (n *Network) trainLoss(c *config.TrainConfig, labelsVec *mat.VecDense) (Loss, error) {
+	return configLoss(trainCost[c.Cost], c, labelsVec, n.outSize())
+}
+
+// outSize returns the output size of the last network layer or 0 if the network has no layers
+func (n *Network) outSize() int {
+	if len(n.layers) == 0 {
+		return 0
+	}
+	return n.layers[len(n.layers)-1].OutSize()
+}
+
+// configLoss returns the supplied loss with label smoothing and class weights of the supplied
+// training configuration. Balanced class weights are calculated from the supplied labels. The loss
+// is returned unchanged if neither is configured. It fails with error if the number of class weights
+// does not match the number of classes.
+func configLoss(loss Loss, c *config.TrainConfig, labelsVec *mat.VecDense, classes int) (Loss, error) {
+	if c.LabelSmoothing > 0 {
+		smooth, err := NewLabelSmoothing(loss, c.LabelSmoothing)
+		if err != nil {
+			return nil, err
+		}
+		loss = smooth
+	}
+	weights := c.ClassWeights
+	if c.Balanced {
+		weights = BalancedWeights(labelsVec, classes)
+	}
+	if len(weights) == 0 {
+		return loss, nil
+	}
+	if len(weights) != classes {
+		return nil, fmt.Errorf("Class weights mismatch. Classes: %d, Weights: %d\n", classes, len(weights))
+	}
+	return NewClassWeighted(loss, weights)
+}
+
+// lossCost calculates the loss of the neural network output for given input and expected output
+// plus the regularization cost of the weights of all trainable layers
+func (n *Network) lossCost(loss Loss, p penalty, inMx *mat.Dense, labelsVec *mat.VecDense) (float64, error) {
+	// get all network layers
+	layers := n.Layers()
 	// run forward propagation from INPUT layer
-	outMx, err := n.ForwardProp(inMx, len(layers)-1)
+	outMx, err := n.forwardProp(inMx, len(layers)-1)
 	if err != nil {
 		return -1.0, err
 	}
@@ -400,147 +1096,180 @@ func (n *Network) getCost(c *config.TrainConfig, weights []float64,
 		return -1.0, err
 	}
 	// calculate cost
-	tc, _ := trainCost[c.Cost]
-	cost := tc.CostFunc(inMx, outMx, labelsMx)
+	cost := loss.Cost(outMx, labelsMx)
 	// number of data samples
 	samples, _ := inMx.Dims()
-	reg := 0.0
-	// if regularizer is not 0, calculate L2-regularization
-	if c.Lambda > 0 {
-		// Ignore first layer i.e. input layer
-		for _, layer := range layers[1:] {
-			r, c := layer.Weights().Dims()
-			// Don't penalize bias units
-			weightsMx := layer.Weights().View(0, 1, r, c-1)
-			sqrMx := new(mat64.Dense)
-			sqrMx.Apply(matrix.PowMx(2), weightsMx)
-			reg += mat64.Sum(sqrMx)
-		}
-		reg = (c.Lambda / (2 * float64(samples))) * reg
-	}
+	reg := regCost(n.trainLayers(), p, samples)
 	return cost + reg, nil
 }
 
 // getGradient calculates network gradient for a particular network and configuration
 // It returns a gradient slice or fails with error
 func (n *Network) getGradient(c *config.TrainConfig, weights []float64,
-	inMx *mat64.Dense, labelsVec *mat64.Vector) ([]float64, error) {
-	// get all network layers
-	layers := n.Layers()
+	inMx *mat.Dense, labelsVec *mat.VecDense) ([]float64, error) {
 	// if we supply network weights, set the neural network to provided weights
 	if weights != nil {
-		if err := setNetWeights(layers[1:], weights); err != nil {
+		if err := setNetWeights(n.trainLayers(), weights); err != nil {
 			return nil, err
 		}
 	}
-	// run full forward propagation
-	outMx, err := n.ForwardProp(inMx, len(layers)-1)
+	loss, err := n.trainLoss(c, labelsVec)
 	if err != nil {
 		return nil, err
 	}
+	if err := n.lossDeltas(loss, inMx, labelsVec); err != nil {
+		return nil, err
+	}
+	samples, _ := inMx.Dims()
+	// calculate the gradient of all trainable layers
+	return layersGradient(n.trainLayers(), trainPenalty(c), samples), nil
+}
+
+// lossDeltas sets the deltas of all trainable network layers to the deltas of the supplied loss
+// accumulated over all the supplied samples
+func (n *Network) lossDeltas(loss Loss, inMx *mat.Dense, labelsVec *mat.VecDense) error {
+	// get all network layers
+	layers := n.Layers()
+	last := len(layers) - 1
+	// deltas are accumulated from scratch
+	resetDeltas(n.trainLayers())
+	// run full forward propagation and remember the output layer activation inputs
+	hiddenMx, err := n.forwardProp(inMx, last-1)
+	if err != nil {
+		return err
+	}
+	outMx, actInMx, err := layers[last].fwdOut(hiddenMx)
+	if err != nil {
+		return err
+	}
 	// labelsMx is one-of-N matrix for each output label
 	// i.e. 3rd label would be: 0 0 1 0 0 etc.
 	_, labelCount := outMx.Dims()
 	labelsMx, err := matrix.MakeLabelsMx(labelsVec, labelCount)
 	if err != nil {
-		return nil, err
-	}
-	// number of data samples
-	samples, _ := inMx.Dims()
-	// iterate through all samples and calculate errors and corrections
-	for i := 0; i < samples; i++ {
-		// input vector
-		inVec := inMx.RowView(i)
-		// expected output
-		expVec := labelsMx.RowView(i)
-		// output from output layer - safe switch type - ForwardProp returns *mat64.Dense
-		outVec := (outMx.(*mat64.Dense)).RowView(i)
-		// calculate the error = out - y
-		tc, _ := trainCost[c.Cost]
-		deltaVec := tc.Delta(outVec, expVec)
-		// run the backpropagation
-		if err := n.BackProp(inVec.T(), deltaVec.T(), len(layers)-1); err != nil {
-			return nil, err
-		}
+		return err
 	}
-	// calculate the gradient and update network weights
-	var gradient []float64
-	// skip zero layer - INPUT layer has no Deltas
-	for i := 1; i < len(layers); i++ {
-		layer := layers[i]
-		deltas := layer.Deltas()
-		deltas.Scale(1/float64(samples), deltas)
-		if c.Lambda > 0.0 {
-			rows, cols := layer.Weights().Dims()
-			regWeights := mat64.NewDense(rows, cols, nil)
-			reg := c.Lambda / float64(samples)
-			regWeights.Clone(layer.Weights())
-			// set the first column to 0
-			zeros := make([]float64, rows)
-			regWeights.SetCol(0, zeros)
-			regWeights.Scale(reg, regWeights)
-			// Update particular layer deltas matrix
-			regWeights.Add(deltas, regWeights)
-			gradVec := matrix.Mx2Vec(regWeights, false)
-			gradient = append(gradient, gradVec...)
-		}
-	}
-	return gradient, nil
+	// calculate the output error of all samples and backpropagate it
+	deltaMx := outputErr(loss, layers[last], outMx, actInMx, labelsMx)
+	return n.backPropagate(inMx, deltaMx, last)
 }
 
 // Classify classifies the provided data vector to a particular label class.
 // It returns a matrix that contains probabilities of the input belonging to a particular class
+// expressed in percents. If the network has decision thresholds, the probabilities of classes below
+// their thresholds are set to zero, so the most probable class of every sample reaches its threshold.
+// Samples with all the classes below their thresholds are left unchanged. The data is preprocessed
+// by the network preprocessing transformers, if any, before it is classified.
 // It returns error if the network forward propagation fails at any point during classification.
-func (n *Network) Classify(inMx mat64.Matrix) (mat64.Matrix, error) {
+func (n *Network) Classify(inMx mat.Matrix) (mat.Matrix, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
 	if inMx == nil {
 		return nil, fmt.Errorf("Can't classify %v\n", inMx)
 	}
+	inMx, err := n.preprocessed(inMx)
+	if err != nil {
+		return nil, err
+	}
 	// do forward propagation
-	out, err := n.ForwardProp(inMx, len(n.Layers())-1)
+	out, err := n.forwardProp(inMx, len(n.Layers())-1)
+	if err != nil {
+		return nil, err
+	}
+	samples, _ := inMx.Dims()
+	classMx := classProbs(out, samples)
+	applyThresholds(classMx, n.thresholds)
+	return classMx, nil
+}
+
+// ClassifyContext classifies the provided data the same way as Classify does. The data is
+// classified in batches of samples and the classification stops when the supplied context is
+// cancelled or its deadline expires, in which case the context error is returned.
+func (n *Network) ClassifyContext(ctx context.Context, inMx mat.Matrix) (mat.Matrix, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if inMx == nil {
+		return nil, fmt.Errorf("Can't classify %v\n", inMx)
+	}
+	inMx, err := n.preprocessed(inMx)
 	if err != nil {
 		return nil, err
 	}
 	samples, _ := inMx.Dims()
+	denseInMx := asDense(inMx)
+	var classMx *mat.Dense
+	for i := 0; i < samples; i += classifyBatch {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		rows := classifyBatch
+		if i+rows > samples {
+			rows = samples - i
+		}
+		out, err := n.forwardProp(denseInMx.Slice(i, i+rows, 0, denseInMx.RawMatrix().Cols), len(n.layers)-1)
+		if err != nil {
+			return nil, err
+		}
+		batchMx := classProbs(out, rows)
+		applyThresholds(batchMx, n.thresholds)
+		if classMx == nil {
+			_, cols := batchMx.Dims()
+			classMx = mat.NewDense(samples, cols, nil)
+		}
+		classMx.Slice(i, i+batchMx.RawMatrix().Rows, 0, batchMx.RawMatrix().Cols).(*mat.Dense).Copy(batchMx)
+	}
+	return classMx, nil
+}
+
+// classProbs scales network output to class probabilities expressed in percents
+func classProbs(out mat.Matrix, samples int) *mat.Dense {
 	_, results := out.Dims()
 	// classification matrix
-	classMx := mat64.NewDense(samples, results, nil)
+	classMx := mat.NewDense(samples, results, nil)
 	switch o := out.(type) {
-	case *mat64.Dense:
+	case *mat.Dense:
 		for i := 0; i < samples; i++ {
-			row := new(mat64.Dense)
-			row.Clone(o.RowView(i))
-			sum := mat64.Sum(row)
+			row := new(mat.Dense)
+			row.CloneFrom(o.RowView(i))
+			sum := mat.Sum(row)
 			row.Scale(100.0/sum, row)
 			data := matrix.Mx2Vec(row, true)
 			classMx.SetRow(i, data)
 		}
-	case *mat64.Vector:
-		sum := mat64.Sum(o)
-		tmp := new(mat64.Dense)
+	case *mat.VecDense:
+		sum := mat.Sum(o)
+		tmp := new(mat.Dense)
 		tmp.Scale(100.0/sum, o)
 		data := matrix.Mx2Vec(tmp, true)
 		classMx.SetRow(0, data)
 	}
-	return classMx, nil
+	return classMx
 }
 
 // Validate runs forward propagation on the validation data set through neural network.
 // It returns the percentage of successful classifications or error.
-func (n *Network) Validate(valInMx *mat64.Dense, valOut *mat64.Vector) (float64, error) {
+func (n *Network) Validate(valInMx *mat.Dense, valOut *mat.VecDense) (float64, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.validate(valInMx, valOut)
+}
+
+// validate returns the percentage of successful classifications of the validation data set
+func (n *Network) validate(valInMx *mat.Dense, valOut *mat.VecDense) (float64, error) {
 	// validation set can't be nil
 	if valInMx == nil || valOut == nil {
 		return 0.0, fmt.Errorf("Cant validate data set. In: %v, Out: %v\n", valInMx, valOut)
 	}
-	out, err := n.ForwardProp(valInMx, len(n.Layers())-1)
+	out, err := n.forwardProp(valInMx, len(n.Layers())-1)
 	if err != nil {
 		return 0.0, err
 	}
 	rows, _ := out.Dims()
-	outMx := out.(*mat64.Dense)
+	outMx := out.(*mat.Dense)
 	hits := 0.0
 	for i := 0; i < rows; i++ {
 		row := outMx.RowView(i)
-		max := mat64.Max(row)
+		max := mat.Max(row)
 		for j := 0; j < row.Len(); j++ {
 			if row.At(j, 0) == max {
 				if j+1 == int(valOut.At(i, 0)) {
@@ -554,6 +1283,141 @@ func (n *Network) Validate(valInMx *mat64.Dense, valOut *mat64.Vector) (float64,
 	return success, nil
 }
 
+// penalty holds regularization parameters of layer weights
+type penalty struct {
+	// l1 is L1 regularization parameter
+	l1 float64
+	// l2 is L2 regularization parameter
+	l2 float64
+}
+
+// trainPenalty returns regularization parameters of the supplied training configuration.
+// Non-zero L1 and L2 parameters combined give elastic net regularization.
+func trainPenalty(c *config.TrainConfig) penalty {
+	return penalty{l1: c.L1, l2: c.Lambda}
+}
+
+// regCost calculates L1 and L2 regularization cost of the weights of the supplied layers.
+// Bias weights are not regularized.
+func regCost(layers []*Layer, p penalty, samples int) float64 {
+	if p.l1 <= 0 && p.l2 <= 0 {
+		return 0.0
+	}
+	l1, l2 := 0.0, 0.0
+	for _, layer := range layers {
+		r, c := layer.Weights().Dims()
+		// Don't penalize bias units
+		weightsMx := layer.maskedWeights().Slice(0, r, 1, c)
+		sqrMx := new(mat.Dense)
+		sqrMx.Apply(matrix.PowMx(2), weightsMx)
+		l2 += mat.Sum(sqrMx)
+		absMx := new(mat.Dense)
+		absMx.Apply(func(i, j int, x float64) float64 { return math.Abs(x) }, weightsMx)
+		l1 += mat.Sum(absMx)
+	}
+	return (p.l1/float64(samples))*l1 + (p.l2/(2*float64(samples)))*l2
+}
+
+// layersGradient calculates the gradient of the supplied layers from their accumulated deltas
+// averaged over the number of samples and L1 and L2 regularization of their weights. Masked weights
+// have zero gradient. It returns the gradient of all layers unrolled into a single slice.
+func layersGradient(layers []*Layer, p penalty, samples int) []float64 {
+	var gradient []float64
+	for _, gradMx := range layersGradMx(layers, p, samples) {
+		gradient = append(gradient, matrix.Mx2Vec(gradMx, false)...)
+	}
+	return gradient
+}
+
+// layersGradMx calculates the gradient of the supplied layers the same way as layersGradient
+// does. It returns the gradient of each layer in a matrix of the same size as layer weights.
+func layersGradMx(layers []*Layer, p penalty, samples int) []*mat.Dense {
+	gradient := make([]*mat.Dense, len(layers))
+	for i, layer := range layers {
+		gradMx := new(mat.Dense)
+		gradMx.Scale(1/float64(samples), layer.Deltas())
+		if p.l1 > 0.0 || p.l2 > 0.0 {
+			regWeights := new(mat.Dense)
+			regWeights.Apply(func(i, j int, x float64) float64 {
+				// bias weights are not regularized
+				if j == 0 {
+					return 0.0
+				}
+				return (p.l1*sign(x) + p.l2*x) / float64(samples)
+			}, layer.maskedWeights())
+			gradMx.Add(gradMx, regWeights)
+		}
+		// masked weights must not be updated
+		if mask := layer.Mask(); mask != nil {
+			gradMx.MulElem(gradMx, mask)
+		}
+		gradient[i] = gradMx
+	}
+	return gradient
+}
+
+// sign returns the sign of x: -1, 0 or 1
+func sign(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1.0
+	case x < 0:
+		return -1.0
+	}
+	return 0.0
+}
+
+// resetDeltas sets the deltas of the supplied layers to zero values in place
+func resetDeltas(layers []*Layer) {
+	for _, layer := range layers {
+		if deltas := layer.Deltas(); deltas != nil {
+			deltas.Zero()
+		}
+	}
+}
+
+// trainLayers returns all network layers which have trainable weights: network layers
+// following the INPUT layer and HIDDEN layers of all input branches
+func (n *Network) trainLayers() []*Layer {
+	layers := append([]*Layer{}, n.layers[1:]...)
+	for _, branch := range n.branches {
+		layers = append(layers, branch.layers[1:]...)
+	}
+	return layers
+}
+
+// allLayers returns all network layers including the layers of input branches and heads
+func (n *Network) allLayers() []*Layer {
+	layers := append([]*Layer{}, n.layers...)
+	for _, branch := range n.branches {
+		layers = append(layers, branch.layers...)
+	}
+	for _, head := range n.heads {
+		layers = append(layers, head.layers...)
+	}
+	return layers
+}
+
+// setTraining switches all network layers, including branches and heads, in or out of training mode
+func (n *Network) setTraining(training bool) {
+	for _, layer := range n.allLayers() {
+		layer.training = training
+		// weights have been updated by training
+		if !training {
+			layer.syncWeights32()
+		}
+	}
+}
+
+// netWeights returns weights of provided network layers unrolled into a single slice
+func netWeights(layers []*Layer) []float64 {
+	var weights []float64
+	for _, layer := range layers {
+		weights = append(weights, matrix.Mx2Vec(layer.Weights(), false)...)
+	}
+	return weights
+}
+
 // setNetWeights sets weights of provided network layers to values supplied via weights slice
 // The new weights are stored in weights slice which is then rolled into particular layer's
 // weights matrix layer by layer. It fails with error if the supplied weights slice
@@ -570,6 +1434,11 @@ func setNetWeights(layers []*Layer, weights []float64) error {
 		if err != nil {
 			return err
 		}
+		// keep masked weights zeroed
+		if mask := layer.Mask(); mask != nil {
+			layer.Weights().MulElem(layer.Weights(), mask)
+		}
+		layer.syncWeights32()
 		acc += r * c
 	}
 	return nil
@@ -1,23 +1,28 @@
 package neural
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
-	"github.com/gonum/matrix/mat64"
 	"github.com/milosgajdos83/go-neural/pkg/config"
 	"github.com/milosgajdos83/go-neural/pkg/matrix"
 	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
 )
 
 var (
 	fileName  = "manifest.yml"
-	inMx      *mat64.Dense
-	labelsVec *mat64.Vector
+	inMx      *mat.Dense
+	labelsVec *mat.VecDense
 )
 
 func setup() {
@@ -52,9 +57,9 @@ training:
 		4.7, 3.2, 1.3, 0.3,
 		4.6, 3.1, 1.5, 0.4,
 		5.0, 3.6, 1.4, 0.5}
-	inMx = mat64.NewDense(5, 4, features)
+	inMx = mat.NewDense(5, 4, features)
 	labels := []float64{2.0, 1.0, 3.0, 2.0, 4.0}
-	labelsVec = mat64.NewVector(len(labels), labels)
+	labelsVec = mat.NewVecDense(len(labels), labels)
 }
 
 func teardown() {
@@ -155,6 +160,62 @@ func TestNewNetwork(t *testing.T) {
 	c.Arch.Output.Size = origOutSize
 }
 
+func TestNewNetworkFromConfig(t *testing.T) {
+	assert := assert.New(t)
+	m := &config.Manifest{Kind: "feedfwd"}
+	m.Network.Input.Size = 12
+	m.Network.Layers = []config.LayerManifest{
+		{Kind: "conv1d", Size: 2, Activation: "relu"},
+		{Size: 4, Activation: "maxout", Pieces: 3},
+		{Size: 5, Activation: "tanh"},
+	}
+	m.Network.Layers[0].Conv.Width = 2
+	m.Network.Layers[0].Conv.Stride = 1
+	m.Network.Layers[0].Conv.Channels = 3
+	m.Network.Output.Size = 2
+	m.Network.Output.Activation = "softmax"
+	m.Training.Kind = "backprop"
+	m.Training.Cost = "xentropy"
+	m.Training.Optimize.Method = "bfgs"
+	c, err := config.ParseManifest(m)
+	assert.NoError(err)
+	n, err := NewNetworkFromConfig(c)
+	assert.NoError(err)
+	layers := n.Layers()
+	assert.Len(layers, 5)
+	// conv1d layer has 3 positions of 2 output channels
+	assert.Equal(6, layers[1].OutSize())
+	assert.Equal(4, layers[2].OutSize())
+	r, col := layers[2].Weights().Dims()
+	assert.Equal(12, r)
+	assert.Equal(7, col)
+	assert.Equal(5, layers[3].OutSize())
+	assert.Equal(2, layers[4].OutSize())
+	// invalid training configuration
+	c.Training.Cost = "foobar"
+	n, err = NewNetworkFromConfig(c)
+	assert.Nil(n)
+	assert.Error(err)
+	c.Training.Cost = "xentropy"
+	// invalid network configuration
+	c.Network.Arch.Hidden[1].NeurFn.Pieces = 1
+	n, err = NewNetworkFromConfig(c)
+	assert.Nil(n)
+	assert.Error(err)
+	// nil config
+	n, err = NewNetworkFromConfig(nil)
+	assert.Nil(n)
+	assert.Error(err)
+	// preset architectures
+	for _, name := range config.Presets() {
+		c, err := config.Preset(name)
+		assert.NoError(err)
+		n, err = NewNetworkFromConfig(c)
+		assert.NoError(err, "%s", name)
+		assert.NotNil(n)
+	}
+}
+
 func TestAddLayer(t *testing.T) {
 	assert := assert.New(t)
 	// basic configuration settings
@@ -251,7 +312,7 @@ func TestForwardProp(t *testing.T) {
 		4.7, 3.2, 1.3, 0.2,
 		4.6, 3.1, 1.5, 0.2,
 		5.0, 3.6, 1.4, 0.2}
-	inMx := mat64.NewDense(5, 4, features)
+	inMx := mat.NewDense(5, 4, features)
 	inRows, inCols := inMx.Dims()
 	// create test network
 	tmpPath := path.Join(os.TempDir(), fileName)
@@ -304,7 +365,7 @@ func TestForwardProp(t *testing.T) {
 	assert.Nil(out)
 	assert.Error(err)
 	// incorrect input dimensions
-	tstMx := mat64.NewDense(100, 20, nil)
+	tstMx := mat.NewDense(100, 20, nil)
 	assert.NotNil(tstMx)
 	out, err = net.ForwardProp(tstMx, len(layers)-1)
 	assert.Nil(out)
@@ -319,7 +380,7 @@ func TestBackProp(t *testing.T) {
 		4.7, 3.2, 1.3, 0.2,
 		4.6, 3.1, 1.5, 0.2,
 		5.0, 3.6, 1.4, 0.2}
-	inMx := mat64.NewDense(5, 4, features)
+	inMx := mat.NewDense(5, 4, features)
 	_, inCols := inMx.Dims()
 	// create test network
 	tmpPath := path.Join(os.TempDir(), fileName)
@@ -346,12 +407,12 @@ func TestBackProp(t *testing.T) {
 	assert.NotNil(layers)
 	// expected labels
 	expVal := []float64{2, 1, 3, 2, 4}
-	expVec := mat64.NewVector(len(expVal), expVal)
+	expVec := mat.NewVecDense(len(expVal), expVal)
 	// propagate forward to the last layer
 	out, err := net.ForwardProp(inMx, len(layers)-1)
 	assert.NotNil(out)
 	assert.NoError(err)
-	errVec := (out.(*mat64.Dense)).RowView(0)
+	errVec := (out.(*mat.Dense)).RowView(0).(*mat.VecDense)
 	errVec.SubVec(errVec, expVec)
 	// Pick a sample vector and test backprop
 	sampleVec := inMx.RowView(0)
@@ -423,6 +484,8 @@ func TestTrain(t *testing.T) {
 	assert.NoError(err)
 	// create new network
 	netConf := conf.Network
+	// fixed seed keeps line searches independent of the random draws of preceding tests
+	netConf.Seed = 1
 	n, err := NewNetwork(netConf)
 	assert.NotNil(n)
 	assert.NoError(err)
@@ -439,6 +502,66 @@ func TestTrain(t *testing.T) {
 	// calculate cost
 	err = n.Train(trainConf, inMx, labelsVec)
 	assert.NoError(err)
+	// L-BFGS optimization
+	trainConf.Optimize.Method = "lbfgs"
+	trainConf.Optimize.Memory = -1
+	err = n.Train(trainConf, inMx, labelsVec)
+	assert.Error(err)
+	trainConf.Optimize.Memory = 5
+	before, err := n.getCost(trainConf, nil, inMx, labelsVec)
+	assert.NoError(err)
+	err = n.Train(trainConf, inMx, labelsVec)
+	assert.NoError(err)
+	after, err := n.getCost(trainConf, nil, inMx, labelsVec)
+	assert.NoError(err)
+	assert.True(after <= before)
+	// conjugate gradient optimization with custom line search
+	trainConf.Optimize.Method = "cg"
+	trainConf.Optimize.Variant = "foobar"
+	err = n.Train(trainConf, inMx, labelsVec)
+	assert.Error(err)
+	trainConf.Optimize.Variant = "fr"
+	trainConf.Optimize.Tolerance = -1.0
+	err = n.Train(trainConf, inMx, labelsVec)
+	assert.Error(err)
+	trainConf.Optimize.Tolerance = 1e-4
+	trainConf.Optimize.LineSearch = &config.LineSearchConfig{Decrease: 0.5, Curvature: 0.1}
+	err = n.Train(trainConf, inMx, labelsVec)
+	assert.Error(err)
+	trainConf.Optimize.LineSearch = &config.LineSearchConfig{Decrease: 1e-4, Curvature: 0.1}
+	for _, variant := range []string{"", "fr", "pr", "hs", "dy", "hz"} {
+		trainConf.Optimize.Variant = variant
+		before, err = n.getCost(trainConf, nil, inMx, labelsVec)
+		assert.NoError(err)
+		err = n.Train(trainConf, inMx, labelsVec)
+		assert.NoError(err)
+		after, err = n.getCost(trainConf, nil, inMx, labelsVec)
+		assert.NoError(err)
+		assert.True(after <= before, variant)
+	}
+}
+
+func TestTrainContext(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	// cancelled training leaves the best weights found so far
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	params := n.Params()
+	err = n.TrainContext(ctx, conf.Training, inMx, labelsVec)
+	assert.Equal(err, context.Canceled)
+	assert.Equal(n.Params(), params)
+	// training finishes before the deadline
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	assert.NoError(n.TrainContext(ctx, conf.Training, inMx, labelsVec))
 }
 
 func TestClassify(t *testing.T) {
@@ -477,6 +600,28 @@ func TestClassify(t *testing.T) {
 	assert.Equal(oCols, netConf.Arch.Output.Size)
 }
 
+func TestClassifyContext(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NotNil(n)
+	assert.NoError(err)
+	classMx, err := n.ClassifyContext(context.Background(), nil)
+	assert.Nil(classMx)
+	assert.Error(err)
+	expMx, err := n.Classify(inMx)
+	assert.NoError(err)
+	classMx, err = n.ClassifyContext(context.Background(), inMx)
+	assert.NoError(err)
+	assert.True(mat.EqualApprox(classMx, expMx, 1e-12))
+	// cancelled classification
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	classMx, err = n.ClassifyContext(ctx, inMx)
+	assert.Nil(classMx)
+	assert.Equal(err, context.Canceled)
+}
+
 func TestValidate(t *testing.T) {
 	assert := assert.New(t)
 	// basic configuration settings
@@ -491,7 +636,7 @@ func TestValidate(t *testing.T) {
 	assert.NoError(err)
 	// expected labels
 	expVal := []float64{2, 1, 3, 2, 4}
-	expVec := mat64.NewVector(len(expVal), expVal)
+	expVec := mat.NewVecDense(len(expVal), expVal)
 	// nil input throws error
 	success, err := n.Validate(nil, expVec)
 	assert.Error(err)
@@ -538,3 +683,305 @@ func TestSetNetWeights(t *testing.T) {
 	err = setNetWeights(layers[1:], weights)
 	assert.Error(err)
 }
+
+func TestNetworkClone(t *testing.T) {
+	assert := assert.New(t)
+	// create dummy network
+	tmpPath := path.Join(os.TempDir(), fileName)
+	c, err := config.New(tmpPath)
+	assert.NotNil(c)
+	assert.NoError(err)
+	n, err := NewNetwork(c.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	clone := n.Clone()
+	assert.Equal(n.ID(), clone.ID())
+	assert.Equal(n.Kind(), clone.Kind())
+	assert.Equal(len(n.Layers()), len(clone.Layers()))
+	// clone output must be the same as the original network output
+	out, err := n.ForwardProp(inMx, len(n.Layers())-1)
+	assert.NoError(err)
+	cloneOut, err := clone.ForwardProp(inMx, len(clone.Layers())-1)
+	assert.NoError(err)
+	assert.True(mat.Equal(out, cloneOut))
+	// modifying clone weights does not modify the original network
+	clone.Layers()[1].Weights().Set(0, 0, 100.0)
+	assert.False(mat.Equal(n.Layers()[1].Weights(), clone.Layers()[1].Weights()))
+}
+
+func TestInsertRemoveLayer(t *testing.T) {
+	assert := assert.New(t)
+	// create test network
+	n, err := NewBuilder().Input(4).Hidden(5, Sigmoid).Output(3, Softmax).Build()
+	assert.NotNil(n)
+	assert.NoError(err)
+	outLayer := n.Layers()[2]
+	// only HIDDEN layers can be inserted
+	inLayer, err := NewLayer(&config.LayerConfig{Kind: "input", Size: 4}, 4)
+	assert.NoError(err)
+	assert.Error(n.InsertLayer(1, inLayer))
+	c := &config.LayerConfig{
+		Kind: "hidden",
+		Size: 7,
+		NeurFn: &config.NeuronConfig{
+			Activation: "relu",
+		},
+	}
+	layer, err := NewLayer(c, 10)
+	assert.NotNil(layer)
+	assert.NoError(err)
+	// can't insert before INPUT or after OUTPUT layer
+	assert.Error(n.InsertLayer(0, layer))
+	assert.Error(n.InsertLayer(3, layer))
+	// insert layer after the first HIDDEN layer
+	assert.NoError(n.InsertLayer(2, layer))
+	layers := n.Layers()
+	assert.Len(layers, 4)
+	assert.Equal(layers[2], layer)
+	// inserted layer was resized to accept the HIDDEN layer output
+	assert.Equal(layer.InSize(), 5)
+	_, wCols := layer.Weights().Dims()
+	assert.Equal(wCols, 6)
+	// OUTPUT layer was resized to accept the inserted layer output
+	assert.Equal(layers[3], outLayer)
+	assert.Equal(outLayer.InSize(), 7)
+	_, wCols = outLayer.Weights().Dims()
+	assert.Equal(wCols, 8)
+	out, err := n.ForwardProp(inMx, len(layers)-1)
+	assert.NoError(err)
+	_, oCols := out.Dims()
+	assert.Equal(oCols, 3)
+	// can't remove INPUT or OUTPUT layers
+	assert.Error(n.RemoveLayer(0))
+	assert.Error(n.RemoveLayer(3))
+	assert.Error(n.RemoveLayer(10))
+	// remove the first HIDDEN layer
+	assert.NoError(n.RemoveLayer(1))
+	layers = n.Layers()
+	assert.Len(layers, 3)
+	assert.Equal(layers[1], layer)
+	assert.Equal(layer.InSize(), 4)
+	out, err = n.ForwardProp(inMx, len(layers)-1)
+	assert.NoError(err)
+	_, oCols = out.Dims()
+	assert.Equal(oCols, 3)
+	// convolution layer that can't accept the input leaves network intact
+	conv := &config.LayerConfig{
+		Kind: "hidden",
+		Size: 2,
+		NeurFn: &config.NeuronConfig{
+			Activation: "relu",
+		},
+		Conv: &config.ConvConfig{
+			Width:    5,
+			Stride:   1,
+			Channels: 1,
+		},
+	}
+	convLayer, err := NewLayer(conv, 6)
+	assert.NoError(err)
+	assert.NoError(n.InsertLayer(2, convLayer))
+	assert.Equal(convLayer.OutSize(), 6)
+	assert.Equal(outLayer.InSize(), 6)
+	assert.Error(n.RemoveLayer(1))
+	assert.Len(n.Layers(), 4)
+}
+
+func TestSummary(t *testing.T) {
+	assert := assert.New(t)
+	// create test network
+	n, err := NewBuilder().Input(4).Hidden(5, Sigmoid).Output(3, Softmax).Build()
+	assert.NotNil(n)
+	assert.NoError(err)
+	summary := n.Summary()
+	assert.Contains(summary, n.ID())
+	for _, layer := range n.Layers() {
+		assert.Contains(summary, layer.ID())
+	}
+	assert.Contains(summary, "sigmoid")
+	assert.Contains(summary, "softmax")
+	// 5 x (4+1) + 3 x (5+1) parameters
+	assert.Contains(summary, "Total trainable params: 43")
+	// masked weights are not trainable
+	mask := mat.NewDense(5, 5, nil)
+	assert.NoError(n.Layers()[1].SetMask(mask))
+	assert.Contains(n.Summary(), "Total trainable params: 18")
+}
+
+func TestDOT(t *testing.T) {
+	assert := assert.New(t)
+	// create test network
+	n, err := NewBuilder().Input(4).Hidden(5, Sigmoid).Output(3, Softmax).Build()
+	assert.NotNil(n)
+	assert.NoError(err)
+	dot := n.DOT()
+	assert.True(strings.HasPrefix(dot, "digraph"))
+	for _, layer := range n.Layers() {
+		assert.Contains(dot, layer.ID())
+	}
+	assert.Contains(dot, "layer0 -> layer1;")
+	assert.Contains(dot, "layer1 -> layer2;")
+	assert.NotContains(dot, "layer2 -> ")
+}
+
+func TestNewFeedForward(t *testing.T) {
+	assert := assert.New(t)
+	// network without hidden layers
+	n, err := NewFeedForward(4, nil, 3)
+	assert.NotNil(n)
+	assert.NoError(err)
+	assert.Len(n.Layers(), 2)
+	// multiple hidden layers
+	hidden := []int{10, 8, 6, 4}
+	n, err = NewFeedForward(4, hidden, 3)
+	assert.NotNil(n)
+	assert.NoError(err)
+	layers := n.Layers()
+	assert.Len(layers, len(hidden)+2)
+	layerIn := 4
+	for i, size := range hidden {
+		assert.Equal(layers[i+1].Kind(), HIDDEN)
+		assert.Equal(layers[i+1].InSize(), layerIn)
+		assert.Equal(layers[i+1].OutSize(), size)
+		layerIn = size
+	}
+	assert.Equal(layers[len(layers)-1].InSize(), layerIn)
+	out, err := n.ForwardProp(inMx, len(layers)-1)
+	assert.NoError(err)
+	_, oCols := out.Dims()
+	assert.Equal(oCols, 3)
+	// incorrect layer sizes
+	n, err = NewFeedForward(4, []int{10, -1}, 3)
+	assert.Nil(n)
+	assert.Error(err)
+	n, err = NewFeedForward(0, hidden, 3)
+	assert.Nil(n)
+	assert.Error(err)
+}
+
+func TestParams(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewFeedForward(4, []int{5}, 3)
+	assert.NotNil(n)
+	assert.NoError(err)
+	params := n.Params()
+	// (5 x 5) + (3 x 6)
+	assert.Len(params, 43)
+	// incorrect number of params
+	assert.Error(n.SetParams(params[1:]))
+	assert.Error(n.SetParams(append(params, 1.0)))
+	// params are scattered back into layer weights
+	for i := range params {
+		params[i] = float64(i)
+	}
+	assert.NoError(n.SetParams(params))
+	assert.Equal(n.Params(), params)
+	assert.Equal(n.Layers()[1].Weights().At(1, 0), 1.0)
+	assert.Equal(n.Layers()[2].Weights().At(0, 0), 25.0)
+	// heads weights are included
+	h, err := NewHead("foo", LogLikelihood{}, 1.0, newTestLayer("output", 2, Softmax))
+	assert.NoError(err)
+	assert.NoError(n.AddHead(h))
+	assert.Len(n.Params(), 43+2*6)
+}
+
+func TestCopyWeightsFrom(t *testing.T) {
+	assert := assert.New(t)
+
+	src, err := NewFeedForward(4, []int{5, 6}, 3)
+	assert.NotNil(src)
+	assert.NoError(err)
+	dst, err := NewFeedForward(4, []int{5, 7}, 3)
+	assert.NotNil(dst)
+	assert.NoError(err)
+	// nil network
+	skipped, err := dst.CopyWeightsFrom(nil)
+	assert.Nil(skipped)
+	assert.Error(err)
+	skipped, err = dst.CopyWeightsFrom(src)
+	assert.NoError(err)
+	// 2nd hidden and output layers dimensions don't match
+	assert.Len(skipped, 2)
+	assert.Contains(skipped[0], "layer.2")
+	assert.True(mat.Equal(dst.Layers()[1].Weights(), src.Layers()[1].Weights()))
+	assert.False(dst.Layers()[1].Weights() == src.Layers()[1].Weights())
+	// identical architectures copy all the weights
+	clone := src.Clone()
+	clone.Layers()[3].Weights().Set(0, 0, 100.0)
+	skipped, err = src.CopyWeightsFrom(clone)
+	assert.NoError(err)
+	assert.Len(skipped, 0)
+	assert.Equal(src.Params(), clone.Params())
+	// heads are matched by name
+	h, err := NewHead("foo", LogLikelihood{}, 1.0, newTestLayer("output", 2, Softmax))
+	assert.NoError(err)
+	assert.NoError(dst.AddHead(h))
+	skipped, err = dst.CopyWeightsFrom(src)
+	assert.NoError(err)
+	assert.Len(skipped, 3)
+	assert.Contains(skipped[2], "foo.0: no matching layer")
+}
+
+func TestConcurrentInference(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NotNil(n)
+	assert.NoError(err)
+	expOut, err := n.Classify(inMx)
+	assert.NoError(err)
+	params := n.Params()
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			out, err := n.Classify(inMx)
+			if err == nil && !mat.Equal(out, expOut) {
+				err = fmt.Errorf("Unexpected classification result")
+			}
+			errs <- err
+		}()
+		// writers wait for readers to finish
+		go func() {
+			defer wg.Done()
+			errs <- n.SetParams(params)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(err)
+	}
+}
+
+func TestRegularization(t *testing.T) {
+	assert := assert.New(t)
+
+	layer, err := NewLayer(&config.LayerConfig{
+		Kind:   "hidden",
+		Size:   1,
+		NeurFn: &config.NeuronConfig{Activation: "sigmoid"},
+	}, 2)
+	assert.NoError(err)
+	// bias weight is not regularized
+	assert.NoError(layer.SetWeights(mat.NewDense(1, 3, []float64{5.0, -1.0, 2.0})))
+	layer.deltas = mat.NewDense(1, 3, nil)
+	layers := []*Layer{layer}
+	testCases := []struct {
+		p    penalty
+		cost float64
+		grad []float64
+	}{
+		{penalty{}, 0.0, []float64{0.0, 0.0, 0.0}},
+		{penalty{l2: 1.0}, 1.25, []float64{0.0, -0.5, 1.0}},
+		{penalty{l1: 1.0}, 1.5, []float64{0.0, -0.5, 0.5}},
+		{penalty{l1: 1.0, l2: 1.0}, 2.75, []float64{0.0, -1.0, 1.5}},
+	}
+	for _, tc := range testCases {
+		assert.InDelta(tc.cost, regCost(layers, tc.p, 2), 1e-9)
+		assert.Equal(tc.grad, layersGradient(layers, tc.p, 2))
+	}
+}
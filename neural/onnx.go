@@ -0,0 +1,377 @@
+package neural
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"github.com/milosgajdos83/go-neural/pkg/onnx"
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	// onnxIRVersion is ONNX IR version of exported models
+	onnxIRVersion = 7
+	// onnxOpset is ONNX operator set version of exported models
+	onnxOpset = 13
+	// onnxInput is the name of ONNX graph input
+	onnxInput = "input"
+	// onnxOutput is the name of ONNX graph output
+	onnxOutput = "output"
+)
+
+// ExportONNX writes the network to w as ONNX model, so it can be served by onnxruntime or inspected
+// by ONNX tooling. Every fully connected layer is exported as Gemm node followed by its activation node;
+// maxout layers take the max of their pieces using Reshape and ReduceMax nodes. The model has a single
+// float input called "input" with a symbolic batch dimension and a single float output called "output".
+// It fails with error if the network has no layers or if it has convolution layers, branches or heads,
+// which can't be exported.
+func (n *Network) ExportONNX(w io.Writer) error {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if len(n.layers) == 0 || n.layers[0].Kind() != INPUT {
+		return fmt.Errorf("Network must have %s layer to be exported\n", INPUT)
+	}
+	if len(n.branches) > 0 || len(n.heads) > 0 {
+		return fmt.Errorf("Networks with branches or heads can't be exported to ONNX\n")
+	}
+	g := &onnx.Graph{
+		Name: n.id,
+		Inputs: []*onnx.ValueInfo{{
+			Name:     onnxInput,
+			ElemType: onnx.Float,
+			Shape:    []onnx.Dim{{Param: "N"}, {Value: int64(n.layers[0].OutSize())}},
+		}},
+	}
+	in := onnxInput
+	for i, layer := range n.layers[1:] {
+		out := onnxOutput
+		if i < len(n.layers)-2 {
+			out = fmt.Sprintf("layer%d_out", i+1)
+		}
+		if err := onnxLayer(g, layer, fmt.Sprintf("layer%d", i+1), in, out); err != nil {
+			return err
+		}
+		in = out
+	}
+	g.Outputs = []*onnx.ValueInfo{{
+		Name:     in,
+		ElemType: onnx.Float,
+		Shape:    []onnx.Dim{{Param: "N"}, {Value: int64(n.layers[len(n.layers)-1].OutSize())}},
+	}}
+	m := &onnx.Model{
+		IRVersion:    onnxIRVersion,
+		Opsets:       []onnx.OpsetID{{Version: onnxOpset}},
+		ProducerName: "go-neural",
+		Graph:        g,
+	}
+	_, err := w.Write(m.Marshal())
+	return err
+}
+
+// onnxLayer adds the nodes and the initializers of the supplied layer to ONNX graph.
+// Layer nodes read the tensor called in and write the tensor called out.
+func onnxLayer(g *onnx.Graph, l *Layer, name, in, out string) error {
+	if l.conv != nil {
+		return fmt.Errorf("Unsupported ONNX layer %s: %s\n", l.ID(), l.Type())
+	}
+	weights := l.maskedWeights()
+	rows, cols := weights.Dims()
+	b := mat.NewDense(rows, cols-1, nil)
+	b.Copy(weights.Slice(0, rows, 1, cols))
+	c := mat.Col(nil, 0, weights)
+	g.Initializers = append(g.Initializers,
+		onnx.NewFloatTensor(name+"_weights", []int64{int64(rows), int64(cols - 1)}, b.RawMatrix().Data),
+		onnx.NewFloatTensor(name+"_bias", []int64{int64(rows)}, c),
+	)
+	gemm := &onnx.Node{
+		Name:       name + "_gemm",
+		OpType:     "Gemm",
+		Inputs:     []string{in, name + "_weights", name + "_bias"},
+		Outputs:    []string{name + "_gemm"},
+		Attributes: []*onnx.Attribute{{Name: "transB", Type: onnx.AttrInt, I: 1}},
+	}
+	g.Nodes = append(g.Nodes, gemm)
+	var act *onnx.Node
+	switch {
+	case l.pieces > 0:
+		// group the pieces of every neuron and pick the max one
+		shape := name + "_shape"
+		g.Initializers = append(g.Initializers,
+			onnx.NewInt64Tensor(shape, []int64{3}, []int64{-1, int64(l.out), int64(l.pieces)}))
+		g.Nodes = append(g.Nodes, &onnx.Node{
+			Name:    name + "_pieces",
+			OpType:  "Reshape",
+			Inputs:  []string{name + "_gemm", shape},
+			Outputs: []string{name + "_pieces"},
+		})
+		act = &onnx.Node{
+			OpType: "ReduceMax",
+			Inputs: []string{name + "_pieces"},
+			Attributes: []*onnx.Attribute{
+				{Name: "axes", Type: onnx.AttrInts, Ints: []int64{2}},
+				{Name: "keepdims", Type: onnx.AttrInt, I: 0},
+			},
+		}
+	case l.meta == Sigmoid:
+		act = &onnx.Node{OpType: "Sigmoid"}
+	case l.meta == Tanh && l.kind == OUTPUT:
+		// rescaled tanh is sigmoid of doubled input
+		gemm.Attributes = append(gemm.Attributes,
+			&onnx.Attribute{Name: "alpha", Type: onnx.AttrFloat, F: 2},
+			&onnx.Attribute{Name: "beta", Type: onnx.AttrFloat, F: 2},
+		)
+		act = &onnx.Node{OpType: "Sigmoid"}
+	case l.meta == Tanh:
+		act = &onnx.Node{OpType: "Tanh"}
+	case l.meta == ReLU:
+		act = &onnx.Node{
+			OpType:     "LeakyRelu",
+			Attributes: []*onnx.Attribute{{Name: "alpha", Type: onnx.AttrFloat, F: 0.1}},
+		}
+	case l.meta == StdReLU:
+		act = &onnx.Node{OpType: "Relu"}
+	case l.meta == Softmax:
+		act = &onnx.Node{
+			OpType:     "Softmax",
+			Attributes: []*onnx.Attribute{{Name: "axis", Type: onnx.AttrInt, I: 1}},
+		}
+	default:
+		return fmt.Errorf("Unsupported ONNX activation of layer %s: %s\n", l.ID(), l.meta)
+	}
+	if len(act.Inputs) == 0 {
+		act.Inputs = []string{name + "_gemm"}
+	}
+	act.Name = name + "_act"
+	act.Outputs = []string{out}
+	g.Nodes = append(g.Nodes, act)
+	return nil
+}
+
+// onnxActivations maps ONNX activation operators to activation functions
+var onnxActivations = map[string]string{
+	"Relu":      StdReLU,
+	"LeakyRelu": ReLU,
+	"Sigmoid":   Sigmoid,
+	"Tanh":      Tanh,
+	"Softmax":   Softmax,
+}
+
+// onnxLayerData contains layer decoded from ONNX graph
+type onnxLayerData struct {
+	// activation is layer activation function
+	activation string
+	// pieces is a number of maxout pieces
+	pieces int
+	// weights are layer weights with bias in the first column
+	weights *mat.Dense
+}
+
+// ImportONNX reads ONNX model from r and creates feed-forward network which computes the same
+// outputs, so models trained elsewhere can be used for inference. The model graph must be a chain of
+// Gemm nodes, each of which is followed by Relu, LeakyRelu with alpha 0.1, Sigmoid, Tanh or Softmax
+// node. Maxout layers exported by ExportONNX are supported too; Identity and Dropout nodes are ignored.
+// Gemm weights and biases must be stored in graph initializers. The last layer becomes network OUTPUT
+// layer. It fails with error if the model can't be decoded or if its graph can't be represented by
+// the network.
+func ImportONNX(r io.Reader) (*Network, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	m, err := onnx.Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	g := m.Graph
+	if g == nil {
+		return nil, fmt.Errorf("ONNX model has no graph\n")
+	}
+	// graph input is the only input which is not an initializer
+	in := ""
+	for _, v := range g.Inputs {
+		if g.Initializer(v.Name) == nil {
+			in = v.Name
+			break
+		}
+	}
+	if in == "" {
+		return nil, fmt.Errorf("ONNX graph has no input\n")
+	}
+	var layers []*onnxLayerData
+	var layer *onnxLayerData
+	for i := 0; i < len(g.Nodes); i++ {
+		node := g.Nodes[i]
+		if len(node.Inputs) == 0 || node.Inputs[0] != in || len(node.Outputs) == 0 {
+			return nil, fmt.Errorf("Unsupported ONNX node %s: %s does not follow %s\n", node.Name, node.OpType, in)
+		}
+		switch op := node.OpType; {
+		case op == "Identity" || op == "Dropout":
+		case op == "Gemm":
+			if layer != nil {
+				return nil, fmt.Errorf("Missing activation of ONNX node %s\n", g.Nodes[i-1].Name)
+			}
+			if layer, err = onnxGemm(g, node); err != nil {
+				return nil, err
+			}
+		case layer == nil:
+			return nil, fmt.Errorf("Unsupported ONNX node %s: %s must follow Gemm\n", node.Name, op)
+		case op == "Reshape":
+			if i+1 == len(g.Nodes) {
+				return nil, fmt.Errorf("Unsupported ONNX node %s: %s\n", node.Name, op)
+			}
+			i++
+			if layer.pieces, err = onnxMaxout(g, node, g.Nodes[i], layer.weights); err != nil {
+				return nil, err
+			}
+			layer.activation = Maxout
+			node = g.Nodes[i]
+		default:
+			activation, ok := onnxActivations[op]
+			if !ok {
+				return nil, fmt.Errorf("Unsupported ONNX node %s: %s\n", node.Name, op)
+			}
+			if err := onnxActivation(node); err != nil {
+				return nil, err
+			}
+			layer.activation = activation
+		}
+		in = node.Outputs[0]
+		if layer != nil && layer.activation != "" {
+			layers = append(layers, layer)
+			layer = nil
+		}
+	}
+	if layer != nil {
+		return nil, fmt.Errorf("Missing activation of ONNX node %s\n", g.Nodes[len(g.Nodes)-1].Name)
+	}
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("ONNX graph has no layers\n")
+	}
+	if len(g.Outputs) > 0 && g.Outputs[0].Name != in {
+		return nil, fmt.Errorf("Unsupported ONNX graph output: %s\n", g.Outputs[0].Name)
+	}
+	return onnxNetwork(layers)
+}
+
+// onnxGemm decodes weights of layer computed by the supplied Gemm node
+func onnxGemm(g *onnx.Graph, node *onnx.Node) (*onnxLayerData, error) {
+	alpha, beta, transB := 1.0, 1.0, false
+	for _, a := range node.Attributes {
+		switch a.Name {
+		case "alpha":
+			alpha = float64(a.F)
+		case "beta":
+			beta = float64(a.F)
+		case "transB":
+			transB = a.I != 0
+		case "transA":
+			if a.I != 0 {
+				return nil, fmt.Errorf("Unsupported transposed input of ONNX node %s\n", node.Name)
+			}
+		}
+	}
+	if len(node.Inputs) < 2 {
+		return nil, fmt.Errorf("Missing weights of ONNX node %s\n", node.Name)
+	}
+	b := g.Initializer(node.Inputs[1])
+	if b == nil || len(b.Dims) != 2 || int64(len(b.Data)) != b.Dims[0]*b.Dims[1] || b.Dims[0]*b.Dims[1] == 0 {
+		return nil, fmt.Errorf("Incorrect weights of ONNX node %s\n", node.Name)
+	}
+	rows, cols := int(b.Dims[0]), int(b.Dims[1])
+	bMx := mat.NewDense(rows, cols, b.Data)
+	if !transB {
+		bMx = mat.DenseCopyOf(bMx.T())
+		rows, cols = cols, rows
+	}
+	weights := mat.NewDense(rows, cols+1, nil)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			weights.Set(i, j+1, alpha*bMx.At(i, j))
+		}
+	}
+	// bias is optional and can be broadcast
+	if len(node.Inputs) > 2 && node.Inputs[2] != "" {
+		c := g.Initializer(node.Inputs[2])
+		if c == nil || (len(c.Data) != rows && len(c.Data) != 1) {
+			return nil, fmt.Errorf("Incorrect bias of ONNX node %s\n", node.Name)
+		}
+		for i := 0; i < rows; i++ {
+			weights.Set(i, 0, beta*c.Data[i%len(c.Data)])
+		}
+	}
+	return &onnxLayerData{weights: weights}, nil
+}
+
+// onnxMaxout checks that the supplied Reshape and ReduceMax nodes pick the max pieces
+// of layer with the supplied weights and returns the number of maxout pieces
+func onnxMaxout(g *onnx.Graph, reshape, reduce *onnx.Node, weights *mat.Dense) (int, error) {
+	rows, _ := weights.Dims()
+	if len(reshape.Inputs) < 2 || g.Initializer(reshape.Inputs[1]) == nil {
+		return 0, fmt.Errorf("Missing shape of ONNX node %s\n", reshape.Name)
+	}
+	shape := g.Initializer(reshape.Inputs[1]).Data
+	if len(shape) != 3 || shape[0] != -1 || shape[1] < 1 || shape[2] < 2 || int(shape[1]*shape[2]) != rows {
+		return 0, fmt.Errorf("Unsupported shape of ONNX node %s: %v\n", reshape.Name, shape)
+	}
+	if reduce.OpType != "ReduceMax" || len(reduce.Inputs) != 1 || reduce.Inputs[0] != reshape.Outputs[0] ||
+		len(reduce.Outputs) == 0 {
+		return 0, fmt.Errorf("Unsupported ONNX node %s: %s must follow Reshape\n", reduce.Name, reduce.OpType)
+	}
+	axes, keepdims := reduce.Attribute("axes"), reduce.Attribute("keepdims")
+	if axes == nil || len(axes.Ints) != 1 || (axes.Ints[0] != 2 && axes.Ints[0] != -1) ||
+		keepdims == nil || keepdims.I != 0 {
+		return 0, fmt.Errorf("Unsupported attributes of ONNX node %s\n", reduce.Name)
+	}
+	return int(shape[2]), nil
+}
+
+// onnxActivation checks that attributes of the supplied activation node are supported
+func onnxActivation(node *onnx.Node) error {
+	switch node.OpType {
+	case "LeakyRelu":
+		// LeakyRelu alpha defaults to 0.01
+		alpha := node.Attribute("alpha")
+		if alpha == nil || math.Abs(float64(alpha.F)-0.1) > 1e-6 {
+			return fmt.Errorf("Unsupported LeakyRelu slope of ONNX node %s\n", node.Name)
+		}
+	case "Softmax":
+		// softmax must be computed over the outputs of every sample
+		if axis := node.Attribute("axis"); axis != nil && axis.I != 1 && axis.I != -1 {
+			return fmt.Errorf("Unsupported Softmax axis of ONNX node %s: %d\n", node.Name, axis.I)
+		}
+	}
+	return nil
+}
+
+// onnxNetwork creates feed-forward network from the layers decoded from ONNX graph
+func onnxNetwork(layers []*onnxLayerData) (*Network, error) {
+	_, cols := layers[0].weights.Dims()
+	b := NewBuilder().Input(cols - 1)
+	for i, layer := range layers {
+		rows, _ := layer.weights.Dims()
+		switch {
+		case i == len(layers)-1:
+			// OUTPUT layer tanh is rescaled so it can't be used
+			if layer.activation == Maxout || layer.activation == Tanh {
+				return nil, fmt.Errorf("Unsupported ONNX output activation: %s\n", layer.activation)
+			}
+			b.Output(rows, layer.activation)
+		case layer.activation == Maxout:
+			b.Maxout(rows/layer.pieces, layer.pieces)
+		default:
+			b.Hidden(rows, layer.activation)
+		}
+	}
+	n, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	for i, layer := range layers {
+		if err := n.layers[i+1].SetWeights(layer.weights); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,106 @@
+package neural
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/onnx"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestExportONNX(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewBuilder().Input(4).Maxout(3, 2).Hidden(4, ReLU).Hidden(3, Tanh).Output(2, Tanh).Build()
+	assert.NoError(err)
+	var buf bytes.Buffer
+	assert.NoError(n.ExportONNX(&buf))
+	for _, op := range []string{"Gemm", "Reshape", "ReduceMax", "LeakyRelu", "Tanh", "Sigmoid"} {
+		assert.Contains(buf.String(), op)
+	}
+	assert.Contains(buf.String(), "go-neural")
+	// softmax output
+	n, err = NewBuilder().Input(4).Hidden(3, Sigmoid).Output(2, Softmax).Build()
+	assert.NoError(err)
+	buf.Reset()
+	assert.NoError(n.ExportONNX(&buf))
+	assert.Contains(buf.String(), "Softmax")
+	// convolution layers, branches and heads can't be exported
+	n, err = newModelNetwork()
+	assert.NoError(err)
+	assert.Error(n.ExportONNX(&buf))
+	n, err = NewBuilder().Input(4).Conv1D(2, 2, 1, 2, ReLU).Output(2, Softmax).Build()
+	assert.NoError(err)
+	assert.Error(n.ExportONNX(&buf))
+	// empty network
+	assert.Error(new(Network).ExportONNX(&buf))
+}
+
+func TestImportONNX(t *testing.T) {
+	assert := assert.New(t)
+
+	inMx := mat.NewDense(3, 4, []float64{0.1, -0.2, 0.3, 0.4, -1.5, 0.5, 2.0, -0.3, 0.0, 1.0, -1.0, 0.7})
+	nets := [][]string{
+		{Maxout, ReLU, Tanh, Tanh},
+		{StdReLU, Sigmoid, Softmax},
+		{Sigmoid},
+	}
+	for _, acts := range nets {
+		b := NewBuilder().Input(4)
+		for _, act := range acts[:len(acts)-1] {
+			if act == Maxout {
+				b.Maxout(3, 2)
+				continue
+			}
+			b.Hidden(5, act)
+		}
+		n, err := b.Output(2, acts[len(acts)-1]).Build()
+		assert.NoError(err)
+		var buf bytes.Buffer
+		assert.NoError(n.ExportONNX(&buf))
+		imported, err := ImportONNX(&buf)
+		assert.NoError(err)
+		assert.Equal(len(n.Layers()), len(imported.Layers()))
+		out, err := n.ForwardProp(inMx, len(n.Layers())-1)
+		assert.NoError(err)
+		importedOut, err := imported.ForwardProp(inMx, len(imported.Layers())-1)
+		assert.NoError(err)
+		assert.True(mat.EqualApprox(out, importedOut, 1e-5), "%v", acts)
+	}
+	// weights which are not transposed, scaled and with broadcast bias
+	g := &onnx.Graph{
+		Nodes: []*onnx.Node{
+			{Name: "gemm", OpType: "Gemm", Inputs: []string{"x", "w", "b"}, Outputs: []string{"y"},
+				Attributes: []*onnx.Attribute{{Name: "alpha", Type: onnx.AttrFloat, F: 2}}},
+			{Name: "drop", OpType: "Dropout", Inputs: []string{"y"}, Outputs: []string{"z"}},
+			{Name: "relu", OpType: "Relu", Inputs: []string{"z"}, Outputs: []string{"out"}},
+		},
+		Initializers: []*onnx.Tensor{
+			onnx.NewFloatTensor("w", []int64{2, 3}, []float64{1, 2, 3, 4, 5, 6}),
+			onnx.NewFloatTensor("b", []int64{1}, []float64{-10}),
+		},
+		Inputs:  []*onnx.ValueInfo{{Name: "x"}, {Name: "w"}},
+		Outputs: []*onnx.ValueInfo{{Name: "out"}},
+	}
+	m := &onnx.Model{IRVersion: 7, Graph: g}
+	n, err := ImportONNX(bytes.NewReader(m.Marshal()))
+	assert.NoError(err)
+	out, err := n.ForwardProp(mat.NewDense(1, 2, []float64{1, 1}), 1)
+	assert.NoError(err)
+	assert.Equal([]float64{0, 4, 8}, mat.Row(nil, 0, out))
+	// unsupported graphs
+	g.Nodes[2].OpType = "Elu"
+	_, err = ImportONNX(bytes.NewReader(m.Marshal()))
+	assert.Error(err)
+	g.Nodes[2].OpType = "LeakyRelu"
+	_, err = ImportONNX(bytes.NewReader(m.Marshal()))
+	assert.Error(err)
+	g.Nodes = g.Nodes[:2]
+	_, err = ImportONNX(bytes.NewReader(m.Marshal()))
+	assert.Error(err)
+	_, err = ImportONNX(bytes.NewReader([]byte{0x0b}))
+	assert.Error(err)
+	_, err = ImportONNX(bytes.NewReader(nil))
+	assert.Error(err)
+}
@@ -0,0 +1,658 @@
+package neural
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Optimizer updates layer weights using the gradient of the training cost.
+// Optimizers can maintain per-layer state across the steps.
+type Optimizer interface {
+	// Step updates the weights of the supplied layer using the supplied gradient.
+	// The gradient must have the same dimensions as the layer weights.
+	Step(layer *Layer, grad *mat.Dense) error
+}
+
+// RateOptimizer is Optimizer whose learning rate can be changed during training
+type RateOptimizer interface {
+	Optimizer
+	// SetRate sets optimizer learning rate
+	SetRate(rate float64) error
+}
+
+// StatefulOptimizer is Optimizer whose per-layer state can be saved and restored.
+// Saved optimizer state allows to resume training from a checkpoint.
+type StatefulOptimizer interface {
+	Optimizer
+	// State returns a copy of optimizer state of the supplied layers
+	State(layers []*Layer) *OptimState
+	// SetState replaces optimizer state of the supplied layers with the supplied state
+	SetState(layers []*Layer, state *OptimState) error
+}
+
+// OptimState holds optimizer state of network layers. Layers are identified by their index.
+type OptimState struct {
+	// Buffers maps optimizer buffer names to state buffers of layers
+	Buffers map[string]map[int]*mat.Dense
+	// Steps maps layers to the number of their updates
+	Steps map[int]int
+}
+
+// trainerOptim maps optimization methods supported by Trainer to Optimizer constructors
+var trainerOptim = map[string]func(*config.OptimConfig) (Optimizer, error){
+	"sgd": func(c *config.OptimConfig) (Optimizer, error) {
+		return NewSGD(c.LearnRate, c.Momentum, c.Nesterov)
+	},
+	"adam": func(c *config.OptimConfig) (Optimizer, error) {
+		return NewAdam(c.LearnRate, c.Beta1, c.Beta2, c.Epsilon)
+	},
+	"adamw": func(c *config.OptimConfig) (Optimizer, error) {
+		return NewAdamW(c.LearnRate, c.Beta1, c.Beta2, c.Epsilon, c.WeightDecay)
+	},
+	"rmsprop": func(c *config.OptimConfig) (Optimizer, error) {
+		return NewRMSProp(c.LearnRate, c.Decay, c.Epsilon)
+	},
+	"adagrad": func(c *config.OptimConfig) (Optimizer, error) {
+		return NewAdaGrad(c.LearnRate, c.Epsilon)
+	},
+}
+
+// newOptimizer creates new Optimizer per supplied optimization configuration
+func newOptimizer(c *config.OptimConfig) (Optimizer, error) {
+	if c == nil {
+		return nil, fmt.Errorf("Incorrect optimization configuration supplied: %v\n", c)
+	}
+	newOptim, ok := trainerOptim[c.Method]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported optimization method: %s\n", c.Method)
+	}
+	return newOptim(c)
+}
+
+// SGD is stochastic gradient descent optimizer with optional classical or Nesterov momentum.
+// SGD keeps a velocity buffer for every layer it updates.
+type SGD struct {
+	// rate is learning rate
+	rate float64
+	// momentum is momentum coefficient
+	momentum float64
+	// nesterov enables Nesterov momentum
+	nesterov bool
+	// velocity contains velocity buffers of updated layers
+	velocity map[*Layer]*mat.Dense
+}
+
+// NewSGD creates new SGD optimizer with the supplied learning rate and momentum and returns it.
+// Zero momentum disables momentum. If nesterov is true, Nesterov momentum is used instead of
+// classical momentum. It fails with error if the learning rate is not positive or if the momentum
+// is not in [0, 1) interval.
+func NewSGD(rate, momentum float64, nesterov bool) (*SGD, error) {
+	if rate <= 0 {
+		return nil, fmt.Errorf("Incorrect learning rate: %f\n", rate)
+	}
+	if momentum < 0 || momentum >= 1 {
+		return nil, fmt.Errorf("Incorrect momentum: %f\n", momentum)
+	}
+	return &SGD{
+		rate:     rate,
+		momentum: momentum,
+		nesterov: nesterov,
+		velocity: make(map[*Layer]*mat.Dense),
+	}, nil
+}
+
+// Rate returns SGD learning rate
+func (s SGD) Rate() float64 {
+	return s.rate
+}
+
+// SetRate sets SGD learning rate. It fails with error if the rate is negative.
+func (s *SGD) SetRate(rate float64) error {
+	if rate < 0 {
+		return fmt.Errorf("Incorrect learning rate: %f\n", rate)
+	}
+	s.rate = rate
+	return nil
+}
+
+// Momentum returns SGD momentum
+func (s SGD) Momentum() float64 {
+	return s.momentum
+}
+
+// Nesterov returns true if SGD uses Nesterov momentum
+func (s SGD) Nesterov() bool {
+	return s.nesterov
+}
+
+// Step updates layer weights using the supplied gradient. With momentum the velocity of the
+// layer is updated first: v = momentum*v + grad. Classical momentum then updates the weights
+// w = w - rate*v, whereas Nesterov momentum updates them w = w - rate*(grad + momentum*v).
+// Step fails with error if either layer or gradient are nil or their dimensions don't match.
+func (s *SGD) Step(layer *Layer, grad *mat.Dense) error {
+	if err := checkGrad(layer, grad); err != nil {
+		return err
+	}
+	update := grad
+	if s.momentum > 0 {
+		v := layerBuffer(s.velocity, layer)
+		v.Scale(s.momentum, v)
+		v.Add(v, grad)
+		update = v
+		if s.nesterov {
+			update = new(mat.Dense)
+			update.Scale(s.momentum, v)
+			update.Add(update, grad)
+		}
+	}
+	step := new(mat.Dense)
+	step.Scale(s.rate, update)
+	applyStep(layer, step)
+	return nil
+}
+
+// State returns a copy of velocity buffers of the supplied layers
+func (s *SGD) State(layers []*Layer) *OptimState {
+	return &OptimState{
+		Buffers: map[string]map[int]*mat.Dense{
+			"velocity": saveBuffers(s.velocity, layers),
+		},
+	}
+}
+
+// SetState replaces velocity buffers of the supplied layers with the buffers in the supplied state.
+// It fails with error if the state is nil or if the buffers don't match the layers.
+func (s *SGD) SetState(layers []*Layer, state *OptimState) error {
+	if state == nil {
+		return fmt.Errorf("Incorrect optimizer state supplied: %v\n", state)
+	}
+	return loadBuffers(s.velocity, layers, state.Buffers["velocity"])
+}
+
+// checkGrad checks if the supplied gradient can be used to update the supplied layer weights
+func checkGrad(layer *Layer, grad *mat.Dense) error {
+	if layer == nil || grad == nil {
+		return fmt.Errorf("Incorrect layer or gradient supplied: %v, %v\n", layer, grad)
+	}
+	rows, cols := layer.Weights().Dims()
+	if r, c := grad.Dims(); r != rows || c != cols {
+		return fmt.Errorf("Gradient dimension mismatch. Weights: %dx%d, Gradient: %dx%d\n",
+			rows, cols, r, c)
+	}
+	return nil
+}
+
+// layerBuffer returns optimizer state buffer of the supplied layer. A new zero buffer
+// is created if the layer has no buffer yet or if the layer has been resized.
+func layerBuffer(buffers map[*Layer]*mat.Dense, layer *Layer) *mat.Dense {
+	if hasBuffer(buffers, layer) {
+		return buffers[layer]
+	}
+	rows, cols := layer.Weights().Dims()
+	buf := mat.NewDense(rows, cols, nil)
+	buffers[layer] = buf
+	return buf
+}
+
+// hasBuffer returns true if the supplied layer has a state buffer which matches its weights
+func hasBuffer(buffers map[*Layer]*mat.Dense, layer *Layer) bool {
+	buf, ok := buffers[layer]
+	if !ok {
+		return false
+	}
+	rows, cols := layer.Weights().Dims()
+	r, c := buf.Dims()
+	return r == rows && c == cols
+}
+
+// saveBuffers returns copies of state buffers of the supplied layers keyed by layer index
+func saveBuffers(buffers map[*Layer]*mat.Dense, layers []*Layer) map[int]*mat.Dense {
+	saved := make(map[int]*mat.Dense)
+	for i, layer := range layers {
+		if hasBuffer(buffers, layer) {
+			saved[i] = mat.DenseCopyOf(buffers[layer])
+		}
+	}
+	return saved
+}
+
+// loadBuffers replaces state buffers of the supplied layers with copies of the saved buffers.
+// Layers without saved buffers start with new buffers. It fails with error if any of the saved
+// buffers does not match its layer.
+func loadBuffers(buffers map[*Layer]*mat.Dense, layers []*Layer, saved map[int]*mat.Dense) error {
+	for i, buf := range saved {
+		if i < 0 || i >= len(layers) {
+			return fmt.Errorf("Incorrect layer index: %d\n", i)
+		}
+		rows, cols := layers[i].Weights().Dims()
+		if r, c := buf.Dims(); r != rows || c != cols {
+			return fmt.Errorf("Buffer dimension mismatch. Weights: %dx%d, Buffer: %dx%d\n",
+				rows, cols, r, c)
+		}
+	}
+	for i, layer := range layers {
+		delete(buffers, layer)
+		if buf, ok := saved[i]; ok {
+			buffers[layer] = mat.DenseCopyOf(buf)
+		}
+	}
+	return nil
+}
+
+// applyStep subtracts the supplied step from layer weights and keeps masked weights zeroed
+func applyStep(layer *Layer, step *mat.Dense) {
+	weights := layer.Weights()
+	weights.Sub(weights, step)
+	if mask := layer.Mask(); mask != nil {
+		weights.MulElem(weights, mask)
+	}
+}
+
+// Adam is adaptive moment estimation optimizer. Adam keeps exponentially decaying averages
+// of past gradients and past squared gradients for every layer it updates and uses them
+// to compute per-weight learning rates.
+type Adam struct {
+	// rate is learning rate
+	rate float64
+	// beta1 is decay rate of the first moment estimates
+	beta1 float64
+	// beta2 is decay rate of the second moment estimates
+	beta2 float64
+	// eps is added to the denominator for numerical stability
+	eps float64
+	// decay is decoupled weight decay
+	decay float64
+	// m contains the first moment estimates of updated layers
+	m map[*Layer]*mat.Dense
+	// v contains the second moment estimates of updated layers
+	v map[*Layer]*mat.Dense
+	// t contains the number of steps of updated layers
+	t map[*Layer]int
+}
+
+// NewAdam creates new Adam optimizer with the supplied learning rate, decay rates of the moment
+// estimates and epsilon and returns it. Zero beta1, beta2 and eps are replaced with their defaults:
+// 0.9, 0.999 and 1e-8 respectively. It fails with error if the learning rate or eps are not positive
+// or if the decay rates are not in [0, 1) interval.
+func NewAdam(rate, beta1, beta2, eps float64) (*Adam, error) {
+	if rate <= 0 {
+		return nil, fmt.Errorf("Incorrect learning rate: %f\n", rate)
+	}
+	if beta1 == 0.0 {
+		beta1 = 0.9
+	}
+	if beta2 == 0.0 {
+		beta2 = 0.999
+	}
+	if eps == 0.0 {
+		eps = 1e-8
+	}
+	if beta1 < 0 || beta1 >= 1 || beta2 < 0 || beta2 >= 1 {
+		return nil, fmt.Errorf("Incorrect decay rates: %f, %f\n", beta1, beta2)
+	}
+	if eps < 0 {
+		return nil, fmt.Errorf("Incorrect epsilon: %f\n", eps)
+	}
+	return &Adam{
+		rate:  rate,
+		beta1: beta1,
+		beta2: beta2,
+		eps:   eps,
+		m:     make(map[*Layer]*mat.Dense),
+		v:     make(map[*Layer]*mat.Dense),
+		t:     make(map[*Layer]int),
+	}, nil
+}
+
+// NewAdamW creates new Adam optimizer with decoupled weight decay and returns it.
+// Unlike L2 regularization, which adds the weights to the gradient and thus gets scaled by the
+// adaptive learning rates, decoupled weight decay shrinks the weights directly: w = w - rate*decay*w.
+// Bias weights are not decayed. Other parameters are the same as the parameters of NewAdam.
+// It fails with error if any of the parameters is invalid or if the weight decay is negative.
+func NewAdamW(rate, beta1, beta2, eps, decay float64) (*Adam, error) {
+	if decay < 0 {
+		return nil, fmt.Errorf("Incorrect weight decay: %f\n", decay)
+	}
+	adam, err := NewAdam(rate, beta1, beta2, eps)
+	if err != nil {
+		return nil, err
+	}
+	adam.decay = decay
+	return adam, nil
+}
+
+// Rate returns Adam learning rate
+func (a Adam) Rate() float64 {
+	return a.rate
+}
+
+// SetRate sets Adam learning rate. It fails with error if the rate is negative.
+func (a *Adam) SetRate(rate float64) error {
+	if rate < 0 {
+		return fmt.Errorf("Incorrect learning rate: %f\n", rate)
+	}
+	a.rate = rate
+	return nil
+}
+
+// Betas returns decay rates of the first and the second moment estimates
+func (a Adam) Betas() (float64, float64) {
+	return a.beta1, a.beta2
+}
+
+// Epsilon returns Adam epsilon
+func (a Adam) Epsilon() float64 {
+	return a.eps
+}
+
+// WeightDecay returns Adam decoupled weight decay
+func (a Adam) WeightDecay() float64 {
+	return a.decay
+}
+
+// State returns a copy of moment estimates and step counts of the supplied layers
+func (a *Adam) State(layers []*Layer) *OptimState {
+	steps := make(map[int]int)
+	for i, layer := range layers {
+		if hasBuffer(a.m, layer) {
+			steps[i] = a.t[layer]
+		}
+	}
+	return &OptimState{
+		Buffers: map[string]map[int]*mat.Dense{
+			"m": saveBuffers(a.m, layers),
+			"v": saveBuffers(a.v, layers),
+		},
+		Steps: steps,
+	}
+}
+
+// SetState replaces moment estimates and step counts of the supplied layers with the supplied state.
+// It fails with error if the state is nil or if the buffers don't match the layers.
+func (a *Adam) SetState(layers []*Layer, state *OptimState) error {
+	if state == nil {
+		return fmt.Errorf("Incorrect optimizer state supplied: %v\n", state)
+	}
+	if err := loadBuffers(a.m, layers, state.Buffers["m"]); err != nil {
+		return err
+	}
+	if err := loadBuffers(a.v, layers, state.Buffers["v"]); err != nil {
+		return err
+	}
+	for i, layer := range layers {
+		a.t[layer] = state.Steps[i]
+	}
+	return nil
+}
+
+// Step updates layer weights using the supplied gradient. It updates the moment estimates
+// m = beta1*m + (1-beta1)*grad and v = beta2*v + (1-beta2)*grad^2, corrects their bias and
+// updates the weights w = w - rate*m'/(sqrt(v')+eps). Step fails with error if either layer
+// or gradient are nil or their dimensions don't match. With weight decay the weights are decayed
+// before they are updated.
+func (a *Adam) Step(layer *Layer, grad *mat.Dense) error {
+	if err := checkGrad(layer, grad); err != nil {
+		return err
+	}
+	if a.decay > 0 {
+		decayWeights(layer, a.rate*a.decay)
+	}
+	// step count restarts with new moment estimates
+	if !hasBuffer(a.m, layer) {
+		a.t[layer] = 0
+	}
+	m, v := layerBuffer(a.m, layer), layerBuffer(a.v, layer)
+	a.t[layer]++
+	t := float64(a.t[layer])
+	m.Apply(func(i, j int, x float64) float64 {
+		return a.beta1*x + (1-a.beta1)*grad.At(i, j)
+	}, m)
+	v.Apply(func(i, j int, x float64) float64 {
+		g := grad.At(i, j)
+		return a.beta2*x + (1-a.beta2)*g*g
+	}, v)
+	corr1 := 1 - math.Pow(a.beta1, t)
+	corr2 := 1 - math.Pow(a.beta2, t)
+	step := new(mat.Dense)
+	step.Apply(func(i, j int, x float64) float64 {
+		return a.rate * (x / corr1) / (math.Sqrt(v.At(i, j)/corr2) + a.eps)
+	}, m)
+	applyStep(layer, step)
+	return nil
+}
+
+// RMSProp is root mean square propagation optimizer. RMSProp keeps an exponentially decaying
+// average of past squared gradients for every layer it updates and divides the learning rate
+// of every weight by the root of this average.
+type RMSProp struct {
+	// rate is learning rate
+	rate float64
+	// decay is decay rate of the squared gradients average
+	decay float64
+	// eps is added to the denominator for numerical stability
+	eps float64
+	// sq contains squared gradients averages of updated layers
+	sq map[*Layer]*mat.Dense
+}
+
+// NewRMSProp creates new RMSProp optimizer with the supplied learning rate, decay rate
+// and epsilon and returns it. Zero decay and eps are replaced with their defaults: 0.9 and 1e-8
+// respectively. It fails with error if the learning rate is not positive, if the decay rate
+// is not in [0, 1) interval or if eps is negative.
+func NewRMSProp(rate, decay, eps float64) (*RMSProp, error) {
+	if rate <= 0 {
+		return nil, fmt.Errorf("Incorrect learning rate: %f\n", rate)
+	}
+	if decay == 0.0 {
+		decay = 0.9
+	}
+	if eps == 0.0 {
+		eps = 1e-8
+	}
+	if decay < 0 || decay >= 1 {
+		return nil, fmt.Errorf("Incorrect decay rate: %f\n", decay)
+	}
+	if eps < 0 {
+		return nil, fmt.Errorf("Incorrect epsilon: %f\n", eps)
+	}
+	return &RMSProp{
+		rate:  rate,
+		decay: decay,
+		eps:   eps,
+		sq:    make(map[*Layer]*mat.Dense),
+	}, nil
+}
+
+// Rate returns RMSProp learning rate
+func (r RMSProp) Rate() float64 {
+	return r.rate
+}
+
+// SetRate sets RMSProp learning rate. It fails with error if the rate is negative.
+func (r *RMSProp) SetRate(rate float64) error {
+	if rate < 0 {
+		return fmt.Errorf("Incorrect learning rate: %f\n", rate)
+	}
+	r.rate = rate
+	return nil
+}
+
+// Decay returns decay rate of the squared gradients average
+func (r RMSProp) Decay() float64 {
+	return r.decay
+}
+
+// Epsilon returns RMSProp epsilon
+func (r RMSProp) Epsilon() float64 {
+	return r.eps
+}
+
+// State returns a copy of squared gradients averages of the supplied layers
+func (r *RMSProp) State(layers []*Layer) *OptimState {
+	return &OptimState{
+		Buffers: map[string]map[int]*mat.Dense{
+			"sq": saveBuffers(r.sq, layers),
+		},
+	}
+}
+
+// SetState replaces squared gradients averages of the supplied layers with the supplied state.
+// It fails with error if the state is nil or if the buffers don't match the layers.
+func (r *RMSProp) SetState(layers []*Layer, state *OptimState) error {
+	if state == nil {
+		return fmt.Errorf("Incorrect optimizer state supplied: %v\n", state)
+	}
+	return loadBuffers(r.sq, layers, state.Buffers["sq"])
+}
+
+// Step updates layer weights using the supplied gradient. It updates the squared gradients
+// average s = decay*s + (1-decay)*grad^2 and then the weights w = w - rate*grad/(sqrt(s)+eps).
+// Step fails with error if either layer or gradient are nil or their dimensions don't match.
+func (r *RMSProp) Step(layer *Layer, grad *mat.Dense) error {
+	if err := checkGrad(layer, grad); err != nil {
+		return err
+	}
+	sq := layerBuffer(r.sq, layer)
+	sq.Apply(func(i, j int, x float64) float64 {
+		g := grad.At(i, j)
+		return r.decay*x + (1-r.decay)*g*g
+	}, sq)
+	applyStep(layer, adaptiveStep(r.rate, r.eps, grad, sq))
+	return nil
+}
+
+// AdaGrad is adaptive gradient optimizer. AdaGrad accumulates past squared gradients
+// for every layer it updates and divides the learning rate of every weight by the root
+// of the accumulated sum, so frequently updated weights get smaller updates.
+type AdaGrad struct {
+	// rate is learning rate
+	rate float64
+	// eps is added to the denominator for numerical stability
+	eps float64
+	// sq contains accumulated squared gradients of updated layers
+	sq map[*Layer]*mat.Dense
+}
+
+// NewAdaGrad creates new AdaGrad optimizer with the supplied learning rate and epsilon and
+// returns it. Zero eps is replaced with its default 1e-8. It fails with error if the learning
+// rate is not positive or if eps is negative.
+func NewAdaGrad(rate, eps float64) (*AdaGrad, error) {
+	if rate <= 0 {
+		return nil, fmt.Errorf("Incorrect learning rate: %f\n", rate)
+	}
+	if eps == 0.0 {
+		eps = 1e-8
+	}
+	if eps < 0 {
+		return nil, fmt.Errorf("Incorrect epsilon: %f\n", eps)
+	}
+	return &AdaGrad{
+		rate: rate,
+		eps:  eps,
+		sq:   make(map[*Layer]*mat.Dense),
+	}, nil
+}
+
+// Rate returns AdaGrad learning rate
+func (a AdaGrad) Rate() float64 {
+	return a.rate
+}
+
+// SetRate sets AdaGrad learning rate. It fails with error if the rate is negative.
+func (a *AdaGrad) SetRate(rate float64) error {
+	if rate < 0 {
+		return fmt.Errorf("Incorrect learning rate: %f\n", rate)
+	}
+	a.rate = rate
+	return nil
+}
+
+// Epsilon returns AdaGrad epsilon
+func (a AdaGrad) Epsilon() float64 {
+	return a.eps
+}
+
+// State returns a copy of accumulated squared gradients of the supplied layers
+func (a *AdaGrad) State(layers []*Layer) *OptimState {
+	return &OptimState{
+		Buffers: map[string]map[int]*mat.Dense{
+			"sq": saveBuffers(a.sq, layers),
+		},
+	}
+}
+
+// SetState replaces accumulated squared gradients of the supplied layers with the supplied state.
+// It fails with error if the state is nil or if the buffers don't match the layers.
+func (a *AdaGrad) SetState(layers []*Layer, state *OptimState) error {
+	if state == nil {
+		return fmt.Errorf("Incorrect optimizer state supplied: %v\n", state)
+	}
+	return loadBuffers(a.sq, layers, state.Buffers["sq"])
+}
+
+// Step updates layer weights using the supplied gradient. It accumulates the squared gradient
+// s = s + grad^2 and then updates the weights w = w - rate*grad/(sqrt(s)+eps).
+// Step fails with error if either layer or gradient are nil or their dimensions don't match.
+func (a *AdaGrad) Step(layer *Layer, grad *mat.Dense) error {
+	if err := checkGrad(layer, grad); err != nil {
+		return err
+	}
+	sq := layerBuffer(a.sq, layer)
+	sq.Apply(func(i, j int, x float64) float64 {
+		g := grad.At(i, j)
+		return x + g*g
+	}, sq)
+	applyStep(layer, adaptiveStep(a.rate, a.eps, grad, sq))
+	return nil
+}
+
+// decayWeights shrinks all layer weights except bias weights: w = w - decay*w
+func decayWeights(layer *Layer, decay float64) {
+	weights := layer.Weights()
+	weights.Apply(func(i, j int, x float64) float64 {
+		// bias weights are not decayed
+		if j == 0 {
+			return x
+		}
+		return x - decay*x
+	}, weights)
+}
+
+// adaptiveStep returns weights update rate*grad/(sqrt(sq)+eps)
+func adaptiveStep(rate, eps float64, grad, sq *mat.Dense) *mat.Dense {
+	step := new(mat.Dense)
+	step.Apply(func(i, j int, x float64) float64 {
+		return rate * x / (math.Sqrt(sq.At(i, j)) + eps)
+	}, grad)
+	return step
+}
+
+// ClipNorm scales the supplied gradients in place so that their global L2 norm, computed over
+// all gradients together, does not exceed maxNorm. Gradients with smaller norm are not modified.
+// It returns the global norm of the gradients before clipping.
+func ClipNorm(grads []*mat.Dense, maxNorm float64) float64 {
+	sum := 0.0
+	for _, grad := range grads {
+		norm := mat.Norm(grad, 2)
+		sum += norm * norm
+	}
+	norm := math.Sqrt(sum)
+	if norm > maxNorm && norm > 0 {
+		for _, grad := range grads {
+			grad.Scale(maxNorm/norm, grad)
+		}
+	}
+	return norm
+}
+
+// ClipValue clips every element of the supplied gradients in place to [-maxValue, maxValue] interval
+func ClipValue(grads []*mat.Dense, maxValue float64) {
+	for _, grad := range grads {
+		grad.Apply(func(i, j int, x float64) float64 {
+			return math.Max(-maxValue, math.Min(maxValue, x))
+		}, grad)
+	}
+}
@@ -0,0 +1,360 @@
+package neural
+
+import (
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// onesMx returns a matrix of the same size as the supplied matrix filled with ones
+func onesMx(m mat.Matrix) *mat.Dense {
+	rows, cols := m.Dims()
+	onesMx := mat.NewDense(rows, cols, nil)
+	onesMx.Apply(func(i, j int, x float64) float64 { return 1.0 }, onesMx)
+	return onesMx
+}
+
+func TestNewOptimizer(t *testing.T) {
+	assert := assert.New(t)
+
+	optim, err := newOptimizer(nil)
+	assert.Nil(optim)
+	assert.Error(err)
+	optim, err = newOptimizer(&config.OptimConfig{Method: "bfgs", LearnRate: 0.1})
+	assert.Nil(optim)
+	assert.Error(err)
+	optim, err = newOptimizer(&config.OptimConfig{Method: "sgd", LearnRate: 0.1, Momentum: 0.9, Nesterov: true})
+	assert.NotNil(optim)
+	assert.NoError(err)
+	sgd := optim.(*SGD)
+	assert.Equal(sgd.Rate(), 0.1)
+	assert.Equal(sgd.Momentum(), 0.9)
+	assert.True(sgd.Nesterov())
+}
+
+func TestNewSGD(t *testing.T) {
+	assert := assert.New(t)
+
+	testCases := []struct {
+		rate     float64
+		momentum float64
+		expErr   bool
+	}{
+		{0.1, 0.0, false},
+		{0.1, 0.9, false},
+		{0.0, 0.9, true},
+		{0.1, -0.1, true},
+		{0.1, 1.0, true},
+	}
+	for _, tc := range testCases {
+		sgd, err := NewSGD(tc.rate, tc.momentum, false)
+		if tc.expErr {
+			assert.Nil(sgd)
+			assert.Error(err)
+		} else {
+			assert.NotNil(sgd)
+			assert.NoError(err)
+		}
+	}
+}
+
+func TestSGDStep(t *testing.T) {
+	assert := assert.New(t)
+
+	testCases := []struct {
+		momentum float64
+		nesterov bool
+		// scales of the first and the second weights update
+		steps []float64
+	}{
+		{0.0, false, []float64{1.0, 1.0}},
+		{0.5, false, []float64{1.0, 1.5}},
+		{0.5, true, []float64{1.5, 1.75}},
+	}
+	for _, tc := range testCases {
+		sgd, err := NewSGD(0.1, tc.momentum, tc.nesterov)
+		assert.NoError(err)
+		layer := newTestLayer("hidden", 5, "sigmoid")
+		grad := onesMx(layer.Weights())
+		for _, scale := range tc.steps {
+			expMx := new(mat.Dense)
+			expMx.CloneFrom(layer.Weights())
+			expMx.Apply(func(i, j int, x float64) float64 { return x - 0.1*scale }, expMx)
+			assert.NoError(sgd.Step(layer, grad))
+			assert.True(mat.EqualApprox(layer.Weights(), expMx, 1e-9))
+		}
+	}
+	// incorrect parameters
+	sgd, err := NewSGD(0.1, 0.9, false)
+	assert.NoError(err)
+	layer := newTestLayer("hidden", 5, "sigmoid")
+	assert.Error(sgd.Step(nil, onesMx(layer.Weights())))
+	assert.Error(sgd.Step(layer, nil))
+	assert.Error(sgd.Step(layer, mat.NewDense(2, 2, nil)))
+	// masked weights are not updated
+	mask := onesMx(layer.Weights())
+	mask.Set(0, 1, 0.0)
+	assert.NoError(layer.SetMask(mask))
+	assert.NoError(sgd.Step(layer, onesMx(layer.Weights())))
+	assert.Equal(layer.Weights().At(0, 1), 0.0)
+}
+
+func TestNewAdam(t *testing.T) {
+	assert := assert.New(t)
+
+	adam, err := NewAdam(0.01, 0.0, 0.0, 0.0)
+	assert.NotNil(adam)
+	assert.NoError(err)
+	beta1, beta2 := adam.Betas()
+	assert.Equal(beta1, 0.9)
+	assert.Equal(beta2, 0.999)
+	assert.Equal(adam.Epsilon(), 1e-8)
+	assert.Equal(adam.Rate(), 0.01)
+	testCases := []struct {
+		rate, beta1, beta2, eps float64
+	}{
+		{0.0, 0.9, 0.999, 1e-8},
+		{0.01, 1.0, 0.999, 1e-8},
+		{0.01, 0.9, -0.1, 1e-8},
+		{0.01, 0.9, 0.999, -1.0},
+	}
+	for _, tc := range testCases {
+		adam, err := NewAdam(tc.rate, tc.beta1, tc.beta2, tc.eps)
+		assert.Nil(adam)
+		assert.Error(err)
+	}
+}
+
+func TestAdamStep(t *testing.T) {
+	assert := assert.New(t)
+
+	adam, err := NewAdam(0.1, 0.9, 0.999, 1e-8)
+	assert.NoError(err)
+	layer := newTestLayer("hidden", 5, "sigmoid")
+	assert.Error(adam.Step(layer, nil))
+	assert.Error(adam.Step(layer, mat.NewDense(2, 2, nil)))
+	// bias corrected updates of a constant gradient are equal to the learning rate
+	grad := onesMx(layer.Weights())
+	grad.Scale(3.0, grad)
+	for i := 0; i < 3; i++ {
+		expMx := new(mat.Dense)
+		expMx.CloneFrom(layer.Weights())
+		expMx.Apply(func(i, j int, x float64) float64 { return x - 0.1 }, expMx)
+		assert.NoError(adam.Step(layer, grad))
+		assert.True(mat.EqualApprox(layer.Weights(), expMx, 1e-6))
+	}
+	// adam trains the network
+	c := newTrainerConfig()
+	c.Optimize = &config.OptimConfig{Method: "adam", LearnRate: 0.05}
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	before, err := n.getCost(c, nil, inMx, labelsVec)
+	assert.NoError(err)
+	assert.NoError(n.Train(c, inMx, labelsVec))
+	after, err := n.getCost(c, nil, inMx, labelsVec)
+	assert.NoError(err)
+	assert.True(after < before)
+}
+
+func TestNewRMSProp(t *testing.T) {
+	assert := assert.New(t)
+
+	rms, err := NewRMSProp(0.01, 0.0, 0.0)
+	assert.NotNil(rms)
+	assert.NoError(err)
+	assert.Equal(rms.Rate(), 0.01)
+	assert.Equal(rms.Decay(), 0.9)
+	assert.Equal(rms.Epsilon(), 1e-8)
+	testCases := []struct {
+		rate, decay, eps float64
+	}{
+		{0.0, 0.9, 1e-8},
+		{0.01, 1.0, 1e-8},
+		{0.01, -0.1, 1e-8},
+		{0.01, 0.9, -1.0},
+	}
+	for _, tc := range testCases {
+		rms, err := NewRMSProp(tc.rate, tc.decay, tc.eps)
+		assert.Nil(rms)
+		assert.Error(err)
+	}
+}
+
+func TestNewAdaGrad(t *testing.T) {
+	assert := assert.New(t)
+
+	ada, err := NewAdaGrad(0.01, 0.0)
+	assert.NotNil(ada)
+	assert.NoError(err)
+	assert.Equal(ada.Rate(), 0.01)
+	assert.Equal(ada.Epsilon(), 1e-8)
+	ada, err = NewAdaGrad(0.0, 1e-8)
+	assert.Nil(ada)
+	assert.Error(err)
+	ada, err = NewAdaGrad(0.01, -1.0)
+	assert.Nil(ada)
+	assert.Error(err)
+}
+
+func TestAdaptiveStep(t *testing.T) {
+	assert := assert.New(t)
+
+	rms, err := NewRMSProp(0.1, 0.75, 1e-8)
+	assert.NoError(err)
+	ada, err := NewAdaGrad(0.1, 1e-8)
+	assert.NoError(err)
+	testCases := []struct {
+		optim Optimizer
+		// scales of the consecutive weights updates of a constant gradient
+		steps []float64
+	}{
+		// squared gradients average: 0.25, 0.4375
+		{rms, []float64{0.2, 0.1511857892}},
+		// accumulated squared gradients: 1, 2
+		{ada, []float64{0.1, 0.0707106781}},
+	}
+	for _, tc := range testCases {
+		layer := newTestLayer("hidden", 5, "sigmoid")
+		assert.Error(tc.optim.Step(layer, nil))
+		assert.Error(tc.optim.Step(layer, mat.NewDense(2, 2, nil)))
+		grad := onesMx(layer.Weights())
+		for _, scale := range tc.steps {
+			expMx := new(mat.Dense)
+			expMx.CloneFrom(layer.Weights())
+			expMx.Apply(func(i, j int, x float64) float64 { return x - scale }, expMx)
+			assert.NoError(tc.optim.Step(layer, grad))
+			assert.True(mat.EqualApprox(layer.Weights(), expMx, 1e-6))
+		}
+	}
+	// adaptive optimizers train the network
+	for _, method := range []string{"rmsprop", "adagrad"} {
+		c := newTrainerConfig()
+		c.Optimize = &config.OptimConfig{Method: method, LearnRate: 0.05}
+		n, err := NewFeedForward(4, []int{5}, 5)
+		assert.NoError(err)
+		before, err := n.getCost(c, nil, inMx, labelsVec)
+		assert.NoError(err)
+		assert.NoError(n.Train(c, inMx, labelsVec))
+		after, err := n.getCost(c, nil, inMx, labelsVec)
+		assert.NoError(err)
+		assert.True(after < before)
+	}
+}
+
+func TestAdamW(t *testing.T) {
+	assert := assert.New(t)
+
+	adamw, err := NewAdamW(0.1, 0.9, 0.999, 1e-8, -0.1)
+	assert.Nil(adamw)
+	assert.Error(err)
+	adamw, err = NewAdamW(0.0, 0.9, 0.999, 1e-8, 0.1)
+	assert.Nil(adamw)
+	assert.Error(err)
+	adamw, err = NewAdamW(0.1, 0.9, 0.999, 1e-8, 0.5)
+	assert.NotNil(adamw)
+	assert.NoError(err)
+	assert.Equal(adamw.WeightDecay(), 0.5)
+	// zero gradient only decays non-bias weights
+	layer := newTestLayer("hidden", 5, "sigmoid")
+	expMx := new(mat.Dense)
+	expMx.CloneFrom(layer.Weights())
+	expMx.Apply(func(i, j int, x float64) float64 {
+		if j == 0 {
+			return x
+		}
+		return 0.95 * x
+	}, expMx)
+	rows, cols := layer.Weights().Dims()
+	assert.NoError(adamw.Step(layer, mat.NewDense(rows, cols, nil)))
+	assert.True(mat.EqualApprox(layer.Weights(), expMx, 1e-9))
+	// adamw is available via configuration
+	optim, err := newOptimizer(&config.OptimConfig{Method: "adamw", LearnRate: 0.1, WeightDecay: 0.01})
+	assert.NoError(err)
+	assert.Equal(optim.(*Adam).WeightDecay(), 0.01)
+}
+
+func TestSetRate(t *testing.T) {
+	assert := assert.New(t)
+
+	sgd, err := NewSGD(0.1, 0.0, false)
+	assert.NoError(err)
+	adam, err := NewAdam(0.1, 0.0, 0.0, 0.0)
+	assert.NoError(err)
+	rms, err := NewRMSProp(0.1, 0.0, 0.0)
+	assert.NoError(err)
+	ada, err := NewAdaGrad(0.1, 0.0)
+	assert.NoError(err)
+	for _, optim := range []RateOptimizer{sgd, adam, rms, ada} {
+		assert.Error(optim.SetRate(-0.1))
+		assert.NoError(optim.SetRate(0.01))
+	}
+	assert.Equal(sgd.Rate(), 0.01)
+	assert.Equal(adam.Rate(), 0.01)
+	assert.Equal(rms.Rate(), 0.01)
+	assert.Equal(ada.Rate(), 0.01)
+}
+
+func TestOptimState(t *testing.T) {
+	assert := assert.New(t)
+
+	newOptims := func() []StatefulOptimizer {
+		sgd, err := NewSGD(0.1, 0.9, false)
+		assert.NoError(err)
+		adam, err := NewAdam(0.1, 0.0, 0.0, 0.0)
+		assert.NoError(err)
+		rms, err := NewRMSProp(0.1, 0.0, 0.0)
+		assert.NoError(err)
+		ada, err := NewAdaGrad(0.1, 0.0)
+		assert.NoError(err)
+		return []StatefulOptimizer{sgd, adam, rms, ada}
+	}
+	restored := newOptims()
+	for i, optim := range newOptims() {
+		layer := newTestLayer("hidden", 5, "sigmoid")
+		other := layer.Clone()
+		layers := []*Layer{layer}
+		grad := onesMx(layer.Weights())
+		grad.Apply(func(i, j int, x float64) float64 { return float64(i+j) / 10.0 }, grad)
+		assert.NoError(optim.Step(layer, grad))
+		assert.NoError(optim.Step(layer, grad))
+		// restored optimizer continues exactly where the saved one left off
+		state := optim.State(layers)
+		other.Weights().Copy(layer.Weights())
+		assert.NoError(restored[i].SetState([]*Layer{other}, state))
+		assert.NoError(optim.Step(layer, grad))
+		assert.NoError(restored[i].Step(other, grad))
+		assert.True(mat.Equal(layer.Weights(), other.Weights()))
+		// incorrect state
+		assert.Error(restored[i].SetState(layers, nil))
+		assert.Error(restored[i].SetState([]*Layer{newTestLayer("hidden", 3, "sigmoid")}, state))
+		assert.Error(restored[i].SetState(nil, state))
+	}
+}
+
+func TestClipNorm(t *testing.T) {
+	assert := assert.New(t)
+
+	grads := []*mat.Dense{
+		mat.NewDense(1, 2, []float64{3.0, 0.0}),
+		mat.NewDense(2, 1, []float64{0.0, 4.0}),
+	}
+	// gradients within the norm are not modified
+	assert.Equal(ClipNorm(grads, 10.0), 5.0)
+	assert.True(mat.Equal(grads[0], mat.NewDense(1, 2, []float64{3.0, 0.0})))
+	// gradients are scaled by their global norm
+	assert.Equal(ClipNorm(grads, 1.0), 5.0)
+	assert.True(mat.EqualApprox(grads[0], mat.NewDense(1, 2, []float64{0.6, 0.0}), 1e-9))
+	assert.True(mat.EqualApprox(grads[1], mat.NewDense(2, 1, []float64{0.0, 0.8}), 1e-9))
+}
+
+func TestClipValue(t *testing.T) {
+	assert := assert.New(t)
+
+	grads := []*mat.Dense{
+		mat.NewDense(1, 3, []float64{-3.0, 0.5, 2.0}),
+	}
+	ClipValue(grads, 1.0)
+	assert.True(mat.Equal(grads[0], mat.NewDense(1, 3, []float64{-1.0, 0.5, 1.0})))
+}
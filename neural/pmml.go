@@ -0,0 +1,274 @@
+package neural
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// pmmlTarget is the name of PMML target field
+const pmmlTarget = "class"
+
+// pmmlDoc is PMML document
+type pmmlDoc struct {
+	XMLName        xml.Name          `xml:"PMML"`
+	Xmlns          string            `xml:"xmlns,attr"`
+	Version        string            `xml:"version,attr"`
+	Header         pmmlHeader        `xml:"Header"`
+	DataDictionary pmmlDataDict      `xml:"DataDictionary"`
+	NeuralNetwork  pmmlNeuralNetwork `xml:"NeuralNetwork"`
+}
+
+// pmmlHeader is PMML document header
+type pmmlHeader struct {
+	Description string `xml:"description,attr"`
+	Application struct {
+		Name string `xml:"name,attr"`
+	} `xml:"Application"`
+}
+
+// pmmlDataDict is PMML data dictionary
+type pmmlDataDict struct {
+	NumberOfFields int             `xml:"numberOfFields,attr"`
+	Fields         []pmmlDataField `xml:"DataField"`
+}
+
+// pmmlDataField is PMML data field
+type pmmlDataField struct {
+	Name     string      `xml:"name,attr"`
+	Optype   string      `xml:"optype,attr"`
+	DataType string      `xml:"dataType,attr"`
+	Values   []pmmlValue `xml:"Value,omitempty"`
+}
+
+// pmmlValue is a value of categorical PMML data field
+type pmmlValue struct {
+	Value string `xml:"value,attr"`
+}
+
+// pmmlNeuralNetwork is PMML neural network model
+type pmmlNeuralNetwork struct {
+	FunctionName       string             `xml:"functionName,attr"`
+	ActivationFunction string             `xml:"activationFunction,attr"`
+	MiningSchema       []pmmlMiningField  `xml:"MiningSchema>MiningField"`
+	Inputs             pmmlNeuralInputs   `xml:"NeuralInputs"`
+	Layers             []*pmmlNeuralLayer `xml:"NeuralLayer"`
+	Outputs            pmmlNeuralOutputs  `xml:"NeuralOutputs"`
+}
+
+// pmmlMiningField is PMML mining schema field
+type pmmlMiningField struct {
+	Name      string `xml:"name,attr"`
+	UsageType string `xml:"usageType,attr,omitempty"`
+}
+
+// pmmlNeuralInputs contains PMML neural network inputs
+type pmmlNeuralInputs struct {
+	NumberOfInputs int               `xml:"numberOfInputs,attr"`
+	Inputs         []pmmlNeuralInput `xml:"NeuralInput"`
+}
+
+// pmmlNeuralInput is PMML neural network input
+type pmmlNeuralInput struct {
+	ID    string `xml:"id,attr"`
+	Field struct {
+		Optype   string `xml:"optype,attr"`
+		DataType string `xml:"dataType,attr"`
+		FieldRef struct {
+			Field string `xml:"field,attr"`
+		} `xml:"FieldRef"`
+	} `xml:"DerivedField"`
+}
+
+// pmmlNeuralLayer is PMML neural network layer
+type pmmlNeuralLayer struct {
+	NumberOfNeurons     int           `xml:"numberOfNeurons,attr"`
+	ActivationFunction  string        `xml:"activationFunction,attr"`
+	NormalizationMethod string        `xml:"normalizationMethod,attr,omitempty"`
+	Neurons             []*pmmlNeuron `xml:"Neuron"`
+}
+
+// pmmlNeuron is PMML neuron
+type pmmlNeuron struct {
+	ID   string    `xml:"id,attr"`
+	Bias float64   `xml:"bias,attr"`
+	Cons []pmmlCon `xml:"Con"`
+}
+
+// pmmlCon is PMML connection between neurons
+type pmmlCon struct {
+	From   string  `xml:"from,attr"`
+	Weight float64 `xml:"weight,attr"`
+}
+
+// pmmlNeuralOutputs contains PMML neural network outputs
+type pmmlNeuralOutputs struct {
+	NumberOfOutputs int                `xml:"numberOfOutputs,attr"`
+	Outputs         []pmmlNeuralOutput `xml:"NeuralOutput"`
+}
+
+// pmmlNeuralOutput is PMML neural network output
+type pmmlNeuralOutput struct {
+	OutputNeuron string `xml:"outputNeuron,attr"`
+	Field        struct {
+		Optype       string `xml:"optype,attr"`
+		DataType     string `xml:"dataType,attr"`
+		NormDiscrete struct {
+			Field string `xml:"field,attr"`
+			Value string `xml:"value,attr"`
+		} `xml:"NormDiscrete"`
+	} `xml:"DerivedField"`
+}
+
+// pmmlSource is a scaled output of PMML neuron which contributes to the output of a network layer
+type pmmlSource struct {
+	id    string
+	scale float64
+}
+
+// pmmlActivations maps activation functions to PMML activation functions
+var pmmlActivations = map[string]string{
+	Sigmoid: "logistic",
+	Tanh:    "tanh",
+	StdReLU: "rectifier",
+	ReLU:    "rectifier",
+	Softmax: "identity",
+}
+
+// ExportPMML writes the network to w as PMML 4.4 NeuralNetwork classification model, so it can be
+// consumed by PMML scoring engines. fields contains the names of network inputs: if it's nil, inputs
+// are called x1, x2, ... The model predicts categorical "class" field whose values are class labels
+// 1, 2, ... in the order of network outputs. Leaky ReLU, which PMML does not support, is exported as
+// the difference of two rectifier neurons. ExportPMML fails with error if the number of fields does not
+// match the number of network inputs or if the network has convolution or maxout layers, branches or
+// heads, or leaky ReLU OUTPUT layer, which can't be exported.
+func (n *Network) ExportPMML(w io.Writer, fields []string) error {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if len(n.layers) < 2 || n.layers[0].Kind() != INPUT {
+		return fmt.Errorf("Network must have %s layer and at least one more layer to be exported\n", INPUT)
+	}
+	if len(n.branches) > 0 || len(n.heads) > 0 {
+		return fmt.Errorf("Networks with branches or heads can't be exported to PMML\n")
+	}
+	inputs := n.layers[0].OutSize()
+	if fields == nil {
+		for i := 0; i < inputs; i++ {
+			fields = append(fields, fmt.Sprintf("x%d", i+1))
+		}
+	}
+	if len(fields) != inputs {
+		return fmt.Errorf("Fields mismatch. Inputs: %d, Fields: %d\n", inputs, len(fields))
+	}
+	doc := &pmmlDoc{
+		Xmlns:   "http://www.dmg.org/PMML-4_4",
+		Version: "4.4",
+	}
+	doc.Header.Description = "Network " + n.id
+	doc.Header.Application.Name = "go-neural"
+	nn := &doc.NeuralNetwork
+	nn.FunctionName = "classification"
+	nn.ActivationFunction = "logistic"
+	nn.Inputs.NumberOfInputs = inputs
+	// every layer output is a combination of scaled outputs of the preceding PMML layer neurons
+	var prev [][]pmmlSource
+	for i, field := range fields {
+		doc.DataDictionary.Fields = append(doc.DataDictionary.Fields,
+			pmmlDataField{Name: field, Optype: "continuous", DataType: "double"})
+		nn.MiningSchema = append(nn.MiningSchema, pmmlMiningField{Name: field})
+		in := pmmlNeuralInput{ID: fmt.Sprintf("0,%d", i)}
+		in.Field.Optype, in.Field.DataType = "continuous", "double"
+		in.Field.FieldRef.Field = field
+		nn.Inputs.Inputs = append(nn.Inputs.Inputs, in)
+		prev = append(prev, []pmmlSource{{id: in.ID, scale: 1.0}})
+	}
+	for i, layer := range n.layers[1:] {
+		var err error
+		if prev, err = pmmlLayer(nn, layer, i+1, prev); err != nil {
+			return err
+		}
+	}
+	target := pmmlDataField{Name: pmmlTarget, Optype: "categorical", DataType: "string"}
+	nn.Outputs.NumberOfOutputs = len(prev)
+	for i, out := range prev {
+		label := strconv.Itoa(i + 1)
+		target.Values = append(target.Values, pmmlValue{Value: label})
+		o := pmmlNeuralOutput{OutputNeuron: out[0].id}
+		o.Field.Optype, o.Field.DataType = "categorical", "string"
+		o.Field.NormDiscrete.Field, o.Field.NormDiscrete.Value = pmmlTarget, label
+		nn.Outputs.Outputs = append(nn.Outputs.Outputs, o)
+	}
+	doc.DataDictionary.Fields = append(doc.DataDictionary.Fields, target)
+	doc.DataDictionary.NumberOfFields = len(doc.DataDictionary.Fields)
+	nn.MiningSchema = append(nn.MiningSchema, pmmlMiningField{Name: pmmlTarget, UsageType: "target"})
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// pmmlLayer adds PMML layers computing the output of the supplied network layer, which is idx-th
+// network layer, to the PMML neural network. prev contains the sources of the preceding layer outputs.
+// It returns the sources of the network layer outputs.
+func pmmlLayer(nn *pmmlNeuralNetwork, l *Layer, idx int, prev [][]pmmlSource) ([][]pmmlSource, error) {
+	if l.conv != nil || l.pieces > 0 {
+		return nil, fmt.Errorf("Unsupported PMML layer %s: %s\n", l.ID(), l.Type())
+	}
+	act, ok := pmmlActivations[l.meta]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported PMML activation of layer %s: %s\n", l.ID(), l.meta)
+	}
+	leaky := l.meta == ReLU
+	if leaky && l.kind == OUTPUT {
+		return nil, fmt.Errorf("Unsupported PMML activation of %s layer: %s\n", OUTPUT, l.meta)
+	}
+	pl := &pmmlNeuralLayer{ActivationFunction: act}
+	if l.meta == Softmax {
+		pl.NormalizationMethod = "softmax"
+	}
+	// rescaled tanh is sigmoid of doubled input
+	scale := 1.0
+	if l.meta == Tanh && l.kind == OUTPUT {
+		pl.ActivationFunction, scale = "logistic", 2.0
+	}
+	// leaky ReLU is the difference of rectifier of the neuron input and scaled rectifier of its negation
+	signs := []float64{1.0}
+	if leaky {
+		signs = append(signs, -1.0)
+	}
+	weights := l.maskedWeights()
+	rows, cols := weights.Dims()
+	out := make([][]pmmlSource, rows)
+	for s, sign := range signs {
+		for j := 0; j < rows; j++ {
+			neuron := &pmmlNeuron{
+				ID:   fmt.Sprintf("%d,%d", idx, s*rows+j),
+				Bias: sign * scale * weights.At(j, 0),
+			}
+			for k := 1; k < cols; k++ {
+				for _, src := range prev[k-1] {
+					neuron.Cons = append(neuron.Cons, pmmlCon{
+						From:   src.id,
+						Weight: sign * scale * weights.At(j, k) * src.scale,
+					})
+				}
+			}
+			pl.Neurons = append(pl.Neurons, neuron)
+			src := pmmlSource{id: neuron.ID, scale: 1.0}
+			if sign < 0 {
+				src.scale = -0.1
+			}
+			out[j] = append(out[j], src)
+		}
+	}
+	pl.NumberOfNeurons = len(pl.Neurons)
+	nn.Layers = append(nn.Layers, pl)
+	return out, nil
+}
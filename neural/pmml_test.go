@@ -0,0 +1,104 @@
+package neural
+
+import (
+	"bytes"
+	"encoding/xml"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// evalPMML evaluates PMML neural network for the supplied inputs and returns its outputs
+func evalPMML(doc *pmmlDoc, in []float64) []float64 {
+	values := make(map[string]float64)
+	for i, input := range doc.NeuralNetwork.Inputs.Inputs {
+		values[input.ID] = in[i]
+	}
+	for _, layer := range doc.NeuralNetwork.Layers {
+		sum := 0.0
+		for _, neuron := range layer.Neurons {
+			z := neuron.Bias
+			for _, con := range neuron.Cons {
+				z += con.Weight * values[con.From]
+			}
+			switch layer.ActivationFunction {
+			case "logistic":
+				z = 1.0 / (1.0 + math.Exp(-z))
+			case "tanh":
+				z = math.Tanh(z)
+			case "rectifier":
+				z = math.Max(z, 0)
+			}
+			if layer.NormalizationMethod == "softmax" {
+				z = math.Exp(z)
+				sum += z
+			}
+			values[neuron.ID] = z
+		}
+		if layer.NormalizationMethod == "softmax" {
+			for _, neuron := range layer.Neurons {
+				values[neuron.ID] /= sum
+			}
+		}
+	}
+	var out []float64
+	for _, o := range doc.NeuralNetwork.Outputs.Outputs {
+		out = append(out, values[o.OutputNeuron])
+	}
+	return out
+}
+
+func TestExportPMML(t *testing.T) {
+	assert := assert.New(t)
+
+	in := []float64{0.3, -1.2, 0.8, 2.1}
+	nets := [][]string{
+		{ReLU, Tanh, Softmax},
+		{StdReLU, ReLU, Sigmoid},
+		{Sigmoid, Tanh},
+	}
+	for _, acts := range nets {
+		b := NewBuilder().Input(4)
+		for _, act := range acts[:len(acts)-1] {
+			b.Hidden(5, act)
+		}
+		n, err := b.Output(3, acts[len(acts)-1]).Build()
+		assert.NoError(err)
+		var buf bytes.Buffer
+		assert.NoError(n.ExportPMML(&buf, nil))
+		doc := new(pmmlDoc)
+		assert.NoError(xml.Unmarshal(buf.Bytes(), doc))
+		assert.Equal("4.4", doc.Version)
+		assert.Equal(5, doc.DataDictionary.NumberOfFields)
+		assert.Equal("x1", doc.DataDictionary.Fields[0].Name)
+		assert.Len(doc.DataDictionary.Fields[4].Values, 3)
+		assert.Equal(3, doc.NeuralNetwork.Outputs.NumberOfOutputs)
+		out, err := n.ForwardProp(mat.NewDense(1, 4, in), len(n.Layers())-1)
+		assert.NoError(err)
+		expected := mat.Row(nil, 0, out)
+		actual := evalPMML(doc, in)
+		for i := range expected {
+			assert.InDelta(expected[i], actual[i], 1e-9, "%v", acts)
+		}
+	}
+	// custom field names
+	n, err := NewBuilder().Input(2).Hidden(3, ReLU).Output(2, Softmax).Build()
+	assert.NoError(err)
+	var buf bytes.Buffer
+	assert.NoError(n.ExportPMML(&buf, []string{"foo", "bar"}))
+	assert.Contains(buf.String(), `<FieldRef field="bar"></FieldRef>`)
+	assert.Error(n.ExportPMML(&buf, []string{"foo"}))
+	// unsupported networks
+	n, err = NewBuilder().Input(2).Hidden(3, Sigmoid).Output(2, ReLU).Build()
+	assert.NoError(err)
+	assert.Error(n.ExportPMML(&buf, nil))
+	n, err = NewBuilder().Input(2).Maxout(3, 2).Output(2, Softmax).Build()
+	assert.NoError(err)
+	assert.Error(n.ExportPMML(&buf, nil))
+	n, err = newModelNetwork()
+	assert.NoError(err)
+	assert.Error(n.ExportPMML(&buf, nil))
+	assert.Error(new(Network).ExportPMML(&buf, nil))
+}
@@ -0,0 +1,125 @@
+package neural
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/blas"
+	"gonum.org/v1/gonum/blas/blas32"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Precision defines floating point precision of network forward propagation
+type Precision uint
+
+const (
+	// Float64 computes forward propagation in float64 precision
+	Float64 Precision = iota
+	// Float32 computes forward propagation in float32 precision
+	Float32
+)
+
+// String implements Stringer interface for pretty printing
+func (p Precision) String() string {
+	switch p {
+	case Float64:
+		return "float64"
+	case Float32:
+		return "float32"
+	}
+	return "unknown"
+}
+
+// precision maps precision names to Precision
+var precision = map[string]Precision{
+	"":        Float64,
+	"float64": Float64,
+	"float32": Float32,
+}
+
+// Precision returns network precision
+func (n *Network) Precision() Precision {
+	return n.precision
+}
+
+// SetPrecision sets forward propagation precision of all network layers, including the layers of
+// input branches and heads and the layers added to the network later on. Float32 precision is used
+// for inference only: layers always use float64 precision while they are being trained, so the
+// network can be trained and used for inference without switching precision back and forth.
+// Layers in Float32 precision keep float32 copy of their weights, which is refreshed when the weights
+// are set or trained, and multiply it by blas32 gemm.
+// It fails with error if unsupported precision is requested.
+func (n *Network) SetPrecision(p Precision) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if p != Float64 && p != Float32 {
+		return fmt.Errorf("Unsupported precision: %s\n", p)
+	}
+	n.precision = p
+	n.applyPrecision()
+	return nil
+}
+
+// applyPrecision sets network precision, fast activation and device to all network layers
+func (n *Network) applyPrecision() {
+	for _, layer := range n.allLayers() {
+		layer.precision = n.precision
+		layer.fast = n.fast
+		layer.device = n.device
+		layer.syncWeights32()
+	}
+}
+
+// Precision returns layer forward propagation precision
+func (l Layer) Precision() Precision {
+	return l.precision
+}
+
+// syncWeights32 refreshes float32 copy of the masked layer weights used by Float32 precision
+// forward propagation. It must be called whenever the weights change outside of training;
+// layers in Float64 precision don't keep the copy.
+func (l *Layer) syncWeights32() {
+	if l.precision != Float32 || l.weights == nil {
+		l.weights32 = blas32.General{}
+		return
+	}
+	l.weights32 = toFloat32(l.maskedWeights())
+}
+
+// actIn calculates layer activation inputs from the input matrix with bias
+// in the precision configured for the layer
+func (l *Layer) actIn(biasInMx *mat.Dense) *mat.Dense {
+	if l.precision == Float32 && !l.training {
+		return l.mulT32(biasInMx)
+	}
+	actInMx := new(mat.Dense)
+	l.mul(actInMx, biasInMx, l.maskedWeights().T())
+	return actInMx
+}
+
+// mulT32 multiplies matrix a by transposed layer weights in float32 precision using blas32 gemm
+func (l *Layer) mulT32(a *mat.Dense) *mat.Dense {
+	w := l.weights32
+	if w.Data == nil {
+		w = toFloat32(l.maskedWeights())
+	}
+	a32 := toFloat32(a)
+	out := blas32.General{Rows: a32.Rows, Cols: w.Rows, Stride: w.Rows, Data: make([]float32, a32.Rows*w.Rows)}
+	blas32.Gemm(blas.NoTrans, blas.Trans, 1, a32, w, 0, out)
+	data := make([]float64, len(out.Data))
+	for i, v := range out.Data {
+		data[i] = float64(v)
+	}
+	return mat.NewDense(out.Rows, out.Cols, data)
+}
+
+// toFloat32 returns matrix elements converted to float32 stored row by row
+func toFloat32(m *mat.Dense) blas32.General {
+	rows, cols := m.Dims()
+	data := make([]float32, rows*cols)
+	for i := 0; i < rows; i++ {
+		for j, v := range m.RawRowView(i) {
+			data[i*cols+j] = float32(v)
+		}
+	}
+	return blas32.General{Rows: rows, Cols: cols, Stride: cols, Data: data}
+}
@@ -0,0 +1,73 @@
+package neural
+
+import (
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestPrecision(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NotNil(n)
+	assert.NoError(err)
+	assert.Equal(n.Precision(), Float64)
+	assert.Equal(Float32.String(), "float32")
+	assert.Error(n.SetPrecision(Precision(10)))
+	expOut, err := n.ForwardProp(inMx, 2)
+	assert.NoError(err)
+	assert.NoError(n.SetPrecision(Float32))
+	for _, layer := range n.Layers() {
+		assert.Equal(layer.Precision(), Float32)
+	}
+	// float32 output is close to float64 output
+	out, err := n.ForwardProp(inMx, 2)
+	assert.NoError(err)
+	assert.True(mat.EqualApprox(out, expOut, 1e-5))
+	// float32 weights are refreshed when the weights change
+	layer := n.Layers()[1]
+	weights := mat.DenseCopyOf(layer.Weights())
+	weights.Scale(2.0, weights)
+	assert.NoError(layer.SetWeights(weights))
+	assert.NoError(n.SetPrecision(Float64))
+	assert.Nil(layer.weights32.Data)
+	expOut, err = n.ForwardProp(inMx, 2)
+	assert.NoError(err)
+	assert.NoError(n.SetPrecision(Float32))
+	out, err = n.ForwardProp(inMx, 2)
+	assert.NoError(err)
+	assert.True(mat.EqualApprox(out, expOut, 1e-5))
+	weights.Scale(0.5, weights)
+	assert.NoError(layer.SetWeights(weights))
+	rows, cols := weights.Dims()
+	assert.Equal(rows*cols, len(layer.weights32.Data))
+	assert.Equal(float32(weights.At(1, 2)), layer.weights32.Data[cols+2])
+	// layers added later use the network precision
+	assert.NoError(n.AddLayer(newTestLayer("hidden", 3, Tanh)))
+	assert.Equal(n.Layers()[2].Precision(), Float32)
+	// precision is cloned
+	assert.Equal(n.Clone().Precision(), Float32)
+	// precision can be set via network configuration
+	netConfig := &config.NetConfig{
+		Kind: "feedfwd",
+		Arch: &config.NetArch{
+			Input: &config.LayerConfig{Kind: "input", Size: 4},
+			Output: &config.LayerConfig{
+				Kind:   "output",
+				Size:   3,
+				NeurFn: &config.NeuronConfig{Activation: Softmax},
+			},
+		},
+		Precision: "float32",
+	}
+	n, err = NewNetwork(netConfig)
+	assert.NoError(err)
+	assert.Equal(n.Precision(), Float32)
+	netConfig.Precision = "float16"
+	n, err = NewNetwork(netConfig)
+	assert.Nil(n)
+	assert.Error(err)
+}
@@ -0,0 +1,89 @@
+package neural
+
+import (
+	"fmt"
+	"runtime"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Prediction is classification of a single sample returned by PredictBatch
+type Prediction struct {
+	// Index is the position of the sample in the input stream starting from 0
+	Index int
+	// Probs contains probabilities of the sample belonging to particular classes expressed in percents
+	Probs []float64
+	// Class is the predicted class, i.e. the class with the largest probability
+	Class int
+	// Err is classification error: the other fields except Index are not set if it's not nil
+	Err error
+}
+
+// PredictBatch classifies the samples received from inputs the same way as Classify does using the supplied
+// number of workers, which run forward propagation concurrently, and sends their predictions to the returned
+// channel in the order the samples were received. workers smaller than 1 uses GOMAXPROCS workers. At most
+// workers samples are classified at the same time, so slow consumers slow the classification down.
+// Samples which can't be classified, such as the samples whose size doesn't match network input, produce
+// predictions with Err set and the classification carries on. The returned channel is closed once inputs
+// is closed and all the predictions are sent; it must be drained, otherwise the workers never finish.
+func (n *Network) PredictBatch(inputs <-chan []float64, workers int) <-chan Prediction {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	type job struct {
+		index  int
+		sample []float64
+		result chan Prediction
+	}
+	jobs := make(chan job)
+	// pending holds results in the order of inputs and bounds the number of samples in flight
+	pending := make(chan chan Prediction, workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for j := range jobs {
+				j.result <- n.predict(j.index, j.sample)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		defer close(pending)
+		index := 0
+		for sample := range inputs {
+			result := make(chan Prediction, 1)
+			pending <- result
+			jobs <- job{index: index, sample: sample, result: result}
+			index++
+		}
+	}()
+	predictions := make(chan Prediction)
+	go func() {
+		defer close(predictions)
+		for result := range pending {
+			predictions <- <-result
+		}
+	}()
+	return predictions
+}
+
+// predict classifies a single sample with the supplied index
+func (n *Network) predict(index int, sample []float64) Prediction {
+	p := Prediction{Index: index}
+	if len(sample) == 0 {
+		p.Err = fmt.Errorf("Can't classify empty sample %d\n", index)
+		return p
+	}
+	classMx, err := n.Classify(mat.NewDense(1, len(sample), sample))
+	if err != nil {
+		p.Err = err
+		return p
+	}
+	p.Probs = mat.Row(nil, 0, classMx)
+	for i, prob := range p.Probs {
+		if prob > p.Probs[p.Class] {
+			p.Class = i
+		}
+	}
+	p.Class++
+	return p
+}
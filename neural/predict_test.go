@@ -0,0 +1,54 @@
+package neural
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestPredictBatch(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewFeedForward(4, []int{5}, 3)
+	assert.NotNil(n)
+	assert.NoError(err)
+	expMx, err := n.Classify(inMx)
+	assert.NoError(err)
+	rows, _ := inMx.Dims()
+	for _, workers := range []int{0, 1, 3} {
+		inputs := make(chan []float64)
+		go func() {
+			// samples are repeated, so the workers process more samples than they can hold
+			for k := 0; k < 4; k++ {
+				for i := 0; i < rows; i++ {
+					inputs <- mat.Row(nil, i, inMx)
+				}
+			}
+			// incorrect samples don't stop the classification
+			inputs <- []float64{1.0}
+			inputs <- nil
+			inputs <- mat.Row(nil, 0, inMx)
+			close(inputs)
+		}()
+		var predictions []Prediction
+		for p := range n.PredictBatch(inputs, workers) {
+			predictions = append(predictions, p)
+		}
+		assert.Len(predictions, 4*rows+3)
+		for i, p := range predictions {
+			assert.Equal(i, p.Index)
+			switch {
+			case i < 4*rows:
+				assert.NoError(p.Err)
+				assert.Equal(mat.Row(nil, i%rows, expMx), p.Probs, "Workers: %d", workers)
+				assert.Equal(mat.Row(nil, i%rows, expMx)[p.Class-1], mat.Max(expMx.(*mat.Dense).RowView(i%rows)))
+			case i < 4*rows+2:
+				assert.Error(p.Err)
+				assert.Nil(p.Probs)
+			default:
+				assert.NoError(p.Err)
+			}
+		}
+	}
+}
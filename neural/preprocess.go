@@ -0,0 +1,132 @@
+package neural
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"gonum.org/v1/gonum/mat"
+)
+
+// preprocessData is serializable representation of a preprocessing transformer
+type preprocessData struct {
+	Kind   string          `json:"kind"`
+	Params json.RawMessage `json:"params"`
+}
+
+// preprocessKinds maps names of preprocessing transformers to functions which create them
+var preprocessKinds = map[string]func() dataset.Transformer{
+	"standard": func() dataset.Transformer { return new(dataset.StandardScaler) },
+	"minmax":   func() dataset.Transformer { return new(dataset.MinMaxScaler) },
+	"imputer":  func() dataset.Transformer { return new(dataset.Imputer) },
+	"pca":      func() dataset.Transformer { return new(dataset.PCA) },
+}
+
+// preprocessKind returns the name of the supplied preprocessing transformer.
+// It returns empty string if the transformer is not supported.
+func preprocessKind(t dataset.Transformer) string {
+	switch t.(type) {
+	case *dataset.StandardScaler:
+		return "standard"
+	case *dataset.MinMaxScaler:
+		return "minmax"
+	case *dataset.Imputer:
+		return "imputer"
+	case *dataset.PCA:
+		return "pca"
+	}
+	return ""
+}
+
+// Preprocessing returns the preprocessing transformers of the network in the order they are applied.
+// It returns nil if the network has no preprocessing.
+func (n *Network) Preprocessing() []dataset.Transformer {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.preprocess == nil {
+		return nil
+	}
+	return append([]dataset.Transformer{}, n.preprocess...)
+}
+
+// SetPreprocessing sets the fitted transformers which preprocess the network input in the supplied
+// order, such as an Imputer followed by a StandardScaler and PCA. The transformers are saved along
+// with the network and Classify, ClassifyContext and PredictBatch apply them to their input, so
+// inference applies exactly the same preprocessing as training. Calling it without transformers
+// removes the preprocessing. It fails with error if any transformer is nil or if it is not
+// a StandardScaler, MinMaxScaler, Imputer or PCA.
+func (n *Network) SetPreprocessing(transformers ...dataset.Transformer) error {
+	for i, t := range transformers {
+		if t == nil || preprocessKind(t) == "" {
+			return fmt.Errorf("Unsupported preprocessing transformer %d: %T\n", i, t)
+		}
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.preprocess = nil
+	if len(transformers) > 0 {
+		n.preprocess = append([]dataset.Transformer{}, transformers...)
+	}
+	return nil
+}
+
+// Preprocess applies the preprocessing transformers of the network to the supplied input and returns
+// the preprocessed copy of the input. It fails with error if any transformer fails.
+func (n *Network) Preprocess(inMx mat.Matrix) (*mat.Dense, error) {
+	if inMx == nil {
+		return nil, fmt.Errorf("Incorrect input supplied: %v\n", inMx)
+	}
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if len(n.preprocess) == 0 {
+		return mat.DenseCopyOf(inMx), nil
+	}
+	outMx, err := n.preprocessed(inMx)
+	if err != nil {
+		return nil, err
+	}
+	return outMx.(*mat.Dense), nil
+}
+
+// preprocessed applies the preprocessing transformers of the network to the supplied input without
+// locking the network. It returns the input unchanged if the network has no preprocessing.
+func (n *Network) preprocessed(inMx mat.Matrix) (mat.Matrix, error) {
+	for _, t := range n.preprocess {
+		outMx, err := t.Transform(inMx)
+		if err != nil {
+			return nil, err
+		}
+		inMx = outMx
+	}
+	return inMx, nil
+}
+
+// preprocessingData returns serializable representation of the supplied preprocessing transformers
+func preprocessingData(transformers []dataset.Transformer) ([]preprocessData, error) {
+	var data []preprocessData
+	for _, t := range transformers {
+		params, err := json.Marshal(t)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, preprocessData{Kind: preprocessKind(t), Params: params})
+	}
+	return data, nil
+}
+
+// dataPreprocessing returns preprocessing transformers decoded from the supplied representation
+func dataPreprocessing(data []preprocessData) ([]dataset.Transformer, error) {
+	var transformers []dataset.Transformer
+	for _, pd := range data {
+		newTransformer, ok := preprocessKinds[pd.Kind]
+		if !ok {
+			return nil, fmt.Errorf("Unsupported preprocessing transformer: %s\n", pd.Kind)
+		}
+		t := newTransformer()
+		if err := json.Unmarshal(pd.Params, t); err != nil {
+			return nil, fmt.Errorf("Incorrect %s preprocessing: %v\n", pd.Kind, err)
+		}
+		transformers = append(transformers, t)
+	}
+	return transformers, nil
+}
@@ -0,0 +1,139 @@
+package neural
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestPreprocessing(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewFeedForward(2, []int{5}, 3)
+	assert.NoError(err)
+	assert.Nil(n.Preprocessing())
+	dataMx := mat.NewDense(4, 3, []float64{
+		1.0, 10.0, 0.5,
+		2.0, math.NaN(), 0.1,
+		3.0, 30.0, 0.9,
+		4.0, 40.0, 0.2,
+	})
+	imputer, err := dataset.NewImputer(dataset.ImputeMean, 0.0)
+	assert.NoError(err)
+	assert.NoError(imputer.Fit(dataMx))
+	imputedMx, err := imputer.Transform(dataMx)
+	assert.NoError(err)
+	scaler := dataset.NewStandardScaler()
+	assert.NoError(scaler.Fit(imputedMx))
+	scaledMx, err := scaler.Transform(imputedMx)
+	assert.NoError(err)
+	minMax, err := dataset.NewMinMaxScaler(-1.0, 1.0)
+	assert.NoError(err)
+	assert.NoError(minMax.Fit(scaledMx))
+	minMaxMx, err := minMax.Transform(scaledMx)
+	assert.NoError(err)
+	pca, err := dataset.NewPCA(2)
+	assert.NoError(err)
+	assert.NoError(pca.Fit(minMaxMx))
+	expMx, err := pca.Transform(minMaxMx)
+	assert.NoError(err)
+	// unsupported transformers
+	assert.Error(n.SetPreprocessing(imputer, nil))
+	assert.Error(n.SetPreprocessing(struct{ *dataset.PCA }{pca}))
+	assert.NoError(n.SetPreprocessing(imputer, scaler, minMax, pca))
+	assert.Len(n.Preprocessing(), 4)
+	outMx, err := n.Preprocess(dataMx)
+	assert.NoError(err)
+	assert.True(mat.EqualApprox(expMx, outMx, 1e-12))
+	_, err = n.Preprocess(nil)
+	assert.Error(err)
+	// cloned network keeps the preprocessing
+	assert.Len(n.Clone().Preprocessing(), 4)
+	// preprocessing is saved along with the network
+	dir, err := ioutil.TempDir("", "preprocess")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"net.json", "net.bin"} {
+		path := filepath.Join(dir, name)
+		assert.NoError(SaveNetwork(path, n))
+		loaded, err := LoadNetwork(path)
+		assert.NoError(err)
+		assert.Equal(n.Preprocessing(), loaded.Preprocessing())
+		loadedMx, err := loaded.Preprocess(dataMx)
+		assert.NoError(err)
+		assert.True(mat.EqualApprox(expMx, loadedMx, 1e-12))
+	}
+	// removed preprocessing
+	assert.NoError(n.SetPreprocessing())
+	assert.Nil(n.Preprocessing())
+	outMx, err = n.Preprocess(dataMx)
+	assert.NoError(err)
+	assert.Equal(mat.Row(nil, 0, dataMx), mat.Row(nil, 0, outMx))
+	assert.True(math.IsNaN(outMx.At(1, 1)))
+	// incorrect preprocessing
+	for _, data := range []string{
+		`{"kind":"feedfwd","layers":[{"kind":"input","in":2,"size":2}],"preprocess":[{"kind":"foo","params":{}}]}`,
+		`{"kind":"feedfwd","layers":[{"kind":"input","in":2,"size":2}],"preprocess":[{"kind":"imputer","params":{"strategy":"foo"}}]}`,
+	} {
+		assert.Error(n.UnmarshalJSON([]byte(data)), data)
+	}
+}
+
+func TestPreprocessingClassify(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewFeedForward(3, []int{5}, 2)
+	assert.NoError(err)
+	rawMx := mat.NewDense(4, 3, []float64{
+		100.0, 0.001, 5.0,
+		200.0, 0.003, 6.0,
+		300.0, 0.002, 9.0,
+		400.0, 0.004, 7.0,
+	})
+	scaler := dataset.NewStandardScaler()
+	assert.NoError(scaler.Fit(rawMx))
+	scaledMx, err := scaler.Transform(rawMx)
+	assert.NoError(err)
+	expMx, err := n.Classify(scaledMx)
+	assert.NoError(err)
+	assert.NoError(n.SetPreprocessing(scaler))
+	// raw input is scaled before it is classified
+	classMx, err := n.Classify(rawMx)
+	assert.NoError(err)
+	assert.True(mat.EqualApprox(expMx, classMx, 1e-12))
+	classMx, err = n.ClassifyContext(context.Background(), rawMx)
+	assert.NoError(err)
+	assert.True(mat.EqualApprox(expMx, classMx, 1e-12))
+	// loaded network classifies raw input the same way
+	dir, err := ioutil.TempDir("", "preprocess")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "net.json")
+	assert.NoError(SaveNetwork(path, n))
+	loaded, err := LoadNetwork(path)
+	assert.NoError(err)
+	loadedMx, err := loaded.Classify(rawMx)
+	assert.NoError(err)
+	assert.True(mat.EqualApprox(expMx, loadedMx, 1e-12))
+	inputs := make(chan []float64, 4)
+	for i := 0; i < 4; i++ {
+		inputs <- mat.Row(nil, i, rawMx)
+	}
+	close(inputs)
+	for p := range loaded.PredictBatch(inputs, 2) {
+		assert.NoError(p.Err)
+		for j, prob := range p.Probs {
+			assert.InDelta(expMx.At(p.Index, j), prob, 1e-12)
+		}
+	}
+	// input which doesn't match the scaler fails
+	_, err = loaded.Classify(mat.NewDense(1, 2, []float64{1.0, 2.0}))
+	assert.Error(err)
+}
@@ -0,0 +1,42 @@
+package neural
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// Profile runs the supplied function, such as a training run, with CPU profiling enabled and writes
+// the CPU profile to the file with cpuPath and the allocations profile collected once the function
+// returns to the file with memPath. Empty path disables the respective profile. The profiles can be
+// inspected by go tool pprof. It returns the error of the function or the error of profiling.
+func Profile(cpuPath, memPath string, run func() error) (err error) {
+	if cpuPath != "" {
+		f, err := os.Create(cpuPath)
+		if err != nil {
+			return fmt.Errorf("Unable to create CPU profile: %v\n", err)
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return fmt.Errorf("Unable to start CPU profile: %v\n", err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+	if err = run(); err != nil {
+		return err
+	}
+	if memPath != "" {
+		f, err := os.Create(memPath)
+		if err != nil {
+			return fmt.Errorf("Unable to create allocations profile: %v\n", err)
+		}
+		defer f.Close()
+		// allocations profile is only up to date as of the last garbage collection
+		runtime.GC()
+		if err := pprof.Lookup("allocs").WriteTo(f, 0); err != nil {
+			return fmt.Errorf("Unable to write allocations profile: %v\n", err)
+		}
+	}
+	return nil
+}
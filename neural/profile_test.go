@@ -0,0 +1,39 @@
+package neural
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfile(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "profile")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	cpuPath, memPath := filepath.Join(dir, "cpu.prof"), filepath.Join(dir, "mem.prof")
+	tr, err := NewTrainer(newTrainerConfig())
+	assert.NoError(err)
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	assert.NoError(Profile(cpuPath, memPath, func() error {
+		return tr.Train(n, inMx, labelsVec)
+	}))
+	for _, path := range []string{cpuPath, memPath} {
+		info, err := os.Stat(path)
+		assert.NoError(err)
+		assert.True(info.Size() > 0, "%s", path)
+	}
+	// profiles are optional
+	assert.NoError(Profile("", "", func() error { return nil }))
+	// function error is returned
+	runErr := errors.New("run failed")
+	assert.Equal(runErr, Profile("", "", func() error { return runErr }))
+	// incorrect path throws error
+	assert.Error(Profile(filepath.Join(dir, "foo", "cpu.prof"), "", func() error { return nil }))
+}
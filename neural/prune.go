@@ -0,0 +1,101 @@
+package neural
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// prunable is a weight which can be pruned
+type prunable struct {
+	layer *Layer
+	row   int
+	col   int
+	abs   float64
+}
+
+// Prune zeroes the requested fraction of the smallest-magnitude weights across all network layers
+// and masks them so they stay zeroed during training. Bias weights are never pruned and the weights
+// which are already masked count towards the pruned fraction. Prune returns the resulting network
+// sparsity. It fails with error if the requested fraction is outside of the [0, 1] interval.
+func (n *Network) Prune(fraction float64) (float64, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if fraction < 0.0 || fraction > 1.0 {
+		return -1.0, fmt.Errorf("Prune fraction must be within [0, 1]: %f\n", fraction)
+	}
+	var weights []prunable
+	for _, layer := range n.paramLayers() {
+		w := layer.maskedWeights()
+		rows, cols := w.Dims()
+		// skip bias weights
+		for i := 0; i < rows; i++ {
+			for j := 1; j < cols; j++ {
+				weights = append(weights, prunable{layer, i, j, math.Abs(w.At(i, j))})
+			}
+		}
+	}
+	// stable sort keeps the pruning deterministic for weights of the same magnitude
+	sort.SliceStable(weights, func(i, j int) bool {
+		return weights[i].abs < weights[j].abs
+	})
+	count := int(fraction * float64(len(weights)))
+	masks := make(map[*Layer]*mat.Dense)
+	for _, w := range weights[:count] {
+		mask, ok := masks[w.layer]
+		if !ok {
+			mask = newLayerMask(w.layer)
+			masks[w.layer] = mask
+		}
+		mask.Set(w.row, w.col, 0.0)
+	}
+	for layer, mask := range masks {
+		if err := layer.SetMask(mask); err != nil {
+			return -1.0, err
+		}
+	}
+	return n.sparsity(), nil
+}
+
+// Sparsity returns the fraction of network weights which are masked i.e. disabled.
+// Bias weights are not taken into account.
+func (n *Network) Sparsity() float64 {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.sparsity()
+}
+
+// sparsity returns the fraction of masked network weights
+func (n *Network) sparsity() float64 {
+	total, masked := 0, 0
+	for _, layer := range n.paramLayers() {
+		rows, cols := layer.Weights().Dims()
+		total += rows * (cols - 1)
+		if mask := layer.Mask(); mask != nil {
+			masked += rows*(cols-1) - int(mat.Sum(mask.Slice(0, rows, 1, cols)))
+		}
+	}
+	if total == 0 {
+		return 0.0
+	}
+	return float64(masked) / float64(total)
+}
+
+// newLayerMask returns a copy of the layer weights mask or a mask enabling all weights
+// if the layer has no mask
+func newLayerMask(l *Layer) *mat.Dense {
+	rows, cols := l.Weights().Dims()
+	mask := mat.NewDense(rows, cols, nil)
+	if l.mask != nil {
+		mask.Copy(l.mask)
+		return mask
+	}
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			mask.Set(i, j, 1.0)
+		}
+	}
+	return mask
+}
@@ -0,0 +1,59 @@
+package neural
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrune(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewFeedForward(4, []int{5}, 3)
+	assert.NotNil(n)
+	assert.NoError(err)
+	assert.Equal(n.Sparsity(), 0.0)
+	// incorrect fraction
+	sparsity, err := n.Prune(-0.1)
+	assert.Equal(sparsity, -1.0)
+	assert.Error(err)
+	sparsity, err = n.Prune(1.1)
+	assert.Equal(sparsity, -1.0)
+	assert.Error(err)
+	orig := n.Clone()
+	// (5 x 4) + (3 x 5) non-bias weights
+	sparsity, err = n.Prune(0.4)
+	assert.NoError(err)
+	assert.Equal(sparsity, 14.0/35.0)
+	assert.Equal(n.Sparsity(), sparsity)
+	// pruned weights are zeroed and the smallest weights are pruned first
+	maxPruned, minKept := 0.0, math.Inf(1)
+	for l, layer := range n.Layers()[1:] {
+		mask := layer.Mask()
+		assert.NotNil(mask)
+		rows, cols := layer.Weights().Dims()
+		for i := 0; i < rows; i++ {
+			// bias weights are never pruned
+			assert.Equal(mask.At(i, 0), 1.0)
+			for j := 1; j < cols; j++ {
+				w := math.Abs(orig.Layers()[l+1].Weights().At(i, j))
+				if mask.At(i, j) == 0.0 {
+					assert.Equal(layer.Weights().At(i, j), 0.0)
+					maxPruned = math.Max(maxPruned, w)
+					continue
+				}
+				minKept = math.Min(minKept, w)
+			}
+		}
+	}
+	assert.True(maxPruned <= minKept)
+	// pruning is cumulative
+	sparsity, err = n.Prune(0.6)
+	assert.NoError(err)
+	assert.Equal(sparsity, 21.0/35.0)
+	// smaller fraction keeps already pruned weights pruned
+	sparsity, err = n.Prune(0.2)
+	assert.NoError(err)
+	assert.Equal(sparsity, 21.0/35.0)
+}
@@ -0,0 +1,195 @@
+package neural
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	// qMin is the smallest quantized value
+	qMin = math.MinInt8
+	// qMax is the largest quantized value
+	qMax = math.MaxInt8
+)
+
+// QuantizedLayer is a network layer whose weights are quantized to int8 values.
+// Real weight value w is approximated as scale * (q - zeroPoint) where q is the quantized value.
+type QuantizedLayer struct {
+	// layer is the original layer which provides layer activations
+	layer *Layer
+	// weights are quantized weights stored row by row
+	weights []int8
+	// rows is the number of weights rows
+	rows int
+	// cols is the number of weights columns
+	cols int
+	// scale is the quantization scale
+	scale float64
+	// zero is the quantization zero point
+	zero int32
+}
+
+// QuantizedNetwork is a neural network with int8 quantized weights used for inference.
+// QuantizedNetwork trades a little accuracy for a 8x smaller weights storage.
+type QuantizedNetwork struct {
+	// layers are quantized network layers excluding the INPUT layer
+	layers []*QuantizedLayer
+	// in is the number of network inputs
+	in int
+}
+
+// Quantize quantizes network weights to int8 values and returns the quantized network which can
+// be used for inference. Every layer is quantized separately with its own scale and zero point.
+// Layer inputs are quantized dynamically during forward propagation. Quantize fails with error
+// if the network does not have both INPUT and OUTPUT layers or if it has input branches.
+func (n *Network) Quantize() (*QuantizedNetwork, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if len(n.layers) < 2 || n.layers[0].Kind() != INPUT || n.layers[len(n.layers)-1].Kind() != OUTPUT {
+		return nil, fmt.Errorf("Network must have both %s and %s layers\n", INPUT, OUTPUT)
+	}
+	if len(n.branches) > 0 {
+		return nil, fmt.Errorf("Can't quantize network with input branches\n")
+	}
+	qn := &QuantizedNetwork{in: n.layers[0].InSize()}
+	for _, layer := range n.layers[1:] {
+		weights := layer.maskedWeights()
+		rows, cols := weights.Dims()
+		q, scale, zero := quantize(weights.RawMatrix().Data)
+		qn.layers = append(qn.layers, &QuantizedLayer{
+			layer:   layer.Clone(),
+			weights: q,
+			rows:    rows,
+			cols:    cols,
+			scale:   scale,
+			zero:    zero,
+		})
+	}
+	return qn, nil
+}
+
+// Layers returns quantized network layers excluding the INPUT layer
+func (qn *QuantizedNetwork) Layers() []*QuantizedLayer {
+	return qn.layers
+}
+
+// Scale returns layer weights quantization scale
+func (ql QuantizedLayer) Scale() float64 {
+	return ql.scale
+}
+
+// ZeroPoint returns layer weights quantization zero point
+func (ql QuantizedLayer) ZeroPoint() int32 {
+	return ql.zero
+}
+
+// Weights returns dequantized layer weights
+func (ql QuantizedLayer) Weights() *mat.Dense {
+	weights := mat.NewDense(ql.rows, ql.cols, nil)
+	for i := 0; i < ql.rows; i++ {
+		for j := 0; j < ql.cols; j++ {
+			q := int32(ql.weights[i*ql.cols+j])
+			weights.Set(i, j, ql.scale*float64(q-ql.zero))
+		}
+	}
+	return weights
+}
+
+// fwdOut calculates layer output for the provided input using int8 arithmetic
+func (ql *QuantizedLayer) fwdOut(inputMx mat.Matrix) (*mat.Dense, error) {
+	l := ql.layer
+	inRows, inCols := inputMx.Dims()
+	if inCols != l.in {
+		return nil, fmt.Errorf("Dimension mismatch. Layer: %d, Input: %d\n", l.in, inCols)
+	}
+	// convolution layer multiplies input patches
+	if l.conv != nil {
+		inputMx = l.patches(inputMx)
+	}
+	biasInMx := matrix.AddBias(inputMx)
+	rows, cols := biasInMx.Dims()
+	qIn, inScale, inZero := quantize(biasInMx.RawMatrix().Data)
+	// accumulate the products in int32 and rescale the result
+	actInMx := mat.NewDense(rows, ql.rows, nil)
+	for i := 0; i < rows; i++ {
+		for k := 0; k < ql.rows; k++ {
+			var acc int32
+			for j := 0; j < cols; j++ {
+				acc += (int32(qIn[i*cols+j]) - inZero) * (int32(ql.weights[k*ql.cols+j]) - ql.zero)
+			}
+			actInMx.Set(i, k, inScale*ql.scale*float64(acc))
+		}
+	}
+	// reshape to one row per sample
+	if l.conv != nil {
+		actInMx = mat.NewDense(inRows, l.out, actInMx.RawMatrix().Data)
+	}
+	return l.activate(actInMx), nil
+}
+
+// ForwardProp propagates the supplied input through all quantized network layers and returns
+// the network output. It fails with error if the input is nil or if its dimensions don't match
+// the network input.
+func (qn *QuantizedNetwork) ForwardProp(inMx mat.Matrix) (mat.Matrix, error) {
+	if inMx == nil {
+		return nil, fmt.Errorf("Can't forward propagate input: %v\n", inMx)
+	}
+	if _, cols := inMx.Dims(); cols != qn.in {
+		return nil, fmt.Errorf("Dimension mismatch. Network: %d, Input: %d\n", qn.in, cols)
+	}
+	out := inMx
+	for _, layer := range qn.layers {
+		layerOut, err := layer.fwdOut(out)
+		if err != nil {
+			return nil, err
+		}
+		out = layerOut
+	}
+	return out, nil
+}
+
+// Classify classifies the provided data using the quantized network.
+// It returns a matrix that contains probabilities of the input belonging to a particular class
+// It returns error if the forward propagation fails.
+func (qn *QuantizedNetwork) Classify(inMx mat.Matrix) (mat.Matrix, error) {
+	if inMx == nil {
+		return nil, fmt.Errorf("Can't classify %v\n", inMx)
+	}
+	out, err := qn.ForwardProp(inMx)
+	if err != nil {
+		return nil, err
+	}
+	samples, _ := inMx.Dims()
+	return classProbs(out, samples), nil
+}
+
+// quantize quantizes the supplied values to int8 using asymmetric affine quantization.
+// It returns the quantized values along with the quantization scale and zero point.
+func quantize(data []float64) ([]int8, float64, int32) {
+	// quantized range must always contain zero
+	min, max := 0.0, 0.0
+	for _, v := range data {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	scale := (max - min) / float64(qMax-qMin)
+	if scale == 0.0 {
+		scale = 1.0
+	}
+	zero := int32(math.Round(qMin - min/scale))
+	q := make([]int8, len(data))
+	for i, v := range data {
+		qv := int32(math.Round(v/scale)) + zero
+		if qv < qMin {
+			qv = qMin
+		}
+		if qv > qMax {
+			qv = qMax
+		}
+		q[i] = int8(qv)
+	}
+	return q, scale, zero
+}
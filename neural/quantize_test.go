@@ -0,0 +1,69 @@
+package neural
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestQuantize(t *testing.T) {
+	assert := assert.New(t)
+
+	// network without layers
+	qn, err := new(Network).Quantize()
+	assert.Nil(qn)
+	assert.Error(err)
+	n, err := NewBuilder().Input(4).Hidden(5, ReLU).Maxout(3, 2).Output(5, Softmax).Build()
+	assert.NotNil(n)
+	assert.NoError(err)
+	qn, err = n.Quantize()
+	assert.NotNil(qn)
+	assert.NoError(err)
+	assert.Len(qn.Layers(), 3)
+	// dequantized weights are close to the original weights
+	for i, ql := range qn.Layers() {
+		assert.True(ql.Scale() > 0.0)
+		assert.True(ql.ZeroPoint() >= math.MinInt8 && ql.ZeroPoint() <= math.MaxInt8)
+		assert.True(mat.EqualApprox(ql.Weights(), n.Layers()[i+1].Weights(), ql.Scale()))
+	}
+	// incorrect input
+	out, err := qn.ForwardProp(nil)
+	assert.Nil(out)
+	assert.Error(err)
+	out, err = qn.ForwardProp(inMx.Slice(0, 5, 0, 3))
+	assert.Nil(out)
+	assert.Error(err)
+	// quantized output is close to the original output
+	out, err = qn.ForwardProp(inMx)
+	assert.NoError(err)
+	expOut, err := n.ForwardProp(inMx, len(n.Layers())-1)
+	assert.NoError(err)
+	assert.True(mat.EqualApprox(out, expOut, 0.05))
+	classMx, err := qn.Classify(inMx)
+	assert.NoError(err)
+	rows, cols := classMx.Dims()
+	assert.Equal(rows, 5)
+	assert.Equal(cols, 5)
+}
+
+func TestQuantizeValues(t *testing.T) {
+	assert := assert.New(t)
+
+	data := []float64{-1.0, 0.0, 0.5, 2.0}
+	q, scale, zero := quantize(data)
+	assert.Len(q, len(data))
+	assert.InDelta(scale, 3.0/255.0, 1e-12)
+	// zero is represented exactly
+	assert.Equal(int32(q[1]), zero)
+	assert.Equal(q[0], int8(math.MinInt8))
+	assert.Equal(q[3], int8(math.MaxInt8))
+	for i, v := range data {
+		assert.InDelta(scale*float64(int32(q[i])-zero), v, scale)
+	}
+	// all zeros
+	q, scale, _ = quantize([]float64{0.0, 0.0})
+	assert.Equal(scale, 1.0)
+	assert.Len(q, 2)
+}
@@ -0,0 +1,204 @@
+package neural
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+)
+
+// Scheduler computes learning rate of mini-batch training.
+// Trainer consults its Scheduler before every mini-batch update.
+type Scheduler interface {
+	// Rate returns learning rate derived from the base learning rate for the given epoch
+	// and step. Both epoch and step are counted from 0 and step counts all mini-batch updates.
+	Rate(base float64, epoch, step int) float64
+}
+
+// SchedulerFunc allows to use ordinary functions as custom learning rate schedules
+type SchedulerFunc func(base float64, epoch, step int) float64
+
+// Rate returns learning rate computed by f
+func (f SchedulerFunc) Rate(base float64, epoch, step int) float64 {
+	return f(base, epoch, step)
+}
+
+// StepDecay multiplies learning rate by a constant factor every given number of epochs
+type StepDecay struct {
+	// every is a number of epochs between decays
+	every int
+	// factor is a decay factor
+	factor float64
+}
+
+// NewStepDecay creates new StepDecay schedule and returns it.
+// It fails with error if every is not positive or if the factor is not positive.
+func NewStepDecay(every int, factor float64) (*StepDecay, error) {
+	if every <= 0 {
+		return nil, fmt.Errorf("Incorrect number of epochs between decays: %d\n", every)
+	}
+	if factor <= 0 {
+		return nil, fmt.Errorf("Incorrect decay factor: %f\n", factor)
+	}
+	return &StepDecay{
+		every:  every,
+		factor: factor,
+	}, nil
+}
+
+// Rate returns base*factor^(epoch/every)
+func (s StepDecay) Rate(base float64, epoch, step int) float64 {
+	return base * math.Pow(s.factor, float64(epoch/s.every))
+}
+
+// ExpDecay multiplies learning rate by a constant factor every epoch
+type ExpDecay struct {
+	// gamma is a decay factor
+	gamma float64
+}
+
+// NewExpDecay creates new ExpDecay schedule and returns it.
+// It fails with error if gamma is not positive.
+func NewExpDecay(gamma float64) (*ExpDecay, error) {
+	if gamma <= 0 {
+		return nil, fmt.Errorf("Incorrect decay factor: %f\n", gamma)
+	}
+	return &ExpDecay{
+		gamma: gamma,
+	}, nil
+}
+
+// Rate returns base*gamma^epoch
+func (e ExpDecay) Rate(base float64, epoch, step int) float64 {
+	return base * math.Pow(e.gamma, float64(epoch))
+}
+
+// CosineAnnealing decreases learning rate from the base rate to the minimum rate
+// following a half cosine period over the given number of epochs
+type CosineAnnealing struct {
+	// epochs is a number of annealing epochs
+	epochs int
+	// minRate is a minimum learning rate
+	minRate float64
+}
+
+// NewCosineAnnealing creates new CosineAnnealing schedule and returns it.
+// It fails with error if epochs is not positive or if the minimum rate is negative.
+func NewCosineAnnealing(epochs int, minRate float64) (*CosineAnnealing, error) {
+	if epochs <= 0 {
+		return nil, fmt.Errorf("Incorrect number of annealing epochs: %d\n", epochs)
+	}
+	if minRate < 0 {
+		return nil, fmt.Errorf("Incorrect minimum learning rate: %f\n", minRate)
+	}
+	return &CosineAnnealing{
+		epochs:  epochs,
+		minRate: minRate,
+	}, nil
+}
+
+// Rate returns minRate + (base-minRate)*(1+cos(pi*epoch/epochs))/2.
+// Minimum rate is returned after the annealing epochs.
+func (c CosineAnnealing) Rate(base float64, epoch, step int) float64 {
+	if epoch >= c.epochs {
+		return c.minRate
+	}
+	return c.minRate + (base-c.minRate)*(1+math.Cos(math.Pi*float64(epoch)/float64(c.epochs)))/2
+}
+
+// CyclicCosine decreases learning rate from the base rate to the minimum rate following a half
+// cosine period over every cycle of the given number of epochs and then restarts from the base rate.
+// Networks at the end of the cycles can be collected into a snapshot ensemble.
+type CyclicCosine struct {
+	// period is a number of epochs in every cycle
+	period int
+	// minRate is a minimum learning rate
+	minRate float64
+}
+
+// NewCyclicCosine creates new CyclicCosine schedule and returns it.
+// It fails with error if period is not positive or if the minimum rate is negative.
+func NewCyclicCosine(period int, minRate float64) (*CyclicCosine, error) {
+	if period <= 0 {
+		return nil, fmt.Errorf("Incorrect number of epochs in cycle: %d\n", period)
+	}
+	if minRate < 0 {
+		return nil, fmt.Errorf("Incorrect minimum learning rate: %f\n", minRate)
+	}
+	return &CyclicCosine{
+		period:  period,
+		minRate: minRate,
+	}, nil
+}
+
+// Period returns the number of epochs in every cycle
+func (c CyclicCosine) Period() int {
+	return c.period
+}
+
+// Rate returns minRate + (base-minRate)*(1+cos(pi*(epoch mod period)/period))/2
+func (c CyclicCosine) Rate(base float64, epoch, step int) float64 {
+	return c.minRate + (base-c.minRate)*(1+math.Cos(math.Pi*float64(epoch%c.period)/float64(c.period)))/2
+}
+
+// Warmup increases learning rate linearly over the given number of steps
+// and then hands the learning rate over to another schedule
+type Warmup struct {
+	// steps is a number of warmup steps
+	steps int
+	// next is a schedule used after warmup
+	next Scheduler
+}
+
+// NewWarmup creates new Warmup schedule and returns it. If next is nil the base learning rate
+// is used after warmup. It fails with error if the number of steps is not positive.
+func NewWarmup(steps int, next Scheduler) (*Warmup, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("Incorrect number of warmup steps: %d\n", steps)
+	}
+	return &Warmup{
+		steps: steps,
+		next:  next,
+	}, nil
+}
+
+// Rate returns base*(step+1)/steps during warmup and the rate of the next schedule afterwards
+func (w Warmup) Rate(base float64, epoch, step int) float64 {
+	if step < w.steps {
+		return base * float64(step+1) / float64(w.steps)
+	}
+	if w.next == nil {
+		return base
+	}
+	return w.next.Rate(base, epoch, step)
+}
+
+// newScheduler creates new Scheduler per supplied schedule configuration.
+// Cosine annealing runs over the supplied number of epochs.
+func newScheduler(c *config.ScheduleConfig, epochs int) (Scheduler, error) {
+	var sched Scheduler
+	var err error
+	switch c.Kind {
+	case "step":
+		sched, err = NewStepDecay(c.Every, c.Factor)
+	case "exp":
+		sched, err = NewExpDecay(c.Factor)
+	case "cosine":
+		sched, err = NewCosineAnnealing(epochs, c.MinRate)
+	case "cyclic":
+		sched, err = NewCyclicCosine(c.Every, c.MinRate)
+	case "":
+	default:
+		return nil, fmt.Errorf("Unsupported learning rate schedule: %s\n", c.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.Warmup > 0 {
+		return NewWarmup(c.Warmup, sched)
+	}
+	if sched == nil {
+		return nil, fmt.Errorf("Empty learning rate schedule\n")
+	}
+	return sched, nil
+}
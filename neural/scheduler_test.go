@@ -0,0 +1,120 @@
+package neural
+
+import (
+	"math"
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSchedulers(t *testing.T) {
+	assert := assert.New(t)
+
+	step, err := NewStepDecay(0, 0.5)
+	assert.Nil(step)
+	assert.Error(err)
+	step, err = NewStepDecay(2, 0.0)
+	assert.Nil(step)
+	assert.Error(err)
+	exp, err := NewExpDecay(0.0)
+	assert.Nil(exp)
+	assert.Error(err)
+	cos, err := NewCosineAnnealing(0, 0.0)
+	assert.Nil(cos)
+	assert.Error(err)
+	cos, err = NewCosineAnnealing(10, -1.0)
+	assert.Nil(cos)
+	assert.Error(err)
+	cyclic, err := NewCyclicCosine(0, 0.0)
+	assert.Nil(cyclic)
+	assert.Error(err)
+	cyclic, err = NewCyclicCosine(10, -1.0)
+	assert.Nil(cyclic)
+	assert.Error(err)
+	warmup, err := NewWarmup(0, nil)
+	assert.Nil(warmup)
+	assert.Error(err)
+}
+
+func TestSchedulerRate(t *testing.T) {
+	assert := assert.New(t)
+
+	step, err := NewStepDecay(2, 0.5)
+	assert.NoError(err)
+	exp, err := NewExpDecay(0.5)
+	assert.NoError(err)
+	cos, err := NewCosineAnnealing(4, 0.1)
+	assert.NoError(err)
+	cyclic, err := NewCyclicCosine(4, 0.1)
+	assert.NoError(err)
+	assert.Equal(4, cyclic.Period())
+	warmup, err := NewWarmup(4, step)
+	assert.NoError(err)
+	constWarmup, err := NewWarmup(2, nil)
+	assert.NoError(err)
+	custom := SchedulerFunc(func(base float64, epoch, step int) float64 {
+		return base / float64(step+1)
+	})
+	testCases := []struct {
+		sched Scheduler
+		epoch int
+		step  int
+		rate  float64
+	}{
+		{step, 0, 0, 1.0},
+		{step, 1, 5, 1.0},
+		{step, 2, 10, 0.5},
+		{step, 5, 20, 0.25},
+		{exp, 0, 0, 1.0},
+		{exp, 3, 0, 0.125},
+		{cos, 0, 0, 1.0},
+		{cos, 2, 0, 0.55},
+		{cos, 4, 0, 0.1},
+		{cos, 10, 0, 0.1},
+		{cyclic, 0, 0, 1.0},
+		{cyclic, 2, 0, 0.55},
+		{cyclic, 3, 0, 0.1 + 0.9*(1+math.Cos(0.75*math.Pi))/2},
+		{cyclic, 4, 0, 1.0},
+		{cyclic, 6, 0, 0.55},
+		{warmup, 0, 0, 0.25},
+		{warmup, 0, 2, 0.75},
+		{warmup, 1, 4, 1.0},
+		{warmup, 2, 8, 0.5},
+		{constWarmup, 0, 0, 0.5},
+		{constWarmup, 3, 10, 1.0},
+		{custom, 0, 3, 0.25},
+	}
+	for _, tc := range testCases {
+		assert.InDelta(tc.rate, tc.sched.Rate(1.0, tc.epoch, tc.step), 1e-9)
+	}
+}
+
+func TestNewScheduler(t *testing.T) {
+	assert := assert.New(t)
+
+	testCases := []struct {
+		c      *config.ScheduleConfig
+		expErr bool
+	}{
+		{&config.ScheduleConfig{Kind: "step", Every: 2, Factor: 0.5}, false},
+		{&config.ScheduleConfig{Kind: "exp", Factor: 0.9}, false},
+		{&config.ScheduleConfig{Kind: "cosine", MinRate: 0.01, Warmup: 5}, false},
+		{&config.ScheduleConfig{Kind: "cyclic", Every: 5, MinRate: 0.01}, false},
+		{&config.ScheduleConfig{Warmup: 5}, false},
+		{&config.ScheduleConfig{}, true},
+		{&config.ScheduleConfig{Kind: "foo"}, true},
+		{&config.ScheduleConfig{Kind: "step", Factor: 0.5}, true},
+		{&config.ScheduleConfig{Kind: "cyclic", MinRate: 0.01}, true},
+	}
+	for _, tc := range testCases {
+		sched, err := newScheduler(tc.c, 10)
+		if tc.expErr {
+			assert.Nil(sched)
+			assert.Error(err)
+		} else {
+			assert.NotNil(sched)
+			assert.NoError(err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package neural
+
+import (
+	"fmt"
+
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"gonum.org/v1/gonum/mat"
+)
+
+// SparseLayer is a network layer whose weights are stored in a sparse matrix
+type SparseLayer struct {
+	// layer provides layer activations; it does not hold any weights
+	layer *Layer
+	// weights are sparse layer weights including bias weights
+	weights *matrix.CSR
+}
+
+// SparseNetwork is a neural network with sparse weights used for inference. Layers with mostly
+// zero weights, such as pruned layers, need less memory and compute when their weights are sparse:
+// only the non-zero weights are stored and multiplied in forward propagation.
+type SparseNetwork struct {
+	// layers are sparse network layers excluding the INPUT layer
+	layers []*SparseLayer
+	// in is the number of network inputs
+	in int
+}
+
+// Sparse returns the network with weights stored in sparse matrices which can be used for inference.
+// Masked weights are not stored. Sparse fails with error if the network does not have both INPUT and
+// OUTPUT layers or if it has input branches.
+func (n *Network) Sparse() (*SparseNetwork, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if len(n.layers) < 2 || n.layers[0].Kind() != INPUT || n.layers[len(n.layers)-1].Kind() != OUTPUT {
+		return nil, fmt.Errorf("Network must have both %s and %s layers\n", INPUT, OUTPUT)
+	}
+	if len(n.branches) > 0 {
+		return nil, fmt.Errorf("Can't make network with input branches sparse\n")
+	}
+	sn := &SparseNetwork{in: n.layers[0].InSize()}
+	for _, l := range n.layers[1:] {
+		sn.layers = append(sn.layers, &SparseLayer{
+			// the dense weights are not kept
+			layer: &Layer{
+				id:        l.id,
+				kind:      l.kind,
+				act:       l.act,
+				actGrad:   l.actGrad,
+				meta:      l.meta,
+				pieces:    l.pieces,
+				conv:      l.conv,
+				in:        l.in,
+				out:       l.out,
+				precision: l.precision,
+				fast:      l.fast,
+			},
+			weights: matrix.NewCSR(l.maskedWeights()),
+		})
+	}
+	return sn, nil
+}
+
+// Layers returns sparse network layers excluding the INPUT layer
+func (sn *SparseNetwork) Layers() []*SparseLayer {
+	return sn.layers
+}
+
+// Weights returns sparse layer weights
+func (sl SparseLayer) Weights() *matrix.CSR {
+	return sl.weights
+}
+
+// Density returns the fraction of non-zero layer weights
+func (sl SparseLayer) Density() float64 {
+	return sl.weights.Density()
+}
+
+// fwdOut calculates layer output for the provided input using sparse-dense multiplication
+func (sl *SparseLayer) fwdOut(inputMx mat.Matrix) (*mat.Dense, error) {
+	l := sl.layer
+	inRows, inCols := inputMx.Dims()
+	if inCols != l.in {
+		return nil, fmt.Errorf("Dimension mismatch. Layer: %d, Input: %d\n", l.in, inCols)
+	}
+	// convolution layer multiplies input patches
+	if l.conv != nil {
+		inputMx = l.patches(inputMx)
+	}
+	actInMx := sl.weights.MulT(matrix.AddBias(inputMx))
+	// reshape to one row per sample
+	if l.conv != nil {
+		actInMx = mat.NewDense(inRows, l.out, actInMx.RawMatrix().Data)
+	}
+	return l.activate(actInMx), nil
+}
+
+// ForwardProp propagates the supplied input through all sparse network layers and returns
+// the network output. It fails with error if the input is nil or if its dimensions don't match
+// the network input.
+func (sn *SparseNetwork) ForwardProp(inMx mat.Matrix) (mat.Matrix, error) {
+	if inMx == nil {
+		return nil, fmt.Errorf("Can't forward propagate input: %v\n", inMx)
+	}
+	if _, cols := inMx.Dims(); cols != sn.in {
+		return nil, fmt.Errorf("Dimension mismatch. Network: %d, Input: %d\n", sn.in, cols)
+	}
+	out := inMx
+	for _, layer := range sn.layers {
+		layerOut, err := layer.fwdOut(out)
+		if err != nil {
+			return nil, err
+		}
+		out = layerOut
+	}
+	return out, nil
+}
+
+// Classify classifies the provided data using the sparse network.
+// It returns a matrix that contains probabilities of the input belonging to a particular class
+// It returns error if the forward propagation fails.
+func (sn *SparseNetwork) Classify(inMx mat.Matrix) (mat.Matrix, error) {
+	if inMx == nil {
+		return nil, fmt.Errorf("Can't classify %v\n", inMx)
+	}
+	out, err := sn.ForwardProp(inMx)
+	if err != nil {
+		return nil, err
+	}
+	samples, _ := inMx.Dims()
+	return classProbs(out, samples), nil
+}
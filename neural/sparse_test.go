@@ -0,0 +1,61 @@
+package neural
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestSparse(t *testing.T) {
+	assert := assert.New(t)
+
+	// network without layers
+	sn, err := new(Network).Sparse()
+	assert.Nil(sn)
+	assert.Error(err)
+	n, err := NewBuilder().Input(4).Hidden(5, ReLU).Maxout(3, 2).Output(5, Softmax).Build()
+	assert.NotNil(n)
+	assert.NoError(err)
+	_, err = n.Prune(0.5)
+	assert.NoError(err)
+	sn, err = n.Sparse()
+	assert.NotNil(sn)
+	assert.NoError(err)
+	assert.Len(sn.Layers(), 3)
+	// sparse weights equal the masked weights and only the unmasked weights are stored
+	for i, sl := range sn.Layers() {
+		layer := n.Layers()[i+1]
+		assert.True(mat.Equal(sl.Weights(), layer.maskedWeights()))
+		assert.True(sl.Density() < 1.0)
+		assert.Nil(sl.layer.Weights())
+	}
+	// incorrect input
+	out, err := sn.ForwardProp(nil)
+	assert.Nil(out)
+	assert.Error(err)
+	out, err = sn.ForwardProp(inMx.Slice(0, 5, 0, 3))
+	assert.Nil(out)
+	assert.Error(err)
+	// sparse output equals the original output
+	out, err = sn.ForwardProp(inMx)
+	assert.NoError(err)
+	expOut, err := n.ForwardProp(inMx, len(n.Layers())-1)
+	assert.NoError(err)
+	assert.True(mat.EqualApprox(out, expOut, 1e-12))
+	classMx, err := sn.Classify(inMx)
+	assert.NoError(err)
+	expClass, err := n.Classify(inMx)
+	assert.NoError(err)
+	assert.True(mat.EqualApprox(classMx, expClass, 1e-10))
+	// convolution layers are supported too
+	n, err = NewBuilder().Input(4).Conv1D(2, 2, 1, 1, ReLU).Output(3, Softmax).Build()
+	assert.NoError(err)
+	sn, err = n.Sparse()
+	assert.NoError(err)
+	out, err = sn.ForwardProp(inMx)
+	assert.NoError(err)
+	expOut, err = n.ForwardProp(inMx, len(n.Layers())-1)
+	assert.NoError(err)
+	assert.True(mat.EqualApprox(out, expOut, 1e-12))
+}
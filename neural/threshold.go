@@ -0,0 +1,76 @@
+package neural
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Thresholds returns a copy of the network decision thresholds ordered by class labels.
+// It returns nil if the network has no thresholds.
+func (n *Network) Thresholds() []float64 {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.thresholds == nil {
+		return nil
+	}
+	return append([]float64{}, n.thresholds...)
+}
+
+// SetThresholds sets per-class decision thresholds honored by Classify, ordered by class labels.
+// Thresholds are class probabilities in percents, the same units Classify returns, and can be tuned
+// on validation data using eval.TuneThresholds. nil thresholds remove the thresholds.
+// It fails with error if the number of thresholds does not match the network output size or if
+// any threshold is not between 0 and 100.
+func (n *Network) SetThresholds(thresholds []float64) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if thresholds == nil {
+		n.thresholds = nil
+		return nil
+	}
+	if err := checkThresholds(thresholds, n.outSize()); err != nil {
+		return err
+	}
+	n.thresholds = append([]float64{}, thresholds...)
+	return nil
+}
+
+// checkThresholds checks that there is a threshold between 0 and 100 for each of the classes
+func checkThresholds(thresholds []float64, classes int) error {
+	if len(thresholds) != classes {
+		return fmt.Errorf("Thresholds mismatch. Classes: %d, Thresholds: %d\n", classes, len(thresholds))
+	}
+	for _, t := range thresholds {
+		if t < 0 || t > 100 {
+			return fmt.Errorf("Incorrect threshold: %f\n", t)
+		}
+	}
+	return nil
+}
+
+// applyThresholds sets the probabilities of classes below their thresholds to zero, unless all
+// the classes of the sample are below their thresholds, in which case the sample is left unchanged
+func applyThresholds(classMx *mat.Dense, thresholds []float64) {
+	if thresholds == nil {
+		return
+	}
+	rows, cols := classMx.Dims()
+	for i := 0; i < rows; i++ {
+		passed := false
+		for j := 0; j < cols; j++ {
+			if classMx.At(i, j) >= thresholds[j] {
+				passed = true
+				break
+			}
+		}
+		if !passed {
+			continue
+		}
+		for j := 0; j < cols; j++ {
+			if classMx.At(i, j) < thresholds[j] {
+				classMx.Set(i, j, 0)
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package neural
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestThresholds(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewFeedForward(4, []int{5}, 3)
+	assert.NoError(err)
+	assert.Nil(n.Thresholds())
+	// incorrect thresholds
+	assert.Error(n.SetThresholds([]float64{10, 20}))
+	assert.Error(n.SetThresholds([]float64{10, 20, 101}))
+	assert.Error(n.SetThresholds([]float64{10, -1, 20}))
+	probs, err := n.Classify(inMx)
+	assert.NoError(err)
+	// thresholds above the probabilities of the first class reject it
+	rows, _ := probs.Dims()
+	max := 0.0
+	for i := 0; i < rows; i++ {
+		if p := probs.At(i, 0); p > max {
+			max = p
+		}
+	}
+	thresholds := []float64{max + 1e-9, 0, 0}
+	assert.NoError(n.SetThresholds(thresholds))
+	assert.Equal(thresholds, n.Thresholds())
+	out, err := n.Classify(inMx)
+	assert.NoError(err)
+	outCtx, err := n.ClassifyContext(context.Background(), inMx)
+	assert.NoError(err)
+	for i := 0; i < rows; i++ {
+		assert.Equal(0.0, out.At(i, 0))
+		assert.Equal(0.0, outCtx.At(i, 0))
+		for j := 1; j < 3; j++ {
+			assert.Equal(probs.At(i, j), out.At(i, j))
+		}
+	}
+	// samples with all the classes below their thresholds are left unchanged
+	assert.NoError(n.SetThresholds([]float64{100, 100, 100}))
+	out, err = n.Classify(inMx)
+	assert.NoError(err)
+	assert.True(mat.Equal(probs, out))
+	// thresholds are cloned and saved along with the network
+	assert.NoError(n.SetThresholds(thresholds))
+	clone := n.Clone()
+	assert.NoError(clone.SetThresholds(nil))
+	assert.Nil(clone.Thresholds())
+	assert.Equal(thresholds, n.Thresholds())
+	for _, f := range []Format{JSON, Binary} {
+		var buf bytes.Buffer
+		assert.NoError(WriteNetwork(&buf, n, f))
+		loaded, err := ReadNetwork(&buf)
+		assert.NoError(err)
+		assert.Equal(thresholds, loaded.Thresholds())
+	}
+}
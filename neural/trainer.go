@@ -0,0 +1,1093 @@
+package neural
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"github.com/milosgajdos83/go-neural/pkg/eval"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"gonum.org/v1/gonum/mat"
+)
+
+// valMetrics maps validation metrics, which can be evaluated after every epoch and monitored by early
+// stopping, to true if the metric is maximized
+var valMetrics = map[string]bool{
+	"cost":     false,
+	"accuracy": true,
+	"f1":       true,
+	"auc":      true,
+	"logloss":  false,
+}
+
+// defaultMetrics are validation metrics evaluated when no metrics are configured
+var defaultMetrics = []string{"cost", "accuracy"}
+
+// Trainer trains neural networks using mini-batch gradient descent.
+// Trainer splits the training data into training and validation data sets and runs the requested
+// number of epochs. Each epoch passes all the training samples through the network in mini-batches,
+// optionally shuffled, and updates the network weights after every mini-batch using its Optimizer.
+// Gradients of several mini-batches can be accumulated before the weights are updated.
+type Trainer struct {
+	// c is training configuration
+	c *config.TrainConfig
+	// optim updates network weights
+	optim Optimizer
+	// loss is training loss
+	loss Loss
+	// sched computes learning rate of every mini-batch update
+	sched Scheduler
+	// rng is random source used for shuffling
+	rng *rand.Rand
+	// callbacks receive training events
+	callbacks []Callback
+	// custom are custom metrics aggregated across mini-batches
+	custom map[string]eval.Metric
+	// curriculum selects training samples of every epoch
+	curriculum Curriculum
+	// augment randomly transforms training mini-batches
+	augment dataset.Augmenter
+	// snapshots are network snapshots of the last training
+	snapshots []*Network
+	// history is history of the last training
+	history *History
+	// resume is checkpoint from which the next training resumes
+	resume *Checkpoint
+	// updates counts incremental updates done by PartialFit
+	updates int
+	// progress is the state of the running epoch at its last weights update
+	progress epochProgress
+}
+
+// epochProgress is the state of a running epoch at its last weights update,
+// which is checkpointed when the training is cancelled in the middle of the epoch
+type epochProgress struct {
+	// epoch is the running epoch
+	epoch int
+	// batch is the number of mini-batches of the epoch whose gradients updated the weights
+	batch int
+	// step is the number of finished mini-batch updates
+	step int
+	// noiseDraws is the number of random numbers drawn by training noise
+	noiseDraws int64
+	// augmentState is random state of the augmenter
+	augmentState []int64
+}
+
+// NewTrainer creates new Trainer with the supplied training configuration and returns it.
+// Trainer with non-zero configuration Seed shuffles the data and generates noise deterministically,
+// so training a network created with the same seed gives the same results in every run.
+// It fails with error if the training configuration is invalid.
+func NewTrainer(c *config.TrainConfig) (*Trainer, error) {
+	if err := ValidateTrainerConfig(c); err != nil {
+		return nil, err
+	}
+	optim, err := newOptimizer(c.Optimize)
+	if err != nil {
+		return nil, err
+	}
+	var sched Scheduler
+	if c.Schedule != nil {
+		if sched, err = newScheduler(c.Schedule, c.Epochs); err != nil {
+			return nil, err
+		}
+	}
+	seed := c.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Trainer{
+		c:     c,
+		optim: optim,
+		loss:  trainCost[c.Cost],
+		sched: sched,
+		rng:   rand.New(rand.NewSource(seed)),
+	}, nil
+}
+
+// Optimizer returns Trainer optimizer
+func (t *Trainer) Optimizer() Optimizer {
+	return t.optim
+}
+
+// Loss returns Trainer loss
+func (t *Trainer) Loss() Loss {
+	return t.loss
+}
+
+// SetLoss replaces Trainer loss, which is picked by the cost in training configuration,
+// with the supplied loss. It fails with error if the supplied loss is nil.
+func (t *Trainer) SetLoss(l Loss) error {
+	if l == nil {
+		return fmt.Errorf("Incorrect loss supplied: %v\n", l)
+	}
+	t.loss = l
+	return nil
+}
+
+// Scheduler returns Trainer learning rate schedule
+func (t *Trainer) Scheduler() Scheduler {
+	return t.sched
+}
+
+// SetScheduler sets Trainer learning rate schedule. The schedule derives the learning rate
+// from the learning rate in training configuration. nil schedule keeps the learning rate constant.
+// Learning rate schedule requires optimizer which implements RateOptimizer.
+func (t *Trainer) SetScheduler(s Scheduler) {
+	t.sched = s
+}
+
+// SetOptimizer replaces Trainer optimizer with the supplied optimizer.
+// It fails with error if the supplied optimizer is nil.
+func (t *Trainer) SetOptimizer(o Optimizer) error {
+	if o == nil {
+		return fmt.Errorf("Incorrect optimizer supplied: %v\n", o)
+	}
+	t.optim = o
+	return nil
+}
+
+// AddCallback adds the supplied callback to Trainer. Callbacks are called in the order they were added.
+// It fails with error if the supplied callback is nil.
+func (t *Trainer) AddCallback(cb Callback) error {
+	if cb == nil {
+		return fmt.Errorf("Incorrect callback supplied: %v\n", cb)
+	}
+	t.callbacks = append(t.callbacks, cb)
+	return nil
+}
+
+// AddMetric adds the supplied custom metric to Trainer. The metric is updated with the network output
+// of every training mini-batch after its weights update and reported as epoch metric of the supplied
+// name. With validation split the metric is computed on the validation samples, too, which are passed
+// to the metric in mini-batches of the configured size, and reported with "val_" prefix.
+// It fails with error if the metric is nil or if the name is empty or already used by another metric.
+func (t *Trainer) AddMetric(name string, m eval.Metric) error {
+	if m == nil {
+		return fmt.Errorf("Incorrect metric supplied: %v\n", m)
+	}
+	if _, ok := valMetrics[name]; ok || name == "" || strings.HasPrefix(name, "val_") || t.custom[name] != nil {
+		return fmt.Errorf("Incorrect metric name: %s\n", name)
+	}
+	if t.custom == nil {
+		t.custom = make(map[string]eval.Metric)
+	}
+	t.custom[name] = m
+	return nil
+}
+
+// Curriculum returns Trainer curriculum
+func (t *Trainer) Curriculum() Curriculum {
+	return t.curriculum
+}
+
+// SetCurriculum sets Trainer curriculum, which selects the training samples of every epoch.
+// Curriculum indices refer to the training samples left after holding out the validation samples.
+// If the curriculum implements Callback it receives the training events, too. Training resumed from
+// a checkpoint does not replay the curriculum. nil curriculum trains on all the samples in every epoch.
+func (t *Trainer) SetCurriculum(c Curriculum) {
+	t.curriculum = c
+}
+
+// Augmenter returns Trainer augmenter
+func (t *Trainer) Augmenter() dataset.Augmenter {
+	return t.augment
+}
+
+// SetAugmenter sets Trainer augmenter, which randomly transforms every training mini-batch before
+// its gradients are calculated. Validation samples and evaluated metrics are not augmented.
+// nil augmenter trains on the original samples.
+func (t *Trainer) SetAugmenter(a dataset.Augmenter) {
+	t.augment = a
+}
+
+// History returns the history of the last training, which contains metrics of every finished epoch.
+// History of resumed training starts with the first epoch after the checkpoint.
+func (t *Trainer) History() *History {
+	return t.history
+}
+
+// Snapshots returns snapshot ensemble of the network snapshots taken during the last training.
+// Snapshots are taken every configured number of epochs, which usually matches the cycle length
+// of cyclic learning rate schedule. Snapshots of resumed training start after the checkpoint.
+// It fails with error if no snapshots were taken.
+func (t *Trainer) Snapshots(combine Combine) (*Ensemble, error) {
+	if len(t.snapshots) == 0 {
+		return nil, fmt.Errorf("No network snapshots taken\n")
+	}
+	return NewEnsemble(combine, t.snapshots...)
+}
+
+// Resume makes the next training resume from the supplied checkpoint instead of starting from scratch.
+// The training restores network weights, optimizer state, the order of shuffled mini-batches and
+// early stopping state from the checkpoint and continues with the epoch following the checkpoint.
+// It fails with error if the supplied checkpoint is nil.
+func (t *Trainer) Resume(cp *Checkpoint) error {
+	if cp == nil {
+		return fmt.Errorf("Incorrect checkpoint supplied: %v\n", cp)
+	}
+	t.resume = cp
+	return nil
+}
+
+// ValidateTrainerConfig validates mini-batch training configuration.
+// It returns error if any of the configuration parameters is invalid.
+func ValidateTrainerConfig(c *config.TrainConfig) error {
+	// config can't be nil
+	if c == nil {
+		return fmt.Errorf("Incorrect configuration supplied: %v\n", c)
+	}
+	// check if the requested training cost is supported
+	if _, ok := trainCost[c.Cost]; !ok {
+		return fmt.Errorf("Unsupported training cost: %s\n", c.Cost)
+	}
+	// Incorrect lambda supplied
+	if c.Lambda < 0 {
+		return fmt.Errorf("Incorrect regularizer supplied: %f\n", c.Lambda)
+	}
+	if c.L1 < 0 {
+		return fmt.Errorf("Incorrect L1 regularizer supplied: %f\n", c.L1)
+	}
+	if err := validateLossConfig(c); err != nil {
+		return err
+	}
+	// optimization method must be supported by Trainer
+	if c.Optimize == nil {
+		return fmt.Errorf("Unsupported optimization method: %v\n", c.Optimize)
+	}
+	if _, ok := trainerOptim[c.Optimize.Method]; !ok {
+		return fmt.Errorf("Unsupported optimization method: %s\n", c.Optimize.Method)
+	}
+	if c.Optimize.LearnRate <= 0 {
+		return fmt.Errorf("Incorrect learning rate: %f\n", c.Optimize.LearnRate)
+	}
+	if c.Optimize.Momentum < 0 || c.Optimize.Momentum >= 1 {
+		return fmt.Errorf("Incorrect momentum: %f\n", c.Optimize.Momentum)
+	}
+	if c.Optimize.ClipNorm < 0 || c.Optimize.ClipValue < 0 {
+		return fmt.Errorf("Incorrect gradient clipping. Norm: %f, Value: %f\n",
+			c.Optimize.ClipNorm, c.Optimize.ClipValue)
+	}
+	if c.Epochs <= 0 {
+		return fmt.Errorf("Incorrect number of epochs: %d\n", c.Epochs)
+	}
+	if c.BatchSize < 0 {
+		return fmt.Errorf("Incorrect batch size: %d\n", c.BatchSize)
+	}
+	if c.SnapshotEvery < 0 {
+		return fmt.Errorf("Incorrect number of epochs between snapshots: %d\n", c.SnapshotEvery)
+	}
+	if c.Accumulate < 0 {
+		return fmt.Errorf("Incorrect number of accumulated mini-batches: %d\n", c.Accumulate)
+	}
+	if c.ValidSplit < 0 || c.ValidSplit >= 1 {
+		return fmt.Errorf("Incorrect validation split: %f\n", c.ValidSplit)
+	}
+	for _, metric := range c.Metrics {
+		if _, ok := valMetrics[metric]; !ok {
+			return fmt.Errorf("Unsupported validation metric: %s\n", metric)
+		}
+	}
+	if c.Metrics != nil && c.ValidSplit == 0 {
+		return fmt.Errorf("Validation metrics require validation split\n")
+	}
+	if c.EarlyStop != nil {
+		if _, ok := valMetrics[c.EarlyStop.Monitor]; !ok {
+			return fmt.Errorf("Unsupported early stopping metric: %s\n", c.EarlyStop.Monitor)
+		}
+		if !contains(trainerMetrics(c), c.EarlyStop.Monitor) {
+			return fmt.Errorf("Early stopping metric is not evaluated: %s\n", c.EarlyStop.Monitor)
+		}
+		if c.EarlyStop.Patience <= 0 || c.EarlyStop.MinDelta < 0 {
+			return fmt.Errorf("Incorrect early stopping. Patience: %d, Delta: %f\n",
+				c.EarlyStop.Patience, c.EarlyStop.MinDelta)
+		}
+		// early stopping monitors validation metrics
+		if c.ValidSplit == 0 {
+			return fmt.Errorf("Early stopping requires validation split\n")
+		}
+	}
+	if c.SWA != nil {
+		if c.SWA.Start <= 0 || c.SWA.Start > c.Epochs || c.SWA.Every <= 0 {
+			return fmt.Errorf("Incorrect weight averaging. Start: %d, Every: %d\n", c.SWA.Start, c.SWA.Every)
+		}
+		// averaged weights would replace the best weights
+		if c.EarlyStop != nil {
+			return fmt.Errorf("Weight averaging can not be combined with early stopping\n")
+		}
+	}
+	if c.Checkpoint != nil && (c.Checkpoint.Path == "" || c.Checkpoint.Every <= 0) {
+		return fmt.Errorf("Incorrect checkpointing. Path: %s, Every: %d\n",
+			c.Checkpoint.Path, c.Checkpoint.Every)
+	}
+	if c.Rollback && !c.Guard {
+		return fmt.Errorf("Rollback requires non-finite values guard\n")
+	}
+	if c.Sampling != nil {
+		total := 0.0
+		for _, w := range c.Sampling.Weights {
+			if w < 0 {
+				return fmt.Errorf("Incorrect sampling weight: %f\n", w)
+			}
+			total += w
+		}
+		if c.Sampling.Weights != nil && total == 0 {
+			return fmt.Errorf("Incorrect sampling weights: %v\n", c.Sampling.Weights)
+		}
+	}
+	return nil
+}
+
+// Train trains the supplied network on the supplied data.
+// It returns error if the data are invalid, if the network has heads or if the training fails.
+func (t *Trainer) Train(n *Network, inMx *mat.Dense, labelsVec *mat.VecDense) error {
+	return t.TrainContext(context.Background(), n, inMx, labelsVec)
+}
+
+// TrainContext trains the supplied network on the supplied data. The last ValidSplit fraction
+// of the data samples is held out for validation and is not used for training. Training stops
+// when the supplied context is cancelled or its deadline expires. The network is then left with
+// the weights after the last mini-batch update and the context error is returned.
+// With early stopping the training stops when the monitored validation metric has not improved
+// for the configured number of epochs and the network is left with the best weights found.
+// Trainer callbacks are notified about the training progress and can stop the training early.
+// With checkpointing the training state is saved to the checkpoint file every configured number
+// of epochs and when the training is cancelled, in which case the checkpoint holds the state after
+// the last weights update of the cancelled epoch. The training can be resumed from the saved checkpoint
+// using Resume.
+// With stochastic weight averaging the network is left with the average of the weights
+// at the end of the averaged epochs. With class-weighted sampling every epoch draws as many training
+// samples as there are with replacement, so the classes appear in mini-batches according to their
+// sampling weights.
+// It returns error if the data are invalid, if the network has heads or if the training fails.
+func (t *Trainer) TrainContext(ctx context.Context, n *Network, inMx *mat.Dense, labelsVec *mat.VecDense) error {
+	if n == nil {
+		return fmt.Errorf("Incorrect network supplied: %v\n", n)
+	}
+	if inMx == nil || labelsVec == nil {
+		return fmt.Errorf("Incorrect data supplied. In: %v, Labels: %v\n", inMx, labelsVec)
+	}
+	samples, cols := inMx.Dims()
+	if labelsVec.Len() != samples {
+		return fmt.Errorf("Labels mismatch. Samples: %d, Labels: %d\n", samples, labelsVec.Len())
+	}
+	// hold out validation samples
+	valSamples := int(t.c.ValidSplit * float64(samples))
+	trainSamples := samples - valSamples
+	if trainSamples == 0 {
+		return fmt.Errorf("Insufficient number of training samples: %d\n", samples)
+	}
+	stop := t.c.EarlyStop
+	if stop != nil && valSamples == 0 {
+		return fmt.Errorf("Insufficient number of validation samples: %d\n", samples)
+	}
+	trainInMx := inMx.Slice(0, trainSamples, 0, cols).(*mat.Dense)
+	trainLabels := labelsVec.SliceVec(0, trainSamples).(*mat.VecDense)
+	// learning rate schedule requires optimizer with adjustable learning rate
+	if _, ok := t.optim.(RateOptimizer); t.sched != nil && !ok {
+		return fmt.Errorf("Optimizer does not support learning rate schedules: %T\n", t.optim)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	// class weights are calculated from the training samples
+	loss, err := configLoss(t.loss, t.c, trainLabels, n.outSize())
+	if err != nil {
+		return err
+	}
+	layers, err := trainerLayers(n)
+	if err != nil {
+		return err
+	}
+	seed, start, step := t.rng.Int63(), 0, 0
+	// early stopping state
+	var best float64
+	var bestWeights []float64
+	wait := 0
+	var metrics Metrics
+	// stochastic weight averaging state
+	var swaWeights []float64
+	swaCount := 0
+	var noiseDraws int64
+	skip := 0
+	cp := t.resume
+	t.resume = nil
+	if cp != nil {
+		if err := t.restore(layers, cp); err != nil {
+			return err
+		}
+		if r, ok := t.augment.(dataset.RandomState); ok && cp.AugmentState != nil {
+			if err := r.SetRandState(cp.AugmentState); err != nil {
+				return err
+			}
+		}
+		seed, start, step = cp.Seed, cp.Epoch, cp.Step
+		best, bestWeights, wait, metrics = cp.Best, cp.BestWeights, cp.Wait, cp.Metrics
+		swaWeights, swaCount, noiseDraws, skip = cp.SWAWeights, cp.SWACount, cp.NoiseDraws, cp.Batch
+	}
+	// good is the last good training state which the guard rolls back to
+	var good *Checkpoint
+	if t.c.Rollback {
+		good = cp
+		if good == nil {
+			good = &Checkpoint{Weights: netWeights(layers), Optim: t.optimState(layers)}
+		}
+	}
+	// noise of deterministic training is derived from the shuffling seed
+	if t.c.Seed != 0 {
+		matrix.SeedDraws(seed, noiseDraws)
+	}
+	batches, err := dataset.NewBatches(trainInMx, trainLabels, t.c.BatchSize, t.c.Shuffle, t.c.DropLast, seed)
+	if err != nil {
+		return err
+	}
+	// class-weighted sampling draws the training samples of every epoch
+	var sampler *dataset.WeightedSampler
+	if t.c.Sampling != nil {
+		if t.curriculum != nil {
+			return fmt.Errorf("Curriculum can not be combined with weighted sampling\n")
+		}
+		if sampler, err = dataset.NewWeightedSampler(trainLabels, t.c.Sampling.Weights, seed); err != nil {
+			return err
+		}
+	}
+	// replay shuffling and sampling of the finished epochs
+	for i := 0; i < start; i++ {
+		if sampler != nil {
+			sampler.Sample(trainSamples)
+		}
+		batches.Reset()
+	}
+	t.history = NewHistory()
+	t.snapshots = nil
+	err = t.notify(func(cb Callback) error { return cb.OnTrainBegin() })
+	for epoch := start + 1; err == nil && epoch <= t.c.Epochs; epoch++ {
+		if t.curriculum != nil {
+			if err = batches.Select(t.curriculum.Samples(epoch)); err != nil {
+				break
+			}
+		}
+		if sampler != nil {
+			if err = batches.Select(sampler.Sample(trainSamples)); err != nil {
+				break
+			}
+		}
+		batches.Reset()
+		// skip the mini-batches of the resumed epoch which have already been trained
+		for i := 0; i < skip; i++ {
+			if _, _, err = batches.NextBatch(); err != nil {
+				err = fmt.Errorf("Incorrect checkpoint mini-batch: %d\n", skip)
+				break
+			}
+		}
+		if err != nil {
+			break
+		}
+		if step, _, err = t.runEpoch(ctx, n, layers, loss, batches, epoch, step, skip, false); err != nil {
+			break
+		}
+		skip = 0
+		// evaluate the epoch without training noise
+		if metrics, err = t.evaluate(n, loss, inMx, labelsVec, trainSamples); err != nil {
+			break
+		}
+		if t.c.Guard {
+			if err = checkCost(n, metrics["cost"], trainInMx, epoch, 0); err != nil {
+				break
+			}
+			if err = checkCost(n, metrics["val_cost"], inMx, epoch, 0); err != nil {
+				break
+			}
+		}
+		progress := fmt.Sprintf("Epoch %d: cost %f", epoch, metrics["cost"])
+		if valSamples > 0 {
+			for _, metric := range trainerMetrics(t.c) {
+				progress += fmt.Sprintf(", validation %s %f", metric, metrics["val_"+metric])
+			}
+		}
+		t.logf("%s\n", progress)
+		t.history.OnEpochEnd(epoch, metrics)
+		if err = t.notify(func(cb Callback) error { return cb.OnEpochEnd(epoch, metrics) }); err != nil {
+			break
+		}
+		if stop != nil {
+			metric, maximize := metrics["val_"+stop.Monitor], valMetrics[stop.Monitor]
+			if bestWeights == nil || improved(metric, best, stop.MinDelta, maximize) {
+				best, bestWeights, wait = metric, netWeights(layers), 0
+			} else if wait++; wait >= stop.Patience {
+				t.logf("Early stopping at epoch %d: best validation %s %f\n", epoch, stop.Monitor, best)
+				break
+			}
+		}
+		if t.c.SnapshotEvery > 0 && epoch%t.c.SnapshotEvery == 0 {
+			t.snapshots = append(t.snapshots, n.clone())
+		}
+		if swa := t.c.SWA; swa != nil && epoch >= swa.Start && (epoch-swa.Start)%swa.Every == 0 {
+			swaWeights = averageWeights(swaWeights, netWeights(layers), swaCount)
+			swaCount++
+		}
+		if c := t.c.Checkpoint; c != nil && epoch%c.Every == 0 {
+			cp = &Checkpoint{
+				Epoch:        epoch,
+				Step:         step,
+				Seed:         seed,
+				Weights:      netWeights(layers),
+				Optim:        t.optimState(layers),
+				Metrics:      metrics,
+				Best:         best,
+				BestWeights:  bestWeights,
+				Wait:         wait,
+				SWAWeights:   swaWeights,
+				SWACount:     swaCount,
+				NoiseDraws:   matrix.Draws(),
+				AugmentState: t.augmentState(),
+			}
+			if err = SaveCheckpoint(c.Path, cp); err == nil && good != nil {
+				good = cp
+			}
+		}
+	}
+	// cancelled training is checkpointed at the last weights update, so it can be resumed in the middle of the epoch
+	if c := t.c.Checkpoint; c != nil && err != nil && err == ctx.Err() {
+		p := t.progress
+		cp = &Checkpoint{
+			Epoch:        p.epoch - 1,
+			Batch:        p.batch,
+			Step:         p.step,
+			Seed:         seed,
+			Weights:      netWeights(layers),
+			Optim:        t.optimState(layers),
+			Metrics:      metrics,
+			Best:         best,
+			BestWeights:  bestWeights,
+			Wait:         wait,
+			SWAWeights:   swaWeights,
+			SWACount:     swaCount,
+			NoiseDraws:   p.noiseDraws,
+			AugmentState: p.augmentState,
+		}
+		if serr := SaveCheckpoint(c.Path, cp); serr != nil {
+			return serr
+		}
+	}
+	if _, ok := err.(*NonFiniteError); ok && good != nil {
+		if rerr := t.restore(layers, good); rerr != nil {
+			return rerr
+		}
+		t.logf("Rolled back to epoch %d: %v\n", good.Epoch, strings.TrimSpace(err.Error()))
+	}
+	if err != nil && err != ErrStopTraining {
+		return err
+	}
+	// restore the best weights found by early stopping
+	if bestWeights != nil {
+		if err := setNetWeights(layers, bestWeights); err != nil {
+			return err
+		}
+	}
+	// swap in the averaged weights
+	if swaWeights != nil {
+		if err := setNetWeights(layers, swaWeights); err != nil {
+			return err
+		}
+	}
+	if err := t.notify(func(cb Callback) error { return cb.OnTrainEnd(metrics) }); err != ErrStopTraining {
+		return err
+	}
+	return nil
+}
+
+// TrainStream trains the supplied network on the data streamed from the streams opened by the supplied
+// function, which is called at the beginning of every epoch, so the data don't have to fit into memory.
+// The streams determine the mini-batches, so batch size, shuffling and dropping of the last mini-batch
+// are not applied. Training cost of every epoch is the mean cost of its mini-batches after their weights
+// updates. Validation split, early stopping, balanced class weights, checkpointing, weight averaging,
+// snapshots, curricula and resumed training require in-memory data and are not supported.
+// Training stops when the supplied context is cancelled or its deadline expires.
+// It returns error if the training configuration is not supported, if the network has heads,
+// if any stream fails to be opened or read or if the training fails.
+func (t *Trainer) TrainStream(ctx context.Context, n *Network, open func() (dataset.Stream, error)) error {
+	if n == nil {
+		return fmt.Errorf("Incorrect network supplied: %v\n", n)
+	}
+	if open == nil {
+		return fmt.Errorf("Incorrect data stream supplied\n")
+	}
+	c := t.c
+	if c.ValidSplit > 0 || c.EarlyStop != nil || c.Balanced || c.Checkpoint != nil || c.SWA != nil ||
+		c.SnapshotEvery > 0 || c.Sampling != nil || t.curriculum != nil || t.resume != nil {
+		return fmt.Errorf("Training configuration requires in-memory data\n")
+	}
+	if _, ok := t.optim.(RateOptimizer); t.sched != nil && !ok {
+		return fmt.Errorf("Optimizer does not support learning rate schedules: %T\n", t.optim)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	loss, err := configLoss(t.loss, c, nil, n.outSize())
+	if err != nil {
+		return err
+	}
+	layers, err := trainerLayers(n)
+	if err != nil {
+		return err
+	}
+	if c.Seed != 0 {
+		matrix.Seed(t.rng.Int63())
+	}
+	t.history = NewHistory()
+	t.snapshots = nil
+	var metrics Metrics
+	step := 0
+	err = t.notify(func(cb Callback) error { return cb.OnTrainBegin() })
+	for epoch := 1; err == nil && epoch <= c.Epochs; epoch++ {
+		var stream dataset.Stream
+		if stream, err = open(); err != nil {
+			break
+		}
+		var cost float64
+		if step, cost, err = t.runEpoch(ctx, n, layers, loss, stream, epoch, step, 0, true); err != nil {
+			break
+		}
+		if step == 0 {
+			err = fmt.Errorf("Empty data stream\n")
+			break
+		}
+		metrics = Metrics{"cost": cost}
+		t.customResults(metrics, "")
+		t.logf("Epoch %d: cost %f\n", epoch, cost)
+		t.history.OnEpochEnd(epoch, metrics)
+		err = t.notify(func(cb Callback) error { return cb.OnEpochEnd(epoch, metrics) })
+	}
+	if err != nil && err != ErrStopTraining {
+		return err
+	}
+	if err := t.notify(func(cb Callback) error { return cb.OnTrainEnd(metrics) }); err != ErrStopTraining {
+		return err
+	}
+	return nil
+}
+
+// trainerLayers returns the network layers trained by Trainer. It fails with error if the network has heads:
+// their costs require labels of every head, so networks with heads must be trained by TrainHeads.
+func trainerLayers(n *Network) ([]*Layer, error) {
+	if len(n.heads) > 0 {
+		return nil, fmt.Errorf("Trainer can't train network with %d heads: use TrainHeads\n", len(n.heads))
+	}
+	return n.trainLayers(), nil
+}
+
+// logf prints training progress to standard output if verbose training is configured
+func (t *Trainer) logf(format string, args ...interface{}) {
+	if t.c.Verbose {
+		fmt.Printf(format, args...)
+	}
+}
+
+// restore restores network weights and optimizer state of the supplied layers from the supplied checkpoint.
+// It returns error if the checkpoint does not match the layers, the optimizer or the training configuration.
+func (t *Trainer) restore(layers []*Layer, cp *Checkpoint) error {
+	if cp.Epoch < 0 || cp.Epoch > t.c.Epochs {
+		return fmt.Errorf("Incorrect checkpoint epoch: %d\n", cp.Epoch)
+	}
+	if count := len(netWeights(layers)); len(cp.Weights) != count {
+		return fmt.Errorf("Checkpoint weights mismatch. Network: %d, Checkpoint: %d\n", count, len(cp.Weights))
+	}
+	if err := setNetWeights(layers, cp.Weights); err != nil {
+		return err
+	}
+	if cp.Optim == nil {
+		return nil
+	}
+	optim, ok := t.optim.(StatefulOptimizer)
+	if !ok {
+		return fmt.Errorf("Optimizer does not support saved state: %T\n", t.optim)
+	}
+	return optim.SetState(layers, cp.Optim)
+}
+
+// batchEnd calculates the cost of the mini-batch after the weights update and notifies the callbacks
+func (t *Trainer) batchEnd(n *Network, loss Loss, batchInMx *mat.Dense, batchLabels *mat.VecDense,
+	epoch, batch int) (float64, error) {
+	cost, err := n.lossCost(loss, trainPenalty(t.c), batchInMx, batchLabels)
+	if err != nil {
+		return 0.0, err
+	}
+	if t.c.Guard {
+		if err := checkCost(n, cost, batchInMx, epoch, batch); err != nil {
+			return 0.0, err
+		}
+	}
+	m := Metrics{"cost": cost}
+	if err := t.notify(func(cb Callback) error { return cb.OnBatchEnd(epoch, batch, m) }); err != nil {
+		return 0.0, err
+	}
+	return cost, nil
+}
+
+// markProgress records the state of the running epoch after a weights update
+func (t *Trainer) markProgress(epoch, batch, step int) {
+	t.progress = epochProgress{
+		epoch:        epoch,
+		batch:        batch,
+		step:         step,
+		noiseDraws:   matrix.Draws(),
+		augmentState: t.augmentState(),
+	}
+}
+
+// augmentState returns random state of the augmenter or nil if the augmenter is not dataset.RandomState
+func (t *Trainer) augmentState() []int64 {
+	if r, ok := t.augment.(dataset.RandomState); ok {
+		return r.RandState()
+	}
+	return nil
+}
+
+// optimState returns optimizer state of the supplied layers or nil if the optimizer is not StatefulOptimizer
+func (t *Trainer) optimState(layers []*Layer) *OptimState {
+	if optim, ok := t.optim.(StatefulOptimizer); ok {
+		return optim.State(layers)
+	}
+	return nil
+}
+
+// runEpoch runs a single training epoch over the mini-batches of the supplied stream and returns
+// the number of weights updates done so far and the mean mini-batch cost. step is the number of
+// weights updates done in previous epochs and batch is the number of mini-batches of the epoch which
+// have been trained before the training was resumed. Mini-batch costs are only calculated when someone listens
+// or if costs is true; the mean cost is zero otherwise. With gradient accumulation the weights are
+// updated with the average gradient of the configured number of mini-batches. Gradients left
+// at the end of the epoch update the weights on their own.
+func (t *Trainer) runEpoch(ctx context.Context, n *Network, layers []*Layer, loss Loss,
+	stream dataset.Stream, epoch, step, batch int, costs bool) (int, float64, error) {
+	n.setTraining(true)
+	defer n.setTraining(false)
+	accumulate := t.c.Accumulate
+	if accumulate == 0 {
+		accumulate = 1
+	}
+	var accGrads []*mat.Dense
+	accumulated := 0
+	t.markProgress(epoch, batch, step)
+	costSum, costSamples := 0.0, 0
+	for _, m := range t.custom {
+		m.Reset()
+	}
+	for {
+		batchInMx, batchLabels, err := stream.NextBatch()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return step, 0.0, err
+		}
+		if err := ctx.Err(); err != nil {
+			return step, 0.0, err
+		}
+		if batchInMx == nil || batchLabels == nil {
+			return step, 0.0, fmt.Errorf("Incorrect mini-batch. In: %v, Labels: %v\n", batchInMx, batchLabels)
+		}
+		batch++
+		if t.augment != nil {
+			batchInMx = t.augment.Augment(batchInMx)
+		}
+		grads, err := t.gradients(n, layers, loss, batchInMx, batchLabels)
+		if err != nil {
+			return step, 0.0, err
+		}
+		if t.c.Guard {
+			if err := checkGrads(n, layers, grads, batchInMx, epoch, batch); err != nil {
+				return step, 0.0, err
+			}
+		}
+		accGrads = addGrads(accGrads, grads)
+		if accumulated++; accumulated == accumulate {
+			if err := t.update(layers, meanGrads(accGrads, accumulated), epoch-1, step); err != nil {
+				return step, 0.0, err
+			}
+			accGrads, accumulated = nil, 0
+			step++
+		}
+		if err := t.updateCustom(n, batchInMx, batchLabels); err != nil {
+			return step, 0.0, err
+		}
+		if costs || len(t.listeners()) > 0 {
+			cost, err := t.batchEnd(n, loss, batchInMx, batchLabels, epoch, batch)
+			if err != nil {
+				return step, 0.0, err
+			}
+			samples, _ := batchInMx.Dims()
+			costSum, costSamples = costSum+cost*float64(samples), costSamples+samples
+		}
+		// the epoch can be resumed from the state after the weights update
+		if accumulated == 0 {
+			t.markProgress(epoch, batch, step)
+		}
+	}
+	if accumulated > 0 {
+		if err := t.update(layers, meanGrads(accGrads, accumulated), epoch-1, step); err != nil {
+			return step, 0.0, err
+		}
+		step++
+	}
+	if costSamples == 0 {
+		return step, 0.0, nil
+	}
+	return step, costSum / float64(costSamples), nil
+}
+
+// evaluate calculates epoch metrics. The first trainSamples samples are training samples
+// and the rest of the samples are validation samples, which are evaluated with the configured
+// validation metrics. Accuracy is the percentage of hits, F1 is the macro average F1 of all the classes.
+func (t *Trainer) evaluate(n *Network, loss Loss, inMx *mat.Dense, labelsVec *mat.VecDense,
+	trainSamples int) (Metrics, error) {
+	samples, cols := inMx.Dims()
+	trainInMx := inMx.Slice(0, trainSamples, 0, cols).(*mat.Dense)
+	trainLabels := labelsVec.SliceVec(0, trainSamples).(*mat.VecDense)
+	cost, err := n.lossCost(loss, trainPenalty(t.c), trainInMx, trainLabels)
+	if err != nil {
+		return nil, err
+	}
+	m := Metrics{"cost": cost}
+	t.customResults(m, "")
+	valSamples := samples - trainSamples
+	if valSamples == 0 {
+		return m, nil
+	}
+	valInMx := inMx.Slice(trainSamples, trainSamples+valSamples, 0, cols).(*mat.Dense)
+	valLabels := labelsVec.SliceVec(trainSamples, trainSamples+valSamples).(*mat.VecDense)
+	if len(t.custom) > 0 {
+		for _, metric := range t.custom {
+			metric.Reset()
+		}
+		size := t.c.BatchSize
+		if size == 0 {
+			size = valSamples
+		}
+		for i := 0; i < valSamples; i += size {
+			rows := size
+			if i+rows > valSamples {
+				rows = valSamples - i
+			}
+			batchInMx := valInMx.Slice(i, i+rows, 0, cols).(*mat.Dense)
+			if err := t.updateCustom(n, batchInMx, valLabels.SliceVec(i, i+rows).(*mat.VecDense)); err != nil {
+				return nil, err
+			}
+		}
+		t.customResults(m, "val_")
+	}
+	// network output is shared by the metrics computed from predictions
+	var out mat.Matrix
+	for _, metric := range trainerMetrics(t.c) {
+		if out == nil && (metric == "f1" || metric == "auc" || metric == "logloss") {
+			if out, err = n.forwardProp(valInMx, len(n.layers)-1); err != nil {
+				return nil, err
+			}
+		}
+		var value float64
+		switch metric {
+		case "cost":
+			value, err = n.lossCost(loss, trainPenalty(t.c), valInMx, valLabels)
+		case "accuracy":
+			value, err = n.validate(valInMx, valLabels)
+		case "f1":
+			var cm *eval.ConfusionMatrix
+			if cm, err = eval.NewConfusionMatrix(out, valLabels); err == nil {
+				value = cm.Report().Macro.F1
+			}
+		case "auc":
+			value, err = eval.AUC(out, valLabels)
+		case "logloss":
+			value, err = eval.LogLoss(out, valLabels)
+		}
+		if err != nil {
+			return nil, err
+		}
+		m["val_"+metric] = value
+	}
+	return m, nil
+}
+
+// updateCustom updates custom metrics with the network output of the supplied mini-batch
+func (t *Trainer) updateCustom(n *Network, inMx *mat.Dense, labelsVec *mat.VecDense) error {
+	if len(t.custom) == 0 {
+		return nil
+	}
+	out, err := n.forwardProp(inMx, len(n.layers)-1)
+	if err != nil {
+		return err
+	}
+	for _, m := range t.custom {
+		if err := m.Update(out, labelsVec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// customResults stores the results of custom metrics in the supplied metrics with the supplied name prefix
+func (t *Trainer) customResults(m Metrics, prefix string) {
+	for name, metric := range t.custom {
+		m[prefix+name] = metric.Result()
+	}
+}
+
+// trainerMetrics returns validation metrics evaluated by Trainer with the supplied configuration
+func trainerMetrics(c *config.TrainConfig) []string {
+	if c.Metrics == nil {
+		return defaultMetrics
+	}
+	return c.Metrics
+}
+
+// contains returns true if the supplied list contains s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// listeners returns Trainer callbacks followed by the curriculum if it implements Callback
+func (t *Trainer) listeners() []Callback {
+	if cb, ok := t.curriculum.(Callback); ok {
+		return append(t.callbacks[:len(t.callbacks):len(t.callbacks)], cb)
+	}
+	return t.callbacks
+}
+
+// notify calls the supplied event on all Trainer callbacks and returns the first error
+func (t *Trainer) notify(event func(Callback) error) error {
+	for _, cb := range t.listeners() {
+		if err := event(cb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PartialFit updates the weights of the supplied network with a single gradient step on the supplied
+// samples, which can be a single sample or a micro-batch. Gradients are not accumulated between the calls.
+// Optimizer state is kept between the calls,
+// so the network can be trained incrementally from a stream of data without retraining from scratch.
+// Learning rate schedule is advanced by every call as if all the updates were done in the first epoch.
+// Class weights are calculated from the supplied samples. No data are held out for validation.
+// It returns error if the data are invalid, if the network has heads or if the update fails.
+func (t *Trainer) PartialFit(n *Network, inMx *mat.Dense, labelsVec *mat.VecDense) error {
+	if n == nil {
+		return fmt.Errorf("Incorrect network supplied: %v\n", n)
+	}
+	if inMx == nil || labelsVec == nil {
+		return fmt.Errorf("Incorrect data supplied. In: %v, Labels: %v\n", inMx, labelsVec)
+	}
+	samples, _ := inMx.Dims()
+	if labelsVec.Len() != samples {
+		return fmt.Errorf("Labels mismatch. Samples: %d, Labels: %d\n", samples, labelsVec.Len())
+	}
+	if _, ok := t.optim.(RateOptimizer); t.sched != nil && !ok {
+		return fmt.Errorf("Optimizer does not support learning rate schedules: %T\n", t.optim)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	loss, err := configLoss(t.loss, t.c, labelsVec, n.outSize())
+	if err != nil {
+		return err
+	}
+	n.setTraining(true)
+	defer n.setTraining(false)
+	layers, err := trainerLayers(n)
+	if err != nil {
+		return err
+	}
+	if t.augment != nil {
+		inMx = t.augment.Augment(inMx)
+	}
+	grads, err := t.gradients(n, layers, loss, inMx, labelsVec)
+	if err != nil {
+		return err
+	}
+	t.updates++
+	return t.update(layers, grads, 0, t.updates-1)
+}
+
+// averageWeights adds the supplied weights to the running average of count weights and returns it.
+// The supplied weights become the average if nothing has been averaged.
+func averageWeights(avg, weights []float64, count int) []float64 {
+	if avg == nil {
+		return weights
+	}
+	for i := range avg {
+		avg[i] += (weights[i] - avg[i]) / float64(count+1)
+	}
+	return avg
+}
+
+// improved returns true if metric improved on the best metric by more than delta
+func improved(metric, best, delta float64, maximize bool) bool {
+	if maximize {
+		return metric > best+delta
+	}
+	return metric < best-delta
+}
+
+// gradients returns the loss gradients of the supplied network layers on the mini-batch
+func (t *Trainer) gradients(n *Network, layers []*Layer, loss Loss, inMx *mat.Dense,
+	labelsVec *mat.VecDense) ([]*mat.Dense, error) {
+	if err := n.lossDeltas(loss, inMx, labelsVec); err != nil {
+		return nil, err
+	}
+	samples, _ := inMx.Dims()
+	return layersGradMx(layers, trainPenalty(t.c), samples), nil
+}
+
+// update updates the weights of the supplied layers using the supplied gradients. Learning rate
+// is set by the learning rate schedule for the given epoch and step first. The gradients are clipped
+// by value first and then by global norm if clipping is configured.
+func (t *Trainer) update(layers []*Layer, grads []*mat.Dense, epoch, step int) error {
+	if t.sched != nil {
+		rate := t.sched.Rate(t.c.Optimize.LearnRate, epoch, step)
+		if err := t.optim.(RateOptimizer).SetRate(rate); err != nil {
+			return err
+		}
+	}
+	if t.c.Optimize.ClipValue > 0 {
+		ClipValue(grads, t.c.Optimize.ClipValue)
+	}
+	if t.c.Optimize.ClipNorm > 0 {
+		ClipNorm(grads, t.c.Optimize.ClipNorm)
+	}
+	for i, grad := range grads {
+		if err := t.optim.Step(layers[i], grad); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addGrads adds the supplied gradients to the accumulated gradients and returns them.
+// The supplied gradients become the accumulated gradients if nothing has been accumulated.
+func addGrads(acc, grads []*mat.Dense) []*mat.Dense {
+	if acc == nil {
+		return grads
+	}
+	for i, grad := range grads {
+		acc[i].Add(acc[i], grad)
+	}
+	return acc
+}
+
+// meanGrads divides the supplied accumulated gradients by the number of accumulated mini-batches
+func meanGrads(acc []*mat.Dense, count int) []*mat.Dense {
+	if count > 1 {
+		for _, grad := range acc {
+			grad.Scale(1/float64(count), grad)
+		}
+	}
+	return acc
+}
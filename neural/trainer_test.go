@@ -0,0 +1,747 @@
+package neural
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"github.com/milosgajdos83/go-neural/pkg/eval"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// newTrainerConfig returns mini-batch training configuration used in tests
+func newTrainerConfig() *config.TrainConfig {
+	return &config.TrainConfig{
+		Kind:   "backprop",
+		Cost:   "loglike",
+		Lambda: 0.1,
+		Optimize: &config.OptimConfig{
+			Method:    "sgd",
+			LearnRate: 0.5,
+		},
+		Epochs:    30,
+		BatchSize: 2,
+		Shuffle:   true,
+	}
+}
+
+func TestNewTrainer(t *testing.T) {
+	assert := assert.New(t)
+
+	tr, err := NewTrainer(nil)
+	assert.Nil(tr)
+	assert.Error(err)
+	// incorrect configuration parameters
+	testCases := []func(c *config.TrainConfig){
+		func(c *config.TrainConfig) { c.Cost = "foo" },
+		func(c *config.TrainConfig) { c.Lambda = -1.0 },
+		func(c *config.TrainConfig) { c.Optimize = nil },
+		func(c *config.TrainConfig) { c.Optimize.Method = "bfgs" },
+		func(c *config.TrainConfig) { c.Optimize.LearnRate = 0.0 },
+		func(c *config.TrainConfig) { c.Optimize.Momentum = 1.0 },
+		func(c *config.TrainConfig) { c.Optimize.ClipNorm = -1.0 },
+		func(c *config.TrainConfig) { c.Optimize.ClipValue = -1.0 },
+		func(c *config.TrainConfig) { c.LabelSmoothing = 1.0 },
+		func(c *config.TrainConfig) { c.Epochs = 0 },
+		func(c *config.TrainConfig) { c.BatchSize = -1 },
+		func(c *config.TrainConfig) { c.ValidSplit = 1.0 },
+	}
+	for _, tc := range testCases {
+		c := newTrainerConfig()
+		tc(c)
+		tr, err = NewTrainer(c)
+		assert.Nil(tr)
+		assert.Error(err)
+	}
+	tr, err = NewTrainer(newTrainerConfig())
+	assert.NotNil(tr)
+	assert.NoError(err)
+}
+
+func TestTrainerTrain(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	tr, err := NewTrainer(c)
+	assert.NoError(err)
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	// incorrect data
+	assert.Error(tr.Train(nil, inMx, labelsVec))
+	assert.Error(tr.Train(n, nil, labelsVec))
+	assert.Error(tr.Train(n, inMx, labelsVec.SliceVec(0, 3).(*mat.VecDense)))
+	before, err := n.getCost(c, nil, inMx, labelsVec)
+	assert.NoError(err)
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+	after, err := n.getCost(c, nil, inMx, labelsVec)
+	assert.NoError(err)
+	assert.True(after < before)
+	// validation split
+	c.ValidSplit = 0.4
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+	// mini-batch training via network
+	c.ValidSplit = 0.0
+	assert.NoError(n.Train(c, inMx, labelsVec))
+	// cancelled training
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(tr.TrainContext(ctx, n, inMx, labelsVec), context.Canceled)
+}
+
+// captureStdout returns everything printed to standard output by the supplied function
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	out := make(chan []byte)
+	go func() {
+		data, _ := ioutil.ReadAll(r)
+		out <- data
+	}()
+	f()
+	w.Close()
+	return string(<-out)
+}
+
+func TestTrainerVerbose(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	c.Epochs, c.ValidSplit = 2, 0.4
+	tr, err := NewTrainer(c)
+	assert.NoError(err)
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	// trainer is silent by default
+	out := captureStdout(t, func() { assert.NoError(tr.Train(n, inMx, labelsVec)) })
+	assert.Equal("", out)
+	c.Verbose = true
+	out = captureStdout(t, func() { assert.NoError(tr.Train(n, inMx, labelsVec)) })
+	assert.Equal(2, strings.Count(out, "\n"))
+	assert.True(strings.HasPrefix(out, "Epoch 1: cost "))
+	assert.True(strings.Contains(out, "validation accuracy"))
+}
+
+func TestTrainerHeads(t *testing.T) {
+	assert := assert.New(t)
+
+	tr, err := NewTrainer(newTrainerConfig())
+	assert.NoError(err)
+	n, err := NewBuilder().Input(4).Hidden(5, Sigmoid).Output(5, Softmax).Build()
+	assert.NoError(err)
+	h, err := NewHead("foo", LogLikelihood{}, 1.0, newTestLayer("output", 2, Softmax))
+	assert.NoError(err)
+	assert.NoError(n.AddHead(h))
+	// head costs require labels of every head
+	assert.Error(tr.Train(n, inMx, labelsVec))
+	assert.Error(tr.PartialFit(n, inMx, labelsVec))
+	open := func() (dataset.Stream, error) {
+		return dataset.NewBatches(inMx, labelsVec, 2, false, false, 0)
+	}
+	assert.Error(tr.TrainStream(context.Background(), n, open))
+}
+
+func TestTrainerSeed(t *testing.T) {
+	assert := assert.New(t)
+
+	netConf := &config.NetConfig{
+		Kind: "feedfwd",
+		Arch: &config.NetArch{
+			Input: &config.LayerConfig{Kind: "input", Size: 4, Noise: 0.1},
+			Hidden: []*config.LayerConfig{
+				{Kind: "hidden", Size: 5, NeurFn: &config.NeuronConfig{Activation: "sigmoid"}},
+			},
+			Output: &config.LayerConfig{Kind: "output", Size: 5, NeurFn: &config.NeuronConfig{Activation: "softmax"}},
+		},
+		Seed: 7,
+	}
+	c := newTrainerConfig()
+	c.Epochs = 5
+	c.Seed = 7
+	train := func() []float64 {
+		n, err := NewNetwork(netConf)
+		assert.NoError(err)
+		tr, err := NewTrainer(c)
+		assert.NoError(err)
+		assert.NoError(tr.Train(n, inMx, labelsVec))
+		return n.Params()
+	}
+	// the same seeds give the same weights
+	params := train()
+	assert.Equal(params, train())
+	// different seed gives different weights
+	c.Seed = 8
+	assert.NotEqual(params, train())
+}
+
+func TestTrainerTrainStream(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	tr, err := NewTrainer(c)
+	assert.NoError(err)
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	opened := 0
+	open := func() (dataset.Stream, error) {
+		opened++
+		return dataset.NewBatches(inMx, labelsVec, 2, false, false, 0)
+	}
+	// incorrect parameters
+	assert.Error(tr.TrainStream(context.Background(), nil, open))
+	assert.Error(tr.TrainStream(context.Background(), n, nil))
+	// a new stream is opened every epoch
+	before, err := n.getCost(c, nil, inMx, labelsVec)
+	assert.NoError(err)
+	assert.NoError(tr.TrainStream(context.Background(), n, open))
+	assert.Equal(c.Epochs, opened)
+	after, err := n.getCost(c, nil, inMx, labelsVec)
+	assert.NoError(err)
+	assert.True(after < before)
+	assert.Len(tr.History().Metric("cost"), c.Epochs)
+	// failing and empty streams
+	failed := fmt.Errorf("Failed")
+	assert.Equal(failed, tr.TrainStream(context.Background(), n, func() (dataset.Stream, error) {
+		return nil, failed
+	}))
+	assert.Error(tr.TrainStream(context.Background(), n, func() (dataset.Stream, error) {
+		return dataset.NewJSONLStream(strings.NewReader(""), 2)
+	}))
+	// unlabeled stream
+	assert.Error(tr.TrainStream(context.Background(), n, func() (dataset.Stream, error) {
+		return dataset.NewBatches(inMx, nil, 2, false, false, 0)
+	}))
+	// configuration which requires in-memory data
+	c.ValidSplit = 0.2
+	assert.Error(tr.TrainStream(context.Background(), n, open))
+}
+
+func TestTrainerPartialFit(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	tr, err := NewTrainer(c)
+	assert.NoError(err)
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	// incorrect data
+	assert.Error(tr.PartialFit(nil, inMx, labelsVec))
+	assert.Error(tr.PartialFit(n, nil, labelsVec))
+	assert.Error(tr.PartialFit(n, inMx, labelsVec.SliceVec(0, 3).(*mat.VecDense)))
+	before, err := n.getCost(c, nil, inMx, labelsVec)
+	assert.NoError(err)
+	// stream the samples one by one
+	samples, cols := inMx.Dims()
+	for epoch := 0; epoch < 30; epoch++ {
+		for i := 0; i < samples; i++ {
+			sample := inMx.Slice(i, i+1, 0, cols).(*mat.Dense)
+			assert.NoError(tr.PartialFit(n, sample, labelsVec.SliceVec(i, i+1).(*mat.VecDense)))
+		}
+	}
+	after, err := n.getCost(c, nil, inMx, labelsVec)
+	assert.NoError(err)
+	assert.True(after < before)
+	// every call makes a single step per layer
+	r := &recordOptim{}
+	assert.NoError(tr.SetOptimizer(r))
+	assert.NoError(tr.PartialFit(n, inMx, labelsVec))
+	assert.Len(r.grads, len(n.trainLayers()))
+	// network keeps its trainer between the calls
+	assert.Error(n.PartialFit(nil, inMx, labelsVec))
+	assert.NoError(n.PartialFit(c, inMx, labelsVec))
+	online := n.online
+	assert.NoError(n.PartialFit(c, inMx, labelsVec))
+	assert.True(online == n.online)
+	assert.Equal(2, online.updates)
+}
+
+func TestTrainerAccumulate(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	c.Accumulate = -1
+	tr, err := NewTrainer(c)
+	assert.Nil(tr)
+	assert.Error(err)
+	// weights are updated after every accumulated mini-batches and at the end of epoch
+	c.Epochs = 1
+	c.BatchSize = 1
+	c.Accumulate = 2
+	tr, err = NewTrainer(c)
+	assert.NoError(err)
+	r := &recordOptim{}
+	assert.NoError(tr.SetOptimizer(r))
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+	assert.Len(r.grads, 3*len(n.trainLayers()))
+	// accumulated gradients equal the gradients of bigger mini-batches
+	c = newTrainerConfig()
+	c.Lambda = 0.0
+	c.Shuffle = false
+	accNet := n.Clone()
+	assert.NoError(n.Train(c, inMx, labelsVec))
+	c.BatchSize = 1
+	c.Accumulate = 2
+	assert.NoError(accNet.Train(c, inMx, labelsVec))
+	accParams := accNet.Params()
+	for i, param := range n.Params() {
+		assert.InDelta(param, accParams[i], 1e-10)
+	}
+}
+
+func TestTrainerSWA(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	c.Epochs = 4
+	c.BatchSize = 0
+	c.SWA = &config.SWAConfig{Start: 5, Every: 1}
+	tr, err := NewTrainer(c)
+	assert.Nil(tr)
+	assert.Error(err)
+	c.SWA.Start = 2
+	c.EarlyStop = &config.EarlyStopConfig{Monitor: "cost", Patience: 1}
+	c.ValidSplit = 0.4
+	tr, err = NewTrainer(c)
+	assert.Nil(tr)
+	assert.Error(err)
+	c.EarlyStop = nil
+	c.ValidSplit = 0.0
+	tr, err = NewTrainer(c)
+	assert.NoError(err)
+	// weights are shifted by a constant every epoch
+	assert.NoError(tr.SetOptimizer(&shiftOptim{shift: 0.1}))
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	mid := n.Clone()
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+	// average of epochs 2, 3 and 4 equals the weights after epoch 3
+	c = newTrainerConfig()
+	c.Epochs = 3
+	c.BatchSize = 0
+	tr, err = NewTrainer(c)
+	assert.NoError(err)
+	assert.NoError(tr.SetOptimizer(&shiftOptim{shift: 0.1}))
+	assert.NoError(tr.Train(mid, inMx, labelsVec))
+	midParams := mid.Params()
+	for i, param := range n.Params() {
+		assert.InDelta(midParams[i], param, 1e-10)
+	}
+}
+
+func TestTrainerSnapshots(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	c.SnapshotEvery = -1
+	tr, err := NewTrainer(c)
+	assert.Nil(tr)
+	assert.Error(err)
+	// no snapshots
+	c.SnapshotEvery = 0
+	tr, err = NewTrainer(c)
+	assert.NoError(err)
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+	e, err := tr.Snapshots(Average)
+	assert.Nil(e)
+	assert.Error(err)
+	// snapshot at the end of every learning rate cycle
+	c.Epochs = 9
+	c.SnapshotEvery = 3
+	c.Schedule = &config.ScheduleConfig{Kind: "cyclic", Every: 3, MinRate: 0.01}
+	tr, err = NewTrainer(c)
+	assert.NoError(err)
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+	e, err = tr.Snapshots(Vote)
+	assert.NoError(err)
+	assert.Len(e.Networks(), 3)
+	assert.Equal(Vote, e.Combine())
+	// the last snapshot is the trained network
+	assert.Equal(n.Params(), e.Networks()[2].Params())
+	assert.NotEqual(n.Params(), e.Networks()[0].Params())
+	labels, err := e.Predict(inMx)
+	assert.NoError(err)
+	assert.Equal(labelsVec.Len(), labels.Len())
+}
+
+func TestTrainerSetOptimizer(t *testing.T) {
+	assert := assert.New(t)
+
+	tr, err := NewTrainer(newTrainerConfig())
+	assert.NoError(err)
+	sgd, ok := tr.Optimizer().(*SGD)
+	assert.True(ok)
+	assert.Equal(sgd.Rate(), 0.5)
+	assert.Error(tr.SetOptimizer(nil))
+	optim, err := NewSGD(0.5, 0.9, true)
+	assert.NoError(err)
+	assert.NoError(tr.SetOptimizer(optim))
+	assert.Equal(tr.Optimizer(), optim)
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+}
+
+// constOptim is Optimizer which does not support learning rate schedules
+type constOptim struct{}
+
+func (constOptim) Step(layer *Layer, grad *mat.Dense) error {
+	return nil
+}
+
+func TestTrainerScheduler(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	c.Epochs = 2
+	c.Schedule = &config.ScheduleConfig{Kind: "foo"}
+	tr, err := NewTrainer(c)
+	assert.Nil(tr)
+	assert.Error(err)
+	c.Schedule = &config.ScheduleConfig{Kind: "exp", Factor: 0.5}
+	tr, err = NewTrainer(c)
+	assert.NoError(err)
+	assert.NotNil(tr.Scheduler())
+	// the schedule is consulted before every mini-batch update
+	var epochs, steps []int
+	tr.SetScheduler(SchedulerFunc(func(base float64, epoch, step int) float64 {
+		epochs = append(epochs, epoch)
+		steps = append(steps, step)
+		return base / 2
+	}))
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+	assert.Equal(epochs, []int{0, 0, 0, 1, 1, 1})
+	assert.Equal(steps, []int{0, 1, 2, 3, 4, 5})
+	assert.Equal(tr.Optimizer().(*SGD).Rate(), 0.25)
+	// the schedule requires optimizer with adjustable learning rate
+	assert.NoError(tr.SetOptimizer(constOptim{}))
+	assert.Error(tr.Train(n, inMx, labelsVec))
+	tr.SetScheduler(nil)
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+}
+
+// shiftOptim is Optimizer which shifts all layer weights by a constant and counts the steps
+type shiftOptim struct {
+	shift float64
+	steps int
+}
+
+func (s *shiftOptim) Step(layer *Layer, grad *mat.Dense) error {
+	s.steps++
+	layer.Weights().Apply(func(i, j int, x float64) float64 { return x + s.shift }, layer.Weights())
+	return nil
+}
+
+func TestTrainerEarlyStop(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	c.EarlyStop = &config.EarlyStopConfig{Monitor: "cost", Patience: 2, MinDelta: 1e9}
+	// early stopping requires validation data
+	tr, err := NewTrainer(c)
+	assert.Nil(tr)
+	assert.Error(err)
+	c.ValidSplit = 0.4
+	// incorrect early stopping parameters
+	testCases := []func(c *config.EarlyStopConfig){
+		func(c *config.EarlyStopConfig) { c.Monitor = "foo" },
+		func(c *config.EarlyStopConfig) { c.Patience = 0 },
+		func(c *config.EarlyStopConfig) { c.MinDelta = -1.0 },
+	}
+	for _, tc := range testCases {
+		stop := *c.EarlyStop
+		tc(&stop)
+		tr, err = NewTrainer(&config.TrainConfig{
+			Kind: c.Kind, Cost: c.Cost, Optimize: c.Optimize,
+			Epochs: c.Epochs, ValidSplit: c.ValidSplit, EarlyStop: &stop,
+		})
+		assert.Nil(tr)
+		assert.Error(err)
+	}
+	for _, monitor := range []string{"cost", "accuracy"} {
+		c.EarlyStop.Monitor = monitor
+		tr, err = NewTrainer(c)
+		assert.NoError(err)
+		optim := &shiftOptim{shift: 0.01}
+		assert.NoError(tr.SetOptimizer(optim))
+		n, err := NewFeedForward(4, []int{5}, 5)
+		assert.NoError(err)
+		layers := n.trainLayers()
+		weights := netWeights(layers)
+		assert.NoError(tr.Train(n, inMx, labelsVec))
+		// no epoch improves on the first one: training stops after patience epochs
+		// 3 training samples are split into 2 mini-batches per epoch
+		assert.Equal(optim.steps, 3*2*len(layers))
+		// the weights after the first epoch are restored
+		for i, w := range netWeights(layers) {
+			assert.InDelta(weights[i]+0.02, w, 1e-9)
+		}
+	}
+	// too few samples to hold out validation data
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	assert.Error(tr.Train(n, inMx.Slice(0, 2, 0, 4).(*mat.Dense), labelsVec.SliceVec(0, 2).(*mat.VecDense)))
+}
+
+// recordOptim is Optimizer which records the gradients it is supplied with
+type recordOptim struct {
+	grads []*mat.Dense
+}
+
+func (r *recordOptim) Step(layer *Layer, grad *mat.Dense) error {
+	r.grads = append(r.grads, grad)
+	return nil
+}
+
+func TestTrainerClip(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	c.Epochs = 1
+	c.BatchSize = 0
+	c.Optimize.ClipValue = 0.05
+	c.Optimize.ClipNorm = 0.01
+	tr, err := NewTrainer(c)
+	assert.NoError(err)
+	optim := &recordOptim{}
+	assert.NoError(tr.SetOptimizer(optim))
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+	assert.Len(optim.grads, len(n.trainLayers()))
+	sum := 0.0
+	for _, grad := range optim.grads {
+		assert.True(mat.Max(grad) <= 0.05)
+		assert.True(mat.Min(grad) >= -0.05)
+		norm := mat.Norm(grad, 2)
+		sum += norm * norm
+	}
+	assert.True(sum <= 0.01*0.01+1e-12)
+}
+
+func TestTrainerClassWeights(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	c.ClassWeights = []float64{1.0, -1.0}
+	tr, err := NewTrainer(c)
+	assert.Nil(tr)
+	assert.Error(err)
+	c.Balanced = true
+	c.ClassWeights = []float64{1.0, 2.0}
+	tr, err = NewTrainer(c)
+	assert.Nil(tr)
+	assert.Error(err)
+	// the number of class weights must match the number of classes
+	c.Balanced = false
+	tr, err = NewTrainer(c)
+	assert.NoError(err)
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	assert.Error(tr.Train(n, inMx, labelsVec))
+	c.ClassWeights = nil
+	c.Balanced = true
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+}
+
+func TestTrainerAugmenter(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	c.Epochs = 2
+	c.ValidSplit = 0.4
+	tr, err := NewTrainer(c)
+	assert.NoError(err)
+	assert.Nil(tr.Augmenter())
+	// only training mini-batches are augmented
+	var sizes []int
+	tr.SetAugmenter(dataset.AugmenterFunc(func(m *mat.Dense) *mat.Dense {
+		rows, _ := m.Dims()
+		sizes = append(sizes, rows)
+		return mat.DenseCopyOf(m)
+	}))
+	assert.NotNil(tr.Augmenter())
+	orig := mat.DenseCopyOf(inMx)
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+	assert.Equal([]int{2, 1, 2, 1}, sizes)
+	assert.True(mat.Equal(orig, inMx))
+	// incremental training
+	sizes = nil
+	assert.NoError(tr.PartialFit(n, inMx, labelsVec))
+	assert.Equal([]int{5}, sizes)
+}
+
+func TestTrainerSampling(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	c.Epochs = 2
+	c.Sampling = &config.SamplingConfig{Weights: []float64{-1.0}}
+	tr, err := NewTrainer(c)
+	assert.Nil(tr)
+	assert.Error(err)
+	c.Sampling.Weights = []float64{0.0}
+	tr, err = NewTrainer(c)
+	assert.Nil(tr)
+	assert.Error(err)
+	// only the samples of the second class are drawn
+	c.Sampling.Weights = []float64{0.0, 1.0}
+	tr, err = NewTrainer(c)
+	assert.NoError(err)
+	inMx := mat.NewDense(6, 2, []float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 2, 2})
+	labelsVec := mat.NewVecDense(6, []float64{1, 1, 1, 1, 1, 2})
+	var drawn []float64
+	tr.SetAugmenter(dataset.AugmenterFunc(func(m *mat.Dense) *mat.Dense {
+		rows, _ := m.Dims()
+		for i := 0; i < rows; i++ {
+			drawn = append(drawn, m.At(i, 0))
+		}
+		return m
+	}))
+	n, err := NewFeedForward(2, []int{3}, 2)
+	assert.NoError(err)
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+	assert.Len(drawn, 12)
+	for _, v := range drawn {
+		assert.Equal(2.0, v)
+	}
+	// labels without sampling weight
+	c.Sampling.Weights = []float64{1.0}
+	assert.Error(tr.Train(n, inMx, labelsVec))
+	// curriculum selects samples on its own
+	c.Sampling.Weights = nil
+	tr.SetCurriculum(CurriculumFunc(func(epoch int) []int { return nil }))
+	assert.Error(tr.Train(n, inMx, labelsVec))
+	tr.SetCurriculum(nil)
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+}
+
+func TestTrainerMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	c.Epochs = 3
+	c.Metrics = []string{"f1", "auc"}
+	// validation metrics require validation data
+	tr, err := NewTrainer(c)
+	assert.Nil(tr)
+	assert.Error(err)
+	c.ValidSplit = 0.4
+	c.Metrics = []string{"f1", "foo"}
+	tr, err = NewTrainer(c)
+	assert.Nil(tr)
+	assert.Error(err)
+	// early stopping metric must be evaluated
+	c.Metrics = []string{"f1", "auc"}
+	c.EarlyStop = &config.EarlyStopConfig{Monitor: "cost", Patience: 2}
+	tr, err = NewTrainer(c)
+	assert.Nil(tr)
+	assert.Error(err)
+	c.EarlyStop.Monitor = "auc"
+	tr, err = NewTrainer(c)
+	assert.NoError(err)
+	var epochs []Metrics
+	assert.NoError(tr.AddCallback(CallbackFuncs{
+		EpochEnd: func(epoch int, m Metrics) error {
+			epochs = append(epochs, m)
+			return nil
+		},
+	}))
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+	assert.NotEmpty(epochs)
+	for _, m := range epochs {
+		assert.True(hasMetrics(m, "cost", "val_f1", "val_auc"))
+		assert.Len(m, 3)
+		assert.True(m["val_f1"] >= 0 && m["val_f1"] <= 1)
+		assert.True(m["val_auc"] >= 0 && m["val_auc"] <= 1)
+	}
+	assert.Equal([]string{"cost", "val_auc", "val_f1"}, tr.History().Names())
+	// validation metrics match the evaluation of the trained network
+	n, err = NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	c.EarlyStop = nil
+	c.Epochs = 1
+	c.Metrics = []string{"cost", "accuracy", "f1", "auc", "logloss"}
+	tr, err = NewTrainer(c)
+	assert.NoError(err)
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+	valInMx, valLabels := inMx.Slice(3, 5, 0, 4).(*mat.Dense), labelsVec.SliceVec(3, 5).(*mat.VecDense)
+	out, err := n.ForwardProp(valInMx, len(n.Layers())-1)
+	assert.NoError(err)
+	auc, err := eval.AUC(out, valLabels)
+	assert.NoError(err)
+	assert.InDelta(auc, tr.History().Metric("val_auc")[0], 1e-12)
+	logLoss, err := eval.LogLoss(out, valLabels)
+	assert.NoError(err)
+	assert.InDelta(logLoss, tr.History().Metric("val_logloss")[0], 1e-12)
+	acc, err := n.Validate(valInMx, valLabels)
+	assert.NoError(err)
+	assert.InDelta(acc, tr.History().Metric("val_accuracy")[0], 1e-12)
+}
+
+// countMetric is eval.Metric which counts the supplied samples
+type countMetric struct {
+	samples int
+}
+
+func (c *countMetric) Update(pred mat.Matrix, labels *mat.VecDense) error {
+	c.samples += labels.Len()
+	return nil
+}
+
+func (c *countMetric) Result() float64 {
+	return float64(c.samples)
+}
+
+func (c *countMetric) Reset() {
+	c.samples = 0
+}
+
+func TestTrainerAddMetric(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newTrainerConfig()
+	c.Epochs = 2
+	c.BatchSize = 1
+	c.ValidSplit = 0.4
+	tr, err := NewTrainer(c)
+	assert.NoError(err)
+	// incorrect metrics
+	assert.Error(tr.AddMetric("count", nil))
+	for _, name := range []string{"", "cost", "auc", "val_count"} {
+		assert.Error(tr.AddMetric(name, &countMetric{}), "%s", name)
+	}
+	assert.NoError(tr.AddMetric("count", &countMetric{}))
+	assert.Error(tr.AddMetric("count", &countMetric{}))
+	assert.NoError(tr.AddMetric("acc", &eval.AccuracyMetric{}))
+	n, err := NewFeedForward(4, []int{5}, 5)
+	assert.NoError(err)
+	assert.NoError(tr.Train(n, inMx, labelsVec))
+	// metrics are aggregated across the mini-batches of every epoch
+	assert.Equal([]float64{3, 3}, tr.History().Metric("count"))
+	assert.Equal([]float64{2, 2}, tr.History().Metric("val_count"))
+	valInMx, valLabels := inMx.Slice(3, 5, 0, 4).(*mat.Dense), labelsVec.SliceVec(3, 5).(*mat.VecDense)
+	acc, err := n.Validate(valInMx, valLabels)
+	assert.NoError(err)
+	assert.InDelta(acc/100, tr.History().Metric("val_acc")[1], 1e-12)
+	assert.Len(tr.History().Metric("acc"), 2)
+}
@@ -0,0 +1,156 @@
+package neural
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// weightsMagic starts every weights file saved in Binary format
+var weightsMagic = []byte("GONW")
+
+// weightsFile is serializable representation of network weights
+type weightsFile struct {
+	Version uint16          `json:"version"`
+	Layers  []*layerWeights `json:"layers"`
+}
+
+// layerWeights is serializable representation of layer weights
+type layerWeights struct {
+	// Name identifies the layer: layer.i for i-th network layer, branch.i and head.i
+	// for i-th layer of the named input branch or head
+	Name string `json:"name"`
+	Rows int    `json:"rows"`
+	Cols int    `json:"cols"`
+	// Weights contains weights matrix elements in row-major order
+	Weights []float64 `json:"weights"`
+}
+
+// namedLayers returns all network layers which have weights along with their names.
+// Layers are named the same way as in the weights files.
+func (n *Network) namedLayers() ([]string, []*Layer) {
+	var names []string
+	var layers []*Layer
+	add := func(name string, ls []*Layer) {
+		for i, layer := range ls {
+			if layer.Kind() != INPUT {
+				names = append(names, fmt.Sprintf("%s.%d", name, i))
+				layers = append(layers, layer)
+			}
+		}
+	}
+	add("layer", n.layers)
+	for _, branch := range n.branches {
+		add(branch.name, branch.layers)
+	}
+	for _, head := range n.heads {
+		add(head.name, head.layers)
+	}
+	return names, layers
+}
+
+// ExportWeights saves the weights of all the layers of the supplied network to the file with the supplied path,
+// so they can be imported into another network of the same topology using ImportWeights. Layers are
+// identified by name: layer.i for i-th network layer, branch.i and head.i for i-th layer of the named
+// input branch or head. Files are saved in the format selected by their extension the same way as
+// by SaveNetwork, including gzip compression. It returns error if the network is nil or if it fails
+// to write the file.
+func ExportWeights(path string, n *Network) error {
+	if n == nil {
+		return fmt.Errorf("Incorrect network supplied: %v\n", n)
+	}
+	n.mu.RLock()
+	file := &weightsFile{Version: formatVersion}
+	names, layers := n.namedLayers()
+	for i, layer := range layers {
+		rows, cols := layer.Weights().Dims()
+		weights := mat.DenseCopyOf(layer.Weights())
+		file.Layers = append(file.Layers, &layerWeights{
+			Name:    names[i],
+			Rows:    rows,
+			Cols:    cols,
+			Weights: weights.RawMatrix().Data,
+		})
+	}
+	n.mu.RUnlock()
+	f, compress := fileFormat(path)
+	return writeFile(path, gzipWrite(compress, func(w io.Writer) error {
+		if f == Binary {
+			if _, err := w.Write(weightsMagic); err != nil {
+				return err
+			}
+			return gob.NewEncoder(w).Encode(file)
+		}
+		return json.NewEncoder(w).Encode(file)
+	}))
+}
+
+// ImportWeights sets the weights of all the layers of the supplied network to the weights saved in the file with
+// the supplied path by ExportWeights. The file format and compression are detected automatically. Every network layer
+// with weights must have weights of the same dimensions in the file and the file must not contain weights
+// of any other layers. Network weights are only modified if all the layers match. It returns error if
+// the network is nil, if the file can't be read or if its weights don't match the network.
+func ImportWeights(path string, n *Network) error {
+	if n == nil {
+		return fmt.Errorf("Incorrect network supplied: %v\n", n)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	file := new(weightsFile)
+	br, err := gzipReader(f)
+	if err != nil {
+		return fmt.Errorf("Incorrect weights file %s: %v\n", path, err)
+	}
+	if magic, _ := br.Peek(len(weightsMagic)); bytes.Equal(magic, weightsMagic) {
+		br.Discard(len(weightsMagic))
+		err = gob.NewDecoder(br).Decode(file)
+	} else {
+		err = json.NewDecoder(br).Decode(file)
+	}
+	if err != nil {
+		return fmt.Errorf("Incorrect weights file %s: %v\n", path, err)
+	}
+	if err := checkVersion(file.Version); err != nil {
+		return err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	names, layers := n.namedLayers()
+	saved := make(map[string]*layerWeights)
+	for _, lw := range file.Layers {
+		if lw == nil || lw.Rows <= 0 || lw.Cols <= 0 || len(lw.Weights) != lw.Rows*lw.Cols {
+			return fmt.Errorf("Incorrect weights in file %s: %v\n", path, lw)
+		}
+		saved[lw.Name] = lw
+	}
+	if len(saved) != len(layers) {
+		return fmt.Errorf("Layers mismatch. Network: %d, File: %d\n", len(layers), len(saved))
+	}
+	weights := make([]*mat.Dense, len(layers))
+	for i, layer := range layers {
+		lw, ok := saved[names[i]]
+		if !ok {
+			return fmt.Errorf("Missing weights of layer %s\n", names[i])
+		}
+		rows, cols := layer.Weights().Dims()
+		if lw.Rows != rows || lw.Cols != cols {
+			return fmt.Errorf("Dimension mismatch of layer %s. Current: %d x %d Supplied: %d x %d\n",
+				names[i], rows, cols, lw.Rows, lw.Cols)
+		}
+		weights[i] = mat.NewDense(rows, cols, lw.Weights)
+	}
+	for i, layer := range layers {
+		if err := layer.SetWeights(weights[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package neural
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportWeights(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "weights")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	n, err := newModelNetwork()
+	assert.NoError(err)
+	other, err := newModelNetwork()
+	assert.NoError(err)
+	assert.NotEqual(n.Params(), other.Params())
+	for _, name := range []string{"weights.json", "weights.bin", "weights.bin.gz"} {
+		path := filepath.Join(dir, name)
+		assert.Error(ExportWeights(path, nil))
+		assert.NoError(ExportWeights(path, n))
+		assert.Error(ImportWeights(path, nil))
+		dst, err := newModelNetwork()
+		assert.NoError(err)
+		assert.NoError(ImportWeights(path, dst))
+		assert.Equal(n.Params(), dst.Params())
+	}
+	// layers are identified by name
+	data, err := ioutil.ReadFile(filepath.Join(dir, "weights.json"))
+	assert.NoError(err)
+	file := new(weightsFile)
+	assert.NoError(json.Unmarshal(data, file))
+	var names []string
+	for _, lw := range file.Layers {
+		names = append(names, lw.Name)
+	}
+	assert.Equal([]string{"layer.1", "layer.2", "layer.3", "layer.4", "a.1", "foo.0"}, names)
+	// weights are not modified if the topology does not match
+	params := other.Params()
+	path := filepath.Join(dir, "weights.json")
+	file.Layers[4].Name = "b.1"
+	data, err = json.Marshal(file)
+	assert.NoError(err)
+	assert.NoError(ioutil.WriteFile(path, data, 0644))
+	assert.Error(ImportWeights(path, other))
+	file.Layers[4].Name = "a.1"
+	file.Layers[0].Rows--
+	file.Layers[0].Weights = file.Layers[0].Weights[file.Layers[0].Cols:]
+	data, err = json.Marshal(file)
+	assert.NoError(err)
+	assert.NoError(ioutil.WriteFile(path, data, 0644))
+	assert.Error(ImportWeights(path, other))
+	ff, err := NewFeedForward(4, []int{5}, 3)
+	assert.NoError(err)
+	assert.Error(ImportWeights(filepath.Join(dir, "weights.bin"), ff))
+	assert.Equal(params, other.Params())
+	// unsupported version and missing file
+	file.Version = formatVersion + 1
+	data, err = json.Marshal(file)
+	assert.NoError(err)
+	assert.NoError(ioutil.WriteFile(path, data, 0644))
+	assert.Error(ImportWeights(path, other))
+	assert.Error(ImportWeights(filepath.Join(dir, "foo.json"), other))
+	assert.NoError(ioutil.WriteFile(path, []byte("foo"), 0644))
+	assert.Error(ImportWeights(path, other))
+}
@@ -1,9 +1,11 @@
 package config
 
 import (
-	"fmt"
+	"encoding/json"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v1"
 )
@@ -14,12 +16,18 @@ type Manifest struct {
 	Kind string `yaml:"kind"`
 	// Task is neural network task: class, [cluster, predict]
 	Task string `yaml:"task"`
+	// Seed makes weights initialization, data shuffling and noise deterministic: 0 means random seed
+	Seed int64 `yaml:"seed,omitempty"`
 	// Network provides neural network layer config and topology
 	Network struct {
+		// Precision is forward propagation precision: float64, float32
+		Precision string `yaml:"precision,omitempty"`
 		// Input layer configuration
 		Input struct {
 			// Size represents number of input neurons
 			Size int `yaml:"size"`
+			// Noise is standard deviation of Gaussian noise added to input during training
+			Noise float64 `yaml:"noise,omitempty"`
 		} `yaml:"input"`
 		// Hidden layers configuration
 		Hidden struct {
@@ -27,7 +35,13 @@ type Manifest struct {
 			Size []int `yaml:"size"`
 			// Activation is neuron activation function
 			Activation string `yaml:"activation"`
+			// Pieces is a number of affine pieces of maxout activation
+			Pieces int `yaml:"pieces,omitempty"`
+			// Noise is standard deviation of Gaussian noise added to layer input during training
+			Noise float64 `yaml:"noise,omitempty"`
 		} `yaml:"hidden,omitempty"`
+		// Layers contains configuration of every hidden layer: it can't be used along with Hidden
+		Layers []LayerManifest `yaml:"layers,omitempty"`
 		// Output layer configuration
 		Output struct {
 			// Size represents number of input neurons
@@ -40,12 +54,85 @@ type Manifest struct {
 	Training struct {
 		// Kind holds kind of neural network training
 		Kind string `yaml:"kind"`
-		// Cost allows to specify cost function: xentropy, loglike
+		// Cost allows to specify cost function: xentropy, loglike, mse, huber, hinge
 		Cost string `yaml:"cost"`
+		// Epochs is a number of passes through training data of mini-batch training
+		Epochs int `yaml:"epochs,omitempty"`
+		// Batch is mini-batch size: 0 means the whole training data
+		Batch int `yaml:"batch,omitempty"`
+		// Shuffle requests shuffling of training data at the beginning of each epoch
+		Shuffle bool `yaml:"shuffle,omitempty"`
+		// DropLast requests dropping the last incomplete mini-batch of each epoch
+		DropLast bool `yaml:"droplast,omitempty"`
+		// Snapshots is a number of epochs between network snapshots of snapshot ensemble
+		Snapshots int `yaml:"snapshots,omitempty"`
+		// Accumulate is a number of mini-batches whose gradients are accumulated before weights update
+		Accumulate int `yaml:"accumulate,omitempty"`
+		// Guard requests aborting mini-batch training when it produces NaN or Inf values
+		Guard bool `yaml:"guard,omitempty"`
+		// Rollback requests rolling back to the last checkpoint when the guard aborts the training
+		Rollback bool `yaml:"rollback,omitempty"`
+		// Verbose requests printing mini-batch training progress to standard output
+		Verbose bool `yaml:"verbose,omitempty"`
+		// Validation is a fraction of training data held out for validation
+		Validation float64 `yaml:"validation,omitempty"`
+		// Metrics are validation metrics evaluated after every epoch: cost, accuracy, f1, auc, logloss
+		Metrics []string `yaml:"metrics,omitempty"`
+		// Schedule contains learning rate schedule of mini-batch training
+		Schedule struct {
+			// Kind is a kind of learning rate schedule: step, exp, cosine, cyclic
+			Kind string `yaml:"kind,omitempty"`
+			// Every is a number of epochs between step decays or a cycle length
+			Every int `yaml:"every,omitempty"`
+			// Factor is a learning rate decay factor
+			Factor float64 `yaml:"factor,omitempty"`
+			// Min is a minimum learning rate of cosine annealing and cyclic schedule
+			Min float64 `yaml:"min,omitempty"`
+			// Warmup is a number of learning rate warmup steps
+			Warmup int `yaml:"warmup,omitempty"`
+		} `yaml:"schedule,omitempty"`
+		// EarlyStop contains early stopping configuration of mini-batch training
+		EarlyStop struct {
+			// Monitor is a monitored validation metric: cost, accuracy, f1, auc, logloss
+			Monitor string `yaml:"monitor,omitempty"`
+			// Patience is a number of epochs without improvement after which training stops
+			Patience int `yaml:"patience,omitempty"`
+			// Delta is a minimum change of the monitored metric which counts as improvement
+			Delta float64 `yaml:"delta,omitempty"`
+		} `yaml:"earlystop,omitempty"`
+		// Checkpoint contains checkpointing configuration of mini-batch training
+		Checkpoint struct {
+			// Path is a path to checkpoint file
+			Path string `yaml:"path,omitempty"`
+			// Every is a number of epochs between checkpoints
+			Every int `yaml:"every,omitempty"`
+		} `yaml:"checkpoint,omitempty"`
+		// SWA contains stochastic weight averaging configuration of mini-batch training
+		SWA struct {
+			// Start is the first averaged epoch
+			Start int `yaml:"start,omitempty"`
+			// Every is a number of epochs between averaged epochs
+			Every int `yaml:"every,omitempty"`
+		} `yaml:"swa,omitempty"`
+		// Sampling contains class-weighted sampling configuration of mini-batch training
+		Sampling struct {
+			// Balanced requests drawing all the classes with the same probability
+			Balanced bool `yaml:"balanced,omitempty"`
+			// Weights are class sampling weights ordered by class labels
+			Weights []float64 `yaml:"weights,omitempty"`
+		} `yaml:"sampling,omitempty"`
 		// Params contains parameters of neural training
 		Params struct {
 			// Lambda is regualirzation parameter
 			Lambda float64 `yaml:"lambda"`
+			// L1 is L1 regularization parameter
+			L1 float64 `yaml:"l1,omitempty"`
+			// ClassWeights are weights of the loss of particular classes
+			ClassWeights []float64 `yaml:"classweights,omitempty"`
+			// Balanced requests class weights inversely proportional to class frequencies
+			Balanced bool `yaml:"balanced,omitempty"`
+			// Smoothing is label smoothing parameter
+			Smoothing float64 `yaml:"smoothing,omitempty"`
 		} `yaml:"params"`
 		// Optimize contains configuration for training optimization
 		Optimize struct {
@@ -53,15 +140,102 @@ type Manifest struct {
 			Method string `yaml:"method"`
 			// Iterations is a number of major optimization iterations
 			Iterations int `yaml:"iterations,omitempty"`
+			// Rate is learning rate of gradient descent optimization methods
+			Rate float64 `yaml:"rate,omitempty"`
+			// Momentum is momentum of gradient descent optimization methods
+			Momentum float64 `yaml:"momentum,omitempty"`
+			// Nesterov enables Nesterov momentum
+			Nesterov bool `yaml:"nesterov,omitempty"`
+			// Beta1 is decay rate of the first moment estimates of adam
+			Beta1 float64 `yaml:"beta1,omitempty"`
+			// Beta2 is decay rate of the second moment estimates of adam
+			Beta2 float64 `yaml:"beta2,omitempty"`
+			// Epsilon is numerical stability constant of adaptive optimization methods
+			Epsilon float64 `yaml:"epsilon,omitempty"`
+			// Decay is decay rate of the squared gradients average of rmsprop
+			Decay float64 `yaml:"decay,omitempty"`
+			// WeightDecay is decoupled weight decay of adamw
+			WeightDecay float64 `yaml:"weightdecay,omitempty"`
+			// ClipNorm is maximum global norm of gradients
+			ClipNorm float64 `yaml:"clipnorm,omitempty"`
+			// ClipValue is maximum absolute value of gradient elements
+			ClipValue float64 `yaml:"clipvalue,omitempty"`
+			// Memory is a number of past updates stored by lbfgs
+			Memory int `yaml:"memory,omitempty"`
+			// Variant is conjugate gradient formula of cg: fr, pr, hs, dy, hz
+			Variant string `yaml:"variant,omitempty"`
+			// Tolerance is gradient norm at which full-batch optimization converges
+			Tolerance float64 `yaml:"tolerance,omitempty"`
+			// LineSearch contains line search parameters of full-batch optimization methods
+			LineSearch struct {
+				// Decrease is sufficient decrease (Armijo) condition factor
+				Decrease float64 `yaml:"decrease,omitempty"`
+				// Curvature is curvature (Wolfe) condition factor
+				Curvature float64 `yaml:"curvature,omitempty"`
+			} `yaml:"linesearch,omitempty"`
 		} `yaml:"optimize,omitempty"`
 	} `yaml:"training"`
+	// Search contains hyperparameter search configuration
+	Search struct {
+		// Kind is a kind of hyperparameter search: grid, random
+		Kind string `yaml:"kind,omitempty"`
+		// Trials is a number of random search trials
+		Trials int `yaml:"trials,omitempty"`
+		// Params maps names of searched parameters to their search spaces
+		Params map[string]ParamManifest `yaml:"params,omitempty"`
+	} `yaml:"search,omitempty"`
+}
+
+// ParamManifest is a data structure used to decode search space of a single hyperparameter.
+// Search space is either a range between Min and Max or a list of Values.
+type ParamManifest struct {
+	// Min is the lower bound of parameter range
+	Min float64 `yaml:"min,omitempty"`
+	// Max is the upper bound of parameter range
+	Max float64 `yaml:"max,omitempty"`
+	// Scale is parameter range scale: linear, log
+	Scale string `yaml:"scale,omitempty"`
+	// Steps is a number of grid search values of parameter range
+	Steps int `yaml:"steps,omitempty"`
+	// Values contains parameter choices
+	Values []interface{} `yaml:"values,omitempty"`
+}
+
+// LayerManifest is a data structure used to decode configuration of a single hidden layer
+type LayerManifest struct {
+	// Kind is hidden layer kind: dense, conv1d. Empty kind means dense layer
+	Kind string `yaml:"kind,omitempty"`
+	// Size is a number of layer neurons or output channels of conv1d layer
+	Size int `yaml:"size"`
+	// Activation is neuron activation function
+	Activation string `yaml:"activation"`
+	// Pieces is a number of affine pieces of maxout activation
+	Pieces int `yaml:"pieces,omitempty"`
+	// Noise is standard deviation of Gaussian noise added to layer input during training
+	Noise float64 `yaml:"noise,omitempty"`
+	// Conv contains 1D convolution configuration of conv1d layer
+	Conv struct {
+		// Width is convolution kernel width
+		Width int `yaml:"width"`
+		// Stride is a step between two consecutive kernel positions
+		Stride int `yaml:"stride"`
+		// Channels is a number of input channels
+		Channels int `yaml:"channels"`
+	} `yaml:"conv,omitempty"`
+}
+
+// decoders maps manifest file extensions to manifest decoders: other files are decoded as YAML.
+// JSON and TOML manifests use the same keys as YAML manifests.
+var decoders = map[string]func([]byte, interface{}) error{
+	".json": json.Unmarshal,
+	".toml": unmarshalTOML,
 }
 
 // network maps supported training and optimization parameters to a particular neural network
 var network = map[string]map[string][]string{
 	"feedfwd": {
 		"training": {"backprop"},
-		"optim":    {"bfgs"},
+		"optim":    {"bfgs", "lbfgs", "cg", "sgd", "adam", "adamw", "rmsprop", "adagrad"},
 	},
 }
 
@@ -69,6 +243,8 @@ var network = map[string]map[string][]string{
 type NeuronConfig struct {
 	// Activation is a neuron activation function
 	Activation string
+	// Pieces is a number of affine pieces used by maxout activation
+	Pieces int
 }
 
 // LayerConfig allows to specify neural network layer configuration
@@ -79,6 +255,21 @@ type LayerConfig struct {
 	Size int
 	// NeurFn holds neuron configuration
 	NeurFn *NeuronConfig
+	// Noise is standard deviation of Gaussian noise added to layer input during training
+	Noise float64
+	// Conv holds 1D convolution configuration. Size is then a number of output channels
+	Conv *ConvConfig
+}
+
+// ConvConfig allows to specify 1D convolution layer configuration.
+// Layer input is expected to contain Channels values per each sequence position.
+type ConvConfig struct {
+	// Width is convolution kernel width
+	Width int
+	// Stride is a step between two consecutive kernel positions
+	Stride int
+	// Channels is a number of input channels
+	Channels int
 }
 
 // NetArch specifies neural network architecture
@@ -97,15 +288,56 @@ type NetConfig struct {
 	Kind string
 	// Arch specifies network architecture
 	Arch *NetArch
+	// Precision is network forward propagation precision: float64, float32
+	Precision string
+	// Seed is a seed of weights initialization and noise: 0 keeps the default seed
+	Seed int64
 }
 
 // OptimConfig allows to specify advanced optimization configuration
 type OptimConfig struct {
 	// Method is an advanced optimization method
-	// Currently only bfgs algorithm is supported
+	// Supported methods: bfgs, lbfgs, cg, sgd, adam, adamw, rmsprop, adagrad
 	Method string
 	// Iterations specifies the number of optimization iterations
 	Iterations int
+	// LearnRate is learning rate of gradient descent optimization methods
+	LearnRate float64
+	// Momentum is momentum of gradient descent optimization methods
+	Momentum float64
+	// Nesterov enables Nesterov momentum
+	Nesterov bool
+	// Beta1 is decay rate of the first moment estimates of adam
+	Beta1 float64
+	// Beta2 is decay rate of the second moment estimates of adam
+	Beta2 float64
+	// Epsilon is numerical stability constant of adaptive optimization methods
+	Epsilon float64
+	// Decay is decay rate of the squared gradients average of rmsprop
+	Decay float64
+	// WeightDecay is decoupled weight decay of adamw
+	WeightDecay float64
+	// ClipNorm is maximum global norm of gradients: 0 disables clipping
+	ClipNorm float64
+	// ClipValue is maximum absolute value of gradient elements: 0 disables clipping
+	ClipValue float64
+	// Memory is a number of past updates stored by lbfgs: 0 means the default of 15
+	Memory int
+	// Variant is conjugate gradient formula of cg: fr, pr, hs, dy, hz.
+	// Empty variant means Polak-Ribiere formula.
+	Variant string
+	// Tolerance is gradient norm at which full-batch optimization converges: 0 means the default
+	Tolerance float64
+	// LineSearch holds line search parameters of full-batch optimization: nil means the default
+	LineSearch *LineSearchConfig
+}
+
+// LineSearchConfig allows to specify line search of full-batch optimization methods
+type LineSearchConfig struct {
+	// Decrease is sufficient decrease (Armijo) condition factor
+	Decrease float64
+	// Curvature is curvature (Wolfe) condition factor: 0 means the default of 0.9
+	Curvature float64
 }
 
 // TrainConfig allows to specify neural network training configuration
@@ -116,8 +348,99 @@ type TrainConfig struct {
 	Cost string
 	// Lambda is regularizer parameter
 	Lambda float64
+	// L1 is L1 regularization parameter: non-zero L1 and Lambda give elastic net regularization
+	L1 float64
+	// ClassWeights are weights of the loss of particular classes ordered by class labels
+	ClassWeights []float64
+	// Balanced requests class weights inversely proportional to class frequencies
+	Balanced bool
+	// LabelSmoothing is label smoothing parameter: 0 disables label smoothing
+	LabelSmoothing float64
 	// Optimize holds training optimization parameters
 	Optimize *OptimConfig
+	// Epochs is a number of passes through training data of mini-batch training
+	Epochs int
+	// BatchSize is mini-batch size: 0 means the whole training data
+	BatchSize int
+	// Shuffle requests shuffling of training data at the beginning of each epoch
+	Shuffle bool
+	// DropLast requests dropping the last incomplete mini-batch of each epoch
+	DropLast bool
+	// SnapshotEvery is a number of epochs between network snapshots of snapshot ensemble: 0 means no snapshots
+	SnapshotEvery int
+	// Accumulate is a number of mini-batches whose gradients are accumulated before weights update:
+	// 0 or 1 means the weights are updated after every mini-batch
+	Accumulate int
+	// ValidSplit is a fraction of training data held out for validation
+	ValidSplit float64
+	// Metrics are validation metrics evaluated after every epoch of mini-batch training: nil means cost and accuracy
+	Metrics []string
+	// Schedule holds learning rate schedule of mini-batch training
+	Schedule *ScheduleConfig
+	// EarlyStop holds early stopping configuration of mini-batch training
+	EarlyStop *EarlyStopConfig
+	// Checkpoint holds checkpointing configuration of mini-batch training
+	Checkpoint *CheckpointConfig
+	// SWA holds stochastic weight averaging configuration of mini-batch training
+	SWA *SWAConfig
+	// Sampling holds class-weighted sampling configuration of mini-batch training
+	Sampling *SamplingConfig
+	// Seed is a seed of data shuffling, noise and sampling: 0 means random seed
+	Seed int64
+	// Guard aborts mini-batch training with error when activations, gradients or cost are NaN or Inf
+	Guard bool
+	// Rollback restores the last checkpoint, or the initial weights, when the guard aborts the training
+	Rollback bool
+	// Verbose prints epoch metrics, early stopping and rollbacks of mini-batch training to standard output
+	Verbose bool
+}
+
+// SWAConfig allows to specify stochastic weight averaging of mini-batch training
+type SWAConfig struct {
+	// Start is the first averaged epoch
+	Start int
+	// Every is a number of epochs between averaged epochs, such as cyclic learning rate period
+	Every int
+}
+
+// SamplingConfig allows to specify drawing of mini-batch samples with replacement according to
+// class weights, which over-samples minority classes and under-samples majority classes
+type SamplingConfig struct {
+	// Weights are class sampling weights ordered by class labels: nil draws all the classes equally
+	Weights []float64
+}
+
+// CheckpointConfig allows to specify periodic checkpoints of mini-batch training
+type CheckpointConfig struct {
+	// Path is a path to checkpoint file
+	Path string
+	// Every is a number of epochs between checkpoints
+	Every int
+}
+
+// EarlyStopConfig allows to specify early stopping of mini-batch training
+type EarlyStopConfig struct {
+	// Monitor is a monitored validation metric: cost, accuracy, f1, auc, logloss
+	Monitor string
+	// Patience is a number of epochs without improvement after which training stops
+	Patience int
+	// MinDelta is a minimum change of the monitored metric which counts as improvement
+	MinDelta float64
+}
+
+// ScheduleConfig allows to specify learning rate schedule of mini-batch training
+type ScheduleConfig struct {
+	// Kind is a kind of learning rate schedule: step, exp, cosine, cyclic
+	// Empty kind keeps the learning rate constant after warmup
+	Kind string
+	// Every is a number of epochs between step decays or a cycle length of cyclic schedule
+	Every int
+	// Factor is a learning rate decay factor of step and exp schedules
+	Factor float64
+	// MinRate is a minimum learning rate of cosine and cyclic schedules
+	MinRate float64
+	// Warmup is a number of mini-batch steps during which learning rate grows linearly
+	Warmup int
 }
 
 // Config allows to specify neural network architecture and training configuration
@@ -126,12 +449,26 @@ type Config struct {
 	Network *NetConfig
 	// Training holds neural network training configuration
 	Training *TrainConfig
+	// Search holds hyperparameter search configuration: nil means no search
+	Search *SearchConfig
 }
 
 // New returns neural network config struct based on the supplied manifest file.
 // It accepts path to a config manifest file as a parameter. It returns error if the supplied
-// manifest file can't be open or if it can not be parsed into a valid configration object.
+// manifest file can't be read or if it can not be parsed into a valid configration object.
 func New(manPath string) (*Config, error) {
+	m, err := ReadManifest(manPath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseManifest(m)
+}
+
+// ReadManifest reads the manifest from the supplied manifest file, so it can be modified before
+// it is parsed by ParseManifest. Manifest files with .json extension are decoded as JSON, files
+// with .toml extension are decoded as TOML and all the other files are decoded as YAML.
+// It returns error if the file can't be open or decoded.
+func ReadManifest(manPath string) (*Manifest, error) {
 	var m Manifest
 	// Open manifest file
 	f, err := os.Open(manPath)
@@ -145,67 +482,55 @@ func New(manPath string) (*Config, error) {
 		return nil, err
 	}
 	// unmarshal the manifest data into Manifest struct
-	if err := yaml.Unmarshal(manData, &m); err != nil {
+	unmarshal, ok := decoders[strings.ToLower(filepath.Ext(manPath))]
+	if !ok {
+		unmarshal = yaml.Unmarshal
+	}
+	if err := unmarshal(manData, &m); err != nil {
 		return nil, err
 	}
-	return ParseManifest(&m)
+	return &m, nil
 }
 
-// ParseManifest parses the manifest supplied as a parameter into Config or fails with error
+// ParseManifest parses the manifest supplied as a parameter into Config. It fails with
+// ValidationError listing all the problems found by Validate if the manifest is not valid.
 func ParseManifest(m *Manifest) (*Config, error) {
-	// check if the network kind is not empty
-	if m.Kind == "" {
-		return nil, fmt.Errorf("Network kind can not be empty!\n")
-	}
-	// check if the requested network kind is supported
-	if _, ok := network[m.Kind]; !ok {
-		return nil, fmt.Errorf("Unsupported network kind: %s\n", m.Kind)
-	}
-	// parse neural network layer configuration parameters
-	netConfig, err := parseNetConfig(m)
-	if err != nil {
-		return nil, err
-	}
-	// parse trainig configuration parameters
-	trainConfig, err := parseTrainConfig(m)
-	if err != nil {
+	if err := Validate(m); err != nil {
 		return nil, err
 	}
-
 	// return new network configuration
 	return &Config{
-		Network:  netConfig,
-		Training: trainConfig,
+		Network:  parseNetConfig(m),
+		Training: parseTrainConfig(m),
+		Search:   parseSearchConfig(m),
 	}, nil
 }
 
-func parseNetConfig(m *Manifest) (*NetConfig, error) {
+func parseNetConfig(m *Manifest) *NetConfig {
 	// INPUT layer configuration
-	if m.Network.Input.Size <= 0 {
-		return nil, fmt.Errorf("Incorrect input layer size: %d\n", m.Network.Input.Size)
+	inputLayer := &LayerConfig{
+		Kind:  "input",
+		Size:  m.Network.Input.Size,
+		Noise: m.Network.Input.Noise,
 	}
-	inputLayer := &LayerConfig{Kind: "input", Size: m.Network.Input.Size}
 	// HIDDEN network layer configuration
 	var hiddenLayers []*LayerConfig
-	if len(m.Network.Hidden.Size) != 0 {
-		hiddenLayers = make([]*LayerConfig, len(m.Network.Hidden.Size))
-		for i, size := range m.Network.Hidden.Size {
-			if size <= 0 {
-				return nil, fmt.Errorf("Incorrect hidden layer size: %d\n", size)
-			}
-			hiddenLayers[i] = &LayerConfig{
-				Kind: "hidden",
-				Size: size,
-				NeurFn: &NeuronConfig{
-					Activation: m.Network.Hidden.Activation,
-				},
-			}
-		}
+	for _, size := range m.Network.Hidden.Size {
+		hiddenLayers = append(hiddenLayers, &LayerConfig{
+			Kind: "hidden",
+			Size: size,
+			NeurFn: &NeuronConfig{
+				Activation: m.Network.Hidden.Activation,
+				Pieces:     m.Network.Hidden.Pieces,
+			},
+			Noise: m.Network.Hidden.Noise,
+		})
 	}
-	// OUTPUT layer configuration
-	if m.Network.Output.Size <= 0 {
-		return nil, fmt.Errorf("Incorrect output layer size: %d\n", m.Network.Output.Size)
+	// per-layer HIDDEN network layer configuration
+	for i := range m.Network.Layers {
+		hiddenLayers = append(hiddenLayers, parseLayerManifest(&m.Network.Layers[i]))
 	}
+	// OUTPUT layer configuration
 	outputLayer := &LayerConfig{
 		Kind: "output",
 		Size: m.Network.Output.Size,
@@ -221,78 +546,180 @@ func parseNetConfig(m *Manifest) (*NetConfig, error) {
 			Hidden: hiddenLayers,
 			Output: outputLayer,
 		},
-	}, nil
+		Precision: m.Network.Precision,
+		Seed:      m.Seed,
+	}
 }
 
-func parseOptimConfig(m *Manifest) (*OptimConfig, error) {
-	// optimize Method can't be empty
-	if m.Training.Optimize.Method == "" {
-		return nil, fmt.Errorf("Optimize method can not be empty!\n")
+func parseLayerManifest(l *LayerManifest) *LayerConfig {
+	layer := &LayerConfig{
+		Kind: "hidden",
+		Size: l.Size,
+		NeurFn: &NeuronConfig{
+			Activation: l.Activation,
+			Pieces:     l.Pieces,
+		},
+		Noise: l.Noise,
 	}
-	// check if the optimization method is supported
-	var validOptim bool
-	for _, optimizeMethod := range network[m.Kind]["optim"] {
-		if optimizeMethod == m.Training.Optimize.Method {
-			validOptim = true
-			break
+	if l.Kind == "conv1d" {
+		layer.Conv = &ConvConfig{
+			Width:    l.Conv.Width,
+			Stride:   l.Conv.Stride,
+			Channels: l.Conv.Channels,
 		}
 	}
-	if !validOptim {
-		return nil, fmt.Errorf("Unsupported optimization method: %s\n",
-			m.Training.Optimize.Method)
-	}
-	// check number of iterations
-	var iters int
-	if m.Training.Optimize.Iterations <= 0 {
+	return layer
+}
+
+// variants contains supported conjugate gradient formulas
+var variants = []string{"fr", "pr", "hs", "dy", "hz"}
+
+func parseOptimConfig(m *Manifest) *OptimConfig {
+	// default number of iterations
+	iters := m.Training.Optimize.Iterations
+	if iters <= 0 {
 		iters = 20
-	} else {
-		iters = m.Training.Optimize.Iterations
+	}
+	// zero line search parameters mean the default line search
+	var lineSearch *LineSearchConfig
+	ls := m.Training.Optimize.LineSearch
+	if ls.Decrease != 0 || ls.Curvature != 0 {
+		lineSearch = &LineSearchConfig{
+			Decrease:  ls.Decrease,
+			Curvature: ls.Curvature,
+		}
 	}
 
 	return &OptimConfig{
-		Method:     m.Training.Optimize.Method,
-		Iterations: iters,
-	}, nil
+		Method:      m.Training.Optimize.Method,
+		Iterations:  iters,
+		LearnRate:   m.Training.Optimize.Rate,
+		Momentum:    m.Training.Optimize.Momentum,
+		Nesterov:    m.Training.Optimize.Nesterov,
+		Beta1:       m.Training.Optimize.Beta1,
+		Beta2:       m.Training.Optimize.Beta2,
+		Epsilon:     m.Training.Optimize.Epsilon,
+		Decay:       m.Training.Optimize.Decay,
+		WeightDecay: m.Training.Optimize.WeightDecay,
+		ClipNorm:    m.Training.Optimize.ClipNorm,
+		ClipValue:   m.Training.Optimize.ClipValue,
+		Memory:      m.Training.Optimize.Memory,
+		Variant:     m.Training.Optimize.Variant,
+		Tolerance:   m.Training.Optimize.Tolerance,
+		LineSearch:  lineSearch,
+	}
 }
 
-func parseTrainConfig(m *Manifest) (*TrainConfig, error) {
-	// training kind can't be empty
-	if m.Training.Kind == "" {
-		return nil, fmt.Errorf("Training kind can not be empty!\n")
+// schedules contains supported learning rate schedules
+var schedules = []string{"step", "exp", "cosine", "cyclic"}
+
+func parseScheduleConfig(m *Manifest) *ScheduleConfig {
+	sched := m.Training.Schedule
+	// no schedule requested
+	if sched.Kind == "" && sched.Warmup == 0 {
+		return nil
 	}
-	// check if the requested training algorithm is supported
-	var validTraining bool
-	for _, trainingKind := range network[m.Kind]["training"] {
-		if trainingKind == m.Training.Kind {
-			validTraining = true
-			break
-		}
+	return &ScheduleConfig{
+		Kind:    sched.Kind,
+		Every:   sched.Every,
+		Factor:  sched.Factor,
+		MinRate: sched.Min,
+		Warmup:  sched.Warmup,
 	}
-	if !validTraining {
-		return nil, fmt.Errorf("Unsupported training requested: %s\n", m.Training.Kind)
+}
+
+// metrics contains validation metrics which can be evaluated after every epoch
+var metrics = []string{"cost", "accuracy", "f1", "auc", "logloss"}
+
+// monitors contains validation metrics which can be monitored by early stopping
+var monitors = metrics
+
+func parseEarlyStopConfig(m *Manifest) *EarlyStopConfig {
+	stop := m.Training.EarlyStop
+	// no early stopping requested
+	if stop.Monitor == "" && stop.Patience == 0 {
+		return nil
+	}
+	monitor := stop.Monitor
+	if monitor == "" {
+		monitor = "cost"
 	}
+	return &EarlyStopConfig{
+		Monitor:  monitor,
+		Patience: stop.Patience,
+		MinDelta: stop.Delta,
+	}
+}
 
-	// check training cost function
-	if m.Training.Cost == "" {
-		return nil, fmt.Errorf("Cost function can not be empty!\n")
+func parseCheckpointConfig(m *Manifest) *CheckpointConfig {
+	cp := m.Training.Checkpoint
+	// no checkpointing requested
+	if cp.Path == "" && cp.Every == 0 {
+		return nil
 	}
+	every := cp.Every
+	if every == 0 {
+		every = 1
+	}
+	return &CheckpointConfig{
+		Path:  cp.Path,
+		Every: every,
+	}
+}
 
-	// check lambda parameter
-	if m.Training.Params.Lambda < 0 {
-		return nil, fmt.Errorf("Incorrect reg parameter: %f\n", m.Training.Params.Lambda)
+func parseSWAConfig(m *Manifest) *SWAConfig {
+	swa := m.Training.SWA
+	// no weight averaging requested
+	if swa.Start == 0 && swa.Every == 0 {
+		return nil
+	}
+	every := swa.Every
+	if every == 0 {
+		every = 1
 	}
+	return &SWAConfig{
+		Start: swa.Start,
+		Every: every,
+	}
+}
 
-	// parse optimization config
-	optimize, err := parseOptimConfig(m)
-	if err != nil {
-		return nil, err
+func parseSamplingConfig(m *Manifest) *SamplingConfig {
+	sampling := m.Training.Sampling
+	// no weighted sampling requested
+	if !sampling.Balanced && len(sampling.Weights) == 0 {
+		return nil
 	}
+	return &SamplingConfig{
+		Weights: sampling.Weights,
+	}
+}
 
-	// return train config
+func parseTrainConfig(m *Manifest) *TrainConfig {
 	return &TrainConfig{
-		Kind:     m.Training.Kind,
-		Cost:     m.Training.Cost,
-		Lambda:   m.Training.Params.Lambda,
-		Optimize: optimize,
-	}, nil
+		Kind:           m.Training.Kind,
+		Cost:           m.Training.Cost,
+		Lambda:         m.Training.Params.Lambda,
+		L1:             m.Training.Params.L1,
+		ClassWeights:   m.Training.Params.ClassWeights,
+		Balanced:       m.Training.Params.Balanced,
+		LabelSmoothing: m.Training.Params.Smoothing,
+		Optimize:       parseOptimConfig(m),
+		Epochs:         m.Training.Epochs,
+		BatchSize:      m.Training.Batch,
+		Shuffle:        m.Training.Shuffle,
+		DropLast:       m.Training.DropLast,
+		SnapshotEvery:  m.Training.Snapshots,
+		Accumulate:     m.Training.Accumulate,
+		ValidSplit:     m.Training.Validation,
+		Metrics:        m.Training.Metrics,
+		Schedule:       parseScheduleConfig(m),
+		EarlyStop:      parseEarlyStopConfig(m),
+		Checkpoint:     parseCheckpointConfig(m),
+		SWA:            parseSWAConfig(m),
+		Sampling:       parseSamplingConfig(m),
+		Seed:           m.Seed,
+		Guard:          m.Training.Guard,
+		Rollback:       m.Training.Rollback,
+		Verbose:        m.Training.Verbose,
+	}
 }
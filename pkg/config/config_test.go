@@ -21,6 +21,7 @@ func setup() {
 	content := []byte(`kind: feedfwd
 task: class
 network:
+  precision: float32
   input:
     size: 400
   hidden:
@@ -68,6 +69,7 @@ func TestNewConfig(t *testing.T) {
 	assert.NoError(err)
 	// test if the parsed parameters are correct
 	assert.Equal(c.Network.Kind, "feedfwd")
+	assert.Equal(c.Network.Precision, "float32")
 	assert.Equal(c.Network.Arch.Input.Kind, "input")
 	assert.Equal(c.Network.Arch.Input.Size, 400)
 	assert.Equal(c.Network.Arch.Input.NeurFn, (*NeuronConfig)(nil))
@@ -162,6 +164,93 @@ func TestParseNetConfig(t *testing.T) {
 	assert.Nil(c)
 	assert.Error(err)
 	m.Network.Output.Size = origOutSize
+	// per-layer hidden configuration can't be used along with hidden
+	m.Network.Layers = []LayerManifest{{Size: 8, Activation: "relu"}}
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Network.Hidden.Size = nil
+	c, err = ParseManifest(&m)
+	assert.NoError(err)
+	assert.Len(c.Network.Arch.Hidden, 1)
+	assert.Equal(&LayerConfig{Kind: "hidden", Size: 8, NeurFn: &NeuronConfig{Activation: "relu"}},
+		c.Network.Arch.Hidden[0])
+	// incorrect per-layer configuration
+	m.Network.Layers[0].Size = 0
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Network.Layers[0].Size = 8
+	m.Network.Layers[0].Noise = -1.0
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Network.Layers[0].Noise = 0.0
+	m.Network.Layers[0].Kind = "foobar"
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Network.Layers[0].Kind = "conv1d"
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Network.Layers[0].Conv.Width = 3
+	m.Network.Layers[0].Conv.Stride = 1
+	m.Network.Layers[0].Conv.Channels = 4
+	c, err = ParseManifest(&m)
+	assert.NoError(err)
+	assert.Equal(&ConvConfig{Width: 3, Stride: 1, Channels: 4}, c.Network.Arch.Hidden[0].Conv)
+}
+
+func TestNewConfigJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	content := []byte(`{
+	"kind": "feedfwd",
+	"network": {
+		"input": {"size": 16},
+		"layers": [
+			{"kind": "conv1d", "size": 2, "activation": "relu", "conv": {"width": 3, "stride": 1, "channels": 4}},
+			{"size": 5, "activation": "maxout", "pieces": 2, "noise": 0.1}
+		],
+		"output": {"size": 3, "activation": "softmax"}
+	},
+	"training": {
+		"kind": "backprop",
+		"cost": "xentropy",
+		"epochs": 10,
+		"params": {"lambda": 0.5},
+		"optimize": {"method": "adam", "rate": 0.01, "weightdecay": 0.1}
+	}
+}`)
+	tmpPath := filepath.Join(os.TempDir(), "manifest.json")
+	assert.NoError(ioutil.WriteFile(tmpPath, content, 0666))
+	defer os.Remove(tmpPath)
+	c, err := New(tmpPath)
+	assert.NoError(err)
+	assert.Equal("feedfwd", c.Network.Kind)
+	assert.Equal(16, c.Network.Arch.Input.Size)
+	assert.Len(c.Network.Arch.Hidden, 2)
+	assert.Equal(&ConvConfig{Width: 3, Stride: 1, Channels: 4}, c.Network.Arch.Hidden[0].Conv)
+	assert.Equal(&NeuronConfig{Activation: "maxout", Pieces: 2}, c.Network.Arch.Hidden[1].NeurFn)
+	assert.Equal(0.1, c.Network.Arch.Hidden[1].Noise)
+	assert.Nil(c.Network.Arch.Hidden[1].Conv)
+	assert.Equal(3, c.Network.Arch.Output.Size)
+	assert.Equal(10, c.Training.Epochs)
+	assert.Equal(0.5, c.Training.Lambda)
+	assert.Equal("adam", c.Training.Optimize.Method)
+	assert.Equal(0.01, c.Training.Optimize.LearnRate)
+	assert.Equal(0.1, c.Training.Optimize.WeightDecay)
+	// YAML manifest with json extension
+	tmpPath = path.Join(os.TempDir(), fileName)
+	data, err := ioutil.ReadFile(tmpPath)
+	assert.NoError(err)
+	jsonPath := filepath.Join(os.TempDir(), "yaml.json")
+	assert.NoError(ioutil.WriteFile(jsonPath, data, 0666))
+	defer os.Remove(jsonPath)
+	c, err = New(jsonPath)
+	assert.Nil(c)
+	assert.Error(err)
 }
 
 func TestParseOptimize(t *testing.T) {
@@ -192,6 +281,116 @@ func TestParseOptimize(t *testing.T) {
 	assert.Nil(c)
 	assert.Error(err)
 	m.Training.Optimize.Method = origOptimMethod
+	// incorrect learning rate
+	m.Training.Optimize.Rate = -0.1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.Rate = 0.1
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(c.Training.Optimize.LearnRate, 0.1)
+	// incorrect momentum
+	m.Training.Optimize.Momentum = 1.0
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.Momentum = 0.9
+	m.Training.Optimize.Nesterov = true
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(c.Training.Optimize.Momentum, 0.9)
+	assert.True(c.Training.Optimize.Nesterov)
+	// incorrect decay rates and epsilon
+	m.Training.Optimize.Beta2 = 1.0
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.Beta2 = 0.99
+	m.Training.Optimize.Epsilon = -1.0
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.Epsilon = 1e-6
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(c.Training.Optimize.Beta2, 0.99)
+	assert.Equal(c.Training.Optimize.Epsilon, 1e-6)
+	// incorrect decay rate
+	m.Training.Optimize.Decay = 1.0
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.Decay = 0.95
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(c.Training.Optimize.Decay, 0.95)
+	// incorrect weight decay
+	m.Training.Optimize.WeightDecay = -0.1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.WeightDecay = 0.01
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(c.Training.Optimize.WeightDecay, 0.01)
+	// incorrect gradient clipping
+	m.Training.Optimize.ClipNorm = -1.0
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.ClipNorm = 5.0
+	m.Training.Optimize.ClipValue = -1.0
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.ClipValue = 0.5
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(c.Training.Optimize.ClipNorm, 5.0)
+	assert.Equal(c.Training.Optimize.ClipValue, 0.5)
+	// lbfgs memory
+	m.Training.Optimize.Method = "lbfgs"
+	m.Training.Optimize.Memory = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.Memory = 10
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(c.Training.Optimize.Method, "lbfgs")
+	assert.Equal(c.Training.Optimize.Memory, 10)
+	// conjugate gradient
+	m.Training.Optimize.Method = "cg"
+	m.Training.Optimize.Variant = "foobar"
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.Variant = "hz"
+	m.Training.Optimize.Tolerance = -1.0
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.Tolerance = 1e-5
+	m.Training.Optimize.LineSearch.Decrease = 0.95
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.LineSearch.Decrease = 1e-4
+	m.Training.Optimize.LineSearch.Curvature = 0.1
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(c.Training.Optimize.Variant, "hz")
+	assert.Equal(c.Training.Optimize.Tolerance, 1e-5)
+	assert.Equal(c.Training.Optimize.LineSearch, &LineSearchConfig{Decrease: 1e-4, Curvature: 0.1})
 }
 
 func TestParseTraining(t *testing.T) {
@@ -243,6 +442,199 @@ func TestParseTraining(t *testing.T) {
 	assert.Nil(c)
 	assert.Error(err)
 	m.Training.Params.Lambda = origLambda
+	// incorrect L1 parameter
+	m.Training.Params.L1 = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Params.L1 = 0.5
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(c.Training.L1, 0.5)
+	// incorrect class weights
+	m.Training.Params.ClassWeights = []float64{1.0, -1.0}
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Params.ClassWeights = []float64{1.0, 2.0}
+	m.Training.Params.Balanced = true
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Params.Balanced = false
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(c.Training.ClassWeights, []float64{1.0, 2.0})
+	m.Training.Params.ClassWeights = nil
+	// incorrect label smoothing
+	m.Training.Params.Smoothing = 1.0
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Params.Smoothing = 0.1
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(c.Training.LabelSmoothing, 0.1)
+	// incorrect mini-batch parameters
+	m.Training.Epochs = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Epochs = 10
+	m.Training.Batch = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Batch = 32
+	m.Training.Validation = 1.0
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Validation = 0.2
+	m.Training.Snapshots = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Snapshots = 2
+	m.Training.Accumulate = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Accumulate = 4
+	m.Training.Shuffle = true
+	m.Training.DropLast = true
+	m.Training.Verbose = true
+	m.Seed = 7
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(c.Training.Epochs, 10)
+	assert.Equal(c.Training.BatchSize, 32)
+	assert.Equal(c.Training.ValidSplit, 0.2)
+	assert.True(c.Training.Shuffle)
+	assert.True(c.Training.DropLast)
+	assert.True(c.Training.Verbose)
+	assert.Equal(c.Training.Accumulate, 4)
+	assert.Equal(c.Training.SnapshotEvery, 2)
+	assert.Equal(c.Training.Seed, int64(7))
+	assert.Equal(c.Network.Seed, int64(7))
+	assert.Nil(c.Training.Schedule)
+	// incorrect learning rate schedule
+	m.Training.Schedule.Kind = "foobar"
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Schedule.Kind = "step"
+	m.Training.Schedule.Every = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Schedule.Every = 5
+	m.Training.Schedule.Factor = -0.5
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Schedule.Factor = 0.5
+	m.Training.Schedule.Warmup = 10
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(c.Training.Schedule, &ScheduleConfig{Kind: "step", Every: 5, Factor: 0.5, Warmup: 10})
+	m.Training.Schedule.Kind = "cyclic"
+	m.Training.Schedule.Min = 0.01
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(c.Training.Schedule.Kind, "cyclic")
+	m.Training.Schedule.Kind = "step"
+	m.Training.Schedule.Min = 0.0
+	assert.Nil(c.Training.EarlyStop)
+	// incorrect early stopping
+	m.Training.EarlyStop.Monitor = "foobar"
+	m.Training.EarlyStop.Patience = 3
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.EarlyStop.Monitor = ""
+	m.Training.EarlyStop.Patience = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.EarlyStop.Patience = 3
+	m.Training.EarlyStop.Delta = -0.1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.EarlyStop.Delta = 0.01
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(c.Training.EarlyStop, &EarlyStopConfig{Monitor: "cost", Patience: 3, MinDelta: 0.01})
+	assert.Nil(c.Training.Checkpoint)
+	// incorrect checkpointing
+	m.Training.Checkpoint.Every = 5
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Checkpoint.Path = "train.ckpt"
+	m.Training.Checkpoint.Every = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Checkpoint.Every = 0
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(c.Training.Checkpoint, &CheckpointConfig{Path: "train.ckpt", Every: 1})
+	assert.Nil(c.Training.SWA)
+	// incorrect weight averaging
+	m.Training.SWA.Start = 100
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.SWA.Start = 5
+	m.Training.SWA.Every = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.SWA.Every = 0
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.EarlyStop.Patience = 0
+	m.Training.EarlyStop.Monitor = ""
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(c.Training.SWA, &SWAConfig{Start: 5, Every: 1})
+	assert.Nil(c.Training.Sampling)
+	// incorrect class-weighted sampling
+	m.Training.Sampling.Balanced = true
+	m.Training.Sampling.Weights = []float64{1.0, 2.0}
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Sampling.Balanced = false
+	m.Training.Sampling.Weights = []float64{1.0, -2.0}
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Sampling.Weights = []float64{0.0, 0.0}
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Sampling.Weights = []float64{1.0, 2.0}
+	c, err = ParseManifest(&m)
+	assert.NoError(err)
+	assert.Equal(&SamplingConfig{Weights: []float64{1.0, 2.0}}, c.Training.Sampling)
+	m.Training.Sampling.Balanced = true
+	m.Training.Sampling.Weights = nil
+	c, err = ParseManifest(&m)
+	assert.NoError(err)
+	assert.Equal(&SamplingConfig{}, c.Training.Sampling)
 	// correct parameters
 	c, err = ParseManifest(&m)
 	assert.NotNil(c)
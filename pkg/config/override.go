@@ -0,0 +1,161 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix is the prefix of environment variables which override manifest parameters
+const EnvPrefix = "NEURAL_"
+
+// params maps names of the manifest parameters which can be overridden to the manifest fields
+func params(m *Manifest) map[string]interface{} {
+	return map[string]interface{}{
+		"seed":              &m.Seed,
+		"precision":         &m.Network.Precision,
+		"input_size":        &m.Network.Input.Size,
+		"hidden_size":       &m.Network.Hidden.Size,
+		"hidden_activation": &m.Network.Hidden.Activation,
+		"output_size":       &m.Network.Output.Size,
+		"output_activation": &m.Network.Output.Activation,
+		"cost":              &m.Training.Cost,
+		"epochs":            &m.Training.Epochs,
+		"batch_size":        &m.Training.Batch,
+		"shuffle":           &m.Training.Shuffle,
+		"validation":        &m.Training.Validation,
+		"verbose":           &m.Training.Verbose,
+		"patience":          &m.Training.EarlyStop.Patience,
+		"lambda":            &m.Training.Params.Lambda,
+		"l1":                &m.Training.Params.L1,
+		"optimizer":         &m.Training.Optimize.Method,
+		"iterations":        &m.Training.Optimize.Iterations,
+		"learning_rate":     &m.Training.Optimize.Rate,
+		"momentum":          &m.Training.Optimize.Momentum,
+		"weight_decay":      &m.Training.Optimize.WeightDecay,
+	}
+}
+
+// Overrides maps names of manifest parameters to the values which override them, so the same
+// manifest can drive parameter sweeps from scripts. Parameter names are listed by OverrideNames.
+type Overrides map[string]string
+
+// OverrideNames returns sorted names of all the manifest parameters which can be overridden
+func OverrideNames() []string {
+	var names []string
+	for name := range params(&Manifest{}) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EnvOverrides returns overrides read from environment variables whose names are EnvPrefix
+// followed by upper case parameter name, such as NEURAL_LEARNING_RATE=0.01.
+func EnvOverrides() Overrides {
+	o := make(Overrides)
+	for _, env := range os.Environ() {
+		kv := strings.SplitN(env, "=", 2)
+		if len(kv) == 2 && strings.HasPrefix(kv[0], EnvPrefix) {
+			o[strings.ToLower(strings.TrimPrefix(kv[0], EnvPrefix))] = kv[1]
+		}
+	}
+	return o
+}
+
+// overrideFlag is a flag which stores its value in overrides
+type overrideFlag struct {
+	o    Overrides
+	name string
+}
+
+// String implements flag.Value interface
+func (f *overrideFlag) String() string {
+	if f.o == nil {
+		return ""
+	}
+	return f.o[f.name]
+}
+
+// Set implements flag.Value interface
+func (f *overrideFlag) Set(value string) error {
+	f.o[f.name] = value
+	return nil
+}
+
+// Flags defines a flag in the supplied flag set for every manifest parameter which can be overridden.
+// Flag names are parameter names with dashes instead of underscores, such as -learning-rate.
+// Values of the flags which are set on the command line are stored in o.
+func (o Overrides) Flags(fs *flag.FlagSet) {
+	for _, name := range OverrideNames() {
+		fs.Var(&overrideFlag{o: o, name: name}, strings.Replace(name, "_", "-", -1),
+			fmt.Sprintf("Override manifest parameter %s", name))
+	}
+}
+
+// Apply sets the manifest parameters to the override values. Lists such as hidden_size are
+// comma separated. It fails with error if any parameter is unknown or if its value can't be parsed.
+func (o Overrides) Apply(m *Manifest) error {
+	fields := params(m)
+	names := make([]string, 0, len(o))
+	for name := range o {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		field, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("Unknown manifest parameter: %s\n", name)
+		}
+		if err := setParam(field, strings.TrimSpace(o[name])); err != nil {
+			return fmt.Errorf("Incorrect value of %s: %v\n", name, err)
+		}
+	}
+	return nil
+}
+
+// setParam parses the supplied value into the manifest field. The field is not modified on error.
+func setParam(field interface{}, value string) error {
+	switch f := field.(type) {
+	case *string:
+		*f = value
+	case *int:
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*f = v
+	case *int64:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		*f = v
+	case *float64:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		*f = v
+	case *bool:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*f = v
+	case *[]int:
+		var list []int
+		for _, s := range strings.Split(value, ",") {
+			v, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				return err
+			}
+			list = append(list, v)
+		}
+		*f = list
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverridesApply(t *testing.T) {
+	assert := assert.New(t)
+	m := validManifest()
+	m.Network.Layers = nil
+	o := Overrides{
+		"learning_rate":     "0.05",
+		"hidden_size":       "32, 16",
+		"hidden_activation": "tanh",
+		"epochs":            "7",
+		"seed":              "42",
+		"shuffle":           "true",
+		"verbose":           "true",
+		"optimizer":         "sgd",
+	}
+	assert.NoError(o.Apply(m))
+	assert.Equal(0.05, m.Training.Optimize.Rate)
+	assert.Equal([]int{32, 16}, m.Network.Hidden.Size)
+	assert.Equal("tanh", m.Network.Hidden.Activation)
+	assert.Equal(7, m.Training.Epochs)
+	assert.Equal(int64(42), m.Seed)
+	assert.True(m.Training.Shuffle)
+	assert.True(m.Training.Verbose)
+	assert.Equal("sgd", m.Training.Optimize.Method)
+	c, err := ParseManifest(m)
+	assert.NoError(err)
+	assert.Equal(0.05, c.Training.Optimize.LearnRate)
+	// unknown parameter
+	assert.Error(Overrides{"foobar": "1"}.Apply(m))
+	// incorrect values don't modify the manifest
+	for _, name := range []string{"epochs", "learning_rate", "hidden_size", "shuffle", "seed"} {
+		assert.Error(Overrides{name: "foo"}.Apply(m), "%s", name)
+	}
+	assert.Equal(0.05, m.Training.Optimize.Rate)
+	assert.Equal([]int{32, 16}, m.Network.Hidden.Size)
+	assert.Equal(7, m.Training.Epochs)
+	// every parameter can be overridden
+	fields := params(m)
+	for _, name := range OverrideNames() {
+		assert.NotNil(fields[name], "%s", name)
+	}
+}
+
+func TestEnvOverrides(t *testing.T) {
+	assert := assert.New(t)
+	os.Setenv("NEURAL_LEARNING_RATE", "0.01")
+	os.Setenv("NEURAL_BATCH_SIZE", "128")
+	defer os.Unsetenv("NEURAL_LEARNING_RATE")
+	defer os.Unsetenv("NEURAL_BATCH_SIZE")
+	o := EnvOverrides()
+	assert.Equal("0.01", o["learning_rate"])
+	assert.Equal("128", o["batch_size"])
+	m := validManifest()
+	assert.NoError(o.Apply(m))
+	assert.Equal(0.01, m.Training.Optimize.Rate)
+	assert.Equal(128, m.Training.Batch)
+}
+
+func TestOverridesFlags(t *testing.T) {
+	assert := assert.New(t)
+	o := make(Overrides)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	o.Flags(fs)
+	assert.NoError(fs.Parse([]string{"-learning-rate", "0.2", "-hidden-size=8,8"}))
+	assert.Equal(Overrides{"learning_rate": "0.2", "hidden_size": "8,8"}, o)
+	m := validManifest()
+	m.Network.Layers = nil
+	assert.NoError(o.Apply(m))
+	assert.Equal(0.2, m.Training.Optimize.Rate)
+	assert.Equal([]int{8, 8}, m.Network.Hidden.Size)
+	// unknown flag
+	assert.Error(fs.Parse([]string{"-foobar", "1"}))
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v1"
+)
+
+// presets maps names of preset architectures to their manifests
+var presets = map[string]string{
+	// small classifier of a few features into a few classes such as iris data set
+	"mlp-small": `kind: feedfwd
+task: class
+network:
+  input:
+    size: 4
+  hidden:
+    size: [16]
+    activation: relu
+  output:
+    size: 3
+    activation: softmax
+training:
+  kind: backprop
+  cost: xentropy
+  epochs: 100
+  batch: 16
+  shuffle: true
+  params:
+    lambda: 0.001
+  optimize:
+    method: adam
+    rate: 0.01
+`,
+	// classifier of 20x20 pixel MNIST digits such as testdata/data.csv
+	"mnist-classifier": `kind: feedfwd
+task: class
+network:
+  input:
+    size: 400
+  hidden:
+    size: [128, 64]
+    activation: relu
+  output:
+    size: 10
+    activation: softmax
+training:
+  kind: backprop
+  cost: xentropy
+  epochs: 20
+  batch: 64
+  shuffle: true
+  validation: 0.1
+  earlystop:
+    monitor: accuracy
+    patience: 3
+  params:
+    lambda: 0.0001
+  optimize:
+    method: adam
+    rate: 0.001
+`,
+	// autoencoder of 20x20 pixel images with 32 neurons code layer
+	"autoencoder-32": `kind: feedfwd
+task: predict
+network:
+  input:
+    size: 400
+  hidden:
+    size: [128, 32, 128]
+    activation: relu
+  output:
+    size: 400
+    activation: sigmoid
+training:
+  kind: backprop
+  cost: mse
+  epochs: 50
+  batch: 64
+  shuffle: true
+  params:
+    lambda: 0.0
+  optimize:
+    method: adam
+    rate: 0.001
+`,
+}
+
+// Presets returns sorted names of all preset architectures
+func Presets() []string {
+	var names []string
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Preset returns fully populated config of the preset architecture with the supplied name, which
+// can be tweaked before the network is created. The following presets are available:
+//
+//	mlp-small        - 4 inputs, 16 relu neurons, 3 classes trained by adam
+//	mnist-classifier - 20x20 pixel digits, 128 and 64 relu neurons, 10 classes with early stopping
+//	autoencoder-32   - 20x20 pixel images encoded into 32 relu neurons and decoded by mse
+//
+// The autoencoder is meant to be trained to reconstruct its input using ForwardProp and BackProp
+// of the network, as Train fits class labels. Every call returns a new config, so modifying it does not affect the presets. It returns
+// error if the preset does not exist.
+func Preset(name string) (*Config, error) {
+	man, ok := presets[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown preset: %s\n", name)
+	}
+	var m Manifest
+	if err := yaml.Unmarshal([]byte(man), &m); err != nil {
+		return nil, err
+	}
+	return ParseManifest(&m)
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreset(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal([]string{"autoencoder-32", "mlp-small", "mnist-classifier"}, Presets())
+	// all presets are valid
+	for _, name := range Presets() {
+		c, err := Preset(name)
+		assert.NoError(err, "%s", name)
+		assert.NotNil(c.Network.Arch.Input)
+		assert.NotNil(c.Network.Arch.Output)
+		assert.NotNil(c.Training.Optimize)
+	}
+	c, err := Preset("mnist-classifier")
+	assert.NoError(err)
+	assert.Equal(400, c.Network.Arch.Input.Size)
+	assert.Len(c.Network.Arch.Hidden, 2)
+	assert.Equal(10, c.Network.Arch.Output.Size)
+	assert.Equal("adam", c.Training.Optimize.Method)
+	assert.Equal(&EarlyStopConfig{Monitor: "accuracy", Patience: 3}, c.Training.EarlyStop)
+	c, err = Preset("autoencoder-32")
+	assert.NoError(err)
+	assert.Equal(32, c.Network.Arch.Hidden[1].Size)
+	assert.Equal(c.Network.Arch.Input.Size, c.Network.Arch.Output.Size)
+	// tweaking config does not modify the preset
+	c, err = Preset("mlp-small")
+	assert.NoError(err)
+	c.Network.Arch.Input.Size = 10
+	c.Training.Optimize.LearnRate = 0.1
+	c, err = Preset("mlp-small")
+	assert.NoError(err)
+	assert.Equal(4, c.Network.Arch.Input.Size)
+	assert.Equal(0.01, c.Training.Optimize.LearnRate)
+	// unknown preset
+	c, err = Preset("foobar")
+	assert.Nil(c)
+	assert.Error(err)
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// searches contains supported kinds of hyperparameter search
+var searches = []string{"grid", "random"}
+
+// scales contains supported scales of parameter ranges
+var scales = []string{"", "linear", "log"}
+
+// SearchConfig allows to specify hyperparameter search. Every search trial overrides the searched
+// manifest parameters, which are named the same way as by Overrides, with different values.
+type SearchConfig struct {
+	// Kind is a kind of hyperparameter search: grid, random
+	Kind string
+	// Trials is a number of random search trials
+	Trials int
+	// Params contains search spaces of the searched parameters sorted by name
+	Params []*ParamSpace
+	// Seed is a seed of random search: 0 means random seed
+	Seed int64
+}
+
+// ParamSpace allows to specify search space of a single hyperparameter
+type ParamSpace struct {
+	// Name is the name of the searched manifest parameter
+	Name string
+	// Min is the lower bound of parameter range
+	Min float64
+	// Max is the upper bound of parameter range
+	Max float64
+	// Log requests logarithmic scale of parameter range
+	Log bool
+	// Steps is a number of grid search values of parameter range
+	Steps int
+	// Values contains parameter choices: nil means parameter range
+	Values []string
+}
+
+// Trial is a single trial of hyperparameter search
+type Trial struct {
+	// Params contains the values of the searched parameters
+	Params Overrides
+	// Config is the configuration of the trial
+	Config *Config
+}
+
+func parseSearchConfig(m *Manifest) *SearchConfig {
+	if len(m.Search.Params) == 0 {
+		return nil
+	}
+	s := &SearchConfig{
+		Kind:   m.Search.Kind,
+		Trials: m.Search.Trials,
+		Seed:   m.Seed,
+	}
+	for _, name := range searchNames(m) {
+		p := m.Search.Params[name]
+		s.Params = append(s.Params, &ParamSpace{
+			Name:   name,
+			Min:    p.Min,
+			Max:    p.Max,
+			Log:    p.Scale == "log",
+			Steps:  p.Steps,
+			Values: paramValues(p.Values),
+		})
+	}
+	return s
+}
+
+// searchNames returns sorted names of the searched parameters of the supplied manifest
+func searchNames(m *Manifest) []string {
+	var names []string
+	for name := range m.Search.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// paramValues converts decoded parameter choices to override values. Lists are comma separated.
+func paramValues(values []interface{}) []string {
+	if values == nil {
+		return nil
+	}
+	vals := make([]string, len(values))
+	for i, v := range values {
+		if list, ok := v.([]interface{}); ok {
+			vals[i] = strings.Join(paramValues(list), ",")
+			continue
+		}
+		vals[i] = fmt.Sprint(v)
+	}
+	return vals
+}
+
+// isIntParam returns true if the parameter with the supplied name is an integer
+func isIntParam(name string) bool {
+	switch params(&Manifest{})[name].(type) {
+	case *int, *int64:
+		return true
+	}
+	return false
+}
+
+// value returns the parameter value at x, which is between 0 and 1, of its range
+func (p *ParamSpace) value(x float64) string {
+	v := p.Min + x*(p.Max-p.Min)
+	if p.Log {
+		v = math.Exp(math.Log(p.Min) + x*(math.Log(p.Max)-math.Log(p.Min)))
+	}
+	// range bounds are exact
+	switch x {
+	case 0:
+		v = p.Min
+	case 1:
+		v = p.Max
+	}
+	if isIntParam(p.Name) {
+		return strconv.Itoa(int(math.Floor(v + 0.5)))
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// grid returns grid search values of the parameter
+func (p *ParamSpace) grid() []string {
+	if p.Values != nil {
+		return p.Values
+	}
+	var vals []string
+	for i := 0; i < p.Steps; i++ {
+		v := p.value(float64(i) / float64(p.Steps-1))
+		// rounding of integer parameters can produce the same values
+		if len(vals) == 0 || vals[len(vals)-1] != v {
+			vals = append(vals, v)
+		}
+	}
+	return vals
+}
+
+// sample returns random value of the parameter
+func (p *ParamSpace) sample(rng *rand.Rand) string {
+	if p.Values != nil {
+		return p.Values[rng.Intn(len(p.Values))]
+	}
+	return p.value(rng.Float64())
+}
+
+// Overrides returns the overrides of all search trials. Grid search tries all the combinations of
+// parameter values: parameter ranges are split into Steps values. Random search draws Trials
+// combinations with parameters drawn uniformly from their choices or ranges.
+func (s *SearchConfig) Overrides() []Overrides {
+	var trials []Overrides
+	if s.Kind == "random" {
+		seed := s.Seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		rng := rand.New(rand.NewSource(seed))
+		for i := 0; i < s.Trials; i++ {
+			o := make(Overrides)
+			for _, p := range s.Params {
+				o[p.Name] = p.sample(rng)
+			}
+			trials = append(trials, o)
+		}
+		return trials
+	}
+	trials = []Overrides{{}}
+	for _, p := range s.Params {
+		var next []Overrides
+		for _, o := range trials {
+			for _, v := range p.grid() {
+				trial := Overrides{p.Name: v}
+				for name, val := range o {
+					trial[name] = val
+				}
+				next = append(next, trial)
+			}
+		}
+		trials = next
+	}
+	return trials
+}
+
+// Trials returns all hyperparameter search trials of the supplied manifest, so the search can be
+// driven from a single manifest. Every trial config is parsed from a copy of the manifest with the
+// searched parameters overridden by the trial values. It fails with error if the manifest does not
+// contain any searched parameters or if the manifest of any trial is not valid.
+func Trials(m *Manifest) ([]*Trial, error) {
+	c, err := ParseManifest(m)
+	if err != nil {
+		return nil, err
+	}
+	if c.Search == nil {
+		return nil, fmt.Errorf("Manifest does not contain searched parameters\n")
+	}
+	var trials []*Trial
+	for _, o := range c.Search.Overrides() {
+		// overrides replace the manifest fields, so the copy does not modify the manifest
+		trialMan := *m
+		if err := o.Apply(&trialMan); err != nil {
+			return nil, err
+		}
+		trialConfig, err := ParseManifest(&trialMan)
+		if err != nil {
+			return nil, fmt.Errorf("Incorrect trial %v: %v", o, err)
+		}
+		trials = append(trials, &Trial{Params: o, Config: trialConfig})
+	}
+	return trials, nil
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchManifest(t *testing.T) {
+	assert := assert.New(t)
+	content := []byte(`kind: feedfwd
+seed: 7
+network:
+  input:
+    size: 4
+  hidden:
+    size: [8]
+    activation: relu
+  output:
+    size: 3
+    activation: softmax
+training:
+  kind: backprop
+  cost: xentropy
+  optimize:
+    method: adam
+search:
+  kind: random
+  trials: 20
+  params:
+    learning_rate: {min: 1e-4, max: 1e-1, scale: log}
+    epochs: {min: 10, max: 20}
+    hidden_size:
+      values: [16, [32, 16]]
+`)
+	tmpPath := filepath.Join(os.TempDir(), "search.yml")
+	assert.NoError(ioutil.WriteFile(tmpPath, content, 0666))
+	defer os.Remove(tmpPath)
+	m, err := ReadManifest(tmpPath)
+	assert.NoError(err)
+	c, err := ParseManifest(m)
+	assert.NoError(err)
+	assert.Equal("random", c.Search.Kind)
+	assert.Equal(20, c.Search.Trials)
+	assert.Equal(int64(7), c.Search.Seed)
+	assert.Equal([]*ParamSpace{
+		{Name: "epochs", Min: 10, Max: 20},
+		{Name: "hidden_size", Values: []string{"16", "32,16"}},
+		{Name: "learning_rate", Min: 1e-4, Max: 1e-1, Log: true},
+	}, c.Search.Params)
+	trials, err := Trials(m)
+	assert.NoError(err)
+	assert.Len(trials, 20)
+	hidden := make(map[int]bool)
+	for _, trial := range trials {
+		rate := trial.Config.Training.Optimize.LearnRate
+		assert.True(rate >= 1e-4 && rate <= 1e-1, "%f", rate)
+		epochs := trial.Config.Training.Epochs
+		assert.True(epochs >= 10 && epochs <= 20, "%d", epochs)
+		assert.Equal(strconv.Itoa(epochs), trial.Params["epochs"])
+		hidden[len(trial.Config.Network.Arch.Hidden)] = true
+	}
+	assert.Equal(map[int]bool{1: true, 2: true}, hidden)
+	// manifest is not modified by trials
+	assert.Equal([]int{8}, m.Network.Hidden.Size)
+	assert.Equal(0.0, m.Training.Optimize.Rate)
+	// random search with the same seed draws the same trials
+	again, err := Trials(m)
+	assert.NoError(err)
+	for i := range trials {
+		assert.Equal(trials[i].Params, again[i].Params)
+	}
+}
+
+func TestSearchGrid(t *testing.T) {
+	assert := assert.New(t)
+	m := validManifest()
+	m.Search.Kind = "grid"
+	m.Search.Params = map[string]ParamManifest{
+		"learning_rate": {Min: 0.001, Max: 0.1, Scale: "log", Steps: 3},
+		"batch_size":    {Min: 1, Max: 2, Steps: 4},
+		"optimizer":     {Values: []interface{}{"adam", "sgd"}},
+	}
+	trials, err := Trials(m)
+	assert.NoError(err)
+	// batch sizes are rounded to 1 and 2
+	assert.Len(trials, 12)
+	seen := make(map[string]bool)
+	for _, trial := range trials {
+		key := trial.Params["learning_rate"] + " " + trial.Params["batch_size"] + " " + trial.Params["optimizer"]
+		assert.False(seen[key], "%s", key)
+		seen[key] = true
+	}
+	rates := make(map[float64]bool)
+	for _, trial := range trials {
+		rates[trial.Config.Training.Optimize.LearnRate] = true
+	}
+	assert.Len(rates, 3)
+	assert.True(rates[0.001])
+	assert.True(rates[0.1])
+	for r := range rates {
+		if r != 0.001 && r != 0.1 {
+			assert.InDelta(0.01, r, 1e-12)
+		}
+	}
+	// no search
+	_, err = Trials(validManifest())
+	assert.Error(err)
+	// trial which is not valid
+	m.Search.Params["momentum"] = ParamManifest{Values: []interface{}{0.5, 2.0}}
+	_, err = Trials(m)
+	assert.Error(err)
+}
+
+func TestValidateSearch(t *testing.T) {
+	assert := assert.New(t)
+	m := validManifest()
+	m.Search.Kind = "grid"
+	m.Search.Params = map[string]ParamManifest{
+		"foobar":        {Min: 1, Max: 2, Steps: 2},
+		"learning_rate": {Min: 0, Max: 0.1, Scale: "log"},
+		"epochs":        {Min: 2, Max: 1, Steps: 2, Scale: "exp"},
+		"optimizer":     {Min: 1, Max: 2, Steps: 2},
+		"batch_size":    {Values: []interface{}{}},
+		"lambda":        {Values: []interface{}{0.1, "foo"}},
+	}
+	assert.Equal([]string{
+		"search.params.batch_size.values",
+		"search.params.epochs",
+		"search.params.epochs.scale",
+		"search.params.foobar",
+		"search.params.lambda.values[1]",
+		"search.params.learning_rate.min",
+		"search.params.learning_rate.steps",
+		"search.params.optimizer",
+	}, fields(Validate(m)))
+	m = validManifest()
+	m.Search.Kind = "random"
+	assert.Equal([]string{"search.params"}, fields(Validate(m)))
+	m.Search.Params = map[string]ParamManifest{"epochs": {Min: 1, Max: 10}}
+	assert.Equal([]string{"search.trials"}, fields(Validate(m)))
+	m.Search.Kind = "foobar"
+	assert.Equal([]string{"search.kind"}, fields(Validate(m)))
+}
@@ -0,0 +1,430 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// unmarshalTOML decodes TOML manifest data into v. TOML keys are the same as the keys of YAML
+// manifests: the decoded tables are converted to JSON and decoded by encoding/json. The supported
+// subset of TOML contains tables, arrays of tables, dotted keys, basic and literal strings,
+// integers, floats, booleans, arrays and inline tables, which is all manifests need.
+// Multi-line strings and date-times are not supported.
+func unmarshalTOML(data []byte, v interface{}) error {
+	p := &tomlParser{data: string(data)}
+	root, err := p.parse()
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(root)
+	if err != nil {
+		return fmt.Errorf("Incorrect TOML: %v\n", err)
+	}
+	return json.Unmarshal(b, v)
+}
+
+// tomlParser parses TOML documents
+type tomlParser struct {
+	data string
+	pos  int
+}
+
+// errorf returns parsing error at the current position
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	line := strings.Count(p.data[:p.pos], "\n") + 1
+	return fmt.Errorf("Incorrect TOML at line %d: %s\n", line, fmt.Sprintf(format, args...))
+}
+
+// peek returns the current character or 0 at the end of data
+func (p *tomlParser) peek() byte {
+	if p.pos < len(p.data) {
+		return p.data[p.pos]
+	}
+	return 0
+}
+
+// skipSpace skips spaces and tabs
+func (p *tomlParser) skipSpace() {
+	for p.peek() == ' ' || p.peek() == '\t' {
+		p.pos++
+	}
+}
+
+// skipComment skips the comment till the end of line
+func (p *tomlParser) skipComment() {
+	if p.peek() == '#' {
+		for p.pos < len(p.data) && p.data[p.pos] != '\n' {
+			p.pos++
+		}
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments
+func (p *tomlParser) skipBlank() {
+	for {
+		p.skipSpace()
+		p.skipComment()
+		switch p.peek() {
+		case '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// endLine checks that nothing but a comment follows on the current line
+func (p *tomlParser) endLine() error {
+	p.skipSpace()
+	p.skipComment()
+	if p.peek() == '\r' {
+		p.pos++
+	}
+	switch p.peek() {
+	case '\n':
+		p.pos++
+	case 0:
+	default:
+		return p.errorf("unexpected %q", p.peek())
+	}
+	return nil
+}
+
+// parse parses the whole document into its root table
+func (p *tomlParser) parse() (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	cur := root
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.data) {
+			return root, nil
+		}
+		var err error
+		if p.peek() == '[' {
+			cur, err = p.parseHeader(root)
+		} else {
+			err = p.parseKeyValue(cur)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := p.endLine(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseHeader parses table or array of tables header and returns the table it opens
+func (p *tomlParser) parseHeader(root map[string]interface{}) (map[string]interface{}, error) {
+	p.pos++
+	array := p.peek() == '['
+	if array {
+		p.pos++
+	}
+	p.skipSpace()
+	keys, err := p.parseKey()
+	if err != nil {
+		return nil, err
+	}
+	closing := "]"
+	if array {
+		closing = "]]"
+	}
+	if !strings.HasPrefix(p.data[p.pos:], closing) {
+		return nil, p.errorf("missing %s", closing)
+	}
+	p.pos += len(closing)
+	parent, err := p.table(root, keys[:len(keys)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := keys[len(keys)-1]
+	if !array {
+		return p.table(parent, []string{last})
+	}
+	t := make(map[string]interface{})
+	switch v := parent[last].(type) {
+	case nil:
+		parent[last] = []interface{}{t}
+	case []interface{}:
+		parent[last] = append(v, t)
+	default:
+		return nil, p.errorf("%s is not an array of tables", last)
+	}
+	return t, nil
+}
+
+// table returns the table with the supplied keys path in t creating the missing tables.
+// If the path goes through an array of tables, its last table is used.
+func (p *tomlParser) table(t map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	for _, key := range keys {
+		switch v := t[key].(type) {
+		case nil:
+			next := make(map[string]interface{})
+			t[key] = next
+			t = next
+		case map[string]interface{}:
+			t = v
+		case []interface{}:
+			if len(v) == 0 {
+				return nil, p.errorf("%s is not a table", key)
+			}
+			last, ok := v[len(v)-1].(map[string]interface{})
+			if !ok {
+				return nil, p.errorf("%s is not a table", key)
+			}
+			t = last
+		default:
+			return nil, p.errorf("%s is not a table", key)
+		}
+	}
+	return t, nil
+}
+
+// parseKeyValue parses key value pair into table t
+func (p *tomlParser) parseKeyValue(t map[string]interface{}) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	if p.peek() != '=' {
+		return p.errorf("missing = after key %s", strings.Join(keys, "."))
+	}
+	p.pos++
+	p.skipSpace()
+	val, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+	parent, err := p.table(t, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if _, ok := parent[last]; ok {
+		return p.errorf("duplicate key %s", strings.Join(keys, "."))
+	}
+	parent[last] = val
+	return nil
+}
+
+// parseKey parses dotted key along with the whitespace which follows it
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		var key string
+		switch c := p.peek(); {
+		case c == '"':
+			s, err := p.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		case c == '\'':
+			s, err := p.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		default:
+			start := p.pos
+			for isBareKey(p.peek()) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, p.errorf("missing key")
+			}
+			key = p.data[start:p.pos]
+		}
+		keys = append(keys, key)
+		p.skipSpace()
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+		p.skipSpace()
+	}
+}
+
+// isBareKey returns true if c can be used in bare keys
+func isBareKey(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// parseValue parses value
+func (p *tomlParser) parseValue() (interface{}, error) {
+	switch p.peek() {
+	case '"':
+		if strings.HasPrefix(p.data[p.pos:], `"""`) {
+			return nil, p.errorf("multi-line strings are not supported")
+		}
+		return p.parseBasicString()
+	case '\'':
+		if strings.HasPrefix(p.data[p.pos:], `'''`) {
+			return nil, p.errorf("multi-line strings are not supported")
+		}
+		return p.parseLiteralString()
+	case '[':
+		return p.parseArray()
+	case '{':
+		return p.parseInlineTable()
+	}
+	start := p.pos
+	for p.pos < len(p.data) && !strings.ContainsRune(" \t\r\n,]}#", rune(p.data[p.pos])) {
+		p.pos++
+	}
+	tok := p.data[start:p.pos]
+	switch tok {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "inf", "+inf":
+		return math.Inf(1), nil
+	case "-inf":
+		return math.Inf(-1), nil
+	case "nan", "+nan", "-nan":
+		return math.NaN(), nil
+	}
+	num := strings.Replace(tok, "_", "", -1)
+	isHex := strings.HasPrefix(num, "0x")
+	if !isHex && strings.ContainsAny(num, ".eE") {
+		if f, err := strconv.ParseFloat(num, 64); err == nil {
+			return f, nil
+		}
+	} else if i, err := strconv.ParseInt(num, 0, 64); err == nil {
+		return i, nil
+	}
+	p.pos = start
+	return nil, p.errorf("unsupported value %q", tok)
+}
+
+// parseBasicString parses double quoted string
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++
+	var b bytes.Buffer
+	for {
+		if p.pos >= len(p.data) || p.data[p.pos] == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.data[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if p.pos >= len(p.data) {
+				return "", p.errorf("unterminated string")
+			}
+			esc := p.data[p.pos]
+			p.pos++
+			switch esc {
+			case 'b':
+				b.WriteByte('\b')
+			case 't':
+				b.WriteByte('\t')
+			case 'n':
+				b.WriteByte('\n')
+			case 'f':
+				b.WriteByte('\f')
+			case 'r':
+				b.WriteByte('\r')
+			case '"', '\\':
+				b.WriteByte(esc)
+			case 'u', 'U':
+				size := 4
+				if esc == 'U' {
+					size = 8
+				}
+				if p.pos+size > len(p.data) {
+					return "", p.errorf("incorrect unicode escape")
+				}
+				r, err := strconv.ParseUint(p.data[p.pos:p.pos+size], 16, 32)
+				if err != nil || !utf8.ValidRune(rune(r)) {
+					return "", p.errorf("incorrect unicode escape")
+				}
+				b.WriteRune(rune(r))
+				p.pos += size
+			default:
+				return "", p.errorf("incorrect escape \\%c", esc)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+// parseLiteralString parses single quoted string
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++
+	start := p.pos
+	for p.pos < len(p.data) && p.data[p.pos] != '\'' {
+		if p.data[p.pos] == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.data) {
+		return "", p.errorf("unterminated string")
+	}
+	p.pos++
+	return p.data[start : p.pos-1], nil
+}
+
+// parseArray parses array which can span multiple lines
+func (p *tomlParser) parseArray() ([]interface{}, error) {
+	p.pos++
+	arr := []interface{}{}
+	for {
+		p.skipBlank()
+		if p.peek() == ']' {
+			p.pos++
+			return arr, nil
+		}
+		val, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, val)
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, p.errorf("missing , or ] in array")
+		}
+	}
+}
+
+// parseInlineTable parses inline table
+func (p *tomlParser) parseInlineTable() (map[string]interface{}, error) {
+	p.pos++
+	t := make(map[string]interface{})
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.pos++
+		return t, nil
+	}
+	for {
+		p.skipSpace()
+		if err := p.parseKeyValue(t); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return t, nil
+		default:
+			return nil, p.errorf("missing , or } in inline table")
+		}
+	}
+}
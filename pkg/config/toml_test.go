@@ -0,0 +1,138 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalTOML(t *testing.T) {
+	assert := assert.New(t)
+	data := `# go-neural manifest
+kind = "feedfwd"
+task = 'class'
+seed = 1_000
+
+[network]
+precision = "float32"
+input = { size = 16, noise = 0.05 }
+
+[[network.layers]]
+kind = "conv1d"
+size = 2
+activation = "relu"
+conv.width = 3
+conv.stride = 1
+conv.channels = 4
+
+[[network.layers]]
+size = 5
+activation = "maxout"
+pieces = 2
+
+[network.output]
+size = 3          # number of classes
+activation = "softmax"
+
+[training]
+kind = "backprop"
+cost = "xentropy"
+epochs = 10
+shuffle = true
+params.lambda = 5e-1
+params.classweights = [
+	1.0,
+	2.0, # minority class
+	+1.5,
+]
+
+[training.optimize]
+method = "adam"
+rate = 0.01
+"weightdecay" = 1e-2
+`
+	var m Manifest
+	assert.NoError(unmarshalTOML([]byte(data), &m))
+	assert.Equal("feedfwd", m.Kind)
+	assert.Equal("class", m.Task)
+	assert.Equal(int64(1000), m.Seed)
+	assert.Equal("float32", m.Network.Precision)
+	assert.Equal(16, m.Network.Input.Size)
+	assert.Equal(0.05, m.Network.Input.Noise)
+	assert.Len(m.Network.Layers, 2)
+	assert.Equal("conv1d", m.Network.Layers[0].Kind)
+	assert.Equal(3, m.Network.Layers[0].Conv.Width)
+	assert.Equal(4, m.Network.Layers[0].Conv.Channels)
+	assert.Equal(2, m.Network.Layers[1].Pieces)
+	assert.Equal(3, m.Network.Output.Size)
+	assert.Equal("softmax", m.Network.Output.Activation)
+	assert.True(m.Training.Shuffle)
+	assert.Equal(0.5, m.Training.Params.Lambda)
+	assert.Equal([]float64{1.0, 2.0, 1.5}, m.Training.Params.ClassWeights)
+	assert.Equal(0.01, m.Training.Optimize.Rate)
+	assert.Equal(0.01, m.Training.Optimize.WeightDecay)
+	_, err := ParseManifest(&m)
+	assert.NoError(err)
+	// strings
+	var s struct{ A, B, C string }
+	assert.NoError(unmarshalTOML([]byte(`a = "tab\tquote\"\u00e9"
+b = 'C:\path'
+"c" = "#not a comment" # comment`), &s))
+	assert.Equal("tab\tquote\"\u00e9", s.A)
+	assert.Equal(`C:\path`, s.B)
+	assert.Equal("#not a comment", s.C)
+	// incorrect documents
+	for _, doc := range []string{
+		`a = `,
+		`a = "unterminated`,
+		`a = 1 b = 2`,
+		`a = 1` + "\n" + `a = 2`,
+		`a = [1, 2`,
+		`a = { b = 1`,
+		`[table`,
+		`[[array]`,
+		`a = 1979-05-27`,
+		`a = """multi"""`,
+		`a = "\q"`,
+		`= 1`,
+		`a = 1` + "\n" + `[[a]]`,
+		`a = 1` + "\n" + `[a.b]`,
+	} {
+		var v map[string]interface{}
+		assert.Error(unmarshalTOML([]byte(doc), &v), "%s", doc)
+	}
+	// errors report line numbers
+	err = unmarshalTOML([]byte("a = 1\nb = ?"), &m)
+	assert.Contains(err.Error(), "line 2")
+}
+
+func TestNewConfigTOML(t *testing.T) {
+	assert := assert.New(t)
+	content := []byte(`kind = "feedfwd"
+
+[network]
+input.size = 400
+hidden = { size = [25], activation = "relu" }
+output = { size = 10, activation = "softmax" }
+
+[training]
+kind = "backprop"
+cost = "xentropy"
+params = { lambda = 1.0 }
+optimize = { method = "bfgs", iterations = 69 }
+`)
+	tmpPath := filepath.Join(os.TempDir(), "manifest.toml")
+	assert.NoError(ioutil.WriteFile(tmpPath, content, 0666))
+	defer os.Remove(tmpPath)
+	c, err := New(tmpPath)
+	assert.NoError(err)
+	assert.Equal(400, c.Network.Arch.Input.Size)
+	assert.Equal(25, c.Network.Arch.Hidden[0].Size)
+	assert.Equal("relu", c.Network.Arch.Hidden[0].NeurFn.Activation)
+	assert.Equal(10, c.Network.Arch.Output.Size)
+	assert.Equal("bfgs", c.Training.Optimize.Method)
+	assert.Equal(69, c.Training.Optimize.Iterations)
+}
@@ -0,0 +1,308 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// activations contains supported neuron activation functions
+var activations = []string{"sigmoid", "softmax", "tanh", "relu", "stdrelu", "maxout"}
+
+// precisions contains supported forward propagation precisions
+var precisions = []string{"", "float64", "float32"}
+
+// FieldError is a problem found in a particular manifest field
+type FieldError struct {
+	// Field is a path of the manifest field such as network.layers[1].size
+	Field string
+	// Msg describes the problem
+	Msg string
+}
+
+// Error implements error interface
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Msg)
+}
+
+// ValidationError contains all the problems found in a manifest
+type ValidationError []*FieldError
+
+// Error implements error interface
+func (e ValidationError) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return fmt.Sprintf("Invalid manifest: %s\n", strings.Join(msgs, "; "))
+}
+
+// validator collects manifest problems
+type validator struct {
+	errs ValidationError
+}
+
+// check records the problem described by format and args in the supplied field if ok is false
+func (v *validator) check(ok bool, field, format string, args ...interface{}) {
+	if !ok {
+		v.errs = append(v.errs, &FieldError{Field: field, Msg: fmt.Sprintf(format, args...)})
+	}
+}
+
+// contains returns true if list contains s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the supplied manifest. Unlike parsing, which stops at the first problem, it reports
+// all the problems found: unsupported names, invalid hyperparameters and layer sizes which don't fit
+// the output of the preceding layer. It returns ValidationError listing the problems along with the
+// paths of the manifest fields they were found in, or nil if the manifest is valid.
+func Validate(m *Manifest) error {
+	v := &validator{}
+	v.check(m.Kind != "", "kind", "network kind can not be empty")
+	_, supported := network[m.Kind]
+	v.check(m.Kind == "" || supported, "kind", "unsupported network kind: %s", m.Kind)
+	validateNetwork(v, m)
+	validateTraining(v, m, supported)
+	validateSearch(v, m)
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// validateNetwork checks network layers configuration
+func validateNetwork(v *validator, m *Manifest) {
+	n := &m.Network
+	v.check(contains(precisions, n.Precision), "network.precision", "unsupported precision: %s", n.Precision)
+	v.check(n.Input.Size > 0, "network.input.size", "incorrect size: %d", n.Input.Size)
+	v.check(n.Input.Noise >= 0, "network.input.noise", "incorrect noise: %f", n.Input.Noise)
+	v.check(n.Hidden.Noise >= 0, "network.hidden.noise", "incorrect noise: %f", n.Hidden.Noise)
+	if len(n.Hidden.Size) > 0 {
+		v.check(len(n.Layers) == 0, "network.layers", "hidden layers can't be configured by both hidden and layers")
+		validateActivation(v, "network.hidden", n.Hidden.Activation, n.Hidden.Pieces)
+		for i, size := range n.Hidden.Size {
+			v.check(size > 0, fmt.Sprintf("network.hidden.size[%d]", i), "incorrect size: %d", size)
+		}
+	}
+	// layerIn is the size of the current layer input: 0 if it is unknown due to preceding problems
+	layerIn := n.Input.Size
+	for i := range n.Layers {
+		layerIn = validateLayer(v, fmt.Sprintf("network.layers[%d]", i), &n.Layers[i], layerIn)
+	}
+	v.check(n.Output.Size > 0, "network.output.size", "incorrect size: %d", n.Output.Size)
+	v.check(n.Output.Activation != "maxout", "network.output.activation",
+		"unsupported output activation: %s", n.Output.Activation)
+	if n.Output.Activation != "maxout" {
+		validateActivation(v, "network.output", n.Output.Activation, 0)
+	}
+}
+
+// validateLayer checks configuration of the hidden layer with the supplied path whose input
+// has layerIn values. It returns the layer output size or 0 if it can't be determined.
+func validateLayer(v *validator, path string, l *LayerManifest, layerIn int) int {
+	v.check(l.Size > 0, path+".size", "incorrect size: %d", l.Size)
+	v.check(l.Noise >= 0, path+".noise", "incorrect noise: %f", l.Noise)
+	validateActivation(v, path, l.Activation, l.Pieces)
+	if l.Size <= 0 {
+		return 0
+	}
+	switch l.Kind {
+	case "", "dense":
+		return l.Size
+	case "conv1d":
+	default:
+		v.check(false, path+".kind", "unsupported layer kind: %s", l.Kind)
+		return 0
+	}
+	v.check(l.Activation != "maxout" && l.Activation != "softmax", path+".activation",
+		"unsupported convolution activation: %s", l.Activation)
+	c := l.Conv
+	v.check(c.Width > 0, path+".conv.width", "incorrect width: %d", c.Width)
+	v.check(c.Stride > 0, path+".conv.stride", "incorrect stride: %d", c.Stride)
+	v.check(c.Channels > 0, path+".conv.channels", "incorrect channels: %d", c.Channels)
+	if c.Width <= 0 || c.Stride <= 0 || c.Channels <= 0 || layerIn <= 0 {
+		return 0
+	}
+	// layer input must contain the same number of values for every channel
+	if layerIn%c.Channels != 0 {
+		v.check(false, path+".conv.channels", "input size %d is not divisible by channels: %d", layerIn, c.Channels)
+		return 0
+	}
+	length := layerIn / c.Channels
+	if length < c.Width {
+		v.check(false, path+".conv.width", "input length %d is shorter than kernel width: %d", length, c.Width)
+		return 0
+	}
+	return ((length-c.Width)/c.Stride + 1) * l.Size
+}
+
+// validateActivation checks activation function of the layer with the supplied path
+func validateActivation(v *validator, path, act string, pieces int) {
+	if act == "maxout" {
+		v.check(pieces >= 2, path+".pieces", "maxout requires at least 2 pieces: %d", pieces)
+		return
+	}
+	v.check(contains(activations, act), path+".activation", "unsupported activation: %s", act)
+}
+
+// validateTraining checks training configuration. supported is false if the network kind is not
+// supported, so the supported training kinds and optimization methods are unknown.
+func validateTraining(v *validator, m *Manifest, supported bool) {
+	t := &m.Training
+	v.check(t.Kind != "", "training.kind", "training kind can not be empty")
+	v.check(t.Kind == "" || !supported || contains(network[m.Kind]["training"], t.Kind),
+		"training.kind", "unsupported training: %s", t.Kind)
+	v.check(t.Cost != "", "training.cost", "cost function can not be empty")
+	v.check(t.Params.Lambda >= 0, "training.params.lambda", "incorrect reg parameter: %f", t.Params.Lambda)
+	v.check(t.Params.L1 >= 0, "training.params.l1", "incorrect L1 reg parameter: %f", t.Params.L1)
+	v.check(t.Params.Smoothing >= 0 && t.Params.Smoothing < 1, "training.params.smoothing",
+		"incorrect label smoothing: %f", t.Params.Smoothing)
+	v.check(!t.Params.Balanced || len(t.Params.ClassWeights) == 0, "training.params.classweights",
+		"class weights can not be combined with balanced weighting")
+	for i, w := range t.Params.ClassWeights {
+		v.check(w >= 0, fmt.Sprintf("training.params.classweights[%d]", i), "incorrect class weight: %f", w)
+	}
+	v.check(t.Epochs >= 0, "training.epochs", "incorrect number of epochs: %d", t.Epochs)
+	v.check(t.Batch >= 0, "training.batch", "incorrect batch size: %d", t.Batch)
+	v.check(t.Snapshots >= 0, "training.snapshots", "incorrect number of epochs between snapshots: %d", t.Snapshots)
+	v.check(t.Accumulate >= 0, "training.accumulate",
+		"incorrect number of accumulated mini-batches: %d", t.Accumulate)
+	v.check(!t.Rollback || t.Guard, "training.rollback", "rollback requires non-finite values guard")
+	v.check(t.Validation >= 0 && t.Validation < 1, "training.validation",
+		"incorrect validation split: %f", t.Validation)
+	validateOptimize(v, m, supported)
+	// validation metrics
+	for i, metric := range t.Metrics {
+		v.check(contains(metrics, metric), fmt.Sprintf("training.metrics[%d]", i),
+			"unsupported validation metric: %s", metric)
+	}
+	v.check(len(t.Metrics) == 0 || t.Validation > 0, "training.metrics", "validation metrics require validation split")
+	// learning rate schedule
+	s := t.Schedule
+	v.check(s.Kind == "" || contains(schedules, s.Kind), "training.schedule.kind",
+		"unsupported learning rate schedule: %s", s.Kind)
+	v.check(s.Every >= 0, "training.schedule.every", "incorrect number of epochs: %d", s.Every)
+	v.check(s.Warmup >= 0, "training.schedule.warmup", "incorrect number of warmup steps: %d", s.Warmup)
+	v.check(s.Factor >= 0, "training.schedule.factor", "incorrect decay factor: %f", s.Factor)
+	v.check(s.Min >= 0, "training.schedule.min", "incorrect minimum learning rate: %f", s.Min)
+	// early stopping
+	e := t.EarlyStop
+	earlyStop := e.Monitor != "" || e.Patience != 0
+	if earlyStop {
+		v.check(e.Monitor == "" || contains(monitors, e.Monitor), "training.earlystop.monitor",
+			"unsupported early stopping metric: %s", e.Monitor)
+		monitor := e.Monitor
+		if monitor == "" {
+			monitor = "cost"
+		}
+		v.check(len(t.Metrics) == 0 || contains(t.Metrics, monitor), "training.earlystop.monitor",
+			"monitored metric is not evaluated: %s", monitor)
+		v.check(e.Patience > 0, "training.earlystop.patience", "incorrect patience: %d", e.Patience)
+		v.check(e.Delta >= 0, "training.earlystop.delta", "incorrect delta: %f", e.Delta)
+	}
+	// checkpointing
+	if t.Checkpoint.Path != "" || t.Checkpoint.Every != 0 {
+		v.check(t.Checkpoint.Path != "", "training.checkpoint.path", "checkpoint path can not be empty")
+		v.check(t.Checkpoint.Every >= 0, "training.checkpoint.every",
+			"incorrect number of epochs between checkpoints: %d", t.Checkpoint.Every)
+	}
+	// stochastic weight averaging
+	if t.SWA.Start != 0 || t.SWA.Every != 0 {
+		v.check(t.SWA.Start > 0 && t.SWA.Start <= t.Epochs, "training.swa.start",
+			"incorrect first averaged epoch: %d", t.SWA.Start)
+		v.check(t.SWA.Every >= 0, "training.swa.every",
+			"incorrect number of epochs between averaged epochs: %d", t.SWA.Every)
+		v.check(!earlyStop, "training.swa", "weight averaging can not be combined with early stopping")
+	}
+	// class-weighted sampling
+	sampling := t.Sampling
+	if sampling.Balanced || len(sampling.Weights) > 0 {
+		v.check(!sampling.Balanced || len(sampling.Weights) == 0, "training.sampling.weights",
+			"sampling weights can not be combined with balanced sampling")
+		total := 0.0
+		for i, w := range sampling.Weights {
+			v.check(w >= 0, fmt.Sprintf("training.sampling.weights[%d]", i), "incorrect sampling weight: %f", w)
+			total += w
+		}
+		v.check(sampling.Balanced || total > 0, "training.sampling.weights",
+			"incorrect sampling weights: %v", sampling.Weights)
+	}
+}
+
+// validateOptimize checks training optimization configuration
+func validateOptimize(v *validator, m *Manifest, supported bool) {
+	o := &m.Training.Optimize
+	v.check(o.Method != "", "training.optimize.method", "optimize method can not be empty")
+	v.check(o.Method == "" || !supported || contains(network[m.Kind]["optim"], o.Method),
+		"training.optimize.method", "unsupported optimization method: %s", o.Method)
+	v.check(o.Rate >= 0, "training.optimize.rate", "incorrect learning rate: %f", o.Rate)
+	v.check(o.Momentum >= 0 && o.Momentum < 1, "training.optimize.momentum", "incorrect momentum: %f", o.Momentum)
+	v.check(o.Beta1 >= 0 && o.Beta1 < 1, "training.optimize.beta1", "incorrect decay rate: %f", o.Beta1)
+	v.check(o.Beta2 >= 0 && o.Beta2 < 1, "training.optimize.beta2", "incorrect decay rate: %f", o.Beta2)
+	v.check(o.Epsilon >= 0, "training.optimize.epsilon", "incorrect epsilon: %f", o.Epsilon)
+	v.check(o.Decay >= 0 && o.Decay < 1, "training.optimize.decay", "incorrect decay rate: %f", o.Decay)
+	v.check(o.WeightDecay >= 0, "training.optimize.weightdecay", "incorrect weight decay: %f", o.WeightDecay)
+	v.check(o.ClipNorm >= 0, "training.optimize.clipnorm", "incorrect gradient clipping norm: %f", o.ClipNorm)
+	v.check(o.ClipValue >= 0, "training.optimize.clipvalue", "incorrect gradient clipping value: %f", o.ClipValue)
+	v.check(o.Memory >= 0, "training.optimize.memory", "incorrect lbfgs memory: %d", o.Memory)
+	v.check(o.Variant == "" || contains(variants, o.Variant), "training.optimize.variant",
+		"unsupported conjugate gradient variant: %s", o.Variant)
+	v.check(o.Tolerance >= 0, "training.optimize.tolerance", "incorrect tolerance: %f", o.Tolerance)
+	ls := o.LineSearch
+	if ls.Decrease != 0 || ls.Curvature != 0 {
+		// zero curvature factor defaults to 0.9
+		curvature := ls.Curvature
+		if curvature == 0 {
+			curvature = 0.9
+		}
+		v.check(curvature > 0 && curvature < 1, "training.optimize.linesearch.curvature",
+			"incorrect curvature factor: %f", ls.Curvature)
+		v.check(ls.Decrease >= 0 && ls.Decrease < curvature, "training.optimize.linesearch.decrease",
+			"incorrect sufficient decrease factor: %f", ls.Decrease)
+	}
+}
+
+// validateSearch checks hyperparameter search configuration
+func validateSearch(v *validator, m *Manifest) {
+	s := &m.Search
+	if len(s.Params) == 0 {
+		v.check(s.Kind == "" && s.Trials == 0, "search.params", "searched parameters can not be empty")
+		return
+	}
+	v.check(contains(searches, s.Kind), "search.kind", "unsupported search: %s", s.Kind)
+	v.check(s.Kind != "random" || s.Trials > 0, "search.trials", "incorrect number of trials: %d", s.Trials)
+	fields := params(&Manifest{})
+	for _, name := range searchNames(m) {
+		p := s.Params[name]
+		path := "search.params." + name
+		field, ok := fields[name]
+		if !ok {
+			v.check(false, path, "unknown manifest parameter: %s", name)
+			continue
+		}
+		if p.Values != nil {
+			v.check(len(p.Values) > 0, path+".values", "parameter choices can not be empty")
+			for i, val := range paramValues(p.Values) {
+				v.check(setParam(field, val) == nil, fmt.Sprintf("%s.values[%d]", path, i),
+					"incorrect value: %s", val)
+			}
+			continue
+		}
+		switch field.(type) {
+		case *int, *int64, *float64:
+		default:
+			v.check(false, path, "parameter range is not supported: use values")
+			continue
+		}
+		v.check(p.Min < p.Max, path, "incorrect range: %f, %f", p.Min, p.Max)
+		v.check(contains(scales, p.Scale), path+".scale", "unsupported scale: %s", p.Scale)
+		v.check(p.Scale != "log" || p.Min > 0, path+".min", "log scale requires positive range: %f", p.Min)
+		v.check(s.Kind != "grid" || p.Steps >= 2, path+".steps", "grid search requires at least 2 steps: %d", p.Steps)
+	}
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// validManifest returns a valid manifest of a network with convolution layer
+func validManifest() *Manifest {
+	m := &Manifest{Kind: "feedfwd"}
+	m.Network.Input.Size = 12
+	m.Network.Layers = []LayerManifest{
+		{Kind: "conv1d", Size: 2, Activation: "relu"},
+		{Size: 4, Activation: "maxout", Pieces: 2},
+	}
+	m.Network.Layers[0].Conv.Width = 2
+	m.Network.Layers[0].Conv.Stride = 1
+	m.Network.Layers[0].Conv.Channels = 3
+	m.Network.Output.Size = 2
+	m.Network.Output.Activation = "softmax"
+	m.Training.Kind = "backprop"
+	m.Training.Cost = "xentropy"
+	m.Training.Optimize.Method = "adam"
+	return m
+}
+
+// fields returns the fields of all the problems of the supplied validation error
+func fields(err error) []string {
+	verr, ok := err.(ValidationError)
+	if !ok {
+		return nil
+	}
+	var fs []string
+	for _, fe := range verr {
+		fs = append(fs, fe.Field)
+	}
+	return fs
+}
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+	m := validManifest()
+	assert.NoError(Validate(m))
+	// all the problems are reported
+	m.Network.Precision = "float16"
+	m.Network.Layers[1].Activation = "foobar"
+	m.Network.Output.Activation = "maxout"
+	m.Training.Optimize.Rate = -0.1
+	m.Training.Optimize.Momentum = 1.0
+	m.Training.Params.ClassWeights = []float64{1.0, -1.0}
+	err := Validate(m)
+	assert.Error(err)
+	assert.Equal([]string{
+		"network.precision",
+		"network.layers[1].activation",
+		"network.output.activation",
+		"training.params.classweights[1]",
+		"training.optimize.rate",
+		"training.optimize.momentum",
+	}, fields(err))
+	assert.Contains(err.Error(), "network.layers[1].activation: unsupported activation: foobar")
+	// parsing fails with the same problems
+	c, err := ParseManifest(m)
+	assert.Nil(c)
+	assert.Len(fields(err), 6)
+	// convolution input is not divisible by channels
+	m = validManifest()
+	m.Network.Input.Size = 10
+	assert.Equal([]string{"network.layers[0].conv.channels"}, fields(Validate(m)))
+	// convolution input is shorter than kernel
+	m = validManifest()
+	m.Network.Layers[0].Conv.Width = 5
+	assert.Equal([]string{"network.layers[0].conv.width"}, fields(Validate(m)))
+	// convolution input is the output of the preceding layer
+	m = validManifest()
+	m.Network.Layers = append([]LayerManifest{{Size: 7, Activation: "tanh"}}, m.Network.Layers...)
+	assert.Equal([]string{"network.layers[1].conv.channels"}, fields(Validate(m)))
+	m.Network.Layers[0].Size = 9
+	assert.NoError(Validate(m))
+	// unsupported layer kind and convolution activation
+	m = validManifest()
+	m.Network.Layers[0].Activation = "softmax"
+	m.Network.Layers[1].Kind = "foobar"
+	assert.Equal([]string{"network.layers[0].activation", "network.layers[1].kind"}, fields(Validate(m)))
+	// empty and unsupported names
+	m = validManifest()
+	m.Kind = "foobar"
+	m.Training.Kind = ""
+	m.Training.Optimize.Method = ""
+	assert.Equal([]string{"kind", "training.kind", "training.optimize.method"}, fields(Validate(m)))
+	// validation metrics
+	m = validManifest()
+	m.Training.Metrics = []string{"f1", "auc"}
+	assert.Equal([]string{"training.metrics"}, fields(Validate(m)))
+	m.Training.Validation = 0.2
+	assert.NoError(Validate(m))
+	m.Training.Metrics = append(m.Training.Metrics, "foobar")
+	m.Training.EarlyStop.Patience = 2
+	assert.Equal([]string{"training.metrics[2]", "training.earlystop.monitor"}, fields(Validate(m)))
+	m.Training.Metrics = []string{"f1", "auc"}
+	m.Training.EarlyStop.Monitor = "auc"
+	assert.NoError(Validate(m))
+}
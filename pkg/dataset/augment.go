@@ -0,0 +1,324 @@
+package dataset
+
+import (
+	"fmt"
+	"math/rand"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Augmenter randomly transforms mini-batches of training samples, which artificially enlarges
+// the training data. Every call transforms the samples differently. Augmenters must not modify
+// the supplied samples, which can be views of the training data.
+type Augmenter interface {
+	// Augment returns randomly transformed copy of the supplied samples
+	Augment(inMx *mat.Dense) *mat.Dense
+}
+
+// RandomState is implemented by augmenters whose random state can be saved and restored,
+// so training resumed from a checkpoint augments the samples the same way as uninterrupted training
+type RandomState interface {
+	// RandState returns the state of the augmenter random sources
+	RandState() []int64
+	// SetRandState restores the state returned by RandState of an augmenter initialized with the same seed.
+	// It fails with error if the state does not match the augmenter.
+	SetRandState(state []int64) error
+}
+
+// countingSource is random source which counts the numbers drawn since it was seeded,
+// so its state can be restored by replaying the draws
+type countingSource struct {
+	src   rand.Source
+	seed  int64
+	draws int64
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.src.Int63()
+}
+
+// Seed seeds the random source
+func (s *countingSource) Seed(seed int64) {
+	s.src.Seed(seed)
+	s.seed, s.draws = seed, 0
+}
+
+// randState is random source of augmenters which implements RandomState:
+// the state is the number of numbers drawn since the source was seeded
+type randState struct {
+	rng *rand.Rand
+	src *countingSource
+}
+
+// newRandState returns random source initialized with the supplied seed
+func newRandState(seed int64) randState {
+	src := &countingSource{src: rand.NewSource(seed), seed: seed}
+	return randState{rng: rand.New(src), src: src}
+}
+
+// RandState returns the number of random numbers drawn by the augmenter
+func (r randState) RandState() []int64 {
+	return []int64{r.src.draws}
+}
+
+// SetRandState reseeds the random source and skips the supplied number of draws.
+// It fails with error if the state does not contain a single non-negative number.
+func (r randState) SetRandState(state []int64) error {
+	if len(state) != 1 || state[0] < 0 {
+		return fmt.Errorf("Incorrect random state: %v\n", state)
+	}
+	r.rng.Seed(r.src.seed)
+	for r.src.draws < state[0] {
+		r.src.Int63()
+	}
+	return nil
+}
+
+// AugmenterFunc allows to use ordinary functions as custom augmenters
+type AugmenterFunc func(inMx *mat.Dense) *mat.Dense
+
+// Augment returns samples transformed by f
+func (f AugmenterFunc) Augment(inMx *mat.Dense) *mat.Dense {
+	return f(inMx)
+}
+
+// Pipeline is Augmenter which applies a sequence of augmenters in order
+type Pipeline []Augmenter
+
+// Augment returns samples transformed by all the augmenters of the pipeline
+func (p Pipeline) Augment(inMx *mat.Dense) *mat.Dense {
+	outMx := inMx
+	for _, a := range p {
+		outMx = a.Augment(outMx)
+	}
+	// empty pipeline must not return the supplied samples either
+	if outMx == inMx {
+		outMx = mat.DenseCopyOf(inMx)
+	}
+	return outMx
+}
+
+// RandState returns the random states of all the pipeline augmenters which implement RandomState in order
+func (p Pipeline) RandState() []int64 {
+	var state []int64
+	for _, a := range p {
+		if r, ok := a.(RandomState); ok {
+			state = append(state, r.RandState()...)
+		}
+	}
+	return state
+}
+
+// SetRandState restores the random states of all the pipeline augmenters which implement RandomState.
+// It fails with error if the state does not match the pipeline.
+func (p Pipeline) SetRandState(state []int64) error {
+	rest := state
+	for _, a := range p {
+		r, ok := a.(RandomState)
+		if !ok {
+			continue
+		}
+		size := len(r.RandState())
+		if len(rest) < size {
+			return fmt.Errorf("Incorrect random state: %v\n", state)
+		}
+		if err := r.SetRandState(rest[:size]); err != nil {
+			return err
+		}
+		rest = rest[size:]
+	}
+	if len(rest) > 0 {
+		return fmt.Errorf("Incorrect random state: %v\n", state)
+	}
+	return nil
+}
+
+// GaussianNoise is Augmenter which adds Gaussian noise with zero mean to all sample features
+type GaussianNoise struct {
+	// std is noise standard deviation
+	std float64
+	// randState is random source
+	randState
+}
+
+// NewGaussianNoise creates new GaussianNoise augmenter with the supplied standard deviation
+// whose random source is initialized with the supplied seed and returns it.
+// It fails with error if the standard deviation is not positive.
+func NewGaussianNoise(std float64, seed int64) (*GaussianNoise, error) {
+	if std <= 0 {
+		return nil, fmt.Errorf("Incorrect noise standard deviation: %f\n", std)
+	}
+	return &GaussianNoise{
+		std:       std,
+		randState: newRandState(seed),
+	}, nil
+}
+
+// Augment returns the samples with added noise
+func (g *GaussianNoise) Augment(inMx *mat.Dense) *mat.Dense {
+	outMx := new(mat.Dense)
+	outMx.Apply(func(i, j int, x float64) float64 {
+		return x + g.rng.NormFloat64()*g.std
+	}, inMx)
+	return outMx
+}
+
+// FeatureDropout is Augmenter which sets sample features to zero with a constant probability
+type FeatureDropout struct {
+	// p is dropout probability
+	p float64
+	// randState is random source
+	randState
+}
+
+// NewFeatureDropout creates new FeatureDropout augmenter with the supplied dropout probability
+// whose random source is initialized with the supplied seed and returns it.
+// It fails with error if the probability is not in (0, 1).
+func NewFeatureDropout(p float64, seed int64) (*FeatureDropout, error) {
+	if p <= 0 || p >= 1 {
+		return nil, fmt.Errorf("Incorrect dropout probability: %f\n", p)
+	}
+	return &FeatureDropout{
+		p:         p,
+		randState: newRandState(seed),
+	}, nil
+}
+
+// Augment returns the samples with dropped features
+func (d *FeatureDropout) Augment(inMx *mat.Dense) *mat.Dense {
+	outMx := new(mat.Dense)
+	outMx.Apply(func(i, j int, x float64) float64 {
+		if d.rng.Float64() < d.p {
+			return 0.0
+		}
+		return x
+	}, inMx)
+	return outMx
+}
+
+// ImageShape describes the layout of image samples loaded by LoadImageDir: every row contains
+// Height rows of Width pixels and every pixel has Channels values next to each other.
+type ImageShape struct {
+	// Width is image width in pixels
+	Width int
+	// Height is image height in pixels
+	Height int
+	// Channels is a number of pixel values: 1 for grayscale and 3 for color images
+	Channels int
+}
+
+// validate returns error if the shape is invalid
+func (s ImageShape) validate() error {
+	if s.Width <= 0 || s.Height <= 0 || s.Channels <= 0 {
+		return fmt.Errorf("Incorrect image shape: %d x %d x %d\n", s.Width, s.Height, s.Channels)
+	}
+	return nil
+}
+
+// size returns the number of image sample features
+func (s ImageShape) size() int {
+	return s.Width * s.Height * s.Channels
+}
+
+// ImageShift is Augmenter which shifts every image by a random number of pixels horizontally
+// and vertically. Pixels shifted in from outside of the image are zero.
+type ImageShift struct {
+	// shape is image shape
+	shape ImageShape
+	// max is the maximum shift in pixels
+	max int
+	// randState is random source
+	randState
+}
+
+// NewImageShift creates new ImageShift augmenter of images with the supplied shape which shifts
+// the images by at most max pixels in both directions and returns it. Its random source is
+// initialized with the supplied seed. It fails with error if the shape is invalid or if max
+// is not positive.
+func NewImageShift(shape ImageShape, max int, seed int64) (*ImageShift, error) {
+	if err := shape.validate(); err != nil {
+		return nil, err
+	}
+	if max <= 0 {
+		return nil, fmt.Errorf("Incorrect maximum shift: %d\n", max)
+	}
+	return &ImageShift{
+		shape:     shape,
+		max:       max,
+		randState: newRandState(seed),
+	}, nil
+}
+
+// Augment returns the shifted images. Samples which don't match the image shape are returned unchanged.
+func (s *ImageShift) Augment(inMx *mat.Dense) *mat.Dense {
+	rows, cols := inMx.Dims()
+	if cols != s.shape.size() {
+		return mat.DenseCopyOf(inMx)
+	}
+	outMx := mat.NewDense(rows, cols, nil)
+	w, h, ch := s.shape.Width, s.shape.Height, s.shape.Channels
+	for i := 0; i < rows; i++ {
+		dx := s.rng.Intn(2*s.max+1) - s.max
+		dy := s.rng.Intn(2*s.max+1) - s.max
+		in, out := inMx.RawRowView(i), outMx.RawRowView(i)
+		for y := 0; y < h; y++ {
+			srcY := y - dy
+			if srcY < 0 || srcY >= h {
+				continue
+			}
+			for x := 0; x < w; x++ {
+				srcX := x - dx
+				if srcX < 0 || srcX >= w {
+					continue
+				}
+				copy(out[(y*w+x)*ch:(y*w+x+1)*ch], in[(srcY*w+srcX)*ch:(srcY*w+srcX+1)*ch])
+			}
+		}
+	}
+	return outMx
+}
+
+// ImageFlip is Augmenter which flips every image horizontally with probability 0.5
+type ImageFlip struct {
+	// shape is image shape
+	shape ImageShape
+	// randState is random source
+	randState
+}
+
+// NewImageFlip creates new ImageFlip augmenter of images with the supplied shape whose random
+// source is initialized with the supplied seed and returns it. It fails with error if the shape is invalid.
+func NewImageFlip(shape ImageShape, seed int64) (*ImageFlip, error) {
+	if err := shape.validate(); err != nil {
+		return nil, err
+	}
+	return &ImageFlip{
+		shape:     shape,
+		randState: newRandState(seed),
+	}, nil
+}
+
+// Augment returns the randomly flipped images. Samples which don't match the image shape are returned unchanged.
+func (f *ImageFlip) Augment(inMx *mat.Dense) *mat.Dense {
+	outMx := mat.DenseCopyOf(inMx)
+	rows, cols := inMx.Dims()
+	if cols != f.shape.size() {
+		return outMx
+	}
+	w, h, ch := f.shape.Width, f.shape.Height, f.shape.Channels
+	for i := 0; i < rows; i++ {
+		if f.rng.Intn(2) == 0 {
+			continue
+		}
+		in, out := inMx.RawRowView(i), outMx.RawRowView(i)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				srcX := w - 1 - x
+				copy(out[(y*w+x)*ch:(y*w+x+1)*ch], in[(y*w+srcX)*ch:(y*w+srcX+1)*ch])
+			}
+		}
+	}
+	return outMx
+}
@@ -0,0 +1,169 @@
+package dataset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestGaussianNoise(t *testing.T) {
+	assert := assert.New(t)
+
+	g, err := NewGaussianNoise(0.0, 1)
+	assert.Nil(g)
+	assert.Error(err)
+	g, err = NewGaussianNoise(0.1, 1)
+	assert.NoError(err)
+	inMx := mat.NewDense(2, 2, []float64{1, 2, 3, 4})
+	orig := mat.DenseCopyOf(inMx)
+	outMx := g.Augment(inMx)
+	assert.True(mat.Equal(orig, inMx))
+	assert.False(mat.Equal(orig, outMx))
+	assert.True(mat.EqualApprox(orig, outMx, 1.0))
+	// the same seed gives the same noise
+	g2, err := NewGaussianNoise(0.1, 1)
+	assert.NoError(err)
+	assert.True(mat.Equal(outMx, g2.Augment(inMx)))
+}
+
+func TestFeatureDropout(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, p := range []float64{0.0, 1.0} {
+		d, err := NewFeatureDropout(p, 1)
+		assert.Nil(d)
+		assert.Error(err)
+	}
+	d, err := NewFeatureDropout(0.5, 1)
+	assert.NoError(err)
+	inMx := mat.NewDense(10, 10, nil)
+	inMx.Apply(func(i, j int, x float64) float64 { return 1.0 }, inMx)
+	outMx := d.Augment(inMx)
+	dropped := 0
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 10; j++ {
+			assert.Equal(1.0, inMx.At(i, j))
+			if outMx.At(i, j) == 0.0 {
+				dropped++
+			} else {
+				assert.Equal(1.0, outMx.At(i, j))
+			}
+		}
+	}
+	assert.True(dropped > 0 && dropped < 100)
+}
+
+func TestImageShift(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := NewImageShift(ImageShape{Width: 0, Height: 2, Channels: 1}, 1, 1)
+	assert.Nil(s)
+	assert.Error(err)
+	s, err = NewImageShift(ImageShape{Width: 2, Height: 2, Channels: 1}, 0, 1)
+	assert.Nil(s)
+	assert.Error(err)
+	s, err = NewImageShift(ImageShape{Width: 3, Height: 1, Channels: 2}, 1, 1)
+	assert.NoError(err)
+	inMx := mat.NewDense(20, 6, nil)
+	for i := 0; i < 20; i++ {
+		inMx.SetRow(i, []float64{1, 2, 3, 4, 5, 6})
+	}
+	outMx := s.Augment(inMx)
+	// every image is shifted by at most one pixel and pixels stay together
+	shifts := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		row := outMx.RawRowView(i)
+		switch {
+		case mat.Equal(mat.NewVecDense(6, row), mat.NewVecDense(6, []float64{1, 2, 3, 4, 5, 6})):
+			shifts["none"] = true
+		case mat.Equal(mat.NewVecDense(6, row), mat.NewVecDense(6, []float64{0, 0, 1, 2, 3, 4})):
+			shifts["right"] = true
+		case mat.Equal(mat.NewVecDense(6, row), mat.NewVecDense(6, []float64{3, 4, 5, 6, 0, 0})):
+			shifts["left"] = true
+		case mat.Equal(mat.NewVecDense(6, row), mat.NewVecDense(6, []float64{0, 0, 0, 0, 0, 0})):
+			shifts["vertical"] = true
+		default:
+			t.Errorf("Unexpected shifted image: %v", row)
+		}
+	}
+	assert.Len(shifts, 4)
+	// samples which are not images are not changed
+	otherMx := mat.NewDense(1, 2, []float64{1, 2})
+	assert.True(mat.Equal(otherMx, s.Augment(otherMx)))
+}
+
+func TestImageFlip(t *testing.T) {
+	assert := assert.New(t)
+
+	f, err := NewImageFlip(ImageShape{Width: 2, Height: 2}, 1)
+	assert.Nil(f)
+	assert.Error(err)
+	f, err = NewImageFlip(ImageShape{Width: 2, Height: 2, Channels: 1}, 1)
+	assert.NoError(err)
+	inMx := mat.NewDense(20, 4, nil)
+	for i := 0; i < 20; i++ {
+		inMx.SetRow(i, []float64{1, 2, 3, 4})
+	}
+	outMx := f.Augment(inMx)
+	flipped := 0
+	for i := 0; i < 20; i++ {
+		assert.Equal([]float64{1, 2, 3, 4}, inMx.RawRowView(i))
+		if outMx.At(i, 0) == 2 {
+			assert.Equal([]float64{2, 1, 4, 3}, outMx.RawRowView(i))
+			flipped++
+		} else {
+			assert.Equal([]float64{1, 2, 3, 4}, outMx.RawRowView(i))
+		}
+	}
+	assert.True(flipped > 0 && flipped < 20)
+}
+
+func TestPipeline(t *testing.T) {
+	assert := assert.New(t)
+
+	inMx := mat.NewDense(1, 2, []float64{1, 2})
+	// empty pipeline copies the samples
+	outMx := Pipeline{}.Augment(inMx)
+	assert.True(mat.Equal(inMx, outMx))
+	assert.True(outMx != inMx)
+	double := AugmenterFunc(func(m *mat.Dense) *mat.Dense {
+		out := new(mat.Dense)
+		out.Scale(2.0, m)
+		return out
+	})
+	addOne := AugmenterFunc(func(m *mat.Dense) *mat.Dense {
+		out := new(mat.Dense)
+		out.Apply(func(i, j int, x float64) float64 { return x + 1 }, m)
+		return out
+	})
+	outMx = Pipeline{double, addOne}.Augment(inMx)
+	assert.Equal([]float64{3, 5}, outMx.RawRowView(0))
+}
+
+func TestRandomState(t *testing.T) {
+	assert := assert.New(t)
+
+	newPipeline := func() Pipeline {
+		g, err := NewGaussianNoise(0.1, 1)
+		assert.NoError(err)
+		d, err := NewFeatureDropout(0.5, 2)
+		assert.NoError(err)
+		return Pipeline{g, AugmenterFunc(func(inMx *mat.Dense) *mat.Dense { return mat.DenseCopyOf(inMx) }), d}
+	}
+	inMx := mat.NewDense(2, 2, []float64{1, 2, 3, 4})
+	p := newPipeline()
+	p.Augment(inMx)
+	state := p.RandState()
+	assert.Len(state, 2)
+	expMx := p.Augment(inMx)
+	// restored augmenters continue with the same transformations
+	restored := newPipeline()
+	assert.NoError(restored.SetRandState(state))
+	assert.Equal(state, restored.RandState())
+	assert.True(mat.Equal(expMx, restored.Augment(inMx)))
+	// state does not match the pipeline
+	assert.Error(restored.SetRandState([]int64{1}))
+	assert.Error(restored.SetRandState([]int64{1, 2, 3}))
+	assert.Error(restored.SetRandState([]int64{-1, 2}))
+}
@@ -0,0 +1,182 @@
+package dataset
+
+import (
+	"fmt"
+	"math/rand"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Batches iterates over mini-batches of data samples and their labels.
+// Each epoch passes all the samples in mini-batches, optionally in shuffled order.
+// Mini-batches of samples which are not shuffled are views of the data, so the data
+// is never copied as a whole; shuffled mini-batches copy only the samples they contain.
+type Batches struct {
+	// inMx contains data samples in rows
+	inMx *mat.Dense
+	// labels contains sample labels
+	labels *mat.VecDense
+	// size is mini-batch size
+	size int
+	// shuffle requests shuffling of samples every epoch
+	shuffle bool
+	// dropLast requests dropping the last incomplete mini-batch
+	dropLast bool
+	// rng is random source used for shuffling
+	rng *rand.Rand
+	// indices contains indices of selected samples: nil means all the samples
+	indices []int
+	// order is the order of shuffled or selected samples in the current epoch
+	order []int
+	// pos is the position of the next mini-batch in order
+	pos int
+}
+
+// NewBatches creates new mini-batch iterator over the supplied data and returns it. labels can
+// be nil if the data are not labeled. Zero size or size bigger than the number of samples yields
+// all the samples in a single mini-batch. If shuffle is true the samples are shuffled every epoch
+// using random source initialized with the supplied seed. If dropLast is true the last mini-batch
+// is dropped if it is smaller than the requested size. It fails with error if the data are nil,
+// if the number of labels does not match the number of samples or if the size is negative.
+func NewBatches(inMx *mat.Dense, labels *mat.VecDense, size int, shuffle, dropLast bool, seed int64) (*Batches, error) {
+	if inMx == nil {
+		return nil, fmt.Errorf("Incorrect data supplied: %v\n", inMx)
+	}
+	samples, _ := inMx.Dims()
+	if labels != nil && labels.Len() != samples {
+		return nil, fmt.Errorf("Labels mismatch. Samples: %d, Labels: %d\n", samples, labels.Len())
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("Incorrect batch size: %d\n", size)
+	}
+	if size == 0 || size > samples {
+		size = samples
+	}
+	b := &Batches{
+		inMx:     inMx,
+		labels:   labels,
+		size:     size,
+		shuffle:  shuffle,
+		dropLast: dropLast,
+		rng:      rand.New(rand.NewSource(seed)),
+	}
+	b.Reset()
+	return b, nil
+}
+
+// Size returns mini-batch size
+func (b Batches) Size() int {
+	return b.size
+}
+
+// Len returns the number of mini-batches in every epoch
+func (b Batches) Len() int {
+	samples := b.samples()
+	// selected samples can be fewer than mini-batch size
+	size := b.size
+	if size > samples {
+		size = samples
+	}
+	if b.dropLast {
+		return samples / size
+	}
+	return (samples + size - 1) / size
+}
+
+// Reset starts new epoch. Samples are reshuffled if shuffling was requested.
+func (b *Batches) Reset() {
+	b.pos = 0
+	b.order = b.indices
+	if !b.shuffle {
+		return
+	}
+	b.order = b.rng.Perm(b.samples())
+	if b.indices != nil {
+		for i, j := range b.order {
+			b.order[i] = b.indices[j]
+		}
+	}
+}
+
+// Select restricts the following epochs to the samples with the supplied indices. The samples
+// are passed in the supplied order unless shuffling was requested and indices can repeat, which
+// allows to oversample some of the samples. nil indices select all the samples again. Selection
+// takes effect when the next epoch starts. It fails with error if the indices are empty or if any
+// of them is out of range. Selected samples are copied into mini-batches.
+func (b *Batches) Select(indices []int) error {
+	if indices == nil {
+		b.indices = nil
+		return nil
+	}
+	if len(indices) == 0 {
+		return fmt.Errorf("Empty sample selection\n")
+	}
+	samples, _ := b.inMx.Dims()
+	for _, idx := range indices {
+		if idx < 0 || idx >= samples {
+			return fmt.Errorf("Incorrect sample index: %d\n", idx)
+		}
+	}
+	b.indices = append([]int{}, indices...)
+	return nil
+}
+
+// samples returns the number of samples in every epoch
+func (b Batches) samples() int {
+	if b.indices != nil {
+		return len(b.indices)
+	}
+	samples, _ := b.inMx.Dims()
+	return samples
+}
+
+// Next returns the next mini-batch of samples and their labels. Labels are nil if the data
+// are not labeled. It returns false when there are no more mini-batches in the current epoch.
+func (b *Batches) Next() (*mat.Dense, *mat.VecDense, bool) {
+	_, cols := b.inMx.Dims()
+	samples := len(b.order)
+	if b.order == nil {
+		samples, _ = b.inMx.Dims()
+	}
+	size := b.size
+	if size > samples {
+		size = samples
+	}
+	end := b.pos + size
+	if end > samples {
+		end = samples
+	}
+	if b.pos >= samples || (b.dropLast && end-b.pos < size) {
+		return nil, nil, false
+	}
+	start := b.pos
+	b.pos = end
+	// contiguous samples are returned as views
+	if b.order == nil {
+		inMx := b.inMx.Slice(start, end, 0, cols).(*mat.Dense)
+		if b.labels == nil {
+			return inMx, nil, true
+		}
+		return inMx, b.labels.SliceVec(start, end).(*mat.VecDense), true
+	}
+	inMx, labels := Batch(b.inMx, b.labels, b.order[start:end])
+	return inMx, labels, true
+}
+
+// Batch returns a mini-batch which contains copies of the samples with the supplied indices
+// along with their labels. Labels are nil if the supplied labels are nil.
+func Batch(inMx *mat.Dense, labels *mat.VecDense, indices []int) (*mat.Dense, *mat.VecDense) {
+	_, cols := inMx.Dims()
+	batchInMx := mat.NewDense(len(indices), cols, nil)
+	for i, idx := range indices {
+		batchInMx.SetRow(i, inMx.RawRowView(idx))
+	}
+	if labels == nil {
+		return batchInMx, nil
+	}
+	batchLabels := mat.NewVecDense(len(indices), nil)
+	for i, idx := range indices {
+		batchLabels.SetVec(i, labels.At(idx, 0))
+	}
+	return batchInMx, batchLabels
+}
@@ -0,0 +1,180 @@
+package dataset
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func newBatchData() (*mat.Dense, *mat.VecDense) {
+	inMx := mat.NewDense(5, 2, []float64{
+		0.0, 0.5,
+		1.0, 1.5,
+		2.0, 2.5,
+		3.0, 3.5,
+		4.0, 4.5,
+	})
+	labels := mat.NewVecDense(5, []float64{0, 1, 2, 3, 4})
+	return inMx, labels
+}
+
+func TestNewBatches(t *testing.T) {
+	assert := assert.New(t)
+	inMx, labels := newBatchData()
+	// create new batches
+	b, err := NewBatches(inMx, labels, 2, false, false, 1)
+	assert.NotNil(b)
+	assert.NoError(err)
+	assert.Equal(2, b.Size())
+	assert.Equal(3, b.Len())
+	// drop last incomplete batch
+	b, err = NewBatches(inMx, labels, 2, false, true, 1)
+	assert.NoError(err)
+	assert.Equal(2, b.Len())
+	// zero size means all samples in one batch
+	b, err = NewBatches(inMx, nil, 0, false, false, 1)
+	assert.NoError(err)
+	assert.Equal(5, b.Size())
+	assert.Equal(1, b.Len())
+	// nil data
+	b, err = NewBatches(nil, labels, 2, false, false, 1)
+	assert.Nil(b)
+	assert.Error(err)
+	// labels mismatch
+	b, err = NewBatches(inMx, mat.NewVecDense(3, nil), 2, false, false, 1)
+	assert.Nil(b)
+	assert.Error(err)
+	// negative size
+	b, err = NewBatches(inMx, labels, -1, false, false, 1)
+	assert.Nil(b)
+	assert.Error(err)
+}
+
+func TestBatchesNext(t *testing.T) {
+	assert := assert.New(t)
+	inMx, labels := newBatchData()
+	// batches without shuffling follow the data order
+	b, err := NewBatches(inMx, labels, 2, false, false, 1)
+	assert.NoError(err)
+	var sizes []int
+	var seen []float64
+	for batchInMx, batchLabels, ok := b.Next(); ok; batchInMx, batchLabels, ok = b.Next() {
+		rows, _ := batchInMx.Dims()
+		assert.Equal(rows, batchLabels.Len())
+		sizes = append(sizes, rows)
+		for i := 0; i < rows; i++ {
+			assert.Equal(batchLabels.At(i, 0), batchInMx.At(i, 0))
+			seen = append(seen, batchLabels.At(i, 0))
+		}
+	}
+	assert.Equal([]int{2, 2, 1}, sizes)
+	assert.Equal([]float64{0, 1, 2, 3, 4}, seen)
+	// epoch is over until reset
+	_, _, ok := b.Next()
+	assert.False(ok)
+	b.Reset()
+	_, _, ok = b.Next()
+	assert.True(ok)
+	// drop last incomplete batch
+	b, err = NewBatches(inMx, nil, 2, false, true, 1)
+	assert.NoError(err)
+	count := 0
+	for batchInMx, batchLabels, ok := b.Next(); ok; batchInMx, batchLabels, ok = b.Next() {
+		rows, _ := batchInMx.Dims()
+		assert.Equal(2, rows)
+		assert.Nil(batchLabels)
+		count++
+	}
+	assert.Equal(2, count)
+}
+
+func TestBatchesShuffle(t *testing.T) {
+	assert := assert.New(t)
+	inMx, labels := newBatchData()
+	epoch := func(b *Batches) []float64 {
+		var seen []float64
+		for batchInMx, batchLabels, ok := b.Next(); ok; batchInMx, batchLabels, ok = b.Next() {
+			rows, _ := batchInMx.Dims()
+			for i := 0; i < rows; i++ {
+				assert.Equal(batchLabels.At(i, 0), batchInMx.At(i, 0))
+				seen = append(seen, batchLabels.At(i, 0))
+			}
+		}
+		return seen
+	}
+	b1, err := NewBatches(inMx, labels, 2, true, false, 42)
+	assert.NoError(err)
+	b2, err := NewBatches(inMx, labels, 2, true, false, 42)
+	assert.NoError(err)
+	// same seed yields the same order
+	first := epoch(b1)
+	assert.Equal(first, epoch(b2))
+	sorted := append([]float64(nil), first...)
+	sort.Float64s(sorted)
+	assert.Equal([]float64{0, 1, 2, 3, 4}, sorted)
+	// every epoch covers all samples
+	b1.Reset()
+	second := epoch(b1)
+	sort.Float64s(second)
+	assert.Equal([]float64{0, 1, 2, 3, 4}, second)
+	// shuffled batches do not modify the data
+	assert.Equal(2.0, inMx.At(2, 0))
+}
+
+func TestBatchesSelect(t *testing.T) {
+	assert := assert.New(t)
+	inMx, labels := newBatchData()
+	b, err := NewBatches(inMx, labels, 2, false, false, 42)
+	assert.NoError(err)
+	// incorrect selections
+	assert.Error(b.Select([]int{}))
+	assert.Error(b.Select([]int{0, 5}))
+	assert.Error(b.Select([]int{-1}))
+	// selected samples are passed in the selected order
+	assert.NoError(b.Select([]int{3, 0, 3}))
+	b.Reset()
+	assert.Equal(2, b.Len())
+	var seen []float64
+	for _, batchLabels, ok := b.Next(); ok; _, batchLabels, ok = b.Next() {
+		for i := 0; i < batchLabels.Len(); i++ {
+			seen = append(seen, batchLabels.At(i, 0))
+		}
+	}
+	assert.Equal([]float64{3, 0, 3}, seen)
+	// shuffled selection
+	b, err = NewBatches(inMx, labels, 0, true, true, 42)
+	assert.NoError(err)
+	assert.NoError(b.Select([]int{1, 4}))
+	b.Reset()
+	assert.Equal(1, b.Len())
+	_, batchLabels, ok := b.Next()
+	assert.True(ok)
+	seen = []float64{batchLabels.At(0, 0), batchLabels.At(1, 0)}
+	sort.Float64s(seen)
+	assert.Equal([]float64{1, 4}, seen)
+	_, _, ok = b.Next()
+	assert.False(ok)
+	// all the samples are selected again
+	assert.NoError(b.Select(nil))
+	b.Reset()
+	assert.Equal(1, b.Len())
+	batchInMx, _, ok := b.Next()
+	assert.True(ok)
+	rows, _ := batchInMx.Dims()
+	assert.Equal(5, rows)
+}
+
+func TestBatch(t *testing.T) {
+	assert := assert.New(t)
+	inMx, labels := newBatchData()
+	batchInMx, batchLabels := Batch(inMx, labels, []int{4, 1})
+	assert.Equal([]float64{4.0, 4.5}, batchInMx.RawRowView(0))
+	assert.Equal([]float64{1.0, 1.5}, batchInMx.RawRowView(1))
+	assert.Equal(4.0, batchLabels.At(0, 0))
+	assert.Equal(1.0, batchLabels.At(1, 0))
+	// nil labels
+	_, batchLabels = Batch(inMx, nil, []int{0})
+	assert.Nil(batchLabels)
+}
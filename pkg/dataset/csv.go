@@ -0,0 +1,322 @@
+package dataset
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// CSVOptions allows to specify the schema of CSV data read by CSVReader.
+// Columns are referred to by their names from the header. If the data have no header,
+// the columns are named by their indices counted from 0: "0", "1", etc.
+type CSVOptions struct {
+	// Comma is field delimiter: 0 means ','
+	Comma rune
+	// Comment is comment character: lines starting with it are ignored. 0 disables comments
+	Comment rune
+	// Header indicates that the first record contains column names
+	Header bool
+	// Label is the name of label column: empty label means the data are not labeled
+	Label string
+	// Ignore contains names of ignored columns
+	Ignore []string
+	// Categorical contains names of categorical columns. Categorical features are one-hot
+	// encoded into one column per category. Categorical labels are mapped to class labels 1, 2, ...
+	Categorical []string
+	// Categories contains known categories of categorical columns keyed by column name.
+	// Categories which are not known are collected from the data in the order of their first
+	// appearance, which is only possible for labels or if all the data are read at once.
+	Categories map[string][]string
+}
+
+// category holds categories of a categorical column
+type category struct {
+	// values contains category values in the order of their indices
+	values []string
+	// index maps category values to their indices
+	index map[string]int
+	// fixed is true if new categories can not be added
+	fixed bool
+}
+
+// newCategory creates new category with the supplied values
+func newCategory(values []string, fixed bool) *category {
+	c := &category{index: make(map[string]int)}
+	for _, value := range values {
+		c.add(value)
+	}
+	c.fixed = fixed
+	return c
+}
+
+// add adds the supplied value to the categories if it's not there yet and returns its index.
+// It returns -1 if the value is not known and the categories are fixed.
+func (c *category) add(value string) int {
+	if i, ok := c.index[value]; ok {
+		return i
+	}
+	if c.fixed {
+		return -1
+	}
+	c.index[value] = len(c.values)
+	c.values = append(c.values, value)
+	return c.index[value]
+}
+
+// CSVReader reads labeled or unlabeled CSV data into feature matrices and label vectors.
+// The data can be read at once or in chunks of records, which allows to stream large files.
+type CSVReader struct {
+	// r reads CSV records
+	r *csv.Reader
+	// names contains names of all CSV columns
+	names []string
+	// label is label column index: -1 if the data are not labeled
+	label int
+	// features contains indices of feature columns
+	features []int
+	// cats maps indices of categorical columns to their categories
+	cats map[int]*category
+	// pending is the first data record read when the data have no header
+	pending []string
+	// line is the number of read records
+	line int
+}
+
+// NewCSVReader creates new CSVReader which reads the CSV data from the supplied reader using
+// the supplied options and returns it. nil options read unlabeled numeric data without header.
+// The header, or the first record if there is no header, is read immediately. It fails with error
+// if the data are empty, if the options refer to unknown columns or if the categories are invalid.
+func NewCSVReader(r io.Reader, opts *CSVOptions) (*CSVReader, error) {
+	if opts == nil {
+		opts = &CSVOptions{}
+	}
+	csvReader := csv.NewReader(r)
+	if opts.Comma != 0 {
+		csvReader.Comma = opts.Comma
+	}
+	csvReader.Comment = opts.Comment
+	record, err := csvReader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("Empty CSV data\n")
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &CSVReader{
+		r:     csvReader,
+		label: -1,
+		cats:  make(map[int]*category),
+	}
+	// columns without header are named by their indices
+	if opts.Header {
+		c.names = record
+	} else {
+		c.pending = record
+		for i := range record {
+			c.names = append(c.names, strconv.Itoa(i))
+		}
+	}
+	columns := make(map[string]int)
+	for i, name := range c.names {
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("Duplicate CSV column: %s\n", name)
+		}
+		columns[name] = i
+	}
+	if opts.Label != "" {
+		if c.label, err = column(columns, opts.Label); err != nil {
+			return nil, err
+		}
+	}
+	ignored := make(map[int]bool)
+	for _, name := range opts.Ignore {
+		i, err := column(columns, name)
+		if err != nil {
+			return nil, err
+		}
+		ignored[i] = true
+	}
+	for _, name := range opts.Categorical {
+		i, err := column(columns, name)
+		if err != nil {
+			return nil, err
+		}
+		values, fixed := opts.Categories[name]
+		if fixed && len(values) == 0 {
+			return nil, fmt.Errorf("No categories of CSV column: %s\n", name)
+		}
+		c.cats[i] = newCategory(values, fixed)
+		if len(c.cats[i].values) != len(values) {
+			return nil, fmt.Errorf("Duplicate categories of CSV column: %s\n", name)
+		}
+	}
+	for name := range opts.Categories {
+		if i, ok := columns[name]; !ok || c.cats[i] == nil {
+			return nil, fmt.Errorf("Categories of non-categorical CSV column: %s\n", name)
+		}
+	}
+	for i := range c.names {
+		if i != c.label && !ignored[i] {
+			c.features = append(c.features, i)
+		}
+	}
+	if len(c.features) == 0 {
+		return nil, fmt.Errorf("No CSV feature columns\n")
+	}
+	return c, nil
+}
+
+// column returns the index of the column with the supplied name
+func column(columns map[string]int, name string) (int, error) {
+	i, ok := columns[name]
+	if !ok {
+		return 0, fmt.Errorf("Unknown CSV column: %s\n", name)
+	}
+	return i, nil
+}
+
+// Columns returns the names of feature matrix columns. Categorical features are named
+// "column=category". Columns of categories collected from the data are only known
+// after the data have been read.
+func (c CSVReader) Columns() []string {
+	var names []string
+	for _, i := range c.features {
+		cat, ok := c.cats[i]
+		if !ok {
+			names = append(names, c.names[i])
+			continue
+		}
+		for _, value := range cat.values {
+			names = append(names, c.names[i]+"="+value)
+		}
+	}
+	return names
+}
+
+// Classes returns categories of categorical labels: category i is mapped to class label i+1.
+// It returns nil if the labels are not categorical.
+func (c CSVReader) Classes() []string {
+	cat, ok := c.cats[c.label]
+	if !ok {
+		return nil
+	}
+	return append([]string{}, cat.values...)
+}
+
+// Read reads at most n records and returns their feature matrix and label vector. Labels are nil
+// if the data are not labeled. Non-positive n reads all the remaining records. It returns io.EOF
+// if there are no more records. Empty numeric features are missing values, which are read as NaN
+// and can be filled in by Imputer. It fails with error if any other field can not be parsed or if
+// a chunk of records contains categorical features whose categories are not known.
+func (c *CSVReader) Read(n int) (*mat.Dense, *mat.VecDense, error) {
+	// features of unknown categories change the number of columns between chunks
+	if n > 0 {
+		for _, i := range c.features {
+			if cat, ok := c.cats[i]; ok && !cat.fixed {
+				return nil, nil, fmt.Errorf("Unknown categories of CSV column: %s\n", c.names[i])
+			}
+		}
+	}
+	var records [][]float64
+	var labels []float64
+	for n <= 0 || len(records) < n {
+		record, err := c.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		// ignored columns are not parsed
+		values := make([]float64, len(record))
+		for _, i := range c.features {
+			if values[i], err = c.parse(i, record[i]); err != nil {
+				return nil, nil, err
+			}
+		}
+		records = append(records, values)
+		if c.label >= 0 {
+			label, err := c.parse(c.label, record[c.label])
+			if err != nil {
+				return nil, nil, err
+			}
+			labels = append(labels, label)
+		}
+	}
+	if len(records) == 0 {
+		return nil, nil, io.EOF
+	}
+	cols := len(c.Columns())
+	inMx := mat.NewDense(len(records), cols, nil)
+	for r, values := range records {
+		col := 0
+		for _, i := range c.features {
+			cat, ok := c.cats[i]
+			if !ok {
+				inMx.Set(r, col, values[i])
+				col++
+				continue
+			}
+			inMx.Set(r, col+int(values[i]), 1.0)
+			col += len(cat.values)
+		}
+	}
+	if labels == nil {
+		return inMx, nil, nil
+	}
+	return inMx, mat.NewVecDense(len(labels), labels), nil
+}
+
+// next returns the next data record
+func (c *CSVReader) next() ([]string, error) {
+	c.line++
+	if c.pending != nil {
+		record := c.pending
+		c.pending = nil
+		return record, nil
+	}
+	return c.r.Read()
+}
+
+// parse parses the field of the column i. Categorical features are parsed into category indices
+// and categorical labels are parsed into class labels.
+func (c *CSVReader) parse(i int, field string) (float64, error) {
+	cat, ok := c.cats[i]
+	if !ok {
+		if field == "" && i != c.label {
+			return math.NaN(), nil
+		}
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return 0, fmt.Errorf("Incorrect value of CSV column %s in record %d: %s\n", c.names[i], c.line, field)
+		}
+		return value, nil
+	}
+	index := cat.add(field)
+	if index < 0 {
+		return 0, fmt.Errorf("Unknown category of CSV column %s in record %d: %s\n", c.names[i], c.line, field)
+	}
+	if i == c.label {
+		return float64(index + 1), nil
+	}
+	return float64(index), nil
+}
+
+// LoadCSVData reads all the CSV data from the supplied reader using the supplied options and
+// returns their feature matrix and label vector. Labels are nil if the data are not labeled.
+// It fails with error if the data are empty or if they don't match the options.
+func LoadCSVData(r io.Reader, opts *CSVOptions) (*mat.Dense, *mat.VecDense, error) {
+	c, err := NewCSVReader(r, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	inMx, labels, err := c.Read(0)
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("Empty CSV data\n")
+	}
+	return inMx, labels, err
+}
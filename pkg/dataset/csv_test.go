@@ -0,0 +1,128 @@
+package dataset
+
+import (
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestLoadCSVData(t *testing.T) {
+	assert := assert.New(t)
+
+	data := "id;size;color;class\n" +
+		"# comment\n" +
+		"a;1.5;red;cat\n" +
+		"b;2.5;blue;dog\n" +
+		"c;3.5;red;dog\n"
+	opts := &CSVOptions{
+		Comma:       ';',
+		Comment:     '#',
+		Header:      true,
+		Label:       "class",
+		Ignore:      []string{"id"},
+		Categorical: []string{"color", "class"},
+	}
+	inMx, labels, err := LoadCSVData(strings.NewReader(data), opts)
+	assert.NoError(err)
+	assert.True(mat.Equal(inMx, mat.NewDense(3, 3, []float64{
+		1.5, 1.0, 0.0,
+		2.5, 0.0, 1.0,
+		3.5, 1.0, 0.0,
+	})))
+	assert.Equal([]float64{1, 2, 2}, labels.RawVector().Data)
+	// numeric data without header
+	inMx, labels, err = LoadCSVData(strings.NewReader("1,2\n3,4\n"), &CSVOptions{Label: "0"})
+	assert.NoError(err)
+	assert.True(mat.Equal(inMx, mat.NewDense(2, 1, []float64{2, 4})))
+	assert.Equal([]float64{1, 3}, labels.RawVector().Data)
+	// unlabeled data
+	inMx, labels, err = LoadCSVData(strings.NewReader("1,2\n3,4\n"), nil)
+	assert.NoError(err)
+	assert.True(mat.Equal(inMx, mat.NewDense(2, 2, []float64{1, 2, 3, 4})))
+	assert.Nil(labels)
+	// missing features are NaN, missing labels are incorrect
+	inMx, labels, err = LoadCSVData(strings.NewReader("1,,2\n"), &CSVOptions{Label: "2"})
+	assert.NoError(err)
+	assert.Equal(1.0, inMx.At(0, 0))
+	assert.True(math.IsNaN(inMx.At(0, 1)))
+	assert.Equal([]float64{2}, labels.RawVector().Data)
+	inMx, labels, err = LoadCSVData(strings.NewReader("1,2,\n"), &CSVOptions{Label: "2"})
+	assert.Error(err)
+	// incorrect data
+	incorrect := []struct {
+		data string
+		opts *CSVOptions
+	}{
+		{"", nil},
+		{"a,b\n", &CSVOptions{Header: true}},
+		{"1,x\n", nil},
+		{"1,2\n3\n", nil},
+		{"a,a\n1,2\n", &CSVOptions{Header: true}},
+		{"1,2\n", &CSVOptions{Label: "foo"}},
+		{"1,2\n", &CSVOptions{Ignore: []string{"foo"}}},
+		{"1,2\n", &CSVOptions{Ignore: []string{"0", "1"}}},
+		{"1,2\n", &CSVOptions{Categorical: []string{"foo"}}},
+		{"1,2\n", &CSVOptions{Categories: map[string][]string{"0": {"x"}}}},
+		{"1,2\n", &CSVOptions{Categorical: []string{"0"}, Categories: map[string][]string{"0": {}}}},
+		{"1,2\n", &CSVOptions{Categorical: []string{"0"}, Categories: map[string][]string{"0": {"x", "x"}}}},
+		{"x,2\n", &CSVOptions{Categorical: []string{"0"}, Categories: map[string][]string{"0": {"y"}}}},
+	}
+	for _, tc := range incorrect {
+		inMx, labels, err = LoadCSVData(strings.NewReader(tc.data), tc.opts)
+		assert.Nil(inMx)
+		assert.Nil(labels)
+		assert.Error(err, tc.data)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	assert := assert.New(t)
+
+	data := "size,color,class\n" +
+		"1,green,b\n" +
+		"2,red,a\n" +
+		"3,red,c\n"
+	opts := &CSVOptions{
+		Header:      true,
+		Label:       "class",
+		Categorical: []string{"color", "class"},
+		Categories:  map[string][]string{"color": {"red", "green"}},
+	}
+	r, err := NewCSVReader(strings.NewReader(data), opts)
+	assert.NoError(err)
+	assert.Equal([]string{"size", "color=red", "color=green"}, r.Columns())
+	// the data are streamed in chunks
+	inMx, labels, err := r.Read(2)
+	assert.NoError(err)
+	assert.True(mat.Equal(inMx, mat.NewDense(2, 3, []float64{1, 0, 1, 2, 1, 0})))
+	assert.Equal([]float64{1, 2}, labels.RawVector().Data)
+	inMx, labels, err = r.Read(2)
+	assert.NoError(err)
+	assert.True(mat.Equal(inMx, mat.NewDense(1, 3, []float64{3, 1, 0})))
+	assert.Equal([]float64{3}, labels.RawVector().Data)
+	assert.Equal([]string{"b", "a", "c"}, r.Classes())
+	inMx, labels, err = r.Read(2)
+	assert.Nil(inMx)
+	assert.Nil(labels)
+	assert.Equal(io.EOF, err)
+	// chunks require known categories of features
+	opts.Categories = nil
+	r, err = NewCSVReader(strings.NewReader(data), opts)
+	assert.NoError(err)
+	_, _, err = r.Read(2)
+	assert.Error(err)
+	inMx, _, err = r.Read(0)
+	assert.NoError(err)
+	assert.Equal([]string{"size", "color=green", "color=red"}, r.Columns())
+	rows, cols := inMx.Dims()
+	assert.Equal(3, rows)
+	assert.Equal(3, cols)
+	// numeric labels have no classes
+	r, err = NewCSVReader(strings.NewReader("1,2\n"), &CSVOptions{Label: "1"})
+	assert.NoError(err)
+	assert.Nil(r.Classes())
+}
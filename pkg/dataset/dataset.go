@@ -8,18 +8,18 @@ import (
 	"path/filepath"
 	"strconv"
 
-	"github.com/gonum/matrix/mat64"
-	"github.com/gonum/stat"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
 )
 
 // load data funcs
-var loadFuncs = map[string]func(io.Reader) (*mat64.Dense, error){
+var loadFuncs = map[string]func(io.Reader) (*mat.Dense, error){
 	".csv": LoadCSV,
 }
 
 // DataSet represents training data set
 type DataSet struct {
-	mx      mat64.Matrix
+	mx      mat.Matrix
 	labeled bool
 }
 
@@ -64,14 +64,14 @@ func (ds DataSet) IsLabeled() bool {
 }
 
 // Data returns the data set represented as matrix
-func (ds DataSet) Data() mat64.Matrix {
+func (ds DataSet) Data() mat.Matrix {
 	return ds.mx
 }
 
 // Features returns features matrix from the underlying raw data matrix
 // Raw matrix contains both features and labels read from the data file.
 // If the dataset is not labeled the function returns the raw data matrix
-func (ds DataSet) Features() mat64.Matrix {
+func (ds DataSet) Features() mat.Matrix {
 	if !(ds.labeled) {
 		return ds.mx
 	}
@@ -80,14 +80,14 @@ func (ds DataSet) Features() mat64.Matrix {
 	if cols == 1 {
 		return ds.mx
 	}
-	// turn mat64.Matrix into mat64.Dense matrix
-	dataMx := ds.mx.(*mat64.Dense)
-	return dataMx.View(0, 0, rows, cols-1)
+	// turn mat.Matrix into mat.Dense matrix
+	dataMx := ds.mx.(*mat.Dense)
+	return dataMx.Slice(0, rows, 0, cols-1)
 }
 
 // Labels returns data labels from the raw data.
 // If the data set is not labeled or if it only contains one columne it returns nil
-func (ds DataSet) Labels() mat64.Matrix {
+func (ds DataSet) Labels() mat.Matrix {
 	if !(ds.labeled) {
 		return nil
 	}
@@ -95,7 +95,7 @@ func (ds DataSet) Labels() mat64.Matrix {
 	if cols == 1 {
 		return nil
 	}
-	dataMx := ds.mx.(*mat64.Dense)
+	dataMx := ds.mx.(*mat.Dense)
 	return dataMx.ColView(cols - 1)
 }
 
@@ -103,7 +103,7 @@ func (ds DataSet) Labels() mat64.Matrix {
 // It returns data matrix that contains particular CSV fields in columns.
 // It returns error if the supplied data set contains corrrupted data or
 // if the data can not be converted to float numbers
-func LoadCSV(r io.Reader) (*mat64.Dense, error) {
+func LoadCSV(r io.Reader) (*mat.Dense, error) {
 	// data matrix dimensions: rows x cols
 	var rows, cols int
 	// mxData contains ALL data read field by field
@@ -142,14 +142,14 @@ func LoadCSV(r io.Reader) (*mat64.Dense, error) {
 		rows++
 	}
 	// Initialize data matrix with the read data
-	mx := mat64.NewDense(rows, cols, mxData)
+	mx := mat.NewDense(rows, cols, mxData)
 	return mx, nil
 }
 
 // Scale centers the data set to zero mean values and scales each column.
 // It modifies the data stored in the data set. If your data contains also
 // labeles in the last column, make sure you extract it before scaling.
-func Scale(mx mat64.Matrix) mat64.Matrix {
+func Scale(mx mat.Matrix) mat.Matrix {
 	rows, cols := mx.Dims()
 	// mean/stdev store each column mean/stdev values
 	col := make([]float64, rows)
@@ -157,14 +157,14 @@ func Scale(mx mat64.Matrix) mat64.Matrix {
 	stdev := make([]float64, cols)
 	for i := 0; i < cols; i++ {
 		// copy i-th column to col
-		mat64.Col(col, i, mx)
+		mat.Col(col, i, mx)
 		mean[i], stdev[i] = stat.MeanStdDev(col, nil)
 	}
 	scale := func(i, j int, x float64) float64 {
 		return (x - mean[j]) / stdev[j]
 	}
-	dataMx := new(mat64.Dense)
-	dataMx.Clone(mx)
+	dataMx := new(mat.Dense)
+	dataMx.CloneFrom(mx)
 	dataMx.Apply(scale, dataMx)
 	return dataMx
 }
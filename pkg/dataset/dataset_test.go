@@ -9,8 +9,8 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/gonum/matrix/mat64"
 	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
 )
 
 var (
@@ -102,7 +102,7 @@ func TestFeaturesLabels(t *testing.T) {
 	assert.NotNil(ds)
 	// features must be equal to Data
 	features = ds.Features()
-	assert.True(mat64.Equal(features, ds.Data()))
+	assert.True(mat.Equal(features, ds.Data()))
 	// labels must be nil
 	labels = ds.Labels()
 	assert.Nil(labels)
@@ -117,7 +117,7 @@ func TestFeaturesLabels(t *testing.T) {
 	assert.NotNil(ds)
 	// features are the same as raw data
 	features = ds.Features()
-	assert.True(mat64.Equal(features, ds.Data()))
+	assert.True(mat.Equal(features, ds.Data()))
 	// labels must be nil
 	labels = ds.Labels()
 	assert.Nil(labels)
@@ -137,9 +137,9 @@ func TestScale(t *testing.T) {
 		0, -0.1796053020267749,
 		1, 1.0776318121606494,
 	}
-	scaledMx := mat64.NewDense(3, 2, scaled)
+	scaledMx := mat.NewDense(3, 2, scaled)
 	scaledFeats := Scale(features)
-	assert.True(mat64.Equal(scaledFeats, scaledMx))
+	assert.True(mat.Equal(scaledFeats, scaledMx))
 }
 
 func TestLoadCSV(t *testing.T) {
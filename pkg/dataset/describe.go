@@ -0,0 +1,114 @@
+package dataset
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"text/tabwriter"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+)
+
+// FeatureStats contains statistics of a single feature. Missing values, which are represented
+// by NaN, are counted but are not included in the other statistics.
+type FeatureStats struct {
+	// Min is the minimum value
+	Min float64
+	// Max is the maximum value
+	Max float64
+	// Mean is the mean value
+	Mean float64
+	// Std is the sample standard deviation
+	Std float64
+	// Missing is the number of missing values
+	Missing int
+}
+
+// Summary contains statistics of a data set
+type Summary struct {
+	// Samples is the number of samples
+	Samples int
+	// Features contains statistics of every feature
+	Features []FeatureStats
+	// Classes maps class labels to the number of their samples: nil if the data are not labeled
+	Classes map[float64]int
+}
+
+// Describe computes statistics of every feature of the supplied data and the distribution
+// of the supplied labels. labels can be nil if the data are not labeled. Statistics of features
+// with no values are NaN. It fails with error if the data are nil or empty or if the number
+// of labels does not match the number of samples.
+func Describe(inMx mat.Matrix, labels *mat.VecDense) (*Summary, error) {
+	if err := checkFitData(inMx); err != nil {
+		return nil, err
+	}
+	rows, cols := inMx.Dims()
+	if labels != nil && labels.Len() != rows {
+		return nil, fmt.Errorf("Labels mismatch. Samples: %d, Labels: %d\n", rows, labels.Len())
+	}
+	s := &Summary{
+		Samples:  rows,
+		Features: make([]FeatureStats, cols),
+	}
+	for j := range s.Features {
+		col := make([]float64, 0, rows)
+		for i := 0; i < rows; i++ {
+			if x := inMx.At(i, j); !math.IsNaN(x) {
+				col = append(col, x)
+			}
+		}
+		f := &s.Features[j]
+		f.Missing = rows - len(col)
+		if len(col) == 0 {
+			f.Min, f.Max, f.Mean, f.Std = math.NaN(), math.NaN(), math.NaN(), math.NaN()
+			continue
+		}
+		f.Min, f.Max = col[0], col[0]
+		for _, x := range col[1:] {
+			f.Min, f.Max = math.Min(f.Min, x), math.Max(f.Max, x)
+		}
+		f.Mean, f.Std = stat.MeanStdDev(col, nil)
+		// single value has undefined standard deviation
+		if len(col) == 1 {
+			f.Std = 0.0
+		}
+	}
+	if labels != nil {
+		s.Classes = make(map[float64]int)
+		for i := 0; i < labels.Len(); i++ {
+			s.Classes[labels.At(i, 0)]++
+		}
+	}
+	return s, nil
+}
+
+// String returns the summary formatted as a table of feature statistics
+// followed by the class distribution
+func (s Summary) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Samples: %d\n", s.Samples)
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Feature\tMin\tMax\tMean\tStd\tMissing\t")
+	for j, f := range s.Features {
+		fmt.Fprintf(w, "%d\t%g\t%g\t%g\t%g\t%d\t\n", j, f.Min, f.Max, f.Mean, f.Std, f.Missing)
+	}
+	w.Flush()
+	if s.Classes == nil {
+		return buf.String()
+	}
+	labels := make([]float64, 0, len(s.Classes))
+	for label := range s.Classes {
+		labels = append(labels, label)
+	}
+	sort.Float64s(labels)
+	w = tabwriter.NewWriter(&buf, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Class\tSamples\tFraction\t")
+	for _, label := range labels {
+		count := s.Classes[label]
+		fmt.Fprintf(w, "%g\t%d\t%.4f\t\n", label, count, float64(count)/float64(s.Samples))
+	}
+	w.Flush()
+	return buf.String()
+}
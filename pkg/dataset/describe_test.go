@@ -0,0 +1,45 @@
+package dataset
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestDescribe(t *testing.T) {
+	assert := assert.New(t)
+
+	nan := math.NaN()
+	inMx := mat.NewDense(3, 3, []float64{
+		1.0, nan, nan,
+		2.0, 5.0, nan,
+		3.0, nan, nan,
+	})
+	labels := mat.NewVecDense(3, []float64{1, 2, 1})
+	s, err := Describe(inMx, labels)
+	assert.NoError(err)
+	assert.Equal(3, s.Samples)
+	assert.Equal(FeatureStats{Min: 1.0, Max: 3.0, Mean: 2.0, Std: 1.0}, s.Features[0])
+	assert.Equal(FeatureStats{Min: 5.0, Max: 5.0, Mean: 5.0, Std: 0.0, Missing: 2}, s.Features[1])
+	assert.Equal(3, s.Features[2].Missing)
+	assert.True(math.IsNaN(s.Features[2].Mean))
+	assert.Equal(map[float64]int{1: 2, 2: 1}, s.Classes)
+	out := s.String()
+	assert.True(strings.Contains(out, "Samples: 3"))
+	assert.True(strings.Contains(out, "0.6667"))
+	// unlabeled data
+	s, err = Describe(inMx, nil)
+	assert.NoError(err)
+	assert.Nil(s.Classes)
+	assert.False(strings.Contains(s.String(), "Class"))
+	// incorrect data
+	s, err = Describe(nil, nil)
+	assert.Nil(s)
+	assert.Error(err)
+	s, err = Describe(inMx, mat.NewVecDense(2, nil))
+	assert.Nil(s)
+	assert.Error(err)
+}
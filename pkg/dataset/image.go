@@ -0,0 +1,160 @@
+package dataset
+
+import (
+	"fmt"
+	"image"
+	// image decoders register themselves with image package
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// imageExts contains extensions of supported image files
+var imageExts = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+}
+
+// ImageOptions allows to specify how LoadImageDir converts images into samples
+type ImageOptions struct {
+	// Width is width of the resized images: 0 keeps the original width
+	Width int
+	// Height is height of the resized images: 0 keeps the original height
+	Height int
+	// Gray requests converting the images to grayscale
+	Gray bool
+}
+
+// LoadImageDir loads PNG and JPEG images from the class subdirectories of the supplied directory,
+// such as dir/cat/1.png and dir/dog/1.jpg, and returns their feature matrix, label vector and
+// class names. Classes are the sorted subdirectory names and class i is labeled i+1. Every image
+// becomes a row of pixel values scaled to [0, 1] in row-major order. Color pixels have 3 values,
+// red, green and blue, next to each other and grayscale pixels have a single luminance value.
+// Images are resized with bilinear interpolation if the options request a different size. nil options
+// keep the original size and colors. Other files are ignored. It fails with error if the directory
+// can't be read, if it contains no images, if any image fails to be decoded or if the images differ
+// in size.
+func LoadImageDir(dir string, opts *ImageOptions) (*mat.Dense, *mat.VecDense, []string, error) {
+	if opts == nil {
+		opts = &ImageOptions{}
+	}
+	if opts.Width < 0 || opts.Height < 0 {
+		return nil, nil, nil, fmt.Errorf("Incorrect image size: %d x %d\n", opts.Width, opts.Height)
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var classes []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			classes = append(classes, entry.Name())
+		}
+	}
+	sort.Strings(classes)
+	var data, labels []float64
+	var width, height int
+	for i, class := range classes {
+		files, err := ioutil.ReadDir(filepath.Join(dir, class))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for _, file := range files {
+			if file.IsDir() || !imageExts[strings.ToLower(filepath.Ext(file.Name()))] {
+				continue
+			}
+			path := filepath.Join(dir, class, file.Name())
+			img, err := loadImage(path)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			w, h := opts.Width, opts.Height
+			if w == 0 {
+				w = img.Bounds().Dx()
+			}
+			if h == 0 {
+				h = img.Bounds().Dy()
+			}
+			// the first image determines the sample size
+			if width == 0 {
+				width, height = w, h
+			}
+			if w != width || h != height {
+				return nil, nil, nil, fmt.Errorf("Image size mismatch %s: %d x %d\n", path, w, h)
+			}
+			data = append(data, imagePixels(img, w, h, opts.Gray)...)
+			labels = append(labels, float64(i+1))
+		}
+	}
+	if len(labels) == 0 {
+		return nil, nil, nil, fmt.Errorf("No images found in %s\n", dir)
+	}
+	cols := len(data) / len(labels)
+	return mat.NewDense(len(labels), cols, data), mat.NewVecDense(len(labels), labels), classes, nil
+}
+
+// loadImage decodes the image stored in the file with the supplied path
+func loadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("Incorrect image %s: %v\n", path, err)
+	}
+	return img, nil
+}
+
+// imagePixels returns the pixel values of the supplied image resized to width x height
+// using bilinear interpolation. Values are scaled to [0, 1].
+func imagePixels(img image.Image, width, height int, gray bool) []float64 {
+	bounds := img.Bounds()
+	channels := 3
+	if gray {
+		channels = 1
+	}
+	// color returns the channel values of the source pixel x, y
+	color := func(x, y int) [3]float64 {
+		r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+		rgb := [3]float64{float64(r) / 0xffff, float64(g) / 0xffff, float64(b) / 0xffff}
+		if gray {
+			rgb[0] = 0.299*rgb[0] + 0.587*rgb[1] + 0.114*rgb[2]
+		}
+		return rgb
+	}
+	// scale maps the target pixel coordinate to the source coordinate and its neighbours
+	scale := func(i, size, srcSize int) (int, int, float64) {
+		pos := (float64(i)+0.5)*float64(srcSize)/float64(size) - 0.5
+		pos = math.Max(0, math.Min(pos, float64(srcSize-1)))
+		lo := int(pos)
+		hi := lo + 1
+		if hi >= srcSize {
+			hi = srcSize - 1
+		}
+		return lo, hi, pos - float64(lo)
+	}
+	pixels := make([]float64, 0, width*height*channels)
+	for y := 0; y < height; y++ {
+		y0, y1, fy := scale(y, height, bounds.Dy())
+		for x := 0; x < width; x++ {
+			x0, x1, fx := scale(x, width, bounds.Dx())
+			c00, c10, c01, c11 := color(x0, y0), color(x1, y0), color(x0, y1), color(x1, y1)
+			for c := 0; c < channels; c++ {
+				top := c00[c]*(1-fx) + c10[c]*fx
+				bottom := c01[c]*(1-fx) + c11[c]*fx
+				pixels = append(pixels, top*(1-fy)+bottom*fy)
+			}
+		}
+	}
+	return pixels
+}
@@ -0,0 +1,85 @@
+package dataset
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeImage writes w x h image filled with the supplied color to the supplied path
+func writeImage(path string, w, h int, c color.Color) error {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if filepath.Ext(path) == ".jpg" {
+		return jpeg.Encode(f, img, &jpeg.Options{Quality: 100})
+	}
+	return png.Encode(f, img)
+}
+
+func TestLoadImageDir(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "images")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	// empty directory
+	inMx, labels, classes, err := LoadImageDir(dir, nil)
+	assert.Nil(inMx)
+	assert.Nil(labels)
+	assert.Nil(classes)
+	assert.Error(err)
+	assert.NoError(os.Mkdir(filepath.Join(dir, "white"), 0755))
+	assert.NoError(os.Mkdir(filepath.Join(dir, "red"), 0755))
+	assert.NoError(writeImage(filepath.Join(dir, "white", "1.png"), 2, 2, color.White))
+	assert.NoError(writeImage(filepath.Join(dir, "white", "2.jpg"), 2, 2, color.White))
+	assert.NoError(writeImage(filepath.Join(dir, "red", "1.png"), 2, 2, color.RGBA{255, 0, 0, 255}))
+	assert.NoError(ioutil.WriteFile(filepath.Join(dir, "red", "notes.txt"), []byte("foo"), 0644))
+	// color images keep their size
+	inMx, labels, classes, err = LoadImageDir(dir, nil)
+	assert.NoError(err)
+	assert.Equal([]string{"red", "white"}, classes)
+	assert.Equal([]float64{1, 2, 2}, labels.RawVector().Data)
+	rows, cols := inMx.Dims()
+	assert.Equal(3, rows)
+	assert.Equal(2*2*3, cols)
+	assert.Equal([]float64{1, 0, 0}, inMx.RawRowView(0)[:3])
+	for _, v := range inMx.RawRowView(1) {
+		assert.InDelta(1.0, v, 1e-2)
+	}
+	// resized grayscale images
+	inMx, _, _, err = LoadImageDir(dir, &ImageOptions{Width: 3, Height: 1, Gray: true})
+	assert.NoError(err)
+	rows, cols = inMx.Dims()
+	assert.Equal(3, rows)
+	assert.Equal(3, cols)
+	for _, v := range inMx.RawRowView(0) {
+		assert.InDelta(0.299, v, 1e-6)
+	}
+	// incorrect options and images
+	_, _, _, err = LoadImageDir(dir, &ImageOptions{Width: -1})
+	assert.Error(err)
+	_, _, _, err = LoadImageDir(filepath.Join(dir, "foo"), nil)
+	assert.Error(err)
+	assert.NoError(writeImage(filepath.Join(dir, "red", "2.png"), 3, 3, color.White))
+	_, _, _, err = LoadImageDir(dir, nil)
+	assert.Error(err)
+	assert.NoError(ioutil.WriteFile(filepath.Join(dir, "red", "3.png"), []byte("foo"), 0644))
+	_, _, _, err = LoadImageDir(dir, &ImageOptions{Width: 2, Height: 2})
+	assert.Error(err)
+}
@@ -0,0 +1,148 @@
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	// ImputeMean fills missing values with the feature mean
+	ImputeMean = "mean"
+	// ImputeMedian fills missing values with the feature median
+	ImputeMedian = "median"
+	// ImputeConstant fills missing values with a constant
+	ImputeConstant = "constant"
+)
+
+// Imputer fills in missing feature values, which are represented by NaN. Missing values are
+// replaced by a statistic of the feature computed from the training data, so inference inputs
+// are completed exactly like the training data. Features without any value in the training data
+// are filled with the fill value.
+type Imputer struct {
+	// strategy is imputation strategy
+	strategy string
+	// fill is the constant fill value
+	fill float64
+	// values contains fill values of features
+	values []float64
+}
+
+// NewImputer creates new unfitted Imputer with the supplied strategy and returns it. fill is
+// the fill value of ImputeConstant strategy and of features which have no values to compute
+// the statistic from. It fails with error if the strategy is not supported or if fill is NaN.
+func NewImputer(strategy string, fill float64) (*Imputer, error) {
+	if err := checkImputeParams(strategy, fill); err != nil {
+		return nil, err
+	}
+	return &Imputer{
+		strategy: strategy,
+		fill:     fill,
+	}, nil
+}
+
+// checkImputeParams checks if the supplied imputation strategy and fill value are valid
+func checkImputeParams(strategy string, fill float64) error {
+	switch strategy {
+	case ImputeMean, ImputeMedian, ImputeConstant:
+	default:
+		return fmt.Errorf("Unsupported imputation strategy: %s\n", strategy)
+	}
+	if math.IsNaN(fill) {
+		return fmt.Errorf("Incorrect fill value: %f\n", fill)
+	}
+	return nil
+}
+
+// Strategy returns imputation strategy
+func (im Imputer) Strategy() string {
+	return im.strategy
+}
+
+// Fill returns the constant fill value
+func (im Imputer) Fill() float64 {
+	return im.fill
+}
+
+// Values returns fill values of features. It returns nil if the imputer has not been fitted.
+func (im Imputer) Values() []float64 {
+	return im.values
+}
+
+// Fit computes fill values of every feature of the supplied data from its values which are not missing.
+// It fails with error if the data are nil or empty.
+func (im *Imputer) Fit(mx mat.Matrix) error {
+	if err := checkFitData(mx); err != nil {
+		return err
+	}
+	rows, cols := mx.Dims()
+	im.values = make([]float64, cols)
+	for j := 0; j < cols; j++ {
+		col := make([]float64, 0, rows)
+		for i := 0; i < rows; i++ {
+			if x := mx.At(i, j); !math.IsNaN(x) {
+				col = append(col, x)
+			}
+		}
+		im.values[j] = im.fill
+		if len(col) == 0 {
+			continue
+		}
+		switch im.strategy {
+		case ImputeMean:
+			sum := 0.0
+			for _, x := range col {
+				sum += x
+			}
+			im.values[j] = sum / float64(len(col))
+		case ImputeMedian:
+			sort.Float64s(col)
+			im.values[j] = (col[(len(col)-1)/2] + col[len(col)/2]) / 2
+		}
+	}
+	return nil
+}
+
+// Transform returns copy of the supplied data whose missing values are replaced by the feature
+// fill values. It fails with error if the imputer has not been fitted or if the data don't match it.
+func (im Imputer) Transform(mx mat.Matrix) (*mat.Dense, error) {
+	if err := checkTransformData(mx, len(im.values)); err != nil {
+		return nil, err
+	}
+	outMx := new(mat.Dense)
+	outMx.Apply(func(i, j int, x float64) float64 {
+		if math.IsNaN(x) {
+			return im.values[j]
+		}
+		return x
+	}, mx)
+	return outMx, nil
+}
+
+// imputer is JSON representation of Imputer
+type imputer struct {
+	Strategy string    `json:"strategy"`
+	Fill     float64   `json:"fill"`
+	Values   []float64 `json:"values"`
+}
+
+// MarshalJSON encodes the strategy and fitted fill values of the imputer as JSON
+func (im Imputer) MarshalJSON() ([]byte, error) {
+	return json.Marshal(imputer{Strategy: im.strategy, Fill: im.fill, Values: im.values})
+}
+
+// UnmarshalJSON decodes the imputer from JSON. It fails with error if the parameters are invalid.
+func (im *Imputer) UnmarshalJSON(data []byte) error {
+	var p imputer
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	if err := checkImputeParams(p.Strategy, p.Fill); err != nil {
+		return err
+	}
+	im.strategy, im.fill, im.values = p.Strategy, p.Fill, p.Values
+	return nil
+}
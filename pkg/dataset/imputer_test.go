@@ -0,0 +1,71 @@
+package dataset
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestImputer(t *testing.T) {
+	assert := assert.New(t)
+
+	nan := math.NaN()
+	im, err := NewImputer("foo", 0.0)
+	assert.Nil(im)
+	assert.Error(err)
+	im, err = NewImputer(ImputeMean, nan)
+	assert.Nil(im)
+	assert.Error(err)
+	dataMx := mat.NewDense(4, 3, []float64{
+		1.0, nan, nan,
+		2.0, 5.0, nan,
+		nan, 1.0, nan,
+		6.0, 2.0, nan,
+	})
+	testCases := []struct {
+		strategy string
+		values   []float64
+	}{
+		{ImputeMean, []float64{3.0, 8.0 / 3.0, -1.0}},
+		{ImputeMedian, []float64{2.0, 2.0, -1.0}},
+		{ImputeConstant, []float64{-1.0, -1.0, -1.0}},
+	}
+	for _, tc := range testCases {
+		im, err = NewImputer(tc.strategy, -1.0)
+		assert.NoError(err)
+		assert.Equal(tc.strategy, im.Strategy())
+		assert.Equal(-1.0, im.Fill())
+		// unfitted imputer
+		outMx, err := im.Transform(dataMx)
+		assert.Nil(outMx)
+		assert.Error(err)
+		assert.NoError(im.Fit(dataMx))
+		assert.Equal(tc.values, im.Values())
+		outMx, err = im.Transform(dataMx)
+		assert.NoError(err)
+		assert.Equal(tc.values[0], outMx.At(2, 0))
+		assert.Equal(tc.values[1], outMx.At(0, 1))
+		assert.Equal(5.0, outMx.At(1, 1))
+		assert.True(math.IsNaN(dataMx.At(2, 0)))
+	}
+	// median of odd number of values
+	im, err = NewImputer(ImputeMedian, 0.0)
+	assert.NoError(err)
+	assert.NoError(im.Fit(mat.NewDense(3, 1, []float64{3.0, 1.0, 2.0})))
+	assert.Equal([]float64{2.0}, im.Values())
+	// features mismatch
+	outMx, err := im.Transform(dataMx)
+	assert.Nil(outMx)
+	assert.Error(err)
+	assert.Error(im.Fit(nil))
+	// fitted imputer survives serialization
+	data, err := json.Marshal(im)
+	assert.NoError(err)
+	loaded := &Imputer{}
+	assert.NoError(json.Unmarshal(data, loaded))
+	assert.Equal(im, loaded)
+	assert.Error(json.Unmarshal([]byte(`{"strategy":"foo","values":[1]}`), loaded))
+}
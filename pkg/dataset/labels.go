@@ -0,0 +1,147 @@
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"gonum.org/v1/gonum/mat"
+)
+
+// LabelEncoder maps string class labels to numeric class labels 1, 2, ... used by neural networks
+// and back. Class i+1 is the i-th class of the encoder vocabulary. The vocabulary is serializable,
+// so it can be saved alongside the trained network and predictions decode to the same classes.
+type LabelEncoder struct {
+	// classes contains vocabulary of class labels
+	classes []string
+	// index maps class labels to their indices in classes
+	index map[string]int
+}
+
+// NewLabelEncoder creates new LabelEncoder with the supplied vocabulary and returns it.
+// Encoder without classes learns its vocabulary by Fit. It fails with error if any
+// of the classes is duplicate.
+func NewLabelEncoder(classes ...string) (*LabelEncoder, error) {
+	e := &LabelEncoder{}
+	if err := e.setClasses(classes); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// setClasses sets the vocabulary of the encoder
+func (e *LabelEncoder) setClasses(classes []string) error {
+	index := make(map[string]int)
+	for i, class := range classes {
+		if _, ok := index[class]; ok {
+			return fmt.Errorf("Duplicate class: %s\n", class)
+		}
+		index[class] = i
+	}
+	e.classes, e.index = append([]string{}, classes...), index
+	return nil
+}
+
+// Classes returns the vocabulary of the encoder
+func (e LabelEncoder) Classes() []string {
+	return append([]string{}, e.classes...)
+}
+
+// Fit replaces the vocabulary with the sorted distinct values of the supplied labels.
+// It fails with error if no labels are supplied.
+func (e *LabelEncoder) Fit(labels []string) error {
+	if len(labels) == 0 {
+		return fmt.Errorf("No labels supplied\n")
+	}
+	seen := make(map[string]bool)
+	var classes []string
+	for _, label := range labels {
+		if !seen[label] {
+			seen[label] = true
+			classes = append(classes, label)
+		}
+	}
+	sort.Strings(classes)
+	return e.setClasses(classes)
+}
+
+// Encode encodes the supplied labels into a vector of class labels.
+// It fails with error if any of the labels is not in the vocabulary.
+func (e LabelEncoder) Encode(labels []string) (*mat.VecDense, error) {
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("No labels supplied\n")
+	}
+	labelsVec := mat.NewVecDense(len(labels), nil)
+	for i, label := range labels {
+		class, ok := e.index[label]
+		if !ok {
+			return nil, fmt.Errorf("Unknown class: %s\n", label)
+		}
+		labelsVec.SetVec(i, float64(class+1))
+	}
+	return labelsVec, nil
+}
+
+// OneHot encodes the supplied labels into a 1-of-N matrix with a column per vocabulary class.
+// It fails with error if any of the labels is not in the vocabulary.
+func (e LabelEncoder) OneHot(labels []string) (*mat.Dense, error) {
+	labelsVec, err := e.Encode(labels)
+	if err != nil {
+		return nil, err
+	}
+	return matrix.MakeLabelsMx(labelsVec, len(e.classes))
+}
+
+// Decode decodes the supplied vector of class labels into string labels.
+// It fails with error if any of the class labels is out of the vocabulary range.
+func (e LabelEncoder) Decode(labelsVec *mat.VecDense) ([]string, error) {
+	if labelsVec == nil {
+		return nil, fmt.Errorf("Incorrect labels supplied: %v\n", labelsVec)
+	}
+	labels := make([]string, labelsVec.Len())
+	for i := range labels {
+		class := labelsVec.At(i, 0)
+		if class != float64(int(class)) || class < 1 || int(class) > len(e.classes) {
+			return nil, fmt.Errorf("Incorrect label: %f\n", class)
+		}
+		labels[i] = e.classes[int(class)-1]
+	}
+	return labels, nil
+}
+
+// DecodeProbs decodes the supplied matrix, which contains a row of class probabilities per sample
+// such as network classification output, into string labels of the most probable classes.
+// It fails with error if the number of matrix columns does not match the vocabulary.
+func (e LabelEncoder) DecodeProbs(probMx mat.Matrix) ([]string, error) {
+	if probMx == nil {
+		return nil, fmt.Errorf("Incorrect probabilities supplied: %v\n", probMx)
+	}
+	if _, cols := probMx.Dims(); cols != len(e.classes) {
+		return nil, fmt.Errorf("Classes mismatch. Vocabulary: %d, Probabilities: %d\n", len(e.classes), cols)
+	}
+	labelsVec, err := matrix.MakeLabelsVec(probMx)
+	if err != nil {
+		return nil, err
+	}
+	return e.Decode(labelsVec)
+}
+
+// MarshalJSON encodes the vocabulary of the encoder as JSON
+func (e LabelEncoder) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Classes []string `json:"classes"`
+	}{e.classes})
+}
+
+// UnmarshalJSON decodes the vocabulary of the encoder from JSON.
+// It fails with error if any of the classes is duplicate.
+func (e *LabelEncoder) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Classes []string `json:"classes"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	return e.setClasses(v.Classes)
+}
@@ -0,0 +1,62 @@
+package dataset
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestLabelEncoder(t *testing.T) {
+	assert := assert.New(t)
+
+	e, err := NewLabelEncoder("cat", "cat")
+	assert.Nil(e)
+	assert.Error(err)
+	e, err = NewLabelEncoder()
+	assert.NoError(err)
+	assert.Error(e.Fit(nil))
+	// vocabulary is sorted
+	labels := []string{"dog", "cat", "fox", "dog"}
+	assert.NoError(e.Fit(labels))
+	assert.Equal([]string{"cat", "dog", "fox"}, e.Classes())
+	labelsVec, err := e.Encode(labels)
+	assert.NoError(err)
+	assert.Equal([]float64{2, 1, 3, 2}, labelsVec.RawVector().Data)
+	labelsMx, err := e.OneHot(labels[:2])
+	assert.NoError(err)
+	assert.True(mat.Equal(labelsMx, mat.NewDense(2, 3, []float64{0, 1, 0, 1, 0, 0})))
+	decoded, err := e.Decode(labelsVec)
+	assert.NoError(err)
+	assert.Equal(labels, decoded)
+	// unknown labels
+	labelsVec, err = e.Encode([]string{"cow"})
+	assert.Nil(labelsVec)
+	assert.Error(err)
+	labelsMx, err = e.OneHot([]string{"cow"})
+	assert.Nil(labelsMx)
+	assert.Error(err)
+	decoded, err = e.Decode(mat.NewVecDense(1, []float64{4}))
+	assert.Nil(decoded)
+	assert.Error(err)
+	decoded, err = e.Decode(mat.NewVecDense(1, []float64{1.5}))
+	assert.Nil(decoded)
+	assert.Error(err)
+	// decode probabilities
+	probMx := mat.NewDense(2, 3, []float64{0.2, 0.1, 0.7, 0.5, 0.3, 0.2})
+	decoded, err = e.DecodeProbs(probMx)
+	assert.NoError(err)
+	assert.Equal([]string{"fox", "cat"}, decoded)
+	decoded, err = e.DecodeProbs(mat.NewDense(1, 2, nil))
+	assert.Nil(decoded)
+	assert.Error(err)
+	// vocabulary survives serialization
+	data, err := json.Marshal(e)
+	assert.NoError(err)
+	assert.Equal(`{"classes":["cat","dog","fox"]}`, string(data))
+	loaded := &LabelEncoder{}
+	assert.NoError(json.Unmarshal(data, loaded))
+	assert.Equal(e, loaded)
+	assert.Error(json.Unmarshal([]byte(`{"classes":["a","a"]}`), loaded))
+}
@@ -0,0 +1,208 @@
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// PCA projects the features onto their principal components, which reduces the dimensionality
+// of the data while keeping most of their variance. Principal components are computed using
+// singular value decomposition of the centered training data.
+type PCA struct {
+	// components is the requested number of components: 0 if the variance target is used
+	components int
+	// variance is the requested fraction of explained variance: 0 if the number of components is used
+	variance float64
+	// mean contains feature means
+	mean []float64
+	// weightsMx contains principal components in columns
+	weightsMx *mat.Dense
+	// explained contains fractions of variance explained by the kept components
+	explained []float64
+}
+
+// NewPCA creates new unfitted PCA which keeps the supplied number of principal components
+// and returns it. It fails with error if the number of components is not positive.
+func NewPCA(components int) (*PCA, error) {
+	if components <= 0 {
+		return nil, fmt.Errorf("Incorrect number of components: %d\n", components)
+	}
+	return &PCA{
+		components: components,
+	}, nil
+}
+
+// NewPCAVariance creates new unfitted PCA which keeps the smallest number of principal components
+// which explain at least the supplied fraction of the variance of the data and returns it.
+// It fails with error if the fraction is not in (0, 1].
+func NewPCAVariance(variance float64) (*PCA, error) {
+	if variance <= 0 || variance > 1 {
+		return nil, fmt.Errorf("Incorrect explained variance: %f\n", variance)
+	}
+	return &PCA{
+		variance: variance,
+	}, nil
+}
+
+// Components returns principal components in columns of features x components matrix.
+// It returns nil if the PCA has not been fitted.
+func (p PCA) Components() *mat.Dense {
+	return p.weightsMx
+}
+
+// Mean returns feature means. It returns nil if the PCA has not been fitted.
+func (p PCA) Mean() []float64 {
+	return p.mean
+}
+
+// Explained returns fractions of variance explained by the kept principal components
+// in decreasing order. It returns nil if the PCA has not been fitted.
+func (p PCA) Explained() []float64 {
+	return p.explained
+}
+
+// Fit computes principal components of the supplied data. It fails with error if the data
+// are nil or empty, if the data have fewer dimensions than the requested number of components
+// or if the decomposition fails.
+func (p *PCA) Fit(mx mat.Matrix) error {
+	if err := checkFitData(mx); err != nil {
+		return err
+	}
+	rows, cols := mx.Dims()
+	mean := make([]float64, cols)
+	for i := 0; i < rows; i++ {
+		for j := range mean {
+			mean[j] += mx.At(i, j) / float64(rows)
+		}
+	}
+	centMx := new(mat.Dense)
+	centMx.Apply(func(i, j int, x float64) float64 {
+		return x - mean[j]
+	}, mx)
+	var svd mat.SVD
+	if ok := svd.Factorize(centMx, mat.SVDFull); !ok {
+		return fmt.Errorf("Failed to decompose the data\n")
+	}
+	values := svd.Values(nil)
+	if p.components > len(values) {
+		return fmt.Errorf("Insufficient data dimensions. Components: %d, Dimensions: %d\n",
+			p.components, len(values))
+	}
+	total := 0.0
+	for _, s := range values {
+		total += s * s
+	}
+	ratios := make([]float64, len(values))
+	for i, s := range values {
+		// constant data have no variance to explain
+		if total > 0 {
+			ratios[i] = s * s / total
+		}
+	}
+	k := p.components
+	if k == 0 {
+		sum := 0.0
+		for k < len(ratios) && (k == 0 || sum < p.variance-1e-12) {
+			sum += ratios[k]
+			k++
+		}
+	}
+	vMx := new(mat.Dense)
+	svd.VTo(vMx)
+	p.mean = mean
+	p.weightsMx = mat.DenseCopyOf(vMx.Slice(0, cols, 0, k))
+	p.explained = ratios[:k]
+	return nil
+}
+
+// Transform returns projection of the supplied data onto the principal components.
+// It fails with error if the PCA has not been fitted or if the data don't match it.
+func (p PCA) Transform(mx mat.Matrix) (*mat.Dense, error) {
+	if err := checkTransformData(mx, len(p.mean)); err != nil {
+		return nil, err
+	}
+	centMx := new(mat.Dense)
+	centMx.Apply(func(i, j int, x float64) float64 {
+		return x - p.mean[j]
+	}, mx)
+	outMx := new(mat.Dense)
+	outMx.Mul(centMx, p.weightsMx)
+	return outMx, nil
+}
+
+// InverseTransform maps the supplied projections back to the feature space. Variance which is
+// not explained by the kept components is lost. It fails with error if the PCA has not been fitted
+// or if the data don't match the number of components.
+func (p PCA) InverseTransform(mx mat.Matrix) (*mat.Dense, error) {
+	if p.weightsMx == nil {
+		return nil, fmt.Errorf("Transformer has not been fitted\n")
+	}
+	_, k := p.weightsMx.Dims()
+	if err := checkTransformData(mx, k); err != nil {
+		return nil, err
+	}
+	outMx := new(mat.Dense)
+	outMx.Mul(mx, p.weightsMx.T())
+	outMx.Apply(func(i, j int, x float64) float64 {
+		return x + p.mean[j]
+	}, outMx)
+	return outMx, nil
+}
+
+// pca is JSON representation of PCA
+type pca struct {
+	Components int         `json:"components,omitempty"`
+	Variance   float64     `json:"variance,omitempty"`
+	Mean       []float64   `json:"mean"`
+	Weights    [][]float64 `json:"weights"`
+	Explained  []float64   `json:"explained"`
+}
+
+// MarshalJSON encodes the configuration and fitted principal components of the PCA as JSON.
+// Every weights array contains one principal component.
+func (p PCA) MarshalJSON() ([]byte, error) {
+	enc := pca{
+		Components: p.components,
+		Variance:   p.variance,
+		Mean:       p.mean,
+		Explained:  p.explained,
+	}
+	if p.weightsMx != nil {
+		_, k := p.weightsMx.Dims()
+		for j := 0; j < k; j++ {
+			enc.Weights = append(enc.Weights, mat.Col(nil, j, p.weightsMx))
+		}
+	}
+	return json.Marshal(enc)
+}
+
+// UnmarshalJSON decodes the PCA from JSON. It fails with error if the parameters are invalid.
+func (p *PCA) UnmarshalJSON(data []byte) error {
+	var dec pca
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return err
+	}
+	if (dec.Components > 0) == (dec.Variance > 0) || dec.Components < 0 || dec.Variance > 1 {
+		return fmt.Errorf("Incorrect PCA parameters. Components: %d, Variance: %f\n",
+			dec.Components, dec.Variance)
+	}
+	if len(dec.Weights) != len(dec.Explained) || (len(dec.Mean) == 0) != (len(dec.Weights) == 0) {
+		return fmt.Errorf("PCA parameters mismatch. Weights: %d, Explained: %d\n",
+			len(dec.Weights), len(dec.Explained))
+	}
+	var weightsMx *mat.Dense
+	if len(dec.Weights) > 0 {
+		weightsMx = mat.NewDense(len(dec.Mean), len(dec.Weights), nil)
+		for j, w := range dec.Weights {
+			if len(w) != len(dec.Mean) {
+				return fmt.Errorf("PCA parameters mismatch. Mean: %d, Weights: %d\n", len(dec.Mean), len(w))
+			}
+			weightsMx.SetCol(j, w)
+		}
+	}
+	p.components, p.variance = dec.Components, dec.Variance
+	p.mean, p.weightsMx, p.explained = dec.Mean, weightsMx, dec.Explained
+	return nil
+}
@@ -0,0 +1,98 @@
+package dataset
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestPCA(t *testing.T) {
+	assert := assert.New(t)
+
+	p, err := NewPCA(0)
+	assert.Nil(p)
+	assert.Error(err)
+	for _, v := range []float64{0.0, 1.5} {
+		p, err = NewPCAVariance(v)
+		assert.Nil(p)
+		assert.Error(err)
+	}
+	// samples lie on the line y = 2x
+	dataMx := mat.NewDense(4, 2, []float64{
+		1.0, 2.0,
+		2.0, 4.0,
+		3.0, 6.0,
+		4.0, 8.0,
+	})
+	p, err = NewPCA(1)
+	assert.NoError(err)
+	// unfitted PCA
+	outMx, err := p.Transform(dataMx)
+	assert.Nil(outMx)
+	assert.Error(err)
+	outMx, err = p.InverseTransform(dataMx)
+	assert.Nil(outMx)
+	assert.Error(err)
+	assert.NoError(p.Fit(dataMx))
+	assert.Equal([]float64{2.5, 5.0}, p.Mean())
+	assert.InDelta(1.0, p.Explained()[0], 1e-9)
+	comp := p.Components()
+	rows, cols := comp.Dims()
+	assert.Equal(2, rows)
+	assert.Equal(1, cols)
+	assert.InDelta(2.0, comp.At(1, 0)/comp.At(0, 0), 1e-9)
+	outMx, err = p.Transform(dataMx)
+	assert.NoError(err)
+	rows, cols = outMx.Dims()
+	assert.Equal(4, rows)
+	assert.Equal(1, cols)
+	assert.InDelta(math.Sqrt(5)*1.5, math.Abs(outMx.At(0, 0)), 1e-9)
+	// projections of the line are reconstructed exactly
+	inMx, err := p.InverseTransform(outMx)
+	assert.NoError(err)
+	assert.True(mat.EqualApprox(inMx, dataMx, 1e-9))
+	_, err = p.InverseTransform(dataMx)
+	assert.Error(err)
+	// too many components
+	p, err = NewPCA(3)
+	assert.NoError(err)
+	assert.Error(p.Fit(dataMx))
+	assert.Error(p.Fit(nil))
+	// explained variance target
+	noisyMx := mat.NewDense(4, 3, []float64{
+		1.0, 2.0, 0.1,
+		2.0, 4.0, -0.1,
+		3.0, 6.0, 0.1,
+		4.0, 8.0, -0.1,
+	})
+	p, err = NewPCAVariance(0.99)
+	assert.NoError(err)
+	assert.NoError(p.Fit(noisyMx))
+	assert.Len(p.Explained(), 1)
+	p, err = NewPCAVariance(1.0)
+	assert.NoError(err)
+	assert.NoError(p.Fit(noisyMx))
+	assert.Len(p.Explained(), 2)
+	// fitted PCA survives serialization
+	data, err := json.Marshal(p)
+	assert.NoError(err)
+	loaded := &PCA{}
+	assert.NoError(json.Unmarshal(data, loaded))
+	assert.Equal(p.Mean(), loaded.Mean())
+	assert.True(mat.Equal(p.Components(), loaded.Components()))
+	outMx, err = p.Transform(noisyMx)
+	assert.NoError(err)
+	loadedMx, err := loaded.Transform(noisyMx)
+	assert.NoError(err)
+	assert.True(mat.Equal(outMx, loadedMx))
+	for _, data := range []string{
+		`{"mean":[1],"weights":[[1]],"explained":[1]}`,
+		`{"components":1,"mean":[1],"weights":[[1, 2]],"explained":[1]}`,
+		`{"components":1,"mean":[1],"weights":[],"explained":[]}`,
+	} {
+		assert.Error(json.Unmarshal([]byte(data), loaded), data)
+	}
+}
@@ -0,0 +1,91 @@
+package dataset
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// WeightedSampler draws sample indices with replacement so that every class is drawn with
+// probability proportional to its weight regardless of its frequency. Minority classes are
+// over-sampled and majority classes are under-sampled, which allows to handle imbalanced data
+// when the samples are drawn, independently of the class weights of the training loss.
+type WeightedSampler struct {
+	// classes contains sample indices of every drawn class
+	classes [][]int
+	// cumProbs contains cumulative probabilities of the classes
+	cumProbs []float64
+	// rng is random source
+	rng *rand.Rand
+}
+
+// NewWeightedSampler creates new WeightedSampler of the supplied labels whose random source is
+// initialized with the supplied seed and returns it. Weight i belongs to the class labeled i+1.
+// nil weights draw all the classes present in the labels with the same probability. Classes
+// which are not present in the labels are never drawn. It fails with error if the labels are
+// empty, if any weight is negative, if any label has no weight or if no class can be drawn.
+func NewWeightedSampler(labels *mat.VecDense, weights []float64, seed int64) (*WeightedSampler, error) {
+	if labels == nil || labels.Len() == 0 {
+		return nil, fmt.Errorf("Incorrect labels supplied: %v\n", labels)
+	}
+	for _, w := range weights {
+		if w < 0 {
+			return nil, fmt.Errorf("Incorrect class weight: %f\n", w)
+		}
+	}
+	indices := make(map[float64][]int)
+	for i := 0; i < labels.Len(); i++ {
+		label := labels.At(i, 0)
+		if weights != nil && (label != float64(int(label)) || label < 1 || int(label) > len(weights)) {
+			return nil, fmt.Errorf("Missing weight of class: %f\n", label)
+		}
+		indices[label] = append(indices[label], i)
+	}
+	// classes are sorted so that sampling does not depend on map order
+	labelSet := make([]float64, 0, len(indices))
+	for label := range indices {
+		labelSet = append(labelSet, label)
+	}
+	sort.Float64s(labelSet)
+	s := &WeightedSampler{
+		rng: rand.New(rand.NewSource(seed)),
+	}
+	total := 0.0
+	for _, label := range labelSet {
+		w := 1.0
+		if weights != nil {
+			w = weights[int(label)-1]
+		}
+		if w == 0 {
+			continue
+		}
+		total += w
+		s.classes = append(s.classes, indices[label])
+		s.cumProbs = append(s.cumProbs, total)
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("No class can be sampled\n")
+	}
+	for i := range s.cumProbs {
+		s.cumProbs[i] /= total
+	}
+	return s, nil
+}
+
+// Sample draws n sample indices. Every index is drawn by choosing a class according to the class
+// weights and a sample of the class uniformly at random.
+func (s *WeightedSampler) Sample(n int) []int {
+	indices := make([]int, n)
+	for i := range indices {
+		c := sort.SearchFloat64s(s.cumProbs, s.rng.Float64())
+		// guard against rounding of the last cumulative probability
+		if c == len(s.classes) {
+			c--
+		}
+		class := s.classes[c]
+		indices[i] = class[s.rng.Intn(len(class))]
+	}
+	return indices
+}
@@ -0,0 +1,51 @@
+package dataset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestWeightedSampler(t *testing.T) {
+	assert := assert.New(t)
+
+	labels := mat.NewVecDense(6, []float64{1, 1, 1, 1, 2, 3})
+	// incorrect parameters
+	for _, weights := range [][]float64{{1, -1, 1}, {1, 1}, {0, 0, 0}} {
+		s, err := NewWeightedSampler(labels, weights, 1)
+		assert.Nil(s)
+		assert.Error(err, "%v", weights)
+	}
+	s, err := NewWeightedSampler(nil, nil, 1)
+	assert.Nil(s)
+	assert.Error(err)
+	_, err = NewWeightedSampler(mat.NewVecDense(1, []float64{1.5}), []float64{1, 1}, 1)
+	assert.Error(err)
+	// balanced classes are drawn equally often
+	s, err = NewWeightedSampler(labels, nil, 1)
+	assert.NoError(err)
+	counts := make(map[float64]int)
+	for _, idx := range s.Sample(3000) {
+		counts[labels.At(idx, 0)]++
+	}
+	for _, label := range []float64{1, 2, 3} {
+		assert.InDelta(1000, counts[label], 100, "class %f", label)
+	}
+	// zero weight classes are never drawn
+	s, err = NewWeightedSampler(labels, []float64{0, 3, 1}, 1)
+	assert.NoError(err)
+	counts = make(map[float64]int)
+	indices := s.Sample(4000)
+	assert.Len(indices, 4000)
+	for _, idx := range indices {
+		counts[labels.At(idx, 0)]++
+	}
+	assert.Equal(0, counts[1])
+	assert.InDelta(3000, counts[2], 150)
+	assert.InDelta(1000, counts[3], 150)
+	// the same seed draws the same samples
+	s1, _ := NewWeightedSampler(labels, nil, 7)
+	s2, _ := NewWeightedSampler(labels, nil, 7)
+	assert.Equal(s1.Sample(10), s2.Sample(10))
+}
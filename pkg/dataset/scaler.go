@@ -0,0 +1,267 @@
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+)
+
+// Transformer learns a data transformation from training data and applies it to any data.
+// Fitted transformers are serializable, so they can be saved alongside the trained network
+// using Network.SetPreprocessing and inference applies exactly the same transformation as training.
+type Transformer interface {
+	// Fit learns the transformation parameters from the supplied data
+	Fit(mx mat.Matrix) error
+	// Transform returns transformed copy of the supplied data
+	Transform(mx mat.Matrix) (*mat.Dense, error)
+}
+
+// StandardScaler centers every feature to zero mean and scales it to unit standard deviation.
+// Features with zero standard deviation are only centered.
+type StandardScaler struct {
+	// mean contains feature means
+	mean []float64
+	// std contains feature standard deviations
+	std []float64
+}
+
+// NewStandardScaler creates new unfitted StandardScaler and returns it
+func NewStandardScaler() *StandardScaler {
+	return &StandardScaler{}
+}
+
+// Mean returns feature means. It returns nil if the scaler has not been fitted.
+func (s StandardScaler) Mean() []float64 {
+	return s.mean
+}
+
+// Std returns feature standard deviations. It returns nil if the scaler has not been fitted.
+func (s StandardScaler) Std() []float64 {
+	return s.std
+}
+
+// Fit computes the mean and the standard deviation of every feature of the supplied data.
+// It fails with error if the data are nil or empty.
+func (s *StandardScaler) Fit(mx mat.Matrix) error {
+	if err := checkFitData(mx); err != nil {
+		return err
+	}
+	rows, cols := mx.Dims()
+	col := make([]float64, rows)
+	s.mean, s.std = make([]float64, cols), make([]float64, cols)
+	for j := 0; j < cols; j++ {
+		mat.Col(col, j, mx)
+		s.mean[j], s.std[j] = stat.MeanStdDev(col, nil)
+		// single sample has undefined standard deviation
+		if rows == 1 {
+			s.std[j] = 0.0
+		}
+	}
+	return nil
+}
+
+// Transform returns standardized copy of the supplied data: (x - mean) / std.
+// It fails with error if the scaler has not been fitted or if the data don't match it.
+func (s StandardScaler) Transform(mx mat.Matrix) (*mat.Dense, error) {
+	if err := checkTransformData(mx, len(s.mean)); err != nil {
+		return nil, err
+	}
+	outMx := new(mat.Dense)
+	outMx.Apply(func(i, j int, x float64) float64 {
+		return (x - s.mean[j]) / nonZero(s.std[j])
+	}, mx)
+	return outMx, nil
+}
+
+// InverseTransform reverts standardization of the supplied data: x*std + mean.
+// It fails with error if the scaler has not been fitted or if the data don't match it.
+func (s StandardScaler) InverseTransform(mx mat.Matrix) (*mat.Dense, error) {
+	if err := checkTransformData(mx, len(s.mean)); err != nil {
+		return nil, err
+	}
+	outMx := new(mat.Dense)
+	outMx.Apply(func(i, j int, x float64) float64 {
+		return x*nonZero(s.std[j]) + s.mean[j]
+	}, mx)
+	return outMx, nil
+}
+
+// standardScaler is JSON representation of StandardScaler
+type standardScaler struct {
+	Mean []float64 `json:"mean"`
+	Std  []float64 `json:"std"`
+}
+
+// MarshalJSON encodes fitted parameters of the scaler as JSON
+func (s StandardScaler) MarshalJSON() ([]byte, error) {
+	return json.Marshal(standardScaler{Mean: s.mean, Std: s.std})
+}
+
+// UnmarshalJSON decodes the scaler from JSON. It fails with error if the parameters don't match.
+func (s *StandardScaler) UnmarshalJSON(data []byte) error {
+	var p standardScaler
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	if len(p.Mean) != len(p.Std) {
+		return fmt.Errorf("Scaler parameters mismatch. Mean: %d, Std: %d\n", len(p.Mean), len(p.Std))
+	}
+	s.mean, s.std = p.Mean, p.Std
+	return nil
+}
+
+// MinMaxScaler scales every feature linearly into the configured range.
+// Constant features are mapped to the lower bound of the range.
+type MinMaxScaler struct {
+	// min is the lower bound of the range
+	min float64
+	// max is the upper bound of the range
+	max float64
+	// dataMin contains feature minimums
+	dataMin []float64
+	// dataMax contains feature maximums
+	dataMax []float64
+}
+
+// NewMinMaxScaler creates new unfitted MinMaxScaler which scales the features into [min, max]
+// range and returns it. It fails with error if min is not smaller than max.
+func NewMinMaxScaler(min, max float64) (*MinMaxScaler, error) {
+	if min >= max {
+		return nil, fmt.Errorf("Incorrect scaling range: [%f, %f]\n", min, max)
+	}
+	return &MinMaxScaler{
+		min: min,
+		max: max,
+	}, nil
+}
+
+// Range returns the range of the scaled features
+func (s MinMaxScaler) Range() (float64, float64) {
+	return s.min, s.max
+}
+
+// DataMin returns feature minimums. It returns nil if the scaler has not been fitted.
+func (s MinMaxScaler) DataMin() []float64 {
+	return s.dataMin
+}
+
+// DataMax returns feature maximums. It returns nil if the scaler has not been fitted.
+func (s MinMaxScaler) DataMax() []float64 {
+	return s.dataMax
+}
+
+// Fit computes the minimum and the maximum of every feature of the supplied data.
+// It fails with error if the data are nil or empty.
+func (s *MinMaxScaler) Fit(mx mat.Matrix) error {
+	if err := checkFitData(mx); err != nil {
+		return err
+	}
+	rows, cols := mx.Dims()
+	col := make([]float64, rows)
+	s.dataMin, s.dataMax = make([]float64, cols), make([]float64, cols)
+	for j := 0; j < cols; j++ {
+		mat.Col(col, j, mx)
+		s.dataMin[j], s.dataMax[j] = col[0], col[0]
+		for _, x := range col[1:] {
+			if x < s.dataMin[j] {
+				s.dataMin[j] = x
+			}
+			if x > s.dataMax[j] {
+				s.dataMax[j] = x
+			}
+		}
+	}
+	return nil
+}
+
+// Transform returns scaled copy of the supplied data. Data outside of the fitted feature
+// ranges are scaled outside of the configured range. It fails with error if the scaler
+// has not been fitted or if the data don't match it.
+func (s MinMaxScaler) Transform(mx mat.Matrix) (*mat.Dense, error) {
+	if err := checkTransformData(mx, len(s.dataMin)); err != nil {
+		return nil, err
+	}
+	outMx := new(mat.Dense)
+	outMx.Apply(func(i, j int, x float64) float64 {
+		return s.min + (x-s.dataMin[j])/nonZero(s.dataMax[j]-s.dataMin[j])*(s.max-s.min)
+	}, mx)
+	return outMx, nil
+}
+
+// InverseTransform reverts scaling of the supplied data.
+// It fails with error if the scaler has not been fitted or if the data don't match it.
+func (s MinMaxScaler) InverseTransform(mx mat.Matrix) (*mat.Dense, error) {
+	if err := checkTransformData(mx, len(s.dataMin)); err != nil {
+		return nil, err
+	}
+	outMx := new(mat.Dense)
+	outMx.Apply(func(i, j int, x float64) float64 {
+		return s.dataMin[j] + (x-s.min)/(s.max-s.min)*nonZero(s.dataMax[j]-s.dataMin[j])
+	}, mx)
+	return outMx, nil
+}
+
+// minMaxScaler is JSON representation of MinMaxScaler
+type minMaxScaler struct {
+	Min     float64   `json:"min"`
+	Max     float64   `json:"max"`
+	DataMin []float64 `json:"data_min"`
+	DataMax []float64 `json:"data_max"`
+}
+
+// MarshalJSON encodes the range and fitted parameters of the scaler as JSON
+func (s MinMaxScaler) MarshalJSON() ([]byte, error) {
+	return json.Marshal(minMaxScaler{Min: s.min, Max: s.max, DataMin: s.dataMin, DataMax: s.dataMax})
+}
+
+// UnmarshalJSON decodes the scaler from JSON. It fails with error if the parameters are invalid.
+func (s *MinMaxScaler) UnmarshalJSON(data []byte) error {
+	var p minMaxScaler
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	if p.Min >= p.Max {
+		return fmt.Errorf("Incorrect scaling range: [%f, %f]\n", p.Min, p.Max)
+	}
+	if len(p.DataMin) != len(p.DataMax) {
+		return fmt.Errorf("Scaler parameters mismatch. Min: %d, Max: %d\n", len(p.DataMin), len(p.DataMax))
+	}
+	s.min, s.max, s.dataMin, s.dataMax = p.Min, p.Max, p.DataMin, p.DataMax
+	return nil
+}
+
+// checkFitData checks if the supplied data can be used to fit a transformer
+func checkFitData(mx mat.Matrix) error {
+	if mx == nil {
+		return fmt.Errorf("Incorrect data supplied: %v\n", mx)
+	}
+	if rows, cols := mx.Dims(); rows == 0 || cols == 0 {
+		return fmt.Errorf("Empty data supplied: %d x %d\n", rows, cols)
+	}
+	return nil
+}
+
+// checkTransformData checks if the supplied data can be transformed by a transformer fitted
+// on data with the supplied number of features
+func checkTransformData(mx mat.Matrix, features int) error {
+	if features == 0 {
+		return fmt.Errorf("Transformer has not been fitted\n")
+	}
+	if mx == nil {
+		return fmt.Errorf("Incorrect data supplied: %v\n", mx)
+	}
+	if _, cols := mx.Dims(); cols != features {
+		return fmt.Errorf("Features mismatch. Fitted: %d, Data: %d\n", features, cols)
+	}
+	return nil
+}
+
+// nonZero returns x or 1 if x is zero
+func nonZero(x float64) float64 {
+	if x == 0 {
+		return 1.0
+	}
+	return x
+}
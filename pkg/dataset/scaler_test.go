@@ -0,0 +1,79 @@
+package dataset
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestStandardScaler(t *testing.T) {
+	assert := assert.New(t)
+
+	s := NewStandardScaler()
+	dataMx := mat.NewDense(3, 2, []float64{1.0, 5.0, 2.0, 5.0, 3.0, 5.0})
+	// unfitted scaler
+	outMx, err := s.Transform(dataMx)
+	assert.Nil(outMx)
+	assert.Error(err)
+	assert.Error(s.Fit(nil))
+	// constant features are only centered
+	assert.NoError(s.Fit(dataMx))
+	assert.Equal([]float64{2.0, 5.0}, s.Mean())
+	assert.Equal([]float64{1.0, 0.0}, s.Std())
+	outMx, err = s.Transform(dataMx)
+	assert.NoError(err)
+	assert.True(mat.Equal(outMx, mat.NewDense(3, 2, []float64{-1.0, 0.0, 0.0, 0.0, 1.0, 0.0})))
+	inMx, err := s.InverseTransform(outMx)
+	assert.NoError(err)
+	assert.True(mat.Equal(inMx, dataMx))
+	// features mismatch
+	outMx, err = s.Transform(mat.NewDense(1, 3, nil))
+	assert.Nil(outMx)
+	assert.Error(err)
+	// fitted scaler survives serialization
+	data, err := json.Marshal(s)
+	assert.NoError(err)
+	loaded := NewStandardScaler()
+	assert.NoError(json.Unmarshal(data, loaded))
+	assert.Equal(s, loaded)
+	assert.Error(json.Unmarshal([]byte(`{"mean":[1],"std":[]}`), loaded))
+}
+
+func TestMinMaxScaler(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := NewMinMaxScaler(1.0, 1.0)
+	assert.Nil(s)
+	assert.Error(err)
+	s, err = NewMinMaxScaler(-1.0, 1.0)
+	assert.NotNil(s)
+	assert.NoError(err)
+	min, max := s.Range()
+	assert.Equal(-1.0, min)
+	assert.Equal(1.0, max)
+	dataMx := mat.NewDense(3, 2, []float64{0.0, 5.0, 2.0, 5.0, 4.0, 5.0})
+	// unfitted scaler
+	outMx, err := s.InverseTransform(dataMx)
+	assert.Nil(outMx)
+	assert.Error(err)
+	// constant features are mapped to the lower bound
+	assert.NoError(s.Fit(dataMx))
+	assert.Equal([]float64{0.0, 5.0}, s.DataMin())
+	assert.Equal([]float64{4.0, 5.0}, s.DataMax())
+	outMx, err = s.Transform(dataMx)
+	assert.NoError(err)
+	assert.True(mat.Equal(outMx, mat.NewDense(3, 2, []float64{-1.0, -1.0, 0.0, -1.0, 1.0, -1.0})))
+	inMx, err := s.InverseTransform(outMx)
+	assert.NoError(err)
+	assert.True(mat.Equal(inMx, dataMx))
+	// fitted scaler survives serialization
+	data, err := json.Marshal(s)
+	assert.NoError(err)
+	loaded := &MinMaxScaler{}
+	assert.NoError(json.Unmarshal(data, loaded))
+	assert.Equal(s, loaded)
+	assert.Error(json.Unmarshal([]byte(`{"min":1,"max":0}`), loaded))
+	assert.Error(json.Unmarshal([]byte(`{"min":0,"max":1,"data_min":[1]}`), loaded))
+}
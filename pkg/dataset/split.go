@@ -0,0 +1,94 @@
+package dataset
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// SplitDataset randomly splits the supplied samples and their labels into data sets whose sizes are
+// given by the supplied ratios, such as []float64{0.7, 0.15, 0.15} for training, validation and test
+// data sets. Every data set gets floor(ratio*samples) samples and the last one gets the rest, too.
+// If stratify is true, the samples of every class are split separately, so all the data sets keep
+// the class distribution. Samples are shuffled using random source initialized with the supplied seed.
+// labels can be nil if stratify is false; label vectors of the split data sets are then nil too.
+// It fails with error if the data are invalid, if any ratio is not positive, if the ratios don't sum
+// to 1 or if any of the data sets would be empty.
+func SplitDataset(inMx *mat.Dense, labels *mat.VecDense, ratios []float64, seed int64,
+	stratify bool) ([]*mat.Dense, []*mat.VecDense, error) {
+	if inMx == nil {
+		return nil, nil, fmt.Errorf("Incorrect data supplied: %v\n", inMx)
+	}
+	samples, _ := inMx.Dims()
+	if labels != nil && labels.Len() != samples {
+		return nil, nil, fmt.Errorf("Labels mismatch. Samples: %d, Labels: %d\n", samples, labels.Len())
+	}
+	if stratify && labels == nil {
+		return nil, nil, fmt.Errorf("Stratified split requires labels\n")
+	}
+	if len(ratios) == 0 {
+		return nil, nil, fmt.Errorf("No split ratios supplied\n")
+	}
+	sum := 0.0
+	for _, ratio := range ratios {
+		if ratio <= 0 {
+			return nil, nil, fmt.Errorf("Incorrect split ratio: %f\n", ratio)
+		}
+		sum += ratio
+	}
+	if math.Abs(sum-1.0) > 1e-9 {
+		return nil, nil, fmt.Errorf("Split ratios don't sum to 1: %f\n", sum)
+	}
+	rng := rand.New(rand.NewSource(seed))
+	// groups contains sample indices of every class or of all the samples
+	var groups [][]int
+	if stratify {
+		classes := make(map[float64][]int)
+		for i := 0; i < samples; i++ {
+			label := labels.At(i, 0)
+			classes[label] = append(classes[label], i)
+		}
+		// classes are split in a deterministic order
+		keys := make([]float64, 0, len(classes))
+		for label := range classes {
+			keys = append(keys, label)
+		}
+		sort.Float64s(keys)
+		for _, label := range keys {
+			groups = append(groups, classes[label])
+		}
+	} else {
+		groups = [][]int{rng.Perm(samples)}
+	}
+	splits := make([][]int, len(ratios))
+	for _, group := range groups {
+		if stratify {
+			rng.Shuffle(len(group), func(i, j int) { group[i], group[j] = group[j], group[i] })
+		}
+		start := 0
+		for s, ratio := range ratios {
+			end := start + int(ratio*float64(len(group))+1e-9)
+			if s == len(ratios)-1 {
+				end = len(group)
+			}
+			splits[s] = append(splits[s], group[start:end]...)
+			start = end
+		}
+	}
+	inMxs := make([]*mat.Dense, len(splits))
+	labelVecs := make([]*mat.VecDense, len(splits))
+	for s, indices := range splits {
+		if len(indices) == 0 {
+			return nil, nil, fmt.Errorf("Empty data set of split ratio: %f\n", ratios[s])
+		}
+		// stratified data sets must not be ordered by class
+		if stratify {
+			rng.Shuffle(len(indices), func(i, j int) { indices[i], indices[j] = indices[j], indices[i] })
+		}
+		inMxs[s], labelVecs[s] = Batch(inMx, labels, indices)
+	}
+	return inMxs, labelVecs, nil
+}
@@ -0,0 +1,93 @@
+package dataset
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// newSplitData returns 10 samples whose only feature equals the sample index.
+// The first 6 samples are labeled 1 and the other 4 samples are labeled 2.
+func newSplitData() (*mat.Dense, *mat.VecDense) {
+	inMx := mat.NewDense(10, 1, nil)
+	labels := mat.NewVecDense(10, nil)
+	for i := 0; i < 10; i++ {
+		inMx.Set(i, 0, float64(i))
+		labels.SetVec(i, 1.0)
+		if i >= 6 {
+			labels.SetVec(i, 2.0)
+		}
+	}
+	return inMx, labels
+}
+
+func TestSplitDataset(t *testing.T) {
+	assert := assert.New(t)
+
+	inMx, labels := newSplitData()
+	ratios := []float64{0.5, 0.3, 0.2}
+	inMxs, labelVecs, err := SplitDataset(inMx, labels, ratios, 42, false)
+	assert.NoError(err)
+	assert.Len(inMxs, 3)
+	assert.Len(labelVecs, 3)
+	var seen []float64
+	for s, size := range []int{5, 3, 2} {
+		rows, _ := inMxs[s].Dims()
+		assert.Equal(size, rows)
+		assert.Equal(size, labelVecs[s].Len())
+		for i := 0; i < rows; i++ {
+			idx := inMxs[s].At(i, 0)
+			assert.Equal(labels.At(int(idx), 0), labelVecs[s].At(i, 0))
+			seen = append(seen, idx)
+		}
+	}
+	// every sample is in exactly one data set
+	sort.Float64s(seen)
+	assert.Equal([]float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, seen)
+	// the same seed gives the same split
+	again, _, err := SplitDataset(inMx, labels, ratios, 42, false)
+	assert.NoError(err)
+	assert.True(mat.Equal(inMxs[0], again[0]))
+	// unlabeled data
+	inMxs, labelVecs, err = SplitDataset(inMx, nil, []float64{0.5, 0.5}, 1, false)
+	assert.NoError(err)
+	assert.Len(inMxs, 2)
+	assert.Nil(labelVecs[0])
+	// incorrect splits
+	incorrect := []struct {
+		labels   *mat.VecDense
+		ratios   []float64
+		stratify bool
+	}{
+		{labels, nil, false},
+		{labels, []float64{0.5, 0.6}, false},
+		{labels, []float64{1.5, -0.5}, false},
+		{labels, []float64{0.05, 0.95}, false},
+		{nil, []float64{0.5, 0.5}, true},
+		{labels.SliceVec(0, 5).(*mat.VecDense), []float64{0.5, 0.5}, false},
+	}
+	for _, tc := range incorrect {
+		inMxs, labelVecs, err = SplitDataset(inMx, tc.labels, tc.ratios, 1, tc.stratify)
+		assert.Nil(inMxs)
+		assert.Nil(labelVecs)
+		assert.Error(err)
+	}
+}
+
+func TestSplitDatasetStratify(t *testing.T) {
+	assert := assert.New(t)
+
+	inMx, labels := newSplitData()
+	_, labelVecs, err := SplitDataset(inMx, labels, []float64{0.5, 0.5}, 7, true)
+	assert.NoError(err)
+	// both data sets keep the class distribution
+	for _, labelVec := range labelVecs {
+		counts := make(map[float64]int)
+		for i := 0; i < labelVec.Len(); i++ {
+			counts[labelVec.At(i, 0)]++
+		}
+		assert.Equal(map[float64]int{1.0: 3, 2.0: 2}, counts)
+	}
+}
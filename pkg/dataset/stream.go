@@ -0,0 +1,145 @@
+package dataset
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Stream yields mini-batches of samples and their labels one by one, so the data don't have to fit
+// into memory at once. Streams are read once: every training epoch needs a new stream.
+type Stream interface {
+	// NextBatch returns the next mini-batch of samples and their labels. Labels are nil
+	// if the data are not labeled. It returns io.EOF when there are no more mini-batches.
+	NextBatch() (*mat.Dense, *mat.VecDense, error)
+}
+
+// NextBatch returns the next mini-batch of the current epoch. It returns io.EOF when there
+// are no more mini-batches in the epoch. It allows to use Batches as Stream.
+func (b *Batches) NextBatch() (*mat.Dense, *mat.VecDense, error) {
+	inMx, labels, ok := b.Next()
+	if !ok {
+		return nil, nil, io.EOF
+	}
+	return inMx, labels, nil
+}
+
+// CSVStream streams mini-batches of CSV data read lazily from io.Reader
+type CSVStream struct {
+	// r reads CSV records
+	r *CSVReader
+	// size is mini-batch size
+	size int
+}
+
+// NewCSVStream creates new CSVStream which reads mini-batches of size records from the supplied
+// reader using the supplied options and returns it. Categories of categorical features must be
+// supplied in the options. It fails with error if size is not positive or if the CSV reader
+// can't be created. See NewCSVReader for more details.
+func NewCSVStream(r io.Reader, opts *CSVOptions, size int) (*CSVStream, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("Incorrect batch size: %d\n", size)
+	}
+	csvReader, err := NewCSVReader(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &CSVStream{
+		r:    csvReader,
+		size: size,
+	}, nil
+}
+
+// Reader returns CSV reader of the stream
+func (s CSVStream) Reader() *CSVReader {
+	return s.r
+}
+
+// NextBatch reads the next mini-batch of records
+func (s *CSVStream) NextBatch() (*mat.Dense, *mat.VecDense, error) {
+	return s.r.Read(s.size)
+}
+
+// JSONLStream streams mini-batches of JSON Lines data read lazily from io.Reader. Every line
+// contains one sample encoded as JSON object with "features" array and optional "label" number,
+// such as {"features": [0.5, 1.5], "label": 2}. Either all the samples are labeled or none is.
+type JSONLStream struct {
+	// s scans lines
+	s *bufio.Scanner
+	// size is mini-batch size
+	size int
+	// features is a number of sample features
+	features int
+	// labeled is true if the samples are labeled
+	labeled bool
+	// line is the number of read lines
+	line int
+}
+
+// jsonlSample is a sample of JSON Lines data
+type jsonlSample struct {
+	Features []float64 `json:"features"`
+	Label    *float64  `json:"label"`
+}
+
+// NewJSONLStream creates new JSONLStream which reads mini-batches of size samples from the supplied
+// reader and returns it. It fails with error if size is not positive.
+func NewJSONLStream(r io.Reader, size int) (*JSONLStream, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("Incorrect batch size: %d\n", size)
+	}
+	s := bufio.NewScanner(r)
+	// samples with many features make long lines
+	s.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	return &JSONLStream{
+		s:    s,
+		size: size,
+	}, nil
+}
+
+// NextBatch reads the next mini-batch of samples. Empty lines are skipped. It fails with error
+// if any line is not a valid sample or if the samples don't match the first sample.
+func (s *JSONLStream) NextBatch() (*mat.Dense, *mat.VecDense, error) {
+	var data, labels []float64
+	rows := 0
+	for rows < s.size && s.s.Scan() {
+		s.line++
+		line := s.s.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var sample jsonlSample
+		if err := json.Unmarshal(line, &sample); err != nil {
+			return nil, nil, fmt.Errorf("Incorrect sample in line %d: %v\n", s.line, err)
+		}
+		// the first sample determines the data shape
+		if s.features == 0 {
+			if len(sample.Features) == 0 {
+				return nil, nil, fmt.Errorf("No features in line %d\n", s.line)
+			}
+			s.features, s.labeled = len(sample.Features), sample.Label != nil
+		}
+		if len(sample.Features) != s.features || (sample.Label != nil) != s.labeled {
+			return nil, nil, fmt.Errorf("Inconsistent sample in line %d\n", s.line)
+		}
+		data = append(data, sample.Features...)
+		if s.labeled {
+			labels = append(labels, *sample.Label)
+		}
+		rows++
+	}
+	if err := s.s.Err(); err != nil {
+		return nil, nil, err
+	}
+	if rows == 0 {
+		return nil, nil, io.EOF
+	}
+	inMx := mat.NewDense(rows, s.features, data)
+	if !s.labeled {
+		return inMx, nil, nil
+	}
+	return inMx, mat.NewVecDense(rows, labels), nil
+}
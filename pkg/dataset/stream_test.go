@@ -0,0 +1,99 @@
+package dataset
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// readStream reads all mini-batches of the supplied stream and returns their sizes
+func readStream(s Stream) ([]int, error) {
+	var sizes []int
+	for {
+		inMx, _, err := s.NextBatch()
+		if err == io.EOF {
+			return sizes, nil
+		}
+		if err != nil {
+			return sizes, err
+		}
+		rows, _ := inMx.Dims()
+		sizes = append(sizes, rows)
+	}
+}
+
+func TestBatchesNextBatch(t *testing.T) {
+	assert := assert.New(t)
+	inMx, labels := newBatchData()
+	b, err := NewBatches(inMx, labels, 2, false, false, 42)
+	assert.NoError(err)
+	sizes, err := readStream(b)
+	assert.NoError(err)
+	assert.Equal([]int{2, 2, 1}, sizes)
+}
+
+func TestCSVStream(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := NewCSVStream(strings.NewReader("1,2\n"), nil, 0)
+	assert.Nil(s)
+	assert.Error(err)
+	s, err = NewCSVStream(strings.NewReader(""), nil, 2)
+	assert.Nil(s)
+	assert.Error(err)
+	s, err = NewCSVStream(strings.NewReader("1,2\n3,4\n5,6\n"), &CSVOptions{Label: "1"}, 2)
+	assert.NoError(err)
+	assert.NotNil(s.Reader())
+	inMx, labels, err := s.NextBatch()
+	assert.NoError(err)
+	assert.True(mat.Equal(inMx, mat.NewDense(2, 1, []float64{1, 3})))
+	assert.Equal([]float64{2, 4}, labels.RawVector().Data)
+	sizes, err := readStream(s)
+	assert.NoError(err)
+	assert.Equal([]int{1}, sizes)
+}
+
+func TestJSONLStream(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := NewJSONLStream(strings.NewReader(""), 0)
+	assert.Nil(s)
+	assert.Error(err)
+	data := `{"features": [1, 2], "label": 1}` + "\n\n" +
+		`{"features": [3, 4], "label": 2}` + "\n" +
+		`{"features": [5, 6], "label": 1}` + "\n"
+	s, err = NewJSONLStream(strings.NewReader(data), 2)
+	assert.NoError(err)
+	inMx, labels, err := s.NextBatch()
+	assert.NoError(err)
+	assert.True(mat.Equal(inMx, mat.NewDense(2, 2, []float64{1, 2, 3, 4})))
+	assert.Equal([]float64{1, 2}, labels.RawVector().Data)
+	inMx, labels, err = s.NextBatch()
+	assert.NoError(err)
+	assert.True(mat.Equal(inMx, mat.NewDense(1, 2, []float64{5, 6})))
+	assert.Equal([]float64{1}, labels.RawVector().Data)
+	_, _, err = s.NextBatch()
+	assert.Equal(io.EOF, err)
+	// unlabeled samples
+	s, err = NewJSONLStream(strings.NewReader(`{"features": [1]}`), 2)
+	assert.NoError(err)
+	inMx, labels, err = s.NextBatch()
+	assert.NoError(err)
+	assert.NotNil(inMx)
+	assert.Nil(labels)
+	// incorrect samples
+	for _, data := range []string{
+		"foo\n",
+		`{"features": []}`,
+		`{"features": [1], "label": 1}` + "\n" + `{"features": [1, 2], "label": 1}`,
+		`{"features": [1], "label": 1}` + "\n" + `{"features": [1]}`,
+	} {
+		s, err = NewJSONLStream(strings.NewReader(data), 2)
+		assert.NoError(err)
+		_, err = readStream(s)
+		assert.Error(err, data)
+	}
+}
@@ -0,0 +1,59 @@
+package dataset
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Windows converts the supplied time series into supervised learning data using sliding windows.
+// Every row of the series contains the values of all the variables at one time step, so univariate
+// series have a single column. Sample i contains lookback consecutive time steps starting at step i,
+// flattened in time order with the variables of every step next to each other. Its targets are the
+// values of the target columns at the following horizon time steps, in time order. nil targets
+// select all the columns. It returns the samples and the targets in rows. It fails with error if
+// the series is nil, if lookback or horizon is not positive, if any target column is out of range
+// or if the series is too short to make a single window.
+func Windows(series mat.Matrix, lookback, horizon int, targets []int) (*mat.Dense, *mat.Dense, error) {
+	if series == nil {
+		return nil, nil, fmt.Errorf("Incorrect series supplied: %v\n", series)
+	}
+	if lookback <= 0 || horizon <= 0 {
+		return nil, nil, fmt.Errorf("Incorrect window. Lookback: %d, Horizon: %d\n", lookback, horizon)
+	}
+	steps, cols := series.Dims()
+	if targets == nil {
+		for j := 0; j < cols; j++ {
+			targets = append(targets, j)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, nil, fmt.Errorf("No target columns supplied\n")
+	}
+	for _, j := range targets {
+		if j < 0 || j >= cols {
+			return nil, nil, fmt.Errorf("Incorrect target column: %d\n", j)
+		}
+	}
+	windows := steps - lookback - horizon + 1
+	if windows <= 0 {
+		return nil, nil, fmt.Errorf("Insufficient series length: %d\n", steps)
+	}
+	inMx := mat.NewDense(windows, lookback*cols, nil)
+	outMx := mat.NewDense(windows, horizon*len(targets), nil)
+	for i := 0; i < windows; i++ {
+		in := inMx.RawRowView(i)
+		for s := 0; s < lookback; s++ {
+			for j := 0; j < cols; j++ {
+				in[s*cols+j] = series.At(i+s, j)
+			}
+		}
+		out := outMx.RawRowView(i)
+		for s := 0; s < horizon; s++ {
+			for k, j := range targets {
+				out[s*len(targets)+k] = series.At(i+lookback+s, j)
+			}
+		}
+	}
+	return inMx, outMx, nil
+}
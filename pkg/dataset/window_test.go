@@ -0,0 +1,49 @@
+package dataset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestWindows(t *testing.T) {
+	assert := assert.New(t)
+
+	// univariate series
+	series := mat.NewDense(5, 1, []float64{1, 2, 3, 4, 5})
+	inMx, outMx, err := Windows(series, 2, 1, nil)
+	assert.NoError(err)
+	assert.True(mat.Equal(inMx, mat.NewDense(3, 2, []float64{1, 2, 2, 3, 3, 4})))
+	assert.True(mat.Equal(outMx, mat.NewDense(3, 1, []float64{3, 4, 5})))
+	inMx, outMx, err = Windows(series, 2, 2, nil)
+	assert.NoError(err)
+	assert.True(mat.Equal(inMx, mat.NewDense(2, 2, []float64{1, 2, 2, 3})))
+	assert.True(mat.Equal(outMx, mat.NewDense(2, 2, []float64{3, 4, 4, 5})))
+	// multivariate series with a single target
+	series = mat.NewDense(3, 2, []float64{1, 10, 2, 20, 3, 30})
+	inMx, outMx, err = Windows(series, 2, 1, []int{1})
+	assert.NoError(err)
+	assert.True(mat.Equal(inMx, mat.NewDense(1, 4, []float64{1, 10, 2, 20})))
+	assert.True(mat.Equal(outMx, mat.NewDense(1, 1, []float64{30})))
+	// incorrect parameters
+	incorrect := []struct {
+		series   mat.Matrix
+		lookback int
+		horizon  int
+		targets  []int
+	}{
+		{nil, 1, 1, nil},
+		{series, 0, 1, nil},
+		{series, 1, 0, nil},
+		{series, 1, 1, []int{}},
+		{series, 1, 1, []int{2}},
+		{series, 2, 2, nil},
+	}
+	for _, tc := range incorrect {
+		inMx, outMx, err = Windows(tc.series, tc.lookback, tc.horizon, tc.targets)
+		assert.Nil(inMx)
+		assert.Nil(outMx)
+		assert.Error(err)
+	}
+}
@@ -0,0 +1,103 @@
+package eval
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Average is a method of averaging metrics of multi-class classification over the classes
+type Average int
+
+const (
+	// Macro is the unweighted mean of the metrics of all the classes
+	Macro Average = iota
+	// Micro computes the metric from the samples of all the classes pooled together
+	Micro
+	// Weighted is the mean of the metrics of all the classes weighted by class support
+	Weighted
+)
+
+// String returns the name of the averaging method
+func (a Average) String() string {
+	switch a {
+	case Macro:
+		return "macro"
+	case Micro:
+		return "micro"
+	case Weighted:
+		return "weighted"
+	}
+	return "unknown"
+}
+
+// Average returns the class metrics averaged by the supplied method.
+// It returns macro averages if the method is not known.
+func (r *Report) Average(avg Average) ClassMetrics {
+	switch avg {
+	case Micro:
+		return r.Micro
+	case Weighted:
+		return r.Weighted
+	}
+	return r.Macro
+}
+
+// AverageAUC computes the area under ROC curve of the supplied predictions averaged over the classes
+// by the supplied method. Macro average is the mean of one-vs-rest AUCs of all the classes which have
+// both positive and negative samples and weighted average weights them by class support. Micro average
+// is AUC of all the sample and class pairs pooled together, where the class probabilities are scores
+// of the pairs and the pairs of true classes are positive. Unlike AUC, binary classifiers are averaged
+// over both classes, too. It fails with error if the predictions don't match the labels, if the method
+// is not known or if no class has both positive and negative samples.
+func AverageAUC(pred mat.Matrix, labels *mat.VecDense, avg Average) (float64, error) {
+	if err := checkPred(pred, labels); err != nil {
+		return 0.0, err
+	}
+	rows, cols := pred.Dims()
+	switch avg {
+	case Micro:
+		// pairs are binary classified: score of positive class 2 is in the second column
+		pairs := mat.NewDense(rows*cols, 2, nil)
+		pairLabels := mat.NewVecDense(rows*cols, nil)
+		for i := 0; i < rows; i++ {
+			for j := 0; j < cols; j++ {
+				pairs.Set(i*cols+j, 1, pred.At(i, j))
+				label := 1.0
+				if int(labels.At(i, 0)) == j+1 {
+					label = 2.0
+				}
+				pairLabels.SetVec(i*cols+j, label)
+			}
+		}
+		curve, err := ROC(pairs, pairLabels, 2)
+		if err != nil {
+			return 0.0, err
+		}
+		return curve.AUC, nil
+	case Macro, Weighted:
+		sum, weights := 0.0, 0.0
+		for class := 1; class <= cols; class++ {
+			curve, err := ROC(pred, labels, class)
+			if err != nil {
+				continue
+			}
+			weight := 1.0
+			if avg == Weighted {
+				weight = 0.0
+				for i := 0; i < rows; i++ {
+					if int(labels.At(i, 0)) == class {
+						weight++
+					}
+				}
+			}
+			sum += weight * curve.AUC
+			weights += weight
+		}
+		if weights == 0 {
+			return 0.0, fmt.Errorf("AUC requires a class with positive and negative samples\n")
+		}
+		return sum / weights, nil
+	}
+	return 0.0, fmt.Errorf("Unsupported average: %s\n", avg)
+}
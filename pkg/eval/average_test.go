@@ -0,0 +1,68 @@
+package eval
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestReportAverage(t *testing.T) {
+	assert := assert.New(t)
+	c := &ConfusionMatrix{Counts: [][]int{{3, 1}, {2, 0}}}
+	r := c.Report()
+	assert.Equal(r.Macro, r.Average(Macro))
+	assert.Equal(r.Weighted, r.Average(Weighted))
+	// micro averages equal accuracy
+	m := r.Average(Micro)
+	assert.Equal(ClassMetrics{Precision: 0.5, Recall: 0.5, F1: 0.5, Support: 6}, m)
+	assert.InDelta(r.Accuracy, m.F1, 1e-12)
+	assert.Contains(r.String(), "micro avg")
+	assert.Equal("macro", Macro.String())
+	assert.Equal("micro", Micro.String())
+	assert.Equal("weighted", Weighted.String())
+	// F1 metric averages the classes the same way
+	pred, labels := testPred()
+	cm, err := NewConfusionMatrix(pred, labels)
+	assert.NoError(err)
+	for _, avg := range []Average{Macro, Micro, Weighted} {
+		f := &F1Metric{Average: avg}
+		assert.NoError(f.Update(pred, labels))
+		assert.InDelta(cm.Report().Average(avg).F1, f.Result(), 1e-12, "%s", avg)
+	}
+}
+
+func TestAverageAUC(t *testing.T) {
+	assert := assert.New(t)
+	pred, _ := testPred()
+	labels := mat.NewVecDense(6, []float64{1, 1, 1, 2, 2, 3})
+	var aucs []float64
+	for class := 1; class <= 3; class++ {
+		curve, err := ROC(pred, labels, class)
+		assert.NoError(err)
+		aucs = append(aucs, curve.AUC)
+	}
+	auc, err := AverageAUC(pred, labels, Macro)
+	assert.NoError(err)
+	assert.InDelta((aucs[0]+aucs[1]+aucs[2])/3, auc, 1e-12)
+	multiAUC, err := AUC(pred, labels)
+	assert.NoError(err)
+	assert.Equal(auc, multiAUC)
+	auc, err = AverageAUC(pred, labels, Weighted)
+	assert.NoError(err)
+	assert.InDelta((3*aucs[0]+2*aucs[1]+aucs[2])/6, auc, 1e-12)
+	// micro average ranks all the sample and class pairs
+	auc, err = AverageAUC(mat.NewDense(2, 2, []float64{0.8, 0.2, 0.3, 0.7}), mat.NewVecDense(2, []float64{1, 2}), Micro)
+	assert.NoError(err)
+	assert.InDelta(1.0, auc, 1e-12)
+	auc, err = AverageAUC(mat.NewDense(2, 2, []float64{0.6, 0.4, 0.6, 0.4}), mat.NewVecDense(2, []float64{1, 2}), Micro)
+	assert.NoError(err)
+	assert.InDelta(0.5, auc, 1e-12)
+	// unknown average and labels of a single class
+	_, err = AverageAUC(pred, labels, Average(5))
+	assert.Error(err)
+	for _, avg := range []Average{Macro, Weighted} {
+		_, err = AverageAUC(pred, mat.NewVecDense(6, []float64{1, 1, 1, 1, 1, 1}), avg)
+		assert.Error(err)
+	}
+}
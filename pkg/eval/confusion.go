@@ -0,0 +1,230 @@
+package eval
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"gonum.org/v1/gonum/mat"
+)
+
+// ConfusionMatrix counts the samples of every class predicted as every class.
+// Classes are labeled 1, 2, ... the same way as network outputs.
+type ConfusionMatrix struct {
+	// Counts contains the number of samples of class i+1 predicted as class j+1 in i-th row and j-th column
+	Counts [][]int
+}
+
+// NewConfusionMatrix creates confusion matrix of the supplied predictions and true labels.
+// pred contains a row of class probabilities, such as the output of a network, per sample
+// and the predicted class is the class with the largest probability. It fails with error if
+// the predictions are nil or empty, if the number of labels does not match the number of
+// samples or if any label is not between 1 and the number of pred columns.
+func NewConfusionMatrix(pred mat.Matrix, labels *mat.VecDense) (*ConfusionMatrix, error) {
+	if err := checkPred(pred, labels); err != nil {
+		return nil, err
+	}
+	_, classes := pred.Dims()
+	c := newConfusionMatrix(classes)
+	if err := c.add(pred, labels); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// newConfusionMatrix creates empty confusion matrix of the supplied number of classes
+func newConfusionMatrix(classes int) *ConfusionMatrix {
+	c := &ConfusionMatrix{Counts: make([][]int, classes)}
+	for i := range c.Counts {
+		c.Counts[i] = make([]int, classes)
+	}
+	return c
+}
+
+// add counts the supplied predictions, which must have been checked by checkPred,
+// in the confusion matrix. It fails with error if the number of classes does not match.
+func (c *ConfusionMatrix) add(pred mat.Matrix, labels *mat.VecDense) error {
+	if _, classes := pred.Dims(); classes != len(c.Counts) {
+		return fmt.Errorf("Classes mismatch. Confusion matrix: %d, Pred: %d\n", len(c.Counts), classes)
+	}
+	predLabels, err := matrix.MakeLabelsVec(pred)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < labels.Len(); i++ {
+		c.Counts[int(labels.At(i, 0))-1][int(predLabels.At(i, 0))-1]++
+	}
+	return nil
+}
+
+// checkPred checks that the predictions and labels match and that all the labels are valid
+func checkPred(pred mat.Matrix, labels *mat.VecDense) error {
+	if pred == nil || labels == nil {
+		return fmt.Errorf("Incorrect predictions supplied. Pred: %v, Labels: %v\n", pred, labels)
+	}
+	rows, cols := pred.Dims()
+	if rows == 0 || cols == 0 {
+		return fmt.Errorf("Incorrect predictions dimensions: %d x %d\n", rows, cols)
+	}
+	if labels.Len() != rows {
+		return fmt.Errorf("Labels mismatch. Samples: %d, Labels: %d\n", rows, labels.Len())
+	}
+	for i := 0; i < rows; i++ {
+		label := labels.At(i, 0)
+		if label < 1 || label > float64(cols) || label != float64(int(label)) {
+			return fmt.Errorf("Incorrect label: %f\n", label)
+		}
+	}
+	return nil
+}
+
+// Classes returns the number of classes
+func (c *ConfusionMatrix) Classes() int {
+	return len(c.Counts)
+}
+
+// Samples returns the number of samples
+func (c *ConfusionMatrix) Samples() int {
+	samples := 0
+	for _, row := range c.Counts {
+		for _, count := range row {
+			samples += count
+		}
+	}
+	return samples
+}
+
+// Accuracy returns the fraction of correctly classified samples
+func (c *ConfusionMatrix) Accuracy() float64 {
+	samples := c.Samples()
+	if samples == 0 {
+		return 0.0
+	}
+	hits := 0
+	for i := range c.Counts {
+		hits += c.Counts[i][i]
+	}
+	return float64(hits) / float64(samples)
+}
+
+// String returns the confusion matrix formatted as a table with true classes in rows
+func (c *ConfusionMatrix) String() string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(w, "True\\Pred\t")
+	for j := range c.Counts {
+		fmt.Fprintf(w, "%d\t", j+1)
+	}
+	fmt.Fprintln(w)
+	for i, row := range c.Counts {
+		fmt.Fprintf(w, "%d\t", i+1)
+		for _, count := range row {
+			fmt.Fprintf(w, "%d\t", count)
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// ClassMetrics contains classification metrics of a single class or their average
+type ClassMetrics struct {
+	// Precision is the fraction of the samples predicted as the class which belong to the class
+	Precision float64
+	// Recall is the fraction of the samples of the class which are predicted as the class
+	Recall float64
+	// F1 is the harmonic mean of precision and recall
+	F1 float64
+	// Support is the number of samples of the class
+	Support int
+}
+
+// Report is classification report containing metrics of every class and their averages
+type Report struct {
+	// Classes contains metrics of class i+1 at index i
+	Classes []ClassMetrics
+	// Accuracy is the fraction of correctly classified samples
+	Accuracy float64
+	// Macro contains unweighted averages of class metrics
+	Macro ClassMetrics
+	// Micro contains metrics of all the classes pooled together, which all equal accuracy
+	// as every sample belongs to a single class
+	Micro ClassMetrics
+	// Weighted contains averages of class metrics weighted by class support
+	Weighted ClassMetrics
+}
+
+// Report computes precision, recall, F1 and support of every class along with their macro, micro
+// and support weighted averages. Precision of a class which is never predicted, and recall of a class
+// with no samples, are 0.
+func (c *ConfusionMatrix) Report() *Report {
+	r := &Report{
+		Classes:  make([]ClassMetrics, len(c.Counts)),
+		Accuracy: c.Accuracy(),
+	}
+	samples := c.Samples()
+	hits := 0
+	for i := range c.Counts {
+		tp, predicted, support := c.Counts[i][i], 0, 0
+		hits += tp
+		for j := range c.Counts {
+			predicted += c.Counts[j][i]
+			support += c.Counts[i][j]
+		}
+		m := ClassMetrics{Support: support}
+		m.Precision = ratio(tp, predicted)
+		m.Recall = ratio(tp, support)
+		m.F1 = f1(m.Precision, m.Recall)
+		r.Classes[i] = m
+		r.Macro.Precision += m.Precision / float64(len(c.Counts))
+		r.Macro.Recall += m.Recall / float64(len(c.Counts))
+		r.Macro.F1 += m.F1 / float64(len(c.Counts))
+		if samples > 0 {
+			w := float64(support) / float64(samples)
+			r.Weighted.Precision += w * m.Precision
+			r.Weighted.Recall += w * m.Recall
+			r.Weighted.F1 += w * m.F1
+		}
+	}
+	// pooled false positives and false negatives are both the misclassified samples
+	r.Micro.Precision = ratio(hits, samples)
+	r.Micro.Recall = ratio(hits, samples)
+	r.Micro.F1 = f1(r.Micro.Precision, r.Micro.Recall)
+	r.Macro.Support, r.Micro.Support, r.Weighted.Support = samples, samples, samples
+	return r
+}
+
+// ratio returns a/b or 0 if b is 0
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0.0
+	}
+	return float64(a) / float64(b)
+}
+
+// f1 returns harmonic mean of precision and recall or 0 if both are 0
+func f1(precision, recall float64) float64 {
+	if precision+recall == 0 {
+		return 0.0
+	}
+	return 2 * precision * recall / (precision + recall)
+}
+
+// String returns the report formatted as a table of class metrics followed by the accuracy and averages
+func (r *Report) String() string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Class\tPrecision\tRecall\tF1\tSupport\t")
+	for i, m := range r.Classes {
+		fmt.Fprintf(w, "%d\t%.4f\t%.4f\t%.4f\t%d\t\n", i+1, m.Precision, m.Recall, m.F1, m.Support)
+	}
+	fmt.Fprintln(w, "\t\t\t\t\t")
+	fmt.Fprintf(w, "accuracy\t\t\t%.4f\t%d\t\n", r.Accuracy, r.Macro.Support)
+	fmt.Fprintf(w, "macro avg\t%.4f\t%.4f\t%.4f\t%d\t\n", r.Macro.Precision, r.Macro.Recall, r.Macro.F1, r.Macro.Support)
+	fmt.Fprintf(w, "micro avg\t%.4f\t%.4f\t%.4f\t%d\t\n", r.Micro.Precision, r.Micro.Recall, r.Micro.F1, r.Micro.Support)
+	fmt.Fprintf(w, "weighted avg\t%.4f\t%.4f\t%.4f\t%d\t\n",
+		r.Weighted.Precision, r.Weighted.Recall, r.Weighted.F1, r.Weighted.Support)
+	w.Flush()
+	return buf.String()
+}
@@ -0,0 +1,84 @@
+package eval
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// testPred returns predictions of 3 classes and true labels of 6 samples: argmax of predictions
+// is 1, 2, 2, 2, 3, 1 while the labels are 1, 1, 2, 2, 3, 3
+func testPred() (*mat.Dense, *mat.VecDense) {
+	pred := mat.NewDense(6, 3, []float64{
+		0.8, 0.1, 0.1,
+		0.3, 0.6, 0.1,
+		0.2, 0.7, 0.1,
+		0.1, 0.5, 0.4,
+		0.1, 0.2, 0.7,
+		0.5, 0.1, 0.4,
+	})
+	labels := mat.NewVecDense(6, []float64{1, 1, 2, 2, 3, 3})
+	return pred, labels
+}
+
+func TestConfusionMatrix(t *testing.T) {
+	assert := assert.New(t)
+	pred, labels := testPred()
+	c, err := NewConfusionMatrix(pred, labels)
+	assert.NoError(err)
+	assert.Equal([][]int{{1, 1, 0}, {0, 2, 0}, {1, 0, 1}}, c.Counts)
+	assert.Equal(3, c.Classes())
+	assert.Equal(6, c.Samples())
+	assert.InDelta(4.0/6.0, c.Accuracy(), 1e-12)
+	out := c.String()
+	assert.True(strings.Contains(out, "True\\Pred"))
+	assert.Len(strings.Split(strings.TrimSpace(out), "\n"), 4)
+	// incorrect predictions
+	_, err = NewConfusionMatrix(nil, labels)
+	assert.Error(err)
+	_, err = NewConfusionMatrix(pred, mat.NewVecDense(2, []float64{1, 2}))
+	assert.Error(err)
+	for _, label := range []float64{0, 4, 1.5} {
+		_, err = NewConfusionMatrix(mat.NewDense(1, 3, nil), mat.NewVecDense(1, []float64{label}))
+		assert.Error(err, "%f", label)
+	}
+}
+
+func TestReport(t *testing.T) {
+	assert := assert.New(t)
+	pred, labels := testPred()
+	c, err := NewConfusionMatrix(pred, labels)
+	assert.NoError(err)
+	r := c.Report()
+	exp := []ClassMetrics{
+		{Precision: 0.5, Recall: 0.5, F1: 0.5, Support: 2},
+		{Precision: 2.0 / 3.0, Recall: 1.0, F1: 0.8, Support: 2},
+		{Precision: 1.0, Recall: 0.5, F1: 2.0 / 3.0, Support: 2},
+	}
+	for i, m := range exp {
+		assert.InDelta(m.Precision, r.Classes[i].Precision, 1e-12)
+		assert.InDelta(m.Recall, r.Classes[i].Recall, 1e-12)
+		assert.InDelta(m.F1, r.Classes[i].F1, 1e-12)
+		assert.Equal(m.Support, r.Classes[i].Support)
+	}
+	assert.InDelta(4.0/6.0, r.Accuracy, 1e-12)
+	assert.InDelta(13.0/18.0, r.Macro.Precision, 1e-12)
+	assert.InDelta(2.0/3.0, r.Macro.Recall, 1e-12)
+	assert.InDelta((0.5+0.8+2.0/3.0)/3.0, r.Macro.F1, 1e-12)
+	assert.Equal(6, r.Macro.Support)
+	// all classes have the same support
+	assert.InDelta(r.Macro.F1, r.Weighted.F1, 1e-12)
+	// class which is never predicted and class with no samples
+	c = &ConfusionMatrix{Counts: [][]int{{3, 1}, {0, 0}}}
+	r = c.Report()
+	assert.Equal(ClassMetrics{Precision: 1.0, Recall: 0.75, F1: 6.0 / 7.0, Support: 4}, r.Classes[0])
+	assert.Equal(ClassMetrics{}, r.Classes[1])
+	assert.InDelta(6.0/7.0, r.Weighted.F1, 1e-12)
+	assert.InDelta(3.0/7.0, r.Macro.F1, 1e-12)
+	out := r.String()
+	for _, s := range []string{"Precision", "accuracy", "macro avg", "weighted avg", "0.8571"} {
+		assert.True(strings.Contains(out, s), "%s", s)
+	}
+}
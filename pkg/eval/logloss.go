@@ -0,0 +1,49 @@
+package eval
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// probEps bounds probabilities away from 0 and 1, so log-loss of confident mistakes is finite
+const probEps = 1e-15
+
+// LogLoss returns the mean cross-entropy of the supplied predictions against the true labels, which is
+// the mean negative log probability of the true class of every sample. pred contains a row of class
+// probabilities per sample. Rows are normalized to sum to 1, so the percents returned by Classify can
+// be evaluated, too, and probabilities are clipped to [1e-15, 1-1e-15]. Lower log-loss is better.
+// It fails with error if the predictions don't match the labels or if any row does not sum to
+// a positive number.
+func LogLoss(pred mat.Matrix, labels *mat.VecDense) (float64, error) {
+	if err := checkPred(pred, labels); err != nil {
+		return 0.0, err
+	}
+	loss, err := logLossSum(pred, labels)
+	if err != nil {
+		return 0.0, err
+	}
+	rows, _ := pred.Dims()
+	return loss / float64(rows), nil
+}
+
+// logLossSum returns the sum of negative log probabilities of the true classes of the supplied
+// predictions, which must have been checked by checkPred
+func logLossSum(pred mat.Matrix, labels *mat.VecDense) (float64, error) {
+	rows, cols := pred.Dims()
+	loss := 0.0
+	for i := 0; i < rows; i++ {
+		sum := 0.0
+		for j := 0; j < cols; j++ {
+			sum += pred.At(i, j)
+		}
+		if !(sum > 0) || math.IsInf(sum, 1) {
+			return 0.0, fmt.Errorf("Incorrect probabilities of sample %d: sum %f\n", i, sum)
+		}
+		p := pred.At(i, int(labels.At(i, 0))-1) / sum
+		p = math.Max(probEps, math.Min(1-probEps, p))
+		loss -= math.Log(p)
+	}
+	return loss, nil
+}
@@ -0,0 +1,37 @@
+package eval
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestLogLoss(t *testing.T) {
+	assert := assert.New(t)
+	pred := mat.NewDense(3, 2, []float64{
+		0.9, 0.1,
+		0.2, 0.8,
+		0.6, 0.4,
+	})
+	labels := mat.NewVecDense(3, []float64{1, 2, 2})
+	loss, err := LogLoss(pred, labels)
+	assert.NoError(err)
+	assert.InDelta(-(math.Log(0.9)+math.Log(0.8)+math.Log(0.4))/3, loss, 1e-12)
+	// percents give the same loss
+	percents := new(mat.Dense)
+	percents.Scale(100, pred)
+	pLoss, err := LogLoss(percents, labels)
+	assert.NoError(err)
+	assert.InDelta(loss, pLoss, 1e-12)
+	// confident mistakes are finite
+	loss, err = LogLoss(mat.NewDense(1, 2, []float64{1, 0}), mat.NewVecDense(1, []float64{2}))
+	assert.NoError(err)
+	assert.InDelta(-math.Log(probEps), loss, 1e-9)
+	// incorrect probabilities
+	_, err = LogLoss(mat.NewDense(1, 2, []float64{0, 0}), mat.NewVecDense(1, []float64{2}))
+	assert.Error(err)
+	_, err = LogLoss(pred, mat.NewVecDense(3, []float64{1, 2, 3}))
+	assert.Error(err)
+}
@@ -0,0 +1,114 @@
+package eval
+
+import "gonum.org/v1/gonum/mat"
+
+// Metric is a streaming evaluation metric which is updated with the predictions of one batch of samples
+// at a time, so metrics of large data sets can be computed without holding all the predictions in memory.
+// Custom metrics implementing Metric can be added to neural.Trainer, which aggregates them across
+// mini-batches.
+type Metric interface {
+	// Update updates the metric with the predictions of a batch of samples and their true labels
+	Update(pred mat.Matrix, labels *mat.VecDense) error
+	// Result returns the metric of all the samples supplied since the last Reset
+	Result() float64
+	// Reset discards all the supplied samples
+	Reset()
+}
+
+// AccuracyMetric is Metric which computes the fraction of correctly classified samples
+type AccuracyMetric struct {
+	hits    int
+	samples int
+}
+
+// Update counts correctly classified samples of the supplied predictions.
+// It fails with error if the predictions don't match the labels.
+func (a *AccuracyMetric) Update(pred mat.Matrix, labels *mat.VecDense) error {
+	c, err := NewConfusionMatrix(pred, labels)
+	if err != nil {
+		return err
+	}
+	for i := range c.Counts {
+		a.hits += c.Counts[i][i]
+	}
+	a.samples += labels.Len()
+	return nil
+}
+
+// Result returns the fraction of correctly classified samples or 0 if no samples were supplied
+func (a *AccuracyMetric) Result() float64 {
+	return ratio(a.hits, a.samples)
+}
+
+// Reset discards all the supplied samples
+func (a *AccuracyMetric) Reset() {
+	a.hits, a.samples = 0, 0
+}
+
+// LogLossMetric is Metric which computes the mean cross-entropy of the predictions the same way as LogLoss
+type LogLossMetric struct {
+	sum     float64
+	samples int
+}
+
+// Update adds the log-loss of the supplied predictions.
+// It fails with error if the predictions don't match the labels or if they are not probabilities.
+func (l *LogLossMetric) Update(pred mat.Matrix, labels *mat.VecDense) error {
+	if err := checkPred(pred, labels); err != nil {
+		return err
+	}
+	sum, err := logLossSum(pred, labels)
+	if err != nil {
+		return err
+	}
+	l.sum += sum
+	l.samples += labels.Len()
+	return nil
+}
+
+// Result returns the mean log-loss or 0 if no samples were supplied
+func (l *LogLossMetric) Result() float64 {
+	if l.samples == 0 {
+		return 0.0
+	}
+	return l.sum / float64(l.samples)
+}
+
+// Reset discards all the supplied samples
+func (l *LogLossMetric) Reset() {
+	l.sum, l.samples = 0.0, 0
+}
+
+// F1Metric is Metric which computes average F1 of all the classes from the confusion matrix
+// accumulated across the batches. The number of classes is set by the first batch.
+type F1Metric struct {
+	// Average is the method of averaging F1 over the classes: zero value means macro average
+	Average Average
+	c       *ConfusionMatrix
+}
+
+// Update counts the supplied predictions in the accumulated confusion matrix. It fails with error if
+// the predictions don't match the labels or if their number of classes differs from the previous batches.
+func (f *F1Metric) Update(pred mat.Matrix, labels *mat.VecDense) error {
+	if err := checkPred(pred, labels); err != nil {
+		return err
+	}
+	if f.c == nil {
+		_, classes := pred.Dims()
+		f.c = newConfusionMatrix(classes)
+	}
+	return f.c.add(pred, labels)
+}
+
+// Result returns average F1 or 0 if no samples were supplied
+func (f *F1Metric) Result() float64 {
+	if f.c == nil {
+		return 0.0
+	}
+	return f.c.Report().Average(f.Average).F1
+}
+
+// Reset discards all the supplied samples
+func (f *F1Metric) Reset() {
+	f.c = nil
+}
@@ -0,0 +1,43 @@
+package eval
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestMetrics(t *testing.T) {
+	assert := assert.New(t)
+	pred, labels := testPred()
+	c, err := NewConfusionMatrix(pred, labels)
+	assert.NoError(err)
+	logLoss, err := LogLoss(pred, labels)
+	assert.NoError(err)
+	metrics := []struct {
+		m   Metric
+		exp float64
+	}{
+		{&AccuracyMetric{}, c.Accuracy()},
+		{&LogLossMetric{}, logLoss},
+		{&F1Metric{}, c.Report().Macro.F1},
+	}
+	for _, tc := range metrics {
+		assert.Equal(0.0, tc.m.Result())
+		// streamed batches give the same result as all the samples at once
+		for _, rows := range [][2]int{{0, 4}, {4, 2}} {
+			batch := pred.Slice(rows[0], rows[0]+rows[1], 0, 3)
+			assert.NoError(tc.m.Update(batch, labels.SliceVec(rows[0], rows[0]+rows[1]).(*mat.VecDense)))
+		}
+		assert.InDelta(tc.exp, tc.m.Result(), 1e-12, "%T", tc.m)
+		// incorrect predictions do not change the result
+		assert.Error(tc.m.Update(pred, mat.NewVecDense(1, []float64{1})))
+		assert.InDelta(tc.exp, tc.m.Result(), 1e-12, "%T", tc.m)
+		tc.m.Reset()
+		assert.Equal(0.0, tc.m.Result())
+	}
+	// number of classes must not change
+	f := &F1Metric{}
+	assert.NoError(f.Update(pred, labels))
+	assert.Error(f.Update(mat.NewDense(1, 2, []float64{0.4, 0.6}), mat.NewVecDense(1, []float64{1})))
+}
@@ -0,0 +1,86 @@
+package eval
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"text/tabwriter"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// RegressionMetrics contains regression metrics of a single output or their aggregate
+type RegressionMetrics struct {
+	// MAE is mean absolute error
+	MAE float64
+	// RMSE is root mean squared error
+	RMSE float64
+	// R2 is coefficient of determination
+	R2 float64
+}
+
+// RegressionReport contains regression metrics of every output and their aggregate
+type RegressionReport struct {
+	// Outputs contains metrics of i-th output at index i
+	Outputs []RegressionMetrics
+	// Aggregate contains MAE and RMSE of all the outputs together and the mean R2 of the outputs
+	Aggregate RegressionMetrics
+}
+
+// Regression computes MAE, RMSE and R2 of every column of the supplied predictions against the
+// target values along with their aggregate. R2 of an output whose target values are constant
+// is 1 if the output is predicted exactly, otherwise it is 0. It fails with error if either of
+// the matrices is nil or empty or if their dimensions don't match.
+func Regression(pred, target mat.Matrix) (*RegressionReport, error) {
+	if pred == nil || target == nil {
+		return nil, fmt.Errorf("Incorrect predictions supplied. Pred: %v, Target: %v\n", pred, target)
+	}
+	rows, cols := pred.Dims()
+	tRows, tCols := target.Dims()
+	if rows == 0 || cols == 0 || rows != tRows || cols != tCols {
+		return nil, fmt.Errorf("Dimension mismatch. Pred: %d x %d, Target: %d x %d\n", rows, cols, tRows, tCols)
+	}
+	r := &RegressionReport{Outputs: make([]RegressionMetrics, cols)}
+	absSum, sqSum := 0.0, 0.0
+	for j := 0; j < cols; j++ {
+		mean := 0.0
+		for i := 0; i < rows; i++ {
+			mean += target.At(i, j) / float64(rows)
+		}
+		abs, sq, total := 0.0, 0.0, 0.0
+		for i := 0; i < rows; i++ {
+			diff := pred.At(i, j) - target.At(i, j)
+			abs += math.Abs(diff)
+			sq += diff * diff
+			total += (target.At(i, j) - mean) * (target.At(i, j) - mean)
+		}
+		m := &r.Outputs[j]
+		m.MAE = abs / float64(rows)
+		m.RMSE = math.Sqrt(sq / float64(rows))
+		switch {
+		case total > 0:
+			m.R2 = 1 - sq/total
+		case sq == 0:
+			m.R2 = 1.0
+		}
+		absSum += abs
+		sqSum += sq
+		r.Aggregate.R2 += m.R2 / float64(cols)
+	}
+	r.Aggregate.MAE = absSum / float64(rows*cols)
+	r.Aggregate.RMSE = math.Sqrt(sqSum / float64(rows*cols))
+	return r, nil
+}
+
+// String returns the report formatted as a table of output metrics followed by their aggregate
+func (r *RegressionReport) String() string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Output\tMAE\tRMSE\tR2\t")
+	for j, m := range r.Outputs {
+		fmt.Fprintf(w, "%d\t%.4f\t%.4f\t%.4f\t\n", j, m.MAE, m.RMSE, m.R2)
+	}
+	fmt.Fprintf(w, "aggregate\t%.4f\t%.4f\t%.4f\t\n", r.Aggregate.MAE, r.Aggregate.RMSE, r.Aggregate.R2)
+	w.Flush()
+	return buf.String()
+}
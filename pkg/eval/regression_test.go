@@ -0,0 +1,49 @@
+package eval
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestRegression(t *testing.T) {
+	assert := assert.New(t)
+	target := mat.NewDense(4, 2, []float64{
+		1, 2,
+		2, 2,
+		3, 2,
+		4, 2,
+	})
+	pred := mat.NewDense(4, 2, []float64{
+		1, 2,
+		2, 2,
+		3, 2,
+		5, 2,
+	})
+	r, err := Regression(pred, target)
+	assert.NoError(err)
+	assert.InDelta(0.25, r.Outputs[0].MAE, 1e-12)
+	assert.InDelta(0.5, r.Outputs[0].RMSE, 1e-12)
+	assert.InDelta(0.8, r.Outputs[0].R2, 1e-12)
+	// constant target predicted exactly
+	assert.Equal(RegressionMetrics{R2: 1.0}, r.Outputs[1])
+	assert.InDelta(0.125, r.Aggregate.MAE, 1e-12)
+	assert.InDelta(math.Sqrt(0.125), r.Aggregate.RMSE, 1e-12)
+	assert.InDelta(0.9, r.Aggregate.R2, 1e-12)
+	out := r.String()
+	assert.True(strings.Contains(out, "aggregate"))
+	assert.True(strings.Contains(out, "0.8000"))
+	// constant target predicted with error
+	pred.Set(0, 1, 3)
+	r, err = Regression(pred, target)
+	assert.NoError(err)
+	assert.Equal(0.0, r.Outputs[1].R2)
+	// incorrect predictions
+	_, err = Regression(nil, target)
+	assert.Error(err)
+	_, err = Regression(mat.NewDense(4, 1, nil), target)
+	assert.Error(err)
+}
@@ -0,0 +1,105 @@
+package eval
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// ROCPoint is a point of ROC curve
+type ROCPoint struct {
+	// Threshold is the smallest score of samples classified as positive
+	Threshold float64
+	// TPR is true positive rate: the fraction of positive samples classified as positive
+	TPR float64
+	// FPR is false positive rate: the fraction of negative samples classified as positive
+	FPR float64
+}
+
+// ROCCurve is receiver operating characteristic curve of a single class
+type ROCCurve struct {
+	// Points contains curve points ordered by decreasing threshold, starting at infinite threshold
+	// with no samples classified as positive and ending with all samples classified as positive
+	Points []ROCPoint
+	// AUC is the area under the curve
+	AUC float64
+}
+
+// ROC computes ROC curve of the supplied class, which is the positive class while all the other
+// classes are negative, so binary classifiers as well as one-vs-rest multi-class classifiers can be
+// evaluated. pred contains a row of class probabilities per sample and the probability of the class
+// is used as sample score. Samples with the same score share a single curve point.
+// It fails with error if the predictions don't match the labels, if the class does not exist or if
+// the labels don't contain both positive and negative samples.
+func ROC(pred mat.Matrix, labels *mat.VecDense, class int) (*ROCCurve, error) {
+	if err := checkPred(pred, labels); err != nil {
+		return nil, err
+	}
+	rows, cols := pred.Dims()
+	if class < 1 || class > cols {
+		return nil, fmt.Errorf("Incorrect class: %d\n", class)
+	}
+	idx := make([]int, rows)
+	positives := 0
+	for i := range idx {
+		idx[i] = i
+		if int(labels.At(i, 0)) == class {
+			positives++
+		}
+	}
+	negatives := rows - positives
+	if positives == 0 || negatives == 0 {
+		return nil, fmt.Errorf("ROC of class %d requires positive and negative samples. Positive: %d, Negative: %d\n",
+			class, positives, negatives)
+	}
+	// samples are classified as positive in the order of decreasing score
+	sort.SliceStable(idx, func(a, b int) bool {
+		return pred.At(idx[a], class-1) > pred.At(idx[b], class-1)
+	})
+	curve := &ROCCurve{Points: []ROCPoint{{Threshold: math.Inf(1)}}}
+	tp, fp := 0, 0
+	for i, sample := range idx {
+		if int(labels.At(sample, 0)) == class {
+			tp++
+		} else {
+			fp++
+		}
+		score := pred.At(sample, class-1)
+		// samples with the same score are classified together
+		if i+1 < len(idx) && pred.At(idx[i+1], class-1) == score {
+			continue
+		}
+		prev := curve.Points[len(curve.Points)-1]
+		p := ROCPoint{
+			Threshold: score,
+			TPR:       float64(tp) / float64(positives),
+			FPR:       float64(fp) / float64(negatives),
+		}
+		curve.AUC += (p.FPR - prev.FPR) * (p.TPR + prev.TPR) / 2
+		curve.Points = append(curve.Points, p)
+	}
+	return curve, nil
+}
+
+// AUC computes the area under ROC curve of the supplied predictions. AUC of binary classifiers,
+// whose predictions have two columns, is AUC of class 2. AUC of multi-class classifiers is the
+// mean of one-vs-rest AUCs of all the classes which have both positive and negative samples;
+// AverageAUC allows to choose other averaging methods.
+// It fails with error if the predictions don't match the labels or if no class has both positive
+// and negative samples.
+func AUC(pred mat.Matrix, labels *mat.VecDense) (float64, error) {
+	if err := checkPred(pred, labels); err != nil {
+		return 0.0, err
+	}
+	_, cols := pred.Dims()
+	if cols == 2 {
+		curve, err := ROC(pred, labels, 2)
+		if err != nil {
+			return 0.0, err
+		}
+		return curve.AUC, nil
+	}
+	return AverageAUC(pred, labels, Macro)
+}
@@ -0,0 +1,86 @@
+package eval
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// binaryPred returns predictions of binary classifier with class 2 scores 0.9, 0.8, 0.7, 0.6, 0.55, 0.4
+func binaryPred() (*mat.Dense, *mat.VecDense) {
+	scores := []float64{0.9, 0.8, 0.7, 0.6, 0.55, 0.4}
+	pred := mat.NewDense(len(scores), 2, nil)
+	for i, s := range scores {
+		pred.Set(i, 0, 1-s)
+		pred.Set(i, 1, s)
+	}
+	return pred, mat.NewVecDense(6, []float64{2, 2, 1, 2, 1, 1})
+}
+
+func TestROC(t *testing.T) {
+	assert := assert.New(t)
+	pred, labels := binaryPred()
+	curve, err := ROC(pred, labels, 2)
+	assert.NoError(err)
+	exp := []ROCPoint{
+		{Threshold: math.Inf(1), TPR: 0, FPR: 0},
+		{Threshold: 0.9, TPR: 1.0 / 3.0, FPR: 0},
+		{Threshold: 0.8, TPR: 2.0 / 3.0, FPR: 0},
+		{Threshold: 0.7, TPR: 2.0 / 3.0, FPR: 1.0 / 3.0},
+		{Threshold: 0.6, TPR: 1, FPR: 1.0 / 3.0},
+		{Threshold: 0.55, TPR: 1, FPR: 2.0 / 3.0},
+		{Threshold: 0.4, TPR: 1, FPR: 1},
+	}
+	assert.Len(curve.Points, len(exp))
+	for i, p := range exp {
+		assert.Equal(p.Threshold, curve.Points[i].Threshold)
+		assert.InDelta(p.TPR, curve.Points[i].TPR, 1e-12)
+		assert.InDelta(p.FPR, curve.Points[i].FPR, 1e-12)
+	}
+	assert.InDelta(8.0/9.0, curve.AUC, 1e-12)
+	// class 1 scores and labels are complementary
+	curve, err = ROC(pred, labels, 1)
+	assert.NoError(err)
+	assert.InDelta(8.0/9.0, curve.AUC, 1e-12)
+	// tied scores share a point
+	curve, err = ROC(mat.NewDense(2, 2, []float64{0.5, 0.5, 0.5, 0.5}), mat.NewVecDense(2, []float64{1, 2}), 2)
+	assert.NoError(err)
+	assert.Len(curve.Points, 2)
+	assert.InDelta(0.5, curve.AUC, 1e-12)
+	// incorrect class
+	_, err = ROC(pred, labels, 3)
+	assert.Error(err)
+	// no negative samples
+	_, err = ROC(pred, mat.NewVecDense(6, []float64{2, 2, 2, 2, 2, 2}), 2)
+	assert.Error(err)
+}
+
+func TestAUC(t *testing.T) {
+	assert := assert.New(t)
+	pred, labels := binaryPred()
+	auc, err := AUC(pred, labels)
+	assert.NoError(err)
+	assert.InDelta(8.0/9.0, auc, 1e-12)
+	// multi-class AUC is the mean of one-vs-rest AUCs
+	pred, labels = testPred()
+	auc, err = AUC(pred, labels)
+	assert.NoError(err)
+	sum := 0.0
+	for class := 1; class <= 3; class++ {
+		curve, err := ROC(pred, labels, class)
+		assert.NoError(err)
+		sum += curve.AUC
+	}
+	assert.InDelta(sum/3.0, auc, 1e-12)
+	// classes without negative samples are skipped
+	pred = mat.NewDense(2, 3, []float64{0.8, 0.1, 0.1, 0.3, 0.6, 0.1})
+	labels = mat.NewVecDense(2, []float64{1, 2})
+	auc, err = AUC(pred, labels)
+	assert.NoError(err)
+	assert.InDelta(1.0, auc, 1e-12)
+	// single class
+	_, err = AUC(pred, mat.NewVecDense(2, []float64{1, 1}))
+	assert.Error(err)
+}
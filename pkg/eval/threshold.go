@@ -0,0 +1,69 @@
+package eval
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// BinaryMetric scores binary classification of a single class from the numbers of true positive,
+// false positive, false negative and true negative samples. Larger scores are better.
+type BinaryMetric func(tp, fp, fn, tn int) float64
+
+// F1Score is BinaryMetric which returns F1 score of the class
+func F1Score(tp, fp, fn, tn int) float64 {
+	return f1(ratio(tp, tp+fp), ratio(tp, tp+fn))
+}
+
+// TuneThreshold sweeps decision thresholds of the supplied class and returns the threshold which
+// maximizes the supplied metric along with its score. Samples whose class score, which is the class
+// column of pred, reaches the threshold are classified as the class. The swept thresholds are the
+// scores of the samples, so the threshold is in the same units as pred, such as the percents returned
+// by Classify. nil metric maximizes F1Score. Ties are resolved in favour of the largest threshold.
+// It fails with error if the predictions don't match the labels, if the class does not exist or if
+// the labels don't contain both positive and negative samples of the class.
+func TuneThreshold(pred mat.Matrix, labels *mat.VecDense, class int, metric BinaryMetric) (float64, float64, error) {
+	curve, err := ROC(pred, labels, class)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	if metric == nil {
+		metric = F1Score
+	}
+	positives := 0
+	for i := 0; i < labels.Len(); i++ {
+		if int(labels.At(i, 0)) == class {
+			positives++
+		}
+	}
+	negatives := labels.Len() - positives
+	best, bestScore := 0.0, math.Inf(-1)
+	// the first curve point has infinite threshold which classifies no samples as the class
+	for _, p := range curve.Points[1:] {
+		tp := int(math.Floor(p.TPR*float64(positives) + 0.5))
+		fp := int(math.Floor(p.FPR*float64(negatives) + 0.5))
+		if score := metric(tp, fp, positives-tp, negatives-fp); score > bestScore {
+			best, bestScore = p.Threshold, score
+		}
+	}
+	return best, bestScore, nil
+}
+
+// TuneThresholds tunes decision thresholds of all the classes using TuneThreshold and returns them
+// ordered by class labels. Classes without both positive and negative samples get zero threshold,
+// which never rejects the class. It fails with error if the predictions don't match the labels.
+func TuneThresholds(pred mat.Matrix, labels *mat.VecDense, metric BinaryMetric) ([]float64, error) {
+	if err := checkPred(pred, labels); err != nil {
+		return nil, err
+	}
+	_, cols := pred.Dims()
+	thresholds := make([]float64, cols)
+	for class := 1; class <= cols; class++ {
+		threshold, _, err := TuneThreshold(pred, labels, class, metric)
+		if err != nil {
+			continue
+		}
+		thresholds[class-1] = threshold
+	}
+	return thresholds, nil
+}
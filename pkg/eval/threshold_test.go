@@ -0,0 +1,51 @@
+package eval
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestTuneThreshold(t *testing.T) {
+	assert := assert.New(t)
+	pred, labels := binaryPred()
+	threshold, score, err := TuneThreshold(pred, labels, 2, nil)
+	assert.NoError(err)
+	assert.Equal(0.6, threshold)
+	assert.InDelta(6.0/7.0, score, 1e-12)
+	// ties are resolved in favour of the largest threshold
+	accuracy := func(tp, fp, fn, tn int) float64 {
+		return float64(tp+tn) / float64(tp+fp+fn+tn)
+	}
+	threshold, score, err = TuneThreshold(pred, labels, 2, accuracy)
+	assert.NoError(err)
+	assert.Equal(0.8, threshold)
+	assert.InDelta(5.0/6.0, score, 1e-12)
+	// incorrect class and labels
+	_, _, err = TuneThreshold(pred, labels, 3, nil)
+	assert.Error(err)
+	_, _, err = TuneThreshold(pred, mat.NewVecDense(6, []float64{1, 1, 1, 1, 1, 1}), 2, nil)
+	assert.Error(err)
+}
+
+func TestTuneThresholds(t *testing.T) {
+	assert := assert.New(t)
+	pred, labels := testPred()
+	thresholds, err := TuneThresholds(pred, labels, F1Score)
+	assert.NoError(err)
+	assert.Len(thresholds, 3)
+	assert.Equal(0.3, thresholds[0])
+	for class := 1; class <= 3; class++ {
+		threshold, _, err := TuneThreshold(pred, labels, class, nil)
+		assert.NoError(err)
+		assert.Equal(threshold, thresholds[class-1])
+	}
+	// classes without both positive and negative samples are never rejected
+	pred, _ = binaryPred()
+	thresholds, err = TuneThresholds(pred, mat.NewVecDense(6, []float64{1, 1, 1, 1, 1, 1}), nil)
+	assert.NoError(err)
+	assert.Equal([]float64{0, 0}, thresholds)
+	_, err = TuneThresholds(pred, mat.NewVecDense(1, []float64{1}), nil)
+	assert.Error(err)
+}
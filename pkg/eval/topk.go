@@ -0,0 +1,36 @@
+package eval
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// TopKAccuracy returns the fraction of samples whose true class is among the k classes with the
+// largest predicted probabilities. pred contains a row of class probabilities per sample. The true
+// class is among the top k classes if fewer than k classes have larger probability, so classes tied
+// with the k-th class count as hits. It fails with error if the predictions don't match the labels
+// or if k is not between 1 and the number of classes.
+func TopKAccuracy(pred mat.Matrix, labels *mat.VecDense, k int) (float64, error) {
+	if err := checkPred(pred, labels); err != nil {
+		return 0.0, err
+	}
+	rows, cols := pred.Dims()
+	if k < 1 || k > cols {
+		return 0.0, fmt.Errorf("Incorrect k: %d\n", k)
+	}
+	hits := 0
+	for i := 0; i < rows; i++ {
+		score := pred.At(i, int(labels.At(i, 0))-1)
+		larger := 0
+		for j := 0; j < cols; j++ {
+			if pred.At(i, j) > score {
+				larger++
+			}
+		}
+		if larger < k {
+			hits++
+		}
+	}
+	return float64(hits) / float64(rows), nil
+}
@@ -0,0 +1,38 @@
+package eval
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestTopKAccuracy(t *testing.T) {
+	assert := assert.New(t)
+	pred, labels := testPred()
+	// top-1 accuracy is accuracy
+	acc, err := TopKAccuracy(pred, labels, 1)
+	assert.NoError(err)
+	assert.InDelta(4.0/6.0, acc, 1e-12)
+	// second sample and last sample have their class second
+	acc, err = TopKAccuracy(pred, labels, 2)
+	assert.NoError(err)
+	assert.InDelta(1.0, acc, 1e-12)
+	acc, err = TopKAccuracy(pred, labels, 3)
+	assert.NoError(err)
+	assert.InDelta(1.0, acc, 1e-12)
+	// ties with the k-th class count as hits
+	acc, err = TopKAccuracy(mat.NewDense(1, 3, []float64{0.4, 0.3, 0.3}), mat.NewVecDense(1, []float64{3}), 2)
+	assert.NoError(err)
+	assert.Equal(1.0, acc)
+	acc, err = TopKAccuracy(mat.NewDense(1, 3, []float64{0.4, 0.3, 0.3}), mat.NewVecDense(1, []float64{3}), 1)
+	assert.NoError(err)
+	assert.Equal(0.0, acc)
+	// incorrect k
+	for _, k := range []int{0, 4} {
+		_, err = TopKAccuracy(pred, labels, k)
+		assert.Error(err, "%d", k)
+	}
+	_, err = TopKAccuracy(pred, mat.NewVecDense(1, []float64{1}), 1)
+	assert.Error(err)
+}
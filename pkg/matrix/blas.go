@@ -0,0 +1,69 @@
+package matrix
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"gonum.org/v1/gonum/blas"
+	"gonum.org/v1/gonum/blas/blas64"
+	"gonum.org/v1/gonum/blas/gonum"
+)
+
+var (
+	// blasMu protects blasImpls and blasName
+	blasMu sync.Mutex
+	// blasImpls contains BLAS implementations available in the binary by name
+	blasImpls = map[string]blas.Float64{"native": gonum.Implementation{}}
+	// blasName is the name of the BLAS implementation in use
+	blasName = "native"
+)
+
+// registerBLAS makes BLAS implementation available under the supplied name.
+// If def is true the implementation is used by default.
+func registerBLAS(name string, impl blas.Float64, def bool) {
+	blasMu.Lock()
+	defer blasMu.Unlock()
+	blasImpls[name] = impl
+	if def {
+		blas64.Use(impl)
+		blasName = name
+	}
+}
+
+// BLASBackends returns sorted names of BLAS implementations available in the binary.
+// native pure Go implementation is always available, cblas implementation, which calls
+// OpenBLAS, Accelerate or any other CBLAS library via cgo, is available in binaries
+// built with cblas build tag.
+func BLASBackends() []string {
+	blasMu.Lock()
+	defer blasMu.Unlock()
+	var names []string
+	for name := range blasImpls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BLAS returns the name of BLAS implementation used by all matrix operations
+func BLAS() string {
+	blasMu.Lock()
+	defer blasMu.Unlock()
+	return blasName
+}
+
+// UseBLAS selects BLAS implementation used by all matrix operations, including matrix
+// multiplication of network layers. It should be called before any networks are trained.
+// It fails with error if the implementation is not available in the binary.
+func UseBLAS(name string) error {
+	blasMu.Lock()
+	defer blasMu.Unlock()
+	impl, ok := blasImpls[name]
+	if !ok {
+		return fmt.Errorf("Unsupported BLAS implementation: %s\n", name)
+	}
+	blas64.Use(impl)
+	blasName = name
+	return nil
+}
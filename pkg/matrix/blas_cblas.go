@@ -0,0 +1,12 @@
+//go:build cblas
+// +build cblas
+
+package matrix
+
+import "gonum.org/v1/netlib/blas/netlib"
+
+// binaries built with cblas build tag use CBLAS library via cgo by default;
+// the library is linked according to CGO_LDFLAGS, e.g. -lopenblas
+func init() {
+	registerBLAS("cblas", netlib.Implementation{}, true)
+}
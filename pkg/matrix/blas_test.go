@@ -0,0 +1,27 @@
+package matrix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestUseBLAS(t *testing.T) {
+	assert := assert.New(t)
+
+	// native implementation is always available
+	assert.Contains(BLASBackends(), "native")
+	def := BLAS()
+	defer UseBLAS(def)
+	assert.NoError(UseBLAS("native"))
+	assert.Equal("native", BLAS())
+	// matrix multiplication uses the selected implementation
+	a := mat.NewDense(2, 2, []float64{1, 2, 3, 4})
+	out := new(mat.Dense)
+	out.Mul(a, a)
+	assert.True(mat.Equal(mat.NewDense(2, 2, []float64{7, 10, 15, 22}), out))
+	// unsupported implementation throws error
+	assert.Error(UseBLAS("foo"))
+	assert.Equal("native", BLAS())
+}
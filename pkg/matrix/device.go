@@ -0,0 +1,86 @@
+package matrix
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Device performs matrix multiplications of network forward propagation and backpropagation.
+// CPU device, which multiplies matrices using the selected BLAS implementation, is always available;
+// accelerator devices, such as GPUs, are available in binaries built with their build tags.
+// Devices can also implement Activator.
+type Device interface {
+	// Name returns device name
+	Name() string
+	// Mul stores the product of matrices a and b in dst the same way as mat.Dense Mul does.
+	// It panics if the dimensions of the matrices don't match or if the device fails.
+	Mul(dst *mat.Dense, a, b mat.Matrix)
+}
+
+// Activator is implemented by devices which can also apply activation functions, so accelerators
+// can activate layer neurons along with multiplying their inputs
+type Activator interface {
+	// Activate applies the activation function with the supplied name to all elements of matrix a
+	// and stores the result in dst the same way as mat.Dense Apply does. Activation names are the
+	// names of layer activation functions: sigmoid, tanh, relu, stdrelu and softmax, which only
+	// applies exponential, and tanhout, which is tanh of OUTPUT layers rescaled to 0.5*(tanh(x)+1).
+	// It returns false if the device does not support the activation function.
+	Activate(dst *mat.Dense, activation string, a mat.Matrix) bool
+}
+
+// CPU is the default device which multiplies matrices in the main memory
+var CPU Device = cpu{}
+
+// cpu multiplies matrices by gonum using the selected BLAS implementation
+type cpu struct{}
+
+// Name returns device name
+func (cpu) Name() string {
+	return "cpu"
+}
+
+// Mul stores the product of matrices a and b in dst
+func (cpu) Mul(dst *mat.Dense, a, b mat.Matrix) {
+	dst.Mul(a, b)
+}
+
+var (
+	// devicesMu protects devices
+	devicesMu sync.Mutex
+	// devices contains devices available in the binary by name
+	devices = map[string]Device{"cpu": CPU}
+)
+
+// registerDevice makes the supplied device available by its name
+func registerDevice(d Device) {
+	devicesMu.Lock()
+	defer devicesMu.Unlock()
+	devices[d.Name()] = d
+}
+
+// Devices returns sorted names of devices available in the binary
+func Devices() []string {
+	devicesMu.Lock()
+	defer devicesMu.Unlock()
+	var names []string
+	for name := range devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupDevice returns the device with the supplied name.
+// It fails with error if the device is not available in the binary.
+func LookupDevice(name string) (Device, error) {
+	devicesMu.Lock()
+	defer devicesMu.Unlock()
+	d, ok := devices[name]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported device: %s\n", name)
+	}
+	return d, nil
+}
@@ -0,0 +1,27 @@
+//go:build cuda || opencl
+// +build cuda opencl
+
+package matrix
+
+import "gonum.org/v1/gonum/mat"
+
+// rowMajor returns the supplied matrix as a dense matrix whose rows are stored contiguously,
+// so its raw data can be copied to device memory at once
+func rowMajor(m mat.Matrix) *mat.Dense {
+	if d, ok := m.(*mat.Dense); ok {
+		if raw := d.RawMatrix(); raw.Stride == raw.Cols {
+			return d
+		}
+	}
+	return mat.DenseCopyOf(m)
+}
+
+// reuseAs prepares dst for a result of the supplied dimensions: empty dst is resized,
+// otherwise its dimensions must match or reuseAs panics
+func reuseAs(dst *mat.Dense, rows, cols int) {
+	if dst.IsEmpty() {
+		dst.ReuseAs(rows, cols)
+	} else if r, c := dst.Dims(); r != rows || c != cols {
+		panic(mat.ErrShape)
+	}
+}
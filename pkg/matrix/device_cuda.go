@@ -0,0 +1,105 @@
+//go:build cuda
+// +build cuda
+
+package matrix
+
+/*
+#cgo LDFLAGS: -lcublas -lcudart
+#include <cuda_runtime.h>
+#include <cublas_v2.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// binaries built with cuda build tag can multiply matrices on NVIDIA GPUs using cuBLAS;
+// CUDA toolkit headers and libraries must be available to cgo, e.g. via CGO_CFLAGS and CGO_LDFLAGS.
+// The device is only available if CUDA runtime finds at least one GPU.
+func init() {
+	var count C.int
+	if C.cudaGetDeviceCount(&count) == C.cudaSuccess && count > 0 {
+		registerDevice(&cuda{})
+	}
+}
+
+// cuda multiplies matrices on the default CUDA device using cuBLAS
+type cuda struct {
+	// mu serializes the use of cuBLAS handle
+	mu     sync.Mutex
+	handle C.cublasHandle_t
+}
+
+// Name returns device name
+func (c *cuda) Name() string {
+	return "cuda"
+}
+
+// Mul stores the product of matrices a and b in dst. The matrices are copied to device memory,
+// multiplied by cublasDgemm and the result is copied back. It panics if the dimensions of the
+// matrices don't match or if CUDA fails.
+func (c *cuda) Mul(dst *mat.Dense, a, b mat.Matrix) {
+	m, k := a.Dims()
+	bk, n := b.Dims()
+	if k != bk {
+		panic(mat.ErrShape)
+	}
+	reuseAs(dst, m, n)
+	denseA, denseB := rowMajor(a), rowMajor(b)
+	out := make([]float64, m*n)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.handle == nil {
+		if status := C.cublasCreate(&c.handle); status != C.CUBLAS_STATUS_SUCCESS {
+			panic(fmt.Errorf("Unable to create cuBLAS handle: %d\n", int(status)))
+		}
+	}
+	devA := toDevice(denseA.RawMatrix().Data)
+	defer C.cudaFree(devA)
+	devB := toDevice(denseB.RawMatrix().Data)
+	defer C.cudaFree(devB)
+	devC := allocDevice(len(out))
+	defer C.cudaFree(devC)
+	// cuBLAS matrices are column-major: row-major C = A x B is column-major Cᵀ = Bᵀ x Aᵀ
+	alpha, beta := C.double(1.0), C.double(0.0)
+	status := C.cublasDgemm(c.handle, C.CUBLAS_OP_N, C.CUBLAS_OP_N, C.int(n), C.int(m), C.int(k),
+		&alpha, (*C.double)(devB), C.int(n), (*C.double)(devA), C.int(k), &beta, (*C.double)(devC), C.int(n))
+	if status != C.CUBLAS_STATUS_SUCCESS {
+		panic(fmt.Errorf("cuBLAS multiplication failed: %d\n", int(status)))
+	}
+	if len(out) > 0 {
+		checkCUDA(C.cudaMemcpy(unsafe.Pointer(&out[0]), devC, C.size_t(len(out)*8), C.cudaMemcpyDeviceToHost))
+	}
+	dst.Copy(mat.NewDense(m, n, out))
+}
+
+// allocDevice allocates device memory for size float64 values
+func allocDevice(size int) unsafe.Pointer {
+	var ptr unsafe.Pointer
+	if size == 0 {
+		return ptr
+	}
+	checkCUDA(C.cudaMalloc(&ptr, C.size_t(size*8)))
+	return ptr
+}
+
+// toDevice copies the supplied values to newly allocated device memory
+func toDevice(data []float64) unsafe.Pointer {
+	ptr := allocDevice(len(data))
+	if len(data) > 0 {
+		checkCUDA(C.cudaMemcpy(ptr, unsafe.Pointer(&data[0]), C.size_t(len(data)*8), C.cudaMemcpyHostToDevice))
+	}
+	return ptr
+}
+
+// checkCUDA panics if the supplied CUDA runtime call failed
+func checkCUDA(err C.cudaError_t) {
+	if err != C.cudaSuccess {
+		panic(fmt.Errorf("CUDA failed: %s\n", C.GoString(C.cudaGetErrorString(err))))
+	}
+}
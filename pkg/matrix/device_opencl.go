@@ -0,0 +1,294 @@
+//go:build opencl
+// +build opencl
+
+package matrix
+
+/*
+#cgo linux LDFLAGS: -lOpenCL
+#cgo darwin LDFLAGS: -framework OpenCL
+#define CL_TARGET_OPENCL_VERSION 120
+#define CL_USE_DEPRECATED_OPENCL_1_2_APIS
+#include <stdlib.h>
+#ifdef __APPLE__
+#include <OpenCL/opencl.h>
+#else
+#include <CL/cl.h>
+#endif
+*/
+import "C"
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// kernelSource contains OpenCL kernels of matrix multiplication and activation functions
+const kernelSource = `
+#pragma OPENCL EXTENSION cl_khr_fp64 : enable
+
+__kernel void matmul(const int m, const int n, const int k,
+		__global const double *a, __global const double *b, __global double *c) {
+	int i = get_global_id(0);
+	int j = get_global_id(1);
+	if (i >= m || j >= n) {
+		return;
+	}
+	double sum = 0.0;
+	for (int p = 0; p < k; p++) {
+		sum += a[i*k+p] * b[p*n+j];
+	}
+	c[i*n+j] = sum;
+}
+
+__kernel void activate(const int kind, const int size, __global const double *a, __global double *out) {
+	int i = get_global_id(0);
+	if (i >= size) {
+		return;
+	}
+	double x = a[i];
+	switch (kind) {
+	case 0:
+		out[i] = 1.0 / (1.0 + exp(-x));
+		break;
+	case 1:
+		out[i] = exp(x);
+		break;
+	case 2:
+		out[i] = tanh(x);
+		break;
+	case 3:
+		out[i] = x > 0.0 ? x : 0.1 * x;
+		break;
+	case 4:
+		out[i] = x > 0.0 ? x : 0.0;
+		break;
+	default:
+		out[i] = 0.5 * (tanh(x) + 1.0);
+	}
+}
+`
+
+// clActivations maps activation function names to the kinds of activate kernel
+var clActivations = map[string]C.cl_int{
+	"sigmoid": 0,
+	"softmax": 1,
+	"tanh":    2,
+	"relu":    3,
+	"stdrelu": 4,
+	"tanhout": 5,
+}
+
+// binaries built with opencl build tag can multiply matrices and apply activation functions on
+// OpenCL devices, such as AMD or Intel GPUs; the device is only available if an OpenCL platform is found
+func init() {
+	var platforms C.cl_uint
+	if C.clGetPlatformIDs(0, nil, &platforms) == C.CL_SUCCESS && platforms > 0 {
+		registerDevice(&opencl{})
+	}
+}
+
+// opencl multiplies matrices and applies activation functions on the first GPU of the first
+// OpenCL platform or on its first device of any type if the platform has no GPU.
+// The device must support double precision.
+type opencl struct {
+	// once sets up the device when it's used for the first time
+	once sync.Once
+	err  error
+	// mu serializes the use of the command queue and kernels
+	mu       sync.Mutex
+	ctx      C.cl_context
+	queue    C.cl_command_queue
+	matmul   C.cl_kernel
+	activate C.cl_kernel
+}
+
+// Name returns device name
+func (o *opencl) Name() string {
+	return "opencl"
+}
+
+// setup creates OpenCL context and command queue and builds the kernels
+func (o *opencl) setup() error {
+	var platform C.cl_platform_id
+	if st := C.clGetPlatformIDs(1, &platform, nil); st != C.CL_SUCCESS {
+		return clError("clGetPlatformIDs", st)
+	}
+	var device C.cl_device_id
+	if st := C.clGetDeviceIDs(platform, C.CL_DEVICE_TYPE_GPU, 1, &device, nil); st != C.CL_SUCCESS {
+		if st := C.clGetDeviceIDs(platform, C.CL_DEVICE_TYPE_ALL, 1, &device, nil); st != C.CL_SUCCESS {
+			return clError("clGetDeviceIDs", st)
+		}
+	}
+	var st C.cl_int
+	if o.ctx = C.clCreateContext(nil, 1, &device, nil, nil, &st); st != C.CL_SUCCESS {
+		return clError("clCreateContext", st)
+	}
+	if o.queue = C.clCreateCommandQueue(o.ctx, device, 0, &st); st != C.CL_SUCCESS {
+		return clError("clCreateCommandQueue", st)
+	}
+	src := C.CString(kernelSource)
+	defer C.free(unsafe.Pointer(src))
+	program := C.clCreateProgramWithSource(o.ctx, 1, &src, nil, &st)
+	if st != C.CL_SUCCESS {
+		return clError("clCreateProgramWithSource", st)
+	}
+	if st := C.clBuildProgram(program, 1, &device, nil, nil, nil); st != C.CL_SUCCESS {
+		return clError("clBuildProgram", st)
+	}
+	for name, kernel := range map[string]*C.cl_kernel{"matmul": &o.matmul, "activate": &o.activate} {
+		cName := C.CString(name)
+		*kernel = C.clCreateKernel(program, cName, &st)
+		C.free(unsafe.Pointer(cName))
+		if st != C.CL_SUCCESS {
+			return clError("clCreateKernel", st)
+		}
+	}
+	return nil
+}
+
+// Mul stores the product of matrices a and b in dst. It panics if the dimensions of the matrices
+// don't match or if OpenCL fails.
+func (o *opencl) Mul(dst *mat.Dense, a, b mat.Matrix) {
+	m, k := a.Dims()
+	bk, n := b.Dims()
+	if k != bk {
+		panic(mat.ErrShape)
+	}
+	reuseAs(dst, m, n)
+	out := make([]float64, m*n)
+	err := o.run(func() error {
+		aMem, err := o.buffer(rowMajor(a).RawMatrix().Data)
+		if err != nil {
+			return err
+		}
+		defer C.clReleaseMemObject(aMem)
+		bMem, err := o.buffer(rowMajor(b).RawMatrix().Data)
+		if err != nil {
+			return err
+		}
+		defer C.clReleaseMemObject(bMem)
+		cMem, err := o.buffer(out)
+		if err != nil {
+			return err
+		}
+		defer C.clReleaseMemObject(cMem)
+		args := []kernelArg{clInt(m), clInt(n), clInt(k), clMem(aMem), clMem(bMem), clMem(cMem)}
+		return o.exec(o.matmul, args, []int{m, n}, cMem, out)
+	})
+	if err != nil {
+		panic(err)
+	}
+	dst.Copy(mat.NewDense(m, n, out))
+}
+
+// Activate applies the activation function with the supplied name to all elements of matrix a
+// and stores the result in dst. It panics if OpenCL fails.
+func (o *opencl) Activate(dst *mat.Dense, activation string, a mat.Matrix) bool {
+	kind, ok := clActivations[activation]
+	if !ok {
+		return false
+	}
+	rows, cols := a.Dims()
+	reuseAs(dst, rows, cols)
+	out := make([]float64, rows*cols)
+	err := o.run(func() error {
+		aMem, err := o.buffer(rowMajor(a).RawMatrix().Data)
+		if err != nil {
+			return err
+		}
+		defer C.clReleaseMemObject(aMem)
+		outMem, err := o.buffer(out)
+		if err != nil {
+			return err
+		}
+		defer C.clReleaseMemObject(outMem)
+		args := []kernelArg{{unsafe.Sizeof(kind), unsafe.Pointer(&kind)}, clInt(len(out)), clMem(aMem), clMem(outMem)}
+		return o.exec(o.activate, args, []int{len(out)}, outMem, out)
+	})
+	if err != nil {
+		panic(err)
+	}
+	dst.Copy(mat.NewDense(rows, cols, out))
+	return true
+}
+
+// run sets up the device if needed and runs the supplied function with exclusive access to the device
+func (o *opencl) run(f func() error) error {
+	o.once.Do(func() {
+		o.err = o.setup()
+	})
+	if o.err != nil {
+		return o.err
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return f()
+}
+
+// buffer creates device buffer initialized with the supplied values. Empty data, such as the data
+// of a zero-row batch, creates an uninitialized buffer of a single value as OpenCL rejects empty buffers.
+func (o *opencl) buffer(data []float64) (C.cl_mem, error) {
+	var st C.cl_int
+	var mem C.cl_mem
+	if len(data) == 0 {
+		mem = C.clCreateBuffer(o.ctx, C.CL_MEM_READ_WRITE, C.size_t(8), nil, &st)
+	} else {
+		mem = C.clCreateBuffer(o.ctx, C.CL_MEM_READ_WRITE|C.CL_MEM_COPY_HOST_PTR, C.size_t(len(data)*8),
+			unsafe.Pointer(&data[0]), &st)
+	}
+	if st != C.CL_SUCCESS {
+		return nil, clError("clCreateBuffer", st)
+	}
+	return mem, nil
+}
+
+// kernelArg is OpenCL kernel argument
+type kernelArg struct {
+	size uintptr
+	ptr  unsafe.Pointer
+}
+
+// clInt returns int kernel argument
+func clInt(v int) kernelArg {
+	i := C.cl_int(v)
+	return kernelArg{unsafe.Sizeof(i), unsafe.Pointer(&i)}
+}
+
+// clMem returns buffer kernel argument
+func clMem(mem C.cl_mem) kernelArg {
+	return kernelArg{unsafe.Sizeof(mem), unsafe.Pointer(&mem)}
+}
+
+// exec runs the kernel with the supplied arguments over the supplied global work size
+// and reads the result buffer into out. Nothing is run if out is empty.
+func (o *opencl) exec(kernel C.cl_kernel, args []kernelArg, work []int, result C.cl_mem, out []float64) error {
+	if len(out) == 0 {
+		return nil
+	}
+	for i, arg := range args {
+		if st := C.clSetKernelArg(kernel, C.cl_uint(i), C.size_t(arg.size), arg.ptr); st != C.CL_SUCCESS {
+			return clError("clSetKernelArg", st)
+		}
+	}
+	global := make([]C.size_t, len(work))
+	for i, w := range work {
+		global[i] = C.size_t(w)
+	}
+	st := C.clEnqueueNDRangeKernel(o.queue, kernel, C.cl_uint(len(global)), nil, &global[0], nil, 0, nil, nil)
+	if st != C.CL_SUCCESS {
+		return clError("clEnqueueNDRangeKernel", st)
+	}
+	st = C.clEnqueueReadBuffer(o.queue, result, C.CL_TRUE, 0, C.size_t(len(out)*8), unsafe.Pointer(&out[0]), 0, nil, nil)
+	if st != C.CL_SUCCESS {
+		return clError("clEnqueueReadBuffer", st)
+	}
+	return nil
+}
+
+// clError returns error of the failed OpenCL call
+func clError(call string, st C.cl_int) error {
+	return fmt.Errorf("OpenCL %s failed: %d\n", call, int(st))
+}
@@ -0,0 +1,27 @@
+package matrix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestDevice(t *testing.T) {
+	assert := assert.New(t)
+
+	// CPU device is always available
+	assert.Contains(Devices(), "cpu")
+	d, err := LookupDevice("cpu")
+	assert.NoError(err)
+	assert.Equal(CPU, d)
+	assert.Equal("cpu", d.Name())
+	a := mat.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6})
+	out := new(mat.Dense)
+	d.Mul(out, a, a.T())
+	assert.True(mat.Equal(mat.NewDense(2, 2, []float64{14, 32, 32, 77}), out))
+	// unsupported device throws error
+	d, err = LookupDevice("foo")
+	assert.Nil(d)
+	assert.Error(err)
+}
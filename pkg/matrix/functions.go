@@ -1,6 +1,8 @@
 package matrix
 
-import "math"
+import (
+	"math"
+)
 
 // LogMx allows to calculate log of each matrix element
 func LogMx(i, j int, x float64) float64 {
@@ -28,6 +30,24 @@ func PowMx(f float64) func(int, int, float64) float64 {
 	}
 }
 
+// NoiseMx allows to add Gaussian noise with zero mean and standard deviation std
+// to all matrix elements
+func NoiseMx(std float64) func(int, int, float64) float64 {
+	return func(i, j int, x float64) float64 {
+		return x + rng.NormFloat64()*std
+	}
+}
+
+// MaskMx allows to set matrix elements to zero with probability p
+func MaskMx(p float64) func(int, int, float64) float64 {
+	return func(i, j int, x float64) float64 {
+		if rng.Float64() < p {
+			return 0.0
+		}
+		return x
+	}
+}
+
 // ExpMx allows to calculate exponential of matrix elements
 func ExpMx(i, j int, x float64) float64 {
 	return math.Exp(x)
@@ -53,6 +73,42 @@ func SigmoidGrad(x float64) float64 {
 	return Sigmoid(x) * (1 - Sigmoid(x))
 }
 
+// FastExpMx allows to calculate fast approximate exponential of matrix elements
+func FastExpMx(i, j int, x float64) float64 {
+	return FastExp(x)
+}
+
+// FastSigmoidMx allows to apply fast approximate sigmoid func to all matrix elements
+func FastSigmoidMx(i, j int, x float64) float64 {
+	return FastSigmoid(x)
+}
+
+// FastExp provides fast approximation of exponential with relative error below 1e-5.
+// x is split into x*log2(e) = n + f, where n is an integer and |f| <= 0.5, so exp(x) = 2^n * 2^f.
+// 2^f is approximated by a polynomial and n is added to the exponent bits of the result.
+func FastExp(x float64) float64 {
+	switch {
+	case x != x:
+		return x
+	case x > 709.0:
+		return math.Inf(1)
+	case x < -708.0:
+		return 0.0
+	}
+	// adding 1.5*2^52 rounds t to the nearest integer stored in the low mantissa bits
+	const shift = 1.5 * (1 << 52)
+	t := x * math.Log2E
+	r := t + shift
+	y := (t - (r - shift)) * math.Ln2
+	p := 1 + y*(1+y*(1.0/2+y*(1.0/6+y*(1.0/24+y*(1.0/120)))))
+	return math.Float64frombits(math.Float64bits(p) + math.Float64bits(r)<<52)
+}
+
+// FastSigmoid provides fast approximation of sigmoid activation function
+func FastSigmoid(x float64) float64 {
+	return 1.0 / (1.0 + FastExp(-x))
+}
+
 // TanhMx allows to apply tanh function to all matrix elements
 func TanhMx(i, j int, x float64) float64 {
 	return math.Tanh(x)
@@ -84,3 +140,19 @@ func ReluGradMx(i, j int, x float64) float64 {
 	}
 	return 0.1
 }
+
+// StdReluMx allows to apply standard non-leaky Relu to all matrix elements
+func StdReluMx(i, j int, x float64) float64 {
+	if x > 0 {
+		return x
+	}
+	return 0.0
+}
+
+// StdReluGradMx provides standard Relu "derivation" used in backpropagation algorithm
+func StdReluGradMx(i, j int, x float64) float64 {
+	if x > 0.0 {
+		return 1.0
+	}
+	return 0.0
+}
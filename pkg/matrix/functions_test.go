@@ -4,15 +4,15 @@ import (
 	"math"
 	"testing"
 
-	"github.com/gonum/matrix/mat64"
 	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
 )
 
 func TestLogMx(t *testing.T) {
 	assert := assert.New(t)
 
 	inData := []float64{1.0, 10, 20}
-	inMx := mat64.NewDense(1, len(inData), inData)
+	inMx := mat.NewDense(1, len(inData), inData)
 	assert.NotNil(inMx)
 	// test cases
 	testCases := []struct {
@@ -24,11 +24,11 @@ func TestLogMx(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		tstMx := mat64.NewDense(1, len(tc.data), tc.data)
+		tstMx := mat.NewDense(1, len(tc.data), tc.data)
 		assert.NotNil(tstMx)
-		logMx := new(mat64.Dense)
+		logMx := new(mat.Dense)
 		logMx.Apply(LogMx, inMx)
-		assert.True(tc.expected == mat64.EqualApprox(logMx, tstMx, 0.001))
+		assert.True(tc.expected == mat.EqualApprox(logMx, tstMx, 0.001))
 	}
 }
 
@@ -36,7 +36,7 @@ func TestSubtrMx(t *testing.T) {
 	assert := assert.New(t)
 
 	inData := []float64{1.0, 2.0, 3.0}
-	inMx := mat64.NewDense(1, len(inData), inData)
+	inMx := mat.NewDense(1, len(inData), inData)
 	assert.NotNil(inMx)
 	// test cases
 	testCases := []struct {
@@ -48,11 +48,11 @@ func TestSubtrMx(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		tstMx := mat64.NewDense(1, len(tc.data), tc.data)
+		tstMx := mat.NewDense(1, len(tc.data), tc.data)
 		assert.NotNil(tstMx)
-		sub1Mx := new(mat64.Dense)
+		sub1Mx := new(mat.Dense)
 		sub1Mx.Apply(SubtrMx(1), inMx)
-		assert.True(tc.expected == mat64.Equal(sub1Mx, tstMx))
+		assert.True(tc.expected == mat.Equal(sub1Mx, tstMx))
 	}
 }
 
@@ -60,7 +60,7 @@ func TestAddMx(t *testing.T) {
 	assert := assert.New(t)
 
 	inData := []float64{1.0, 2.0, 3.0}
-	inMx := mat64.NewDense(1, len(inData), inData)
+	inMx := mat.NewDense(1, len(inData), inData)
 	assert.NotNil(inMx)
 	// test cases
 	testCases := []struct {
@@ -72,11 +72,11 @@ func TestAddMx(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		tstMx := mat64.NewDense(1, len(tc.data), tc.data)
+		tstMx := mat.NewDense(1, len(tc.data), tc.data)
 		assert.NotNil(tstMx)
-		add1Mx := new(mat64.Dense)
+		add1Mx := new(mat.Dense)
 		add1Mx.Apply(AddMx(1), inMx)
-		assert.True(tc.expected == mat64.Equal(add1Mx, tstMx))
+		assert.True(tc.expected == mat.Equal(add1Mx, tstMx))
 	}
 }
 
@@ -84,7 +84,7 @@ func TestPowMx(t *testing.T) {
 	assert := assert.New(t)
 
 	inData := []float64{1.0, 2.0, 3.0}
-	inMx := mat64.NewDense(1, len(inData), inData)
+	inMx := mat.NewDense(1, len(inData), inData)
 	assert.NotNil(inMx)
 	// test cases
 	testCases := []struct {
@@ -96,11 +96,11 @@ func TestPowMx(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		tstMx := mat64.NewDense(1, len(tc.data), tc.data)
+		tstMx := mat.NewDense(1, len(tc.data), tc.data)
 		assert.NotNil(tstMx)
-		pow3Mx := new(mat64.Dense)
+		pow3Mx := new(mat.Dense)
 		pow3Mx.Apply(PowMx(3.0), inMx)
-		assert.True(tc.expected == mat64.Equal(pow3Mx, tstMx))
+		assert.True(tc.expected == mat.Equal(pow3Mx, tstMx))
 	}
 }
 
@@ -108,7 +108,7 @@ func TestExpMx(t *testing.T) {
 	assert := assert.New(t)
 
 	inData := []float64{1.0, 0.0}
-	inMx := mat64.NewDense(1, len(inData), inData)
+	inMx := mat.NewDense(1, len(inData), inData)
 	assert.NotNil(inMx)
 	// test cases
 	testCases := []struct {
@@ -120,11 +120,11 @@ func TestExpMx(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		tstMx := mat64.NewDense(1, len(tc.data), tc.data)
+		tstMx := mat.NewDense(1, len(tc.data), tc.data)
 		assert.NotNil(tstMx)
-		expMx := new(mat64.Dense)
+		expMx := new(mat.Dense)
 		expMx.Apply(ExpMx, inMx)
-		assert.True(tc.expected == mat64.Equal(expMx, tstMx))
+		assert.True(tc.expected == mat.Equal(expMx, tstMx))
 	}
 }
 
@@ -132,7 +132,7 @@ func TestSigmoidMx(t *testing.T) {
 	assert := assert.New(t)
 
 	inData := []float64{0.0, math.Pow(10.0, 6.0), -10000000}
-	inMx := mat64.NewDense(1, len(inData), inData)
+	inMx := mat.NewDense(1, len(inData), inData)
 	assert.NotNil(inMx)
 	// test cases
 	testCases := []struct {
@@ -144,11 +144,11 @@ func TestSigmoidMx(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		tstMx := mat64.NewDense(1, len(tc.data), tc.data)
+		tstMx := mat.NewDense(1, len(tc.data), tc.data)
 		assert.NotNil(tstMx)
-		sigMx := new(mat64.Dense)
+		sigMx := new(mat.Dense)
 		sigMx.Apply(SigmoidMx, inMx)
-		assert.True(tc.expected == mat64.EqualApprox(sigMx, tstMx, 0.001))
+		assert.True(tc.expected == mat.EqualApprox(sigMx, tstMx, 0.001))
 	}
 }
 
@@ -156,7 +156,7 @@ func TestTanhMx(t *testing.T) {
 	assert := assert.New(t)
 
 	inData := []float64{0.0, math.Pow(10.0, 2.0), -10000000}
-	inMx := mat64.NewDense(1, len(inData), inData)
+	inMx := mat.NewDense(1, len(inData), inData)
 	assert.NotNil(inMx)
 	// test cases
 	testCases := []struct {
@@ -168,11 +168,11 @@ func TestTanhMx(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		tstMx := mat64.NewDense(1, len(tc.data), tc.data)
+		tstMx := mat.NewDense(1, len(tc.data), tc.data)
 		assert.NotNil(tstMx)
-		tanhMx := new(mat64.Dense)
+		tanhMx := new(mat.Dense)
 		tanhMx.Apply(TanhMx, inMx)
-		assert.True(tc.expected == mat64.EqualApprox(tanhMx, tstMx, 0.001))
+		assert.True(tc.expected == mat.EqualApprox(tanhMx, tstMx, 0.001))
 	}
 }
 
@@ -180,7 +180,7 @@ func TestTanhGradMx(t *testing.T) {
 	assert := assert.New(t)
 
 	inData := []float64{0.0, math.Pow(10.0, 2.0), -10000000}
-	inMx := mat64.NewDense(1, len(inData), inData)
+	inMx := mat.NewDense(1, len(inData), inData)
 	assert.NotNil(inMx)
 	// test cases
 	testCases := []struct {
@@ -192,11 +192,11 @@ func TestTanhGradMx(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		tstMx := mat64.NewDense(1, len(tc.data), tc.data)
+		tstMx := mat.NewDense(1, len(tc.data), tc.data)
 		assert.NotNil(tstMx)
-		tanhGradMx := new(mat64.Dense)
+		tanhGradMx := new(mat.Dense)
 		tanhGradMx.Apply(TanhGradMx, inMx)
-		assert.True(tc.expected == mat64.EqualApprox(tanhGradMx, tstMx, 0.001))
+		assert.True(tc.expected == mat.EqualApprox(tanhGradMx, tstMx, 0.001))
 	}
 }
 
@@ -204,7 +204,7 @@ func TestReluMx(t *testing.T) {
 	assert := assert.New(t)
 
 	inData := []float64{0.0, 20.0, -1.0}
-	inMx := mat64.NewDense(1, len(inData), inData)
+	inMx := mat.NewDense(1, len(inData), inData)
 	assert.NotNil(inMx)
 	// test cases
 	testCases := []struct {
@@ -216,11 +216,11 @@ func TestReluMx(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		tstMx := mat64.NewDense(1, len(tc.data), tc.data)
+		tstMx := mat.NewDense(1, len(tc.data), tc.data)
 		assert.NotNil(tstMx)
-		reluMx := new(mat64.Dense)
+		reluMx := new(mat.Dense)
 		reluMx.Apply(ReluMx, inMx)
-		assert.True(tc.expected == mat64.Equal(reluMx, tstMx))
+		assert.True(tc.expected == mat.Equal(reluMx, tstMx))
 	}
 }
 
@@ -228,7 +228,7 @@ func TestReluGradMx(t *testing.T) {
 	assert := assert.New(t)
 
 	inData := []float64{0.0, 20.0, -1.0}
-	inMx := mat64.NewDense(1, len(inData), inData)
+	inMx := mat.NewDense(1, len(inData), inData)
 	assert.NotNil(inMx)
 	// test cases
 	testCases := []struct {
@@ -240,10 +240,72 @@ func TestReluGradMx(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		tstMx := mat64.NewDense(1, len(tc.data), tc.data)
+		tstMx := mat.NewDense(1, len(tc.data), tc.data)
 		assert.NotNil(tstMx)
-		reluGradMx := new(mat64.Dense)
+		reluGradMx := new(mat.Dense)
 		reluGradMx.Apply(ReluGradMx, inMx)
-		assert.True(tc.expected == mat64.Equal(reluGradMx, tstMx))
+		assert.True(tc.expected == mat.Equal(reluGradMx, tstMx))
 	}
 }
+
+func TestStdReluMx(t *testing.T) {
+	assert := assert.New(t)
+
+	inData := []float64{0.0, 20.0, -1.0}
+	inMx := mat.NewDense(1, len(inData), inData)
+	reluMx := new(mat.Dense)
+	reluMx.Apply(StdReluMx, inMx)
+	assert.Equal([]float64{0.0, 20.0, 0.0}, reluMx.RawMatrix().Data)
+	reluGradMx := new(mat.Dense)
+	reluGradMx.Apply(StdReluGradMx, inMx)
+	assert.Equal([]float64{0.0, 1.0, 0.0}, reluGradMx.RawMatrix().Data)
+}
+
+func TestNoiseMx(t *testing.T) {
+	assert := assert.New(t)
+
+	inData := []float64{1.0, 2.0, 3.0}
+	inMx := mat.NewDense(1, len(inData), inData)
+	assert.NotNil(inMx)
+	// zero noise does not modify the matrix
+	noiseMx := new(mat.Dense)
+	noiseMx.Apply(NoiseMx(0.0), inMx)
+	assert.True(mat.Equal(noiseMx, inMx))
+	// non-zero noise modifies the matrix
+	noiseMx.Apply(NoiseMx(1.0), inMx)
+	assert.False(mat.Equal(noiseMx, inMx))
+}
+
+func TestMaskMx(t *testing.T) {
+	assert := assert.New(t)
+
+	inData := []float64{1.0, 2.0, 3.0}
+	inMx := mat.NewDense(1, len(inData), inData)
+	assert.NotNil(inMx)
+	// zero probability does not modify the matrix
+	maskMx := new(mat.Dense)
+	maskMx.Apply(MaskMx(0.0), inMx)
+	assert.True(mat.Equal(maskMx, inMx))
+	// probability 1 masks all elements
+	maskMx.Apply(MaskMx(1.0), inMx)
+	assert.True(mat.Equal(maskMx, mat.NewDense(1, len(inData), nil)))
+}
+
+func TestFastExp(t *testing.T) {
+	assert := assert.New(t)
+
+	for x := -700.0; x <= 700.0; x += 0.37 {
+		exp := math.Exp(x)
+		assert.InEpsilon(exp, FastExp(x), 1e-5, "x: %f", x)
+		assert.InDelta(Sigmoid(x), FastSigmoid(x), 1e-6, "x: %f", x)
+	}
+	assert.Equal(1.0, FastExp(0.0))
+	assert.True(math.IsInf(FastExp(1000.0), 1))
+	assert.Equal(0.0, FastExp(-1000.0))
+	assert.True(math.IsNaN(FastExp(math.NaN())))
+	assert.Equal(1.0, FastSigmoid(1000.0))
+	assert.Equal(0.0, FastSigmoid(-1000.0))
+	// matrix functions match the element functions
+	assert.Equal(FastExp(1.5), FastExpMx(0, 0, 1.5))
+	assert.Equal(FastSigmoid(1.5), FastSigmoidMx(0, 0, 1.5))
+}
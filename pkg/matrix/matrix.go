@@ -4,14 +4,71 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"sync"
 
-	"github.com/gonum/matrix/mat64"
+	"gonum.org/v1/gonum/mat"
 )
 
+// rng is random source used by MakeRandMx, NoiseMx and MaskMx.
+// It is seeded with a constant, so the random matrices are reproducible across runs.
+var rng = rand.New(rngSrc)
+
+// rngSrc is the source of rng
+var rngSrc = &lockedSource{src: rand.NewSource(55)}
+
+// lockedSource is random source which is safe for concurrent use.
+// It counts the numbers drawn since it was seeded, so its state can be restored by replaying the draws.
+type lockedSource struct {
+	mu    sync.Mutex
+	src   rand.Source
+	draws int64
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.draws++
+	return s.src.Int63()
+}
+
+// Seed seeds the random source
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+	s.draws = 0
+}
+
+// Seed seeds the random source used by MakeRandMx, NoiseMx and MaskMx
+// so that the sequence of generated random matrices is reproducible
+func Seed(seed int64) {
+	rng.Seed(seed)
+}
+
+// Draws returns the number of random numbers drawn from the random source used by MakeRandMx,
+// NoiseMx and MaskMx since it was last seeded
+func Draws() int64 {
+	rngSrc.mu.Lock()
+	defer rngSrc.mu.Unlock()
+	return rngSrc.draws
+}
+
+// SeedDraws seeds the random source used by MakeRandMx, NoiseMx and MaskMx and skips the supplied
+// number of draws, which restores the state the random source had after drawing them since it was seeded
+func SeedDraws(seed, draws int64) {
+	rng.Seed(seed)
+	rngSrc.mu.Lock()
+	defer rngSrc.mu.Unlock()
+	for ; rngSrc.draws < draws; rngSrc.draws++ {
+		rngSrc.src.Int63()
+	}
+}
+
 // Ones returns a matrix of rows x cols filled with 1.0
-func Ones(rows, cols int) *mat64.Dense {
+func Ones(rows, cols int) *mat.Dense {
 	// allocate zero matrix and set every element to 1.0
-	onesMx := mat64.NewDense(rows, cols, nil)
+	onesMx := mat.NewDense(rows, cols, nil)
 	for i := 0; i < rows; i++ {
 		for j := 0; j < cols; j++ {
 			onesMx.Set(i, j, 1.0)
@@ -20,31 +77,68 @@ func Ones(rows, cols int) *mat64.Dense {
 	return onesMx
 }
 
-// AddBias adds a bias unit (either a vector or a single unit) to mat64.Matrix
+// AddBias adds a bias unit (either a vector or a single unit) to mat.Matrix
 // and returns the new augmented matrix without modifying the original one
-func AddBias(m mat64.Matrix) *mat64.Dense {
+func AddBias(m mat.Matrix) *mat.Dense {
 	rows, cols := m.Dims()
 	// bias is a 1-column matrix that contains 1.0s
 	bias := Ones(rows, 1)
 	// allocate the new augmented bias matrix
-	biasMx := mat64.NewDense(rows, cols+1, nil)
+	biasMx := mat.NewDense(rows, cols+1, nil)
 	biasMx.Augment(bias, m)
 	return biasMx
 }
 
+// AddBiasTo stores m augmented with a bias column in dst and returns dst. dst must be either
+// empty or have the same number of rows as m and one more column, so it can be reused across
+// calls with inputs of the same dimensions. It panics if dst dimensions don't match.
+func AddBiasTo(dst *mat.Dense, m mat.Matrix) *mat.Dense {
+	rows, cols := m.Dims()
+	if dst.IsEmpty() {
+		dst.ReuseAs(rows, cols+1)
+	} else if r, c := dst.Dims(); r != rows || c != cols+1 {
+		panic(mat.ErrShape)
+	}
+	setBias(dst, m)
+	return dst
+}
+
+// AddBiasBuf stores m augmented with a bias column in matrix backed by the supplied buffer and
+// returns it along with the buffer. The buffer is only grown when it's too small for the augmented
+// matrix, so reusing the returned buffer avoids allocations across calls with inputs of any
+// dimensions. The returned matrix is only valid until the buffer is reused.
+func AddBiasBuf(buf []float64, m mat.Matrix) (*mat.Dense, []float64) {
+	rows, cols := m.Dims()
+	if size := rows * (cols + 1); cap(buf) < size {
+		buf = make([]float64, size)
+	}
+	biasMx := mat.NewDense(rows, cols+1, buf[:rows*(cols+1)])
+	setBias(biasMx, m)
+	return biasMx, buf
+}
+
+// setBias sets the first column of dst to 1.0 and copies m into the remaining columns
+func setBias(dst *mat.Dense, m mat.Matrix) {
+	rows, cols := m.Dims()
+	for i := 0; i < rows; i++ {
+		dst.Set(i, 0, 1.0)
+	}
+	dst.Slice(0, rows, 1, cols+1).(*mat.Dense).Copy(m)
+}
+
 // MakeLabelsMx creates a 1-of-N matrix from the supplied vector of labels
 // Labels matrix has the following dimensions: labels.Len() x expLabels
 // It does not modify the supplied matrix of labels.
 // It returns error if the number of labels is negative integer or
 // if one of the labels is non-positive or is greater than number of labels
-func MakeLabelsMx(labels *mat64.Vector, expLabels int) (*mat64.Dense, error) {
+func MakeLabelsMx(labels *mat.VecDense, expLabels int) (*mat.Dense, error) {
 	if expLabels < 0 {
 		return nil, fmt.Errorf("Incorrect number of labels: %d\n", expLabels)
 	}
 	// get number of samples
 	samples := labels.Len()
 	// create labels matrix
-	mx := mat64.NewDense(samples, expLabels, nil)
+	mx := mat.NewDense(samples, expLabels, nil)
 	for i := 0; i < samples; i++ {
 		val := labels.At(i, 0)
 		if val <= 0 || int(val) > expLabels {
@@ -55,29 +149,52 @@ func MakeLabelsMx(labels *mat64.Vector, expLabels int) (*mat64.Dense, error) {
 	return mx, nil
 }
 
+// MakeLabelsVec decodes the supplied matrix which contains a row of class probabilities, or a 1-of-N
+// encoded label, per sample into a vector of labels. Label of every row is the index of the column
+// with the largest value counted from 1. It does not modify the supplied matrix.
+// It returns error if the supplied matrix is nil or if it has no columns.
+func MakeLabelsVec(m mat.Matrix) (*mat.VecDense, error) {
+	if m == nil {
+		return nil, fmt.Errorf("Incorrect matrix supplied: %v\n", m)
+	}
+	rows, cols := m.Dims()
+	if cols == 0 {
+		return nil, fmt.Errorf("Incorrect number of labels: %d\n", cols)
+	}
+	labels := mat.NewVecDense(rows, nil)
+	for i := 0; i < rows; i++ {
+		max := 0
+		for j := 1; j < cols; j++ {
+			if m.At(i, j) > m.At(i, max) {
+				max = j
+			}
+		}
+		labels.SetVec(i, float64(max+1))
+	}
+	return labels, nil
+}
+
 // MakeRandMx creates a new matrix with of size rows x cols that is initialized
 // to random number uniformly distributed in interval (min, max)
-func MakeRandMx(rows, cols int, min, max float64) (*mat64.Dense, error) {
+func MakeRandMx(rows, cols int, min, max float64) (*mat.Dense, error) {
 	if rows <= 0 || cols <= 0 {
 		return nil, fmt.Errorf("Incorrect dimensions supplied: %d x %dd\n", rows, cols)
 	}
-	// set random seed
-	rand.Seed(55)
 	// empirically this is supposed to be the best value
 	epsilon := math.Sqrt(6.0) / math.Sqrt(float64(rows+cols))
 	// allocate data slice
 	randVals := make([]float64, rows*cols)
 	for i := range randVals {
 		// we need value between 0 and 1.0
-		randVals[i] = rand.Float64()*(max-min) + min
+		randVals[i] = rng.Float64()*(max-min) + min
 		randVals[i] = randVals[i]*(2*epsilon) - epsilon
 	}
-	return mat64.NewDense(rows, cols, randVals), nil
+	return mat.NewDense(rows, cols, randVals), nil
 }
 
 // Mx2Vec unrolls all elements of matrix into a slice and returns it.
 // Matrix elements can be unrolled either by row or by a column.
-func Mx2Vec(m *mat64.Dense, byRow bool) []float64 {
+func Mx2Vec(m *mat.Dense, byRow bool) []float64 {
 	if byRow {
 		return mx2VecByRow(m)
 	}
@@ -85,7 +202,7 @@ func Mx2Vec(m *mat64.Dense, byRow bool) []float64 {
 }
 
 // mx2VecByRow rolls matrix into a slice by rows
-func mx2VecByRow(m *mat64.Dense) []float64 {
+func mx2VecByRow(m *mat.Dense) []float64 {
 	rows, cols := m.Dims()
 	vec := make([]float64, rows*cols)
 	for i := 0; i < rows; i++ {
@@ -98,7 +215,7 @@ func mx2VecByRow(m *mat64.Dense) []float64 {
 }
 
 // mx2VecByCol rolls matrix into a slice by columns
-func mx2VecByCol(m *mat64.Dense) []float64 {
+func mx2VecByCol(m *mat.Dense) []float64 {
 	rows, cols := m.Dims()
 	vec := make([]float64, rows*cols)
 	for i := 0; i < cols; i++ {
@@ -113,7 +230,7 @@ func mx2VecByCol(m *mat64.Dense) []float64 {
 // SetMx2Vec sets all elements of a matrix to values stored in a slice
 // passed in as a parameter. It fails with error if number of elements
 // of the matrix is bigger than number of elements of the slice.
-func SetMx2Vec(mx *mat64.Dense, vec []float64, byRow bool) (err error) {
+func SetMx2Vec(mx *mat.Dense, vec []float64, byRow bool) (err error) {
 	r, c := mx.Dims()
 	if r*c != len(vec) {
 		err = fmt.Errorf("Elements count mismatch: Vec: %d, Matrix: %d\n", len(vec), r*c)
@@ -128,7 +245,7 @@ func SetMx2Vec(mx *mat64.Dense, vec []float64, byRow bool) (err error) {
 }
 
 // setMxByRowVec sets elements of mx from vec by rows
-func setMx2VecByRow(mx *mat64.Dense, vec []float64) {
+func setMx2VecByRow(mx *mat.Dense, vec []float64) {
 	rows, cols := mx.Dims()
 	acc := 0
 	for i := 0; i < rows; i++ {
@@ -138,7 +255,7 @@ func setMx2VecByRow(mx *mat64.Dense, vec []float64) {
 }
 
 // setMxByColVec sets elements of mx from vec by columns
-func setMx2VecByCol(mx *mat64.Dense, vec []float64) {
+func setMx2VecByCol(mx *mat.Dense, vec []float64) {
 	rows, cols := mx.Dims()
 	acc := 0
 	for i := 0; i < cols; i++ {
@@ -149,56 +266,56 @@ func setMx2VecByCol(mx *mat64.Dense, vec []float64) {
 
 // RowsMax returns a slice of max values per each matrix row
 // It returns nil if passed in matrix is nil or has zero elements
-func RowsMax(m *mat64.Dense) []float64 {
+func RowsMax(m *mat.Dense) []float64 {
 	if m == nil {
 		return nil
 	}
 	rows, _ := m.Dims()
 	max := make([]float64, rows)
 	for i := 0; i < rows; i++ {
-		max[i] = mat64.Max(m.RowView(i))
+		max[i] = mat.Max(m.RowView(i))
 	}
 	return max
 }
 
 // ColsMax returns a slice of max values per each matrix column
 // It returns nil if passed in matrix is nil or has zero elements
-func ColsMax(m *mat64.Dense) []float64 {
+func ColsMax(m *mat.Dense) []float64 {
 	if m == nil {
 		return nil
 	}
 	_, cols := m.Dims()
 	max := make([]float64, cols)
 	for i := 0; i < cols; i++ {
-		max[i] = mat64.Max(m.ColView(i))
+		max[i] = mat.Max(m.ColView(i))
 	}
 	return max
 }
 
 // RowSums returns a slice of sums of all elemnts in each matrix row
 // It returns nil if passed in matrix is nil or has zero elements
-func RowSums(m *mat64.Dense) []float64 {
+func RowSums(m *mat.Dense) []float64 {
 	if m == nil {
 		return nil
 	}
 	rows, _ := m.Dims()
 	sum := make([]float64, rows)
 	for i := 0; i < rows; i++ {
-		sum[i] = mat64.Sum(m.RowView(i))
+		sum[i] = mat.Sum(m.RowView(i))
 	}
 	return sum
 }
 
 // ColSums returns a slice of sums of all elemnts in each matrix column
 // It returns nil if passed in matrix is nil or has zero elements
-func ColSums(m *mat64.Dense) []float64 {
+func ColSums(m *mat.Dense) []float64 {
 	if m == nil {
 		return nil
 	}
 	_, cols := m.Dims()
 	sum := make([]float64, cols)
 	for i := 0; i < cols; i++ {
-		sum[i] = mat64.Sum(m.ColView(i))
+		sum[i] = mat.Sum(m.ColView(i))
 	}
 	return sum
 }
@@ -3,8 +3,8 @@ package matrix
 import (
 	"testing"
 
-	"github.com/gonum/matrix/mat64"
 	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
 )
 
 func TestOnes(t *testing.T) {
@@ -15,20 +15,20 @@ func TestOnes(t *testing.T) {
 
 	// all elements must be equal to 1.0
 	onesVec := []float64{1.0, 1.0, 1.0, 1.0}
-	onesMx := mat64.NewDense(2, 2, onesVec)
+	onesMx := mat.NewDense(2, 2, onesVec)
 	mx := Ones(2, 2)
 	assert.NotNil(mx)
-	assert.True(mat64.Equal(onesMx, mx))
+	assert.True(mat.Equal(onesMx, mx))
 }
 
 func TestAddBias(t *testing.T) {
 	assert := assert.New(t)
 
 	// create new matrix and add bias
-	tstMx := mat64.NewDense(2, 2, nil)
+	tstMx := mat.NewDense(2, 2, nil)
 	biasMx := AddBias(tstMx)
 	assert.NotNil(biasMx)
-	assert.False(mat64.Equal(tstMx, biasMx))
+	assert.False(mat.Equal(tstMx, biasMx))
 	r, c := tstMx.Dims()
 	rb, cb := biasMx.Dims()
 	// bias matrix has one more column
@@ -36,8 +36,45 @@ func TestAddBias(t *testing.T) {
 	assert.True(rb == r)
 	// check if the bias matrix first col is all 1.0s
 	biasCol := biasMx.ColView(0)
-	tstVec := mat64.NewDense(2, 1, []float64{1.0, 1.0})
-	assert.True(mat64.Equal(tstVec, biasCol))
+	tstVec := mat.NewDense(2, 1, []float64{1.0, 1.0})
+	assert.True(mat.Equal(tstVec, biasCol))
+}
+
+func TestAddBiasTo(t *testing.T) {
+	assert := assert.New(t)
+
+	tstMx := mat.NewDense(2, 2, []float64{1.0, 2.0, 3.0, 4.0})
+	expMx := mat.NewDense(2, 3, []float64{1.0, 1.0, 2.0, 1.0, 3.0, 4.0})
+	// empty matrix is allocated
+	dst := new(mat.Dense)
+	biasMx := AddBiasTo(dst, tstMx)
+	assert.True(biasMx == dst)
+	assert.True(mat.Equal(expMx, biasMx))
+	// matrix of matching dimensions is reused
+	tstMx.Set(0, 0, 5.0)
+	expMx.Set(0, 1, 5.0)
+	AddBiasTo(dst, tstMx)
+	assert.True(mat.Equal(expMx, dst))
+	// dimensions mismatch panics
+	assert.Panics(func() { AddBiasTo(dst, mat.NewDense(3, 2, nil)) })
+}
+
+func TestAddBiasBuf(t *testing.T) {
+	assert := assert.New(t)
+
+	tstMx := mat.NewDense(2, 2, []float64{1.0, 2.0, 3.0, 4.0})
+	expMx := mat.NewDense(2, 3, []float64{1.0, 1.0, 2.0, 1.0, 3.0, 4.0})
+	// nil buffer is allocated
+	biasMx, buf := AddBiasBuf(nil, tstMx)
+	assert.True(mat.Equal(expMx, biasMx))
+	assert.Len(buf, 6)
+	// smaller input reuses the buffer
+	smallMx, smallBuf := AddBiasBuf(buf, mat.NewDense(1, 1, []float64{2.0}))
+	assert.True(mat.Equal(mat.NewDense(1, 2, []float64{1.0, 2.0}), smallMx))
+	assert.True(&smallBuf[0] == &buf[0])
+	// larger input grows the buffer
+	_, largeBuf := AddBiasBuf(buf, mat.NewDense(3, 2, nil))
+	assert.Len(largeBuf, 9)
 }
 
 func TestMakeLabelsMx(t *testing.T) {
@@ -46,7 +83,7 @@ func TestMakeLabelsMx(t *testing.T) {
 	// create new labels vector
 	labCount := 2
 	labels := []float64{1.0, 2.0, 1.0}
-	labVec := mat64.NewVector(len(labels), labels)
+	labVec := mat.NewVecDense(len(labels), labels)
 	labMx, err := MakeLabelsMx(labVec, labCount)
 	assert.NotNil(labMx)
 	assert.NoError(err)
@@ -56,12 +93,36 @@ func TestMakeLabelsMx(t *testing.T) {
 
 	// this will fail with error
 	labCount = -2
-	labVec = mat64.NewVector(len(labels), nil)
+	labVec = mat.NewVecDense(len(labels), nil)
 	labMx, err = MakeLabelsMx(labVec, labCount)
 	assert.Nil(labMx)
 	assert.Error(err)
 }
 
+func TestMakeLabelsVec(t *testing.T) {
+	assert := assert.New(t)
+
+	// decode probabilities
+	probMx := mat.NewDense(3, 3, []float64{
+		0.1, 0.7, 0.2,
+		0.6, 0.2, 0.2,
+		0.1, 0.2, 0.7,
+	})
+	labVec, err := MakeLabelsVec(probMx)
+	assert.NoError(err)
+	assert.Equal([]float64{2.0, 1.0, 3.0}, labVec.RawVector().Data)
+	// decoding reverts encoding
+	labMx, err := MakeLabelsMx(labVec, 3)
+	assert.NoError(err)
+	decVec, err := MakeLabelsVec(labMx)
+	assert.NoError(err)
+	assert.True(mat.EqualApprox(labVec, decVec, 0))
+	// incorrect matrix
+	labVec, err = MakeLabelsVec(nil)
+	assert.Nil(labVec)
+	assert.Error(err)
+}
+
 func TestMakeRandMx(t *testing.T) {
 	assert := assert.New(t)
 
@@ -76,7 +137,7 @@ func TestMakeRandMx(t *testing.T) {
 	assert.True(c == cols)
 	for i := 0; i < c; i++ {
 		col := randMx.ColView(i)
-		assert.True(max >= mat64.Max(col))
+		assert.True(max >= mat.Max(col))
 	}
 
 	// Can't create new matrix
@@ -85,6 +146,47 @@ func TestMakeRandMx(t *testing.T) {
 	assert.Error(err)
 }
 
+func TestSeed(t *testing.T) {
+	assert := assert.New(t)
+
+	// the same seed generates the same matrices
+	Seed(1)
+	randMx1, err := MakeRandMx(2, 3, 0.0, 1.0)
+	assert.NoError(err)
+	noiseMx1 := new(mat.Dense)
+	noiseMx1.Apply(NoiseMx(1.0), randMx1)
+	Seed(1)
+	randMx2, err := MakeRandMx(2, 3, 0.0, 1.0)
+	assert.NoError(err)
+	noiseMx2 := new(mat.Dense)
+	noiseMx2.Apply(NoiseMx(1.0), randMx2)
+	assert.True(mat.Equal(randMx1, randMx2))
+	assert.True(mat.Equal(noiseMx1, noiseMx2))
+	// subsequent matrices differ
+	randMx3, err := MakeRandMx(2, 3, 0.0, 1.0)
+	assert.NoError(err)
+	assert.False(mat.Equal(randMx2, randMx3))
+}
+
+func TestSeedDraws(t *testing.T) {
+	assert := assert.New(t)
+
+	Seed(1)
+	assert.Equal(int64(0), Draws())
+	_, err := MakeRandMx(2, 3, 0.0, 1.0)
+	assert.NoError(err)
+	draws := Draws()
+	assert.Equal(int64(6), draws)
+	randMx1, err := MakeRandMx(2, 3, 0.0, 1.0)
+	assert.NoError(err)
+	// restored random source continues with the same matrices
+	SeedDraws(1, draws)
+	assert.Equal(draws, Draws())
+	randMx2, err := MakeRandMx(2, 3, 0.0, 1.0)
+	assert.NoError(err)
+	assert.True(mat.Equal(randMx1, randMx2))
+}
+
 func TestMx2Vec(t *testing.T) {
 	assert := assert.New(t)
 
@@ -92,7 +194,7 @@ func TestMx2Vec(t *testing.T) {
 	byRow := []float64{1.2, 3.4, 4.5, 6.7, 8.9, 10.0}
 	byCol := []float64{1.2, 4.5, 8.9, 3.4, 6.7, 10.0}
 	// NewDense creates new matrix by rows
-	tstMx := mat64.NewDense(3, 2, byRow)
+	tstMx := mat.NewDense(3, 2, byRow)
 	// Check if the marix is rolled into slice by row
 	rowVec := Mx2Vec(tstMx, true)
 	assert.NotNil(rowVec)
@@ -109,23 +211,23 @@ func TestSetMx2Vec(t *testing.T) {
 
 	// expected results
 	data := []float64{1.2, 3.4, 4.5, 6.7, 8.9, 10.0}
-	mx := mat64.NewDense(3, 2, nil)
+	mx := mat.NewDense(3, 2, nil)
 	assert.NotNil(mx)
 
 	// Set matrix by row
 	err := SetMx2Vec(mx, data, true)
-	rowMx := mat64.NewDense(3, 2, data)
+	rowMx := mat.NewDense(3, 2, data)
 	assert.NoError(err)
 	assert.NotNil(rowMx)
-	assert.True(mat64.Equal(mx, rowMx))
+	assert.True(mat.Equal(mx, rowMx))
 
 	// Set matrix by col
 	err = SetMx2Vec(mx, data, false)
 	colData := []float64{1.2, 6.7, 3.4, 8.9, 4.5, 10.0}
-	colMx := mat64.NewDense(3, 2, colData)
+	colMx := mat.NewDense(3, 2, colData)
 	assert.NoError(err)
 	assert.NotNil(colMx)
-	assert.True(mat64.Equal(mx, colMx))
+	assert.True(mat.Equal(mx, colMx))
 
 	// Vector is smaller than number of matrix elements
 	shortVec := []float64{1.3, 2.4}
@@ -139,7 +241,7 @@ func TestRowColMax(t *testing.T) {
 	data := []float64{1.2, 3.4, 4.5, 6.7, 8.9, 10.0}
 	rowsMax := []float64{3.4, 6.7, 10.0}
 	colsMax := []float64{8.9, 10.0}
-	mx := mat64.NewDense(3, 2, data)
+	mx := mat.NewDense(3, 2, data)
 	assert.NotNil(mx)
 	// check rows
 	tstRowsMax := RowsMax(mx)
@@ -162,7 +264,7 @@ func TestRowColSums(t *testing.T) {
 	rowSums := []float64{4.6, 11.2, 18.9}
 	colSums := []float64{14.6, 20.1}
 	delta := 0.001
-	mx := mat64.NewDense(3, 2, data)
+	mx := mat.NewDense(3, 2, data)
 	assert.NotNil(t, mx)
 	// check rows
 	tstRowSums := RowSums(mx)
@@ -0,0 +1,50 @@
+package matrix
+
+import (
+	"runtime"
+	"sync"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// ParallelThreshold is the number of matrix elements from which ParallelApply
+// splits the work across goroutines; smaller matrices are not worth the overhead
+var ParallelThreshold = 1 << 14
+
+// ParallelApply applies fn to all elements of matrix a and stores the result in dst the same way
+// as mat.Dense Apply does. Matrices with at least ParallelThreshold elements are split into chunks
+// of rows which are processed by GOMAXPROCS goroutines, so fn must be safe for concurrent use and
+// must not depend on the order in which the elements are processed.
+// dst must be either empty or have the same dimensions as a, otherwise ParallelApply panics.
+func ParallelApply(dst *mat.Dense, fn func(int, int, float64) float64, a mat.Matrix) {
+	rows, cols := a.Dims()
+	workers := runtime.GOMAXPROCS(0)
+	if rows*cols < ParallelThreshold || rows < 2 || workers < 2 {
+		dst.Apply(fn, a)
+		return
+	}
+	if dst.IsEmpty() {
+		dst.ReuseAs(rows, cols)
+	} else if r, c := dst.Dims(); r != rows || c != cols {
+		panic(mat.ErrShape)
+	}
+	chunk := (rows + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < rows; start += chunk {
+		end := start + chunk
+		if end > rows {
+			end = rows
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				row := dst.RawRowView(i)
+				for j := range row {
+					row[j] = fn(i, j, a.At(i, j))
+				}
+			}
+		}(start, end)
+	}
+	wg.Wait()
+}
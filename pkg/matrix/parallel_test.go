@@ -0,0 +1,34 @@
+package matrix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestParallelApply(t *testing.T) {
+	assert := assert.New(t)
+
+	defer func(threshold int) { ParallelThreshold = threshold }(ParallelThreshold)
+	tstMx, err := MakeRandMx(37, 5, -1.0, 1.0)
+	assert.NoError(err)
+	expMx := new(mat.Dense)
+	expMx.Apply(SigmoidMx, tstMx)
+	// small matrices as well as large matrices match Apply
+	for _, threshold := range []int{1 << 14, 1} {
+		ParallelThreshold = threshold
+		outMx := new(mat.Dense)
+		ParallelApply(outMx, SigmoidMx, tstMx)
+		assert.True(mat.Equal(expMx, outMx), "Threshold: %d", threshold)
+		// element indices are passed to fn
+		ParallelApply(outMx, func(i, j int, x float64) float64 { return float64(i*5 + j) }, tstMx)
+		assert.Equal(float64(36*5+4), outMx.At(36, 4))
+		// matrix can be applied in place
+		inPlace := mat.DenseCopyOf(tstMx)
+		ParallelApply(inPlace, SigmoidMx, inPlace)
+		assert.True(mat.Equal(expMx, inPlace), "Threshold: %d", threshold)
+	}
+	// dimensions mismatch panics
+	assert.Panics(func() { ParallelApply(mat.NewDense(2, 2, nil), SigmoidMx, tstMx) })
+}
@@ -0,0 +1,53 @@
+package matrix
+
+import (
+	"fmt"
+	"sync"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Pool is a pool of matrices of the same dimensions backed by sync.Pool. It allows to reuse
+// intermediate matrices, such as backpropagation deltas, across iterations instead of allocating
+// them over and over again, which reduces garbage collection pauses. Pool is safe for concurrent use.
+type Pool struct {
+	rows int
+	cols int
+	pool sync.Pool
+}
+
+// NewPool creates a pool of rows x cols matrices.
+// It fails with error if either of the dimensions is not positive.
+func NewPool(rows, cols int) (*Pool, error) {
+	if rows <= 0 || cols <= 0 {
+		return nil, fmt.Errorf("Incorrect pool dimensions: %d x %d\n", rows, cols)
+	}
+	p := &Pool{rows: rows, cols: cols}
+	p.pool.New = func() interface{} {
+		return mat.NewDense(rows, cols, nil)
+	}
+	return p, nil
+}
+
+// Dims returns the dimensions of pooled matrices
+func (p *Pool) Dims() (int, int) {
+	return p.rows, p.cols
+}
+
+// Get returns a matrix from the pool or allocates a new one if the pool is empty.
+// Matrices returned to the pool are not zeroed, so the matrix can contain arbitrary values.
+func (p *Pool) Get() *mat.Dense {
+	return p.pool.Get().(*mat.Dense)
+}
+
+// Put returns the matrix to the pool, so it can be reused by Get. The matrix must not be used
+// after it's been returned. Matrices with different dimensions than the pool are discarded.
+func (p *Pool) Put(m *mat.Dense) {
+	if m == nil {
+		return
+	}
+	if r, c := m.Dims(); r != p.rows || c != p.cols {
+		return
+	}
+	p.pool.Put(m)
+}
@@ -0,0 +1,37 @@
+package matrix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestPool(t *testing.T) {
+	assert := assert.New(t)
+
+	// dimensions must be positive
+	p, err := NewPool(0, 3)
+	assert.Nil(p)
+	assert.Error(err)
+	p, err = NewPool(2, 3)
+	assert.NotNil(p)
+	assert.NoError(err)
+	r, c := p.Dims()
+	assert.Equal(2, r)
+	assert.Equal(3, c)
+	// empty pool allocates matrices of pool dimensions
+	m := p.Get()
+	r, c = m.Dims()
+	assert.Equal(2, r)
+	assert.Equal(3, c)
+	// matrices of other dimensions are discarded
+	p.Put(mat.NewDense(3, 2, nil))
+	p.Put(nil)
+	p.Put(m)
+	for i := 0; i < 3; i++ {
+		r, c = p.Get().Dims()
+		assert.Equal(2, r)
+		assert.Equal(3, c)
+	}
+}
@@ -0,0 +1,102 @@
+package matrix
+
+import (
+	"gonum.org/v1/gonum/mat"
+)
+
+// CSR is a sparse matrix stored in compressed sparse row format: only non-zero elements
+// are stored along with their column indices, row by row. CSR implements mat.Matrix.
+type CSR struct {
+	rows int
+	cols int
+	// indptr contains the index of the first non-zero element of i-th row at index i
+	// and the number of non-zero elements at the last index
+	indptr []int
+	// indices contains column indices of non-zero elements
+	indices []int
+	// data contains values of non-zero elements
+	data []float64
+}
+
+// NewCSR creates a sparse matrix which contains the non-zero elements of the supplied matrix
+func NewCSR(m mat.Matrix) *CSR {
+	rows, cols := m.Dims()
+	s := &CSR{rows: rows, cols: cols, indptr: make([]int, rows+1)}
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			if v := m.At(i, j); v != 0.0 {
+				s.indices = append(s.indices, j)
+				s.data = append(s.data, v)
+			}
+		}
+		s.indptr[i+1] = len(s.data)
+	}
+	return s
+}
+
+// Dims returns the dimensions of the matrix
+func (s *CSR) Dims() (int, int) {
+	return s.rows, s.cols
+}
+
+// At returns the element in i-th row and j-th column.
+// It panics if either of the indices is out of range.
+func (s *CSR) At(i, j int) float64 {
+	if i < 0 || i >= s.rows {
+		panic(mat.ErrRowAccess)
+	}
+	if j < 0 || j >= s.cols {
+		panic(mat.ErrColAccess)
+	}
+	for k := s.indptr[i]; k < s.indptr[i+1]; k++ {
+		if s.indices[k] == j {
+			return s.data[k]
+		}
+	}
+	return 0.0
+}
+
+// T returns the transpose of the matrix
+func (s *CSR) T() mat.Matrix {
+	return mat.Transpose{Matrix: s}
+}
+
+// NNZ returns the number of non-zero elements
+func (s *CSR) NNZ() int {
+	return len(s.data)
+}
+
+// Density returns the fraction of non-zero elements
+func (s *CSR) Density() float64 {
+	if s.rows*s.cols == 0 {
+		return 0.0
+	}
+	return float64(len(s.data)) / float64(s.rows*s.cols)
+}
+
+// MulT multiplies the dense matrix a by the transpose of the sparse matrix, i.e. it computes a x sᵀ,
+// and returns the dense result. Only the non-zero elements are multiplied, so the multiplication
+// is cheaper than dense multiplication by the density of the sparse matrix.
+// It panics if the number of columns of a does not match the number of columns of the sparse matrix.
+func (s *CSR) MulT(a mat.Matrix) *mat.Dense {
+	rows, cols := a.Dims()
+	if cols != s.cols {
+		panic(mat.ErrShape)
+	}
+	denseA, ok := a.(*mat.Dense)
+	if !ok {
+		denseA = mat.DenseCopyOf(a)
+	}
+	out := mat.NewDense(rows, s.rows, nil)
+	for i := 0; i < rows; i++ {
+		aRow, outRow := denseA.RawRowView(i), out.RawRowView(i)
+		for r := 0; r < s.rows; r++ {
+			var sum float64
+			for k := s.indptr[r]; k < s.indptr[r+1]; k++ {
+				sum += s.data[k] * aRow[s.indices[k]]
+			}
+			outRow[r] = sum
+		}
+	}
+	return out
+}
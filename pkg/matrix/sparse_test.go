@@ -0,0 +1,38 @@
+package matrix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestCSR(t *testing.T) {
+	assert := assert.New(t)
+
+	dense := mat.NewDense(3, 4, []float64{
+		1.0, 0.0, 0.0, 2.0,
+		0.0, 0.0, 0.0, 0.0,
+		0.0, 3.0, 4.0, 0.0,
+	})
+	s := NewCSR(dense)
+	r, c := s.Dims()
+	assert.Equal(3, r)
+	assert.Equal(4, c)
+	assert.Equal(4, s.NNZ())
+	assert.InDelta(4.0/12.0, s.Density(), 1e-12)
+	assert.True(mat.Equal(dense, s))
+	assert.True(mat.Equal(dense.T(), s.T()))
+	assert.Panics(func() { s.At(3, 0) })
+	assert.Panics(func() { s.At(0, -1) })
+	// sparse multiplication matches dense multiplication
+	a, err := MakeRandMx(5, 4, -1.0, 1.0)
+	assert.NoError(err)
+	expMx := new(mat.Dense)
+	expMx.Mul(a, dense.T())
+	assert.True(mat.EqualApprox(expMx, s.MulT(a), 1e-12))
+	assert.True(mat.EqualApprox(expMx, s.MulT(a.T().T()), 1e-12))
+	assert.Panics(func() { s.MulT(mat.NewDense(2, 3, nil)) })
+	// empty matrix has zero density
+	assert.Equal(0.0, NewCSR(mat.NewDense(2, 2, nil)).Density())
+}
@@ -0,0 +1,353 @@
+package onnx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// ONNX protobuf field numbers which are only decoded
+const (
+	tensorFloatData  = 4
+	tensorInt64Data  = 7
+	tensorDoubleData = 10
+
+	wireFixed64 = 1
+)
+
+// field is a decoded protobuf field
+type field struct {
+	// num is field number
+	num int
+	// wire is field wire type
+	wire int
+	// v is the value of varint and fixed size fields
+	v uint64
+	// b is the value of length-delimited fields
+	b []byte
+}
+
+// decoder decodes protobuf messages
+type decoder struct {
+	buf []byte
+}
+
+// next decodes the next field of the message. It returns false when there are no more fields.
+func (d *decoder) next() (*field, bool, error) {
+	if len(d.buf) == 0 {
+		return nil, false, nil
+	}
+	tag, err := d.varint()
+	if err != nil {
+		return nil, false, err
+	}
+	f := &field{num: int(tag >> 3), wire: int(tag & 7)}
+	switch f.wire {
+	case wireVarint:
+		f.v, err = d.varint()
+	case wireFixed64:
+		f.v, err = d.fixed(8)
+	case wireFixed32:
+		f.v, err = d.fixed(4)
+	case wireBytes:
+		var n uint64
+		if n, err = d.varint(); err == nil {
+			if n > uint64(len(d.buf)) {
+				return nil, false, fmt.Errorf("Truncated field %d: %d bytes\n", f.num, n)
+			}
+			f.b, d.buf = d.buf[:n], d.buf[n:]
+		}
+	default:
+		return nil, false, fmt.Errorf("Unsupported wire type of field %d: %d\n", f.num, f.wire)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return f, true, nil
+}
+
+// varint decodes varint
+func (d *decoder) varint() (uint64, error) {
+	var v uint64
+	for i := 0; i < len(d.buf) && i < 10; i++ {
+		v |= uint64(d.buf[i]&0x7f) << (7 * uint(i))
+		if d.buf[i] < 0x80 {
+			d.buf = d.buf[i+1:]
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("Incorrect varint\n")
+}
+
+// fixed decodes little-endian fixed size integer with the supplied number of bytes
+func (d *decoder) fixed(size int) (uint64, error) {
+	if len(d.buf) < size {
+		return 0, fmt.Errorf("Truncated fixed size value\n")
+	}
+	var v uint64
+	if size == 4 {
+		v = uint64(binary.LittleEndian.Uint32(d.buf))
+	} else {
+		v = binary.LittleEndian.Uint64(d.buf)
+	}
+	d.buf = d.buf[size:]
+	return v, nil
+}
+
+// decode decodes all the fields of the message with the supplied function
+func decode(b []byte, fn func(f *field) error) error {
+	d := &decoder{buf: b}
+	for {
+		f, ok, err := d.next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+}
+
+// values returns the values of repeated scalar field which can be either packed or unpacked.
+// size is the size of fixed size values or 0 for varints.
+func (f *field) values(size int) ([]uint64, error) {
+	if f.wire != wireBytes {
+		return []uint64{f.v}, nil
+	}
+	var vals []uint64
+	d := &decoder{buf: f.b}
+	for len(d.buf) > 0 {
+		var v uint64
+		var err error
+		if size == 0 {
+			v, err = d.varint()
+		} else {
+			v, err = d.fixed(size)
+		}
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, v)
+	}
+	return vals, nil
+}
+
+// Unmarshal decodes ONNX model from the supplied ONNX protobuf data. Fields which are not
+// supported by Model are ignored. It fails with error if the data are not valid protobuf data.
+func Unmarshal(data []byte) (*Model, error) {
+	m := new(Model)
+	err := decode(data, func(f *field) error {
+		switch f.num {
+		case modelIRVersion:
+			m.IRVersion = int64(f.v)
+		case modelProducerName:
+			m.ProducerName = string(f.b)
+		case modelProducerVersion:
+			m.ProducerVersion = string(f.b)
+		case modelGraph:
+			m.Graph = new(Graph)
+			return m.Graph.decode(f.b)
+		case modelOpsetImport:
+			var o OpsetID
+			err := decode(f.b, func(f *field) error {
+				switch f.num {
+				case opsetDomain:
+					o.Domain = string(f.b)
+				case opsetVersion:
+					o.Version = int64(f.v)
+				}
+				return nil
+			})
+			m.Opsets = append(m.Opsets, o)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Incorrect ONNX model: %v", err)
+	}
+	return m, nil
+}
+
+// decode decodes the graph
+func (g *Graph) decode(b []byte) error {
+	return decode(b, func(f *field) error {
+		switch f.num {
+		case graphNode:
+			n := new(Node)
+			g.Nodes = append(g.Nodes, n)
+			return n.decode(f.b)
+		case graphName:
+			g.Name = string(f.b)
+		case graphInitializer:
+			t := new(Tensor)
+			g.Initializers = append(g.Initializers, t)
+			return t.decode(f.b)
+		case graphInput:
+			v := new(ValueInfo)
+			g.Inputs = append(g.Inputs, v)
+			return v.decode(f.b)
+		case graphOutput:
+			v := new(ValueInfo)
+			g.Outputs = append(g.Outputs, v)
+			return v.decode(f.b)
+		}
+		return nil
+	})
+}
+
+// decode decodes the node
+func (n *Node) decode(b []byte) error {
+	return decode(b, func(f *field) error {
+		switch f.num {
+		case nodeInput:
+			n.Inputs = append(n.Inputs, string(f.b))
+		case nodeOutput:
+			n.Outputs = append(n.Outputs, string(f.b))
+		case nodeName:
+			n.Name = string(f.b)
+		case nodeOpType:
+			n.OpType = string(f.b)
+		case nodeAttribute:
+			a := new(Attribute)
+			n.Attributes = append(n.Attributes, a)
+			return a.decode(f.b)
+		}
+		return nil
+	})
+}
+
+// decode decodes the attribute
+func (a *Attribute) decode(b []byte) error {
+	return decode(b, func(f *field) error {
+		switch f.num {
+		case attrName:
+			a.Name = string(f.b)
+		case attrF:
+			a.F = math.Float32frombits(uint32(f.v))
+		case attrI:
+			a.I = int64(f.v)
+		case attrInts:
+			vals, err := f.values(0)
+			for _, v := range vals {
+				a.Ints = append(a.Ints, int64(v))
+			}
+			return err
+		case attrType:
+			a.Type = int32(f.v)
+		}
+		return nil
+	})
+}
+
+// decode decodes the tensor
+func (t *Tensor) decode(b []byte) error {
+	var raw []byte
+	err := decode(b, func(f *field) error {
+		switch f.num {
+		case tensorDims:
+			vals, err := f.values(0)
+			for _, v := range vals {
+				t.Dims = append(t.Dims, int64(v))
+			}
+			return err
+		case tensorDataType:
+			t.DataType = int32(f.v)
+		case tensorName:
+			t.Name = string(f.b)
+		case tensorRaw:
+			raw = f.b
+		case tensorFloatData:
+			vals, err := f.values(4)
+			for _, v := range vals {
+				t.Data = append(t.Data, float64(math.Float32frombits(uint32(v))))
+			}
+			return err
+		case tensorInt64Data:
+			vals, err := f.values(0)
+			for _, v := range vals {
+				t.Data = append(t.Data, float64(int64(v)))
+			}
+			return err
+		case tensorDoubleData:
+			vals, err := f.values(8)
+			for _, v := range vals {
+				t.Data = append(t.Data, math.Float64frombits(v))
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil || raw == nil {
+		return err
+	}
+	size := 8
+	if t.DataType == Float {
+		size = 4
+	}
+	switch t.DataType {
+	case Float, Double, Int64:
+	default:
+		return fmt.Errorf("Unsupported data type of tensor %s: %d\n", t.Name, t.DataType)
+	}
+	if len(raw)%size != 0 {
+		return fmt.Errorf("Incorrect raw data of tensor %s: %d bytes\n", t.Name, len(raw))
+	}
+	t.Data = make([]float64, len(raw)/size)
+	for i := range t.Data {
+		switch t.DataType {
+		case Float:
+			t.Data[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(raw[4*i:])))
+		case Double:
+			t.Data[i] = math.Float64frombits(binary.LittleEndian.Uint64(raw[8*i:]))
+		case Int64:
+			t.Data[i] = float64(int64(binary.LittleEndian.Uint64(raw[8*i:])))
+		}
+	}
+	return nil
+}
+
+// decode decodes the value info
+func (v *ValueInfo) decode(b []byte) error {
+	return decode(b, func(f *field) error {
+		switch f.num {
+		case valueName:
+			v.Name = string(f.b)
+		case valueType:
+			return decode(f.b, func(f *field) error {
+				if f.num != typeTensor {
+					return nil
+				}
+				return decode(f.b, func(f *field) error {
+					switch f.num {
+					case tensorElemType:
+						v.ElemType = int32(f.v)
+					case tensorShape:
+						return decode(f.b, func(f *field) error {
+							if f.num != shapeDim {
+								return nil
+							}
+							var dim Dim
+							v.Shape = append(v.Shape, dim)
+							d := &v.Shape[len(v.Shape)-1]
+							return decode(f.b, func(f *field) error {
+								switch f.num {
+								case dimValue:
+									d.Value = int64(f.v)
+								case dimParam:
+									d.Param = string(f.b)
+								}
+								return nil
+							})
+						})
+					}
+					return nil
+				})
+			})
+		}
+		return nil
+	})
+}
@@ -0,0 +1,80 @@
+package onnx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshal(t *testing.T) {
+	assert := assert.New(t)
+
+	m := &Model{
+		IRVersion:    7,
+		Opsets:       []OpsetID{{Version: 13}, {Domain: "foo", Version: 1}},
+		ProducerName: "bar",
+		Graph: &Graph{
+			Name: "g",
+			Nodes: []*Node{{
+				Name:    "n",
+				OpType:  "Gemm",
+				Inputs:  []string{"x", "w"},
+				Outputs: []string{"y"},
+				Attributes: []*Attribute{
+					{Name: "alpha", Type: AttrFloat, F: 0.5},
+					{Name: "transB", Type: AttrInt, I: 1},
+					{Name: "axes", Type: AttrInts, Ints: []int64{-1, 2}},
+				},
+			}},
+			Initializers: []*Tensor{
+				NewFloatTensor("w", []int64{1, 2}, []float64{0.5, -2.0}),
+				NewInt64Tensor("s", []int64{2}, []int64{-1, 3}),
+				{Name: "d", Dims: []int64{1}, DataType: Double, Data: []float64{0.1}},
+			},
+			Inputs:  []*ValueInfo{{Name: "x", ElemType: Float, Shape: []Dim{{Param: "N"}, {Value: 2}}}},
+			Outputs: []*ValueInfo{{Name: "y", ElemType: Float, Shape: []Dim{{Param: "N"}, {Value: 1}}}},
+		},
+	}
+	decoded, err := Unmarshal(m.Marshal())
+	assert.NoError(err)
+	assert.Equal(m, decoded)
+	assert.Equal(m.Graph.Initializers[1], decoded.Graph.Initializer("s"))
+	assert.Nil(decoded.Graph.Initializer("foo"))
+	assert.Equal(int64(1), decoded.Graph.Nodes[0].Attribute("transB").I)
+	assert.Nil(decoded.Graph.Nodes[0].Attribute("foo"))
+	// unknown fields are skipped
+	data := append([]byte{0x30, 0x01, 0x51, 1, 2, 3, 4, 5, 6, 7, 8}, m.Marshal()...)
+	decoded, err = Unmarshal(data)
+	assert.NoError(err)
+	assert.Equal(m, decoded)
+	// truncated data
+	data = m.Marshal()
+	_, err = Unmarshal(data[:len(data)-3])
+	assert.Error(err)
+	_, err = Unmarshal([]byte{0x08, 0x80})
+	assert.Error(err)
+	// unsupported wire type
+	_, err = Unmarshal([]byte{0x0b})
+	assert.Error(err)
+}
+
+func TestTensorDecode(t *testing.T) {
+	assert := assert.New(t)
+
+	// packed float data
+	tensor := new(Tensor)
+	assert.NoError(tensor.decode([]byte{0x08, 0x02, 0x10, 0x01,
+		0x22, 0x08, 0x00, 0x00, 0x80, 0x3f, 0x00, 0x00, 0x00, 0x40}))
+	assert.Equal([]int64{2}, tensor.Dims)
+	assert.Equal([]float64{1.0, 2.0}, tensor.Data)
+	// unpacked int64 data
+	tensor = new(Tensor)
+	assert.NoError(tensor.decode([]byte{0x10, 0x07, 0x38, 0x03, 0x38, 0x04}))
+	assert.Equal([]float64{3.0, 4.0}, tensor.Data)
+	// unsupported raw data type
+	tensor = new(Tensor)
+	assert.Error(tensor.decode([]byte{0x10, 0x02, 0x4a, 0x01, 0x00}))
+	// incorrect raw data length
+	tensor = new(Tensor)
+	assert.Error(tensor.decode([]byte{0x10, 0x01, 0x4a, 0x03, 0x00, 0x00, 0x00}))
+}
@@ -0,0 +1,206 @@
+package onnx
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// ONNX protobuf field numbers
+const (
+	modelIRVersion       = 1
+	modelProducerName    = 2
+	modelProducerVersion = 3
+	modelGraph           = 7
+	modelOpsetImport     = 8
+
+	opsetDomain  = 1
+	opsetVersion = 2
+
+	graphNode        = 1
+	graphName        = 2
+	graphInitializer = 5
+	graphInput       = 11
+	graphOutput      = 12
+
+	nodeInput     = 1
+	nodeOutput    = 2
+	nodeName      = 3
+	nodeOpType    = 4
+	nodeAttribute = 5
+
+	attrName = 1
+	attrF    = 2
+	attrI    = 3
+	attrInts = 8
+	attrType = 20
+
+	tensorDims     = 1
+	tensorDataType = 2
+	tensorName     = 8
+	tensorRaw      = 9
+
+	valueName = 1
+	valueType = 2
+
+	typeTensor     = 1
+	tensorElemType = 1
+	tensorShape    = 2
+	shapeDim       = 1
+	dimValue       = 1
+	dimParam       = 2
+
+	wireVarint  = 0
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// encoder encodes protobuf messages
+type encoder struct {
+	buf []byte
+}
+
+// varint appends varint field
+func (e *encoder) varint(field int, v uint64) {
+	e.tag(field, wireVarint)
+	e.buf = appendVarint(e.buf, v)
+}
+
+// bytes appends length-delimited field
+func (e *encoder) bytes(field int, b []byte) {
+	e.tag(field, wireBytes)
+	e.buf = appendVarint(e.buf, uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// string appends string field if it's not empty
+func (e *encoder) string(field int, s string) {
+	if s != "" {
+		e.bytes(field, []byte(s))
+	}
+}
+
+// float appends float32 field
+func (e *encoder) float(field int, f float32) {
+	e.tag(field, wireFixed32)
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, math.Float32bits(f))
+}
+
+// message appends embedded message field encoded by the supplied function
+func (e *encoder) message(field int, encode func(*encoder)) {
+	m := new(encoder)
+	encode(m)
+	e.bytes(field, m.buf)
+}
+
+// tag appends field tag
+func (e *encoder) tag(field, wire int) {
+	e.buf = appendVarint(e.buf, uint64(field<<3|wire))
+}
+
+// appendVarint appends varint encoded v to b
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+// Marshal encodes the model in ONNX protobuf format
+func (m *Model) Marshal() []byte {
+	e := new(encoder)
+	e.varint(modelIRVersion, uint64(m.IRVersion))
+	e.string(modelProducerName, m.ProducerName)
+	e.string(modelProducerVersion, m.ProducerVersion)
+	if m.Graph != nil {
+		e.message(modelGraph, m.Graph.encode)
+	}
+	for _, o := range m.Opsets {
+		o := o
+		e.message(modelOpsetImport, func(e *encoder) {
+			e.string(opsetDomain, o.Domain)
+			e.varint(opsetVersion, uint64(o.Version))
+		})
+	}
+	return e.buf
+}
+
+// encode encodes the graph
+func (g *Graph) encode(e *encoder) {
+	for _, n := range g.Nodes {
+		e.message(graphNode, n.encode)
+	}
+	e.string(graphName, g.Name)
+	for _, t := range g.Initializers {
+		e.message(graphInitializer, t.encode)
+	}
+	for _, v := range g.Inputs {
+		e.message(graphInput, v.encode)
+	}
+	for _, v := range g.Outputs {
+		e.message(graphOutput, v.encode)
+	}
+}
+
+// encode encodes the node
+func (n *Node) encode(e *encoder) {
+	for _, in := range n.Inputs {
+		e.bytes(nodeInput, []byte(in))
+	}
+	for _, out := range n.Outputs {
+		e.bytes(nodeOutput, []byte(out))
+	}
+	e.string(nodeName, n.Name)
+	e.string(nodeOpType, n.OpType)
+	for _, a := range n.Attributes {
+		e.message(nodeAttribute, a.encode)
+	}
+}
+
+// encode encodes the attribute
+func (a *Attribute) encode(e *encoder) {
+	e.string(attrName, a.Name)
+	switch a.Type {
+	case AttrFloat:
+		e.float(attrF, a.F)
+	case AttrInt:
+		e.varint(attrI, uint64(a.I))
+	case AttrInts:
+		for _, v := range a.Ints {
+			e.varint(attrInts, uint64(v))
+		}
+	}
+	e.varint(attrType, uint64(a.Type))
+}
+
+// encode encodes the tensor
+func (t *Tensor) encode(e *encoder) {
+	for _, d := range t.Dims {
+		e.varint(tensorDims, uint64(d))
+	}
+	e.varint(tensorDataType, uint64(t.DataType))
+	e.string(tensorName, t.Name)
+	e.bytes(tensorRaw, t.rawData())
+}
+
+// encode encodes the value info
+func (v *ValueInfo) encode(e *encoder) {
+	e.string(valueName, v.Name)
+	e.message(valueType, func(e *encoder) {
+		e.message(typeTensor, func(e *encoder) {
+			e.varint(tensorElemType, uint64(v.ElemType))
+			e.message(tensorShape, func(e *encoder) {
+				for _, d := range v.Shape {
+					d := d
+					e.message(shapeDim, func(e *encoder) {
+						if d.Param != "" {
+							e.string(dimParam, d.Param)
+							return
+						}
+						e.varint(dimValue, uint64(d.Value))
+					})
+				}
+			})
+		})
+	})
+}
@@ -0,0 +1,63 @@
+package onnx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendVarint(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]byte{0x00}, appendVarint(nil, 0))
+	assert.Equal([]byte{0x7f}, appendVarint(nil, 127))
+	assert.Equal([]byte{0xac, 0x02}, appendVarint(nil, 300))
+	// max integer takes ten bytes
+	assert.Len(appendVarint(nil, 1<<64-1), 10)
+}
+
+func TestModelMarshal(t *testing.T) {
+	assert := assert.New(t)
+
+	m := &Model{IRVersion: 7, Opsets: []OpsetID{{Version: 13}}}
+	assert.Equal([]byte{0x08, 0x07, 0x42, 0x02, 0x10, 0x0d}, m.Marshal())
+	// graph is encoded before opsets
+	m.Graph = &Graph{Name: "g"}
+	assert.Equal([]byte{0x08, 0x07, 0x3a, 0x03, 0x12, 0x01, 'g', 0x42, 0x02, 0x10, 0x0d}, m.Marshal())
+}
+
+func TestTensorEncode(t *testing.T) {
+	assert := assert.New(t)
+
+	e := new(encoder)
+	NewFloatTensor("w", []int64{2}, []float64{1.0, 0.0}).encode(e)
+	assert.Equal([]byte{0x08, 0x02, 0x10, 0x01, 0x42, 0x01, 'w',
+		0x4a, 0x08, 0x00, 0x00, 0x80, 0x3f, 0x00, 0x00, 0x00, 0x00}, e.buf)
+	e = new(encoder)
+	NewInt64Tensor("s", []int64{1}, []int64{-1}).encode(e)
+	assert.Equal([]byte{0x08, 0x01, 0x10, 0x07, 0x42, 0x01, 's',
+		0x4a, 0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, e.buf)
+}
+
+func TestAttributeEncode(t *testing.T) {
+	assert := assert.New(t)
+
+	e := new(encoder)
+	(&Attribute{Name: "a", Type: AttrInt, I: 1}).encode(e)
+	assert.Equal([]byte{0x0a, 0x01, 'a', 0x18, 0x01, 0xa0, 0x01, 0x02}, e.buf)
+	e = new(encoder)
+	(&Attribute{Name: "a", Type: AttrFloat, F: 1.0}).encode(e)
+	assert.Equal([]byte{0x0a, 0x01, 'a', 0x15, 0x00, 0x00, 0x80, 0x3f, 0xa0, 0x01, 0x01}, e.buf)
+	e = new(encoder)
+	(&Attribute{Name: "a", Type: AttrInts, Ints: []int64{1, 2}}).encode(e)
+	assert.Equal([]byte{0x0a, 0x01, 'a', 0x40, 0x01, 0x40, 0x02, 0xa0, 0x01, 0x07}, e.buf)
+}
+
+func TestValueInfoEncode(t *testing.T) {
+	assert := assert.New(t)
+
+	e := new(encoder)
+	(&ValueInfo{Name: "x", ElemType: Float, Shape: []Dim{{Param: "N"}, {Value: 3}}}).encode(e)
+	assert.Equal([]byte{0x0a, 0x01, 'x', 0x12, 0x0f, 0x0a, 0x0d, 0x08, 0x01, 0x12, 0x09,
+		0x0a, 0x03, 0x12, 0x01, 'N', 0x0a, 0x02, 0x08, 0x03}, e.buf)
+}
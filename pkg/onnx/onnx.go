@@ -0,0 +1,186 @@
+package onnx
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+const (
+	// Float is ONNX float32 tensor element type
+	Float int32 = 1
+	// Int64 is ONNX int64 tensor element type
+	Int64 int32 = 7
+	// Double is ONNX float64 tensor element type
+	Double int32 = 11
+)
+
+const (
+	// AttrFloat is ONNX float attribute type
+	AttrFloat int32 = 1
+	// AttrInt is ONNX integer attribute type
+	AttrInt int32 = 2
+	// AttrInts is ONNX integer list attribute type
+	AttrInts int32 = 7
+)
+
+// Model is ONNX model. Only the parts of ONNX format needed to describe simple
+// feed-forward networks are supported.
+type Model struct {
+	// IRVersion is ONNX IR version
+	IRVersion int64
+	// Opsets contains imported operator sets
+	Opsets []OpsetID
+	// ProducerName is the name of the tool which produced the model
+	ProducerName string
+	// ProducerVersion is the version of the tool which produced the model
+	ProducerVersion string
+	// Graph is model computation graph
+	Graph *Graph
+}
+
+// OpsetID identifies operator set
+type OpsetID struct {
+	// Domain is operator set domain: empty for the default ONNX domain
+	Domain string
+	// Version is operator set version
+	Version int64
+}
+
+// Graph is ONNX computation graph
+type Graph struct {
+	// Name is graph name
+	Name string
+	// Nodes contains graph nodes in topological order
+	Nodes []*Node
+	// Initializers contains constant tensors such as weights
+	Initializers []*Tensor
+	// Inputs contains graph inputs
+	Inputs []*ValueInfo
+	// Outputs contains graph outputs
+	Outputs []*ValueInfo
+}
+
+// Node is ONNX graph node which applies an operator to its inputs
+type Node struct {
+	// Name is node name
+	Name string
+	// OpType is operator type such as Gemm or Relu
+	OpType string
+	// Inputs contains names of node inputs
+	Inputs []string
+	// Outputs contains names of node outputs
+	Outputs []string
+	// Attributes contains operator attributes
+	Attributes []*Attribute
+}
+
+// Attribute is ONNX node attribute
+type Attribute struct {
+	// Name is attribute name
+	Name string
+	// Type is attribute type: AttrFloat, AttrInt or AttrInts
+	Type int32
+	// F is float value
+	F float32
+	// I is integer value
+	I int64
+	// Ints is integer list value
+	Ints []int64
+}
+
+// Tensor is ONNX tensor
+type Tensor struct {
+	// Name is tensor name
+	Name string
+	// Dims contains tensor dimensions
+	Dims []int64
+	// DataType is tensor element type: Float, Int64 or Double
+	DataType int32
+	// Data contains tensor elements in row-major order
+	Data []float64
+}
+
+// ValueInfo describes a tensor flowing through the graph
+type ValueInfo struct {
+	// Name is tensor name
+	Name string
+	// ElemType is tensor element type
+	ElemType int32
+	// Shape contains tensor dimensions
+	Shape []Dim
+}
+
+// Dim is ONNX tensor dimension which is either fixed or symbolic
+type Dim struct {
+	// Value is fixed dimension size
+	Value int64
+	// Param is symbolic dimension name, such as batch size: empty for fixed dimensions
+	Param string
+}
+
+// NewFloatTensor creates new float32 tensor with the supplied name, dimensions and data and returns it
+func NewFloatTensor(name string, dims []int64, data []float64) *Tensor {
+	return &Tensor{
+		Name:     name,
+		Dims:     dims,
+		DataType: Float,
+		Data:     data,
+	}
+}
+
+// NewInt64Tensor creates new int64 tensor with the supplied name, dimensions and data and returns it
+func NewInt64Tensor(name string, dims []int64, data []int64) *Tensor {
+	t := &Tensor{
+		Name:     name,
+		Dims:     dims,
+		DataType: Int64,
+		Data:     make([]float64, len(data)),
+	}
+	for i, v := range data {
+		t.Data[i] = float64(v)
+	}
+	return t
+}
+
+// rawData returns little-endian encoded tensor elements
+func (t Tensor) rawData() []byte {
+	switch t.DataType {
+	case Float:
+		raw := make([]byte, 4*len(t.Data))
+		for i, v := range t.Data {
+			binary.LittleEndian.PutUint32(raw[4*i:], math.Float32bits(float32(v)))
+		}
+		return raw
+	case Int64:
+		raw := make([]byte, 8*len(t.Data))
+		for i, v := range t.Data {
+			binary.LittleEndian.PutUint64(raw[8*i:], uint64(int64(v)))
+		}
+		return raw
+	}
+	raw := make([]byte, 8*len(t.Data))
+	for i, v := range t.Data {
+		binary.LittleEndian.PutUint64(raw[8*i:], math.Float64bits(v))
+	}
+	return raw
+}
+
+// Initializer returns the graph initializer with the supplied name or nil if it does not exist
+func (g *Graph) Initializer(name string) *Tensor {
+	for _, t := range g.Initializers {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Attribute returns the node attribute with the supplied name or nil if it does not exist
+func (n *Node) Attribute(name string) *Attribute {
+	for _, a := range n.Attributes {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}